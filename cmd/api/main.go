@@ -56,6 +56,12 @@ import (
 // @tag.description Unit management endpoints
 // @tag.name Applications
 // @tag.description Application management endpoints
+// @tag.name OAuth
+// @tag.description OAuth2 / OpenID Connect provider - authorization code with PKCE, tokens, userinfo, client registration
+// @tag.name Invitations
+// @tag.description User invitation and onboarding into companies
+// @tag.name Security Alerts
+// @tag.description Security anomaly alerts raised over audit and login events
 // @tag.name Audit
 // @tag.description Audit log endpoints
 // @tag.name System
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gin-scalable-api/config"
+	"gin-scalable-api/pkg/audittrail"
+	"gin-scalable-api/pkg/database"
+	"log"
+	"os"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.Load()
+
+	var (
+		company = flag.Int64("company", -1, "Company ID to verify (0 for entries without company), all chains when omitted")
+		asJSON  = flag.Bool("json", false, "Print the reports as JSON")
+		anchors = flag.String("anchors", cfg.Audit.AnchorFile, "File of exported chain heads to verify against, empty to skip")
+	)
+	flag.Parse()
+
+	// Connect to database
+	dbConfig := database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.Name,
+		SSLMode:  cfg.Database.SSLMode,
+	}
+
+	db, err := database.NewConnection(dbConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	store := audittrail.NewChainStore(db.DB, *anchors)
+
+	var reports []*audittrail.ChainReport
+	if *company >= 0 {
+		report, err := store.Verify(*company)
+		if err != nil {
+			log.Fatalf("Verification failed: %v", err)
+		}
+		reports = append(reports, report)
+	} else {
+		reports, err = store.VerifyAll()
+		if err != nil {
+			log.Fatalf("Verification failed: %v", err)
+		}
+	}
+
+	valid := true
+	for _, report := range reports {
+		valid = valid && report.Valid
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			log.Fatalf("Failed to encode reports: %v", err)
+		}
+	} else {
+		for _, report := range reports {
+			if report.Valid {
+				fmt.Printf("chain %d: OK, %d records, head seq %d, head hash %s\n",
+					report.CompanyID, report.Records, report.HeadSeq, report.HeadHash)
+			} else {
+				fmt.Printf("chain %d: BROKEN at seq %d (audit log id %d): %s\n",
+					report.CompanyID, report.Break.Seq, report.Break.ID, report.Break.Reason)
+			}
+		}
+		if len(reports) == 0 {
+			fmt.Println("No hash-chained audit entries found")
+		}
+	}
+
+	if !valid {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gin-scalable-api/pkg/jwt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Generates a new JWT signing key for rotation. New keys are added next to the old
+// ones; after restarting with JWT_ACTIVE_KEY_ID set to the new key, the old keys keep
+// verifying tokens until they expire and can then be removed from the directory.
+func main() {
+	var (
+		dir       = flag.String("dir", "keys", "Directory holding <kid>.pem signing keys")
+		algorithm = flag.String("alg", jwt.RS256, "Signing algorithm: RS256, ES256")
+		keyID     = flag.String("kid", "", "Key ID (default: current date and time)")
+	)
+	flag.Parse()
+
+	if *keyID == "" {
+		*keyID = time.Now().UTC().Format("20060102-150405")
+	}
+
+	signer, err := jwt.GenerateKey(*algorithm)
+	if err != nil {
+		log.Fatalf("Failed to generate key: %v", err)
+	}
+
+	data, err := jwt.EncodePrivateKeyPEM(signer)
+	if err != nil {
+		log.Fatalf("Failed to encode key: %v", err)
+	}
+
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		log.Fatalf("Failed to create key directory: %v", err)
+	}
+
+	path := filepath.Join(*dir, *keyID+".pem")
+	if _, err := os.Stat(path); err == nil {
+		log.Fatalf("Key %s already exists", path)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		log.Fatalf("Failed to write key: %v", err)
+	}
+
+	fmt.Printf("Generated %s key %s\n", *algorithm, path)
+	fmt.Printf("Activate it with JWT_ACTIVE_KEY_ID=%s\n", *keyID)
+}
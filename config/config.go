@@ -8,11 +8,19 @@ import (
 )
 
 type Config struct {
-	Port     string
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	CORS     CORSConfig
+	Port       string
+	Database   DatabaseConfig
+	Redis      RedisConfig
+	JWT        JWTConfig
+	CORS       CORSConfig
+	Lockout    LockoutConfig
+	Password   PasswordConfig
+	Mail       MailConfig
+	Audit      AuditConfig
+	Anomaly    AnomalyConfig
+	Assignment AssignmentConfig
+	Elevation  ElevationConfig
+	SoD        SoDConfig
 }
 
 type DatabaseConfig struct {
@@ -34,6 +42,101 @@ type RedisConfig struct {
 
 type JWTConfig struct {
 	Secret string
+	// TokenMode is "opaque" (random tokens looked up in Redis) or "jwt" (signed access tokens)
+	TokenMode string
+	// Issuer is the public base URL of this service, also used as OpenID Connect issuer
+	Issuer      string
+	Algorithm   string
+	KeysDir     string
+	ActiveKeyID string
+}
+
+// LockoutConfig controls brute-force protection of the login endpoints
+type LockoutConfig struct {
+	MaxAttempts    int
+	IPMaxAttempts  int
+	WindowMinutes  int
+	LockoutMinutes int
+}
+
+// PasswordConfig is the default password policy; companies can override it
+type PasswordConfig struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	HistoryCount     int
+	MaxAgeDays       int
+	BlocklistFile    string
+}
+
+// MailConfig selects how transactional emails such as password resets are delivered
+type MailConfig struct {
+	// Driver is "smtp", "file" or "log"
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	FileDir      string
+	// PasswordResetURL is the frontend page that receives the reset token as ?token=
+	PasswordResetURL string
+	// InvitationAcceptURL is the frontend page that receives the invitation token as ?token=
+	InvitationAcceptURL string
+}
+
+// AuditConfig sizes the queue of the automatic audit trail of mutating requests and
+// configures the archiving of old entries
+type AuditConfig struct {
+	QueueSize       int
+	BatchSize       int
+	FlushIntervalMs int
+	// RetentionDays applies to companies without a retention policy, 0 keeps entries forever
+	RetentionDays            int
+	ArchiveDir               string
+	ArchiveBatchSize         int
+	RetentionIntervalMinutes int
+	// AnchorFile receives the exported chain heads; empty disables the export
+	AnchorFile            string
+	AnchorIntervalMinutes int
+}
+
+// AnomalyConfig holds the thresholds of the security anomaly detector
+type AnomalyConfig struct {
+	Enabled                  bool
+	IntervalSeconds          int
+	FailedLoginThreshold     int
+	FailedLoginWindowMinutes int
+	MassDeleteThreshold      int
+	MassDeleteWindowMinutes  int
+	// Business hours for the off-hours rule; empty BusinessDays disables the rule
+	BusinessHoursStart int
+	BusinessHoursEnd   int
+	BusinessDays       string
+	Timezone           string
+}
+
+// AssignmentConfig configures the sweeper of time-bound role assignments
+type AssignmentConfig struct {
+	SweepIntervalSeconds int
+}
+
+// ElevationConfig configures just-in-time role elevation requests
+type ElevationConfig struct {
+	// Holders of can_approve on this module approve the requests of their company
+	ApproverModuleURL string
+	// Holders of this role approve the requests of their company as well; empty disables
+	ApproverRole       string
+	MaxDurationMinutes int
+}
+
+// SoDConfig configures the enforcement of separation-of-duties rules
+type SoDConfig struct {
+	// "enforce" rejects changes that introduce a violation; "override" allows them when
+	// the change carries a justification, which is recorded
+	Mode string
 }
 
 type CORSConfig struct {
@@ -63,12 +166,80 @@ func Load() *Config {
 			UseTLS:   getEnvAsBool("REDIS_USE_TLS", false),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
+			Secret:      getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
+			TokenMode:   getEnv("TOKEN_MODE", "opaque"),
+			Issuer:      getEnv("JWT_ISSUER", "http://localhost:8081"),
+			Algorithm:   getEnv("JWT_ALGORITHM", "RS256"),
+			KeysDir:     getEnv("JWT_KEYS_DIR", ""),
+			ActiveKeyID: getEnv("JWT_ACTIVE_KEY_ID", ""),
 		},
 		CORS: CORSConfig{
 			Origins:     getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:3001,http://127.0.0.1:3000"),
 			Environment: getEnv("ENVIRONMENT", "development"),
 		},
+		Lockout: LockoutConfig{
+			MaxAttempts:    getEnvAsInt("LOGIN_MAX_ATTEMPTS", 5),
+			IPMaxAttempts:  getEnvAsInt("LOGIN_IP_MAX_ATTEMPTS", 20),
+			WindowMinutes:  getEnvAsInt("LOGIN_ATTEMPT_WINDOW_MINUTES", 15),
+			LockoutMinutes: getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
+		},
+		Password: PasswordConfig{
+			MinLength:        getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			RequireUppercase: getEnvAsBool("PASSWORD_REQUIRE_UPPERCASE", true),
+			RequireLowercase: getEnvAsBool("PASSWORD_REQUIRE_LOWERCASE", true),
+			RequireDigit:     getEnvAsBool("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol:    getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+			HistoryCount:     getEnvAsInt("PASSWORD_HISTORY_COUNT", 5),
+			MaxAgeDays:       getEnvAsInt("PASSWORD_MAX_AGE_DAYS", 0),
+			BlocklistFile:    getEnv("PASSWORD_BLOCKLIST_FILE", "config/common-passwords.txt"),
+		},
+		Mail: MailConfig{
+			Driver:              getEnv("MAIL_DRIVER", "log"),
+			From:                getEnv("MAIL_FROM", "RBAC Service <no-reply@localhost>"),
+			SMTPHost:            getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:            getEnvAsInt("SMTP_PORT", 587),
+			SMTPUsername:        getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:        getEnv("SMTP_PASSWORD", ""),
+			FileDir:             getEnv("MAIL_FILE_DIR", "tmp/mail"),
+			PasswordResetURL:    getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+			InvitationAcceptURL: getEnv("INVITATION_ACCEPT_URL", "http://localhost:3000/accept-invitation"),
+		},
+		Audit: AuditConfig{
+			QueueSize:       getEnvAsInt("AUDIT_QUEUE_SIZE", 1024),
+			BatchSize:       getEnvAsInt("AUDIT_BATCH_SIZE", 100),
+			FlushIntervalMs: getEnvAsInt("AUDIT_FLUSH_INTERVAL_MS", 1000),
+
+			RetentionDays:            getEnvAsInt("AUDIT_RETENTION_DAYS", 0),
+			ArchiveDir:               getEnv("AUDIT_ARCHIVE_DIR", "archives/audit"),
+			ArchiveBatchSize:         getEnvAsInt("AUDIT_ARCHIVE_BATCH_SIZE", 5000),
+			RetentionIntervalMinutes: getEnvAsInt("AUDIT_RETENTION_INTERVAL_MINUTES", 60),
+
+			AnchorFile:            getEnv("AUDIT_ANCHOR_FILE", "archives/audit-anchors.ndjson"),
+			AnchorIntervalMinutes: getEnvAsInt("AUDIT_ANCHOR_INTERVAL_MINUTES", 5),
+		},
+		Anomaly: AnomalyConfig{
+			Enabled:                  getEnvAsBool("ANOMALY_DETECTION_ENABLED", true),
+			IntervalSeconds:          getEnvAsInt("ANOMALY_INTERVAL_SECONDS", 30),
+			FailedLoginThreshold:     getEnvAsInt("ANOMALY_FAILED_LOGIN_THRESHOLD", 10),
+			FailedLoginWindowMinutes: getEnvAsInt("ANOMALY_FAILED_LOGIN_WINDOW_MINUTES", 10),
+			MassDeleteThreshold:      getEnvAsInt("ANOMALY_MASS_DELETE_THRESHOLD", 20),
+			MassDeleteWindowMinutes:  getEnvAsInt("ANOMALY_MASS_DELETE_WINDOW_MINUTES", 5),
+			BusinessHoursStart:       getEnvAsInt("ANOMALY_BUSINESS_HOURS_START", 7),
+			BusinessHoursEnd:         getEnvAsInt("ANOMALY_BUSINESS_HOURS_END", 19),
+			BusinessDays:             getEnv("ANOMALY_BUSINESS_DAYS", "mon,tue,wed,thu,fri"),
+			Timezone:                 getEnv("ANOMALY_TIMEZONE", "Local"),
+		},
+		Assignment: AssignmentConfig{
+			SweepIntervalSeconds: getEnvAsInt("ASSIGNMENT_SWEEP_INTERVAL_SECONDS", 60),
+		},
+		Elevation: ElevationConfig{
+			ApproverModuleURL:  getEnv("ELEVATION_APPROVER_MODULE_URL", "/roles"),
+			ApproverRole:       getEnv("ELEVATION_APPROVER_ROLE", "COMPANY_ADMIN"),
+			MaxDurationMinutes: getEnvAsInt("ELEVATION_MAX_DURATION_MINUTES", 8*60),
+		},
+		SoD: SoDConfig{
+			Mode: getEnv("SOD_MODE", "enforce"),
+		},
 	}
 }
 
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"time"
+
+	roleModule "gin-scalable-api/internal/modules/role"
+)
+
+// roleAssigner lets the invitation and elevation modules assign roles through the role
+// module without importing it. Invitation assignments are audited as made by the system.
+type roleAssigner struct {
+	roleService *roleModule.Service
+}
+
+func (a *roleAssigner) AssignRole(userID, roleID, companyID int64, branchID, unitID *int64) error {
+	_, err := a.roleService.AssignRoleToUser(0, &roleModule.AssignRoleRequest{
+		UserID:    userID,
+		RoleID:    roleID,
+		CompanyID: companyID,
+		BranchID:  branchID,
+		UnitID:    unitID,
+	})
+	return err
+}
+
+// AssignRoleUntil lets the elevation module grant an approved role until the end of the
+// elevation. The assignment is audited as made by the approver.
+func (a *roleAssigner) AssignRoleUntil(actorID, userID, roleID, companyID int64, branchID, unitID *int64, validUntil time.Time) (int64, error) {
+	assignment, err := a.roleService.AssignRoleToUser(actorID, &roleModule.AssignRoleRequest{
+		UserID:     userID,
+		RoleID:     roleID,
+		CompanyID:  companyID,
+		BranchID:   branchID,
+		UnitID:     unitID,
+		ValidUntil: &validUntil,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return assignment.ID, nil
+}
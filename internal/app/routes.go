@@ -3,15 +3,22 @@ package app
 import (
 	"database/sql"
 	"gin-scalable-api/middleware"
+	"gin-scalable-api/pkg/audittrail"
 
 	// Module imports
+	accessReviewModule "gin-scalable-api/internal/modules/accessreview"
+	alertModule "gin-scalable-api/internal/modules/alert"
 	applicationModule "gin-scalable-api/internal/modules/application"
 	auditModule "gin-scalable-api/internal/modules/audit"
 	authModule "gin-scalable-api/internal/modules/auth"
 	branchModule "gin-scalable-api/internal/modules/branch"
 	companyModule "gin-scalable-api/internal/modules/company"
+	elevationModule "gin-scalable-api/internal/modules/elevation"
+	invitationModule "gin-scalable-api/internal/modules/invitation"
 	moduleModule "gin-scalable-api/internal/modules/module"
+	oauthModule "gin-scalable-api/internal/modules/oauth"
 	roleModule "gin-scalable-api/internal/modules/role"
+	sodModule "gin-scalable-api/internal/modules/sod"
 	subscriptionModule "gin-scalable-api/internal/modules/subscription"
 	unitModule "gin-scalable-api/internal/modules/unit"
 	userModule "gin-scalable-api/internal/modules/user"
@@ -21,7 +28,7 @@ import (
 )
 
 // SetupNewModuleRoutes sets up routes using module-based structure
-func SetupNewModuleRoutes(r *gin.Engine, h *NewModuleHandlers, jwtSecret string, redis *redis.Client, db *sql.DB) {
+func SetupNewModuleRoutes(r *gin.Engine, h *NewModuleHandlers, jwtSecret string, redis *redis.Client, db *sql.DB, auditQueue *audittrail.Queue) {
 	// Global middleware
 	r.Use(middleware.SmartRateLimit())
 
@@ -30,6 +37,10 @@ func SetupNewModuleRoutes(r *gin.Engine, h *NewModuleHandlers, jwtSecret string,
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Well-known discovery documents (JWKS, OpenID Connect)
+	authModule.RegisterWellKnownRoutes(r, h.Auth)
+	oauthModule.RegisterWellKnownRoutes(r, h.OAuth)
+
 	// API routes
 	api := r.Group("/api/v1")
 
@@ -39,9 +50,16 @@ func SetupNewModuleRoutes(r *gin.Engine, h *NewModuleHandlers, jwtSecret string,
 	// Subscription plans (public)
 	subscriptionModule.RegisterRoutes(api, h.Subscription)
 
+	// OAuth token endpoint (public, clients authenticate themselves)
+	oauthModule.RegisterRoutes(api, h.OAuth)
+
+	// Invitation acceptance (public, the link token authenticates the invitee)
+	invitationModule.RegisterRoutes(api, h.Invitation)
+
 	// Protected routes
 	protected := api.Group("")
 	protected.Use(middleware.AuthMiddleware(jwtSecret, redis))
+	protected.Use(middleware.AuditTrail(auditQueue))
 	{
 		// Register all module routes
 		userModule.RegisterRoutes(protected, h.User)
@@ -55,5 +73,26 @@ func SetupNewModuleRoutes(r *gin.Engine, h *NewModuleHandlers, jwtSecret string,
 
 		// Subscription admin routes (protected)
 		subscriptionModule.RegisterProtectedRoutes(protected, h.Subscription)
+
+		// Session management routes (protected)
+		authModule.RegisterProtectedRoutes(protected, h.Auth)
+
+		// OAuth authorization, userinfo and client registration routes (protected)
+		oauthModule.RegisterProtectedRoutes(protected, h.OAuth)
+
+		// Invitation management routes (protected)
+		invitationModule.RegisterProtectedRoutes(protected, h.Invitation)
+
+		// Security alerts raised by the anomaly detector (protected)
+		alertModule.RegisterRoutes(protected, h.Alert)
+
+		// Just-in-time role elevation requests (protected)
+		elevationModule.RegisterRoutes(protected, h.Elevation)
+
+		// Access review (recertification) campaigns (protected)
+		accessReviewModule.RegisterRoutes(protected, h.AccessReview)
+
+		// Separation-of-duties rules and violation report (protected)
+		sodModule.RegisterRoutes(protected, h.SoD)
 	}
 }
@@ -1,22 +1,41 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"gin-scalable-api/config"
 	"gin-scalable-api/middleware"
+	"gin-scalable-api/pkg/anomaly"
+	"gin-scalable-api/pkg/audittrail"
 	"gin-scalable-api/pkg/database"
+	"gin-scalable-api/pkg/jwt"
+	"gin-scalable-api/pkg/lockout"
+	"gin-scalable-api/pkg/mailer"
+	"gin-scalable-api/pkg/password"
 	"gin-scalable-api/pkg/rbac"
 	"gin-scalable-api/pkg/token"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	// Module imports
+	accessReviewModule "gin-scalable-api/internal/modules/accessreview"
+	alertModule "gin-scalable-api/internal/modules/alert"
 	applicationModule "gin-scalable-api/internal/modules/application"
 	auditModule "gin-scalable-api/internal/modules/audit"
 	authModule "gin-scalable-api/internal/modules/auth"
 	branchModule "gin-scalable-api/internal/modules/branch"
 	companyModule "gin-scalable-api/internal/modules/company"
+	elevationModule "gin-scalable-api/internal/modules/elevation"
+	invitationModule "gin-scalable-api/internal/modules/invitation"
 	moduleModule "gin-scalable-api/internal/modules/module"
+	oauthModule "gin-scalable-api/internal/modules/oauth"
 	roleModule "gin-scalable-api/internal/modules/role"
+	sodModule "gin-scalable-api/internal/modules/sod"
 	subscriptionModule "gin-scalable-api/internal/modules/subscription"
 	unitModule "gin-scalable-api/internal/modules/unit"
 	userModule "gin-scalable-api/internal/modules/user"
@@ -29,8 +48,13 @@ import (
 )
 
 type Server struct {
-	router *gin.Engine
-	config *config.Config
+	router     *gin.Engine
+	config     *config.Config
+	auditQueue *audittrail.Queue
+	archiver   *audittrail.Archiver
+	anchorer   *audittrail.Anchorer
+	detector   *anomaly.Detector
+	sweeper    *rbac.AssignmentSweeper
 }
 
 func NewServer(cfg *config.Config) *Server {
@@ -58,8 +82,48 @@ func (s *Server) Initialize() error {
 	// Initialize Redis
 	redis := config.InitRedis(s.config)
 
+	// Signing keys for ID tokens and, in JWT token mode, access tokens
+	signingKeys, err := s.loadSigningKeys()
+	if err != nil {
+		return err
+	}
+
+	// Mail delivery for password resets
+	mailSender, err := s.newMailSender()
+	if err != nil {
+		return err
+	}
+
+	// Automatic audit trail of mutating requests, written in the background
+	s.auditQueue = audittrail.NewQueue(audittrail.NewSQLWriter(db.DB), audittrail.Config{
+		BufferSize:    s.config.Audit.QueueSize,
+		BatchSize:     s.config.Audit.BatchSize,
+		FlushInterval: time.Duration(s.config.Audit.FlushIntervalMs) * time.Millisecond,
+	})
+
+	// Archiving of audit entries past their retention period, started by Run
+	s.archiver = audittrail.NewArchiver(db.DB, audittrail.RetentionConfig{
+		Dir:         s.config.Audit.ArchiveDir,
+		DefaultDays: s.config.Audit.RetentionDays,
+		Interval:    time.Duration(s.config.Audit.RetentionIntervalMinutes) * time.Minute,
+		BatchSize:   s.config.Audit.ArchiveBatchSize,
+	})
+
+	// Export of the audit chain heads outside the database, started by Run
+	if s.config.Audit.AnchorFile != "" {
+		s.anchorer = audittrail.NewAnchorer(db.DB, audittrail.AnchorConfig{
+			File:     s.config.Audit.AnchorFile,
+			Interval: time.Duration(s.config.Audit.AnchorIntervalMinutes) * time.Minute,
+		})
+	}
+
+	// Security anomaly detection over the audit log, started by Run
+	if s.config.Anomaly.Enabled {
+		s.detector = anomaly.NewDetector(db.DB, s.anomalyConfig())
+	}
+
 	// Initialize NEW module handlers
-	newModuleHandlers := s.initializeNewModuleHandlers(redis, db.DB)
+	newModuleHandlers := s.initializeNewModuleHandlers(redis, db.DB, signingKeys, mailSender)
 
 	// Initialize Gin router
 	s.router = gin.Default()
@@ -73,17 +137,131 @@ func (s *Server) Initialize() error {
 	swaggerHandler.RegisterRoutes(s.router)
 
 	// Setup NEW module routes
-	SetupNewModuleRoutes(s.router, newModuleHandlers, s.config.JWT.Secret, redis, db.DB)
+	SetupNewModuleRoutes(s.router, newModuleHandlers, s.config.JWT.Secret, redis, db.DB, s.auditQueue)
 
 	return nil
 }
 
-func (s *Server) initializeNewModuleHandlers(redis *redis.Client, db *sql.DB) *NewModuleHandlers {
+// loadSigningKeys loads the JWT signing keys and switches access tokens to JWTs when TOKEN_MODE=jwt
+func (s *Server) loadSigningKeys() (*jwt.KeySet, error) {
+	jwtConfig := s.config.JWT
+
+	keySet, err := jwt.LoadKeySet(jwtConfig.KeysDir, jwtConfig.ActiveKeyID, jwtConfig.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWT signing keys: %w", err)
+	}
+	if jwtConfig.KeysDir == "" {
+		log.Printf("WARNING: JWT_KEYS_DIR is not set, using an ephemeral %s key; signed tokens will not survive a restart", jwtConfig.Algorithm)
+	}
+
+	if jwtConfig.TokenMode == "jwt" {
+		token.EnableJWT(keySet, jwtConfig.Issuer)
+		log.Printf("JWT access tokens enabled (active key: %s)", keySet.ActiveKeyID())
+	}
+
+	return keySet, nil
+}
+
+// anomalyConfig converts the ANOMALY_* settings; invalid business days or time zones
+// fall back to the defaults
+func (s *Server) anomalyConfig() anomaly.Config {
+	cfg := s.config.Anomaly
+	config := anomaly.DefaultConfig()
+	config.Interval = time.Duration(cfg.IntervalSeconds) * time.Second
+	config.FailedLoginThreshold = cfg.FailedLoginThreshold
+	config.FailedLoginWindow = time.Duration(cfg.FailedLoginWindowMinutes) * time.Minute
+	config.MassDeleteThreshold = cfg.MassDeleteThreshold
+	config.MassDeleteWindow = time.Duration(cfg.MassDeleteWindowMinutes) * time.Minute
+	config.BusinessHours.StartHour = cfg.BusinessHoursStart
+	config.BusinessHours.EndHour = cfg.BusinessHoursEnd
+
+	if days, err := anomaly.ParseWeekdays(cfg.BusinessDays); err != nil {
+		log.Printf("Invalid ANOMALY_BUSINESS_DAYS, using Monday to Friday: %v", err)
+	} else {
+		config.BusinessHours.Days = days
+	}
+
+	if location, err := time.LoadLocation(cfg.Timezone); err != nil {
+		log.Printf("Invalid ANOMALY_TIMEZONE, using local time: %v", err)
+	} else {
+		config.BusinessHours.Location = location
+	}
+
+	return config
+}
+
+// newMailSender creates the mail sender selected by MAIL_DRIVER
+func (s *Server) newMailSender() (mailer.Sender, error) {
+	mailConfig := s.config.Mail
+
+	sender, err := mailer.New(mailer.Config{
+		Driver:   mailConfig.Driver,
+		From:     mailConfig.From,
+		Host:     mailConfig.SMTPHost,
+		Port:     mailConfig.SMTPPort,
+		Username: mailConfig.SMTPUsername,
+		Password: mailConfig.SMTPPassword,
+		Dir:      mailConfig.FileDir,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mail sender: %w", err)
+	}
+	if mailConfig.Driver != "smtp" {
+		log.Printf("WARNING: MAIL_DRIVER=%s, emails such as password reset links are not delivered to users", mailConfig.Driver)
+	}
+
+	return sender, nil
+}
+
+// newPasswordStore loads the common password blocklist and the default password policy
+func (s *Server) newPasswordStore(db *sql.DB) *password.Store {
+	passwordConfig := s.config.Password
+
+	if count, err := password.LoadBlocklist(passwordConfig.BlocklistFile); err != nil {
+		log.Printf("WARNING: password blocklist not loaded, common passwords are not rejected: %v", err)
+	} else {
+		log.Printf("Loaded %d common passwords from %s", count, passwordConfig.BlocklistFile)
+	}
+
+	return password.NewStore(db, password.Policy{
+		MinLength:        passwordConfig.MinLength,
+		RequireUppercase: passwordConfig.RequireUppercase,
+		RequireLowercase: passwordConfig.RequireLowercase,
+		RequireDigit:     passwordConfig.RequireDigit,
+		RequireSymbol:    passwordConfig.RequireSymbol,
+		HistoryCount:     passwordConfig.HistoryCount,
+		MaxAgeDays:       passwordConfig.MaxAgeDays,
+	})
+}
+
+func (s *Server) initializeNewModuleHandlers(redis *redis.Client, db *sql.DB, signingKeys *jwt.KeySet, mailSender mailer.Sender) *NewModuleHandlers {
 	// Initialize token service
 	tokenService := token.NewSimpleTokenService(redis)
 
-	// Initialize RBAC service
-	rbacService := rbac.NewRBACService(db)
+	// Brute-force protection of the login endpoints, shared with the admin unlock
+	lockoutConfig := lockout.DefaultConfig()
+	lockoutConfig.MaxAttempts = s.config.Lockout.MaxAttempts
+	lockoutConfig.IPMaxAttempts = s.config.Lockout.IPMaxAttempts
+	lockoutConfig.Window = time.Duration(s.config.Lockout.WindowMinutes) * time.Minute
+	lockoutConfig.Duration = time.Duration(s.config.Lockout.LockoutMinutes) * time.Minute
+	loginGuard := lockout.NewGuard(redis, lockoutConfig)
+
+	// Password policies: the configured default, overridable per company
+	passwordStore := s.newPasswordStore(db)
+
+	// Initialize RBAC service with a shared permission cache
+	permissionCache := rbac.NewPermissionCache(redis, db)
+	rbacService := rbac.NewCachedRBACService(db, permissionCache)
+
+	// Expiry of time-bound role assignments, started by Run
+	s.sweeper = rbac.NewAssignmentSweeper(db, permissionCache, tokenService, s.auditQueue,
+		time.Duration(s.config.Assignment.SweepIntervalSeconds)*time.Second)
+
+	// Separation-of-duties rules, checked on role assignments and permission updates
+	sodGuard := rbac.NewSoDGuard(db, s.config.SoD.Mode == "override", s.auditQueue)
+
+	// Dry runs of role and unit role permission changes
+	permissionSimulator := rbac.NewPermissionSimulator(db)
 
 	// Initialize module repositories (using module implementations)
 	userRepo := userModule.NewUserRepository(db)
@@ -95,19 +273,37 @@ func (s *Server) initializeNewModuleHandlers(redis *redis.Client, db *sql.DB) *N
 	auditRepo := auditModule.NewRepository(db)
 	unitRepo := unitModule.NewRepository(db)
 	applicationRepo := applicationModule.NewRepository(db)
+	oauthRepo := oauthModule.NewRepository(db)
+	invitationRepo := invitationModule.NewRepository(db)
+	alertRepo := alertModule.NewRepository(db)
+	elevationRepo := elevationModule.NewRepository(db)
+	accessReviewRepo := accessReviewModule.NewRepository(db)
+	sodRepo := sodModule.NewRepository(db)
 
 	// Initialize module services
 	authRepo := authModule.NewRepository(db)
-	authService := authModule.NewService(authRepo, tokenService, s.config.JWT.Secret)
-	userService := userModule.NewService(userRepo, rbacService)
-	roleService := roleModule.NewService(roleRepo)
-	companyService := companyModule.NewService(companyRepo)
+	authService := authModule.NewService(authRepo, tokenService, s.config.JWT.Secret, signingKeys, loginGuard, passwordStore, mailSender, s.config.Mail.PasswordResetURL)
+	userService := userModule.NewService(userRepo, rbacService, loginGuard, passwordStore)
+	roleService := roleModule.NewService(roleRepo, permissionCache, sodGuard, permissionSimulator, s.auditQueue)
+	companyService := companyModule.NewService(companyRepo, passwordStore)
 	branchService := branchModule.NewService(branchRepo)
-	moduleService := moduleModule.NewService(moduleRepo)
-	unitService := unitModule.NewService(unitRepo)
-	subscriptionService := subscriptionModule.NewService(subscriptionRepo)
-	auditService := auditModule.NewService(auditRepo)
+	moduleService := moduleModule.NewService(moduleRepo, permissionCache)
+	unitService := unitModule.NewService(unitRepo, permissionCache, sodGuard, permissionSimulator, s.auditQueue)
+	subscriptionService := subscriptionModule.NewService(subscriptionRepo, permissionCache, s.auditQueue)
+	auditService := auditModule.NewService(auditRepo, audittrail.NewChainStore(db, s.config.Audit.AnchorFile), s.config.Audit.RetentionDays)
 	applicationService := applicationModule.NewService(applicationRepo)
+	oauthService := oauthModule.NewService(oauthRepo, tokenService, signingKeys, s.config.JWT.Issuer)
+	invitationService := invitationModule.NewService(invitationRepo, tokenService, passwordStore,
+		invitationModule.NewMailNotifier(mailSender), &roleAssigner{roleService: roleService}, s.config.Mail.InvitationAcceptURL)
+	alertService := alertModule.NewService(alertRepo)
+	elevationService := elevationModule.NewService(elevationRepo, &roleAssigner{roleService: roleService}, s.auditQueue,
+		elevationModule.Policy{
+			ApproverModuleURL: s.config.Elevation.ApproverModuleURL,
+			ApproverRole:      s.config.Elevation.ApproverRole,
+			MaxDuration:       time.Duration(s.config.Elevation.MaxDurationMinutes) * time.Minute,
+		})
+	accessReviewService := accessReviewModule.NewService(accessReviewRepo, permissionCache, tokenService, s.auditQueue)
+	sodService := sodModule.NewService(sodRepo, sodGuard, s.auditQueue)
 
 	// Initialize module handlers
 	return &NewModuleHandlers{
@@ -121,12 +317,67 @@ func (s *Server) initializeNewModuleHandlers(redis *redis.Client, db *sql.DB) *N
 		Subscription: subscriptionModule.NewHandler(subscriptionService),
 		Audit:        auditModule.NewHandler(auditService),
 		Application:  applicationModule.NewHandler(applicationService),
+		OAuth:        oauthModule.NewHandler(oauthService),
+		Invitation:   invitationModule.NewHandler(invitationService),
+		Alert:        alertModule.NewHandler(alertService),
+		Elevation:    elevationModule.NewHandler(elevationService),
+		AccessReview: accessReviewModule.NewHandler(accessReviewService),
+		SoD:          sodModule.NewHandler(sodService),
 	}
 }
 
+// Run serves until SIGINT or SIGTERM, then finishes in-flight requests and writes
+// the buffered audit entries before returning
 func (s *Server) Run() error {
-	log.Printf("Server starting on port %s", s.config.Port)
-	return s.router.Run(":" + s.config.Port)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{
+		Addr:    ":" + s.config.Port,
+		Handler: s.router,
+	}
+
+	if s.archiver != nil {
+		go s.archiver.Start(ctx)
+	}
+	if s.anchorer != nil {
+		go s.anchorer.Start(ctx)
+	}
+	if s.detector != nil {
+		go s.detector.Start(ctx)
+	}
+	if s.sweeper != nil {
+		go s.sweeper.Start(ctx)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on port %s", s.config.Port)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	case <-ctx.Done():
+		log.Printf("Shutting down server")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down server gracefully: %v", err)
+	}
+	if s.auditQueue != nil {
+		if err := s.auditQueue.Close(shutdownCtx); err != nil {
+			log.Printf("Failed to flush audit entries: %v", err)
+		}
+	}
+
+	return nil
 }
 
 // NewModuleHandlers struct for new module-based handlers
@@ -141,4 +392,10 @@ type NewModuleHandlers struct {
 	Subscription *subscriptionModule.Handler
 	Audit        *auditModule.Handler
 	Application  *applicationModule.Handler
+	OAuth        *oauthModule.Handler
+	Invitation   *invitationModule.Handler
+	Alert        *alertModule.Handler
+	Elevation    *elevationModule.Handler
+	AccessReview *accessReviewModule.Handler
+	SoD          *sodModule.Handler
 }
@@ -24,22 +24,102 @@ const (
 	MsgRegisterSuccess      = "Registration successful"
 	MsgTokenRefreshed       = "Token successfully refreshed"
 	MsgPasswordChanged      = "Password successfully changed"
-	MsgPasswordResetSent    = "Password reset email has been sent"
+	MsgPasswordResetSent    = "If the email is registered, a password reset link has been sent"
 	MsgPasswordResetSuccess = "Password reset successful"
 	MsgInvalidCredentials   = "Invalid credentials"
 	MsgTokenExpired         = "Token has expired"
 	MsgTokenInvalid         = "Token is invalid"
+	MsgSessionsRetrieved    = "Active sessions successfully retrieved"
+	MsgSessionRevoked       = "Session successfully revoked"
+	MsgTwoFactorRequired    = "Two-factor verification required"
+	MsgTwoFactorSetup       = "Two-factor secret generated, confirm it with a code from the authenticator app"
+	MsgTwoFactorEnabled     = "Two-factor authentication successfully enabled"
+	MsgTwoFactorDisabled    = "Two-factor authentication successfully disabled"
+	MsgTwoFactorStatus      = "Two-factor status successfully retrieved"
+	MsgRecoveryCodesRenewed = "Recovery codes successfully regenerated"
+	MsgTwoFactorPolicy      = "Two-factor policy successfully retrieved"
+	MsgTwoFactorPolicySaved = "Two-factor policy successfully updated"
+	MsgPasswordExpired      = "Password has expired and must be changed"
+	MsgPasswordPolicy       = "Password policy successfully retrieved"
+	MsgPasswordPolicySaved  = "Password policy successfully updated"
+)
+
+// OAuth Module Messages
+const (
+	MsgOAuthClientsRetrieved    = "OAuth clients successfully retrieved"
+	MsgOAuthClientCreated       = "OAuth client successfully created"
+	MsgOAuthClientUpdated       = "OAuth client successfully updated"
+	MsgOAuthClientDeleted       = "OAuth client successfully deleted"
+	MsgOAuthClientSecretRotated = "OAuth client secret successfully rotated"
+	MsgAuthorizationValidated   = "Authorization request successfully validated"
+	MsgAuthorizationDecided     = "Authorization request successfully decided"
+	MsgConsentsRetrieved        = "Consents successfully retrieved"
+	MsgConsentRevoked           = "Consent successfully revoked"
+)
+
+// Invitation Module Messages
+const (
+	MsgInvitationCreated  = "Invitation successfully sent"
+	MsgInvitationsList    = "Invitations successfully retrieved"
+	MsgInvitationResent   = "Invitation successfully resent"
+	MsgInvitationRevoked  = "Invitation successfully revoked"
+	MsgInvitationValid    = "Invitation is valid"
+	MsgInvitationAccepted = "Invitation accepted, the account has been created"
+)
+
+// Elevation Module Messages
+const (
+	MsgElevationRequested = "Elevation request successfully submitted"
+	MsgElevationsList     = "Elevation requests successfully retrieved"
+	MsgElevationRetrieved = "Elevation request successfully retrieved"
+	MsgElevationApproved  = "Elevation request approved, the role has been granted"
+	MsgElevationRejected  = "Elevation request successfully rejected"
+	MsgElevationCancelled = "Elevation request successfully cancelled"
+)
+
+// Access Review Module Messages
+const (
+	MsgAccessReviewCreated    = "Access review successfully started"
+	MsgAccessReviewsList      = "Access reviews successfully retrieved"
+	MsgAccessReviewRetrieved  = "Access review successfully retrieved"
+	MsgAccessReviewItemsList  = "Access review items successfully retrieved"
+	MsgAccessReviewDecided    = "Access review decisions successfully saved"
+	MsgAccessReviewReassigned = "Access review item successfully reassigned"
+	MsgAccessReviewClosed     = "Access review successfully closed"
+	MsgAccessReviewReport     = "Access review report successfully generated"
+)
+
+// Separation of Duties Module Messages
+const (
+	MsgSoDRuleCreated      = "SoD rule successfully created"
+	MsgSoDRulesList        = "SoD rules successfully retrieved"
+	MsgSoDRuleRetrieved    = "SoD rule successfully retrieved"
+	MsgSoDRuleUpdated      = "SoD rule successfully updated"
+	MsgSoDRuleDeleted      = "SoD rule successfully deleted"
+	MsgSoDViolationsReport = "SoD violation report successfully generated"
+)
+
+// Security Alert Module Messages
+const (
+	MsgAlertsList        = "Security alerts successfully retrieved"
+	MsgAlertRetrieved    = "Security alert successfully retrieved"
+	MsgAlertAcknowledged = "Security alert successfully acknowledged"
+	MsgAlertResolved     = "Security alert successfully resolved"
 )
 
 // User Module Messages
 const (
-	MsgUserRetrieved      = "User successfully retrieved"
-	MsgUsersRetrieved     = "Users list successfully retrieved"
-	MsgUserCreated        = "User successfully created"
-	MsgUserUpdated        = "User successfully updated"
-	MsgUserDeleted        = "User successfully deleted"
-	MsgUserNotFound       = "User not found"
-	MsgEmailAlreadyExists = "Email already exists"
+	MsgUserRetrieved       = "User successfully retrieved"
+	MsgUsersRetrieved      = "Users list successfully retrieved"
+	MsgUserCreated         = "User successfully created"
+	MsgUserUpdated         = "User successfully updated"
+	MsgUserDeleted         = "User successfully deleted"
+	MsgUserNotFound        = "User not found"
+	MsgEmailAlreadyExists  = "Email already exists"
+	MsgLockoutRetrieved    = "Login lockout status successfully retrieved"
+	MsgUserUnlocked        = "User successfully unlocked"
+	MsgPermissionExplained = "Permission decision successfully explained"
+	MsgAccessChecked       = "Access checks successfully evaluated"
 )
 
 // Company Module Messages
@@ -55,28 +135,32 @@ const (
 
 // Role Module Messages
 const (
-	MsgRoleRetrieved      = "Role successfully retrieved"
-	MsgRolesRetrieved     = "Roles list successfully retrieved"
-	MsgRoleCreated        = "Role successfully created"
-	MsgRoleUpdated        = "Role successfully updated"
-	MsgRoleDeleted        = "Role successfully deleted"
-	MsgRoleNotFound       = "Role not found"
-	MsgRoleNameExists     = "Role name already exists"
-	MsgRoleAssigned       = "Role successfully assigned"
-	MsgRoleUnassigned     = "Role successfully unassigned"
-	MsgPermissionsUpdated = "Permissions successfully updated"
+	MsgRoleRetrieved        = "Role successfully retrieved"
+	MsgRolesRetrieved       = "Roles list successfully retrieved"
+	MsgRoleCreated          = "Role successfully created"
+	MsgRoleUpdated          = "Role successfully updated"
+	MsgRoleDeleted          = "Role successfully deleted"
+	MsgRoleNotFound         = "Role not found"
+	MsgRoleNameExists       = "Role name already exists"
+	MsgRoleAssigned         = "Role successfully assigned"
+	MsgRoleUnassigned       = "Role successfully unassigned"
+	MsgPermissionsUpdated   = "Permissions successfully updated"
+	MsgRoleParentsUpdated   = "Role parents successfully updated"
+	MsgPermissionsSimulated = "Permission change successfully simulated"
 )
 
 // Module Module Messages
 const (
-	MsgModuleRetrieved      = "Module successfully retrieved"
-	MsgModulesRetrieved     = "Modules list successfully retrieved"
-	MsgModuleCreated        = "Module successfully created"
-	MsgModuleUpdated        = "Module successfully updated"
-	MsgModuleDeleted        = "Module successfully deleted"
-	MsgModuleNotFound       = "Module not found"
-	MsgModuleURLExists      = "Module URL already exists"
-	MsgUserModulesRetrieved = "User modules successfully retrieved"
+	MsgModuleRetrieved        = "Module successfully retrieved"
+	MsgModulesRetrieved       = "Modules list successfully retrieved"
+	MsgModuleCreated          = "Module successfully created"
+	MsgModuleUpdated          = "Module successfully updated"
+	MsgModuleDeleted          = "Module successfully deleted"
+	MsgModuleNotFound         = "Module not found"
+	MsgModuleURLExists        = "Module URL already exists"
+	MsgUserModulesRetrieved   = "User modules successfully retrieved"
+	MsgModuleActionsRetrieved = "Module actions successfully retrieved"
+	MsgModuleActionsUpdated   = "Module actions successfully updated"
 )
 
 // Branch Module Messages
@@ -121,6 +205,11 @@ const (
 	MsgAuditLogCreated     = "Audit log successfully created"
 	MsgAuditStatsRetrieved = "Audit statistics successfully retrieved"
 	MsgAuditLogsCleanedUp  = "Old audit logs successfully cleaned up"
+	MsgAuditChainVerified  = "Audit log chain verification completed"
+
+	MsgAuditRetentionRetrieved = "Audit retention policies successfully retrieved"
+	MsgAuditRetentionSaved     = "Audit retention policy successfully saved"
+	MsgAuditRetentionDeleted   = "Audit retention policy successfully deleted"
 )
 
 // Subscription Module Messages
@@ -211,3 +300,34 @@ const (
 	AuditError   = "error"
 	AuditWarning = "warning"
 )
+
+// Two-Factor Authentication
+const (
+	TwoFactorIssuer            = "RBAC Service"
+	TwoFactorChallengeTTL      = 5 * 60 // seconds
+	TwoFactorMaxAttempts       = 5
+	TwoFactorRecoveryCodeCount = 10
+)
+
+// Password Policy
+const (
+	PasswordChangeChallengeTTL = 10 * 60 // seconds
+	PasswordResetTokenTTL      = 30 * 60 // seconds
+	PasswordResetCooldown      = 60      // seconds between reset mails per user
+)
+
+// Invitations
+const (
+	InvitationTTL = 7 * 24 * 60 * 60 // seconds
+)
+
+// Elevation requests
+const (
+	ElevationApprovalTTL = 24 * 60 * 60 // seconds a request waits for an approver
+)
+
+// OAuth2 / OpenID Connect
+const (
+	OAuthAuthorizationCodeTTL = 60      // seconds
+	OAuthIDTokenTTL           = 60 * 60 // seconds
+)
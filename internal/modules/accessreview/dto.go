@@ -0,0 +1,123 @@
+package accessreview
+
+import "time"
+
+// CreateCampaignRequest starts an access review of a company, optionally narrowed to a
+// branch and/or a role
+type CreateCampaignRequest struct {
+	Name      string     `json:"name" validate:"required,min=3,max=150"`
+	CompanyID int64      `json:"company_id" validate:"required,min=1"`
+	BranchID  *int64     `json:"branch_id" validate:"omitempty,min=1"`
+	RoleID    *int64     `json:"role_id" validate:"omitempty,min=1"`
+	DueAt     *time.Time `json:"due_at,omitempty"`
+}
+
+// CampaignListRequest filters campaigns. With company_id the company admins see all
+// campaigns of the company; without it reviewers see the campaigns they have items in.
+type CampaignListRequest struct {
+	CompanyID int64  `form:"company_id"`
+	Status    string `form:"status"`
+	Limit     int    `form:"limit"`
+	Offset    int    `form:"offset"`
+}
+
+// ItemListRequest filters the items of a campaign. Reviewers only see their own items.
+type ItemListRequest struct {
+	ReviewerID int64  `form:"reviewer_id"`
+	Decision   string `form:"decision"`
+	Limit      int    `form:"limit"`
+	Offset     int    `form:"offset"`
+}
+
+// DecideItemsRequest certifies or revokes one or more items of the reviewer
+type DecideItemsRequest struct {
+	ItemIDs  []int64 `json:"item_ids" validate:"required,min=1,max=500,dive,min=1"`
+	Decision string  `json:"decision" validate:"required,oneof=certify revoke"`
+	Comment  string  `json:"comment" validate:"max=1000"`
+}
+
+// ReassignItemRequest hands an item to another reviewer
+type ReassignItemRequest struct {
+	ReviewerID int64 `json:"reviewer_id" validate:"required,min=1"`
+}
+
+// ReportRequest selects the format of a campaign report
+type ReportRequest struct {
+	Format string `form:"format"`
+}
+
+type CampaignResponse struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	CompanyID   int64      `json:"company_id"`
+	CompanyName string     `json:"company_name"`
+	BranchID    *int64     `json:"branch_id"`
+	RoleID      *int64     `json:"role_id"`
+	Status      string     `json:"status"`
+	DueAt       *string    `json:"due_at"`
+	CreatedBy   *int64     `json:"created_by"`
+	ClosedBy    *int64     `json:"closed_by"`
+	ClosedAt    *string    `json:"closed_at"`
+	CreatedAt   string     `json:"created_at"`
+	Progress    Progress   `json:"progress"`
+	Reviewers   []Progress `json:"reviewers,omitempty"`
+}
+
+type CampaignListResponse struct {
+	Data    []*CampaignResponse `json:"data"`
+	Total   int64               `json:"total"`
+	Limit   int                 `json:"limit"`
+	Offset  int                 `json:"offset"`
+	HasMore bool                `json:"has_more"`
+}
+
+type ItemResponse struct {
+	ID           int64   `json:"id"`
+	Kind         string  `json:"kind"`
+	AssignmentID int64   `json:"assignment_id"`
+	UserID       *int64  `json:"user_id"`
+	UserName     *string `json:"user_name"`
+	UserEmail    *string `json:"user_email"`
+	RoleID       int64   `json:"role_id"`
+	RoleName     string  `json:"role_name"`
+	BranchID     *int64  `json:"branch_id"`
+	BranchName   *string `json:"branch_name"`
+	UnitID       *int64  `json:"unit_id"`
+	UnitName     *string `json:"unit_name"`
+	ValidUntil   *string `json:"valid_until"`
+	ReviewerID   *int64  `json:"reviewer_id"`
+	ReviewerName *string `json:"reviewer_name"`
+	Decision     string  `json:"decision"`
+	Comment      *string `json:"comment"`
+	DecidedBy    *int64  `json:"decided_by"`
+	DecidedAt    *string `json:"decided_at"`
+	RemovedAt    *string `json:"removed_at"`
+}
+
+type ItemListResponse struct {
+	Data    []*ItemResponse `json:"data"`
+	Total   int64           `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+	HasMore bool            `json:"has_more"`
+}
+
+type DecideItemsResponse struct {
+	Updated  int      `json:"updated"`
+	Progress Progress `json:"progress"`
+}
+
+// CloseCampaignResponse summarizes the removals made when a campaign closed
+type CloseCampaignResponse struct {
+	Campaign *CampaignResponse `json:"campaign"`
+	Removed  int               `json:"removed"`
+	// Revoked items whose assignment no longer existed
+	AlreadyGone int `json:"already_gone"`
+}
+
+// ReportResponse is the JSON form of a campaign report
+type ReportResponse struct {
+	Campaign    *CampaignResponse `json:"campaign"`
+	Items       []*ItemResponse   `json:"items"`
+	GeneratedAt string            `json:"generated_at"`
+}
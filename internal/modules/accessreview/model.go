@@ -0,0 +1,97 @@
+package accessreview
+
+import (
+	"time"
+)
+
+// Campaign statuses
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+)
+
+// Item decisions
+const (
+	DecisionPending   = "pending"
+	DecisionCertified = "certified"
+	DecisionRevoked   = "revoked"
+)
+
+// Item kinds, matching the assignment tables
+const (
+	KindUserRole = "user_role"
+	KindUnitRole = "unit_role"
+)
+
+// Campaign is an access review of the assignments of a company, optionally narrowed to
+// a branch and/or a role
+type Campaign struct {
+	ID          int64      `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	CompanyID   int64      `json:"company_id" db:"company_id"`
+	CompanyName string     `json:"company_name" db:"-"`
+	BranchID    *int64     `json:"branch_id" db:"branch_id"`
+	RoleID      *int64     `json:"role_id" db:"role_id"`
+	Status      string     `json:"status" db:"status"`
+	DueAt       *time.Time `json:"due_at" db:"due_at"`
+	CreatedBy   *int64     `json:"created_by" db:"created_by"`
+	ClosedBy    *int64     `json:"closed_by" db:"closed_by"`
+	ClosedAt    *time.Time `json:"closed_at" db:"closed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (Campaign) TableName() string {
+	return "access_review_campaigns"
+}
+
+// Item is one assignment under review. The assignment fields are a snapshot taken when
+// the campaign started; UserID is nil for unit roles.
+type Item struct {
+	ID           int64      `json:"id" db:"id"`
+	CampaignID   int64      `json:"campaign_id" db:"campaign_id"`
+	Kind         string     `json:"kind" db:"kind"`
+	AssignmentID int64      `json:"assignment_id" db:"assignment_id"`
+	UserID       *int64     `json:"user_id" db:"user_id"`
+	UserName     *string    `json:"user_name" db:"-"`
+	UserEmail    *string    `json:"user_email" db:"-"`
+	RoleID       int64      `json:"role_id" db:"role_id"`
+	RoleName     string     `json:"role_name" db:"-"`
+	CompanyID    int64      `json:"company_id" db:"company_id"`
+	BranchID     *int64     `json:"branch_id" db:"branch_id"`
+	BranchName   *string    `json:"branch_name" db:"-"`
+	UnitID       *int64     `json:"unit_id" db:"unit_id"`
+	UnitName     *string    `json:"unit_name" db:"-"`
+	ValidUntil   *time.Time `json:"valid_until" db:"valid_until"`
+	ReviewerID   *int64     `json:"reviewer_id" db:"reviewer_id"`
+	ReviewerName *string    `json:"reviewer_name" db:"-"`
+	Decision     string     `json:"decision" db:"decision"`
+	Comment      *string    `json:"comment" db:"comment"`
+	DecidedBy    *int64     `json:"decided_by" db:"decided_by"`
+	DecidedAt    *time.Time `json:"decided_at" db:"decided_at"`
+	RemovedAt    *time.Time `json:"removed_at" db:"removed_at"`
+}
+
+func (Item) TableName() string {
+	return "access_review_items"
+}
+
+// Progress counts the decisions of a campaign, overall or for one reviewer
+type Progress struct {
+	ReviewerID   *int64  `json:"reviewer_id,omitempty"`
+	ReviewerName *string `json:"reviewer_name,omitempty"`
+	Total        int64   `json:"total"`
+	Pending      int64   `json:"pending"`
+	Certified    int64   `json:"certified"`
+	Revoked      int64   `json:"revoked"`
+}
+
+// RemovedAssignment is a revoked assignment deleted when its campaign closed
+type RemovedAssignment struct {
+	ItemID       int64
+	Kind         string
+	AssignmentID int64
+	UserID       *int64
+	UnitID       *int64
+	RoleID       int64
+}
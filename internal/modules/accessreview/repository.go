@@ -0,0 +1,573 @@
+package accessreview
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gin-scalable-api/pkg/rbac"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const campaignSelect = `
+	SELECT ac.id, ac.name, ac.company_id, c.name, ac.branch_id, ac.role_id, ac.status, ac.due_at,
+		ac.created_by, ac.closed_by, ac.closed_at, ac.created_at, ac.updated_at
+	FROM access_review_campaigns ac
+	JOIN companies c ON ac.company_id = c.id
+`
+
+func scanCampaign(scanner interface{ Scan(...interface{}) error }) (*Campaign, error) {
+	campaign := &Campaign{}
+	err := scanner.Scan(
+		&campaign.ID, &campaign.Name, &campaign.CompanyID, &campaign.CompanyName, &campaign.BranchID,
+		&campaign.RoleID, &campaign.Status, &campaign.DueAt, &campaign.CreatedBy, &campaign.ClosedBy,
+		&campaign.ClosedAt, &campaign.CreatedAt, &campaign.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+const itemSelect = `
+	SELECT i.id, i.campaign_id, i.kind, i.assignment_id, i.user_id, u.name, u.email, i.role_id, r.name,
+		i.company_id, i.branch_id, b.name, i.unit_id, un.name, i.valid_until, i.reviewer_id, rv.name,
+		i.decision, i.comment, i.decided_by, i.decided_at, i.removed_at
+	FROM access_review_items i
+	JOIN roles r ON i.role_id = r.id
+	LEFT JOIN users u ON i.user_id = u.id
+	LEFT JOIN branches b ON i.branch_id = b.id
+	LEFT JOIN units un ON i.unit_id = un.id
+	LEFT JOIN users rv ON i.reviewer_id = rv.id
+`
+
+func scanItem(scanner interface{ Scan(...interface{}) error }) (*Item, error) {
+	item := &Item{}
+	err := scanner.Scan(
+		&item.ID, &item.CampaignID, &item.Kind, &item.AssignmentID, &item.UserID, &item.UserName,
+		&item.UserEmail, &item.RoleID, &item.RoleName, &item.CompanyID, &item.BranchID, &item.BranchName,
+		&item.UnitID, &item.UnitName, &item.ValidUntil, &item.ReviewerID, &item.ReviewerName,
+		&item.Decision, &item.Comment, &item.DecidedBy, &item.DecidedAt, &item.RemovedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// IsCompanyAdmin checks whether a user may run the access reviews of a company
+func (r *Repository) IsCompanyAdmin(userID, companyID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true
+				AND (r.name = 'SUPER_ADMIN' OR (r.name = 'COMPANY_ADMIN' AND ur.company_id = $2))
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID, companyID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check company admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// IsCompanyMember checks whether a user holds any active role in a company
+func (r *Repository) IsCompanyMember(userID, companyID int64) (bool, error) {
+	var isMember bool
+	query := `SELECT EXISTS(SELECT 1 FROM active_user_roles WHERE user_id = $1 AND company_id = $2)`
+	if err := r.db.QueryRow(query, userID, companyID).Scan(&isMember); err != nil {
+		return false, fmt.Errorf("failed to check company membership: %w", err)
+	}
+	return isMember, nil
+}
+
+// CompanyExists checks whether an active company exists
+func (r *Repository) CompanyExists(companyID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM companies WHERE id = $1 AND is_active = true)`
+	if err := r.db.QueryRow(query, companyID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check company: %w", err)
+	}
+	return exists, nil
+}
+
+// BranchBelongsToCompany checks whether a branch is part of a company
+func (r *Repository) BranchBelongsToCompany(branchID, companyID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM branches WHERE id = $1 AND company_id = $2)`
+	if err := r.db.QueryRow(query, branchID, companyID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check branch: %w", err)
+	}
+	return exists, nil
+}
+
+// RoleExists checks whether a role exists
+func (r *Repository) RoleExists(roleID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1)`
+	if err := r.db.QueryRow(query, roleID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check role: %w", err)
+	}
+	return exists, nil
+}
+
+// SnapshotAssignments returns every user role and unit role assignment of a company,
+// optionally narrowed to a branch and/or a role, including scheduled ones
+func (r *Repository) SnapshotAssignments(companyID int64, branchID, roleID *int64) ([]*Item, error) {
+	query := `
+		SELECT 'user_role', ur.id, ur.user_id, ur.role_id, ur.company_id, ur.branch_id, ur.unit_id, ur.valid_until
+		FROM user_roles ur
+		WHERE ur.company_id = $1
+			AND ($2::bigint IS NULL OR ur.branch_id = $2)
+			AND ($3::bigint IS NULL OR ur.role_id = $3)
+		UNION ALL
+		SELECT 'unit_role', unr.id, NULL, unr.role_id, b.company_id, u.branch_id, unr.unit_id, unr.valid_until
+		FROM unit_roles unr
+		JOIN units u ON unr.unit_id = u.id
+		JOIN branches b ON u.branch_id = b.id
+		WHERE b.company_id = $1
+			AND ($2::bigint IS NULL OR u.branch_id = $2)
+			AND ($3::bigint IS NULL OR unr.role_id = $3)
+		ORDER BY 1, 2
+	`
+
+	rows, err := r.db.Query(query, companyID, branchID, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*Item
+	for rows.Next() {
+		item := &Item{Decision: DecisionPending}
+		if err := rows.Scan(&item.Kind, &item.AssignmentID, &item.UserID, &item.RoleID, &item.CompanyID,
+			&item.BranchID, &item.UnitID, &item.ValidUntil); err != nil {
+			return nil, fmt.Errorf("failed to scan assignment: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// AdminAssignments returns the administrative roles held in a company by active users
+func (r *Repository) AdminAssignments(companyID int64) ([]rbac.AdminAssignment, error) {
+	query := `
+		SELECT ur.user_id, r.name, ur.company_id, ur.branch_id, ur.unit_id
+		FROM active_user_roles ur
+		JOIN roles r ON ur.role_id = r.id AND r.is_active = true
+		JOIN users u ON ur.user_id = u.id AND u.is_active = true AND u.deleted_at IS NULL
+		WHERE ur.company_id = $1
+			AND r.name IN ('SUPER_ADMIN', 'COMPANY_ADMIN', 'BRANCH_ADMIN', 'UNIT_ADMIN')
+	`
+
+	rows, err := r.db.Query(query, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admins: %w", err)
+	}
+	defer rows.Close()
+
+	var admins []rbac.AdminAssignment
+	for rows.Next() {
+		var admin rbac.AdminAssignment
+		if err := rows.Scan(&admin.UserID, &admin.RoleName, &admin.CompanyID, &admin.BranchID, &admin.UnitID); err != nil {
+			return nil, fmt.Errorf("failed to scan admin: %w", err)
+		}
+		admins = append(admins, admin)
+	}
+
+	return admins, rows.Err()
+}
+
+// Create stores a campaign together with its items
+func (r *Repository) Create(campaign *Campaign, items []*Item) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO access_review_campaigns (name, company_id, branch_id, role_id, status, due_at, created_by,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'open', $5, $6, NOW(), NOW())
+		RETURNING id, status, created_at, updated_at
+	`
+	err = tx.QueryRow(query, campaign.Name, campaign.CompanyID, campaign.BranchID, campaign.RoleID,
+		campaign.DueAt, campaign.CreatedBy,
+	).Scan(&campaign.ID, &campaign.Status, &campaign.CreatedAt, &campaign.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create access review: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO access_review_items (campaign_id, kind, assignment_id, user_id, role_id, company_id,
+			branch_id, unit_id, valid_until, reviewer_id, decision, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, 'pending', NOW(), NOW())
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare access review items: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, item := range items {
+		if _, err := stmt.Exec(campaign.ID, item.Kind, item.AssignmentID, item.UserID, item.RoleID, item.CompanyID,
+			item.BranchID, item.UnitID, item.ValidUntil, item.ReviewerID); err != nil {
+			return fmt.Errorf("failed to create access review item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a campaign by ID
+func (r *Repository) GetByID(id int64) (*Campaign, error) {
+	campaign, err := scanCampaign(r.db.QueryRow(campaignSelect+" WHERE ac.id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("access review tidak ditemukan (access review not found)")
+		}
+		return nil, fmt.Errorf("failed to get access review: %w", err)
+	}
+	return campaign, nil
+}
+
+// campaignConditions translates the campaign list filters into SQL. A reviewer filter
+// keeps the campaigns with at least one item of the reviewer.
+func campaignConditions(companyID, reviewerID int64, status string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if companyID > 0 {
+		args = append(args, companyID)
+		conditions = append(conditions, fmt.Sprintf("ac.company_id = $%d", len(args)))
+	}
+	if reviewerID > 0 {
+		args = append(args, reviewerID)
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS(SELECT 1 FROM access_review_items i WHERE i.campaign_id = ac.id AND i.reviewer_id = $%d)", len(args)))
+	}
+	if status == StatusOpen || status == StatusClosed {
+		conditions = append(conditions, "ac.status = '"+status+"'")
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// List retrieves campaigns, newest first
+func (r *Repository) List(companyID, reviewerID int64, status string, limit, offset int) ([]*Campaign, error) {
+	where, args := campaignConditions(companyID, reviewerID, status)
+	args = append(args, limit, offset)
+	query := campaignSelect + where +
+		fmt.Sprintf(" ORDER BY ac.created_at DESC, ac.id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var campaigns []*Campaign
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan access review: %w", err)
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	return campaigns, rows.Err()
+}
+
+// Count counts the campaigns matching the list filters
+func (r *Repository) Count(companyID, reviewerID int64, status string) (int64, error) {
+	where, args := campaignConditions(companyID, reviewerID, status)
+
+	var count int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM access_review_campaigns ac"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count access reviews: %w", err)
+	}
+	return count, nil
+}
+
+// ReviewerProgress counts the decisions of a campaign per reviewer
+func (r *Repository) ReviewerProgress(campaignID int64) ([]Progress, error) {
+	query := `
+		SELECT i.reviewer_id, rv.name, COUNT(*),
+			COUNT(*) FILTER (WHERE i.decision = 'pending'),
+			COUNT(*) FILTER (WHERE i.decision = 'certified'),
+			COUNT(*) FILTER (WHERE i.decision = 'revoked')
+		FROM access_review_items i
+		LEFT JOIN users rv ON i.reviewer_id = rv.id
+		WHERE i.campaign_id = $1
+		GROUP BY i.reviewer_id, rv.name
+		ORDER BY i.reviewer_id NULLS LAST
+	`
+
+	rows, err := r.db.Query(query, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access review progress: %w", err)
+	}
+	defer rows.Close()
+
+	var progress []Progress
+	for rows.Next() {
+		var p Progress
+		if err := rows.Scan(&p.ReviewerID, &p.ReviewerName, &p.Total, &p.Pending, &p.Certified, &p.Revoked); err != nil {
+			return nil, fmt.Errorf("failed to scan access review progress: %w", err)
+		}
+		progress = append(progress, p)
+	}
+
+	return progress, rows.Err()
+}
+
+// itemConditions translates the item list filters into SQL
+func itemConditions(campaignID, reviewerID int64, decision string) (string, []interface{}) {
+	conditions := []string{"i.campaign_id = $1"}
+	args := []interface{}{campaignID}
+
+	if reviewerID > 0 {
+		args = append(args, reviewerID)
+		conditions = append(conditions, fmt.Sprintf("i.reviewer_id = $%d", len(args)))
+	}
+	switch decision {
+	case DecisionPending, DecisionCertified, DecisionRevoked:
+		conditions = append(conditions, "i.decision = '"+decision+"'")
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// ListItems retrieves the items of a campaign by user and role; a limit of 0 returns all
+func (r *Repository) ListItems(campaignID, reviewerID int64, decision string, limit, offset int) ([]*Item, error) {
+	where, args := itemConditions(campaignID, reviewerID, decision)
+	query := itemSelect + where + " ORDER BY u.name NULLS LAST, un.name, r.name, i.id"
+	if limit > 0 {
+		args = append(args, limit, offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access review items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*Item
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan access review item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// CountItems counts the items matching the item list filters
+func (r *Repository) CountItems(campaignID, reviewerID int64, decision string) (int64, error) {
+	where, args := itemConditions(campaignID, reviewerID, decision)
+
+	var count int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM access_review_items i"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count access review items: %w", err)
+	}
+	return count, nil
+}
+
+// GetItem retrieves an item of a campaign
+func (r *Repository) GetItem(campaignID, itemID int64) (*Item, error) {
+	item, err := scanItem(r.db.QueryRow(itemSelect+" WHERE i.campaign_id = $1 AND i.id = $2", campaignID, itemID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("item access review tidak ditemukan (item not found)")
+		}
+		return nil, fmt.Errorf("failed to get access review item: %w", err)
+	}
+	return item, nil
+}
+
+// Decide records the decision of a reviewer on items of an open campaign. Either all
+// items are updated or none: every item must belong to the campaign, be assigned to the
+// reviewer and not concern the reviewer's own access.
+func (r *Repository) Decide(campaignID, reviewerID int64, itemIDs []int64, decision string, comment *string) error {
+	idsJSON, err := json.Marshal(itemIDs)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Shares the lock taken when closing, so no decision lands in a closed campaign
+	var status string
+	if err := tx.QueryRow(`SELECT status FROM access_review_campaigns WHERE id = $1 FOR SHARE`, campaignID).Scan(&status); err != nil {
+		return fmt.Errorf("failed to lock access review: %w", err)
+	}
+	if status != StatusOpen {
+		return fmt.Errorf("access review sudah ditutup, keputusan tidak dapat diubah (cannot decide)")
+	}
+
+	query := `
+		UPDATE access_review_items
+		SET decision = $4, comment = $5, decided_by = $2, decided_at = NOW(), updated_at = NOW()
+		WHERE campaign_id = $1 AND reviewer_id = $2 AND user_id IS DISTINCT FROM $2
+			AND id IN (SELECT jsonb_array_elements_text($3::jsonb)::bigint)
+	`
+	result, err := tx.Exec(query, campaignID, reviewerID, string(idsJSON), decision, comment)
+	if err != nil {
+		return fmt.Errorf("failed to decide access review items: %w", err)
+	}
+	if updated, _ := result.RowsAffected(); updated != int64(len(itemIDs)) {
+		return fmt.Errorf("access denied: some items are not assigned to you for review")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Reassign hands a pending item of an open campaign to another reviewer
+func (r *Repository) Reassign(campaignID, itemID, reviewerID int64) error {
+	query := `
+		UPDATE access_review_items i
+		SET reviewer_id = $3, updated_at = NOW()
+		FROM access_review_campaigns ac
+		WHERE i.campaign_id = ac.id AND ac.id = $1 AND i.id = $2 AND ac.status = 'open' AND i.decision = 'pending'
+	`
+	result, err := r.db.Exec(query, campaignID, itemID, reviewerID)
+	if err != nil {
+		return fmt.Errorf("failed to reassign access review item: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("item sudah diputuskan atau access review sudah ditutup (cannot reassign)")
+	}
+	return nil
+}
+
+// Close closes an open campaign and deletes the assignments of its revoked items that
+// still exist unchanged. It returns the removed assignments and the number of revoked
+// items whose assignment was already gone.
+func (r *Repository) Close(campaignID, actorID int64) ([]RemovedAssignment, int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRow(`SELECT status FROM access_review_campaigns WHERE id = $1 FOR UPDATE`, campaignID).Scan(&status); err != nil {
+		return nil, 0, fmt.Errorf("failed to lock access review: %w", err)
+	}
+	if status != StatusOpen {
+		return nil, 0, fmt.Errorf("access review sudah ditutup (cannot close)")
+	}
+
+	var removed []RemovedAssignment
+
+	rows, err := tx.Query(`
+		DELETE FROM user_roles ur
+		USING access_review_items i
+		WHERE i.campaign_id = $1 AND i.kind = 'user_role' AND i.decision = 'revoked'
+			AND ur.id = i.assignment_id AND ur.user_id = i.user_id AND ur.role_id = i.role_id
+		RETURNING i.id, ur.id, ur.user_id, ur.unit_id, ur.role_id
+	`, campaignID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to remove revoked user roles: %w", err)
+	}
+	for rows.Next() {
+		assignment := RemovedAssignment{Kind: KindUserRole}
+		if err := rows.Scan(&assignment.ItemID, &assignment.AssignmentID, &assignment.UserID,
+			&assignment.UnitID, &assignment.RoleID); err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("failed to scan removed user role: %w", err)
+		}
+		removed = append(removed, assignment)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read removed user roles: %w", err)
+	}
+
+	rows, err = tx.Query(`
+		DELETE FROM unit_roles unr
+		USING access_review_items i
+		WHERE i.campaign_id = $1 AND i.kind = 'unit_role' AND i.decision = 'revoked'
+			AND unr.id = i.assignment_id AND unr.unit_id = i.unit_id AND unr.role_id = i.role_id
+		RETURNING i.id, unr.id, unr.unit_id, unr.role_id
+	`, campaignID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to remove revoked unit roles: %w", err)
+	}
+	for rows.Next() {
+		assignment := RemovedAssignment{Kind: KindUnitRole}
+		if err := rows.Scan(&assignment.ItemID, &assignment.AssignmentID, &assignment.UnitID, &assignment.RoleID); err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("failed to scan removed unit role: %w", err)
+		}
+		removed = append(removed, assignment)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read removed unit roles: %w", err)
+	}
+
+	itemIDs := make([]int64, 0, len(removed))
+	for _, assignment := range removed {
+		itemIDs = append(itemIDs, assignment.ItemID)
+	}
+	idsJSON, err := json.Marshal(itemIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := tx.Exec(`
+		UPDATE access_review_items SET removed_at = NOW(), updated_at = NOW()
+		WHERE id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)
+	`, string(idsJSON)); err != nil {
+		return nil, 0, fmt.Errorf("failed to mark removed items: %w", err)
+	}
+
+	var revoked int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) FROM access_review_items WHERE campaign_id = $1 AND decision = 'revoked'
+	`, campaignID).Scan(&revoked); err != nil {
+		return nil, 0, fmt.Errorf("failed to count revoked items: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE access_review_campaigns
+		SET status = 'closed', closed_by = $2, closed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, campaignID, actorID); err != nil {
+		return nil, 0, fmt.Errorf("failed to close access review: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return removed, revoked - len(removed), nil
+}
@@ -0,0 +1,411 @@
+package accessreview
+
+import (
+	"fmt"
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/middleware"
+	"gin-scalable-api/pkg/logger"
+	"gin-scalable-api/pkg/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler struct
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Handler methods
+
+// @Summary      Mulai access review
+// @Description  Memulai kampanye access review untuk perusahaan, opsional dipersempit ke cabang dan/atau peran. Semua assignment user role dan unit role dalam cakupan disalin sebagai item dan dibagikan ke reviewer: admin unit, lalu admin cabang, lalu admin perusahaan. Hanya untuk COMPANY_ADMIN perusahaan tersebut atau SUPER_ADMIN
+// @Tags         Access Reviews
+// @Accept       json
+// @Produce      json
+// @Param        campaign  body      accessreview.CreateCampaignRequest  true  "Data kampanye"
+// @Success      201       {object}  response.Response{data=accessreview.CampaignResponse}  "Access review berhasil dimulai"
+// @Failure      400       {object}  response.Response  "Bad request"
+// @Failure      403       {object}  response.Response  "Bukan admin perusahaan"
+// @Failure      404       {object}  response.Response  "Perusahaan, cabang, atau peran tidak ditemukan"
+// @Failure      422       {object}  response.Response  "Tidak ada assignment dalam cakupan"
+// @Router       /api/v1/access-reviews [post]
+// @Security     BearerAuth
+func (h *Handler) CreateCampaign(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*CreateCampaignRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.CreateCampaign(userID, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusCreated, constants.MsgAccessReviewCreated, result)
+}
+
+// @Summary      Daftar access review
+// @Description  Dengan company_id mengembalikan semua kampanye perusahaan (admin perusahaan). Tanpa company_id mengembalikan kampanye yang memiliki item untuk direview oleh pengguna
+// @Tags         Access Reviews
+// @Produce      json
+// @Param        company_id  query     int     false  "Company ID"
+// @Param        status      query     string  false  "open atau closed"
+// @Param        limit       query     int     false  "Jumlah data (default 10, maks 100)"
+// @Param        offset      query     int     false  "Offset"
+// @Success      200         {object}  response.Response{data=accessreview.CampaignListResponse}  "Daftar access review berhasil diambil"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      403         {object}  response.Response  "Bukan admin perusahaan"
+// @Router       /api/v1/access-reviews [get]
+// @Security     BearerAuth
+func (h *Handler) GetCampaigns(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CampaignListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", err.Error())
+		return
+	}
+
+	result, err := h.service.GetCampaigns(userID, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAccessReviewsList, result)
+}
+
+// @Summary      Detail access review
+// @Description  Mengambil kampanye beserta progresnya. Admin perusahaan melihat progres per reviewer, reviewer hanya progres item miliknya
+// @Tags         Access Reviews
+// @Produce      json
+// @Param        id   path      int  true  "Access review ID"
+// @Success      200  {object}  response.Response{data=accessreview.CampaignResponse}  "Access review berhasil diambil"
+// @Failure      403  {object}  response.Response  "Bukan admin perusahaan atau reviewer"
+// @Failure      404  {object}  response.Response  "Access review tidak ditemukan"
+// @Router       /api/v1/access-reviews/{id} [get]
+// @Security     BearerAuth
+func (h *Handler) GetCampaign(c *gin.Context) {
+	id, userID, ok := campaignParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetCampaign(userID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAccessReviewRetrieved, result)
+}
+
+// @Summary      Item access review
+// @Description  Mengambil item kampanye. Admin perusahaan melihat semua item dan dapat memfilter per reviewer, reviewer hanya item yang ditugaskan kepadanya
+// @Tags         Access Reviews
+// @Produce      json
+// @Param        id           path      int     true   "Access review ID"
+// @Param        reviewer_id  query     int     false  "Filter reviewer (admin perusahaan)"
+// @Param        decision     query     string  false  "pending, certified, atau revoked"
+// @Param        limit        query     int     false  "Jumlah data (default 50, maks 500)"
+// @Param        offset       query     int     false  "Offset"
+// @Success      200          {object}  response.Response{data=accessreview.ItemListResponse}  "Item access review berhasil diambil"
+// @Failure      400          {object}  response.Response  "Bad request"
+// @Failure      403          {object}  response.Response  "Bukan admin perusahaan atau reviewer"
+// @Failure      404          {object}  response.Response  "Access review tidak ditemukan"
+// @Router       /api/v1/access-reviews/{id}/items [get]
+// @Security     BearerAuth
+func (h *Handler) GetItems(c *gin.Context) {
+	id, userID, ok := campaignParams(c)
+	if !ok {
+		return
+	}
+
+	var req ItemListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", err.Error())
+		return
+	}
+
+	result, err := h.service.GetItems(userID, id, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAccessReviewItemsList, result)
+}
+
+// @Summary      Putuskan item access review
+// @Description  Reviewer men-certify atau me-revoke satu atau beberapa item yang ditugaskan kepadanya. Keputusan dapat diubah selama kampanye masih open; assignment yang di-revoke dihapus saat kampanye ditutup
+// @Tags         Access Reviews
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                                 true  "Access review ID"
+// @Param        decision  body      accessreview.DecideItemsRequest     true  "Item dan keputusan"
+// @Success      200       {object}  response.Response{data=accessreview.DecideItemsResponse}  "Keputusan berhasil disimpan"
+// @Failure      400       {object}  response.Response  "Bad request"
+// @Failure      403       {object}  response.Response  "Item bukan milik reviewer"
+// @Failure      404       {object}  response.Response  "Access review tidak ditemukan"
+// @Failure      422       {object}  response.Response  "Access review sudah ditutup"
+// @Router       /api/v1/access-reviews/{id}/decisions [post]
+// @Security     BearerAuth
+func (h *Handler) DecideItems(c *gin.Context) {
+	id, userID, ok := campaignParams(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*DecideItemsRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.DecideItems(userID, id, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAccessReviewDecided, result)
+}
+
+// @Summary      Alihkan reviewer item
+// @Description  Memindahkan item yang belum diputuskan ke reviewer lain di perusahaan yang sama. Pengguna tidak dapat me-review aksesnya sendiri
+// @Tags         Access Reviews
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                                true  "Access review ID"
+// @Param        item_id   path      int                                true  "Item ID"
+// @Param        reviewer  body      accessreview.ReassignItemRequest   true  "Reviewer baru"
+// @Success      200       {object}  response.Response{data=accessreview.ItemResponse}  "Reviewer berhasil dialihkan"
+// @Failure      400       {object}  response.Response  "Reviewer tidak valid"
+// @Failure      403       {object}  response.Response  "Bukan admin perusahaan"
+// @Failure      404       {object}  response.Response  "Access review atau item tidak ditemukan"
+// @Failure      422       {object}  response.Response  "Item sudah diputuskan atau kampanye ditutup"
+// @Router       /api/v1/access-reviews/{id}/items/{item_id}/reviewer [put]
+// @Security     BearerAuth
+func (h *Handler) ReassignItem(c *gin.Context) {
+	id, userID, ok := campaignParams(c)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.ParseInt(c.Param("item_id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid item ID")
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*ReassignItemRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.ReassignItem(userID, id, itemID, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAccessReviewReassigned, result)
+}
+
+// @Summary      Tutup access review
+// @Description  Menutup kampanye dan menghapus assignment yang di-revoke. Pengguna yang kehilangan assignment langsung kehilangan sesinya. Item yang belum diputuskan tetap mempertahankan aksesnya
+// @Tags         Access Reviews
+// @Produce      json
+// @Param        id   path      int  true  "Access review ID"
+// @Success      200  {object}  response.Response{data=accessreview.CloseCampaignResponse}  "Access review berhasil ditutup"
+// @Failure      403  {object}  response.Response  "Bukan admin perusahaan"
+// @Failure      404  {object}  response.Response  "Access review tidak ditemukan"
+// @Failure      422  {object}  response.Response  "Access review sudah ditutup"
+// @Router       /api/v1/access-reviews/{id}/close [post]
+// @Security     BearerAuth
+func (h *Handler) CloseCampaign(c *gin.Context) {
+	id, userID, ok := campaignParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.CloseCampaign(userID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAccessReviewClosed, result)
+}
+
+// @Summary      Export laporan access review
+// @Description  Mengunduh laporan kampanye dengan satu baris per item beserta reviewer, keputusan, komentar, dan waktu penghapusan. Hanya untuk admin perusahaan
+// @Tags         Access Reviews
+// @Produce      text/csv
+// @Produce      json
+// @Param        id      path      int     true   "Access review ID"
+// @Param        format  query     string  false  "csv (default) atau json"
+// @Success      200     {file}    file  "Laporan access review"
+// @Failure      400     {object}  response.Response  "Format tidak valid"
+// @Failure      403     {object}  response.Response  "Bukan admin perusahaan"
+// @Failure      404     {object}  response.Response  "Access review tidak ditemukan"
+// @Router       /api/v1/access-reviews/{id}/report [get]
+// @Security     BearerAuth
+func (h *Handler) ExportReport(c *gin.Context) {
+	id, userID, ok := campaignParams(c)
+	if !ok {
+		return
+	}
+
+	var req ReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", err.Error())
+		return
+	}
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = ReportFormatCSV
+	}
+	if format != ReportFormatCSV && format != ReportFormatJSON {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", "format harus csv atau json (invalid)")
+		return
+	}
+
+	report, err := h.service.Report(userID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Failed to export access review", err.Error())
+		return
+	}
+
+	if format == ReportFormatJSON {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", ReportFileName(id, format)))
+		response.Success(c, http.StatusOK, constants.MsgAccessReviewReport, report)
+		return
+	}
+
+	data, err := ReportCSV(report)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Access review %d report failed: %v", id, err))
+		response.Error(c, http.StatusInternalServerError, "Failed to export access review", err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", ReportFileName(id, format)))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// campaignParams reads the campaign ID and the caller
+func campaignParams(c *gin.Context) (int64, int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid access review ID")
+		return 0, 0, false
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return id, userID, true
+}
+
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
+// Route registration
+
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
+	reviews := router.Group("/access-reviews")
+	{
+		// GET /api/v1/access-reviews - List the campaigns of a company, or the caller's as reviewer
+		reviews.GET("", handler.GetCampaigns)
+
+		// POST /api/v1/access-reviews - Start a campaign and snapshot the assignments in scope
+		reviews.POST("",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &CreateCampaignRequest{},
+			}),
+			handler.CreateCampaign,
+		)
+
+		// GET /api/v1/access-reviews/:id - Get a campaign with its progress
+		reviews.GET("/:id", handler.GetCampaign)
+
+		// GET /api/v1/access-reviews/:id/items - List the items of a campaign
+		reviews.GET("/:id/items", handler.GetItems)
+
+		// POST /api/v1/access-reviews/:id/decisions - Certify or revoke items
+		reviews.POST("/:id/decisions",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &DecideItemsRequest{},
+			}),
+			handler.DecideItems,
+		)
+
+		// PUT /api/v1/access-reviews/:id/items/:item_id/reviewer - Reassign an item
+		reviews.PUT("/:id/items/:item_id/reviewer",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &ReassignItemRequest{},
+			}),
+			handler.ReassignItem,
+		)
+
+		// POST /api/v1/access-reviews/:id/close - Close a campaign and remove revoked assignments
+		reviews.POST("/:id/close", handler.CloseCampaign)
+
+		// GET /api/v1/access-reviews/:id/report - Export the campaign report
+		reviews.GET("/:id/report", handler.ExportReport)
+	}
+}
@@ -0,0 +1,637 @@
+package accessreview
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"gin-scalable-api/pkg/audittrail"
+	"gin-scalable-api/pkg/logger"
+	"gin-scalable-api/pkg/rbac"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Service struct {
+	repo            *Repository
+	permissionCache *rbac.PermissionCache
+	sessions        rbac.SessionRevoker
+	auditRecorder   audittrail.Recorder
+}
+
+func NewService(repo *Repository, permissionCache *rbac.PermissionCache, sessions rbac.SessionRevoker, auditRecorder audittrail.Recorder) *Service {
+	return &Service{
+		repo:            repo,
+		permissionCache: permissionCache,
+		sessions:        sessions,
+		auditRecorder:   auditRecorder,
+	}
+}
+
+// Campaign administration
+
+// CreateCampaign snapshots the assignments in scope and gives each one a reviewer: an
+// admin of its unit, branch or company, in that order, and otherwise the admin starting
+// the campaign. Nobody reviews their own access.
+func (s *Service) CreateCampaign(adminID int64, req *CreateCampaignRequest) (*CampaignResponse, error) {
+	if err := s.requireCompanyAdmin(adminID, req.CompanyID); err != nil {
+		return nil, err
+	}
+	if req.BranchID != nil {
+		exists, err := s.repo.BranchBelongsToCompany(*req.BranchID, req.CompanyID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, errors.New("cabang tidak ditemukan di perusahaan ini (branch not found)")
+		}
+	}
+	if req.RoleID != nil {
+		exists, err := s.repo.RoleExists(*req.RoleID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("peran dengan ID %d tidak ditemukan (role not found)", *req.RoleID)
+		}
+	}
+	if req.DueAt != nil && !req.DueAt.After(time.Now()) {
+		return nil, errors.New("due_at harus di masa depan (invalid due_at)")
+	}
+
+	items, err := s.repo.SnapshotAssignments(req.CompanyID, req.BranchID, req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, errors.New("tidak ada assignment dalam cakupan ini, access review tidak dapat dimulai (cannot start)")
+	}
+
+	admins, err := s.repo.AdminAssignments(req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		var subjectID int64
+		if item.UserID != nil {
+			subjectID = *item.UserID
+		}
+		reviewerID, ok := rbac.ChooseReviewer(admins, item.CompanyID, item.BranchID, item.UnitID, subjectID)
+		if !ok {
+			// The starting admin is left with its own access; a reassignment resolves it
+			reviewerID = adminID
+		}
+		item.ReviewerID = &reviewerID
+	}
+
+	campaign := &Campaign{
+		Name:      strings.TrimSpace(req.Name),
+		CompanyID: req.CompanyID,
+		BranchID:  req.BranchID,
+		RoleID:    req.RoleID,
+		DueAt:     req.DueAt,
+		CreatedBy: &adminID,
+	}
+	if err := s.repo.Create(campaign, items); err != nil {
+		return nil, err
+	}
+
+	audittrail.RecordChange(s.auditRecorder, adminID, "access_review_started", "access_review", campaign.ID,
+		map[string]interface{}{}, map[string]interface{}{
+			"name":       campaign.Name,
+			"company_id": campaign.CompanyID,
+			"branch_id":  campaign.BranchID,
+			"role_id":    campaign.RoleID,
+			"items":      len(items),
+		})
+
+	return s.campaignResponse(campaign.ID, 0)
+}
+
+func (s *Service) GetCampaigns(userID int64, req *CampaignListRequest) (*CampaignListResponse, error) {
+	switch req.Status {
+	case "", StatusOpen, StatusClosed:
+	default:
+		return nil, errors.New("status harus open atau closed (invalid status)")
+	}
+
+	// Company admins list the campaigns of their company, reviewers their own campaigns
+	var reviewerID int64
+	if req.CompanyID > 0 {
+		if err := s.requireCompanyAdmin(userID, req.CompanyID); err != nil {
+			return nil, err
+		}
+	} else {
+		reviewerID = userID
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	campaigns, err := s.repo.List(req.CompanyID, reviewerID, req.Status, req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.Count(req.CompanyID, reviewerID, req.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]*CampaignResponse, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		progress, err := s.repo.ReviewerProgress(campaign.ID)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, toCampaignResponse(campaign, progress, reviewerID))
+	}
+
+	return &CampaignListResponse{
+		Data:    data,
+		Total:   total,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+		HasMore: int64(req.Offset+req.Limit) < total,
+	}, nil
+}
+
+// GetCampaign returns a campaign with its progress: per reviewer for company admins,
+// the caller's own share for reviewers
+func (s *Service) GetCampaign(userID, id int64) (*CampaignResponse, error) {
+	campaign, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	isAdmin, err := s.requireParticipant(userID, campaign)
+	if err != nil {
+		return nil, err
+	}
+
+	var reviewerID int64
+	if !isAdmin {
+		reviewerID = userID
+	}
+	return s.campaignResponse(id, reviewerID)
+}
+
+// ReassignItem hands a pending item to another member of the company
+func (s *Service) ReassignItem(adminID, id, itemID int64, req *ReassignItemRequest) (*ItemResponse, error) {
+	campaign, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireCompanyAdmin(adminID, campaign.CompanyID); err != nil {
+		return nil, err
+	}
+
+	item, err := s.repo.GetItem(id, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.UserID != nil && *item.UserID == req.ReviewerID {
+		return nil, errors.New("pengguna tidak dapat me-review aksesnya sendiri (invalid reviewer)")
+	}
+
+	isMember, err := s.repo.IsCompanyMember(req.ReviewerID, campaign.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, errors.New("reviewer harus anggota perusahaan (invalid reviewer)")
+	}
+
+	if err := s.repo.Reassign(id, itemID, req.ReviewerID); err != nil {
+		return nil, err
+	}
+
+	audittrail.RecordChange(s.auditRecorder, adminID, "access_review_item_reassigned", "access_review", id,
+		map[string]interface{}{"item_id": itemID, "reviewer_id": item.ReviewerID},
+		map[string]interface{}{"item_id": itemID, "reviewer_id": req.ReviewerID})
+
+	reassigned, err := s.repo.GetItem(id, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return toItemResponse(reassigned), nil
+}
+
+// CloseCampaign ends a campaign and removes the assignments revoked in it. Undecided
+// items keep their access. The users who lost an assignment have their cached
+// permissions dropped and their sessions ended.
+func (s *Service) CloseCampaign(adminID, id int64) (*CloseCampaignResponse, error) {
+	campaign, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireCompanyAdmin(adminID, campaign.CompanyID); err != nil {
+		return nil, err
+	}
+
+	removed, alreadyGone, err := s.repo.Close(id, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.applyRemovals(adminID, id, removed)
+
+	audittrail.RecordChange(s.auditRecorder, adminID, "access_review_closed", "access_review", id,
+		map[string]interface{}{"status": StatusOpen},
+		map[string]interface{}{"status": StatusClosed, "removed": len(removed), "already_gone": alreadyGone})
+
+	result, err := s.campaignResponse(id, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &CloseCampaignResponse{Campaign: result, Removed: len(removed), AlreadyGone: alreadyGone}, nil
+}
+
+// Reviewing
+
+// GetItems lists the items of a campaign. Company admins see every item and may filter
+// by reviewer; reviewers see the items assigned to them.
+func (s *Service) GetItems(userID, id int64, req *ItemListRequest) (*ItemListResponse, error) {
+	switch req.Decision {
+	case "", DecisionPending, DecisionCertified, DecisionRevoked:
+	default:
+		return nil, errors.New("decision harus pending, certified, atau revoked (invalid decision)")
+	}
+
+	campaign, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	isAdmin, err := s.requireParticipant(userID, campaign)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		if req.ReviewerID > 0 && req.ReviewerID != userID {
+			return nil, errors.New("access denied: reviewers can only list their own items")
+		}
+		req.ReviewerID = userID
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+	if req.Limit > 500 {
+		req.Limit = 500
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	items, err := s.repo.ListItems(id, req.ReviewerID, req.Decision, req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountItems(id, req.ReviewerID, req.Decision)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]*ItemResponse, 0, len(items))
+	for _, item := range items {
+		data = append(data, toItemResponse(item))
+	}
+
+	return &ItemListResponse{
+		Data:    data,
+		Total:   total,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+		HasMore: int64(req.Offset+req.Limit) < total,
+	}, nil
+}
+
+// DecideItems certifies or revokes items assigned to the reviewer. Decisions can be
+// changed until the campaign closes.
+func (s *Service) DecideItems(reviewerID, id int64, req *DecideItemsRequest) (*DecideItemsResponse, error) {
+	decision := DecisionCertified
+	if req.Decision == "revoke" {
+		decision = DecisionRevoked
+	}
+
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	itemIDs := uniqueIDs(req.ItemIDs)
+	var comment *string
+	if trimmed := strings.TrimSpace(req.Comment); trimmed != "" {
+		comment = &trimmed
+	}
+
+	if err := s.repo.Decide(id, reviewerID, itemIDs, decision, comment); err != nil {
+		return nil, err
+	}
+
+	audittrail.RecordChange(s.auditRecorder, reviewerID, "access_review_decided", "access_review", id,
+		map[string]interface{}{}, map[string]interface{}{"item_ids": itemIDs, "decision": decision})
+
+	progress, err := s.repo.ReviewerProgress(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DecideItemsResponse{
+		Updated:  len(itemIDs),
+		Progress: sumProgress(progress, reviewerID),
+	}, nil
+}
+
+// Reporting
+
+// Report formats
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatJSON = "json"
+)
+
+var reportCSVHeader = []string{
+	"item_id", "kind", "assignment_id", "user_id", "user_name", "user_email", "role", "branch", "unit",
+	"valid_until", "reviewer_id", "reviewer_name", "decision", "comment", "decided_at", "removed_at",
+}
+
+// Report returns every item of a campaign with its decision, for company admins
+func (s *Service) Report(adminID, id int64) (*ReportResponse, error) {
+	campaign, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireCompanyAdmin(adminID, campaign.CompanyID); err != nil {
+		return nil, err
+	}
+
+	result, err := s.campaignResponse(id, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.ListItems(id, 0, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]*ItemResponse, 0, len(items))
+	for _, item := range items {
+		data = append(data, toItemResponse(item))
+	}
+
+	return &ReportResponse{
+		Campaign:    result,
+		Items:       data,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// ReportCSV renders a report as CSV with one row per item
+func ReportCSV(report *ReportResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(reportCSVHeader); err != nil {
+		return nil, err
+	}
+
+	for _, item := range report.Items {
+		record := []string{
+			strconv.FormatInt(item.ID, 10),
+			item.Kind,
+			strconv.FormatInt(item.AssignmentID, 10),
+			formatOptionalInt(item.UserID),
+			csvSafe(formatOptionalString(item.UserName)),
+			csvSafe(formatOptionalString(item.UserEmail)),
+			csvSafe(item.RoleName),
+			csvSafe(formatOptionalString(item.BranchName)),
+			csvSafe(formatOptionalString(item.UnitName)),
+			formatOptionalString(item.ValidUntil),
+			formatOptionalInt(item.ReviewerID),
+			csvSafe(formatOptionalString(item.ReviewerName)),
+			item.Decision,
+			csvSafe(formatOptionalString(item.Comment)),
+			formatOptionalString(item.DecidedAt),
+			formatOptionalString(item.RemovedAt),
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// ReportFileName names the export of a campaign report
+func ReportFileName(id int64, format string) string {
+	return fmt.Sprintf("access-review-%d-%s.%s", id, time.Now().Format("20060102-150405"), format)
+}
+
+// Helpers
+
+func (s *Service) requireCompanyAdmin(adminID, companyID int64) error {
+	exists, err := s.repo.CompanyExists(companyID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("perusahaan tidak ditemukan (company not found)")
+	}
+
+	isAdmin, err := s.repo.IsCompanyAdmin(adminID, companyID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("access denied: only company admins can manage access reviews")
+	}
+	return nil
+}
+
+// requireParticipant checks that a user is a company admin of the campaign or one of
+// its reviewers, and reports which
+func (s *Service) requireParticipant(userID int64, campaign *Campaign) (bool, error) {
+	isAdmin, err := s.repo.IsCompanyAdmin(userID, campaign.CompanyID)
+	if err != nil {
+		return false, err
+	}
+	if isAdmin {
+		return true, nil
+	}
+
+	assigned, err := s.repo.CountItems(campaign.ID, userID, "")
+	if err != nil {
+		return false, err
+	}
+	if assigned == 0 {
+		return false, errors.New("access denied: not a reviewer of this access review")
+	}
+	return false, nil
+}
+
+// applyRemovals drops the cached permissions and ends the sessions of the users who lost
+// an assignment, and records each removal in the audit trail
+func (s *Service) applyRemovals(adminID, campaignID int64, removed []RemovedAssignment) {
+	revoked := make(map[int64]bool)
+	for _, assignment := range removed {
+		var userIDs []int64
+		if assignment.UserID != nil {
+			userIDs = []int64{*assignment.UserID}
+		} else if assignment.UnitID != nil {
+			unitUserIDs, err := s.permissionCache.UnitUserIDs(*assignment.UnitID)
+			if err != nil {
+				logger.Error(fmt.Sprintf("Failed to resolve users of revoked %s %d: %v", assignment.Kind, assignment.AssignmentID, err))
+			}
+			userIDs = unitUserIDs
+		}
+
+		if err := s.permissionCache.InvalidateUsers(userIDs...); err != nil {
+			logger.Error(fmt.Sprintf("Failed to invalidate permissions of revoked %s %d: %v", assignment.Kind, assignment.AssignmentID, err))
+		}
+		for _, userID := range userIDs {
+			if revoked[userID] || s.sessions == nil {
+				continue
+			}
+			revoked[userID] = true
+			if err := s.sessions.RevokeAllUserTokens(userID); err != nil {
+				logger.Error(fmt.Sprintf("Failed to revoke sessions of user %d: %v", userID, err))
+			}
+		}
+
+		audittrail.RecordChange(s.auditRecorder, adminID, assignment.Kind+"_revoked", assignment.Kind, assignment.AssignmentID,
+			map[string]interface{}{
+				"user_id":          assignment.UserID,
+				"unit_id":          assignment.UnitID,
+				"role_id":          assignment.RoleID,
+				"access_review_id": campaignID,
+			}, map[string]interface{}{})
+	}
+}
+
+// campaignResponse loads a campaign with its progress; a reviewer ID limits the
+// progress to that reviewer's items
+func (s *Service) campaignResponse(id, reviewerID int64) (*CampaignResponse, error) {
+	campaign, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.repo.ReviewerProgress(id)
+	if err != nil {
+		return nil, err
+	}
+	return toCampaignResponse(campaign, progress, reviewerID), nil
+}
+
+func toCampaignResponse(campaign *Campaign, progress []Progress, reviewerID int64) *CampaignResponse {
+	result := &CampaignResponse{
+		ID:          campaign.ID,
+		Name:        campaign.Name,
+		CompanyID:   campaign.CompanyID,
+		CompanyName: campaign.CompanyName,
+		BranchID:    campaign.BranchID,
+		RoleID:      campaign.RoleID,
+		Status:      campaign.Status,
+		DueAt:       formatTime(campaign.DueAt),
+		CreatedBy:   campaign.CreatedBy,
+		ClosedBy:    campaign.ClosedBy,
+		ClosedAt:    formatTime(campaign.ClosedAt),
+		CreatedAt:   campaign.CreatedAt.Format(time.RFC3339),
+		Progress:    sumProgress(progress, reviewerID),
+	}
+	if reviewerID == 0 {
+		result.Reviewers = progress
+	}
+	return result
+}
+
+// sumProgress adds up the progress of all reviewers, or returns one reviewer's
+func sumProgress(progress []Progress, reviewerID int64) Progress {
+	var total Progress
+	for _, p := range progress {
+		if reviewerID > 0 && (p.ReviewerID == nil || *p.ReviewerID != reviewerID) {
+			continue
+		}
+		total.Total += p.Total
+		total.Pending += p.Pending
+		total.Certified += p.Certified
+		total.Revoked += p.Revoked
+	}
+	return total
+}
+
+func toItemResponse(item *Item) *ItemResponse {
+	return &ItemResponse{
+		ID:           item.ID,
+		Kind:         item.Kind,
+		AssignmentID: item.AssignmentID,
+		UserID:       item.UserID,
+		UserName:     item.UserName,
+		UserEmail:    item.UserEmail,
+		RoleID:       item.RoleID,
+		RoleName:     item.RoleName,
+		BranchID:     item.BranchID,
+		BranchName:   item.BranchName,
+		UnitID:       item.UnitID,
+		UnitName:     item.UnitName,
+		ValidUntil:   formatTime(item.ValidUntil),
+		ReviewerID:   item.ReviewerID,
+		ReviewerName: item.ReviewerName,
+		Decision:     item.Decision,
+		Comment:      item.Comment,
+		DecidedBy:    item.DecidedBy,
+		DecidedAt:    formatTime(item.DecidedAt),
+		RemovedAt:    formatTime(item.RemovedAt),
+	}
+}
+
+// csvSafe keeps spreadsheet applications from evaluating user controlled values as formulas
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
+
+func formatOptionalInt(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+func formatOptionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+	return unique
+}
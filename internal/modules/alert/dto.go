@@ -0,0 +1,47 @@
+package alert
+
+// AlertListRequest filters the security alerts
+type AlertListRequest struct {
+	CompanyID *int64 `form:"company_id"`
+	UserID    *int64 `form:"user_id"`
+	Status    string `form:"status"`
+	Severity  string `form:"severity"`
+	Rule      string `form:"rule"`
+	Limit     int    `form:"limit"`
+	Offset    int    `form:"offset"`
+}
+
+// ResolveAlertRequest closes an alert with an optional note on the outcome
+type ResolveAlertRequest struct {
+	Note string `json:"note" validate:"omitempty,max=2000"`
+}
+
+type AlertResponse struct {
+	ID              int64                  `json:"id"`
+	Rule            string                 `json:"rule"`
+	Severity        string                 `json:"severity"`
+	Status          string                 `json:"status"`
+	Title           string                 `json:"title"`
+	UserID          *int64                 `json:"user_id"`
+	CompanyID       *int64                 `json:"company_id"`
+	IPAddress       *string                `json:"ip_address"`
+	Details         map[string]interface{} `json:"details"`
+	Occurrences     int                    `json:"occurrences"`
+	FirstAuditLogID *int64                 `json:"first_audit_log_id"`
+	LastAuditLogID  *int64                 `json:"last_audit_log_id"`
+	FirstSeenAt     string                 `json:"first_seen_at"`
+	LastSeenAt      string                 `json:"last_seen_at"`
+	AcknowledgedBy  *int64                 `json:"acknowledged_by"`
+	AcknowledgedAt  *string                `json:"acknowledged_at"`
+	ResolvedBy      *int64                 `json:"resolved_by"`
+	ResolvedAt      *string                `json:"resolved_at"`
+	ResolutionNote  *string                `json:"resolution_note"`
+}
+
+type AlertListResponse struct {
+	Data    []*AlertResponse `json:"data"`
+	Total   int64            `json:"total"`
+	Limit   int              `json:"limit"`
+	Offset  int              `json:"offset"`
+	HasMore bool             `json:"has_more"`
+}
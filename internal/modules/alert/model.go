@@ -0,0 +1,59 @@
+package alert
+
+import (
+	"time"
+)
+
+// Alert statuses
+const (
+	StatusOpen         = "open"
+	StatusAcknowledged = "acknowledged"
+	StatusResolved     = "resolved"
+)
+
+// Alert severities, as raised by the anomaly detector
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// SecurityAlert is an anomaly raised by a detector rule. Repeated detections of the same
+// anomaly increase Occurrences until the alert is resolved.
+type SecurityAlert struct {
+	ID              int64                  `json:"id" db:"id"`
+	Rule            string                 `json:"rule" db:"rule"`
+	Severity        string                 `json:"severity" db:"severity"`
+	Status          string                 `json:"status" db:"status"`
+	Title           string                 `json:"title" db:"title"`
+	UserID          *int64                 `json:"user_id" db:"user_id"`
+	CompanyID       *int64                 `json:"company_id" db:"company_id"`
+	IPAddress       *string                `json:"ip_address" db:"ip_address"`
+	Details         map[string]interface{} `json:"details" db:"details"`
+	Occurrences     int                    `json:"occurrences" db:"occurrences"`
+	FirstAuditLogID *int64                 `json:"first_audit_log_id" db:"first_audit_log_id"`
+	LastAuditLogID  *int64                 `json:"last_audit_log_id" db:"last_audit_log_id"`
+	FirstSeenAt     time.Time              `json:"first_seen_at" db:"first_seen_at"`
+	LastSeenAt      time.Time              `json:"last_seen_at" db:"last_seen_at"`
+	AcknowledgedBy  *int64                 `json:"acknowledged_by" db:"acknowledged_by"`
+	AcknowledgedAt  *time.Time             `json:"acknowledged_at" db:"acknowledged_at"`
+	ResolvedBy      *int64                 `json:"resolved_by" db:"resolved_by"`
+	ResolvedAt      *time.Time             `json:"resolved_at" db:"resolved_at"`
+	ResolutionNote  *string                `json:"resolution_note" db:"resolution_note"`
+	CreatedAt       time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+func (SecurityAlert) TableName() string {
+	return "security_alerts"
+}
+
+// AlertFilter selects alerts; nil and empty fields do not filter
+type AlertFilter struct {
+	CompanyID *int64
+	UserID    *int64
+	Status    string
+	Severity  string
+	Rule      string
+}
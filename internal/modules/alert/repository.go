@@ -0,0 +1,200 @@
+package alert
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const alertSelect = `
+	SELECT id, rule, severity, status, title, user_id, company_id, ip_address, details, occurrences,
+		first_audit_log_id, last_audit_log_id, first_seen_at, last_seen_at, acknowledged_by, acknowledged_at,
+		resolved_by, resolved_at, resolution_note, created_at, updated_at
+	FROM security_alerts
+`
+
+func scanAlert(scanner interface{ Scan(...interface{}) error }) (*SecurityAlert, error) {
+	alert := &SecurityAlert{}
+	var details []byte
+
+	err := scanner.Scan(
+		&alert.ID, &alert.Rule, &alert.Severity, &alert.Status, &alert.Title, &alert.UserID, &alert.CompanyID,
+		&alert.IPAddress, &details, &alert.Occurrences, &alert.FirstAuditLogID, &alert.LastAuditLogID,
+		&alert.FirstSeenAt, &alert.LastSeenAt, &alert.AcknowledgedBy, &alert.AcknowledgedAt,
+		&alert.ResolvedBy, &alert.ResolvedAt, &alert.ResolutionNote, &alert.CreatedAt, &alert.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(details, &alert.Details); err != nil {
+		return nil, fmt.Errorf("failed to parse alert details: %w", err)
+	}
+
+	return alert, nil
+}
+
+// IsSuperAdmin checks whether a user holds the SUPER_ADMIN role
+func (r *Repository) IsSuperAdmin(userID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true AND r.name = 'SUPER_ADMIN'
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check super admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// IsCompanyAdmin checks whether a user may handle the alerts of a company
+func (r *Repository) IsCompanyAdmin(userID, companyID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true
+				AND (r.name = 'SUPER_ADMIN' OR (r.name = 'COMPANY_ADMIN' AND ur.company_id = $2))
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID, companyID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check company admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+func filterConditions(filter *AlertFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.CompanyID != nil {
+		add("company_id = $%d", *filter.CompanyID)
+	}
+	if filter.UserID != nil {
+		add("user_id = $%d", *filter.UserID)
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.Severity != "" {
+		add("severity = $%d", filter.Severity)
+	}
+	if filter.Rule != "" {
+		add("rule = $%d", filter.Rule)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// List retrieves alerts, most recently seen first
+func (r *Repository) List(filter *AlertFilter, limit, offset int) ([]*SecurityAlert, error) {
+	where, args := filterConditions(filter)
+	args = append(args, limit, offset)
+	query := alertSelect + where +
+		fmt.Sprintf(" ORDER BY last_seen_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get security alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []*SecurityAlert
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan security alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+// Count counts the alerts matching a filter
+func (r *Repository) Count(filter *AlertFilter) (int64, error) {
+	where, args := filterConditions(filter)
+
+	var count int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM security_alerts"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count security alerts: %w", err)
+	}
+	return count, nil
+}
+
+// GetByID retrieves an alert, nil when it does not exist
+func (r *Repository) GetByID(id int64) (*SecurityAlert, error) {
+	alert, err := scanAlert(r.db.QueryRow(alertSelect+" WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get security alert: %w", err)
+	}
+	return alert, nil
+}
+
+// Acknowledge marks an open alert as being handled
+func (r *Repository) Acknowledge(id, userID int64) error {
+	query := `
+		UPDATE security_alerts
+		SET status = 'acknowledged', acknowledged_by = $2, acknowledged_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'open'
+	`
+
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge security alert: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("alert tidak dalam status open, tidak dapat di-acknowledge (cannot acknowledge)")
+	}
+	return nil
+}
+
+// Resolve closes an open or acknowledged alert. Later detections of the same anomaly
+// raise a new alert.
+func (r *Repository) Resolve(id, userID int64, note string) error {
+	query := `
+		UPDATE security_alerts
+		SET status = 'resolved', resolved_by = $2, resolved_at = NOW(), resolution_note = NULLIF($3, ''),
+			acknowledged_by = COALESCE(acknowledged_by, $2), acknowledged_at = COALESCE(acknowledged_at, NOW()),
+			updated_at = NOW()
+		WHERE id = $1 AND status IN ('open', 'acknowledged')
+	`
+
+	result, err := r.db.Exec(query, id, userID, note)
+	if err != nil {
+		return fmt.Errorf("failed to resolve security alert: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("alert sudah diselesaikan, tidak dapat diselesaikan lagi (cannot resolve)")
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package alert
+
+import (
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/middleware"
+	"gin-scalable-api/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler struct
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Handler methods
+
+// @Summary      Daftar security alert
+// @Description  Mengambil alert hasil deteksi anomali (burst login gagal, login dari IP/user agent baru, mass delete, eskalasi hak akses, akses di luar jam kerja). Tanpa company_id hanya untuk SUPER_ADMIN; dengan company_id untuk COMPANY_ADMIN perusahaan tersebut
+// @Tags         Security Alerts
+// @Produce      json
+// @Param        company_id  query     int     false  "Company ID"
+// @Param        user_id     query     int     false  "User ID"
+// @Param        status      query     string  false  "open, acknowledged, atau resolved"
+// @Param        severity    query     string  false  "low, medium, high, atau critical"
+// @Param        rule        query     string  false  "Nama rule, misalnya failed_login_burst"
+// @Param        limit       query     int     false  "Jumlah data (default 20, maks 100)"
+// @Param        offset      query     int     false  "Offset"
+// @Success      200         {object}  response.Response{data=alert.AlertListResponse}  "Daftar alert berhasil diambil"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      403         {object}  response.Response  "Bukan admin"
+// @Router       /api/v1/alerts [get]
+// @Security     BearerAuth
+func (h *Handler) GetAlerts(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req AlertListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", err.Error())
+		return
+	}
+
+	result, err := h.service.GetAlerts(userID, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAlertsList, result)
+}
+
+// @Summary      Detail security alert
+// @Description  Mengambil detail sebuah alert
+// @Tags         Security Alerts
+// @Produce      json
+// @Param        id   path      int  true  "Alert ID"
+// @Success      200  {object}  response.Response{data=alert.AlertResponse}  "Alert berhasil diambil"
+// @Failure      403  {object}  response.Response  "Bukan admin"
+// @Failure      404  {object}  response.Response  "Alert tidak ditemukan"
+// @Router       /api/v1/alerts/{id} [get]
+// @Security     BearerAuth
+func (h *Handler) GetAlert(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid alert ID")
+		return
+	}
+
+	result, err := h.service.GetAlert(userID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAlertRetrieved, result)
+}
+
+// @Summary      Acknowledge security alert
+// @Description  Menandai alert yang masih open sedang ditangani. Deteksi berikutnya untuk anomali yang sama tetap digabung ke alert ini
+// @Tags         Security Alerts
+// @Produce      json
+// @Param        id   path      int  true  "Alert ID"
+// @Success      200  {object}  response.Response{data=alert.AlertResponse}  "Alert berhasil di-acknowledge"
+// @Failure      403  {object}  response.Response  "Bukan admin"
+// @Failure      404  {object}  response.Response  "Alert tidak ditemukan"
+// @Failure      422  {object}  response.Response  "Alert tidak dalam status open"
+// @Router       /api/v1/alerts/{id}/acknowledge [post]
+// @Security     BearerAuth
+func (h *Handler) AcknowledgeAlert(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid alert ID")
+		return
+	}
+
+	result, err := h.service.AcknowledgeAlert(userID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAlertAcknowledged, result)
+}
+
+// @Summary      Resolve security alert
+// @Description  Menutup alert dengan catatan hasil investigasi opsional. Deteksi berikutnya untuk anomali yang sama membuat alert baru
+// @Tags         Security Alerts
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int                        true  "Alert ID"
+// @Param        resolution  body      alert.ResolveAlertRequest  true  "Catatan penyelesaian"
+// @Success      200         {object}  response.Response{data=alert.AlertResponse}  "Alert berhasil diselesaikan"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      403         {object}  response.Response  "Bukan admin"
+// @Failure      404         {object}  response.Response  "Alert tidak ditemukan"
+// @Failure      422         {object}  response.Response  "Alert sudah diselesaikan"
+// @Router       /api/v1/alerts/{id}/resolve [post]
+// @Security     BearerAuth
+func (h *Handler) ResolveAlert(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid alert ID")
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*ResolveAlertRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.ResolveAlert(userID, id, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAlertResolved, result)
+}
+
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
+// Route registration
+
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
+	alerts := router.Group("/alerts")
+	{
+		// GET /api/v1/alerts - List security alerts
+		alerts.GET("", handler.GetAlerts)
+
+		// GET /api/v1/alerts/:id - Get a security alert
+		alerts.GET("/:id", handler.GetAlert)
+
+		// POST /api/v1/alerts/:id/acknowledge - Mark an alert as being investigated
+		alerts.POST("/:id/acknowledge", handler.AcknowledgeAlert)
+
+		// POST /api/v1/alerts/:id/resolve - Close an alert
+		alerts.POST("/:id/resolve",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &ResolveAlertRequest{},
+			}),
+			handler.ResolveAlert,
+		)
+	}
+}
@@ -0,0 +1,189 @@
+package alert
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// GetAlerts lists alerts. Super admins see all alerts, company admins the alerts of a
+// company they administer.
+func (s *Service) GetAlerts(actorID int64, req *AlertListRequest) (*AlertListResponse, error) {
+	filter := &AlertFilter{
+		CompanyID: req.CompanyID,
+		UserID:    req.UserID,
+		Status:    strings.ToLower(req.Status),
+		Severity:  strings.ToLower(req.Severity),
+		Rule:      strings.TrimSpace(req.Rule),
+	}
+
+	switch filter.Status {
+	case "", StatusOpen, StatusAcknowledged, StatusResolved:
+	default:
+		return nil, errors.New("status harus open, acknowledged, atau resolved (invalid status)")
+	}
+	switch filter.Severity {
+	case "", SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+	default:
+		return nil, errors.New("severity harus low, medium, high, atau critical (invalid severity)")
+	}
+
+	if filter.CompanyID != nil {
+		if err := s.requireCompanyAdmin(actorID, *filter.CompanyID); err != nil {
+			return nil, err
+		}
+	} else if err := s.requireSuperAdmin(actorID); err != nil {
+		return nil, err
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	alerts, err := s.repo.List(filter, req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.Count(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*AlertResponse, 0, len(alerts))
+	for _, alert := range alerts {
+		responses = append(responses, toAlertResponse(alert))
+	}
+
+	return &AlertListResponse{
+		Data:    responses,
+		Total:   total,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+		HasMore: int64(req.Offset+req.Limit) < total,
+	}, nil
+}
+
+func (s *Service) GetAlert(actorID, id int64) (*AlertResponse, error) {
+	alert, err := s.accessibleAlert(actorID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toAlertResponse(alert), nil
+}
+
+// AcknowledgeAlert marks an open alert as being investigated
+func (s *Service) AcknowledgeAlert(actorID, id int64) (*AlertResponse, error) {
+	if _, err := s.accessibleAlert(actorID, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Acknowledge(id, actorID); err != nil {
+		return nil, err
+	}
+
+	return s.GetAlert(actorID, id)
+}
+
+// ResolveAlert closes an alert; a new detection of the same anomaly raises a new alert
+func (s *Service) ResolveAlert(actorID, id int64, req *ResolveAlertRequest) (*AlertResponse, error) {
+	if _, err := s.accessibleAlert(actorID, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Resolve(id, actorID, strings.TrimSpace(req.Note)); err != nil {
+		return nil, err
+	}
+
+	return s.GetAlert(actorID, id)
+}
+
+// accessibleAlert loads an alert the actor may handle. Alerts without a company are
+// visible to super admins only.
+func (s *Service) accessibleAlert(actorID, id int64) (*SecurityAlert, error) {
+	alert, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, errors.New("alert tidak ditemukan (not found)")
+	}
+
+	if alert.CompanyID != nil {
+		err = s.requireCompanyAdmin(actorID, *alert.CompanyID)
+	} else {
+		err = s.requireSuperAdmin(actorID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+func (s *Service) requireSuperAdmin(actorID int64) error {
+	isAdmin, err := s.repo.IsSuperAdmin(actorID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("hanya super admin yang dapat melihat semua alert (access denied)")
+	}
+	return nil
+}
+
+func (s *Service) requireCompanyAdmin(actorID, companyID int64) error {
+	isAdmin, err := s.repo.IsCompanyAdmin(actorID, companyID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("hanya admin perusahaan yang dapat menangani alert perusahaan ini (access denied)")
+	}
+	return nil
+}
+
+func toAlertResponse(alert *SecurityAlert) *AlertResponse {
+	return &AlertResponse{
+		ID:              alert.ID,
+		Rule:            alert.Rule,
+		Severity:        alert.Severity,
+		Status:          alert.Status,
+		Title:           alert.Title,
+		UserID:          alert.UserID,
+		CompanyID:       alert.CompanyID,
+		IPAddress:       alert.IPAddress,
+		Details:         alert.Details,
+		Occurrences:     alert.Occurrences,
+		FirstAuditLogID: alert.FirstAuditLogID,
+		LastAuditLogID:  alert.LastAuditLogID,
+		FirstSeenAt:     alert.FirstSeenAt.Format(time.RFC3339),
+		LastSeenAt:      alert.LastSeenAt.Format(time.RFC3339),
+		AcknowledgedBy:  alert.AcknowledgedBy,
+		AcknowledgedAt:  formatOptionalTime(alert.AcknowledgedAt),
+		ResolvedBy:      alert.ResolvedBy,
+		ResolvedAt:      formatOptionalTime(alert.ResolvedAt),
+		ResolutionNote:  alert.ResolutionNote,
+	}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
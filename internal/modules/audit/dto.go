@@ -1,6 +1,8 @@
 package audit
 
 import (
+	"gin-scalable-api/pkg/audittrail"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -32,20 +34,61 @@ type CreateAuditLogRequest struct {
 }
 
 type AuditLogResponse struct {
-	ID           int64   `json:"id"`
-	UserID       *int64  `json:"user_id"`
-	UserIdentity *string `json:"user_identity"`
-	Action       string  `json:"action"`
-	Resource     string  `json:"resource"`
-	ResourceID   *string `json:"resource_id"`
-	Method       string  `json:"method"`
-	URL          string  `json:"url"`
-	Status       string  `json:"status"`
-	StatusCode   int     `json:"status_code"`
-	Message      string  `json:"message"`
-	CreatedAt    string  `json:"created_at"`
-	UserName     *string `json:"user_name,omitempty"`
-	UserEmail    *string `json:"user_email,omitempty"`
+	ID           int64               `json:"id"`
+	UserID       *int64              `json:"user_id"`
+	UserIdentity *string             `json:"user_identity"`
+	CompanyID    *int64              `json:"company_id,omitempty"`
+	Action       string              `json:"action"`
+	Resource     string              `json:"resource"`
+	ResourceID   *string             `json:"resource_id"`
+	Method       string              `json:"method"`
+	URL          string              `json:"url"`
+	Status       string              `json:"status"`
+	StatusCode   int                 `json:"status_code"`
+	Message      string              `json:"message"`
+	Changes      []audittrail.Change `json:"changes,omitempty"`
+	CreatedAt    string              `json:"created_at"`
+	UserName     *string             `json:"user_name,omitempty"`
+	UserEmail    *string             `json:"user_email,omitempty"`
+}
+
+type AuditExportRequest struct {
+	Format    string `form:"format"`
+	DateFrom  string `form:"date_from"`
+	DateTo    string `form:"date_to"`
+	UserID    *int64 `form:"user_id"`
+	CompanyID *int64 `form:"company_id"`
+	Action    string `form:"action"`
+	Resource  string `form:"resource"`
+	Status    string `form:"status"`
+}
+
+type RetentionPolicyRequest struct {
+	RetentionDays int `json:"retention_days" validate:"required,min=1,max=36500"`
+}
+
+type RetentionPolicyResponse struct {
+	CompanyID     int64  `json:"company_id"`
+	CompanyName   string `json:"company_name"`
+	RetentionDays int    `json:"retention_days"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+type RetentionPolicyListResponse struct {
+	// DefaultDays applies to companies without a policy, 0 keeps their logs forever
+	DefaultDays int                        `json:"default_days"`
+	Policies    []*RetentionPolicyResponse `json:"policies"`
+}
+
+type ChainVerifyRequest struct {
+	CompanyID *int64 `form:"company_id"`
+}
+
+type ChainVerifyResponse struct {
+	Valid      bool                      `json:"valid"`
+	Chains     []*audittrail.ChainReport `json:"chains"`
+	VerifiedAt string                    `json:"verified_at"`
 }
 
 type AuditListResponse struct {
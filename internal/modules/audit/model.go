@@ -1,20 +1,26 @@
 package audit
 
-import "time"
+import (
+	"time"
+
+	"gin-scalable-api/pkg/audittrail"
+)
 
 type AuditLog struct {
-	ID           int64     `json:"id" db:"id"`
-	UserID       *int64    `json:"user_id" db:"user_id"`
-	UserIdentity *string   `json:"user_identity" db:"user_identity"`
-	Action       string    `json:"action" db:"action"`
-	Resource     string    `json:"resource" db:"resource"`
-	ResourceID   *string   `json:"resource_id" db:"resource_id"`
-	Method       string    `json:"method" db:"method"`
-	URL          string    `json:"url" db:"url"`
-	Status       string    `json:"status" db:"status"`
-	StatusCode   int       `json:"status_code" db:"status_code"`
-	Message      string    `json:"message" db:"message"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ID           int64               `json:"id" db:"id"`
+	UserID       *int64              `json:"user_id" db:"user_id"`
+	UserIdentity *string             `json:"user_identity" db:"user_identity"`
+	CompanyID    *int64              `json:"company_id,omitempty" db:"company_id"`
+	Action       string              `json:"action" db:"action"`
+	Resource     string              `json:"resource" db:"resource"`
+	ResourceID   *string             `json:"resource_id" db:"resource_id"`
+	Method       string              `json:"method" db:"method"`
+	URL          string              `json:"url" db:"url"`
+	Status       string              `json:"status" db:"status"`
+	StatusCode   int                 `json:"status_code" db:"status_code"`
+	Message      string              `json:"message" db:"message"`
+	Changes      []audittrail.Change `json:"changes,omitempty" db:"-"`
+	CreatedAt    time.Time           `json:"created_at" db:"created_at"`
 }
 
 func (AuditLog) TableName() string {
@@ -26,3 +32,23 @@ type AuditLogWithUser struct {
 	UserName  *string `json:"user_name" db:"user_name"`
 	UserEmail *string `json:"user_email" db:"user_email"`
 }
+
+// ExportFilter selects the audit logs of an export; nil and empty fields do not filter
+type ExportFilter struct {
+	From      *time.Time
+	To        *time.Time
+	UserID    *int64
+	CompanyID *int64
+	Action    string
+	Resource  string
+	Success   *bool
+}
+
+// RetentionPolicy overrides the default retention period of a company's audit logs
+type RetentionPolicy struct {
+	CompanyID     int64     `json:"company_id" db:"company_id"`
+	CompanyName   string    `json:"company_name" db:"company_name"`
+	RetentionDays int       `json:"retention_days" db:"retention_days"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
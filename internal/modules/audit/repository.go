@@ -4,7 +4,11 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"gin-scalable-api/pkg/audittrail"
 )
 
 type Repository interface {
@@ -14,6 +18,13 @@ type Repository interface {
 	GetByUserID(userID int64, limit int) ([]*AuditLogWithUser, error)
 	GetByUserIdentity(identity string, limit int) ([]*AuditLogWithUser, error)
 	GetStats() (*AuditStatsResponse, error)
+	Stream(filter *ExportFilter, fn func(*AuditLogWithUser) error) error
+	IsSuperAdmin(userID int64) (bool, error)
+	IsCompanyAdmin(userID, companyID int64) (bool, error)
+	CompanyExists(companyID int64) (bool, error)
+	GetRetentionPolicies() ([]*RetentionPolicy, error)
+	UpsertRetentionPolicy(companyID int64, days int) (*RetentionPolicy, error)
+	DeleteRetentionPolicy(companyID int64) (bool, error)
 }
 
 type repository struct {
@@ -42,8 +53,10 @@ func (r *repository) Create(log *AuditLog) error {
 
 	resourceID := int64(0)
 	if log.ResourceID != nil {
-		// Convert string to int64 if needed
-		resourceID = 123 // placeholder
+		// resource_id is numeric; non-numeric IDs are kept in the message only
+		if parsed, err := strconv.ParseInt(*log.ResourceID, 10, 64); err == nil {
+			resourceID = parsed
+		}
 	}
 
 	success := log.Status == "success"
@@ -132,6 +145,7 @@ func (r *repository) GetAll(req *AuditListRequest) ([]*AuditLogWithUser, error)
 			resourceIDStr := fmt.Sprintf("%d", *resourceID)
 			log.ResourceID = &resourceIDStr
 		}
+		log.Changes = changesFromDetails(details)
 
 		logs = append(logs, log)
 	}
@@ -213,6 +227,7 @@ func (r *repository) GetByUserID(userID int64, limit int) ([]*AuditLogWithUser,
 			resourceIDStr := fmt.Sprintf("%d", *resourceID)
 			log.ResourceID = &resourceIDStr
 		}
+		log.Changes = changesFromDetails(details)
 
 		logs = append(logs, log)
 	}
@@ -285,6 +300,7 @@ func (r *repository) GetByUserIdentity(identity string, limit int) ([]*AuditLogW
 			resourceIDStr := fmt.Sprintf("%d", *resourceID)
 			log.ResourceID = &resourceIDStr
 		}
+		log.Changes = changesFromDetails(details)
 
 		logs = append(logs, log)
 	}
@@ -292,6 +308,118 @@ func (r *repository) GetByUserIdentity(identity string, limit int) ([]*AuditLogW
 	return logs, nil
 }
 
+// Stream passes the matching audit logs to fn, oldest first, without loading them all
+// into memory. Streaming stops at the first error returned by fn.
+func (r *repository) Stream(filter *ExportFilter, fn func(*AuditLogWithUser) error) error {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.From != nil {
+		addCondition("al.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("al.created_at < $%d", *filter.To)
+	}
+	if filter.UserID != nil {
+		addCondition("al.user_id = $%d", *filter.UserID)
+	}
+	if filter.CompanyID != nil {
+		addCondition("COALESCE(al.company_id, 0) = $%d", *filter.CompanyID)
+	}
+	if filter.Action != "" {
+		addCondition("al.action = $%d", filter.Action)
+	}
+	if filter.Resource != "" {
+		addCondition("al.resource = $%d", filter.Resource)
+	}
+	if filter.Success != nil {
+		addCondition("al.success = $%d", *filter.Success)
+	}
+
+	query := `SELECT al.id, al.user_id, al.company_id, al.action, al.resource, al.resource_id,
+			  al.details, al.success, al.created_at, u.name, u.email, u.user_identity
+			  FROM audit_logs al
+			  LEFT JOIN users u ON al.user_id = u.id`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY al.created_at, al.id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log := &AuditLogWithUser{}
+		var detailsJSON []byte
+		var resourceID *int64
+		var success bool
+
+		if err := rows.Scan(&log.ID, &log.UserID, &log.CompanyID, &log.Action, &log.Resource, &resourceID,
+			&detailsJSON, &success, &log.CreatedAt, &log.UserName, &log.UserEmail, &log.UserIdentity); err != nil {
+			return fmt.Errorf("failed to scan audit log: %w", err)
+		}
+
+		var details map[string]interface{}
+		if len(detailsJSON) > 0 {
+			json.Unmarshal(detailsJSON, &details)
+		}
+
+		if method, ok := details["method"].(string); ok {
+			log.Method = method
+		}
+		if url, ok := details["url"].(string); ok {
+			log.URL = url
+		}
+		if success {
+			log.Status = "success"
+		} else {
+			log.Status = "error"
+		}
+		if statusCode, ok := details["status_code"].(float64); ok {
+			log.StatusCode = int(statusCode)
+		}
+		if message, ok := details["message"].(string); ok {
+			log.Message = message
+		}
+		if resourceID != nil {
+			resourceIDStr := fmt.Sprintf("%d", *resourceID)
+			log.ResourceID = &resourceIDStr
+		}
+		log.Changes = changesFromDetails(details)
+
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// changesFromDetails extracts the field-level diff recorded by services, if any
+func changesFromDetails(details map[string]interface{}) []audittrail.Change {
+	raw, ok := details["changes"]
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var changes []audittrail.Change
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil
+	}
+	return changes
+}
+
 func (r *repository) GetStats() (*AuditStatsResponse, error) {
 	stats := &AuditStatsResponse{}
 
@@ -388,3 +516,109 @@ func (r *repository) GetStats() (*AuditStatsResponse, error) {
 
 	return stats, nil
 }
+
+// IsSuperAdmin checks whether a user holds the SUPER_ADMIN role
+func (r *repository) IsSuperAdmin(userID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true AND r.name = 'SUPER_ADMIN'
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check super admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// IsCompanyAdmin checks whether a user is COMPANY_ADMIN of the given company
+func (r *repository) IsCompanyAdmin(userID, companyID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND ur.company_id = $2 AND r.is_active = true AND r.name = 'COMPANY_ADMIN'
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID, companyID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check company admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// CompanyExists checks whether a company exists
+func (r *repository) CompanyExists(companyID int64) (bool, error) {
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM companies WHERE id = $1)`, companyID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check company: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *repository) GetRetentionPolicies() ([]*RetentionPolicy, error) {
+	rows, err := r.db.Query(`
+		SELECT p.company_id, c.name, p.retention_days, p.created_at, p.updated_at
+		FROM audit_retention_policies p
+		JOIN companies c ON c.id = p.company_id
+		ORDER BY c.name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []*RetentionPolicy
+	for rows.Next() {
+		policy := &RetentionPolicy{}
+		if err := rows.Scan(&policy.CompanyID, &policy.CompanyName, &policy.RetentionDays,
+			&policy.CreatedAt, &policy.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit retention policy: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+func (r *repository) UpsertRetentionPolicy(companyID int64, days int) (*RetentionPolicy, error) {
+	policy := &RetentionPolicy{CompanyID: companyID}
+	err := r.db.QueryRow(`
+		WITH upserted AS (
+			INSERT INTO audit_retention_policies (company_id, retention_days, created_at, updated_at)
+			VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+			ON CONFLICT (company_id) DO UPDATE
+			SET retention_days = EXCLUDED.retention_days, updated_at = CURRENT_TIMESTAMP
+			RETURNING company_id, retention_days, created_at, updated_at
+		)
+		SELECT c.name, u.retention_days, u.created_at, u.updated_at
+		FROM upserted u
+		JOIN companies c ON c.id = u.company_id
+	`, companyID, days).Scan(&policy.CompanyName, &policy.RetentionDays, &policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save audit retention policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+func (r *repository) DeleteRetentionPolicy(companyID int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM audit_retention_policies WHERE company_id = $1`, companyID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete audit retention policy: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete audit retention policy: %w", err)
+	}
+	return affected > 0, nil
+}
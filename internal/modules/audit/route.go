@@ -1,7 +1,10 @@
 package audit
 
 import (
+	"fmt"
 	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/middleware"
+	"gin-scalable-api/pkg/logger"
 	"gin-scalable-api/pkg/response"
 	"net/http"
 	"strconv"
@@ -172,6 +175,208 @@ func (h *Handler) GetAuditStats(c *gin.Context) {
 	response.Success(c, http.StatusOK, constants.MsgAuditStatsRetrieved, statsResponse)
 }
 
+// VerifyAuditChain godoc
+// @Summary      Verify audit log hash chain
+// @Description  Menghitung ulang hash chain audit log per company dan melaporkan record pertama yang diubah atau hilang, termasuk terhadap head chain yang diekspor ke AUDIT_ANCHOR_FILE. Tanpa company_id semua chain diverifikasi (chain 0 berisi entry tanpa company). Hanya untuk super admin.
+// @Tags         Audit
+// @Accept       json
+// @Produce      json
+// @Param        company_id  query     int  false  "Company ID (0 untuk entry tanpa company)"
+// @Success      200         {object}  response.Response{data=audit.ChainVerifyResponse}  "Verifikasi audit chain selesai"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      401         {object}  response.Response  "Unauthorized"
+// @Failure      403         {object}  response.Response  "Forbidden - hanya super admin"
+// @Failure      500         {object}  response.Response  "Internal server error"
+// @Router       /api/v1/audit/chain/verify [get]
+// @Security     BearerAuth
+func (h *Handler) VerifyAuditChain(c *gin.Context) {
+	var req ChainVerifyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", err.Error())
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.VerifyChain(userID, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Failed to verify audit chain", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAuditChainVerified, result)
+}
+
+// ExportAuditLogs godoc
+// @Summary      Export audit logs
+// @Description  Mengekspor audit logs sebagai CSV atau NDJSON secara streaming, diurutkan dari yang terlama. Super admin dapat mengekspor semua log, admin perusahaan hanya log perusahaannya (company_id wajib).
+// @Tags         Audit
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format      query     string  false  "csv (default) atau ndjson"
+// @Param        date_from   query     string  false  "Mulai tanggal (YYYY-MM-DD atau RFC3339)"
+// @Param        date_to     query     string  false  "Sampai tanggal, inklusif (YYYY-MM-DD atau RFC3339)"
+// @Param        user_id     query     int     false  "Filter by user ID"
+// @Param        company_id  query     int     false  "Filter by company ID (0 untuk entry tanpa company)"
+// @Param        action      query     string  false  "Filter by action"
+// @Param        resource    query     string  false  "Filter by resource"
+// @Param        status      query     string  false  "success atau error"
+// @Success      200         {file}    file  "File export audit logs"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      403         {object}  response.Response  "Forbidden"
+// @Failure      500         {object}  response.Response  "Internal server error"
+// @Router       /api/v1/audit/export [get]
+// @Security     BearerAuth
+func (h *Handler) ExportAuditLogs(c *gin.Context) {
+	var req AuditExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", err.Error())
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.service.PrepareExport(userID, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Failed to export audit logs", err.Error())
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName()))
+	c.Status(http.StatusOK)
+
+	// The status is already sent, a failure can only cut the export short
+	if err := export.Write(c.Writer); err != nil {
+		logger.Error(fmt.Sprintf("Audit log export failed: %v", err))
+	}
+}
+
+// GetRetentionPolicies godoc
+// @Summary      Get audit retention policies
+// @Description  Mendapatkan masa retensi audit log per perusahaan beserta default untuk perusahaan tanpa kebijakan. Hanya untuk super admin.
+// @Tags         Audit
+// @Produce      json
+// @Success      200  {object}  response.Response{data=audit.RetentionPolicyListResponse}  "Kebijakan retensi berhasil diambil"
+// @Failure      403  {object}  response.Response  "Forbidden - hanya super admin"
+// @Failure      500  {object}  response.Response  "Internal server error"
+// @Router       /api/v1/audit/retention [get]
+// @Security     BearerAuth
+func (h *Handler) GetRetentionPolicies(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetRetentionPolicies(userID)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Failed to get audit retention policies", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAuditRetentionRetrieved, result)
+}
+
+// SetRetentionPolicy godoc
+// @Summary      Set audit retention policy
+// @Description  Mengatur berapa hari audit log sebuah perusahaan disimpan. Log yang lebih lama diarsipkan ke file NDJSON terkompresi lalu dihapus oleh background job. Hanya untuk super admin.
+// @Tags         Audit
+// @Accept       json
+// @Produce      json
+// @Param        company_id  path      int                           true  "Company ID"
+// @Param        policy      body      audit.RetentionPolicyRequest  true  "Masa retensi"
+// @Success      200         {object}  response.Response{data=audit.RetentionPolicyResponse}  "Kebijakan retensi berhasil disimpan"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      403         {object}  response.Response  "Forbidden - hanya super admin"
+// @Failure      404         {object}  response.Response  "Perusahaan tidak ditemukan"
+// @Router       /api/v1/audit/retention/{company_id} [put]
+// @Security     BearerAuth
+func (h *Handler) SetRetentionPolicy(c *gin.Context) {
+	companyID, err := strconv.ParseInt(c.Param("company_id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid company ID", "Company ID must be a valid number")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*RetentionPolicyRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.SetRetentionPolicy(userID, companyID, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Failed to save audit retention policy", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAuditRetentionSaved, result)
+}
+
+// DeleteRetentionPolicy godoc
+// @Summary      Delete audit retention policy
+// @Description  Menghapus kebijakan retensi sebuah perusahaan sehingga default berlaku kembali. Hanya untuk super admin.
+// @Tags         Audit
+// @Produce      json
+// @Param        company_id  path      int  true  "Company ID"
+// @Success      200         {object}  response.Response  "Kebijakan retensi berhasil dihapus"
+// @Failure      403         {object}  response.Response  "Forbidden - hanya super admin"
+// @Failure      404         {object}  response.Response  "Kebijakan retensi tidak ditemukan"
+// @Router       /api/v1/audit/retention/{company_id} [delete]
+// @Security     BearerAuth
+func (h *Handler) DeleteRetentionPolicy(c *gin.Context) {
+	companyID, err := strconv.ParseInt(c.Param("company_id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid company ID", "Company ID must be a valid number")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRetentionPolicy(userID, companyID); err != nil {
+		response.ErrorWithAutoStatus(c, "Failed to delete audit retention policy", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAuditRetentionDeleted, nil)
+}
+
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
 // Route registration
 func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
 	audit := router.Group("/audit")
@@ -185,6 +390,26 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
 		// GET /api/v1/audit/stats - Get audit statistics
 		audit.GET("/stats", handler.GetAuditStats)
 
+		// GET /api/v1/audit/chain/verify - Verify the audit log hash chain
+		audit.GET("/chain/verify", handler.VerifyAuditChain)
+
+		// GET /api/v1/audit/export - Stream filtered audit logs as CSV or NDJSON
+		audit.GET("/export", handler.ExportAuditLogs)
+
+		// GET /api/v1/audit/retention - List audit retention policies
+		audit.GET("/retention", handler.GetRetentionPolicies)
+
+		// PUT /api/v1/audit/retention/:company_id - Set the retention period of a company
+		audit.PUT("/retention/:company_id",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &RetentionPolicyRequest{},
+			}),
+			handler.SetRetentionPolicy,
+		)
+
+		// DELETE /api/v1/audit/retention/:company_id - Return a company to the default retention
+		audit.DELETE("/retention/:company_id", handler.DeleteRetentionPolicy)
+
 		// GET /api/v1/audit/users/:userId/logs - Get user audit logs by ID
 		audit.GET("/users/:userId/logs", handler.GetUserAuditLogs)
 
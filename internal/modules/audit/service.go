@@ -1,13 +1,30 @@
 package audit
 
-import "time"
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/pkg/audittrail"
+	"gin-scalable-api/pkg/logger"
+)
 
 type Service struct {
-	repo Repository
+	repo   Repository
+	chains *audittrail.ChainStore
+	// retentionDays applies to companies without a retention policy
+	retentionDays int
 }
 
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repository, chains *audittrail.ChainStore, retentionDays int) *Service {
+	return &Service{repo: repo, chains: chains, retentionDays: retentionDays}
 }
 
 func (s *Service) GetAuditLogs(req *AuditListRequest) (*AuditListResponse, error) {
@@ -118,6 +135,231 @@ func (s *Service) GetAuditStats() (*AuditStatsResponse, error) {
 	return s.repo.GetStats()
 }
 
+// VerifyChain recomputes the hash chain of one company, or of all chains when no company
+// is given, and reports the first broken record of each chain
+func (s *Service) VerifyChain(actorID int64, req *ChainVerifyRequest) (*ChainVerifyResponse, error) {
+	isSuperAdmin, err := s.repo.IsSuperAdmin(actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !isSuperAdmin {
+		return nil, errors.New("hanya super admin yang dapat memverifikasi audit log (access denied)")
+	}
+
+	var reports []*audittrail.ChainReport
+	if req.CompanyID != nil {
+		if *req.CompanyID < 0 {
+			return nil, errors.New("company_id tidak valid (invalid)")
+		}
+		report, err := s.chains.Verify(*req.CompanyID)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	} else {
+		reports, err = s.chains.VerifyAll()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	valid := true
+	for _, report := range reports {
+		if !report.Valid {
+			valid = false
+			logger.Warning(fmt.Sprintf("Audit chain %d broken at seq %d: %s", report.CompanyID, report.Break.Seq, report.Break.Reason))
+		}
+	}
+
+	return &ChainVerifyResponse{
+		Valid:      valid,
+		Chains:     reports,
+		VerifiedAt: time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// PrepareExport validates an export request. Super admins export any logs, company
+// admins only the logs of their company.
+func (s *Service) PrepareExport(actorID int64, req *AuditExportRequest) (*AuditExport, error) {
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = ExportFormatCSV
+	}
+	if format != ExportFormatCSV && format != ExportFormatNDJSON {
+		return nil, errors.New("format harus csv atau ndjson (invalid)")
+	}
+
+	filter, err := exportFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkExportAccess(actorID, filter.CompanyID); err != nil {
+		return nil, err
+	}
+
+	return &AuditExport{repo: s.repo, format: format, filter: filter}, nil
+}
+
+func (s *Service) checkExportAccess(actorID int64, companyID *int64) error {
+	isSuperAdmin, err := s.repo.IsSuperAdmin(actorID)
+	if err != nil {
+		return err
+	}
+	if isSuperAdmin {
+		return nil
+	}
+
+	if companyID == nil {
+		return errors.New("company_id wajib diisi untuk admin perusahaan (required)")
+	}
+	isAdmin, err := s.repo.IsCompanyAdmin(actorID, *companyID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("hanya admin perusahaan yang dapat mengekspor audit log (access denied)")
+	}
+	return nil
+}
+
+func exportFilter(req *AuditExportRequest) (*ExportFilter, error) {
+	filter := &ExportFilter{
+		UserID:    req.UserID,
+		CompanyID: req.CompanyID,
+		Action:    strings.TrimSpace(req.Action),
+		Resource:  strings.TrimSpace(req.Resource),
+	}
+
+	if req.DateFrom != "" {
+		from, err := parseExportDate(req.DateFrom, false)
+		if err != nil {
+			return nil, errors.New("date_from tidak valid, gunakan YYYY-MM-DD atau RFC3339 (invalid)")
+		}
+		filter.From = &from
+	}
+	if req.DateTo != "" {
+		to, err := parseExportDate(req.DateTo, true)
+		if err != nil {
+			return nil, errors.New("date_to tidak valid, gunakan YYYY-MM-DD atau RFC3339 (invalid)")
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, errors.New("date_from harus sebelum date_to (invalid)")
+	}
+
+	switch strings.ToLower(req.Status) {
+	case "":
+	case constants.AuditSuccess:
+		success := true
+		filter.Success = &success
+	case constants.AuditError:
+		success := false
+		filter.Success = &success
+	default:
+		return nil, errors.New("status harus success atau error (invalid)")
+	}
+
+	return filter, nil
+}
+
+// parseExportDate accepts a date or an RFC3339 timestamp. A date as upper bound
+// includes the whole day.
+func parseExportDate(value string, upper bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// GetRetentionPolicies lists the companies with their own retention period
+func (s *Service) GetRetentionPolicies(actorID int64) (*RetentionPolicyListResponse, error) {
+	if err := s.requireSuperAdmin(actorID); err != nil {
+		return nil, err
+	}
+
+	policies, err := s.repo.GetRetentionPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*RetentionPolicyResponse, 0, len(policies))
+	for _, policy := range policies {
+		responses = append(responses, toRetentionPolicyResponse(policy))
+	}
+
+	return &RetentionPolicyListResponse{DefaultDays: s.retentionDays, Policies: responses}, nil
+}
+
+// SetRetentionPolicy sets how many days the audit logs of a company are kept before
+// they are archived
+func (s *Service) SetRetentionPolicy(actorID, companyID int64, req *RetentionPolicyRequest) (*RetentionPolicyResponse, error) {
+	if err := s.requireSuperAdmin(actorID); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repo.CompanyExists(companyID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("perusahaan tidak ditemukan (not found)")
+	}
+
+	policy, err := s.repo.UpsertRetentionPolicy(companyID, req.RetentionDays)
+	if err != nil {
+		return nil, err
+	}
+
+	return toRetentionPolicyResponse(policy), nil
+}
+
+// DeleteRetentionPolicy returns a company to the default retention period
+func (s *Service) DeleteRetentionPolicy(actorID, companyID int64) error {
+	if err := s.requireSuperAdmin(actorID); err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.DeleteRetentionPolicy(companyID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("kebijakan retensi tidak ditemukan (not found)")
+	}
+	return nil
+}
+
+func (s *Service) requireSuperAdmin(actorID int64) error {
+	isSuperAdmin, err := s.repo.IsSuperAdmin(actorID)
+	if err != nil {
+		return err
+	}
+	if !isSuperAdmin {
+		return errors.New("hanya super admin yang dapat mengelola retensi audit log (access denied)")
+	}
+	return nil
+}
+
+func toRetentionPolicyResponse(policy *RetentionPolicy) *RetentionPolicyResponse {
+	return &RetentionPolicyResponse{
+		CompanyID:     policy.CompanyID,
+		CompanyName:   policy.CompanyName,
+		RetentionDays: policy.RetentionDays,
+		CreatedAt:     policy.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     policy.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
 func toAuditLogResponse(log *AuditLogWithUser) *AuditLogResponse {
 	if log == nil {
 		return nil
@@ -127,6 +369,7 @@ func toAuditLogResponse(log *AuditLogWithUser) *AuditLogResponse {
 		ID:           log.ID,
 		UserID:       log.UserID,
 		UserIdentity: log.UserIdentity,
+		CompanyID:    log.CompanyID,
 		Action:       log.Action,
 		Resource:     log.Resource,
 		ResourceID:   log.ResourceID,
@@ -135,8 +378,129 @@ func toAuditLogResponse(log *AuditLogWithUser) *AuditLogResponse {
 		Status:       log.Status,
 		StatusCode:   log.StatusCode,
 		Message:      log.Message,
+		Changes:      log.Changes,
 		CreatedAt:    log.CreatedAt.Format(time.RFC3339),
 		UserName:     log.UserName,
 		UserEmail:    log.UserEmail,
 	}
 }
+
+// Export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// exportFlushEvery is the number of rows after which a streamed export is flushed
+const exportFlushEvery = 500
+
+var exportCSVHeader = []string{
+	"id", "created_at", "company_id", "user_id", "user_identity", "user_name", "user_email",
+	"action", "resource", "resource_id", "method", "url", "status", "status_code", "message",
+}
+
+// AuditExport is a validated export that is written to the response once its headers are sent
+type AuditExport struct {
+	repo   Repository
+	format string
+	filter *ExportFilter
+}
+
+func (e *AuditExport) ContentType() string {
+	if e.format == ExportFormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+func (e *AuditExport) FileName() string {
+	return fmt.Sprintf("audit-logs-%s.%s", time.Now().Format("20060102-150405"), e.format)
+}
+
+// Write streams the matching audit logs to w
+func (e *AuditExport) Write(w io.Writer) error {
+	flusher, _ := w.(http.Flusher)
+
+	var csvWriter *csv.Writer
+	var encoder *json.Encoder
+	if e.format == ExportFormatCSV {
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(exportCSVHeader); err != nil {
+			return err
+		}
+	} else {
+		encoder = json.NewEncoder(w)
+	}
+
+	rows := 0
+	err := e.repo.Stream(e.filter, func(log *AuditLogWithUser) error {
+		if csvWriter != nil {
+			if err := csvWriter.Write(csvRecord(log)); err != nil {
+				return err
+			}
+		} else if err := encoder.Encode(toAuditLogResponse(log)); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			if csvWriter != nil {
+				csvWriter.Flush()
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if flushErr := csvWriter.Error(); err == nil {
+			err = flushErr
+		}
+	}
+	return err
+}
+
+func csvRecord(log *AuditLogWithUser) []string {
+	return []string{
+		strconv.FormatInt(log.ID, 10),
+		log.CreatedAt.Format(time.RFC3339),
+		formatOptionalInt(log.CompanyID),
+		formatOptionalInt(log.UserID),
+		csvSafe(formatOptionalString(log.UserIdentity)),
+		csvSafe(formatOptionalString(log.UserName)),
+		csvSafe(formatOptionalString(log.UserEmail)),
+		csvSafe(log.Action),
+		csvSafe(log.Resource),
+		formatOptionalString(log.ResourceID),
+		log.Method,
+		csvSafe(log.URL),
+		log.Status,
+		strconv.Itoa(log.StatusCode),
+		csvSafe(log.Message),
+	}
+}
+
+// csvSafe keeps spreadsheet applications from evaluating user controlled values as formulas
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatOptionalInt(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+func formatOptionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
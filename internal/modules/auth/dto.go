@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"gin-scalable-api/pkg/token"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -42,8 +44,9 @@ type ForgotPasswordRequest struct {
 
 // Reset Password Request DTO
 type ResetPasswordRequest struct {
-	Token       string `json:"token" validate:"required"`
-	NewPassword string `json:"new_password" validate:"required,min=6"`
+	Token           string `json:"token" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,max=72"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
 }
 
 // Profile Request DTO
@@ -64,6 +67,57 @@ type LoginResponse struct {
 	TokenType    string      `json:"token_type"`
 	ExpiresIn    int64       `json:"expires_in"`
 	User         interface{} `json:"user"`
+
+	// Set instead of tokens when the login still needs a second factor
+	TwoFactorRequired      bool     `json:"two_factor_required,omitempty"`
+	TwoFactorSetupRequired bool     `json:"two_factor_setup_required,omitempty"`
+	PasswordChangeRequired bool     `json:"password_change_required,omitempty"`
+	ChallengeToken         string   `json:"challenge_token,omitempty"`
+	ChallengeExpiresIn     int64    `json:"challenge_expires_in,omitempty"`
+	RecoveryCodes          []string `json:"recovery_codes,omitempty"`
+}
+
+// Expired Password Change Request DTO (login step after an expired password)
+type ExpiredPasswordChangeRequest struct {
+	ChallengeToken  string `json:"challenge_token" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,max=72"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
+}
+
+// Two-Factor Challenge Request DTO (second login step)
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+// Two-Factor Verify Request DTO (second login step)
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,min=6,max=20"`
+}
+
+// Two-Factor Code Request DTO (TOTP code or recovery code of the current user)
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,min=6,max=20"`
+}
+
+// Two-Factor Setup Response DTO
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	Issuer          string `json:"issuer"`
+	AccountName     string `json:"account_name"`
+}
+
+// Two-Factor Status Response DTO
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// Recovery Codes Response DTO
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 // Refresh Token Response DTO
@@ -74,6 +128,12 @@ type RefreshTokenResponse struct {
 	ExpiresIn    int64  `json:"expires_in"`
 }
 
+// Session List Response DTO
+type SessionListResponse struct {
+	Sessions []token.SessionInfo `json:"sessions"`
+	Total    int                 `json:"total"`
+}
+
 // Register Response DTO
 type RegisterResponse struct {
 	User    interface{} `json:"user"`
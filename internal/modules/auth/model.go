@@ -28,6 +28,17 @@ type UserRole struct {
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// UserTwoFactor model for TOTP enrollment of a user
+type UserTwoFactor struct {
+	UserID       int64      `json:"user_id" db:"user_id"`
+	Secret       string     `json:"-" db:"secret"`
+	IsEnabled    bool       `json:"is_enabled" db:"is_enabled"`
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	EnabledAt    *time.Time `json:"enabled_at" db:"enabled_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
 func (User) TableName() string {
 	return "users"
 }
@@ -35,3 +46,7 @@ func (User) TableName() string {
 func (UserRole) TableName() string {
 	return "user_roles"
 }
+
+func (UserTwoFactor) TableName() string {
+	return "user_two_factor"
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"gin-scalable-api/pkg/model"
 	"time"
@@ -144,7 +145,7 @@ func (r *Repository) GetByIDWithRoles(id int64) (map[string]interface{}, error)
 				WHEN ur.branch_id IS NOT NULL THEN 'branch'
 				ELSE 'company'
 			END as assignment_level
-		FROM user_roles ur
+		FROM active_user_roles ur
 		JOIN roles r ON ur.role_id = r.id
 		JOIN companies c ON ur.company_id = c.id
 		LEFT JOIN branches b ON ur.branch_id = b.id
@@ -213,7 +214,7 @@ func (r *Repository) GetByIDWithRoles(id int64) (map[string]interface{}, error)
 func (r *Repository) GetUserModulesGroupedWithSubscription(userID int64) (map[string][][]string, error) {
 	// Get user's company ID
 	var companyID int64
-	err := r.db.QueryRow("SELECT company_id FROM user_roles WHERE user_id = $1 LIMIT 1", userID).Scan(&companyID)
+	err := r.db.QueryRow("SELECT company_id FROM active_user_roles WHERE user_id = $1 LIMIT 1", userID).Scan(&companyID)
 	if err != nil {
 		// If no company found, return empty modules (no fallback)
 		return make(map[string][][]string), nil
@@ -223,8 +224,9 @@ func (r *Repository) GetUserModulesGroupedWithSubscription(userID int64) (map[st
 	query := `
 		SELECT DISTINCT m.name, m.url, m.icon, m.description, m.category, m.parent_id,
 			CASE WHEN m.parent_id IS NULL THEN 0 ELSE 1 END as sort_order
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		JOIN plan_modules pm ON m.id = pm.module_id AND pm.is_included = true
 		JOIN subscriptions s ON pm.plan_id = s.plan_id
@@ -268,7 +270,7 @@ func (r *Repository) GetUserModulesGroupedWithSubscription(userID int64) (map[st
 func (r *Repository) GetUserApplicationsWithModules(userID int64) (map[string]interface{}, error) {
 	// Get user's company ID
 	var companyID int64
-	err := r.db.QueryRow("SELECT company_id FROM user_roles WHERE user_id = $1 LIMIT 1", userID).Scan(&companyID)
+	err := r.db.QueryRow("SELECT company_id FROM active_user_roles WHERE user_id = $1 LIMIT 1", userID).Scan(&companyID)
 	if err != nil {
 		return make(map[string]interface{}), nil
 	}
@@ -280,8 +282,9 @@ func (r *Repository) GetUserApplicationsWithModules(userID int64) (map[string]in
 			a.icon as app_icon, a.url as app_url, a.sort_order as app_sort_order,
 			m.name as module_name, m.url as module_url, m.icon as module_icon, 
 			m.description as module_description, m.category as module_category
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		JOIN applications a ON m.application_id = a.id
 		JOIN plan_modules pm ON m.id = pm.module_id AND pm.is_included = true
@@ -344,7 +347,7 @@ func (r *Repository) GetUserApplicationsWithModules(userID int64) (map[string]in
 func (r *Repository) GetUserModulesWithSubscription(userID int64) ([]string, error) {
 	// Get user's company ID
 	var companyID int64
-	err := r.db.QueryRow("SELECT company_id FROM user_roles WHERE user_id = $1 LIMIT 1", userID).Scan(&companyID)
+	err := r.db.QueryRow("SELECT company_id FROM active_user_roles WHERE user_id = $1 LIMIT 1", userID).Scan(&companyID)
 	if err != nil {
 		// If no company found, return empty modules (no fallback)
 		return []string{}, nil
@@ -353,8 +356,9 @@ func (r *Repository) GetUserModulesWithSubscription(userID int64) ([]string, err
 	// Query modules with subscription filtering
 	query := `
 		SELECT DISTINCT m.url
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		JOIN plan_modules pm ON m.id = pm.module_id AND pm.is_included = true
 		JOIN subscriptions s ON pm.plan_id = s.plan_id
@@ -391,7 +395,7 @@ func (r *Repository) GetUserModulesWithSubscription(userID int64) ([]string, err
 func (r *Repository) GetUserRoles(userID int64) ([]*UserRole, error) {
 	query := `
 		SELECT ur.id, ur.user_id, ur.role_id, ur.company_id, ur.branch_id, ur.unit_id
-		FROM user_roles ur
+		FROM active_user_roles ur
 		WHERE ur.user_id = $1
 		ORDER BY ur.id
 	`
@@ -436,12 +440,231 @@ func (r *Repository) Create(user *User) error {
 	return nil
 }
 
+// CreateAuditLog records a security event of the auth flow in audit_logs.
+// A userID of 0 stores the event without a user, e.g. a lockout of an IP address.
+func (r *Repository) CreateAuditLog(userID int64, action string, success bool, details map[string]interface{}) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_logs (user_id, action, resource, resource_id, details, success, created_at)
+		VALUES ($1, $2, 'auth', 0, $3, $4, CURRENT_TIMESTAMP)
+	`
+
+	auditUserID := sql.NullInt64{Int64: userID, Valid: userID != 0}
+	if _, err := r.db.Exec(query, auditUserID, action, detailsJSON, success); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+// GetTwoFactor retrieves the TOTP enrollment of a user, nil when the user never enrolled
+func (r *Repository) GetTwoFactor(userID int64) (*UserTwoFactor, error) {
+	twoFactor := &UserTwoFactor{}
+	query := `
+		SELECT user_id, secret, is_enabled, last_used_step, enabled_at, created_at, updated_at
+		FROM user_two_factor
+		WHERE user_id = $1
+	`
+
+	err := r.db.QueryRow(query, userID).Scan(
+		&twoFactor.UserID, &twoFactor.Secret, &twoFactor.IsEnabled, &twoFactor.LastUsedStep,
+		&twoFactor.EnabledAt, &twoFactor.CreatedAt, &twoFactor.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two factor enrollment: %w", err)
+	}
+
+	return twoFactor, nil
+}
+
+// SavePendingTwoFactor stores a new secret awaiting confirmation; an enabled enrollment is never overwritten
+func (r *Repository) SavePendingTwoFactor(userID int64, secret string) error {
+	query := `
+		INSERT INTO user_two_factor (user_id, secret, is_enabled, last_used_step, created_at, updated_at)
+		VALUES ($1, $2, false, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = CURRENT_TIMESTAMP
+		WHERE user_two_factor.is_enabled = false
+	`
+
+	result, err := r.db.Exec(query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to save two factor secret: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("two factor authentication already enabled")
+	}
+
+	return nil
+}
+
+// EnableTwoFactor activates the enrollment and replaces the recovery codes in one transaction
+func (r *Repository) EnableTwoFactor(userID int64, step int64, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE user_two_factor
+		SET is_enabled = true, last_used_step = $2, enabled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND is_enabled = false
+	`
+	result, err := tx.Exec(query, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable two factor: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("two factor enrollment not found")
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTwoFactor removes the enrollment and all recovery codes of a user
+func (r *Repository) DisableTwoFactor(userID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM user_two_factor WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to disable two factor: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// MarkTwoFactorStepUsed advances the last used time step. It returns false when the
+// step was already used, which rejects replay of a code within its validity window.
+func (r *Repository) MarkTwoFactorStepUsed(userID int64, step int64) (bool, error) {
+	query := `
+		UPDATE user_two_factor SET last_used_step = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND last_used_step < $2
+	`
+
+	result, err := r.db.Exec(query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to update two factor step: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// UseRecoveryCode consumes an unused recovery code, returning false when none matched
+func (r *Repository) UseRecoveryCode(userID int64, codeHash string) (bool, error) {
+	query := `
+		UPDATE user_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ReplaceRecoveryCodes discards all recovery codes of a user and stores new ones
+func (r *Repository) ReplaceRecoveryCodes(userID int64, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID int64, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	for _, codeHash := range codeHashes {
+		_, err := tx.Exec(`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, codeHash)
+		if err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CountUnusedRecoveryCodes counts recovery codes that can still be used
+func (r *Repository) CountUnusedRecoveryCodes(userID int64) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+	if err := r.db.QueryRow(query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// IsTwoFactorRequired checks the company and role policies of every company the user holds an active role in
+func (r *Repository) IsTwoFactorRequired(userID int64) (bool, error) {
+	var required bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN two_factor_policies tfp ON tfp.company_id = ur.company_id
+			WHERE ur.user_id = $1
+				AND (tfp.role_id IS NULL OR tfp.role_id = ur.role_id)
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID).Scan(&required); err != nil {
+		return false, fmt.Errorf("failed to check two factor policy: %w", err)
+	}
+
+	return required, nil
+}
+
 // Helper methods for basic tier modules
 func (r *Repository) getUserBasicModules(userID int64) ([]string, error) {
 	query := `
 		SELECT DISTINCT m.url
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		WHERE ur.user_id = $1 
 			AND rm.can_read = true
@@ -472,8 +695,9 @@ func (r *Repository) getUserBasicModulesGrouped(userID int64) (map[string][][]st
 	query := `
 		SELECT DISTINCT m.name, m.url, m.icon, m.description, m.category, m.parent_id,
 			CASE WHEN m.parent_id IS NULL THEN 0 ELSE 1 END as sort_order
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		WHERE ur.user_id = $1 
 			AND rm.can_read = true
@@ -676,7 +900,7 @@ func (r *Repository) GetUserProfileByApplication(userIdentity, applicationCode s
 			b.name as branch_name,
 			ur.unit_id,
 			u.name as unit_name
-		FROM user_roles ur
+		FROM active_user_roles ur
 		JOIN roles ro ON ur.role_id = ro.id
 		LEFT JOIN companies c ON ur.company_id = c.id
 		LEFT JOIN branches b ON ur.branch_id = b.id
@@ -746,7 +970,8 @@ func (r *Repository) GetUserProfileByApplication(userIdentity, applicationCode s
 			m.id, m.name, m.url, m.icon, m.description, m.category
 		FROM modules m
 		JOIN role_modules rm ON m.id = rm.module_id
-		JOIN user_roles ur ON rm.role_id = ur.role_id
+		JOIN role_ancestors ra ON ra.ancestor_id = rm.role_id
+		JOIN active_user_roles ur ON ur.role_id = ra.role_id
 		JOIN plan_modules pm ON m.id = pm.module_id
 		JOIN subscriptions s ON pm.plan_id = s.plan_id
 		WHERE ur.user_id = $1 
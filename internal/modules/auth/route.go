@@ -1,10 +1,13 @@
 package auth
 
 import (
+	"errors"
 	"gin-scalable-api/internal/constants"
 	"gin-scalable-api/middleware"
+	"gin-scalable-api/pkg/lockout"
 	"gin-scalable-api/pkg/response"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,6 +34,7 @@ func NewHandler(service *Service) *Handler {
 // @Success      200          {object}  response.Response{data=auth.LoginResponse}  "Login berhasil"
 // @Failure      400          {object}  response.Response  "Bad request - format request tidak valid"
 // @Failure      401          {object}  response.Response  "Unauthorized - kredensial tidak valid"
+// @Failure      429          {object}  response.Response  "Too many requests - akun atau IP terkunci sementara, lihat header Retry-After"
 // @Router       /api/v1/auth/login [post]
 func (h *Handler) Login(c *gin.Context) {
 	validatedBody, exists := c.Get("validated_body")
@@ -51,11 +55,11 @@ func (h *Handler) Login(c *gin.Context) {
 
 	authResponse, err := h.service.Login(req, userAgent, ip)
 	if err != nil {
-		response.Error(c, http.StatusUnauthorized, "Login failed", err.Error())
+		writeLoginError(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, constants.MsgLoginSuccess, authResponse)
+	response.Success(c, http.StatusOK, loginMessage(authResponse), authResponse)
 }
 
 // @Summary      Login dengan email
@@ -67,6 +71,7 @@ func (h *Handler) Login(c *gin.Context) {
 // @Success      200          {object}  response.Response{data=auth.LoginResponse}  "Login berhasil"
 // @Failure      400          {object}  response.Response  "Bad request - format request tidak valid"
 // @Failure      401          {object}  response.Response  "Unauthorized - kredensial tidak valid"
+// @Failure      429          {object}  response.Response  "Too many requests - akun atau IP terkunci sementara, lihat header Retry-After"
 // @Router       /api/v1/auth/login-email [post]
 func (h *Handler) LoginWithEmail(c *gin.Context) {
 	validatedBody, exists := c.Get("validated_body")
@@ -87,15 +92,15 @@ func (h *Handler) LoginWithEmail(c *gin.Context) {
 
 	authResponse, err := h.service.LoginWithEmail(req, userAgent, ip)
 	if err != nil {
-		response.Error(c, http.StatusUnauthorized, "Login failed", err.Error())
+		writeLoginError(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, constants.MsgLoginSuccess, authResponse)
+	response.Success(c, http.StatusOK, loginMessage(authResponse), authResponse)
 }
 
 // @Summary      Refresh access token
-// @Description  Memperbarui access token menggunakan refresh token yang valid
+// @Description  Memperbarui access token menggunakan refresh token yang valid. Refresh token lama langsung tidak berlaku; penggunaan ulang token lama mencabut seluruh sesi dalam family yang sama
 // @Tags         🔐 Authentication
 // @Accept       json
 // @Produce      json
@@ -117,7 +122,7 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	authResponse, err := h.service.RefreshToken(req)
+	authResponse, err := h.service.RefreshToken(req, c.GetHeader("User-Agent"), c.ClientIP())
 	if err != nil {
 		response.Error(c, http.StatusUnauthorized, "Token refresh failed", err.Error())
 		return
@@ -227,7 +232,13 @@ func (h *Handler) GetUserSessionCount(c *gin.Context) {
 		return
 	}
 
-	accessCount, err := h.service.GetUserSessionCount(user.ID)
+	sessionCount, err := h.service.GetUserSessionCount(user.ID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Operation failed", err.Error())
+		return
+	}
+
+	accessCount, err := h.service.GetUserAccessTokenCount(user.ID)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Operation failed", err.Error())
 		return
@@ -244,10 +255,288 @@ func (h *Handler) GetUserSessionCount(c *gin.Context) {
 		"user_id":             user.ID,
 		"access_token_count":  accessCount,
 		"refresh_token_count": refreshCount,
-		"total_sessions":      accessCount,
+		"total_sessions":      sessionCount,
 	})
 }
 
+// @Summary      List active sessions
+// @Description  Mendapatkan daftar sesi aktif pengguna yang sedang login beserta informasi perangkat (user agent, IP, waktu dibuat, terakhir aktif)
+// @Tags         🔐 Authentication
+// @Produce      json
+// @Success      200  {object}  response.Response{data=auth.SessionListResponse}  "Daftar sesi berhasil diambil"
+// @Failure      401  {object}  response.Response  "Unauthorized"
+// @Failure      500  {object}  response.Response  "Internal server error"
+// @Router       /api/v1/auth/sessions [get]
+// @Security     BearerAuth
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.service.GetSessions(userID, c.GetString("session_id"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgSessionsRetrieved, sessions)
+}
+
+// @Summary      Revoke session
+// @Description  Mencabut satu sesi aktif milik pengguna yang sedang login berdasarkan session_id
+// @Tags         🔐 Authentication
+// @Produce      json
+// @Param        session_id  path      string  true  "Session ID"
+// @Success      200         {object}  response.Response  "Sesi berhasil dicabut"
+// @Failure      401         {object}  response.Response  "Unauthorized"
+// @Failure      404         {object}  response.Response  "Sesi tidak ditemukan"
+// @Router       /api/v1/auth/sessions/{session_id} [delete]
+// @Security     BearerAuth
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeSession(userID, c.Param("session_id")); err != nil {
+		response.ErrorWithAutoStatus(c, "Failed to revoke session", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgSessionRevoked, nil)
+}
+
+// @Summary      Verify two-factor login
+// @Description  Langkah kedua login: memverifikasi kode TOTP atau recovery code terhadap challenge token dari endpoint login, lalu mengembalikan access token dan refresh token
+// @Tags         🔐 Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      auth.TwoFactorVerifyRequest  true  "Challenge token dan kode verifikasi"
+// @Success      200      {object}  response.Response{data=auth.LoginResponse}  "Login berhasil"
+// @Failure      400      {object}  response.Response  "Bad request - format request tidak valid"
+// @Failure      401      {object}  response.Response  "Unauthorized - challenge atau kode tidak valid"
+// @Router       /api/v1/auth/2fa/verify [post]
+func (h *Handler) VerifyTwoFactor(c *gin.Context) {
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*TwoFactorVerifyRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "invalid body structure")
+		return
+	}
+
+	authResponse, err := h.service.VerifyTwoFactorLogin(req)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "Two-factor verification failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, loginMessage(authResponse), authResponse)
+}
+
+// @Summary      Change expired password during login
+// @Description  Langkah terakhir login bila password kedaluwarsa atau wajib diganti: menyetel password baru sesuai kebijakan password, lalu mengembalikan access token dan refresh token
+// @Tags         🔐 Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      auth.ExpiredPasswordChangeRequest  true  "Challenge token dan password baru"
+// @Success      200      {object}  response.Response{data=auth.LoginResponse}  "Login berhasil"
+// @Failure      400      {object}  response.Response  "Bad request - password tidak memenuhi kebijakan"
+// @Failure      401      {object}  response.Response  "Unauthorized - challenge tidak valid"
+// @Router       /api/v1/auth/password/expired [post]
+func (h *Handler) ChangeExpiredPassword(c *gin.Context) {
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*ExpiredPasswordChangeRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "invalid body structure")
+		return
+	}
+
+	authResponse, err := h.service.ChangeExpiredPassword(req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Password change failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgLoginSuccess, authResponse)
+}
+
+// @Summary      Setup two-factor during login
+// @Description  Membuat secret TOTP untuk pengguna yang diwajibkan 2FA oleh kebijakan perusahaan namun belum mendaftar. Konfirmasi dilakukan melalui /auth/2fa/verify
+// @Tags         🔐 Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      auth.TwoFactorChallengeRequest  true  "Challenge token dari login"
+// @Success      200      {object}  response.Response{data=auth.TwoFactorSetupResponse}  "Secret dan provisioning URI"
+// @Failure      401      {object}  response.Response  "Unauthorized - challenge tidak valid"
+// @Router       /api/v1/auth/2fa/setup [post]
+func (h *Handler) SetupTwoFactor(c *gin.Context) {
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*TwoFactorChallengeRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "invalid body structure")
+		return
+	}
+
+	setup, err := h.service.SetupTwoFactorLogin(req)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "Two-factor setup failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgTwoFactorSetup, setup)
+}
+
+// @Summary      Get two-factor status
+// @Description  Mendapatkan status autentikasi dua faktor pengguna yang sedang login
+// @Tags         🔐 Authentication
+// @Produce      json
+// @Success      200  {object}  response.Response{data=auth.TwoFactorStatusResponse}  "Status 2FA"
+// @Failure      401  {object}  response.Response  "Unauthorized"
+// @Router       /api/v1/auth/2fa [get]
+// @Security     BearerAuth
+func (h *Handler) GetTwoFactorStatus(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.GetTwoFactorStatus(userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgTwoFactorStatus, status)
+}
+
+// @Summary      Enroll two-factor
+// @Description  Membuat secret TOTP baru beserta provisioning URI (untuk QR code) bagi pengguna yang sedang login
+// @Tags         🔐 Authentication
+// @Produce      json
+// @Success      200  {object}  response.Response{data=auth.TwoFactorSetupResponse}  "Secret dan provisioning URI"
+// @Failure      400  {object}  response.Response  "2FA sudah aktif"
+// @Router       /api/v1/auth/2fa/enroll [post]
+// @Security     BearerAuth
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	setup, err := h.service.EnrollTwoFactor(userID)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Two-factor enrollment failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgTwoFactorSetup, setup)
+}
+
+// @Summary      Enable two-factor
+// @Description  Mengaktifkan 2FA dengan kode TOTP pertama dan mengembalikan recovery code (hanya ditampilkan sekali)
+// @Tags         🔐 Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      auth.TwoFactorCodeRequest  true  "Kode TOTP"
+// @Success      200      {object}  response.Response{data=auth.RecoveryCodesResponse}  "2FA aktif"
+// @Failure      400      {object}  response.Response  "Kode tidak valid"
+// @Router       /api/v1/auth/2fa/enable [post]
+// @Security     BearerAuth
+func (h *Handler) EnableTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := validatedTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.EnableTwoFactor(userID, req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to enable two-factor", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgTwoFactorEnabled, codes)
+}
+
+// @Summary      Disable two-factor
+// @Description  Menonaktifkan 2FA setelah verifikasi kode TOTP atau recovery code. Ditolak bila 2FA diwajibkan kebijakan perusahaan atau role
+// @Tags         🔐 Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      auth.TwoFactorCodeRequest  true  "Kode TOTP atau recovery code"
+// @Success      200      {object}  response.Response  "2FA nonaktif"
+// @Failure      400      {object}  response.Response  "Kode tidak valid atau 2FA diwajibkan"
+// @Router       /api/v1/auth/2fa/disable [post]
+// @Security     BearerAuth
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := validatedTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DisableTwoFactor(userID, req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to disable two-factor", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgTwoFactorDisabled, nil)
+}
+
+// @Summary      Regenerate recovery codes
+// @Description  Mengganti seluruh recovery code setelah verifikasi kode TOTP
+// @Tags         🔐 Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      auth.TwoFactorCodeRequest  true  "Kode TOTP"
+// @Success      200      {object}  response.Response{data=auth.RecoveryCodesResponse}  "Recovery code baru"
+// @Failure      400      {object}  response.Response  "Kode tidak valid"
+// @Router       /api/v1/auth/2fa/recovery-codes [post]
+// @Security     BearerAuth
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := validatedTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(userID, req)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to regenerate recovery codes", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgRecoveryCodesRenewed, codes)
+}
+
 // @Summary      Cleanup expired tokens
 // @Description  Membersihkan token yang sudah expired dari database
 // @Tags         🔐 Authentication
@@ -310,7 +599,144 @@ func (h *Handler) GetProfile(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Profile successfully retrieved", profileResponse)
 }
 
+// currentUserID reads the authenticated user ID, writing the error response when it is missing
+// @Summary      Request password reset
+// @Description  Mengirim tautan reset password sekali pakai ke email pengguna. Respons selalu sukses, termasuk untuk email yang tidak terdaftar, agar keberadaan akun tidak terungkap
+// @Tags         🔐 Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      auth.ForgotPasswordRequest  true  "Email akun"
+// @Success      200      {object}  response.Response  "Email reset password dikirim bila akun terdaftar"
+// @Failure      400      {object}  response.Response  "Bad request - format request tidak valid"
+// @Router       /api/v1/auth/password/forgot [post]
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*ForgotPasswordRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "invalid body structure")
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(req, c.GetHeader("User-Agent"), c.ClientIP()); err != nil {
+		response.Error(c, http.StatusInternalServerError, "Password reset failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgPasswordResetSent, nil)
+}
+
+// @Summary      Reset password
+// @Description  Menyetel password baru menggunakan token dari email reset password. Token hanya berlaku satu kali dan seluruh sesi pengguna dicabut setelah reset berhasil
+// @Tags         🔐 Authentication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      auth.ResetPasswordRequest  true  "Token reset dan password baru"
+// @Success      200      {object}  response.Response  "Password berhasil direset"
+// @Failure      400      {object}  response.Response  "Bad request - token tidak valid atau password tidak memenuhi kebijakan"
+// @Router       /api/v1/auth/password/reset [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*ResetPasswordRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "invalid body structure")
+		return
+	}
+
+	if err := h.service.ResetPassword(req, c.GetHeader("User-Agent"), c.ClientIP()); err != nil {
+		response.ErrorWithAutoStatus(c, "Password reset failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgPasswordResetSuccess, nil)
+}
+
+// loginMessage describes which login step a response completes or still requires
+func loginMessage(authResponse *LoginResponse) string {
+	switch {
+	case authResponse.TwoFactorRequired:
+		return constants.MsgTwoFactorRequired
+	case authResponse.PasswordChangeRequired:
+		return constants.MsgPasswordExpired
+	default:
+		return constants.MsgLoginSuccess
+	}
+}
+
+// writeLoginError answers a failed login; lockouts get 429 with a Retry-After header
+func writeLoginError(c *gin.Context, err error) {
+	var lockoutErr *lockout.Error
+	if errors.As(err, &lockoutErr) {
+		c.Header("Retry-After", strconv.Itoa(lockoutErr.RetryAfterSeconds()))
+		response.Error(c, http.StatusTooManyRequests, "Too many login attempts", err.Error())
+		return
+	}
+
+	response.Error(c, http.StatusUnauthorized, "Login failed", err.Error())
+}
+
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
+// validatedTwoFactorCode reads the validated TwoFactorCodeRequest body
+func validatedTwoFactorCode(c *gin.Context) (*TwoFactorCodeRequest, bool) {
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "validation failed")
+		return nil, false
+	}
+
+	req, ok := validatedBody.(*TwoFactorCodeRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "invalid body structure")
+		return nil, false
+	}
+
+	return req, true
+}
+
 // Route registration
+// @Summary      JSON Web Key Set
+// @Description  Kunci publik untuk memverifikasi access token JWT dan ID token OpenID Connect secara lokal oleh layanan lain
+// @Tags         🔐 Authentication
+// @Produce      json
+// @Success      200  {object}  jwt.JSONWebKeySet  "JWKS"
+// @Failure      404  {object}  response.Response  "Kunci penandatanganan belum dikonfigurasi"
+// @Router       /.well-known/jwks.json [get]
+func (h *Handler) GetJWKS(c *gin.Context) {
+	jwks, err := h.service.GetJWKS()
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "JWKS not available", err.Error())
+		return
+	}
+
+	// Served as a plain JWKS document so standard JWT libraries can consume it
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
+
 func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 	auth := api.Group("/auth")
 	{
@@ -357,5 +783,93 @@ func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 
 		// GET /api/v1/auth/profile - Get user profile by application
 		auth.GET("/profile", handler.GetProfile)
+
+		// POST /api/v1/auth/2fa/verify - Second login step with TOTP or recovery code
+		auth.POST("/2fa/verify",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &TwoFactorVerifyRequest{},
+			}),
+			handler.VerifyTwoFactor,
+		)
+
+		// POST /api/v1/auth/2fa/setup - Enroll during login when 2FA is required by policy
+		auth.POST("/2fa/setup",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &TwoFactorChallengeRequest{},
+			}),
+			handler.SetupTwoFactor,
+		)
+
+		// POST /api/v1/auth/password/expired - Replace an expired password during login
+		auth.POST("/password/expired",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &ExpiredPasswordChangeRequest{},
+			}),
+			handler.ChangeExpiredPassword,
+		)
+
+		// POST /api/v1/auth/password/forgot - Email a password reset link
+		auth.POST("/password/forgot",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &ForgotPasswordRequest{},
+			}),
+			handler.ForgotPassword,
+		)
+
+		// POST /api/v1/auth/password/reset - Set a new password with an emailed reset token
+		auth.POST("/password/reset",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &ResetPasswordRequest{},
+			}),
+			handler.ResetPassword,
+		)
 	}
 }
+
+// RegisterProtectedRoutes registers auth routes that require an authenticated session
+func RegisterProtectedRoutes(router *gin.RouterGroup, handler *Handler) {
+	auth := router.Group("/auth")
+	{
+		// GET /api/v1/auth/sessions - List active sessions of the current user
+		auth.GET("/sessions", handler.ListSessions)
+
+		// DELETE /api/v1/auth/sessions/:session_id - Revoke one session of the current user
+		auth.DELETE("/sessions/:session_id", handler.RevokeSession)
+
+		// GET /api/v1/auth/2fa - Two-factor status of the current user
+		auth.GET("/2fa", handler.GetTwoFactorStatus)
+
+		// POST /api/v1/auth/2fa/enroll - Generate a TOTP secret and provisioning URI
+		auth.POST("/2fa/enroll", handler.EnrollTwoFactor)
+
+		// POST /api/v1/auth/2fa/enable - Confirm enrollment and receive recovery codes
+		auth.POST("/2fa/enable",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &TwoFactorCodeRequest{},
+			}),
+			handler.EnableTwoFactor,
+		)
+
+		// POST /api/v1/auth/2fa/disable - Disable two-factor authentication
+		auth.POST("/2fa/disable",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &TwoFactorCodeRequest{},
+			}),
+			handler.DisableTwoFactor,
+		)
+
+		// POST /api/v1/auth/2fa/recovery-codes - Regenerate recovery codes
+		auth.POST("/2fa/recovery-codes",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &TwoFactorCodeRequest{},
+			}),
+			handler.RegenerateRecoveryCodes,
+		)
+	}
+}
+
+// RegisterWellKnownRoutes registers discovery documents served from the server root
+func RegisterWellKnownRoutes(router *gin.Engine, handler *Handler) {
+	// GET /.well-known/jwks.json - Public keys for JWT access token and ID token verification
+	router.GET("/.well-known/jwks.json", handler.GetJWKS)
+}
@@ -5,41 +5,260 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/pkg/jwt"
+	"gin-scalable-api/pkg/lockout"
+	"gin-scalable-api/pkg/logger"
+	"gin-scalable-api/pkg/mailer"
 	"gin-scalable-api/pkg/password"
 	"gin-scalable-api/pkg/token"
+	"gin-scalable-api/pkg/totp"
+	"net/url"
+	"strings"
 	"time"
 )
 
 type Service struct {
-	repo         *Repository
-	tokenService *token.SimpleTokenService
-	jwtSecret    string
+	repo          *Repository
+	tokenService  *token.SimpleTokenService
+	jwtSecret     string
+	signingKeys   *jwt.KeySet
+	loginGuard    *lockout.Guard
+	passwordStore *password.Store
+	mailSender    mailer.Sender
+	// passwordResetURL is the frontend page that receives reset tokens
+	passwordResetURL string
 }
 
-func NewService(repo *Repository, tokenService *token.SimpleTokenService, jwtSecret string) *Service {
+func NewService(repo *Repository, tokenService *token.SimpleTokenService, jwtSecret string, signingKeys *jwt.KeySet, loginGuard *lockout.Guard, passwordStore *password.Store, mailSender mailer.Sender, passwordResetURL string) *Service {
 	return &Service{
-		repo:         repo,
-		tokenService: tokenService,
-		jwtSecret:    jwtSecret,
+		repo:             repo,
+		tokenService:     tokenService,
+		jwtSecret:        jwtSecret,
+		signingKeys:      signingKeys,
+		loginGuard:       loginGuard,
+		passwordStore:    passwordStore,
+		mailSender:       mailSender,
+		passwordResetURL: passwordResetURL,
 	}
 }
 
 func (s *Service) Login(req *LoginRequest, userAgent, ip string) (*LoginResponse, error) {
-	// Get user by user_identity
-	user, err := s.repo.GetByUserIdentity(req.UserIdentity)
+	user, err := s.authenticate(req.UserIdentity, func() (*User, error) {
+		return s.repo.GetByUserIdentity(req.UserIdentity)
+	}, req.Password, userAgent, ip)
 	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(user, userAgent, ip)
+}
+
+// authenticate looks up a user by identifier and verifies the password behind the brute-force
+// guard. Failed attempts count against the IP and the account or, for identifiers matching no
+// account, the identifier, so both lock out alike and do not reveal which accounts exist. The
+// attempts are reset by completeLogin or, with 2FA, once the second factor passes.
+func (s *Service) authenticate(identity string, lookup func() (*User, error), plainPassword, userAgent, ip string) (*User, error) {
+	if err := s.loginGuard.CheckIP(ip); err != nil {
+		return nil, err
+	}
+
+	user, err := lookup()
+	if err != nil {
+		if err := s.loginGuard.CheckIdentity(identity); err != nil {
+			return nil, err
+		}
+		s.recordIdentityFailure(identity, userAgent, ip)
 		return nil, errors.New("kredensial tidak valid")
 	}
 
+	if err := s.loginGuard.CheckAccount(user.ID); err != nil {
+		return nil, err
+	}
+
 	if !user.IsActive {
 		return nil, errors.New("akun pengguna tidak aktif")
 	}
 
-	if err := password.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+	if err := password.VerifyPassword(user.PasswordHash, plainPassword); err != nil {
+		s.recordLoginFailure(user.ID, "Invalid credentials", userAgent, ip)
 		return nil, errors.New("kredensial tidak valid")
 	}
 
-	// UserAgent and IP are now passed as parameters
+	return user, nil
+}
+
+// recordLoginSuccess resets the failed attempts of a user who passed every login factor
+func (s *Service) recordLoginSuccess(userID int64) {
+	if err := s.loginGuard.RecordSuccess(userID); err != nil {
+		logger.Warning(fmt.Sprintf("Failed to reset login attempts for user %d: %v", userID, err))
+	}
+}
+
+// recordLoginFailure counts a failed password or second factor and audits the lockouts it triggers
+func (s *Service) recordLoginFailure(userID int64, message, userAgent, ip string) {
+	s.auditLogin(userID, "login_failed", false, message, userAgent, ip)
+
+	failure, err := s.loginGuard.RecordFailure(userID, ip)
+	if err != nil {
+		logger.Warning(fmt.Sprintf("Failed to record login attempt from %s: %v", ip, err))
+		return
+	}
+
+	if failure.AccountLocked {
+		s.auditLockout(userID, "account_locked", "Account locked after too many failed login attempts", failure, userAgent, ip)
+	}
+	if failure.IPLocked {
+		s.auditLockout(0, "ip_locked", "IP address locked after too many failed login attempts", failure, userAgent, ip)
+	}
+}
+
+// recordIdentityFailure counts a failed login for an identifier that matches no account
+func (s *Service) recordIdentityFailure(identity, userAgent, ip string) {
+	s.auditLogin(0, "login_failed", false, "Invalid credentials", userAgent, ip)
+
+	failure, err := s.loginGuard.RecordIdentityFailure(identity, ip)
+	if err != nil {
+		logger.Warning(fmt.Sprintf("Failed to record login attempt from %s: %v", ip, err))
+		return
+	}
+
+	if failure.IPLocked {
+		s.auditLockout(0, "ip_locked", "IP address locked after too many failed login attempts", failure, userAgent, ip)
+	}
+}
+
+// auditLogin records the outcome of a login for the anomaly detector
+func (s *Service) auditLogin(userID int64, action string, success bool, message, userAgent, ip string) {
+	status, statusCode := constants.AuditSuccess, 200
+	if !success {
+		status, statusCode = constants.AuditError, 401
+	}
+
+	details := map[string]interface{}{
+		"method":      "POST",
+		"url":         "/api/v1/auth/login",
+		"status":      status,
+		"status_code": statusCode,
+		"message":     message,
+		"ip":          ip,
+		"user_agent":  userAgent,
+	}
+
+	if err := s.repo.CreateAuditLog(userID, action, success, details); err != nil {
+		logger.Error(fmt.Sprintf("Failed to audit %s for user %d from %s: %v", action, userID, ip, err))
+	}
+}
+
+func (s *Service) auditLockout(userID int64, action, message string, failure *lockout.Failure, userAgent, ip string) {
+	details := map[string]interface{}{
+		"method":      "POST",
+		"url":         "/api/v1/auth/login",
+		"status":      constants.AuditWarning,
+		"status_code": 429,
+		"message":     message,
+		"attempts":    failure.Attempts,
+		"ip":          ip,
+		"user_agent":  userAgent,
+	}
+
+	if err := s.repo.CreateAuditLog(userID, action, false, details); err != nil {
+		logger.Error(fmt.Sprintf("Failed to audit %s for user %d from %s: %v", action, userID, ip, err))
+	}
+}
+
+// completeLogin starts a 2FA challenge or finishes the login of a user whose password was verified
+func (s *Service) completeLogin(user *User, userAgent, ip string) (*LoginResponse, error) {
+	// Users with 2FA enabled or required by policy get a challenge instead of tokens
+	challenge, err := s.startTwoFactorChallenge(user, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return challenge, nil
+	}
+	s.recordLoginSuccess(user.ID)
+
+	return s.finishLogin(user, userAgent, ip)
+}
+
+// finishLogin issues tokens, unless the password has expired and must be replaced first.
+// It runs after the second factor, so only the account owner can set the new password.
+func (s *Service) finishLogin(user *User, userAgent, ip string) (*LoginResponse, error) {
+	policy, err := s.passwordStore.EffectivePolicy(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	changedAt, err := s.passwordStore.ChangedAt(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !policy.IsExpired(changedAt, time.Now()) {
+		return s.issueLoginTokens(user, userAgent, ip)
+	}
+
+	challengeToken, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := token.LoginChallenge{
+		UserID:                 user.ID,
+		UserAgent:              userAgent,
+		IP:                     ip,
+		PasswordChangeRequired: true,
+	}
+	ttl := time.Duration(constants.PasswordChangeChallengeTTL) * time.Second
+	if err := s.tokenService.StoreLoginChallenge(challengeToken, challenge, ttl); err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		PasswordChangeRequired: true,
+		ChallengeToken:         challengeToken,
+		ChallengeExpiresIn:     constants.PasswordChangeChallengeTTL,
+	}, nil
+}
+
+// ChangeExpiredPassword replaces an expired password during login and issues the session tokens
+func (s *Service) ChangeExpiredPassword(req *ExpiredPasswordChangeRequest) (*LoginResponse, error) {
+	challenge, err := s.tokenService.GetLoginChallenge(req.ChallengeToken)
+	if err != nil || !challenge.PasswordChangeRequired {
+		return nil, errors.New("challenge token tidak valid atau kedaluwarsa")
+	}
+
+	user, err := s.repo.GetByID(challenge.UserID)
+	if err != nil || !user.IsActive {
+		return nil, errors.New("akun pengguna tidak aktif")
+	}
+
+	policy, err := s.passwordStore.CheckNewPassword(user.ID, req.NewPassword, personalInfo(user))
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one request may turn the challenge into a session
+	consumed, err := s.tokenService.DeleteLoginChallenge(req.ChallengeToken)
+	if err != nil || !consumed {
+		return nil, errors.New("challenge token tidak valid atau kedaluwarsa")
+	}
+	s.recordLoginSuccess(user.ID)
+
+	hashedPassword, err := password.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.passwordStore.SetPassword(user.ID, hashedPassword, policy.HistoryCount, false); err != nil {
+		return nil, err
+	}
+
+	return s.issueLoginTokens(user, challenge.UserAgent, challenge.IP)
+}
+
+// issueLoginTokens creates a new session for an authenticated user
+func (s *Service) issueLoginTokens(user *User, userAgent, ip string) (*LoginResponse, error) {
 	userWithRoles, err := s.repo.GetByIDWithRoles(user.ID)
 	if err != nil || userWithRoles == nil {
 		userWithRoles = map[string]interface{}{
@@ -82,11 +301,6 @@ func (s *Service) Login(req *LoginRequest, userAgent, ip string) (*LoginResponse
 		}
 	}
 
-	accessToken, err := s.tokenService.GenerateToken()
-	if err != nil {
-		return nil, err
-	}
-
 	refreshToken, err := s.tokenService.GenerateToken()
 	if err != nil {
 		return nil, err
@@ -99,24 +313,31 @@ func (s *Service) Login(req *LoginRequest, userAgent, ip string) (*LoginResponse
 
 	expiresAt := time.Now().Add(15 * time.Minute)
 
-	accessMetadata := token.TokenMetadata{
+	refreshMetadata := token.RefreshTokenMetadata{
 		UserID:    user.ID,
+		FamilyID:  familyID,
 		UserAgent: userAgent,
 		IP:        ip,
-		Abilities: moduleURLs,
-		ExpiresAt: expiresAt.Unix(),
 	}
 
-	if err := s.tokenService.StoreAccessToken(accessToken, accessMetadata, 15*time.Minute); err != nil {
+	// Store refresh token first so the family exists before access tokens bind to it
+	if err := s.tokenService.StoreRefreshToken(refreshToken, refreshMetadata, 7*24*time.Hour); err != nil {
 		return nil, err
 	}
 
-	refreshMetadata := token.RefreshTokenMetadata{
-		UserID:   user.ID,
-		FamilyID: familyID,
+	accessMetadata := token.TokenMetadata{
+		UserID:    user.ID,
+		CompanyID: s.primaryCompanyID(user.ID),
+		UserAgent: userAgent,
+		IP:        ip,
+		Abilities: moduleURLs,
+		ExpiresAt: expiresAt.Unix(),
+		FamilyID:  familyID,
 	}
 
-	if err := s.tokenService.StoreRefreshToken(refreshToken, refreshMetadata, 7*24*time.Hour); err != nil {
+	// Opaque token or signed JWT, depending on the configured token mode
+	accessToken, err := s.tokenService.IssueAccessToken(accessMetadata, 15*time.Minute)
+	if err != nil {
 		return nil, err
 	}
 
@@ -133,6 +354,8 @@ func (s *Service) Login(req *LoginRequest, userAgent, ip string) (*LoginResponse
 		"subscription":  subscriptionInfo,
 	}
 
+	s.auditLogin(user.ID, "login_succeeded", true, "Login succeeded", userAgent, ip)
+
 	return &LoginResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -143,27 +366,155 @@ func (s *Service) Login(req *LoginRequest, userAgent, ip string) (*LoginResponse
 }
 
 func (s *Service) LoginWithEmail(req *LoginEmailRequest, userAgent, ip string) (*LoginResponse, error) {
-	// Get user by email
+	user, err := s.authenticate(req.Email, func() (*User, error) {
+		return s.repo.GetByEmail(req.Email)
+	}, req.Password, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(user, userAgent, ip)
+}
+
+// RequestPasswordReset emails a single-use reset link. It succeeds silently for unknown
+// or inactive emails, so the response never reveals whether an account exists.
+func (s *Service) RequestPasswordReset(req *ForgotPasswordRequest, userAgent, ip string) error {
 	user, err := s.repo.GetByEmail(req.Email)
 	if err != nil {
-		return nil, errors.New("kredensial tidak valid")
+		return nil
 	}
 
-	if !user.IsActive {
-		return nil, errors.New("akun pengguna tidak aktif")
+	resetToken, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return err
 	}
 
-	if err := password.VerifyPassword(user.PasswordHash, req.Password); err != nil {
-		return nil, errors.New("kredensial tidak valid")
+	reset := token.PasswordReset{
+		UserID:      user.ID,
+		RequestedIP: ip,
+		CreatedAt:   time.Now().Unix(),
+	}
+	ttl := time.Duration(constants.PasswordResetTokenTTL) * time.Second
+	cooldown := time.Duration(constants.PasswordResetCooldown) * time.Second
+	err = s.tokenService.StorePasswordResetToken(resetToken, reset, ttl, cooldown)
+	if errors.Is(err, token.ErrPasswordResetThrottled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	message := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset password",
+		Body: fmt.Sprintf("Halo %s,\n\n"+
+			"Kami menerima permintaan untuk mereset password akun Anda. Buka tautan berikut untuk membuat password baru:\n\n"+
+			"%s\n\n"+
+			"Tautan ini berlaku selama %d menit dan hanya dapat digunakan satu kali. "+
+			"Abaikan email ini bila Anda tidak meminta reset password.\n",
+			user.Name, s.passwordResetLink(resetToken), constants.PasswordResetTokenTTL/60),
+	}
+
+	// Sent in the background so the response time does not reveal whether the account exists
+	go func() {
+		if err := s.mailSender.Send(message); err != nil {
+			logger.Error(fmt.Sprintf("Failed to send password reset mail to user %d: %v", user.ID, err))
+		}
+	}()
+
+	s.auditPasswordReset(user.ID, "password_reset_requested", "/api/v1/auth/password/forgot", userAgent, ip)
+	return nil
+}
+
+// ResetPassword sets a new password with an emailed reset token and ends every session of the user
+func (s *Service) ResetPassword(req *ResetPasswordRequest, userAgent, ip string) error {
+	reset, err := s.tokenService.GetPasswordReset(req.Token)
+	if err != nil {
+		return errors.New("token reset password tidak valid atau kedaluwarsa (invalid token)")
+	}
+
+	user, err := s.repo.GetByID(reset.UserID)
+	if err != nil || !user.IsActive {
+		return errors.New("token reset password tidak valid atau kedaluwarsa (invalid token)")
+	}
+
+	// Checked before the token is consumed, so a rejected password can be retried
+	policy, err := s.passwordStore.CheckNewPassword(user.ID, req.NewPassword, personalInfo(user))
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.tokenService.ConsumePasswordResetToken(req.Token); err != nil {
+		return errors.New("token reset password tidak valid atau kedaluwarsa (invalid token)")
+	}
+
+	hashedPassword, err := password.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.passwordStore.SetPassword(user.ID, hashedPassword, policy.HistoryCount, false); err != nil {
+		return err
+	}
+
+	// Whoever knew the old password loses every session, and the owner is no longer locked out
+	if err := s.tokenService.RevokeAllUserTokens(user.ID); err != nil {
+		logger.Error(fmt.Sprintf("Failed to revoke sessions of user %d after password reset: %v", user.ID, err))
+	}
+	if _, err := s.loginGuard.Unlock(user.ID); err != nil {
+		logger.Warning(fmt.Sprintf("Failed to clear login lockout of user %d after password reset: %v", user.ID, err))
+	}
+
+	s.auditPasswordReset(user.ID, "password_reset", "/api/v1/auth/password/reset", userAgent, ip)
+	return nil
+}
+
+func (s *Service) passwordResetLink(resetToken string) string {
+	separator := "?"
+	if strings.Contains(s.passwordResetURL, "?") {
+		separator = "&"
 	}
+	return s.passwordResetURL + separator + "token=" + url.QueryEscape(resetToken)
+}
+
+func (s *Service) auditPasswordReset(userID int64, action, path, userAgent, ip string) {
+	details := map[string]interface{}{
+		"method":      "POST",
+		"url":         path,
+		"status":      constants.AuditSuccess,
+		"status_code": 200,
+		"ip":          ip,
+		"user_agent":  userAgent,
+	}
+
+	if err := s.repo.CreateAuditLog(userID, action, true, details); err != nil {
+		logger.Error(fmt.Sprintf("Failed to audit %s for user %d: %v", action, userID, err))
+	}
+}
 
-	// Convert to LoginRequest and call Login
-	loginReq := &LoginRequest{
-		UserIdentity: *user.UserIdentity,
-		Password:     req.Password,
+func personalInfo(user *User) password.PersonalInfo {
+	info := password.PersonalInfo{Name: user.Name, Email: user.Email}
+	if user.UserIdentity != nil {
+		info.UserIdentity = *user.UserIdentity
 	}
+	return info
+}
+
+// primaryCompanyID returns the company of the first role assignment, carried in JWT access tokens
+func (s *Service) primaryCompanyID(userID int64) int64 {
+	userRoles, err := s.repo.GetUserRoles(userID)
+	if err != nil || len(userRoles) == 0 {
+		return 0
+	}
+	return userRoles[0].CompanyID
+}
 
-	return s.Login(loginReq, userAgent, ip)
+// GetJWKS returns the public keys that verify JWT access tokens and ID tokens
+func (s *Service) GetJWKS() (*jwt.JSONWebKeySet, error) {
+	if s.signingKeys == nil {
+		return nil, errors.New("jwks tidak ditemukan, kunci penandatanganan belum dikonfigurasi")
+	}
+	jwks := s.signingKeys.JWKS()
+	return &jwks, nil
 }
 
 func (s *Service) generateFamilyID() (string, error) {
@@ -174,8 +525,14 @@ func (s *Service) generateFamilyID() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-func (s *Service) RefreshToken(req *RefreshTokenRequest) (*RefreshTokenResponse, error) {
-	refreshMetadata, err := s.tokenService.GetRefreshToken(req.RefreshToken)
+// RefreshToken rotates a refresh token. The presented token is consumed, so
+// presenting it a second time is treated as theft and revokes the whole family.
+func (s *Service) RefreshToken(req *RefreshTokenRequest, userAgent, ip string) (*RefreshTokenResponse, error) {
+	refreshMetadata, err := s.tokenService.ConsumeRefreshToken(req.RefreshToken)
+	if errors.Is(err, token.ErrRefreshTokenReused) {
+		s.auditRefreshTokenReuse(refreshMetadata, userAgent, ip)
+		return nil, errors.New("refresh token sudah pernah digunakan, seluruh sesi terkait telah dicabut")
+	}
 	if err != nil {
 		return nil, errors.New("refresh token tidak valid atau kedaluwarsa")
 	}
@@ -204,11 +561,6 @@ func (s *Service) RefreshToken(req *RefreshTokenRequest) (*RefreshTokenResponse,
 		moduleURLs = []string{}
 	}
 
-	accessToken, err := s.tokenService.GenerateToken()
-	if err != nil {
-		return nil, err
-	}
-
 	newRefreshToken, err := s.tokenService.GenerateToken()
 	if err != nil {
 		return nil, err
@@ -216,24 +568,38 @@ func (s *Service) RefreshToken(req *RefreshTokenRequest) (*RefreshTokenResponse,
 
 	expiresAt := time.Now().Add(15 * time.Minute)
 
-	accessMetadata := token.TokenMetadata{
+	newRefreshMetadata := token.RefreshTokenMetadata{
 		UserID:    user.ID,
-		UserAgent: "",
-		IP:        "",
-		Abilities: moduleURLs,
-		ExpiresAt: expiresAt.Unix(),
+		FamilyID:  refreshMetadata.FamilyID,
+		UserAgent: refreshMetadata.UserAgent,
+		IP:        refreshMetadata.IP,
+		ClientID:  refreshMetadata.ClientID,
+		Scope:     refreshMetadata.Scope,
 	}
 
-	if err := s.tokenService.StoreAccessToken(accessToken, accessMetadata, 15*time.Minute); err != nil {
+	if err := s.tokenService.StoreRefreshToken(newRefreshToken, newRefreshMetadata, 7*24*time.Hour); err != nil {
 		return nil, err
 	}
 
-	newRefreshMetadata := token.RefreshTokenMetadata{
-		UserID:   user.ID,
-		FamilyID: refreshMetadata.FamilyID,
+	var companyID int64
+	if len(userRoles) > 0 {
+		companyID = userRoles[0].CompanyID
 	}
 
-	if err := s.tokenService.StoreRefreshToken(newRefreshToken, newRefreshMetadata, 7*24*time.Hour); err != nil {
+	accessMetadata := token.TokenMetadata{
+		UserID:    user.ID,
+		CompanyID: companyID,
+		UserAgent: userAgent,
+		IP:        ip,
+		Abilities: moduleURLs,
+		ExpiresAt: expiresAt.Unix(),
+		FamilyID:  refreshMetadata.FamilyID,
+		ClientID:  refreshMetadata.ClientID,
+		Scope:     refreshMetadata.Scope,
+	}
+
+	accessToken, err := s.tokenService.IssueAccessToken(accessMetadata, 15*time.Minute)
+	if err != nil {
 		return nil, err
 	}
 
@@ -247,17 +613,36 @@ func (s *Service) RefreshToken(req *RefreshTokenRequest) (*RefreshTokenResponse,
 	}, nil
 }
 
+// auditRefreshTokenReuse records a replayed refresh token in the audit log
+func (s *Service) auditRefreshTokenReuse(metadata *token.RefreshTokenMetadata, userAgent, ip string) {
+	details := map[string]interface{}{
+		"method":      "POST",
+		"url":         "/api/v1/auth/refresh",
+		"status":      constants.AuditWarning,
+		"status_code": 401,
+		"message":     "Refresh token reuse detected, token family revoked",
+		"family_id":   metadata.FamilyID,
+		"ip":          ip,
+		"user_agent":  userAgent,
+	}
+
+	if err := s.repo.CreateAuditLog(metadata.UserID, "refresh_token_reuse", false, details); err != nil {
+		logger.Error(fmt.Sprintf("Failed to audit refresh token reuse for user %d: %v", metadata.UserID, err))
+	}
+}
+
+// Logout ends the session the access token belongs to; other sessions stay active
 func (s *Service) Logout(accessToken string) error {
 	metadata, err := s.tokenService.GetAccessToken(accessToken)
 	if err != nil {
 		return nil
 	}
 
-	if err := s.tokenService.RevokeAllUserTokens(metadata.UserID); err != nil {
-		return err
+	if metadata.FamilyID == "" {
+		return s.tokenService.RevokeAllUserTokens(metadata.UserID)
 	}
 
-	return nil
+	return s.tokenService.RevokeFamily(metadata.FamilyID)
 }
 
 func (s *Service) LogoutByUserID(userID int64) error {
@@ -289,6 +674,42 @@ func (s *Service) GetUserRefreshTokenCount(userID int64) (int64, error) {
 
 	return int64(len(tokensResponse.RefreshTokens)), nil
 }
+
+func (s *Service) GetUserAccessTokenCount(userID int64) (int64, error) {
+	tokensResponse, err := s.tokenService.GetUserTokens(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(tokensResponse.AccessTokens)), nil
+}
+
+// GetSessions lists active sessions of a user and flags the one making the request
+func (s *Service) GetSessions(userID int64, currentSessionID string) (*SessionListResponse, error) {
+	sessions, err := s.tokenService.GetUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == currentSessionID
+	}
+
+	return &SessionListResponse{
+		Sessions: sessions,
+		Total:    len(sessions),
+	}, nil
+}
+
+// RevokeSession ends one session of a user
+func (s *Service) RevokeSession(userID int64, sessionID string) error {
+	if err := s.tokenService.RevokeSession(userID, sessionID); err != nil {
+		return errors.New("sesi tidak ditemukan (session not found)")
+	}
+
+	return nil
+}
+
 func (s *Service) GetUserProfile(req *ProfileRequest) (*ProfileResponse, error) {
 	userProfile, err := s.repo.GetUserProfileByApplication(req.UserIdentity, req.ApplicationCode)
 	if err != nil {
@@ -299,3 +720,287 @@ func (s *Service) GetUserProfile(req *ProfileRequest) (*ProfileResponse, error)
 		User: userProfile,
 	}, nil
 }
+
+// startTwoFactorChallenge returns a challenge response when the user must pass a second factor
+func (s *Service) startTwoFactorChallenge(user *User, userAgent, ip string) (*LoginResponse, error) {
+	twoFactor, err := s.repo.GetTwoFactor(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := twoFactor != nil && twoFactor.IsEnabled
+
+	required, err := s.repo.IsTwoFactorRequired(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled && !required {
+		return nil, nil
+	}
+
+	challengeToken, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := token.LoginChallenge{
+		UserID:        user.ID,
+		UserAgent:     userAgent,
+		IP:            ip,
+		SetupRequired: !enabled,
+	}
+	ttl := time.Duration(constants.TwoFactorChallengeTTL) * time.Second
+	if err := s.tokenService.StoreLoginChallenge(challengeToken, challenge, ttl); err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		TwoFactorRequired:      true,
+		TwoFactorSetupRequired: !enabled,
+		ChallengeToken:         challengeToken,
+		ChallengeExpiresIn:     constants.TwoFactorChallengeTTL,
+	}, nil
+}
+
+// SetupTwoFactorLogin generates a secret for a user whose policy requires 2FA but who has not enrolled yet
+func (s *Service) SetupTwoFactorLogin(req *TwoFactorChallengeRequest) (*TwoFactorSetupResponse, error) {
+	challenge, err := s.tokenService.GetLoginChallenge(req.ChallengeToken)
+	if err != nil || challenge.PasswordChangeRequired {
+		return nil, errors.New("challenge token tidak valid atau kedaluwarsa")
+	}
+
+	if !challenge.SetupRequired {
+		return nil, errors.New("autentikasi dua faktor sudah aktif untuk pengguna ini")
+	}
+
+	return s.EnrollTwoFactor(challenge.UserID)
+}
+
+// VerifyTwoFactorLogin completes the second login step and issues the session tokens
+func (s *Service) VerifyTwoFactorLogin(req *TwoFactorVerifyRequest) (*LoginResponse, error) {
+	challenge, err := s.tokenService.GetLoginChallenge(req.ChallengeToken)
+	if err != nil || challenge.PasswordChangeRequired {
+		return nil, errors.New("challenge token tidak valid atau kedaluwarsa")
+	}
+
+	user, err := s.repo.GetByID(challenge.UserID)
+	if err != nil || !user.IsActive {
+		return nil, errors.New("akun pengguna tidak aktif")
+	}
+
+	// Failed codes count against the account like failed passwords, so new challenges
+	// do not give a fresh set of guesses
+	if err := s.loginGuard.CheckAccount(user.ID); err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	if challenge.SetupRequired {
+		recoveryCodes, err = s.confirmTwoFactor(user.ID, req.Code)
+	} else {
+		err = s.verifySecondFactor(user.ID, req.Code, true)
+	}
+	if err != nil {
+		s.recordLoginFailure(user.ID, "Invalid second factor", challenge.UserAgent, challenge.IP)
+		attempts, _ := s.tokenService.RecordLoginChallengeFailure(req.ChallengeToken)
+		if attempts >= constants.TwoFactorMaxAttempts {
+			s.tokenService.DeleteLoginChallenge(req.ChallengeToken)
+		}
+		return nil, err
+	}
+
+	// Only one verification may turn the challenge into a session
+	consumed, err := s.tokenService.DeleteLoginChallenge(req.ChallengeToken)
+	if err != nil || !consumed {
+		return nil, errors.New("challenge token tidak valid atau kedaluwarsa")
+	}
+	s.recordLoginSuccess(user.ID)
+
+	loginResponse, err := s.finishLogin(user, challenge.UserAgent, challenge.IP)
+	if err != nil {
+		return nil, err
+	}
+	loginResponse.RecoveryCodes = recoveryCodes
+
+	return loginResponse, nil
+}
+
+// GetTwoFactorStatus returns the 2FA state of a user
+func (s *Service) GetTwoFactorStatus(userID int64) (*TwoFactorStatusResponse, error) {
+	twoFactor, err := s.repo.GetTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := s.repo.IsTwoFactorRequired(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatusResponse{
+		Enabled:  twoFactor != nil && twoFactor.IsEnabled,
+		Required: required,
+	}
+	if status.Enabled {
+		if status.RecoveryCodesRemaining, err = s.repo.CountUnusedRecoveryCodes(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// EnrollTwoFactor generates a new pending secret and its provisioning URI
+func (s *Service) EnrollTwoFactor(userID int64) (*TwoFactorSetupResponse, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("pengguna tidak ditemukan")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SavePendingTwoFactor(userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(constants.TwoFactorIssuer, user.Email, secret),
+		Issuer:          constants.TwoFactorIssuer,
+		AccountName:     user.Email,
+	}, nil
+}
+
+// EnableTwoFactor confirms a pending enrollment with a TOTP code
+func (s *Service) EnableTwoFactor(userID int64, req *TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	recoveryCodes, err := s.confirmTwoFactor(userID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableTwoFactor removes 2FA after verifying a code, unless a policy requires it
+func (s *Service) DisableTwoFactor(userID int64, req *TwoFactorCodeRequest) error {
+	required, err := s.repo.IsTwoFactorRequired(userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("autentikasi dua faktor diwajibkan oleh kebijakan perusahaan dan tidak dapat dinonaktifkan")
+	}
+
+	if err := s.verifySecondFactor(userID, req.Code, true); err != nil {
+		return err
+	}
+
+	return s.repo.DisableTwoFactor(userID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a TOTP code
+func (s *Service) RegenerateRecoveryCodes(userID int64, req *TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	if err := s.verifySecondFactor(userID, req.Code, false); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, codeHashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(userID, codeHashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// confirmTwoFactor validates the first code of a pending enrollment and enables it
+func (s *Service) confirmTwoFactor(userID int64, code string) ([]string, error) {
+	twoFactor, err := s.repo.GetTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor == nil || twoFactor.IsEnabled {
+		return nil, errors.New("tidak ada pendaftaran autentikasi dua faktor yang menunggu konfirmasi")
+	}
+
+	step, ok := totp.Validate(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return nil, errors.New("kode verifikasi tidak valid")
+	}
+
+	recoveryCodes, codeHashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.EnableTwoFactor(userID, step, codeHashes); err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// verifySecondFactor checks a TOTP code, or a single-use recovery code when allowed
+func (s *Service) verifySecondFactor(userID int64, code string, allowRecoveryCode bool) error {
+	twoFactor, err := s.repo.GetTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	if twoFactor == nil || !twoFactor.IsEnabled {
+		return errors.New("autentikasi dua faktor belum aktif")
+	}
+
+	if step, ok := totp.Validate(twoFactor.Secret, code, time.Now()); ok {
+		// A code is accepted only once, even within its validity window
+		fresh, err := s.repo.MarkTwoFactorStepUsed(userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return errors.New("kode verifikasi sudah digunakan")
+		}
+		return nil
+	}
+
+	if allowRecoveryCode {
+		used, err := s.repo.UseRecoveryCode(userID, s.tokenService.HashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+	}
+
+	return errors.New("kode verifikasi tidak valid")
+}
+
+// generateRecoveryCodes returns plain codes for the user and their hashes for storage
+func (s *Service) generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, constants.TwoFactorRecoveryCodeCount)
+	hashes := make([]string, 0, constants.TwoFactorRecoveryCodeCount)
+
+	for i := 0; i < constants.TwoFactorRecoveryCodeCount; i++ {
+		bytes := make([]byte, 5)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(bytes)
+		code := raw[:5] + "-" + raw[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, s.tokenService.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -43,6 +43,47 @@ type CompanyListResponse struct {
 	HasMore bool               `json:"has_more"`
 }
 
+// UpdateTwoFactorPolicyRequest replaces the 2FA policy of a company
+type UpdateTwoFactorPolicyRequest struct {
+	RequireForAll bool    `json:"require_for_all"`
+	RoleIDs       []int64 `json:"role_ids" validate:"omitempty,dive,min=1"`
+}
+
+// UpdatePasswordPolicyRequest replaces the password policy of a company
+type UpdatePasswordPolicyRequest struct {
+	MinLength        int  `json:"min_length" validate:"required,min=6,max=72"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+	HistoryCount     int  `json:"history_count" validate:"min=0,max=24"`
+	MaxAgeDays       int  `json:"max_age_days" validate:"min=0,max=3650"`
+}
+
+type PasswordPolicyResponse struct {
+	CompanyID int64 `json:"company_id"`
+	// IsDefault is true when the company has no own policy and the service default applies
+	IsDefault        bool `json:"is_default"`
+	MinLength        int  `json:"min_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+	HistoryCount     int  `json:"history_count"`
+	MaxAgeDays       int  `json:"max_age_days"`
+}
+
+type TwoFactorPolicyRoleResponse struct {
+	RoleID   int64  `json:"role_id"`
+	RoleName string `json:"role_name"`
+}
+
+type TwoFactorPolicyResponse struct {
+	CompanyID     int64                         `json:"company_id"`
+	RequireForAll bool                          `json:"require_for_all"`
+	Roles         []TwoFactorPolicyRoleResponse `json:"roles"`
+}
+
 // Validation functions
 var validate *validator.Validate
 
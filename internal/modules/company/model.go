@@ -20,3 +20,35 @@ type CompanyWithStats struct {
 func (Company) TableName() string {
 	return "companies"
 }
+
+// TwoFactorPolicy requires 2FA for a whole company (RoleID nil) or for holders of one role in it
+type TwoFactorPolicy struct {
+	ID        int64     `json:"id" db:"id"`
+	CompanyID int64     `json:"company_id" db:"company_id"`
+	RoleID    *int64    `json:"role_id" db:"role_id"`
+	RoleName  *string   `json:"role_name,omitempty" db:"-"`
+	CreatedBy *int64    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+func (TwoFactorPolicy) TableName() string {
+	return "two_factor_policies"
+}
+
+// PasswordPolicy overrides the default password policy for the users of a company
+type PasswordPolicy struct {
+	CompanyID        int64     `json:"company_id" db:"company_id"`
+	MinLength        int       `json:"min_length" db:"min_length"`
+	RequireUppercase bool      `json:"require_uppercase" db:"require_uppercase"`
+	RequireLowercase bool      `json:"require_lowercase" db:"require_lowercase"`
+	RequireDigit     bool      `json:"require_digit" db:"require_digit"`
+	RequireSymbol    bool      `json:"require_symbol" db:"require_symbol"`
+	HistoryCount     int       `json:"history_count" db:"history_count"`
+	MaxAgeDays       int       `json:"max_age_days" db:"max_age_days"`
+	UpdatedBy        *int64    `json:"updated_by" db:"updated_by"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (PasswordPolicy) TableName() string {
+	return "password_policies"
+}
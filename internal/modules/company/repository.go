@@ -227,3 +227,141 @@ func (r *CompanyRepository) Count(search string, isActive *bool) (int64, error)
 
 	return count, nil
 }
+
+// IsCompanyAdmin checks whether a user is SUPER_ADMIN or COMPANY_ADMIN of the company
+func (r *CompanyRepository) IsCompanyAdmin(userID, companyID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true
+				AND (r.name = 'SUPER_ADMIN' OR (r.name = 'COMPANY_ADMIN' AND ur.company_id = $2))
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID, companyID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check company admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// GetTwoFactorPolicies retrieves the 2FA policies of a company
+func (r *CompanyRepository) GetTwoFactorPolicies(companyID int64) ([]*TwoFactorPolicy, error) {
+	query := `
+		SELECT tfp.id, tfp.company_id, tfp.role_id, r.name, tfp.created_by, tfp.created_at
+		FROM two_factor_policies tfp
+		LEFT JOIN roles r ON tfp.role_id = r.id
+		WHERE tfp.company_id = $1
+		ORDER BY tfp.role_id NULLS FIRST
+	`
+
+	rows, err := r.db.Query(query, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two factor policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []*TwoFactorPolicy
+	for rows.Next() {
+		policy := &TwoFactorPolicy{}
+		if err := rows.Scan(&policy.ID, &policy.CompanyID, &policy.RoleID, &policy.RoleName,
+			&policy.CreatedBy, &policy.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan two factor policy: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// ReplaceTwoFactorPolicies replaces all 2FA policies of a company in one transaction
+func (r *CompanyRepository) ReplaceTwoFactorPolicies(companyID int64, requireForAll bool, roleIDs []int64, createdBy int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM two_factor_policies WHERE company_id = $1`, companyID); err != nil {
+		return fmt.Errorf("failed to delete two factor policies: %w", err)
+	}
+
+	insert := `INSERT INTO two_factor_policies (company_id, role_id, created_by) VALUES ($1, $2, $3)`
+	if requireForAll {
+		if _, err := tx.Exec(insert, companyID, nil, createdBy); err != nil {
+			return fmt.Errorf("failed to create two factor policy: %w", err)
+		}
+	}
+	for _, roleID := range roleIDs {
+		if _, err := tx.Exec(insert, companyID, roleID, createdBy); err != nil {
+			return fmt.Errorf("failed to create two factor policy for role %d: %w", roleID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetPasswordPolicy retrieves the password policy of a company, nil when it uses the default
+func (r *CompanyRepository) GetPasswordPolicy(companyID int64) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{}
+	query := `
+		SELECT company_id, min_length, require_uppercase, require_lowercase, require_digit,
+		       require_symbol, history_count, max_age_days, updated_by, updated_at
+		FROM password_policies
+		WHERE company_id = $1
+	`
+
+	err := r.db.QueryRow(query, companyID).Scan(
+		&policy.CompanyID, &policy.MinLength, &policy.RequireUppercase, &policy.RequireLowercase,
+		&policy.RequireDigit, &policy.RequireSymbol, &policy.HistoryCount, &policy.MaxAgeDays,
+		&policy.UpdatedBy, &policy.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// SavePasswordPolicy creates or replaces the password policy of a company
+func (r *CompanyRepository) SavePasswordPolicy(policy *PasswordPolicy) error {
+	query := `
+		INSERT INTO password_policies (company_id, min_length, require_uppercase, require_lowercase,
+			require_digit, require_symbol, history_count, max_age_days, updated_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (company_id) DO UPDATE SET
+			min_length = EXCLUDED.min_length,
+			require_uppercase = EXCLUDED.require_uppercase,
+			require_lowercase = EXCLUDED.require_lowercase,
+			require_digit = EXCLUDED.require_digit,
+			require_symbol = EXCLUDED.require_symbol,
+			history_count = EXCLUDED.history_count,
+			max_age_days = EXCLUDED.max_age_days,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRow(query, policy.CompanyID, policy.MinLength, policy.RequireUppercase,
+		policy.RequireLowercase, policy.RequireDigit, policy.RequireSymbol, policy.HistoryCount,
+		policy.MaxAgeDays, policy.UpdatedBy).Scan(&policy.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save password policy: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePasswordPolicy removes the password policy of a company so the default applies again
+func (r *CompanyRepository) DeletePasswordPolicy(companyID int64) error {
+	if _, err := r.db.Exec(`DELETE FROM password_policies WHERE company_id = $1`, companyID); err != nil {
+		return fmt.Errorf("failed to delete password policy: %w", err)
+	}
+	return nil
+}
@@ -180,6 +180,205 @@ func (h *Handler) DeleteCompany(c *gin.Context) {
 	response.Success(c, http.StatusOK, constants.MsgCompanyDeleted, nil)
 }
 
+// @Summary      Get company two-factor policy
+// @Description  Mendapatkan kebijakan autentikasi dua faktor company (wajib untuk seluruh pengguna atau per role)
+// @Tags         Companies
+// @Produce      json
+// @Param        id   path      int  true  "Company ID"
+// @Success      200  {object}  response.Response{data=company.TwoFactorPolicyResponse}  "Kebijakan 2FA berhasil diambil"
+// @Failure      400  {object}  response.Response  "Bad request - Invalid company ID"
+// @Failure      404  {object}  response.Response  "Company tidak ditemukan"
+// @Router       /api/v1/companies/{id}/two-factor-policy [get]
+// @Security     BearerAuth
+func (h *Handler) GetTwoFactorPolicy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid company ID")
+		return
+	}
+
+	result, err := h.service.GetTwoFactorPolicy(id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgTwoFactorPolicy, result)
+}
+
+// @Summary      Update company two-factor policy
+// @Description  Mengganti kebijakan autentikasi dua faktor company. Hanya dapat dilakukan oleh COMPANY_ADMIN company tersebut atau SUPER_ADMIN
+// @Tags         Companies
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                                   true  "Company ID"
+// @Param        policy  body      company.UpdateTwoFactorPolicyRequest  true  "Kebijakan 2FA"
+// @Success      200     {object}  response.Response{data=company.TwoFactorPolicyResponse}  "Kebijakan 2FA berhasil diupdate"
+// @Failure      400     {object}  response.Response  "Bad request"
+// @Failure      403     {object}  response.Response  "Bukan admin company"
+// @Failure      404     {object}  response.Response  "Company tidak ditemukan"
+// @Router       /api/v1/companies/{id}/two-factor-policy [put]
+// @Security     BearerAuth
+func (h *Handler) UpdateTwoFactorPolicy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid company ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*UpdateTwoFactorPolicyRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.UpdateTwoFactorPolicy(userIDInt64, id, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgTwoFactorPolicySaved, result)
+}
+
+// @Summary      Get company password policy
+// @Description  Mendapatkan kebijakan password company. Bila company belum memiliki kebijakan sendiri, kebijakan default layanan dikembalikan dengan is_default=true
+// @Tags         Companies
+// @Produce      json
+// @Param        id   path      int  true  "Company ID"
+// @Success      200  {object}  response.Response{data=company.PasswordPolicyResponse}  "Kebijakan password berhasil diambil"
+// @Failure      400  {object}  response.Response  "Bad request - Invalid company ID"
+// @Failure      404  {object}  response.Response  "Company tidak ditemukan"
+// @Router       /api/v1/companies/{id}/password-policy [get]
+// @Security     BearerAuth
+func (h *Handler) GetPasswordPolicy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid company ID")
+		return
+	}
+
+	result, err := h.service.GetPasswordPolicy(id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgPasswordPolicy, result)
+}
+
+// @Summary      Update company password policy
+// @Description  Mengganti kebijakan password company: panjang minimal, jenis karakter, jumlah riwayat password yang tidak boleh dipakai ulang, dan umur maksimal password. Pengguna yang tergabung di beberapa company mendapat kebijakan paling ketat. Hanya dapat dilakukan oleh COMPANY_ADMIN company tersebut atau SUPER_ADMIN
+// @Tags         Companies
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                                  true  "Company ID"
+// @Param        policy  body      company.UpdatePasswordPolicyRequest  true  "Kebijakan password"
+// @Success      200     {object}  response.Response{data=company.PasswordPolicyResponse}  "Kebijakan password berhasil diupdate"
+// @Failure      400     {object}  response.Response  "Bad request"
+// @Failure      403     {object}  response.Response  "Bukan admin company"
+// @Failure      404     {object}  response.Response  "Company tidak ditemukan"
+// @Router       /api/v1/companies/{id}/password-policy [put]
+// @Security     BearerAuth
+func (h *Handler) UpdatePasswordPolicy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid company ID")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*UpdatePasswordPolicyRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.UpdatePasswordPolicy(userID, id, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgPasswordPolicySaved, result)
+}
+
+// @Summary      Reset company password policy
+// @Description  Menghapus kebijakan password company sehingga kebijakan default layanan kembali berlaku. Hanya dapat dilakukan oleh COMPANY_ADMIN company tersebut atau SUPER_ADMIN
+// @Tags         Companies
+// @Produce      json
+// @Param        id   path      int  true  "Company ID"
+// @Success      200  {object}  response.Response{data=company.PasswordPolicyResponse}  "Kebijakan password dikembalikan ke default"
+// @Failure      400  {object}  response.Response  "Bad request - Invalid company ID"
+// @Failure      403  {object}  response.Response  "Bukan admin company"
+// @Failure      404  {object}  response.Response  "Company tidak ditemukan"
+// @Router       /api/v1/companies/{id}/password-policy [delete]
+// @Security     BearerAuth
+func (h *Handler) ResetPasswordPolicy(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid company ID")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.ResetPasswordPolicy(userID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgPasswordPolicySaved, result)
+}
+
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
 // Route registration
 func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 	companies := api.Group("/companies")
@@ -208,5 +407,30 @@ func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 
 		// DELETE /api/v1/companies/:id - Delete company by ID
 		companies.DELETE("/:id", handler.DeleteCompany)
+
+		// GET /api/v1/companies/:id/two-factor-policy - Get two-factor policy of a company
+		companies.GET("/:id/two-factor-policy", handler.GetTwoFactorPolicy)
+
+		// PUT /api/v1/companies/:id/two-factor-policy - Replace two-factor policy of a company
+		companies.PUT("/:id/two-factor-policy",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &UpdateTwoFactorPolicyRequest{},
+			}),
+			handler.UpdateTwoFactorPolicy,
+		)
+
+		// GET /api/v1/companies/:id/password-policy - Get password policy of a company
+		companies.GET("/:id/password-policy", handler.GetPasswordPolicy)
+
+		// PUT /api/v1/companies/:id/password-policy - Replace password policy of a company
+		companies.PUT("/:id/password-policy",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &UpdatePasswordPolicyRequest{},
+			}),
+			handler.UpdatePasswordPolicy,
+		)
+
+		// DELETE /api/v1/companies/:id/password-policy - Revert to the default password policy
+		companies.DELETE("/:id/password-policy", handler.ResetPasswordPolicy)
 	}
 }
@@ -1,15 +1,18 @@
 package company
 
 import (
+	"errors"
+	"gin-scalable-api/pkg/password"
 	"time"
 )
 
 type Service struct {
-	repo *CompanyRepository
+	repo          *CompanyRepository
+	passwordStore *password.Store
 }
 
-func NewService(repo *CompanyRepository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *CompanyRepository, passwordStore *password.Store) *Service {
+	return &Service{repo: repo, passwordStore: passwordStore}
 }
 
 func (s *Service) GetCompanies(req *CompanyListRequest) (*CompanyListResponse, error) {
@@ -123,3 +126,150 @@ func toCompanyResponse(company *Company) *CompanyResponse {
 		UpdatedAt: company.UpdatedAt.Format(time.RFC3339),
 	}
 }
+
+func (s *Service) GetTwoFactorPolicy(companyID int64) (*TwoFactorPolicyResponse, error) {
+	if _, err := s.repo.GetByID(companyID); err != nil {
+		return nil, err
+	}
+
+	policies, err := s.repo.GetTwoFactorPolicies(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTwoFactorPolicyResponse(companyID, policies), nil
+}
+
+// UpdateTwoFactorPolicy replaces the 2FA requirement of a company; only its admins may change it
+func (s *Service) UpdateTwoFactorPolicy(adminID, companyID int64, req *UpdateTwoFactorPolicyRequest) (*TwoFactorPolicyResponse, error) {
+	if err := s.requireCompanyAdmin(adminID, companyID, "the two-factor policy"); err != nil {
+		return nil, err
+	}
+
+	// Drop duplicates so the unique (company, role) index is never hit
+	seen := make(map[int64]bool)
+	var roleIDs []int64
+	for _, roleID := range req.RoleIDs {
+		if !seen[roleID] {
+			seen[roleID] = true
+			roleIDs = append(roleIDs, roleID)
+		}
+	}
+
+	if err := s.repo.ReplaceTwoFactorPolicies(companyID, req.RequireForAll, roleIDs, adminID); err != nil {
+		return nil, err
+	}
+
+	return s.GetTwoFactorPolicy(companyID)
+}
+
+func (s *Service) GetPasswordPolicy(companyID int64) (*PasswordPolicyResponse, error) {
+	if _, err := s.repo.GetByID(companyID); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.repo.GetPasswordPolicy(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy == nil {
+		base := s.passwordStore.BasePolicy()
+		return &PasswordPolicyResponse{
+			CompanyID:        companyID,
+			IsDefault:        true,
+			MinLength:        base.MinLength,
+			RequireUppercase: base.RequireUppercase,
+			RequireLowercase: base.RequireLowercase,
+			RequireDigit:     base.RequireDigit,
+			RequireSymbol:    base.RequireSymbol,
+			HistoryCount:     base.HistoryCount,
+			MaxAgeDays:       base.MaxAgeDays,
+		}, nil
+	}
+
+	return &PasswordPolicyResponse{
+		CompanyID:        companyID,
+		MinLength:        policy.MinLength,
+		RequireUppercase: policy.RequireUppercase,
+		RequireLowercase: policy.RequireLowercase,
+		RequireDigit:     policy.RequireDigit,
+		RequireSymbol:    policy.RequireSymbol,
+		HistoryCount:     policy.HistoryCount,
+		MaxAgeDays:       policy.MaxAgeDays,
+	}, nil
+}
+
+// UpdatePasswordPolicy replaces the password policy of a company; only its admins may change it
+func (s *Service) UpdatePasswordPolicy(adminID, companyID int64, req *UpdatePasswordPolicyRequest) (*PasswordPolicyResponse, error) {
+	if err := s.requireCompanyAdmin(adminID, companyID, "the password policy"); err != nil {
+		return nil, err
+	}
+
+	policy := &PasswordPolicy{
+		CompanyID:        companyID,
+		MinLength:        req.MinLength,
+		RequireUppercase: req.RequireUppercase,
+		RequireLowercase: req.RequireLowercase,
+		RequireDigit:     req.RequireDigit,
+		RequireSymbol:    req.RequireSymbol,
+		HistoryCount:     req.HistoryCount,
+		MaxAgeDays:       req.MaxAgeDays,
+		UpdatedBy:        &adminID,
+	}
+	if err := s.repo.SavePasswordPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	return s.GetPasswordPolicy(companyID)
+}
+
+// ResetPasswordPolicy drops the own password policy of a company so the default applies again
+func (s *Service) ResetPasswordPolicy(adminID, companyID int64) (*PasswordPolicyResponse, error) {
+	if err := s.requireCompanyAdmin(adminID, companyID, "the password policy"); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.DeletePasswordPolicy(companyID); err != nil {
+		return nil, err
+	}
+
+	return s.GetPasswordPolicy(companyID)
+}
+
+func (s *Service) requireCompanyAdmin(adminID, companyID int64, subject string) error {
+	if _, err := s.repo.GetByID(companyID); err != nil {
+		return err
+	}
+
+	isAdmin, err := s.repo.IsCompanyAdmin(adminID, companyID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("access denied: only company admins can change " + subject)
+	}
+	return nil
+}
+
+func toTwoFactorPolicyResponse(companyID int64, policies []*TwoFactorPolicy) *TwoFactorPolicyResponse {
+	response := &TwoFactorPolicyResponse{
+		CompanyID: companyID,
+		Roles:     []TwoFactorPolicyRoleResponse{},
+	}
+
+	for _, policy := range policies {
+		if policy.RoleID == nil {
+			response.RequireForAll = true
+			continue
+		}
+
+		role := TwoFactorPolicyRoleResponse{RoleID: *policy.RoleID}
+		if policy.RoleName != nil {
+			role.RoleName = *policy.RoleName
+		}
+		response.Roles = append(response.Roles, role)
+	}
+
+	return response
+}
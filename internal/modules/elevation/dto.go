@@ -0,0 +1,65 @@
+package elevation
+
+// CreateElevationRequest asks for a role at a company, branch or unit scope for a
+// limited time
+type CreateElevationRequest struct {
+	RoleID          int64  `json:"role_id" validate:"required,min=1"`
+	CompanyID       int64  `json:"company_id" validate:"required,min=1"`
+	BranchID        *int64 `json:"branch_id" validate:"omitempty,min=1"`
+	UnitID          *int64 `json:"unit_id" validate:"omitempty,min=1"`
+	DurationMinutes int    `json:"duration_minutes" validate:"required,min=15"`
+	Justification   string `json:"justification" validate:"required,min=10,max=1000"`
+}
+
+// DecisionRequest carries the note of an approval, rejection or cancellation; a
+// rejection requires one
+type DecisionRequest struct {
+	Note string `json:"note"`
+}
+
+// ElevationListRequest filters elevation requests. With company_id the approvers of the
+// company see all its requests; without it users see their own.
+type ElevationListRequest struct {
+	CompanyID int64  `form:"company_id"`
+	UserID    int64  `form:"user_id"`
+	Status    string `form:"status"`
+	Limit     int    `form:"limit"`
+	Offset    int    `form:"offset"`
+}
+
+type ElevationEventResponse struct {
+	Event     string  `json:"event"`
+	ActorID   *int64  `json:"actor_id"`
+	ActorName *string `json:"actor_name"`
+	Note      *string `json:"note"`
+	CreatedAt string  `json:"created_at"`
+}
+
+type ElevationResponse struct {
+	ID              int64                    `json:"id"`
+	UserID          int64                    `json:"user_id"`
+	UserName        string                   `json:"user_name"`
+	RoleID          int64                    `json:"role_id"`
+	RoleName        string                   `json:"role_name"`
+	CompanyID       int64                    `json:"company_id"`
+	BranchID        *int64                   `json:"branch_id"`
+	UnitID          *int64                   `json:"unit_id"`
+	DurationMinutes int                      `json:"duration_minutes"`
+	Justification   string                   `json:"justification"`
+	Status          string                   `json:"status"`
+	DecidedBy       *int64                   `json:"decided_by"`
+	DecidedAt       *string                  `json:"decided_at"`
+	DecisionNote    *string                  `json:"decision_note"`
+	UserRoleID      *int64                   `json:"user_role_id"`
+	ExpiresAt       string                   `json:"expires_at"`
+	CreatedAt       string                   `json:"created_at"`
+	History         []ElevationEventResponse `json:"history,omitempty"`
+}
+
+type ElevationListResponse struct {
+	Data    []*ElevationResponse `json:"data"`
+	Total   int64                `json:"total"`
+	Limit   int                  `json:"limit"`
+	Offset  int                  `json:"offset"`
+	HasMore bool                 `json:"has_more"`
+}
@@ -0,0 +1,75 @@
+package elevation
+
+import (
+	"time"
+)
+
+// Request statuses; an expired request keeps status pending or approved and is recognized by ExpiresAt
+const (
+	StatusPending   = "pending"
+	StatusApproved  = "approved"
+	StatusRejected  = "rejected"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
+)
+
+// History events; EventExpired is derived from ExpiresAt and never stored
+const (
+	EventRequested = "requested"
+	EventApproved  = "approved"
+	EventRejected  = "rejected"
+	EventCancelled = "cancelled"
+	EventExpired   = "expired"
+)
+
+// ElevationRequest asks for a role at a company, branch or unit scope for a bounded
+// duration. While pending, ExpiresAt is the approval deadline; once approved it is the
+// end of the temporary assignment.
+type ElevationRequest struct {
+	ID              int64      `json:"id" db:"id"`
+	UserID          int64      `json:"user_id" db:"user_id"`
+	UserName        string     `json:"user_name" db:"-"`
+	RoleID          int64      `json:"role_id" db:"role_id"`
+	RoleName        string     `json:"role_name" db:"-"`
+	CompanyID       int64      `json:"company_id" db:"company_id"`
+	BranchID        *int64     `json:"branch_id" db:"branch_id"`
+	UnitID          *int64     `json:"unit_id" db:"unit_id"`
+	DurationMinutes int        `json:"duration_minutes" db:"duration_minutes"`
+	Justification   string     `json:"justification" db:"justification"`
+	Status          string     `json:"status" db:"status"`
+	DecidedBy       *int64     `json:"decided_by" db:"decided_by"`
+	DecidedAt       *time.Time `json:"decided_at" db:"decided_at"`
+	DecisionNote    *string    `json:"decision_note" db:"decision_note"`
+	UserRoleID      *int64     `json:"user_role_id" db:"user_role_id"`
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (ElevationRequest) TableName() string {
+	return "elevation_requests"
+}
+
+// EffectiveStatus reports pending requests past their approval deadline and approved
+// requests past the end of the elevation as expired
+func (r *ElevationRequest) EffectiveStatus(now time.Time) string {
+	if (r.Status == StatusPending || r.Status == StatusApproved) && !now.Before(r.ExpiresAt) {
+		return StatusExpired
+	}
+	return r.Status
+}
+
+// ElevationEvent is one entry in the history of a request
+type ElevationEvent struct {
+	ID        int64     `json:"id" db:"id"`
+	RequestID int64     `json:"request_id" db:"request_id"`
+	Event     string    `json:"event" db:"event"`
+	ActorID   *int64    `json:"actor_id" db:"actor_id"`
+	ActorName *string   `json:"actor_name" db:"-"`
+	Note      *string   `json:"note" db:"note"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+func (ElevationEvent) TableName() string {
+	return "elevation_request_events"
+}
@@ -0,0 +1,423 @@
+package elevation
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const elevationSelect = `
+	SELECT e.id, e.user_id, u.name, e.role_id, r.name, e.company_id, e.branch_id, e.unit_id,
+		e.duration_minutes, e.justification, e.status, e.decided_by, e.decided_at, e.decision_note,
+		e.user_role_id, e.expires_at, e.created_at, e.updated_at
+	FROM elevation_requests e
+	JOIN users u ON e.user_id = u.id
+	JOIN roles r ON e.role_id = r.id
+`
+
+func scanElevation(scanner interface{ Scan(...interface{}) error }) (*ElevationRequest, error) {
+	request := &ElevationRequest{}
+	err := scanner.Scan(
+		&request.ID, &request.UserID, &request.UserName, &request.RoleID, &request.RoleName,
+		&request.CompanyID, &request.BranchID, &request.UnitID, &request.DurationMinutes,
+		&request.Justification, &request.Status, &request.DecidedBy, &request.DecidedAt,
+		&request.DecisionNote, &request.UserRoleID, &request.ExpiresAt, &request.CreatedAt, &request.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// IsSuperAdmin checks whether a user holds the SUPER_ADMIN role
+func (r *Repository) IsSuperAdmin(userID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true AND r.name = 'SUPER_ADMIN'
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check super admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// IsCompanyMember checks whether a user holds any active role in a company
+func (r *Repository) IsCompanyMember(userID, companyID int64) (bool, error) {
+	var isMember bool
+	query := `SELECT EXISTS(SELECT 1 FROM active_user_roles WHERE user_id = $1 AND company_id = $2)`
+	if err := r.db.QueryRow(query, userID, companyID).Scan(&isMember); err != nil {
+		return false, fmt.Errorf("failed to check company membership: %w", err)
+	}
+	return isMember, nil
+}
+
+// ApproverIDs returns the active users who may decide the elevation requests of a company:
+// holders of can_approve on the approver module, holders of the approver role in the
+// company, and super admins
+func (r *Repository) ApproverIDs(companyID int64, moduleURL, roleName string) ([]int64, error) {
+	query := `
+		SELECT DISTINCT ur.user_id
+		FROM active_user_roles ur
+		JOIN roles r ON ur.role_id = r.id AND r.is_active = true
+		JOIN users u ON ur.user_id = u.id AND u.is_active = true AND u.deleted_at IS NULL
+		WHERE r.name = 'SUPER_ADMIN'
+			OR (ur.company_id = $1 AND (
+				($3 <> '' AND r.name = $3)
+				OR ($2 <> '' AND EXISTS(
+					SELECT 1
+					FROM role_ancestors ra
+					JOIN role_modules rm ON rm.role_id = ra.ancestor_id
+					JOIN modules m ON rm.module_id = m.id
+					WHERE ra.role_id = ur.role_id AND rm.can_approve = true
+						AND m.is_active = true AND m.url = $2
+				))
+			))
+		ORDER BY ur.user_id
+	`
+
+	rows, err := r.db.Query(query, companyID, moduleURL, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approvers: %w", err)
+	}
+	defer rows.Close()
+
+	var approverIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan approver: %w", err)
+		}
+		approverIDs = append(approverIDs, userID)
+	}
+
+	return approverIDs, rows.Err()
+}
+
+// CompanyExists checks whether an active company exists
+func (r *Repository) CompanyExists(companyID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM companies WHERE id = $1 AND is_active = true)`
+	if err := r.db.QueryRow(query, companyID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check company: %w", err)
+	}
+	return exists, nil
+}
+
+// BranchBelongsToCompany checks whether a branch is part of a company
+func (r *Repository) BranchBelongsToCompany(branchID, companyID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM branches WHERE id = $1 AND company_id = $2)`
+	if err := r.db.QueryRow(query, branchID, companyID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check branch: %w", err)
+	}
+	return exists, nil
+}
+
+// UnitBranchID returns the branch of a unit within a company
+func (r *Repository) UnitBranchID(unitID, companyID int64) (int64, error) {
+	var branchID int64
+	query := `
+		SELECT u.branch_id
+		FROM units u
+		JOIN branches b ON u.branch_id = b.id
+		WHERE u.id = $1 AND b.company_id = $2
+	`
+	if err := r.db.QueryRow(query, unitID, companyID).Scan(&branchID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("unit tidak ditemukan di perusahaan ini (unit not found)")
+		}
+		return 0, fmt.Errorf("failed to check unit: %w", err)
+	}
+	return branchID, nil
+}
+
+// GetRoleName returns the name of an active role
+func (r *Repository) GetRoleName(roleID int64) (string, error) {
+	var name string
+	query := `SELECT name FROM roles WHERE id = $1 AND is_active = true`
+	if err := r.db.QueryRow(query, roleID).Scan(&name); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("peran dengan ID %d tidak ditemukan (role not found)", roleID)
+		}
+		return "", fmt.Errorf("failed to get role: %w", err)
+	}
+	return name, nil
+}
+
+// HoldsRole checks whether a user already holds a role in effect at exactly the given scope
+func (r *Repository) HoldsRole(userID, roleID, companyID int64, branchID, unitID *int64) (bool, error) {
+	var holds bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM active_user_roles
+			WHERE user_id = $1 AND role_id = $2 AND company_id = $3
+				AND branch_id IS NOT DISTINCT FROM $4 AND unit_id IS NOT DISTINCT FROM $5
+		)
+	`
+	if err := r.db.QueryRow(query, userID, roleID, companyID, branchID, unitID).Scan(&holds); err != nil {
+		return false, fmt.Errorf("failed to check role assignment: %w", err)
+	}
+	return holds, nil
+}
+
+// HasOpenRequest checks whether a user already has an unexpired pending or approved
+// request for a role at the given scope
+func (r *Repository) HasOpenRequest(userID, roleID, companyID int64, branchID, unitID *int64) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM elevation_requests
+			WHERE user_id = $1 AND role_id = $2 AND company_id = $3
+				AND branch_id IS NOT DISTINCT FROM $4 AND unit_id IS NOT DISTINCT FROM $5
+				AND status IN ('pending', 'approved') AND expires_at > NOW()
+		)
+	`
+	if err := r.db.QueryRow(query, userID, roleID, companyID, branchID, unitID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check elevation requests: %w", err)
+	}
+	return exists, nil
+}
+
+// Create stores a new pending request together with its requested event
+func (r *Repository) Create(request *ElevationRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO elevation_requests (user_id, role_id, company_id, branch_id, unit_id, duration_minutes,
+			justification, status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending', $8, NOW(), NOW())
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err = tx.QueryRow(query, request.UserID, request.RoleID, request.CompanyID, request.BranchID, request.UnitID,
+		request.DurationMinutes, request.Justification, request.ExpiresAt,
+	).Scan(&request.ID, &request.Status, &request.CreatedAt, &request.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create elevation request: %w", err)
+	}
+
+	if err := addEvent(tx, request.ID, EventRequested, &request.UserID, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an elevation request by ID
+func (r *Repository) GetByID(id int64) (*ElevationRequest, error) {
+	request, err := scanElevation(r.db.QueryRow(elevationSelect+" WHERE e.id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("permintaan elevasi tidak ditemukan (elevation request not found)")
+		}
+		return nil, fmt.Errorf("failed to get elevation request: %w", err)
+	}
+	return request, nil
+}
+
+// GetEvents retrieves the stored history of a request, oldest first
+func (r *Repository) GetEvents(requestID int64) ([]*ElevationEvent, error) {
+	query := `
+		SELECT ev.id, ev.request_id, ev.event, ev.actor_id, u.name, ev.note, ev.created_at
+		FROM elevation_request_events ev
+		LEFT JOIN users u ON ev.actor_id = u.id
+		WHERE ev.request_id = $1
+		ORDER BY ev.created_at, ev.id
+	`
+
+	rows, err := r.db.Query(query, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get elevation history: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*ElevationEvent
+	for rows.Next() {
+		event := &ElevationEvent{}
+		if err := rows.Scan(&event.ID, &event.RequestID, &event.Event, &event.ActorID, &event.ActorName,
+			&event.Note, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan elevation event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// listConditions translates the list filters into SQL; expired is derived from expires_at
+func listConditions(companyID, userID int64, status string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if companyID > 0 {
+		args = append(args, companyID)
+		conditions = append(conditions, fmt.Sprintf("e.company_id = $%d", len(args)))
+	}
+	if userID > 0 {
+		args = append(args, userID)
+		conditions = append(conditions, fmt.Sprintf("e.user_id = $%d", len(args)))
+	}
+
+	switch status {
+	case StatusPending, StatusApproved:
+		conditions = append(conditions, "e.status = '"+status+"' AND e.expires_at > NOW()")
+	case StatusExpired:
+		conditions = append(conditions, "e.status IN ('pending', 'approved') AND e.expires_at <= NOW()")
+	case StatusRejected, StatusCancelled:
+		conditions = append(conditions, "e.status = '"+status+"'")
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// List retrieves elevation requests, newest first
+func (r *Repository) List(companyID, userID int64, status string, limit, offset int) ([]*ElevationRequest, error) {
+	where, args := listConditions(companyID, userID, status)
+	args = append(args, limit, offset)
+	query := elevationSelect + where +
+		fmt.Sprintf(" ORDER BY e.created_at DESC, e.id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get elevation requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*ElevationRequest
+	for rows.Next() {
+		request, err := scanElevation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan elevation request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// Count counts the elevation requests matching the list filters
+func (r *Repository) Count(companyID, userID int64, status string) (int64, error) {
+	where, args := listConditions(companyID, userID, status)
+
+	var count int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM elevation_requests e"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count elevation requests: %w", err)
+	}
+	return count, nil
+}
+
+// Approve locks an unexpired pending request, lets assign create the temporary role
+// assignment and records the approval. Concurrent decisions on the same request wait for
+// the lock and then find it no longer pending.
+func (r *Repository) Approve(id, approverID int64, note *string, expiresAt time.Time,
+	assign func(*ElevationRequest) (int64, error)) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	request, err := scanElevation(tx.QueryRow(elevationSelect+
+		" WHERE e.id = $1 AND e.status = 'pending' AND e.expires_at > NOW() FOR UPDATE OF e", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("permintaan elevasi sudah tidak pending, tidak dapat disetujui (cannot approve)")
+		}
+		return fmt.Errorf("failed to lock elevation request: %w", err)
+	}
+
+	userRoleID, err := assign(request)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE elevation_requests
+		SET status = 'approved', decided_by = $2, decided_at = NOW(), decision_note = $3,
+			user_role_id = $4, expires_at = $5, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(query, id, approverID, note, userRoleID, expiresAt); err != nil {
+		return fmt.Errorf("failed to approve elevation request: %w", err)
+	}
+
+	if err := addEvent(tx, id, EventApproved, &approverID, note); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Close moves an unexpired pending request to rejected or cancelled and records the event
+func (r *Repository) Close(id int64, status string, actorID int64, note *string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE elevation_requests
+		SET status = $2, decided_by = $3, decided_at = NOW(), decision_note = $4, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+	`
+	result, err := tx.Exec(query, id, status, actorID, note)
+	if err != nil {
+		return fmt.Errorf("failed to update elevation request: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		if status == StatusCancelled {
+			return fmt.Errorf("permintaan elevasi sudah tidak pending, tidak dapat dibatalkan (cannot cancel)")
+		}
+		return fmt.Errorf("permintaan elevasi sudah tidak pending, tidak dapat ditolak (cannot reject)")
+	}
+
+	if err := addEvent(tx, id, status, &actorID, note); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func addEvent(tx *sql.Tx, requestID int64, event string, actorID *int64, note *string) error {
+	query := `
+		INSERT INTO elevation_request_events (request_id, event, actor_id, note, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+	`
+	if _, err := tx.Exec(query, requestID, event, actorID, note); err != nil {
+		return fmt.Errorf("failed to record elevation event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package elevation
+
+import (
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/middleware"
+	"gin-scalable-api/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler struct
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Handler methods
+
+// @Summary      Ajukan elevasi peran
+// @Description  Mengajukan peran sementara pada cakupan perusahaan, cabang, atau unit untuk durasi tertentu dengan justifikasi. Permintaan menunggu persetujuan approver perusahaan; setelah disetujui peran diberikan dan berakhir otomatis
+// @Tags         Elevation Requests
+// @Accept       json
+// @Produce      json
+// @Param        request  body      elevation.CreateElevationRequest  true  "Data permintaan elevasi"
+// @Success      201      {object}  response.Response{data=elevation.ElevationResponse}  "Permintaan elevasi berhasil diajukan"
+// @Failure      400      {object}  response.Response  "Durasi atau justifikasi tidak valid"
+// @Failure      403      {object}  response.Response  "Bukan anggota perusahaan atau peran tidak dapat diminta"
+// @Failure      404      {object}  response.Response  "Perusahaan, cabang, unit, atau peran tidak ditemukan"
+// @Failure      409      {object}  response.Response  "Peran sudah dimiliki atau permintaan serupa masih berjalan"
+// @Failure      422      {object}  response.Response  "Tidak ada approver untuk perusahaan"
+// @Router       /api/v1/elevation-requests [post]
+// @Security     BearerAuth
+func (h *Handler) CreateRequest(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*CreateElevationRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.CreateRequest(userID, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusCreated, constants.MsgElevationRequested, result)
+}
+
+// @Summary      Daftar permintaan elevasi
+// @Description  Tanpa company_id mengembalikan permintaan milik pengguna sendiri. Dengan company_id mengembalikan permintaan perusahaan tersebut, hanya untuk approver perusahaan
+// @Tags         Elevation Requests
+// @Produce      json
+// @Param        company_id  query     int     false  "Company ID"
+// @Param        user_id     query     int     false  "Filter pemohon (bersama company_id)"
+// @Param        status      query     string  false  "pending, approved, rejected, cancelled, atau expired"
+// @Param        limit       query     int     false  "Jumlah data (default 10, maks 100)"
+// @Param        offset      query     int     false  "Offset"
+// @Success      200         {object}  response.Response{data=elevation.ElevationListResponse}  "Daftar permintaan elevasi berhasil diambil"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      403         {object}  response.Response  "Bukan approver perusahaan"
+// @Router       /api/v1/elevation-requests [get]
+// @Security     BearerAuth
+func (h *Handler) GetRequests(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ElevationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", err.Error())
+		return
+	}
+
+	result, err := h.service.GetRequests(userID, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgElevationsList, result)
+}
+
+// @Summary      Detail permintaan elevasi
+// @Description  Mengambil permintaan elevasi beserta riwayat lengkapnya (requested, approved, rejected, cancelled, expired). Untuk pemohon dan approver perusahaan
+// @Tags         Elevation Requests
+// @Produce      json
+// @Param        id   path      int  true  "Elevation request ID"
+// @Success      200  {object}  response.Response{data=elevation.ElevationResponse}  "Permintaan elevasi berhasil diambil"
+// @Failure      403  {object}  response.Response  "Bukan pemohon atau approver"
+// @Failure      404  {object}  response.Response  "Permintaan elevasi tidak ditemukan"
+// @Router       /api/v1/elevation-requests/{id} [get]
+// @Security     BearerAuth
+func (h *Handler) GetRequest(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid elevation request ID")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetRequest(userID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgElevationRetrieved, result)
+}
+
+// @Summary      Setujui permintaan elevasi
+// @Description  Memberikan peran yang diminta selama durasi permintaan, dihitung sejak persetujuan. Peran dicabut otomatis setelah durasi berakhir. Pemohon tidak dapat menyetujui permintaannya sendiri
+// @Tags         Elevation Requests
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                           true   "Elevation request ID"
+// @Param        request  body      elevation.DecisionRequest     false  "Catatan persetujuan"
+// @Success      200      {object}  response.Response{data=elevation.ElevationResponse}  "Permintaan elevasi disetujui"
+// @Failure      403      {object}  response.Response  "Bukan approver perusahaan"
+// @Failure      404      {object}  response.Response  "Permintaan elevasi tidak ditemukan"
+// @Failure      422      {object}  response.Response  "Permintaan sudah tidak pending"
+// @Router       /api/v1/elevation-requests/{id}/approve [post]
+// @Security     BearerAuth
+func (h *Handler) ApproveRequest(c *gin.Context) {
+	id, userID, req, ok := decisionParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.ApproveRequest(userID, id, req.Note)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgElevationApproved, result)
+}
+
+// @Summary      Tolak permintaan elevasi
+// @Description  Menolak permintaan elevasi yang masih pending. Alasan penolakan wajib diisi dan ditampilkan ke pemohon
+// @Tags         Elevation Requests
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                        true  "Elevation request ID"
+// @Param        request  body      elevation.DecisionRequest  true  "Alasan penolakan"
+// @Success      200      {object}  response.Response{data=elevation.ElevationResponse}  "Permintaan elevasi ditolak"
+// @Failure      400      {object}  response.Response  "Alasan penolakan kosong"
+// @Failure      403      {object}  response.Response  "Bukan approver perusahaan"
+// @Failure      404      {object}  response.Response  "Permintaan elevasi tidak ditemukan"
+// @Failure      422      {object}  response.Response  "Permintaan sudah tidak pending"
+// @Router       /api/v1/elevation-requests/{id}/reject [post]
+// @Security     BearerAuth
+func (h *Handler) RejectRequest(c *gin.Context) {
+	id, userID, req, ok := decisionParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.RejectRequest(userID, id, req.Note)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgElevationRejected, result)
+}
+
+// @Summary      Batalkan permintaan elevasi
+// @Description  Menarik kembali permintaan elevasi milik sendiri yang masih pending
+// @Tags         Elevation Requests
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                        true   "Elevation request ID"
+// @Param        request  body      elevation.DecisionRequest  false  "Catatan pembatalan"
+// @Success      200      {object}  response.Response{data=elevation.ElevationResponse}  "Permintaan elevasi dibatalkan"
+// @Failure      403      {object}  response.Response  "Bukan pemohon"
+// @Failure      404      {object}  response.Response  "Permintaan elevasi tidak ditemukan"
+// @Failure      422      {object}  response.Response  "Permintaan sudah tidak pending"
+// @Router       /api/v1/elevation-requests/{id}/cancel [post]
+// @Security     BearerAuth
+func (h *Handler) CancelRequest(c *gin.Context) {
+	id, userID, req, ok := decisionParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.CancelRequest(userID, id, req.Note)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgElevationCancelled, result)
+}
+
+// decisionParams reads the request ID, the caller and the optional decision body
+func decisionParams(c *gin.Context) (int64, int64, *DecisionRequest, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid elevation request ID")
+		return 0, 0, nil, false
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return 0, 0, nil, false
+	}
+
+	// The body is optional; only a rejection requires a note
+	req := &DecisionRequest{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(req); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err.Error())
+			return 0, 0, nil, false
+		}
+	}
+	if len(req.Note) > 1000 {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", "note must be at most 1000 characters")
+		return 0, 0, nil, false
+	}
+
+	return id, userID, req, true
+}
+
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
+// Route registration
+
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
+	elevations := router.Group("/elevation-requests")
+	{
+		// GET /api/v1/elevation-requests - List own requests, or a company's for its approvers
+		elevations.GET("", handler.GetRequests)
+
+		// POST /api/v1/elevation-requests - Request a role for a limited time
+		elevations.POST("",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &CreateElevationRequest{},
+			}),
+			handler.CreateRequest,
+		)
+
+		// GET /api/v1/elevation-requests/:id - Get a request with its history
+		elevations.GET("/:id", handler.GetRequest)
+
+		// POST /api/v1/elevation-requests/:id/approve - Approve and grant the role temporarily
+		elevations.POST("/:id/approve", handler.ApproveRequest)
+
+		// POST /api/v1/elevation-requests/:id/reject - Reject a pending request
+		elevations.POST("/:id/reject", handler.RejectRequest)
+
+		// POST /api/v1/elevation-requests/:id/cancel - Withdraw an own pending request
+		elevations.POST("/:id/cancel", handler.CancelRequest)
+	}
+}
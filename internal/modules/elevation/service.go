@@ -0,0 +1,427 @@
+package elevation
+
+import (
+	"errors"
+	"fmt"
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/pkg/audittrail"
+	"strings"
+	"time"
+)
+
+// RoleAssigner grants a role until a given time and returns the assignment ID.
+// Implemented on top of the role module, which owns the assignment rules and the
+// permission cache invalidation.
+type RoleAssigner interface {
+	AssignRoleUntil(actorID, userID, roleID, companyID int64, branchID, unitID *int64, validUntil time.Time) (int64, error)
+}
+
+// Policy configures who approves elevation requests and how long an elevation may last
+type Policy struct {
+	// ApproverModuleURL is the module whose can_approve grant makes a user an approver
+	ApproverModuleURL string
+	// ApproverRole additionally makes the holders of this role in the company approvers
+	ApproverRole string
+	MaxDuration  time.Duration
+}
+
+type Service struct {
+	repo          *Repository
+	roleAssigner  RoleAssigner
+	auditRecorder audittrail.Recorder
+	policy        Policy
+}
+
+func NewService(repo *Repository, roleAssigner RoleAssigner, auditRecorder audittrail.Recorder, policy Policy) *Service {
+	return &Service{
+		repo:          repo,
+		roleAssigner:  roleAssigner,
+		auditRecorder: auditRecorder,
+		policy:        policy,
+	}
+}
+
+// Requester operations
+
+// CreateRequest asks for a role at a company, branch or unit scope. The request waits
+// for an approver until constants.ElevationApprovalTTL has passed.
+func (s *Service) CreateRequest(userID int64, req *CreateElevationRequest) (*ElevationResponse, error) {
+	justification := strings.TrimSpace(req.Justification)
+	if len(justification) < 10 {
+		return nil, errors.New("justifikasi minimal 10 karakter (invalid justification)")
+	}
+	if s.policy.MaxDuration > 0 && time.Duration(req.DurationMinutes)*time.Minute > s.policy.MaxDuration {
+		return nil, fmt.Errorf("durasi elevasi maksimal %d menit (invalid duration)", int(s.policy.MaxDuration.Minutes()))
+	}
+
+	exists, err := s.repo.CompanyExists(req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("perusahaan tidak ditemukan (company not found)")
+	}
+
+	isMember, err := s.repo.IsCompanyMember(userID, req.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, errors.New("access denied: only members of the company can request elevation")
+	}
+
+	branchID, err := s.resolveScope(req.CompanyID, req.BranchID, req.UnitID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleName, err := s.repo.GetRoleName(req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	if roleName == "SUPER_ADMIN" {
+		return nil, errors.New("access denied: SUPER_ADMIN cannot be requested through elevation")
+	}
+
+	holds, err := s.repo.HoldsRole(userID, req.RoleID, req.CompanyID, branchID, req.UnitID)
+	if err != nil {
+		return nil, err
+	}
+	if holds {
+		return nil, errors.New("anda sudah memiliki peran ini pada cakupan tersebut (already exists)")
+	}
+
+	open, err := s.repo.HasOpenRequest(userID, req.RoleID, req.CompanyID, branchID, req.UnitID)
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, errors.New("permintaan elevasi untuk peran ini masih pending atau aktif (already exists)")
+	}
+
+	approverIDs, err := s.approvers(req.CompanyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(approverIDs) == 0 {
+		return nil, errors.New("tidak ada approver untuk perusahaan ini, permintaan tidak dapat diajukan (cannot request)")
+	}
+
+	request := &ElevationRequest{
+		UserID:          userID,
+		RoleID:          req.RoleID,
+		CompanyID:       req.CompanyID,
+		BranchID:        branchID,
+		UnitID:          req.UnitID,
+		DurationMinutes: req.DurationMinutes,
+		Justification:   justification,
+		ExpiresAt:       time.Now().Add(constants.ElevationApprovalTTL * time.Second),
+	}
+	if err := s.repo.Create(request); err != nil {
+		return nil, err
+	}
+
+	audittrail.RecordChange(s.auditRecorder, userID, "elevation_requested", "elevation_request", request.ID,
+		map[string]interface{}{}, requestSnapshot(request, StatusPending))
+
+	return s.GetRequest(userID, request.ID)
+}
+
+// CancelRequest withdraws a pending request of the requester
+func (s *Service) CancelRequest(userID, id int64, note string) (*ElevationResponse, error) {
+	request, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if request.UserID != userID {
+		return nil, errors.New("access denied: only the requester can cancel an elevation request")
+	}
+
+	if err := s.repo.Close(id, StatusCancelled, userID, optionalNote(note)); err != nil {
+		return nil, err
+	}
+
+	audittrail.RecordChange(s.auditRecorder, userID, "elevation_cancelled", "elevation_request", id,
+		requestSnapshot(request, StatusPending), requestSnapshot(request, StatusCancelled))
+
+	return s.GetRequest(userID, id)
+}
+
+// Approver operations
+
+// ApproveRequest grants the requested role for the requested duration, counted from
+// the approval. The assignment expires on its own through the assignment sweeper.
+func (s *Service) ApproveRequest(approverID, id int64, note string) (*ElevationResponse, error) {
+	request, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireDecider(approverID, request); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(time.Duration(request.DurationMinutes) * time.Minute)
+	err = s.repo.Approve(id, approverID, optionalNote(note), expiresAt, func(locked *ElevationRequest) (int64, error) {
+		userRoleID, err := s.roleAssigner.AssignRoleUntil(approverID, locked.UserID, locked.RoleID,
+			locked.CompanyID, locked.BranchID, locked.UnitID, expiresAt)
+		if err != nil {
+			return 0, fmt.Errorf("gagal memberikan peran %d: %w", locked.RoleID, err)
+		}
+		return userRoleID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	approved := *request
+	approved.ExpiresAt = expiresAt
+	audittrail.RecordChange(s.auditRecorder, approverID, "elevation_approved", "elevation_request", id,
+		requestSnapshot(request, StatusPending), requestSnapshot(&approved, StatusApproved))
+
+	return s.GetRequest(approverID, id)
+}
+
+// RejectRequest declines a pending request; the reason is shown to the requester
+func (s *Service) RejectRequest(approverID, id int64, note string) (*ElevationResponse, error) {
+	if strings.TrimSpace(note) == "" {
+		return nil, errors.New("alasan penolakan wajib diisi (note required)")
+	}
+
+	request, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireDecider(approverID, request); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Close(id, StatusRejected, approverID, optionalNote(note)); err != nil {
+		return nil, err
+	}
+
+	audittrail.RecordChange(s.auditRecorder, approverID, "elevation_rejected", "elevation_request", id,
+		requestSnapshot(request, StatusPending), requestSnapshot(request, StatusRejected))
+
+	return s.GetRequest(approverID, id)
+}
+
+// Queries
+
+// GetRequest returns a request with its full history to the requester and the approvers
+// of its company
+func (s *Service) GetRequest(userID, id int64) (*ElevationResponse, error) {
+	request, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if request.UserID != userID {
+		if err := s.requireApprover(userID, request.CompanyID); err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := s.repo.GetEvents(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := toResponse(request, time.Now())
+	result.History = toHistory(request, events, time.Now())
+	return result, nil
+}
+
+// GetRequests lists the requests of a company to its approvers, or the caller's own
+// requests when no company is given
+func (s *Service) GetRequests(userID int64, req *ElevationListRequest) (*ElevationListResponse, error) {
+	switch req.Status {
+	case "", StatusPending, StatusApproved, StatusRejected, StatusCancelled, StatusExpired:
+	default:
+		return nil, errors.New("status harus pending, approved, rejected, cancelled, atau expired (invalid status)")
+	}
+
+	filterUserID := req.UserID
+	if req.CompanyID > 0 {
+		if err := s.requireApprover(userID, req.CompanyID); err != nil {
+			return nil, err
+		}
+	} else {
+		if req.UserID > 0 && req.UserID != userID {
+			return nil, errors.New("company_id wajib diisi untuk melihat permintaan pengguna lain (required)")
+		}
+		filterUserID = userID
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	requests, err := s.repo.List(req.CompanyID, filterUserID, req.Status, req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.Count(req.CompanyID, filterUserID, req.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	data := make([]*ElevationResponse, 0, len(requests))
+	for _, request := range requests {
+		data = append(data, toResponse(request, now))
+	}
+
+	return &ElevationListResponse{
+		Data:    data,
+		Total:   total,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+		HasMore: int64(req.Offset+req.Limit) < total,
+	}, nil
+}
+
+// Helpers
+
+// approvers resolves the approvers of a company, leaving out the requester
+func (s *Service) approvers(companyID, requesterID int64) ([]int64, error) {
+	approverIDs, err := s.repo.ApproverIDs(companyID, s.policy.ApproverModuleURL, s.policy.ApproverRole)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]int64, 0, len(approverIDs))
+	for _, approverID := range approverIDs {
+		if approverID != requesterID {
+			filtered = append(filtered, approverID)
+		}
+	}
+	return filtered, nil
+}
+
+// requireApprover checks that a user approves for a company
+func (s *Service) requireApprover(userID, companyID int64) error {
+	approverIDs, err := s.repo.ApproverIDs(companyID, s.policy.ApproverModuleURL, s.policy.ApproverRole)
+	if err != nil {
+		return err
+	}
+	for _, approverID := range approverIDs {
+		if approverID == userID {
+			return nil
+		}
+	}
+	return errors.New("access denied: only approvers of the company can manage elevation requests")
+}
+
+// requireDecider checks that a user may decide a request. Requesters never decide their
+// own requests, even when they are approvers.
+func (s *Service) requireDecider(userID int64, request *ElevationRequest) error {
+	if request.UserID == userID {
+		return errors.New("access denied: requesters cannot decide their own elevation requests")
+	}
+	return s.requireApprover(userID, request.CompanyID)
+}
+
+// resolveScope checks that the branch and unit belong to the company and returns the
+// branch of the assignment, derived from the unit when only the unit is given
+func (s *Service) resolveScope(companyID int64, branchID, unitID *int64) (*int64, error) {
+	if unitID != nil {
+		unitBranchID, err := s.repo.UnitBranchID(*unitID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if branchID != nil && *branchID != unitBranchID {
+			return nil, errors.New("unit tidak berada di cabang yang dipilih (invalid unit)")
+		}
+		return &unitBranchID, nil
+	}
+
+	if branchID != nil {
+		exists, err := s.repo.BranchBelongsToCompany(*branchID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, errors.New("cabang tidak ditemukan di perusahaan ini (branch not found)")
+		}
+	}
+	return branchID, nil
+}
+
+func requestSnapshot(request *ElevationRequest, status string) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":          request.UserID,
+		"role_id":          request.RoleID,
+		"company_id":       request.CompanyID,
+		"branch_id":        request.BranchID,
+		"unit_id":          request.UnitID,
+		"duration_minutes": request.DurationMinutes,
+		"status":           status,
+		"expires_at":       request.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+func toResponse(request *ElevationRequest, now time.Time) *ElevationResponse {
+	return &ElevationResponse{
+		ID:              request.ID,
+		UserID:          request.UserID,
+		UserName:        request.UserName,
+		RoleID:          request.RoleID,
+		RoleName:        request.RoleName,
+		CompanyID:       request.CompanyID,
+		BranchID:        request.BranchID,
+		UnitID:          request.UnitID,
+		DurationMinutes: request.DurationMinutes,
+		Justification:   request.Justification,
+		Status:          request.EffectiveStatus(now),
+		DecidedBy:       request.DecidedBy,
+		DecidedAt:       formatTime(request.DecidedAt),
+		DecisionNote:    request.DecisionNote,
+		UserRoleID:      request.UserRoleID,
+		ExpiresAt:       request.ExpiresAt.Format(time.RFC3339),
+		CreatedAt:       request.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// toHistory converts the stored events and appends the expiry, which is not stored
+func toHistory(request *ElevationRequest, events []*ElevationEvent, now time.Time) []ElevationEventResponse {
+	history := make([]ElevationEventResponse, 0, len(events)+1)
+	for _, event := range events {
+		history = append(history, ElevationEventResponse{
+			Event:     event.Event,
+			ActorID:   event.ActorID,
+			ActorName: event.ActorName,
+			Note:      event.Note,
+			CreatedAt: event.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	if request.EffectiveStatus(now) == StatusExpired {
+		history = append(history, ElevationEventResponse{
+			Event:     EventExpired,
+			CreatedAt: request.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+	return history
+}
+
+func optionalNote(note string) *string {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil
+	}
+	return &note
+}
+
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
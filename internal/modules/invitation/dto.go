@@ -0,0 +1,79 @@
+package invitation
+
+import "gin-scalable-api/pkg/password"
+
+// CreateInvitationRequest invites an email into a company with pre-selected roles
+type CreateInvitationRequest struct {
+	Email     string  `json:"email" validate:"required,email"`
+	Name      *string `json:"name" validate:"omitempty,min=2,max=100"`
+	CompanyID int64   `json:"company_id" validate:"required,min=1"`
+	BranchID  *int64  `json:"branch_id" validate:"omitempty,min=1"`
+	UnitID    *int64  `json:"unit_id" validate:"omitempty,min=1"`
+	RoleIDs   []int64 `json:"role_ids" validate:"required,min=1,max=20,dive,min=1"`
+}
+
+// InvitationListRequest filters the invitations of a company
+type InvitationListRequest struct {
+	CompanyID int64  `form:"company_id"`
+	Status    string `form:"status"`
+	Limit     int    `form:"limit"`
+	Offset    int    `form:"offset"`
+}
+
+// AcceptInvitationRequest creates the invited account with a password chosen by the invitee
+type AcceptInvitationRequest struct {
+	Token           string  `json:"token" validate:"required"`
+	Name            string  `json:"name" validate:"required,min=2,max=100"`
+	UserIdentity    *string `json:"user_identity" validate:"omitempty,min=2,max=50"`
+	Password        string  `json:"password" validate:"required,min=6,max=72"`
+	ConfirmPassword string  `json:"confirm_password" validate:"required,eqfield=Password"`
+}
+
+type InvitationRoleResponse struct {
+	RoleID   int64  `json:"role_id"`
+	RoleName string `json:"role_name"`
+}
+
+type InvitationResponse struct {
+	ID             int64                    `json:"id"`
+	Email          string                   `json:"email"`
+	Name           *string                  `json:"name"`
+	CompanyID      int64                    `json:"company_id"`
+	CompanyName    string                   `json:"company_name"`
+	BranchID       *int64                   `json:"branch_id"`
+	UnitID         *int64                   `json:"unit_id"`
+	Roles          []InvitationRoleResponse `json:"roles"`
+	Status         string                   `json:"status"`
+	InvitedBy      *int64                   `json:"invited_by"`
+	AcceptedUserID *int64                   `json:"accepted_user_id"`
+	SendCount      int                      `json:"send_count"`
+	ExpiresAt      string                   `json:"expires_at"`
+	LastSentAt     string                   `json:"last_sent_at"`
+	AcceptedAt     *string                  `json:"accepted_at"`
+	RevokedAt      *string                  `json:"revoked_at"`
+	CreatedAt      string                   `json:"created_at"`
+}
+
+type InvitationListResponse struct {
+	Data    []*InvitationResponse `json:"data"`
+	Total   int64                 `json:"total"`
+	Limit   int                   `json:"limit"`
+	Offset  int                   `json:"offset"`
+	HasMore bool                  `json:"has_more"`
+}
+
+// InvitationPreviewResponse is shown to the invitee before accepting
+type InvitationPreviewResponse struct {
+	Email          string                   `json:"email"`
+	Name           *string                  `json:"name"`
+	CompanyName    string                   `json:"company_name"`
+	Roles          []InvitationRoleResponse `json:"roles"`
+	ExpiresAt      string                   `json:"expires_at"`
+	PasswordPolicy password.Policy          `json:"password_policy"`
+}
+
+type AcceptInvitationResponse struct {
+	UserID    int64  `json:"user_id"`
+	Email     string `json:"email"`
+	CompanyID int64  `json:"company_id"`
+}
@@ -0,0 +1,58 @@
+package invitation
+
+import (
+	"time"
+)
+
+// Invitation statuses; an expired invitation keeps status pending and is recognized by ExpiresAt
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusRevoked  = "revoked"
+	StatusExpired  = "expired"
+)
+
+// Invitation invites an email into a company, optionally scoped to a branch or unit,
+// with the roles the new user receives on acceptance
+type Invitation struct {
+	ID             int64      `json:"id" db:"id"`
+	Email          string     `json:"email" db:"email"`
+	Name           *string    `json:"name" db:"name"`
+	CompanyID      int64      `json:"company_id" db:"company_id"`
+	CompanyName    string     `json:"company_name" db:"-"`
+	BranchID       *int64     `json:"branch_id" db:"branch_id"`
+	UnitID         *int64     `json:"unit_id" db:"unit_id"`
+	RoleIDs        []int64    `json:"role_ids" db:"role_ids"`
+	TokenHash      string     `json:"-" db:"token_hash"`
+	Status         string     `json:"status" db:"status"`
+	InvitedBy      *int64     `json:"invited_by" db:"invited_by"`
+	AcceptedUserID *int64     `json:"accepted_user_id" db:"accepted_user_id"`
+	SendCount      int        `json:"send_count" db:"send_count"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	LastSentAt     time.Time  `json:"last_sent_at" db:"last_sent_at"`
+	AcceptedAt     *time.Time `json:"accepted_at" db:"accepted_at"`
+	RevokedAt      *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+// EffectiveStatus reports pending invitations past their expiry as expired
+func (i *Invitation) EffectiveStatus(now time.Time) string {
+	if i.Status == StatusPending && now.After(i.ExpiresAt) {
+		return StatusExpired
+	}
+	return i.Status
+}
+
+// User model for invitation module - only fields needed to create the invitee
+type User struct {
+	ID           int64   `json:"id" db:"id"`
+	Name         string  `json:"name" db:"name"`
+	Email        string  `json:"email" db:"email"`
+	UserIdentity *string `json:"user_identity" db:"user_identity"`
+	PasswordHash string  `json:"-" db:"password_hash"`
+}
@@ -0,0 +1,406 @@
+package invitation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const invitationSelect = `
+	SELECT i.id, i.email, i.name, i.company_id, c.name, i.branch_id, i.unit_id, i.role_ids,
+		i.token_hash, i.status, i.invited_by, i.accepted_user_id, i.send_count, i.expires_at,
+		i.last_sent_at, i.accepted_at, i.revoked_at, i.created_at, i.updated_at
+	FROM invitations i
+	JOIN companies c ON i.company_id = c.id
+`
+
+func scanInvitation(scanner interface{ Scan(...interface{}) error }) (*Invitation, error) {
+	invitation := &Invitation{}
+	var roleIDs []byte
+
+	err := scanner.Scan(
+		&invitation.ID, &invitation.Email, &invitation.Name, &invitation.CompanyID, &invitation.CompanyName,
+		&invitation.BranchID, &invitation.UnitID, &roleIDs, &invitation.TokenHash, &invitation.Status,
+		&invitation.InvitedBy, &invitation.AcceptedUserID, &invitation.SendCount, &invitation.ExpiresAt,
+		&invitation.LastSentAt, &invitation.AcceptedAt, &invitation.RevokedAt,
+		&invitation.CreatedAt, &invitation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(roleIDs, &invitation.RoleIDs); err != nil {
+		return nil, fmt.Errorf("failed to parse invitation roles: %w", err)
+	}
+
+	return invitation, nil
+}
+
+// IsCompanyAdmin checks whether a user may manage the invitations of a company
+func (r *Repository) IsCompanyAdmin(userID, companyID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true
+				AND (r.name = 'SUPER_ADMIN' OR (r.name = 'COMPANY_ADMIN' AND ur.company_id = $2))
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID, companyID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check company admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// IsSuperAdmin checks whether a user holds the SUPER_ADMIN role
+func (r *Repository) IsSuperAdmin(userID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true AND r.name = 'SUPER_ADMIN'
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check super admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// CompanyExists checks whether an active company exists
+func (r *Repository) CompanyExists(companyID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM companies WHERE id = $1 AND is_active = true)`
+	if err := r.db.QueryRow(query, companyID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check company: %w", err)
+	}
+	return exists, nil
+}
+
+// BranchBelongsToCompany checks whether a branch is part of a company
+func (r *Repository) BranchBelongsToCompany(branchID, companyID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM branches WHERE id = $1 AND company_id = $2)`
+	if err := r.db.QueryRow(query, branchID, companyID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check branch: %w", err)
+	}
+	return exists, nil
+}
+
+// UnitBranchID returns the branch of a unit within a company
+func (r *Repository) UnitBranchID(unitID, companyID int64) (int64, error) {
+	var branchID int64
+	query := `
+		SELECT u.branch_id
+		FROM units u
+		JOIN branches b ON u.branch_id = b.id
+		WHERE u.id = $1 AND b.company_id = $2
+	`
+	if err := r.db.QueryRow(query, unitID, companyID).Scan(&branchID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("unit tidak ditemukan di perusahaan ini (unit not found)")
+		}
+		return 0, fmt.Errorf("failed to check unit: %w", err)
+	}
+	return branchID, nil
+}
+
+// GetRoleNames returns the names of the given active roles keyed by ID
+func (r *Repository) GetRoleNames(roleIDs []int64) (map[int64]string, error) {
+	names := make(map[int64]string, len(roleIDs))
+	if len(roleIDs) == 0 {
+		return names, nil
+	}
+
+	idsJSON, err := json.Marshal(roleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, name FROM roles
+		WHERE is_active = true AND id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)
+	`
+
+	rows, err := r.db.Query(query, string(idsJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		names[id] = name
+	}
+
+	return names, rows.Err()
+}
+
+// EmailRegistered checks whether an account already uses an email
+func (r *Repository) EmailRegistered(email string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL)`
+	if err := r.db.QueryRow(query, email).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check email: %w", err)
+	}
+	return exists, nil
+}
+
+// UserIdentityRegistered checks whether an account already uses a user identity
+func (r *Repository) UserIdentityRegistered(userIdentity string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE user_identity = $1 AND deleted_at IS NULL)`
+	if err := r.db.QueryRow(query, userIdentity).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check user identity: %w", err)
+	}
+	return exists, nil
+}
+
+// HasPendingInvitation checks whether an email already has a pending invitation into a company, expired or not
+func (r *Repository) HasPendingInvitation(companyID int64, email string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM invitations
+			WHERE company_id = $1 AND LOWER(email) = LOWER($2) AND status = 'pending'
+		)
+	`
+	if err := r.db.QueryRow(query, companyID, email).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check invitation: %w", err)
+	}
+	return exists, nil
+}
+
+// Create stores a new pending invitation
+func (r *Repository) Create(invitation *Invitation) error {
+	roleIDs, err := json.Marshal(invitation.RoleIDs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO invitations (email, name, company_id, branch_id, unit_id, role_ids, token_hash, status,
+			invited_by, send_count, expires_at, last_sent_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending', $8, 1, $9, NOW(), NOW(), NOW())
+		RETURNING id, status, send_count, last_sent_at, created_at, updated_at
+	`
+
+	err = r.db.QueryRow(query, invitation.Email, invitation.Name, invitation.CompanyID, invitation.BranchID,
+		invitation.UnitID, roleIDs, invitation.TokenHash, invitation.InvitedBy, invitation.ExpiresAt,
+	).Scan(&invitation.ID, &invitation.Status, &invitation.SendCount, &invitation.LastSentAt,
+		&invitation.CreatedAt, &invitation.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an invitation by ID
+func (r *Repository) GetByID(id int64) (*Invitation, error) {
+	invitation, err := scanInvitation(r.db.QueryRow(invitationSelect+" WHERE i.id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("undangan tidak ditemukan (invitation not found)")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return invitation, nil
+}
+
+// GetByTokenHash retrieves an invitation by the hash of its link token
+func (r *Repository) GetByTokenHash(tokenHash string) (*Invitation, error) {
+	invitation, err := scanInvitation(r.db.QueryRow(invitationSelect+" WHERE i.token_hash = $1", tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("undangan tidak ditemukan (invitation not found)")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return invitation, nil
+}
+
+// statusCondition translates a list filter into SQL; expired is derived from expires_at
+func statusCondition(status string) string {
+	switch status {
+	case StatusPending:
+		return " AND i.status = 'pending' AND i.expires_at > NOW()"
+	case StatusExpired:
+		return " AND i.status = 'pending' AND i.expires_at <= NOW()"
+	case StatusAccepted, StatusRevoked:
+		return " AND i.status = '" + status + "'"
+	default:
+		return ""
+	}
+}
+
+// List retrieves the invitations of a company, newest first
+func (r *Repository) List(companyID int64, status string, limit, offset int) ([]*Invitation, error) {
+	query := invitationSelect + " WHERE i.company_id = $1" + statusCondition(status) +
+		" ORDER BY i.created_at DESC, i.id DESC LIMIT $2 OFFSET $3"
+
+	rows, err := r.db.Query(query, companyID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []*Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+// Count counts the invitations of a company matching a status filter
+func (r *Repository) Count(companyID int64, status string) (int64, error) {
+	var count int64
+	query := "SELECT COUNT(*) FROM invitations i WHERE i.company_id = $1" + statusCondition(status)
+	if err := r.db.QueryRow(query, companyID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count invitations: %w", err)
+	}
+	return count, nil
+}
+
+// RenewToken replaces the link token of a pending invitation and extends its expiry.
+// The previous link stops working immediately.
+func (r *Repository) RenewToken(id int64, tokenHash string, expiresAt time.Time) error {
+	query := `
+		UPDATE invitations
+		SET token_hash = $2, expires_at = $3, send_count = send_count + 1, last_sent_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	result, err := r.db.Exec(query, id, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to renew invitation: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("undangan sudah tidak aktif, tidak dapat dikirim ulang (cannot resend)")
+	}
+	return nil
+}
+
+// Revoke cancels a pending invitation
+func (r *Repository) Revoke(id int64) error {
+	query := `
+		UPDATE invitations SET status = 'revoked', revoked_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("undangan sudah tidak aktif, tidak dapat dibatalkan (cannot revoke)")
+	}
+	return nil
+}
+
+// Claim marks an unexpired pending invitation as accepted. Only one of several
+// concurrent acceptances of the same link succeeds.
+func (r *Repository) Claim(id int64) (bool, error) {
+	query := `
+		UPDATE invitations SET status = 'accepted', accepted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim invitation: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// ReleaseClaim returns a claimed invitation to pending after a failed acceptance
+func (r *Repository) ReleaseClaim(id int64) error {
+	query := `
+		UPDATE invitations SET status = 'pending', accepted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'accepted' AND accepted_user_id IS NULL
+	`
+	if _, err := r.db.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to release invitation: %w", err)
+	}
+	return nil
+}
+
+// SetAcceptedUser links an accepted invitation to the account created for it
+func (r *Repository) SetAcceptedUser(id, userID int64) error {
+	query := `UPDATE invitations SET accepted_user_id = $2, updated_at = NOW() WHERE id = $1`
+	if _, err := r.db.Exec(query, id, userID); err != nil {
+		return fmt.Errorf("failed to update invitation: %w", err)
+	}
+	return nil
+}
+
+// CreateUser creates the account of an invitee
+func (r *Repository) CreateUser(user *User) error {
+	query := `
+		INSERT INTO users (name, email, user_identity, password_hash, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING id
+	`
+
+	if err := r.db.QueryRow(query, user.Name, user.Email, user.UserIdentity, user.PasswordHash).Scan(&user.ID); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUser removes an account whose onboarding failed before any role was assigned
+func (r *Repository) DeleteUser(userID int64) error {
+	if _, err := r.db.Exec(`DELETE FROM users WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+// CreateAuditLog records an invitation event
+func (r *Repository) CreateAuditLog(actorID int64, action string, invitationID int64, details map[string]interface{}) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_logs (user_id, action, resource, resource_id, details, success, created_at)
+		VALUES ($1, $2, 'invitation', $3, $4, true, CURRENT_TIMESTAMP)
+	`
+
+	if _, err := r.db.Exec(query, actorID, action, invitationID, detailsJSON); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package invitation
+
+import (
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/middleware"
+	"gin-scalable-api/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler struct
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Handler methods
+
+// @Summary      Undang pengguna
+// @Description  Mengundang email ke perusahaan, opsional ke cabang atau unit, dengan peran yang sudah dipilih. Tautan undangan dikirim ke email tersebut. Hanya untuk COMPANY_ADMIN perusahaan tersebut atau SUPER_ADMIN
+// @Tags         Invitations
+// @Accept       json
+// @Produce      json
+// @Param        invitation  body      invitation.CreateInvitationRequest  true  "Data undangan"
+// @Success      201         {object}  response.Response{data=invitation.InvitationResponse}  "Undangan berhasil dikirim"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      403         {object}  response.Response  "Bukan admin perusahaan"
+// @Failure      404         {object}  response.Response  "Perusahaan, cabang, unit, atau peran tidak ditemukan"
+// @Failure      409         {object}  response.Response  "Email sudah terdaftar atau sudah diundang"
+// @Router       /api/v1/invitations [post]
+// @Security     BearerAuth
+func (h *Handler) CreateInvitation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*CreateInvitationRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.CreateInvitation(userID, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusCreated, constants.MsgInvitationCreated, result)
+}
+
+// @Summary      Daftar undangan
+// @Description  Mengambil daftar undangan sebuah perusahaan, opsional difilter berdasarkan status
+// @Tags         Invitations
+// @Produce      json
+// @Param        company_id  query     int     true   "Company ID"
+// @Param        status      query     string  false  "pending, accepted, revoked, atau expired"
+// @Param        limit       query     int     false  "Jumlah data (default 10, maks 100)"
+// @Param        offset      query     int     false  "Offset"
+// @Success      200         {object}  response.Response{data=invitation.InvitationListResponse}  "Daftar undangan berhasil diambil"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      403         {object}  response.Response  "Bukan admin perusahaan"
+// @Router       /api/v1/invitations [get]
+// @Security     BearerAuth
+func (h *Handler) GetInvitations(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req InvitationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", err.Error())
+		return
+	}
+
+	result, err := h.service.GetInvitations(userID, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgInvitationsList, result)
+}
+
+// @Summary      Kirim ulang undangan
+// @Description  Mengirim tautan baru untuk undangan yang masih pending atau sudah kedaluwarsa dan memperpanjang masa berlakunya. Tautan lama langsung tidak berlaku
+// @Tags         Invitations
+// @Produce      json
+// @Param        id   path      int  true  "Invitation ID"
+// @Success      200  {object}  response.Response{data=invitation.InvitationResponse}  "Undangan berhasil dikirim ulang"
+// @Failure      403  {object}  response.Response  "Bukan admin perusahaan"
+// @Failure      404  {object}  response.Response  "Undangan tidak ditemukan"
+// @Failure      422  {object}  response.Response  "Undangan sudah diterima atau dibatalkan"
+// @Router       /api/v1/invitations/{id}/resend [post]
+// @Security     BearerAuth
+func (h *Handler) ResendInvitation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid invitation ID")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.ResendInvitation(userID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgInvitationResent, result)
+}
+
+// @Summary      Batalkan undangan
+// @Description  Membatalkan undangan yang masih pending sehingga tautannya tidak dapat digunakan lagi
+// @Tags         Invitations
+// @Produce      json
+// @Param        id   path      int  true  "Invitation ID"
+// @Success      200  {object}  response.Response  "Undangan berhasil dibatalkan"
+// @Failure      403  {object}  response.Response  "Bukan admin perusahaan"
+// @Failure      404  {object}  response.Response  "Undangan tidak ditemukan"
+// @Failure      422  {object}  response.Response  "Undangan sudah diterima atau dibatalkan"
+// @Router       /api/v1/invitations/{id} [delete]
+// @Security     BearerAuth
+func (h *Handler) RevokeInvitation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid invitation ID")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeInvitation(userID, id); err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgInvitationRevoked, nil)
+}
+
+// @Summary      Cek undangan
+// @Description  Dipanggil halaman penerimaan undangan untuk menampilkan perusahaan, peran, dan kebijakan password yang berlaku sebelum pengguna membuat akun
+// @Tags         Invitations
+// @Produce      json
+// @Param        token  query     string  true  "Token dari tautan undangan"
+// @Success      200    {object}  response.Response{data=invitation.InvitationPreviewResponse}  "Undangan valid"
+// @Failure      401    {object}  response.Response  "Token tidak valid atau kedaluwarsa"
+// @Router       /api/v1/invitations/accept [get]
+func (h *Handler) PreviewInvitation(c *gin.Context) {
+	result, err := h.service.PreviewInvitation(c.Query("token"))
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Invalid invitation", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgInvitationValid, result)
+}
+
+// @Summary      Terima undangan
+// @Description  Membuat akun pengguna yang diundang dengan password pilihannya sendiri dan memberikan peran dari undangan. Password harus memenuhi kebijakan password perusahaan
+// @Tags         Invitations
+// @Accept       json
+// @Produce      json
+// @Param        request  body      invitation.AcceptInvitationRequest  true  "Token undangan dan data akun"
+// @Success      201      {object}  response.Response{data=invitation.AcceptInvitationResponse}  "Akun berhasil dibuat"
+// @Failure      400      {object}  response.Response  "Password tidak memenuhi kebijakan"
+// @Failure      401      {object}  response.Response  "Token tidak valid atau kedaluwarsa"
+// @Failure      409      {object}  response.Response  "Email atau user identity sudah terdaftar"
+// @Router       /api/v1/invitations/accept [post]
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*AcceptInvitationRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.AcceptInvitation(req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusCreated, constants.MsgInvitationAccepted, result)
+}
+
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
+// Route registration
+
+func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
+	invitations := api.Group("/invitations")
+	{
+		// GET /api/v1/invitations/accept - Preview an invitation from its link token
+		invitations.GET("/accept", handler.PreviewInvitation)
+
+		// POST /api/v1/invitations/accept - Create the invited account
+		invitations.POST("/accept",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &AcceptInvitationRequest{},
+			}),
+			handler.AcceptInvitation,
+		)
+	}
+}
+
+func RegisterProtectedRoutes(router *gin.RouterGroup, handler *Handler) {
+	invitations := router.Group("/invitations")
+	{
+		// GET /api/v1/invitations - List the invitations of a company
+		invitations.GET("", handler.GetInvitations)
+
+		// POST /api/v1/invitations - Invite a user into a company
+		invitations.POST("",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &CreateInvitationRequest{},
+			}),
+			handler.CreateInvitation,
+		)
+
+		// POST /api/v1/invitations/:id/resend - Send a new link for an invitation
+		invitations.POST("/:id/resend", handler.ResendInvitation)
+
+		// DELETE /api/v1/invitations/:id - Revoke a pending invitation
+		invitations.DELETE("/:id", handler.RevokeInvitation)
+	}
+}
@@ -0,0 +1,543 @@
+package invitation
+
+import (
+	"errors"
+	"fmt"
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/pkg/logger"
+	"gin-scalable-api/pkg/mailer"
+	"gin-scalable-api/pkg/password"
+	"gin-scalable-api/pkg/token"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Notifier delivers invitation links to invitees
+type Notifier interface {
+	NotifyInvitation(invitation *Invitation, link string) error
+}
+
+// RoleAssigner grants a role to a user. Implemented on top of the role module, which
+// owns the assignment rules and the permission cache invalidation.
+type RoleAssigner interface {
+	AssignRole(userID, roleID, companyID int64, branchID, unitID *int64) error
+}
+
+// MailNotifier sends invitation links by email
+type MailNotifier struct {
+	sender mailer.Sender
+}
+
+func NewMailNotifier(sender mailer.Sender) *MailNotifier {
+	return &MailNotifier{sender: sender}
+}
+
+func (n *MailNotifier) NotifyInvitation(invitation *Invitation, link string) error {
+	greeting := "Halo"
+	if invitation.Name != nil && *invitation.Name != "" {
+		greeting = "Halo " + *invitation.Name
+	}
+
+	return n.sender.Send(mailer.Message{
+		To:      invitation.Email,
+		Subject: "Undangan bergabung dengan " + invitation.CompanyName,
+		Body: fmt.Sprintf("%s,\n\n"+
+			"Anda diundang untuk bergabung dengan %s. Buka tautan berikut untuk membuat akun dan password Anda:\n\n"+
+			"%s\n\n"+
+			"Tautan ini berlaku hingga %s dan hanya dapat digunakan satu kali. "+
+			"Abaikan email ini bila Anda tidak mengenal pengirimnya.\n",
+			greeting, invitation.CompanyName, link, invitation.ExpiresAt.Format("02 Jan 2006 15:04 MST")),
+	})
+}
+
+type Service struct {
+	repo          *Repository
+	tokenService  *token.SimpleTokenService
+	passwordStore *password.Store
+	notifier      Notifier
+	roleAssigner  RoleAssigner
+	acceptURL     string
+}
+
+func NewService(repo *Repository, tokenService *token.SimpleTokenService, passwordStore *password.Store,
+	notifier Notifier, roleAssigner RoleAssigner, acceptURL string) *Service {
+	return &Service{
+		repo:          repo,
+		tokenService:  tokenService,
+		passwordStore: passwordStore,
+		notifier:      notifier,
+		roleAssigner:  roleAssigner,
+		acceptURL:     acceptURL,
+	}
+}
+
+var errInvalidInvitation = errors.New("undangan tidak valid atau kedaluwarsa (invalid token)")
+
+// Admin operations
+
+func (s *Service) CreateInvitation(adminID int64, req *CreateInvitationRequest) (*InvitationResponse, error) {
+	if err := s.requireCompanyAdmin(adminID, req.CompanyID); err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	branchID, err := s.resolveScope(req.CompanyID, req.BranchID, req.UnitID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleIDs := uniqueIDs(req.RoleIDs)
+	if err := s.checkRoles(adminID, roleIDs); err != nil {
+		return nil, err
+	}
+
+	registered, err := s.repo.EmailRegistered(email)
+	if err != nil {
+		return nil, err
+	}
+	if registered {
+		return nil, errors.New("email sudah terdaftar, assign role langsung ke pengguna tersebut (already exists)")
+	}
+
+	// Expired invitations still count: resend them, or revoke them to invite with other roles
+	pending, err := s.repo.HasPendingInvitation(req.CompanyID, email)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("undangan untuk email ini sudah ada, gunakan kirim ulang atau batalkan terlebih dahulu (already exists)")
+	}
+
+	inviteToken, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &Invitation{
+		Email:     email,
+		Name:      req.Name,
+		CompanyID: req.CompanyID,
+		BranchID:  branchID,
+		UnitID:    req.UnitID,
+		RoleIDs:   roleIDs,
+		TokenHash: s.tokenService.HashToken(inviteToken),
+		InvitedBy: &adminID,
+		ExpiresAt: time.Now().Add(constants.InvitationTTL * time.Second),
+	}
+	if err := s.repo.Create(invitation); err != nil {
+		return nil, err
+	}
+
+	s.audit(adminID, "invitation_created", invitation)
+
+	// Reload for the company name used in the message
+	created, err := s.repo.GetByID(invitation.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.notifier.NotifyInvitation(created, s.acceptLink(inviteToken)); err != nil {
+		logger.Error(fmt.Sprintf("Failed to send invitation %d: %v", created.ID, err))
+		return nil, errors.New("undangan tersimpan tetapi gagal dikirim, silakan kirim ulang")
+	}
+
+	return s.toResponses([]*Invitation{created})[0], nil
+}
+
+func (s *Service) GetInvitations(adminID int64, req *InvitationListRequest) (*InvitationListResponse, error) {
+	if req.CompanyID <= 0 {
+		return nil, errors.New("company_id wajib diisi (required)")
+	}
+	switch req.Status {
+	case "", StatusPending, StatusAccepted, StatusRevoked, StatusExpired:
+	default:
+		return nil, errors.New("status harus pending, accepted, revoked, atau expired (invalid status)")
+	}
+	if err := s.requireCompanyAdmin(adminID, req.CompanyID); err != nil {
+		return nil, err
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	invitations, err := s.repo.List(req.CompanyID, req.Status, req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.Count(req.CompanyID, req.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InvitationListResponse{
+		Data:    s.toResponses(invitations),
+		Total:   total,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+		HasMore: int64(req.Offset+req.Limit) < total,
+	}, nil
+}
+
+// ResendInvitation sends a new link for a pending or expired invitation and restarts
+// its validity; the previous link stops working
+func (s *Service) ResendInvitation(adminID, id int64) (*InvitationResponse, error) {
+	invitation, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireCompanyAdmin(adminID, invitation.CompanyID); err != nil {
+		return nil, err
+	}
+	if invitation.Status != StatusPending {
+		return nil, errors.New("undangan sudah tidak aktif, tidak dapat dikirim ulang (cannot resend)")
+	}
+
+	inviteToken, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(constants.InvitationTTL * time.Second)
+	if err := s.repo.RenewToken(id, s.tokenService.HashToken(inviteToken), expiresAt); err != nil {
+		return nil, err
+	}
+
+	resent, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(adminID, "invitation_resent", resent)
+
+	if err := s.notifier.NotifyInvitation(resent, s.acceptLink(inviteToken)); err != nil {
+		logger.Error(fmt.Sprintf("Failed to resend invitation %d: %v", id, err))
+		return nil, errors.New("undangan gagal dikirim ulang, silakan coba lagi")
+	}
+
+	return s.toResponses([]*Invitation{resent})[0], nil
+}
+
+func (s *Service) RevokeInvitation(adminID, id int64) error {
+	invitation, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.requireCompanyAdmin(adminID, invitation.CompanyID); err != nil {
+		return err
+	}
+
+	if err := s.repo.Revoke(id); err != nil {
+		return err
+	}
+
+	s.audit(adminID, "invitation_revoked", invitation)
+	return nil
+}
+
+// Invitee operations
+
+// PreviewInvitation shows the invitee what they are joining and which password rules apply
+func (s *Service) PreviewInvitation(inviteToken string) (*InvitationPreviewResponse, error) {
+	invitation, err := s.pendingInvitation(inviteToken)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.passwordStore.CompanyPolicy(invitation.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InvitationPreviewResponse{
+		Email:          invitation.Email,
+		Name:           invitation.Name,
+		CompanyName:    invitation.CompanyName,
+		Roles:          s.toResponses([]*Invitation{invitation})[0].Roles,
+		ExpiresAt:      invitation.ExpiresAt.Format(time.RFC3339),
+		PasswordPolicy: policy,
+	}, nil
+}
+
+// AcceptInvitation creates the invitee's account with the chosen password and assigns
+// the invited roles through the role module
+func (s *Service) AcceptInvitation(req *AcceptInvitationRequest) (*AcceptInvitationResponse, error) {
+	invitation, err := s.pendingInvitation(req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	registered, err := s.repo.EmailRegistered(invitation.Email)
+	if err != nil {
+		return nil, err
+	}
+	if registered {
+		return nil, errors.New("email sudah terdaftar (already exists)")
+	}
+
+	var userIdentity string
+	if req.UserIdentity != nil {
+		userIdentity = strings.TrimSpace(*req.UserIdentity)
+		taken, err := s.repo.UserIdentityRegistered(userIdentity)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, errors.New("user identity sudah digunakan (already exists)")
+		}
+	}
+
+	// The invitee joins one company, so only its policy applies to the first password
+	policy, err := s.passwordStore.CompanyPolicy(invitation.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	info := password.PersonalInfo{Name: req.Name, Email: invitation.Email, UserIdentity: userIdentity}
+	if err := policy.Validate(req.Password, info); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := password.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// Claimed only after validation, so a rejected password can be retried with the same link
+	claimed, err := s.repo.Claim(invitation.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, errInvalidInvitation
+	}
+
+	user := &User{
+		Name:         strings.TrimSpace(req.Name),
+		Email:        invitation.Email,
+		PasswordHash: hashedPassword,
+	}
+	if userIdentity != "" {
+		user.UserIdentity = &userIdentity
+	}
+
+	if err := s.onboard(invitation, user, policy); err != nil {
+		if releaseErr := s.repo.ReleaseClaim(invitation.ID); releaseErr != nil {
+			logger.Error(fmt.Sprintf("Failed to release invitation %d: %v", invitation.ID, releaseErr))
+		}
+		return nil, err
+	}
+
+	s.audit(user.ID, "invitation_accepted", invitation)
+
+	return &AcceptInvitationResponse{
+		UserID:    user.ID,
+		Email:     user.Email,
+		CompanyID: invitation.CompanyID,
+	}, nil
+}
+
+// onboard creates the account and assigns the invited roles. A partially created
+// account is removed again so the invitation can be accepted once the cause is fixed.
+func (s *Service) onboard(invitation *Invitation, user *User, policy password.Policy) error {
+	if err := s.repo.CreateUser(user); err != nil {
+		return err
+	}
+
+	err := s.passwordStore.SetPassword(user.ID, user.PasswordHash, policy.HistoryCount, false)
+	if err == nil {
+		for _, roleID := range invitation.RoleIDs {
+			if err = s.roleAssigner.AssignRole(user.ID, roleID, invitation.CompanyID, invitation.BranchID, invitation.UnitID); err != nil {
+				err = fmt.Errorf("gagal memberikan peran %d: %w", roleID, err)
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = s.repo.SetAcceptedUser(invitation.ID, user.ID)
+	}
+
+	if err != nil {
+		if deleteErr := s.repo.DeleteUser(user.ID); deleteErr != nil {
+			logger.Error(fmt.Sprintf("Failed to remove user %d after failed onboarding: %v", user.ID, deleteErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// Helpers
+
+func (s *Service) pendingInvitation(inviteToken string) (*Invitation, error) {
+	if inviteToken == "" {
+		return nil, errInvalidInvitation
+	}
+
+	invitation, err := s.repo.GetByTokenHash(s.tokenService.HashToken(inviteToken))
+	if err != nil {
+		return nil, errInvalidInvitation
+	}
+	if invitation.EffectiveStatus(time.Now()) != StatusPending {
+		return nil, errInvalidInvitation
+	}
+	return invitation, nil
+}
+
+func (s *Service) requireCompanyAdmin(adminID, companyID int64) error {
+	exists, err := s.repo.CompanyExists(companyID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("perusahaan tidak ditemukan (company not found)")
+	}
+
+	isAdmin, err := s.repo.IsCompanyAdmin(adminID, companyID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("access denied: only company admins can manage invitations")
+	}
+	return nil
+}
+
+// resolveScope checks that the branch and unit belong to the company and returns the
+// branch of the assignment, derived from the unit when only the unit is given
+func (s *Service) resolveScope(companyID int64, branchID, unitID *int64) (*int64, error) {
+	if unitID != nil {
+		unitBranchID, err := s.repo.UnitBranchID(*unitID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if branchID != nil && *branchID != unitBranchID {
+			return nil, errors.New("unit tidak berada di cabang yang dipilih (invalid unit)")
+		}
+		return &unitBranchID, nil
+	}
+
+	if branchID != nil {
+		exists, err := s.repo.BranchBelongsToCompany(*branchID, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, errors.New("cabang tidak ditemukan di perusahaan ini (branch not found)")
+		}
+	}
+	return branchID, nil
+}
+
+// checkRoles verifies that every role exists and that only super admins hand out SUPER_ADMIN
+func (s *Service) checkRoles(adminID int64, roleIDs []int64) error {
+	names, err := s.repo.GetRoleNames(roleIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, roleID := range roleIDs {
+		name, ok := names[roleID]
+		if !ok {
+			return fmt.Errorf("peran dengan ID %d tidak ditemukan (role not found)", roleID)
+		}
+		if name == "SUPER_ADMIN" {
+			isSuperAdmin, err := s.repo.IsSuperAdmin(adminID)
+			if err != nil {
+				return err
+			}
+			if !isSuperAdmin {
+				return errors.New("access denied: only super admins can invite super admins")
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) acceptLink(inviteToken string) string {
+	separator := "?"
+	if strings.Contains(s.acceptURL, "?") {
+		separator = "&"
+	}
+	return s.acceptURL + separator + "token=" + url.QueryEscape(inviteToken)
+}
+
+func (s *Service) audit(actorID int64, action string, invitation *Invitation) {
+	details := map[string]interface{}{
+		"email":      invitation.Email,
+		"company_id": invitation.CompanyID,
+		"role_ids":   invitation.RoleIDs,
+	}
+	if err := s.repo.CreateAuditLog(actorID, action, invitation.ID, details); err != nil {
+		logger.Error(fmt.Sprintf("Failed to audit %s for invitation %d: %v", action, invitation.ID, err))
+	}
+}
+
+// toResponses converts invitations, resolving all role names with a single query
+func (s *Service) toResponses(invitations []*Invitation) []*InvitationResponse {
+	var roleIDs []int64
+	for _, invitation := range invitations {
+		roleIDs = append(roleIDs, invitation.RoleIDs...)
+	}
+
+	names, err := s.repo.GetRoleNames(uniqueIDs(roleIDs))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to resolve invitation roles: %v", err))
+		names = map[int64]string{}
+	}
+
+	now := time.Now()
+	responses := make([]*InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		roles := make([]InvitationRoleResponse, 0, len(invitation.RoleIDs))
+		for _, roleID := range invitation.RoleIDs {
+			roles = append(roles, InvitationRoleResponse{RoleID: roleID, RoleName: names[roleID]})
+		}
+
+		responses = append(responses, &InvitationResponse{
+			ID:             invitation.ID,
+			Email:          invitation.Email,
+			Name:           invitation.Name,
+			CompanyID:      invitation.CompanyID,
+			CompanyName:    invitation.CompanyName,
+			BranchID:       invitation.BranchID,
+			UnitID:         invitation.UnitID,
+			Roles:          roles,
+			Status:         invitation.EffectiveStatus(now),
+			InvitedBy:      invitation.InvitedBy,
+			AcceptedUserID: invitation.AcceptedUserID,
+			SendCount:      invitation.SendCount,
+			ExpiresAt:      invitation.ExpiresAt.Format(time.RFC3339),
+			LastSentAt:     invitation.LastSentAt.Format(time.RFC3339),
+			AcceptedAt:     formatTime(invitation.AcceptedAt),
+			RevokedAt:      formatTime(invitation.RevokedAt),
+			CreatedAt:      invitation.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return responses
+}
+
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -97,6 +97,28 @@ type ModuleTreeResponse struct {
 	Path             string                `json:"path"`
 }
 
+// UpdateModuleActionsRequest replaces the actions a module declares beyond the standard ones
+type UpdateModuleActionsRequest struct {
+	Actions []ModuleActionRequest `json:"actions" validate:"max=50,dive"`
+}
+
+type ModuleActionRequest struct {
+	Action      string `json:"action" validate:"required,max=50"`
+	Description string `json:"description" validate:"omitempty,max=255"`
+}
+
+type ModuleActionResponse struct {
+	Action      string `json:"action"`
+	Description string `json:"description"`
+	Standard    bool   `json:"standard"`
+}
+
+// ModuleActionsResponse lists every action that can be granted on a module
+type ModuleActionsResponse struct {
+	ModuleID int64                  `json:"module_id"`
+	Actions  []ModuleActionResponse `json:"actions"`
+}
+
 // Validation functions
 var validate *validator.Validate
 
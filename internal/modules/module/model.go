@@ -48,6 +48,19 @@ type UserModule struct {
 	CanDelete        bool      `json:"can_delete" db:"can_delete"`
 }
 
+// ModuleAction is a permission action a module declares beyond read, write, delete and approve
+type ModuleAction struct {
+	ID          int64     `json:"id" db:"id"`
+	ModuleID    int64     `json:"module_id" db:"module_id"`
+	Action      string    `json:"action" db:"action"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
 func (Module) TableName() string {
 	return "modules"
 }
+
+func (ModuleAction) TableName() string {
+	return "module_actions"
+}
@@ -2,6 +2,7 @@ package module
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	// removed
 	"gin-scalable-api/pkg/model"
@@ -393,7 +394,8 @@ func (r *ModuleRepository) GetUserModules(userID, companyID int64) ([]*UserModul
 			rm.can_read, rm.can_write, rm.can_delete
 		FROM modules m
 		JOIN role_modules rm ON m.id = rm.module_id
-		JOIN user_roles ur ON rm.role_id = ur.role_id
+		JOIN role_ancestors ra ON ra.ancestor_id = rm.role_id
+		JOIN active_user_roles ur ON ur.role_id = ra.role_id
 		WHERE ur.user_id = $1 
 			AND (ur.company_id = $2 OR $2 = 0)
 			AND rm.can_read = true
@@ -432,7 +434,8 @@ func (r *ModuleRepository) CheckUserAccess(userID int64, moduleURL string) (bool
 			SELECT 1
 			FROM modules m
 			JOIN role_modules rm ON m.id = rm.module_id
-			JOIN user_roles ur ON rm.role_id = ur.role_id
+			JOIN role_ancestors ra ON ra.ancestor_id = rm.role_id
+			JOIN active_user_roles ur ON ur.role_id = ra.role_id
 			WHERE ur.user_id = $1 
 				AND m.url = $2
 				AND rm.can_read = true
@@ -517,3 +520,79 @@ func (r *ModuleRepository) Count(search string, category, subscriptionTier strin
 
 	return count, nil
 }
+
+// GetActions retrieves the actions a module declares beyond the standard ones
+func (r *ModuleRepository) GetActions(moduleID int64) ([]*ModuleAction, error) {
+	query := `
+		SELECT id, module_id, action, description, created_at
+		FROM module_actions
+		WHERE module_id = $1
+		ORDER BY action
+	`
+
+	rows, err := r.db.Query(query, moduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []*ModuleAction{}
+	for rows.Next() {
+		action := &ModuleAction{}
+		if err := rows.Scan(&action.ID, &action.ModuleID, &action.Action, &action.Description, &action.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan module action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
+
+// ReplaceActions replaces the declared actions of a module. Actions no longer declared are
+// removed from every role and unit role grant on the module, together with their conditions.
+func (r *ModuleRepository) ReplaceActions(moduleID int64, actions []*ModuleAction, removed []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM module_actions WHERE module_id = $1", moduleID); err != nil {
+		return fmt.Errorf("failed to delete module actions: %w", err)
+	}
+
+	for _, action := range actions {
+		err := tx.QueryRow(`
+			INSERT INTO module_actions (module_id, action, description)
+			VALUES ($1, $2, $3)
+			RETURNING id, created_at
+		`, moduleID, action.Action, action.Description).Scan(&action.ID, &action.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert module action: %w", err)
+		}
+	}
+
+	if len(removed) > 0 {
+		removedList, err := json.Marshal(removed)
+		if err != nil {
+			return err
+		}
+		for _, table := range []string{"role_modules", "unit_role_modules"} {
+			_, err := tx.Exec(fmt.Sprintf(`
+				UPDATE %s
+				SET actions = actions - ARRAY(SELECT jsonb_array_elements_text($2::jsonb)),
+					conditions = NULLIF(conditions - ARRAY(SELECT jsonb_array_elements_text($2::jsonb)), '{}'::jsonb)
+				WHERE module_id = $1
+			`, table), moduleID, string(removedList))
+			if err != nil {
+				return fmt.Errorf("failed to revoke removed actions: %w", err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
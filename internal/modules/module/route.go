@@ -323,6 +323,76 @@ func (h *Handler) GetModuleAncestors(c *gin.Context) {
 	response.Success(c, http.StatusOK, constants.MsgModulesRetrieved, result)
 }
 
+// @Summary      Get module actions
+// @Description  Mendapatkan action yang dapat diberikan pada module: action standar (read, write, delete, approve) dan action yang dideklarasikan module
+// @Tags         Modules
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Module ID"
+// @Success      200  {object}  response.Response{data=module.ModuleActionsResponse}  "Module actions berhasil diambil"
+// @Failure      400  {object}  response.Response  "Bad request - Invalid module ID"
+// @Failure      404  {object}  response.Response  "Module tidak ditemukan"
+// @Failure      500  {object}  response.Response  "Internal server error"
+// @Router       /api/v1/modules/{id}/actions [get]
+// @Security     BearerAuth
+func (h *Handler) GetModuleActions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid module ID")
+		return
+	}
+
+	result, err := h.service.GetModuleActions(id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgModuleActionsRetrieved, result)
+}
+
+// @Summary      Update module actions
+// @Description  Mengganti daftar action yang dideklarasikan module di luar action standar, misalnya export, print, void atau post-journal. Action yang dihapus dicabut dari semua role dan unit role
+// @Tags         Modules
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                                true  "Module ID"
+// @Param        actions  body      module.UpdateModuleActionsRequest  true  "Daftar action module"
+// @Success      200      {object}  response.Response{data=module.ModuleActionsResponse}  "Module actions berhasil diupdate"
+// @Failure      400      {object}  response.Response  "Bad request - Invalid module ID atau nama action tidak valid"
+// @Failure      404      {object}  response.Response  "Module tidak ditemukan"
+// @Failure      409      {object}  response.Response  "Conflict - action duplikat atau action standar"
+// @Failure      500      {object}  response.Response  "Internal server error"
+// @Router       /api/v1/modules/{id}/actions [put]
+// @Security     BearerAuth
+func (h *Handler) UpdateModuleActions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid module ID")
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*UpdateModuleActionsRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.UpdateModuleActions(id, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgModuleActionsUpdated, result)
+}
+
 // Route registration
 func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 	modules := api.Group("/modules")
@@ -360,5 +430,16 @@ func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 
 		// GET /api/v1/modules/:id/ancestors - Get module ancestors by ID
 		modules.GET("/:id/ancestors", handler.GetModuleAncestors)
+
+		// GET /api/v1/modules/:id/actions - Get the actions that can be granted on a module
+		modules.GET("/:id/actions", handler.GetModuleActions)
+
+		// PUT /api/v1/modules/:id/actions - Replace the actions a module declares
+		modules.PUT("/:id/actions",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &UpdateModuleActionsRequest{},
+			}),
+			handler.UpdateModuleActions,
+		)
 	}
 }
@@ -1,15 +1,19 @@
 package module
 
 import (
+	"fmt"
 	"time"
+
+	"gin-scalable-api/pkg/rbac"
 )
 
 type Service struct {
-	repo *ModuleRepository
+	repo            *ModuleRepository
+	permissionCache *rbac.PermissionCache
 }
 
-func NewService(repo *ModuleRepository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *ModuleRepository, permissionCache *rbac.PermissionCache) *Service {
+	return &Service{repo: repo, permissionCache: permissionCache}
 }
 
 func (s *Service) GetModules(req *ModuleListRequest) (*ModuleListResponse, error) {
@@ -203,11 +207,99 @@ func (s *Service) UpdateModule(id int64, req *UpdateModuleRequest) (*ModuleRespo
 		return nil, err
 	}
 
+	// Inactive modules and modules above the company's subscription tier grant nothing
+	if req.IsActive != nil || req.SubscriptionTier != "" {
+		if err := s.permissionCache.InvalidateModule(id); err != nil {
+			return nil, err
+		}
+	}
+
 	return toModuleResponse(module), nil
 }
 
 func (s *Service) DeleteModule(id int64) error {
-	return s.repo.Delete(id)
+	// Resolve the holders of grants on the module before it is deleted
+	userIDs, err := s.permissionCache.ModuleUserIDs(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	return s.permissionCache.InvalidateUsers(userIDs...)
+}
+
+// GetModuleActions lists the standard actions and the actions the module declares
+func (s *Service) GetModuleActions(id int64) (*ModuleActionsResponse, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	actions, err := s.repo.GetActions(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toModuleActionsResponse(id, actions), nil
+}
+
+// UpdateModuleActions replaces the actions the module declares beyond the standard ones.
+// Grants of actions that are no longer declared are revoked.
+func (s *Service) UpdateModuleActions(id int64, req *UpdateModuleActionsRequest) (*ModuleActionsResponse, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool, len(req.Actions))
+	actions := make([]*ModuleAction, 0, len(req.Actions))
+	for _, action := range req.Actions {
+		if !rbac.IsValidAction(action.Action) {
+			return nil, fmt.Errorf("nama action %q tidak valid (invalid): gunakan huruf kecil, angka, '_' atau '-' dan diawali huruf", action.Action)
+		}
+		if rbac.IsStandardAction(action.Action) {
+			return nil, fmt.Errorf("action %q sudah tersedia sebagai action standar (already exists)", action.Action)
+		}
+		if declared[action.Action] {
+			return nil, fmt.Errorf("action %q dideklarasikan lebih dari sekali (duplicate)", action.Action)
+		}
+		declared[action.Action] = true
+		actions = append(actions, &ModuleAction{ModuleID: id, Action: action.Action, Description: action.Description})
+	}
+
+	current, err := s.repo.GetActions(id)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, action := range current {
+		if !declared[action.Action] {
+			removed = append(removed, action.Action)
+		}
+	}
+
+	if err := s.repo.ReplaceActions(id, actions, removed); err != nil {
+		return nil, err
+	}
+	if len(removed) > 0 {
+		if err := s.permissionCache.InvalidateModule(id); err != nil {
+			return nil, err
+		}
+	}
+
+	return toModuleActionsResponse(id, actions), nil
+}
+
+func toModuleActionsResponse(moduleID int64, declared []*ModuleAction) *ModuleActionsResponse {
+	response := &ModuleActionsResponse{ModuleID: moduleID, Actions: []ModuleActionResponse{}}
+	for _, action := range rbac.StandardActions {
+		response.Actions = append(response.Actions, ModuleActionResponse{Action: action, Standard: true})
+	}
+	for _, action := range declared {
+		response.Actions = append(response.Actions, ModuleActionResponse{Action: action.Action, Description: action.Description})
+	}
+	return response
 }
 
 func toModuleResponse(module *Module) *ModuleResponse {
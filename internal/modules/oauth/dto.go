@@ -0,0 +1,173 @@
+package oauth
+
+import (
+	"net/http"
+	"time"
+)
+
+// Client registration DTOs
+type CreateClientRequest struct {
+	ApplicationID  int64    `json:"application_id" validate:"required,min=1"`
+	Name           string   `json:"name" validate:"required,min=2,max=100"`
+	RedirectURIs   []string `json:"redirect_uris" validate:"required,min=1,dive,required,url"`
+	IsConfidential *bool    `json:"is_confidential"`
+}
+
+type UpdateClientRequest struct {
+	Name         *string  `json:"name" validate:"omitempty,min=2,max=100"`
+	RedirectURIs []string `json:"redirect_uris" validate:"omitempty,min=1,dive,required,url"`
+	IsActive     *bool    `json:"is_active"`
+}
+
+type ClientResponse struct {
+	ID              int64     `json:"id"`
+	ApplicationID   int64     `json:"application_id"`
+	ApplicationCode string    `json:"application_code"`
+	ApplicationName string    `json:"application_name"`
+	ClientID        string    `json:"client_id"`
+	Name            string    `json:"name"`
+	RedirectURIs    []string  `json:"redirect_uris"`
+	IsConfidential  bool      `json:"is_confidential"`
+	IsActive        bool      `json:"is_active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ClientCredentialsResponse carries the client secret, which is only shown once
+type ClientCredentialsResponse struct {
+	Client       *ClientResponse `json:"client"`
+	ClientSecret string          `json:"client_secret,omitempty"`
+}
+
+// Authorization DTOs
+
+// AuthorizeRequest holds the parameters of an authorization request. The login portal
+// forwards them from the query string of the client redirect.
+type AuthorizeRequest struct {
+	ResponseType        string `json:"response_type" form:"response_type" validate:"required"`
+	ClientID            string `json:"client_id" form:"client_id" validate:"required"`
+	RedirectURI         string `json:"redirect_uri" form:"redirect_uri" validate:"required"`
+	Scope               string `json:"scope" form:"scope" validate:"required"`
+	State               string `json:"state" form:"state"`
+	Nonce               string `json:"nonce" form:"nonce"`
+	CodeChallenge       string `json:"code_challenge" form:"code_challenge" validate:"required"`
+	CodeChallengeMethod string `json:"code_challenge_method" form:"code_challenge_method"`
+	Prompt              string `json:"prompt" form:"prompt"`
+}
+
+// ConsentDecisionRequest is the authorization request together with the decision of the user
+type ConsentDecisionRequest struct {
+	AuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+type AuthorizeResponse struct {
+	ClientID        string   `json:"client_id"`
+	ClientName      string   `json:"client_name"`
+	ApplicationCode string   `json:"application_code"`
+	ApplicationName string   `json:"application_name"`
+	Scopes          []string `json:"scopes"`
+	ConsentRequired bool     `json:"consent_required"`
+	// RedirectTo is set once the request is decided; the portal sends the browser there
+	RedirectTo string `json:"redirect_to,omitempty"`
+}
+
+type ConsentResponse struct {
+	ClientID        string    `json:"client_id"`
+	ClientName      string    `json:"client_name"`
+	ApplicationName string    `json:"application_name"`
+	Scopes          []string  `json:"scopes"`
+	GrantedAt       time.Time `json:"granted_at"`
+}
+
+// Token endpoint DTOs (RFC 6749 section 4.1.3 and 6), sent form encoded
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope"`
+}
+
+// IDTokenClaims are the claims of an OpenID Connect ID token
+type IDTokenClaims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          string   `json:"aud"`
+	ExpiresAt         int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	AuthTime          int64    `json:"auth_time"`
+	Nonce             string   `json:"nonce,omitempty"`
+	AuthorizedParty   string   `json:"azp"`
+	Name              string   `json:"name,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	CompanyID         int64    `json:"company_id,omitempty"`
+	Roles             []string `json:"roles,omitempty"`
+	Modules           []string `json:"modules,omitempty"`
+}
+
+type UserInfoResponse struct {
+	Subject           string   `json:"sub"`
+	Name              string   `json:"name,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	CompanyID         int64    `json:"company_id,omitempty"`
+	Roles             []string `json:"roles,omitempty"`
+	Modules           []string `json:"modules,omitempty"`
+}
+
+// DiscoveryResponse is the OpenID Provider metadata document
+type DiscoveryResponse struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// Error is an OAuth2 error response (RFC 6749 section 5.2)
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Status      int    `json:"-"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newError(status int, code, description string) *Error {
+	return &Error{Code: code, Description: description, Status: status}
+}
+
+func invalidRequest(description string) *Error {
+	return newError(http.StatusBadRequest, "invalid_request", description)
+}
+
+func invalidClient(description string) *Error {
+	return newError(http.StatusUnauthorized, "invalid_client", description)
+}
+
+func invalidGrant(description string) *Error {
+	return newError(http.StatusBadRequest, "invalid_grant", description)
+}
@@ -0,0 +1,48 @@
+package oauth
+
+import (
+	"time"
+)
+
+// Client is an OAuth2 / OpenID Connect client registered for an application
+type Client struct {
+	ID               int64     `json:"id" db:"id"`
+	ApplicationID    int64     `json:"application_id" db:"application_id"`
+	ApplicationCode  string    `json:"application_code" db:"application_code"`
+	ApplicationName  string    `json:"application_name" db:"application_name"`
+	ClientID         string    `json:"client_id" db:"client_id"`
+	ClientSecretHash *string   `json:"-" db:"client_secret_hash"`
+	Name             string    `json:"name" db:"name"`
+	RedirectURIs     []string  `json:"redirect_uris" db:"redirect_uris"`
+	IsConfidential   bool      `json:"is_confidential" db:"is_confidential"`
+	IsActive         bool      `json:"is_active" db:"is_active"`
+	CreatedBy        *int64    `json:"created_by" db:"created_by"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Consent records the scopes a user granted to a client
+type Consent struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	ClientID  int64     `json:"client_id" db:"client_id"`
+	Scopes    string    `json:"scopes" db:"scopes"`
+	GrantedAt time.Time `json:"granted_at" db:"granted_at"`
+}
+
+// User model for oauth module - only fields needed for ID tokens and userinfo
+type User struct {
+	ID           int64   `json:"id" db:"id"`
+	Name         string  `json:"name" db:"name"`
+	Email        string  `json:"email" db:"email"`
+	UserIdentity *string `json:"user_identity" db:"user_identity"`
+	IsActive     bool    `json:"is_active" db:"is_active"`
+}
+
+func (Client) TableName() string {
+	return "oauth_clients"
+}
+
+func (Consent) TableName() string {
+	return "oauth_consents"
+}
@@ -0,0 +1,362 @@
+package oauth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const clientSelect = `
+	SELECT oc.id, oc.application_id, a.code, a.name, oc.client_id, oc.client_secret_hash, oc.name,
+		oc.redirect_uris, oc.is_confidential, oc.is_active AND a.is_active, oc.created_by,
+		oc.created_at, oc.updated_at
+	FROM oauth_clients oc
+	JOIN applications a ON oc.application_id = a.id
+`
+
+func scanClient(scanner interface{ Scan(...interface{}) error }) (*Client, error) {
+	client := &Client{}
+	var redirectURIs []byte
+
+	err := scanner.Scan(
+		&client.ID, &client.ApplicationID, &client.ApplicationCode, &client.ApplicationName,
+		&client.ClientID, &client.ClientSecretHash, &client.Name, &redirectURIs,
+		&client.IsConfidential, &client.IsActive, &client.CreatedBy,
+		&client.CreatedAt, &client.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(redirectURIs, &client.RedirectURIs); err != nil {
+		return nil, fmt.Errorf("failed to parse redirect uris: %w", err)
+	}
+
+	return client, nil
+}
+
+// ApplicationExists checks whether an application exists
+func (r *Repository) ApplicationExists(applicationID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM applications WHERE id = $1)`
+	if err := r.db.QueryRow(query, applicationID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check application: %w", err)
+	}
+	return exists, nil
+}
+
+// IsSuperAdmin checks whether a user holds the SUPER_ADMIN role
+func (r *Repository) IsSuperAdmin(userID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true AND r.name = 'SUPER_ADMIN'
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check super admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// CreateClient registers a new OAuth client
+func (r *Repository) CreateClient(client *Client) error {
+	redirectURIs, err := json.Marshal(client.RedirectURIs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO oauth_clients (application_id, client_id, client_secret_hash, name, redirect_uris,
+			is_confidential, is_active, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, true, $7, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+
+	err = r.db.QueryRow(query, client.ApplicationID, client.ClientID, client.ClientSecretHash, client.Name,
+		redirectURIs, client.IsConfidential, client.CreatedBy,
+	).Scan(&client.ID, &client.CreatedAt, &client.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create oauth client: %w", err)
+	}
+
+	return nil
+}
+
+// GetClientByID retrieves a client by its primary key
+func (r *Repository) GetClientByID(id int64) (*Client, error) {
+	client, err := scanClient(r.db.QueryRow(clientSelect+" WHERE oc.id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("oauth client not found")
+		}
+		return nil, fmt.Errorf("failed to get oauth client: %w", err)
+	}
+	return client, nil
+}
+
+// GetClientByClientID retrieves a client by its public client_id
+func (r *Repository) GetClientByClientID(clientID string) (*Client, error) {
+	client, err := scanClient(r.db.QueryRow(clientSelect+" WHERE oc.client_id = $1", clientID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("oauth client not found")
+		}
+		return nil, fmt.Errorf("failed to get oauth client: %w", err)
+	}
+	return client, nil
+}
+
+// GetClients lists clients, optionally of a single application
+func (r *Repository) GetClients(applicationID int64) ([]*Client, error) {
+	query := clientSelect + " WHERE ($1 = 0 OR oc.application_id = $1) ORDER BY oc.id"
+
+	rows, err := r.db.Query(query, applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth clients: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []*Client
+	for rows.Next() {
+		client, err := scanClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan oauth client: %w", err)
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+// UpdateClient saves name, redirect URIs and status of a client
+func (r *Repository) UpdateClient(client *Client) error {
+	redirectURIs, err := json.Marshal(client.RedirectURIs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE oauth_clients
+		SET name = $1, redirect_uris = $2, is_active = $3, updated_at = NOW()
+		WHERE id = $4
+	`
+	if _, err := r.db.Exec(query, client.Name, redirectURIs, client.IsActive, client.ID); err != nil {
+		return fmt.Errorf("failed to update oauth client: %w", err)
+	}
+	return nil
+}
+
+// UpdateClientSecret replaces the secret hash of a confidential client
+func (r *Repository) UpdateClientSecret(id int64, secretHash string) error {
+	query := `UPDATE oauth_clients SET client_secret_hash = $1, updated_at = NOW() WHERE id = $2`
+	if _, err := r.db.Exec(query, secretHash, id); err != nil {
+		return fmt.Errorf("failed to update oauth client secret: %w", err)
+	}
+	return nil
+}
+
+// DeleteClient removes a client together with its consents
+func (r *Repository) DeleteClient(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM oauth_clients WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete oauth client: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("oauth client not found")
+	}
+
+	return nil
+}
+
+// GetConsent retrieves the consent a user gave to a client, nil if there is none
+func (r *Repository) GetConsent(userID, clientID int64) (*Consent, error) {
+	consent := &Consent{}
+	query := `
+		SELECT id, user_id, client_id, scopes, granted_at
+		FROM oauth_consents
+		WHERE user_id = $1 AND client_id = $2
+	`
+
+	err := r.db.QueryRow(query, userID, clientID).Scan(
+		&consent.ID, &consent.UserID, &consent.ClientID, &consent.Scopes, &consent.GrantedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consent: %w", err)
+	}
+
+	return consent, nil
+}
+
+// SaveConsent stores or replaces the consent of a user for a client
+func (r *Repository) SaveConsent(userID, clientID int64, scopes string) error {
+	query := `
+		INSERT INTO oauth_consents (user_id, client_id, scopes, granted_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = EXCLUDED.scopes, granted_at = EXCLUDED.granted_at
+	`
+	if _, err := r.db.Exec(query, userID, clientID, scopes, time.Now()); err != nil {
+		return fmt.Errorf("failed to save consent: %w", err)
+	}
+	return nil
+}
+
+// GetUserConsents lists the consents of a user with client details
+func (r *Repository) GetUserConsents(userID int64) ([]*ConsentResponse, error) {
+	query := `
+		SELECT oc.client_id, oc.name, a.name, c.scopes, c.granted_at
+		FROM oauth_consents c
+		JOIN oauth_clients oc ON c.client_id = oc.id
+		JOIN applications a ON oc.application_id = a.id
+		WHERE c.user_id = $1
+		ORDER BY c.granted_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consents: %w", err)
+	}
+	defer rows.Close()
+
+	var consents []*ConsentResponse
+	for rows.Next() {
+		consent := &ConsentResponse{}
+		var scopes string
+		if err := rows.Scan(&consent.ClientID, &consent.ClientName, &consent.ApplicationName, &scopes, &consent.GrantedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan consent: %w", err)
+		}
+		consent.Scopes = splitScope(scopes)
+		consents = append(consents, consent)
+	}
+
+	return consents, nil
+}
+
+// DeleteConsent revokes the consent of a user for a client
+func (r *Repository) DeleteConsent(userID, clientID int64) error {
+	result, err := r.db.Exec(`DELETE FROM oauth_consents WHERE user_id = $1 AND client_id = $2`, userID, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to delete consent: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("consent not found")
+	}
+
+	return nil
+}
+
+// GetUserByID retrieves the profile fields used in ID tokens
+func (r *Repository) GetUserByID(id int64) (*User, error) {
+	user := &User{}
+	query := `SELECT id, name, email, user_identity, is_active FROM users WHERE id = $1 AND deleted_at IS NULL`
+
+	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Name, &user.Email, &user.UserIdentity, &user.IsActive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserCompanyID returns the company of the first active role assignment of a user
+func (r *Repository) GetUserCompanyID(userID int64) (int64, error) {
+	var companyID int64
+	query := `SELECT company_id FROM active_user_roles WHERE user_id = $1 ORDER BY id LIMIT 1`
+
+	err := r.db.QueryRow(query, userID).Scan(&companyID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user company: %w", err)
+	}
+
+	return companyID, nil
+}
+
+// GetUserApplicationRoles returns the names of active roles a user holds for an application
+func (r *Repository) GetUserApplicationRoles(userID, applicationID int64) ([]string, error) {
+	query := `
+		SELECT DISTINCT ro.name
+		FROM active_user_roles ur
+		JOIN roles ro ON ur.role_id = ro.id
+		WHERE ur.user_id = $1 AND ro.is_active = true AND ro.application_id = $2
+		ORDER BY ro.name
+	`
+
+	return r.queryStrings(query, userID, applicationID)
+}
+
+// GetUserApplicationModules returns the module URLs of an application a user may read,
+// limited to modules included in the active subscription of the company
+func (r *Repository) GetUserApplicationModules(userID, applicationID, companyID int64) ([]string, error) {
+	query := `
+		SELECT DISTINCT m.url
+		FROM modules m
+		JOIN role_modules rm ON m.id = rm.module_id
+		JOIN role_ancestors ra ON ra.ancestor_id = rm.role_id
+		JOIN active_user_roles ur ON ur.role_id = ra.role_id
+		JOIN plan_modules pm ON m.id = pm.module_id
+		JOIN subscriptions s ON pm.plan_id = s.plan_id
+		WHERE ur.user_id = $1
+		AND m.application_id = $2
+		AND s.company_id = $3
+		AND s.status = 'active'
+		AND (s.end_date >= CURRENT_DATE OR s.end_date IS NULL)
+		AND pm.is_included = true
+		AND m.is_active = true
+		AND rm.can_read = true
+		ORDER BY m.url
+	`
+
+	return r.queryStrings(query, userID, applicationID, companyID)
+}
+
+func (r *Repository) queryStrings(query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %w", err)
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to scan: %w", err)
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,511 @@
+package oauth
+
+import (
+	"errors"
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/middleware"
+	"gin-scalable-api/pkg/response"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler struct
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Handler methods
+
+// @Summary      Daftar OAuth client
+// @Description  Mengambil daftar OAuth client, opsional difilter per aplikasi. Hanya untuk SUPER_ADMIN
+// @Tags         OAuth
+// @Produce      json
+// @Param        application_id  query     int  false  "Filter berdasarkan application ID"
+// @Success      200             {object}  response.Response{data=[]oauth.ClientResponse}  "Daftar client berhasil diambil"
+// @Failure      403             {object}  response.Response  "Bukan super admin"
+// @Router       /api/v1/oauth/clients [get]
+// @Security     BearerAuth
+func (h *Handler) GetClients(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var applicationID int64
+	if value := c.Query("application_id"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Bad request", "Invalid application ID")
+			return
+		}
+		applicationID = parsed
+	}
+
+	result, err := h.service.GetClients(userID, applicationID)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgOAuthClientsRetrieved, result)
+}
+
+// @Summary      Daftarkan OAuth client
+// @Description  Mendaftarkan OAuth client untuk sebuah aplikasi. Client secret hanya ditampilkan sekali. Client publik (is_confidential=false) tidak memiliki secret dan wajib memakai PKCE
+// @Tags         OAuth
+// @Accept       json
+// @Produce      json
+// @Param        client  body      oauth.CreateClientRequest  true  "Data client"
+// @Success      201     {object}  response.Response{data=oauth.ClientCredentialsResponse}  "Client berhasil dibuat"
+// @Failure      400     {object}  response.Response  "Bad request"
+// @Failure      403     {object}  response.Response  "Bukan super admin"
+// @Failure      404     {object}  response.Response  "Aplikasi tidak ditemukan"
+// @Router       /api/v1/oauth/clients [post]
+// @Security     BearerAuth
+func (h *Handler) CreateClient(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*CreateClientRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.CreateClient(userID, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusCreated, constants.MsgOAuthClientCreated, result)
+}
+
+// @Summary      Update OAuth client
+// @Description  Mengubah nama, redirect URI, atau status OAuth client. Hanya untuk SUPER_ADMIN
+// @Tags         OAuth
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                        true  "Client ID (internal)"
+// @Param        client  body      oauth.UpdateClientRequest  true  "Data client"
+// @Success      200     {object}  response.Response{data=oauth.ClientResponse}  "Client berhasil diupdate"
+// @Failure      400     {object}  response.Response  "Bad request"
+// @Failure      403     {object}  response.Response  "Bukan super admin"
+// @Failure      404     {object}  response.Response  "Client tidak ditemukan"
+// @Router       /api/v1/oauth/clients/{id} [put]
+// @Security     BearerAuth
+func (h *Handler) UpdateClient(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid client ID")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*UpdateClientRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.UpdateClient(userID, id, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgOAuthClientUpdated, result)
+}
+
+// @Summary      Rotasi client secret
+// @Description  Membuat client secret baru untuk client confidential. Secret lama langsung tidak berlaku
+// @Tags         OAuth
+// @Produce      json
+// @Param        id   path      int  true  "Client ID (internal)"
+// @Success      200  {object}  response.Response{data=oauth.ClientCredentialsResponse}  "Secret berhasil dirotasi"
+// @Failure      400  {object}  response.Response  "Client publik"
+// @Failure      403  {object}  response.Response  "Bukan super admin"
+// @Failure      404  {object}  response.Response  "Client tidak ditemukan"
+// @Router       /api/v1/oauth/clients/{id}/secret [post]
+// @Security     BearerAuth
+func (h *Handler) RotateClientSecret(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid client ID")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.RotateClientSecret(userID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgOAuthClientSecretRotated, result)
+}
+
+// @Summary      Hapus OAuth client
+// @Description  Menghapus OAuth client beserta seluruh consent pengguna. Hanya untuk SUPER_ADMIN
+// @Tags         OAuth
+// @Produce      json
+// @Param        id   path      int  true  "Client ID (internal)"
+// @Success      200  {object}  response.Response  "Client berhasil dihapus"
+// @Failure      403  {object}  response.Response  "Bukan super admin"
+// @Failure      404  {object}  response.Response  "Client tidak ditemukan"
+// @Router       /api/v1/oauth/clients/{id} [delete]
+// @Security     BearerAuth
+func (h *Handler) DeleteClient(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid client ID")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteClient(userID, id); err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgOAuthClientDeleted, nil)
+}
+
+// @Summary      Validasi authorization request
+// @Description  Dipanggil oleh login portal dengan parameter authorization request dari aplikasi client. Jika pengguna sudah memberi consent, code langsung diterbitkan dan redirect_to berisi URL tujuan; jika belum, consent_required bernilai true
+// @Tags         OAuth
+// @Produce      json
+// @Param        response_type          query     string  true   "Harus code"
+// @Param        client_id              query     string  true   "Client ID"
+// @Param        redirect_uri           query     string  true   "Redirect URI terdaftar"
+// @Param        scope                  query     string  true   "Scope, wajib berisi openid"
+// @Param        state                  query     string  false  "State dari client"
+// @Param        nonce                  query     string  false  "Nonce untuk ID token"
+// @Param        code_challenge         query     string  true   "PKCE code challenge"
+// @Param        code_challenge_method  query     string  true   "Harus S256"
+// @Param        prompt                 query     string  false  "none atau consent"
+// @Success      200                    {object}  response.Response{data=oauth.AuthorizeResponse}  "Authorization request valid"
+// @Failure      400                    {object}  response.Response  "Parameter tidak valid"
+// @Router       /api/v1/oauth/authorize [get]
+// @Security     BearerAuth
+func (h *Handler) Authorize(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok || !requireFirstPartySession(c) {
+		return
+	}
+
+	var req AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	result, err := h.service.Authorize(userID, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Authorization failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAuthorizationValidated, result)
+}
+
+// @Summary      Keputusan consent
+// @Description  Menyimpan keputusan consent pengguna dan mengembalikan redirect_to berisi authorization code atau error access_denied
+// @Tags         OAuth
+// @Accept       json
+// @Produce      json
+// @Param        decision  body      oauth.ConsentDecisionRequest  true  "Authorization request beserta keputusan"
+// @Success      200       {object}  response.Response{data=oauth.AuthorizeResponse}  "Keputusan berhasil disimpan"
+// @Failure      400       {object}  response.Response  "Parameter tidak valid"
+// @Router       /api/v1/oauth/authorize [post]
+// @Security     BearerAuth
+func (h *Handler) DecideConsent(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok || !requireFirstPartySession(c) {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*ConsentDecisionRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.DecideConsent(userID, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Authorization failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAuthorizationDecided, result)
+}
+
+// @Summary      Token endpoint
+// @Description  Menukar authorization code (dengan PKCE code_verifier) atau refresh token menjadi access token, refresh token, dan ID token. Request form-urlencoded; client confidential mengautentikasi dengan HTTP Basic atau client_secret
+// @Tags         OAuth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type     formData  string  true   "authorization_code atau refresh_token"
+// @Param        code           formData  string  false  "Authorization code"
+// @Param        redirect_uri   formData  string  false  "Redirect URI yang sama dengan authorization request"
+// @Param        code_verifier  formData  string  false  "PKCE code verifier"
+// @Param        refresh_token  formData  string  false  "Refresh token"
+// @Param        client_id      formData  string  false  "Client ID (jika tidak memakai HTTP Basic)"
+// @Param        client_secret  formData  string  false  "Client secret (jika tidak memakai HTTP Basic)"
+// @Success      200            {object}  oauth.TokenResponse  "Token berhasil diterbitkan"
+// @Failure      400            {object}  oauth.Error  "invalid_request, invalid_grant, unsupported_grant_type"
+// @Failure      401            {object}  oauth.Error  "invalid_client"
+// @Router       /api/v1/oauth/token [post]
+func (h *Handler) Token(c *gin.Context) {
+	var req TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		writeError(c, invalidRequest(err.Error()))
+		return
+	}
+
+	// client_secret_basic: credentials are form encoded before being put in the header
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, _ = url.QueryUnescape(id)
+		req.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+
+	result, err := h.service.Token(&req, c.GetHeader("User-Agent"), c.ClientIP())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary      UserInfo endpoint
+// @Description  Mengembalikan klaim pengguna sesuai scope dari access token yang diterbitkan melalui OAuth
+// @Tags         OAuth
+// @Produce      json
+// @Success      200  {object}  oauth.UserInfoResponse  "Klaim pengguna"
+// @Failure      401  {object}  response.Response  "Token tidak valid"
+// @Failure      403  {object}  oauth.Error  "insufficient_scope"
+// @Router       /api/v1/oauth/userinfo [get]
+// @Security     BearerAuth
+func (h *Handler) UserInfo(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.UserInfo(userID, c.GetString("client_id"), c.GetString("scope"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary      Daftar consent
+// @Description  Mengambil daftar aplikasi yang telah diberi consent oleh pengguna saat ini
+// @Tags         OAuth
+// @Produce      json
+// @Success      200  {object}  response.Response{data=[]oauth.ConsentResponse}  "Daftar consent berhasil diambil"
+// @Router       /api/v1/oauth/consents [get]
+// @Security     BearerAuth
+func (h *Handler) GetConsents(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetConsents(userID)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgConsentsRetrieved, result)
+}
+
+// @Summary      Cabut consent
+// @Description  Mencabut consent pengguna saat ini untuk sebuah client; authorization berikutnya akan meminta consent lagi
+// @Tags         OAuth
+// @Produce      json
+// @Param        client_id  path      string  true  "Client ID"
+// @Success      200        {object}  response.Response  "Consent berhasil dicabut"
+// @Failure      404        {object}  response.Response  "Consent tidak ditemukan"
+// @Router       /api/v1/oauth/consents/{client_id} [delete]
+// @Security     BearerAuth
+func (h *Handler) RevokeConsent(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeConsent(userID, c.Param("client_id")); err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgConsentRevoked, nil)
+}
+
+// @Summary      OpenID Connect discovery
+// @Description  Metadata OpenID Provider untuk konfigurasi otomatis client OIDC
+// @Tags         OAuth
+// @Produce      json
+// @Success      200  {object}  oauth.DiscoveryResponse  "Metadata provider"
+// @Router       /.well-known/openid-configuration [get]
+func (h *Handler) Discovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.service.Discovery())
+}
+
+// currentUserID reads the authenticated user from the context, writing the error response if missing
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
+// requireFirstPartySession rejects access tokens issued to OAuth clients, so a client
+// cannot approve authorization requests on behalf of the user
+func requireFirstPartySession(c *gin.Context) bool {
+	if c.GetString("client_id") != "" {
+		response.Error(c, http.StatusForbidden, "Forbidden", "access denied: authorization requires a login portal session")
+		return false
+	}
+	return true
+}
+
+// writeError renders errors of the token and userinfo endpoints in the OAuth2 format
+func writeError(c *gin.Context, err error) {
+	var oauthErr *Error
+	if !errors.As(err, &oauthErr) {
+		oauthErr = newError(http.StatusInternalServerError, "server_error", err.Error())
+	}
+
+	if oauthErr.Status == http.StatusUnauthorized && oauthErr.Code == "invalid_client" {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(oauthErr.Status, oauthErr)
+}
+
+// Route registration
+func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
+	oauth := api.Group("/oauth")
+	{
+		// POST /api/v1/oauth/token - Exchange authorization code or refresh token
+		oauth.POST("/token", handler.Token)
+	}
+}
+
+func RegisterProtectedRoutes(router *gin.RouterGroup, handler *Handler) {
+	oauth := router.Group("/oauth")
+	{
+		// GET /api/v1/oauth/authorize - Validate an authorization request for the login portal
+		oauth.GET("/authorize", handler.Authorize)
+
+		// POST /api/v1/oauth/authorize - Record the consent decision and issue the code
+		oauth.POST("/authorize",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &ConsentDecisionRequest{},
+			}),
+			handler.DecideConsent,
+		)
+
+		// GET /api/v1/oauth/userinfo - Claims about the user of an OAuth access token
+		oauth.GET("/userinfo", handler.UserInfo)
+
+		// POST /api/v1/oauth/userinfo - Same as GET, as allowed by OpenID Connect
+		oauth.POST("/userinfo", handler.UserInfo)
+
+		// GET /api/v1/oauth/consents - Consents of the current user
+		oauth.GET("/consents", handler.GetConsents)
+
+		// DELETE /api/v1/oauth/consents/:client_id - Revoke a consent of the current user
+		oauth.DELETE("/consents/:client_id", handler.RevokeConsent)
+
+		// GET /api/v1/oauth/clients - List OAuth clients
+		oauth.GET("/clients", handler.GetClients)
+
+		// POST /api/v1/oauth/clients - Register an OAuth client for an application
+		oauth.POST("/clients",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &CreateClientRequest{},
+			}),
+			handler.CreateClient,
+		)
+
+		// PUT /api/v1/oauth/clients/:id - Update an OAuth client
+		oauth.PUT("/clients/:id",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &UpdateClientRequest{},
+			}),
+			handler.UpdateClient,
+		)
+
+		// POST /api/v1/oauth/clients/:id/secret - Rotate the client secret
+		oauth.POST("/clients/:id/secret", handler.RotateClientSecret)
+
+		// DELETE /api/v1/oauth/clients/:id - Delete an OAuth client
+		oauth.DELETE("/clients/:id", handler.DeleteClient)
+	}
+}
+
+// RegisterWellKnownRoutes registers the OpenID Connect discovery document at the server root
+func RegisterWellKnownRoutes(router *gin.Engine, handler *Handler) {
+	// GET /.well-known/openid-configuration - OpenID Provider metadata
+	router.GET("/.well-known/openid-configuration", handler.Discovery)
+}
@@ -0,0 +1,706 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/pkg/jwt"
+	"gin-scalable-api/pkg/token"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// supportedScopes are the scopes a client may request. Refresh tokens are always
+// issued, so every OAuth login is a regular session that can be listed and revoked.
+var supportedScopes = []string{"openid", "profile", "email", "roles"}
+
+type Service struct {
+	repo         *Repository
+	tokenService *token.SimpleTokenService
+	signingKeys  *jwt.KeySet
+	issuer       string
+}
+
+func NewService(repo *Repository, tokenService *token.SimpleTokenService, signingKeys *jwt.KeySet, issuer string) *Service {
+	return &Service{
+		repo:         repo,
+		tokenService: tokenService,
+		signingKeys:  signingKeys,
+		issuer:       strings.TrimSuffix(issuer, "/"),
+	}
+}
+
+// Client registration
+
+func (s *Service) CreateClient(adminID int64, req *CreateClientRequest) (*ClientCredentialsResponse, error) {
+	if err := s.requireSuperAdmin(adminID); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repo.ApplicationExists(req.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("aplikasi tidak ditemukan (application not found)")
+	}
+
+	if err := validateRedirectURIs(req.RedirectURIs); err != nil {
+		return nil, err
+	}
+
+	clientID, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		ApplicationID:  req.ApplicationID,
+		ClientID:       clientID[:32],
+		Name:           req.Name,
+		RedirectURIs:   req.RedirectURIs,
+		IsConfidential: req.IsConfidential == nil || *req.IsConfidential,
+		CreatedBy:      &adminID,
+	}
+
+	var clientSecret string
+	if client.IsConfidential {
+		clientSecret, err = s.tokenService.GenerateToken()
+		if err != nil {
+			return nil, err
+		}
+		secretHash := s.tokenService.HashToken(clientSecret)
+		client.ClientSecretHash = &secretHash
+	}
+
+	if err := s.repo.CreateClient(client); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.GetClientByID(client.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientCredentialsResponse{
+		Client:       toClientResponse(created),
+		ClientSecret: clientSecret,
+	}, nil
+}
+
+func (s *Service) GetClients(adminID, applicationID int64) ([]*ClientResponse, error) {
+	if err := s.requireSuperAdmin(adminID); err != nil {
+		return nil, err
+	}
+
+	clients, err := s.repo.GetClients(applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []*ClientResponse{}
+	for _, client := range clients {
+		responses = append(responses, toClientResponse(client))
+	}
+
+	return responses, nil
+}
+
+func (s *Service) UpdateClient(adminID, id int64, req *UpdateClientRequest) (*ClientResponse, error) {
+	if err := s.requireSuperAdmin(adminID); err != nil {
+		return nil, err
+	}
+
+	client, err := s.repo.GetClientByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		client.Name = *req.Name
+	}
+	if req.RedirectURIs != nil {
+		if err := validateRedirectURIs(req.RedirectURIs); err != nil {
+			return nil, err
+		}
+		client.RedirectURIs = req.RedirectURIs
+	}
+	if req.IsActive != nil {
+		client.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateClient(client); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.GetClientByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toClientResponse(updated), nil
+}
+
+// RotateClientSecret issues a new secret; the previous secret stops working immediately
+func (s *Service) RotateClientSecret(adminID, id int64) (*ClientCredentialsResponse, error) {
+	if err := s.requireSuperAdmin(adminID); err != nil {
+		return nil, err
+	}
+
+	client, err := s.repo.GetClientByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !client.IsConfidential {
+		return nil, errors.New("client publik tidak memiliki secret (invalid client type)")
+	}
+
+	clientSecret, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateClientSecret(id, s.tokenService.HashToken(clientSecret)); err != nil {
+		return nil, err
+	}
+
+	return &ClientCredentialsResponse{
+		Client:       toClientResponse(client),
+		ClientSecret: clientSecret,
+	}, nil
+}
+
+func (s *Service) DeleteClient(adminID, id int64) error {
+	if err := s.requireSuperAdmin(adminID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteClient(id)
+}
+
+func (s *Service) requireSuperAdmin(userID int64) error {
+	isAdmin, err := s.repo.IsSuperAdmin(userID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("access denied: only super admins can manage oauth clients")
+	}
+	return nil
+}
+
+// Authorization
+
+// Authorize validates an authorization request for the logged in user. When the user
+// already consented to the requested scopes the code is issued right away, otherwise
+// the login portal has to ask for consent and call DecideConsent.
+func (s *Service) Authorize(userID int64, req *AuthorizeRequest) (*AuthorizeResponse, error) {
+	client, scopes, err := s.validateAuthorizeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuthorizeResponse{
+		ClientID:        client.ClientID,
+		ClientName:      client.Name,
+		ApplicationCode: client.ApplicationCode,
+		ApplicationName: client.ApplicationName,
+		Scopes:          scopes,
+	}
+
+	consent, err := s.repo.GetConsent(userID, client.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.ConsentRequired = consent == nil || !containsAll(splitScope(consent.Scopes), scopes) || req.Prompt == "consent"
+
+	if result.ConsentRequired {
+		if req.Prompt == "none" {
+			result.RedirectTo = redirectWithParams(req.RedirectURI, url.Values{
+				"error": {"consent_required"},
+				"state": {req.State},
+			})
+		}
+		return result, nil
+	}
+
+	result.RedirectTo, err = s.issueAuthorizationCode(userID, client, req, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DecideConsent records the decision of the user and completes the authorization request
+func (s *Service) DecideConsent(userID int64, req *ConsentDecisionRequest) (*AuthorizeResponse, error) {
+	client, scopes, err := s.validateAuthorizeRequest(&req.AuthorizeRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuthorizeResponse{
+		ClientID:        client.ClientID,
+		ClientName:      client.Name,
+		ApplicationCode: client.ApplicationCode,
+		ApplicationName: client.ApplicationName,
+		Scopes:          scopes,
+	}
+
+	if !req.Approve {
+		result.RedirectTo = redirectWithParams(req.RedirectURI, url.Values{
+			"error":             {"access_denied"},
+			"error_description": {"user denied the request"},
+			"state":             {req.State},
+		})
+		return result, nil
+	}
+
+	if err := s.repo.SaveConsent(userID, client.ID, strings.Join(scopes, " ")); err != nil {
+		return nil, err
+	}
+
+	result.RedirectTo, err = s.issueAuthorizationCode(userID, client, &req.AuthorizeRequest, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validateAuthorizeRequest checks client, redirect URI, scopes and PKCE parameters
+func (s *Service) validateAuthorizeRequest(req *AuthorizeRequest) (*Client, []string, error) {
+	client, err := s.repo.GetClientByClientID(req.ClientID)
+	if err != nil || !client.IsActive {
+		return nil, nil, errors.New("client_id tidak valid (invalid client)")
+	}
+
+	// The redirect URI must match a registered one exactly, otherwise codes could leak
+	if !containsAll(client.RedirectURIs, []string{req.RedirectURI}) {
+		return nil, nil, errors.New("redirect_uri tidak terdaftar untuk client ini (invalid redirect_uri)")
+	}
+
+	if req.ResponseType != "code" {
+		return nil, nil, errors.New("hanya response_type=code yang didukung (invalid response_type)")
+	}
+
+	scopes := splitScope(req.Scope)
+	if !containsAll(scopes, []string{"openid"}) {
+		return nil, nil, errors.New("scope openid wajib diminta (invalid scope)")
+	}
+	for _, scope := range scopes {
+		if !containsAll(supportedScopes, []string{scope}) {
+			return nil, nil, errors.New("scope " + scope + " tidak didukung (invalid scope)")
+		}
+	}
+
+	if req.CodeChallengeMethod != "S256" {
+		return nil, nil, errors.New("code_challenge_method harus S256 (invalid code_challenge_method)")
+	}
+	if len(req.CodeChallenge) != 43 {
+		return nil, nil, errors.New("code_challenge harus berupa SHA-256 base64url (invalid code_challenge)")
+	}
+
+	return client, scopes, nil
+}
+
+// issueAuthorizationCode stores a single-use code and returns the client redirect carrying it
+func (s *Service) issueAuthorizationCode(userID int64, client *Client, req *AuthorizeRequest, scopes []string) (string, error) {
+	code, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	grant := token.AuthorizationCode{
+		UserID:              userID,
+		ClientID:            client.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               strings.Join(scopes, " "),
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		AuthTime:            time.Now().Unix(),
+	}
+
+	ttl := time.Duration(constants.OAuthAuthorizationCodeTTL) * time.Second
+	if err := s.tokenService.StoreAuthorizationCode(code, grant, ttl); err != nil {
+		return "", err
+	}
+
+	return redirectWithParams(req.RedirectURI, url.Values{
+		"code":  {code},
+		"state": {req.State},
+		"iss":   {s.issuer},
+	}), nil
+}
+
+// Token endpoint
+
+// Token handles the authorization_code and refresh_token grants
+func (s *Service) Token(req *TokenRequest, userAgent, ip string) (*TokenResponse, error) {
+	client, err := s.authenticateClient(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case "authorization_code":
+		return s.exchangeAuthorizationCode(client, req, userAgent, ip)
+	case "refresh_token":
+		return s.refreshAccessToken(client, req, userAgent, ip)
+	case "":
+		return nil, invalidRequest("grant_type wajib diisi")
+	default:
+		return nil, newError(http.StatusBadRequest, "unsupported_grant_type", "grant_type tidak didukung")
+	}
+}
+
+// authenticateClient checks the client credentials; public clients rely on PKCE alone
+func (s *Service) authenticateClient(req *TokenRequest) (*Client, error) {
+	if req.ClientID == "" {
+		return nil, invalidClient("client_id wajib diisi")
+	}
+
+	client, err := s.repo.GetClientByClientID(req.ClientID)
+	if err != nil || !client.IsActive {
+		return nil, invalidClient("client tidak dikenal")
+	}
+
+	if client.IsConfidential {
+		if req.ClientSecret == "" || client.ClientSecretHash == nil {
+			return nil, invalidClient("client_secret wajib diisi")
+		}
+		secretHash := s.tokenService.HashToken(req.ClientSecret)
+		if subtle.ConstantTimeCompare([]byte(secretHash), []byte(*client.ClientSecretHash)) != 1 {
+			return nil, invalidClient("client_secret tidak valid")
+		}
+	}
+
+	return client, nil
+}
+
+func (s *Service) exchangeAuthorizationCode(client *Client, req *TokenRequest, userAgent, ip string) (*TokenResponse, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, invalidRequest("code dan code_verifier wajib diisi")
+	}
+
+	grant, err := s.tokenService.ConsumeAuthorizationCode(req.Code)
+	if err != nil {
+		return nil, invalidGrant("authorization code tidak valid atau kedaluwarsa")
+	}
+
+	if grant.ClientID != client.ClientID || grant.RedirectURI != req.RedirectURI {
+		return nil, invalidGrant("authorization code tidak diterbitkan untuk client atau redirect_uri ini")
+	}
+	if !verifyCodeChallenge(req.CodeVerifier, grant.CodeChallenge) {
+		return nil, invalidGrant("code_verifier tidak cocok dengan code_challenge")
+	}
+
+	user, err := s.repo.GetUserByID(grant.UserID)
+	if err != nil || !user.IsActive {
+		return nil, invalidGrant("pengguna tidak ditemukan atau tidak aktif")
+	}
+
+	familyID, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	result, claims, err := s.issueTokens(client, user, grant.Scope, familyID[:32], userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := s.signIDToken(client, claims, grant.Scope, grant.Nonce, grant.AuthTime)
+	if err != nil {
+		return nil, err
+	}
+	result.IDToken = idToken
+
+	return result, nil
+}
+
+func (s *Service) refreshAccessToken(client *Client, req *TokenRequest, userAgent, ip string) (*TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, invalidRequest("refresh_token wajib diisi")
+	}
+
+	// Consuming rotates the token; a replayed token revokes the whole session
+	metadata, err := s.tokenService.ConsumeRefreshToken(req.RefreshToken)
+	if err != nil {
+		return nil, invalidGrant("refresh token tidak valid atau kedaluwarsa")
+	}
+	if metadata.ClientID != client.ClientID {
+		return nil, invalidGrant("refresh token tidak diterbitkan untuk client ini")
+	}
+
+	user, err := s.repo.GetUserByID(metadata.UserID)
+	if err != nil || !user.IsActive {
+		return nil, invalidGrant("pengguna tidak ditemukan atau tidak aktif")
+	}
+
+	result, _, err := s.issueTokens(client, user, metadata.Scope, metadata.FamilyID, userAgent, ip)
+	return result, err
+}
+
+// issueTokens creates the refresh and access token of an OAuth session. Abilities of the
+// access token are limited to the modules of the client application.
+func (s *Service) issueTokens(client *Client, user *User, scope, familyID, userAgent, ip string) (*TokenResponse, *UserInfoResponse, error) {
+	claims, err := s.userClaims(user, client, []string{"profile", "email", "roles"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshMetadata := token.RefreshTokenMetadata{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		UserAgent: userAgent,
+		IP:        ip,
+		ClientID:  client.ClientID,
+		Scope:     scope,
+	}
+	if err := s.tokenService.StoreRefreshToken(refreshToken, refreshMetadata, 7*24*time.Hour); err != nil {
+		return nil, nil, err
+	}
+
+	accessMetadata := token.TokenMetadata{
+		UserID:    user.ID,
+		CompanyID: claims.CompanyID,
+		UserAgent: userAgent,
+		IP:        ip,
+		Abilities: claims.Modules,
+		ExpiresAt: time.Now().Add(15 * time.Minute).Unix(),
+		FamilyID:  familyID,
+		ClientID:  client.ClientID,
+		Scope:     scope,
+	}
+	accessToken, err := s.tokenService.IssueAccessToken(accessMetadata, 15*time.Minute)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(15 * 60),
+		RefreshToken: refreshToken,
+		Scope:        scope,
+	}, claims, nil
+}
+
+// signIDToken creates the ID token; profile claims follow the granted scopes
+func (s *Service) signIDToken(client *Client, claims *UserInfoResponse, scope, nonce string, authTime int64) (string, error) {
+	now := time.Now()
+
+	idToken := IDTokenClaims{
+		Issuer:          s.issuer,
+		Subject:         claims.Subject,
+		Audience:        client.ClientID,
+		ExpiresAt:       now.Add(time.Duration(constants.OAuthIDTokenTTL) * time.Second).Unix(),
+		IssuedAt:        now.Unix(),
+		AuthTime:        authTime,
+		Nonce:           nonce,
+		AuthorizedParty: client.ClientID,
+	}
+
+	scopes := splitScope(scope)
+	if containsAll(scopes, []string{"profile"}) {
+		idToken.Name = claims.Name
+		idToken.PreferredUsername = claims.PreferredUsername
+	}
+	if containsAll(scopes, []string{"email"}) {
+		idToken.Email = claims.Email
+	}
+	if containsAll(scopes, []string{"roles"}) {
+		idToken.CompanyID = claims.CompanyID
+		idToken.Roles = claims.Roles
+		idToken.Modules = claims.Modules
+	}
+
+	return s.signingKeys.SignPayload(idToken)
+}
+
+// UserInfo returns the claims about the user allowed by the scope of the access token
+func (s *Service) UserInfo(userID int64, clientID, scope string) (*UserInfoResponse, error) {
+	scopes := splitScope(scope)
+	if clientID == "" || !containsAll(scopes, []string{"openid"}) {
+		return nil, newError(http.StatusForbidden, "insufficient_scope", "access token tidak memiliki scope openid")
+	}
+
+	client, err := s.repo.GetClientByClientID(clientID)
+	if err != nil || !client.IsActive {
+		return nil, invalidClient("client tidak dikenal")
+	}
+
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil || !user.IsActive {
+		return nil, newError(http.StatusUnauthorized, "invalid_token", "pengguna tidak ditemukan atau tidak aktif")
+	}
+
+	return s.userClaims(user, client, scopes)
+}
+
+// userClaims collects the claims about a user for the application of a client
+func (s *Service) userClaims(user *User, client *Client, scopes []string) (*UserInfoResponse, error) {
+	claims := &UserInfoResponse{Subject: strconv.FormatInt(user.ID, 10)}
+
+	if containsAll(scopes, []string{"profile"}) {
+		claims.Name = user.Name
+		if user.UserIdentity != nil {
+			claims.PreferredUsername = *user.UserIdentity
+		}
+	}
+	if containsAll(scopes, []string{"email"}) {
+		claims.Email = user.Email
+	}
+
+	if containsAll(scopes, []string{"roles"}) {
+		companyID, err := s.repo.GetUserCompanyID(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		roles, err := s.repo.GetUserApplicationRoles(user.ID, client.ApplicationID)
+		if err != nil {
+			return nil, err
+		}
+		modules, err := s.repo.GetUserApplicationModules(user.ID, client.ApplicationID, companyID)
+		if err != nil {
+			return nil, err
+		}
+
+		claims.CompanyID = companyID
+		claims.Roles = roles
+		claims.Modules = modules
+	}
+
+	return claims, nil
+}
+
+// Consents
+
+func (s *Service) GetConsents(userID int64) ([]*ConsentResponse, error) {
+	consents, err := s.repo.GetUserConsents(userID)
+	if err != nil {
+		return nil, err
+	}
+	if consents == nil {
+		consents = []*ConsentResponse{}
+	}
+	return consents, nil
+}
+
+// RevokeConsent withdraws the consent so the next authorization asks again
+func (s *Service) RevokeConsent(userID int64, clientID string) error {
+	client, err := s.repo.GetClientByClientID(clientID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteConsent(userID, client.ID)
+}
+
+// Discovery returns the OpenID Provider metadata
+func (s *Service) Discovery() *DiscoveryResponse {
+	return &DiscoveryResponse{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/api/v1/oauth/authorize",
+		TokenEndpoint:                     s.issuer + "/api/v1/oauth/token",
+		UserInfoEndpoint:                  s.issuer + "/api/v1/oauth/userinfo",
+		JWKSURI:                           s.issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  s.signingKeys.Algorithms(),
+		ScopesSupported:                   supportedScopes,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "azp",
+			"name", "preferred_username", "email", "company_id", "roles", "modules",
+		},
+	}
+}
+
+// Helpers
+
+func toClientResponse(client *Client) *ClientResponse {
+	return &ClientResponse{
+		ID:              client.ID,
+		ApplicationID:   client.ApplicationID,
+		ApplicationCode: client.ApplicationCode,
+		ApplicationName: client.ApplicationName,
+		ClientID:        client.ClientID,
+		Name:            client.Name,
+		RedirectURIs:    client.RedirectURIs,
+		IsConfidential:  client.IsConfidential,
+		IsActive:        client.IsActive,
+		CreatedAt:       client.CreatedAt,
+		UpdatedAt:       client.UpdatedAt,
+	}
+}
+
+// validateRedirectURIs allows absolute URIs without fragment; plain http only for localhost
+func validateRedirectURIs(redirectURIs []string) error {
+	for _, redirectURI := range redirectURIs {
+		parsed, err := url.Parse(redirectURI)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+			return errors.New("redirect_uri " + redirectURI + " tidak valid (invalid redirect_uri)")
+		}
+		if parsed.Scheme == "http" && parsed.Hostname() != "localhost" && parsed.Hostname() != "127.0.0.1" {
+			return errors.New("redirect_uri " + redirectURI + " harus menggunakan https (invalid redirect_uri)")
+		}
+	}
+	return nil
+}
+
+// verifyCodeChallenge checks a PKCE verifier against an S256 challenge (RFC 7636)
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	digest := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(digest[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+func redirectWithParams(redirectURI string, params url.Values) string {
+	if params.Get("state") == "" {
+		params.Del("state")
+	}
+
+	separator := "?"
+	if strings.Contains(redirectURI, "?") {
+		separator = "&"
+	}
+	return redirectURI + separator + params.Encode()
+}
+
+func splitScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// containsAll reports whether every value of subset is in set
+func containsAll(set, subset []string) bool {
+	for _, value := range subset {
+		found := false
+		for _, candidate := range set {
+			if candidate == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -1,11 +1,17 @@
 package role
 
-import "github.com/go-playground/validator/v10"
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
 
 // CreateRoleRequest DTO
 type CreateRoleRequest struct {
 	Name        string `json:"name" validate:"required,min=2,max=100"`
 	Description string `json:"description"`
+	// ParentRoleIDs are the roles whose module permissions the new role inherits
+	ParentRoleIDs []int64 `json:"parent_role_ids,omitempty" validate:"omitempty,max=20,dive,required"`
 }
 
 // UpdateRoleRequest DTO
@@ -22,33 +28,54 @@ type AssignRoleRequest struct {
 	CompanyID int64  `json:"company_id" validate:"required"`
 	BranchID  *int64 `json:"branch_id"`
 	UnitID    *int64 `json:"unit_id"`
+	// ValidFrom schedules the assignment, ValidUntil expires it (RFC 3339)
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	// Justification overrides a separation-of-duties violation when SOD_MODE=override
+	Justification string `json:"justification,omitempty" validate:"omitempty,max=1000"`
 }
 
 // BulkAssignRoleRequest DTO
 type BulkAssignRoleRequest struct {
-	UserIDs   []int64 `json:"user_ids" validate:"required,min=1"`
-	RoleID    int64   `json:"role_id" validate:"required"`
-	CompanyID int64   `json:"company_id" validate:"required"`
-	BranchID  *int64  `json:"branch_id"`
-	UnitID    *int64  `json:"unit_id"`
+	UserIDs       []int64    `json:"user_ids" validate:"required,min=1"`
+	RoleID        int64      `json:"role_id" validate:"required"`
+	CompanyID     int64      `json:"company_id" validate:"required"`
+	BranchID      *int64     `json:"branch_id"`
+	UnitID        *int64     `json:"unit_id"`
+	ValidFrom     *time.Time `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time `json:"valid_until,omitempty"`
+	Justification string     `json:"justification,omitempty" validate:"omitempty,max=1000"`
 }
 
 // RolePermissionRequest DTO
 type RolePermissionRequest struct {
-	ModuleID  int64 `json:"module_id" validate:"required"`
-	CanRead   bool  `json:"can_read"`
-	CanWrite  bool  `json:"can_write"`
-	CanDelete bool  `json:"can_delete"`
+	ModuleID   int64 `json:"module_id" validate:"required"`
+	CanRead    bool  `json:"can_read"`
+	CanWrite   bool  `json:"can_write"`
+	CanDelete  bool  `json:"can_delete"`
+	CanApprove bool  `json:"can_approve"`
+	// Actions grants further actions the module declares, e.g. export or post-journal
+	Actions []string `json:"actions,omitempty" validate:"omitempty,max=50"`
+	// Conditions maps a granted action to the expression that must hold
+	Conditions map[string]string `json:"conditions,omitempty"`
 }
 
 // UpdateRolePermissionsRequest DTO
 type UpdateRolePermissionsRequest struct {
-	Modules []RolePermissionRequest `json:"modules" validate:"required,dive"`
+	Modules       []RolePermissionRequest `json:"modules" validate:"required,dive"`
+	Justification string                  `json:"justification,omitempty" validate:"omitempty,max=1000"`
 }
 
 // AddRoleModulesRequest DTO - untuk menambahkan module ke role
 type AddRoleModulesRequest struct {
-	Modules []RolePermissionRequest `json:"modules" validate:"required,dive"`
+	Modules       []RolePermissionRequest `json:"modules" validate:"required,dive"`
+	Justification string                  `json:"justification,omitempty" validate:"omitempty,max=1000"`
+}
+
+// UpdateRoleParentsRequest DTO - mengganti semua parent role; kosong menghapus inheritance
+type UpdateRoleParentsRequest struct {
+	ParentRoleIDs []int64 `json:"parent_role_ids" validate:"max=20,dive,required"`
+	Justification string  `json:"justification,omitempty" validate:"omitempty,max=1000"`
 }
 
 // RemoveRoleModulesRequest DTO - untuk menghapus module dari role
@@ -77,17 +104,42 @@ type RoleResponse struct {
 // RoleWithPermissionsResponse DTO
 type RoleWithPermissionsResponse struct {
 	RoleResponse
+	Parents []RoleParentResponse           `json:"parents"`
 	Modules []RoleModulePermissionResponse `json:"modules"`
 }
 
-// RoleModulePermissionResponse DTO
+// RoleParentResponse DTO
+type RoleParentResponse struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// RoleModulePermissionResponse DTO - effective permissions of the role on a module, from
+// its own grant and the grants of the roles it inherits from
 type RoleModulePermissionResponse struct {
-	ModuleID   int64  `json:"module_id"`
-	ModuleName string `json:"module_name"`
-	ModuleURL  string `json:"module_url"`
-	CanRead    bool   `json:"can_read"`
-	CanWrite   bool   `json:"can_write"`
-	CanDelete  bool   `json:"can_delete"`
+	ModuleID   int64    `json:"module_id"`
+	ModuleName string   `json:"module_name"`
+	ModuleURL  string   `json:"module_url"`
+	CanRead    bool     `json:"can_read"`
+	CanWrite   bool     `json:"can_write"`
+	CanDelete  bool     `json:"can_delete"`
+	CanApprove bool     `json:"can_approve"`
+	Actions    []string `json:"actions"`
+	// Conditions are those of the role's own grant
+	Conditions map[string]string `json:"conditions,omitempty"`
+	// Inherited is true when the role has no grant of its own on the module
+	Inherited bool                           `json:"inherited"`
+	Sources   []RolePermissionSourceResponse `json:"sources"`
+}
+
+// RolePermissionSourceResponse DTO - one grant contributing to an effective permission
+type RolePermissionSourceResponse struct {
+	RoleID     int64             `json:"role_id"`
+	RoleName   string            `json:"role_name"`
+	Inherited  bool              `json:"inherited"`
+	Depth      int               `json:"depth"`
+	Actions    []string          `json:"actions"`
+	Conditions map[string]string `json:"conditions,omitempty"`
 }
 
 // UserRoleAssignmentResponse DTO
@@ -102,6 +154,8 @@ type UserRoleAssignmentResponse struct {
 	CompanyName string  `json:"company_name"`
 	BranchName  *string `json:"branch_name"`
 	UnitName    *string `json:"unit_name"`
+	ValidFrom   *string `json:"valid_from,omitempty"`
+	ValidUntil  *string `json:"valid_until,omitempty"`
 	CreatedAt   string  `json:"created_at"`
 }
 
@@ -146,6 +200,11 @@ func ValidateAddRoleModulesRequest(req *AddRoleModulesRequest) error {
 	return validate.Struct(req)
 }
 
+// ValidateUpdateRoleParentsRequest validates update role parents request
+func ValidateUpdateRoleParentsRequest(req *UpdateRoleParentsRequest) error {
+	return validate.Struct(req)
+}
+
 // ValidateRemoveRoleModulesRequest validates remove role modules request
 func ValidateRemoveRoleModulesRequest(req *RemoveRoleModulesRequest) error {
 	return validate.Struct(req)
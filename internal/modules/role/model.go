@@ -1,6 +1,10 @@
 package role
 
-import "time"
+import (
+	"time"
+
+	"gin-scalable-api/pkg/rbac"
+)
 
 type Role struct {
 	ID          int64     `json:"id" db:"id"`
@@ -12,14 +16,17 @@ type Role struct {
 }
 
 type UserRole struct {
-	ID        int64     `json:"id" db:"id"`
-	UserID    int64     `json:"user_id" db:"user_id"`
-	RoleID    int64     `json:"role_id" db:"role_id"`
-	CompanyID int64     `json:"company_id" db:"company_id"`
-	BranchID  *int64    `json:"branch_id" db:"branch_id"`
-	UnitID    *int64    `json:"unit_id" db:"unit_id"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        int64  `json:"id" db:"id"`
+	UserID    int64  `json:"user_id" db:"user_id"`
+	RoleID    int64  `json:"role_id" db:"role_id"`
+	CompanyID int64  `json:"company_id" db:"company_id"`
+	BranchID  *int64 `json:"branch_id" db:"branch_id"`
+	UnitID    *int64 `json:"unit_id" db:"unit_id"`
+	// ValidFrom and ValidUntil bound when the assignment applies; nil is unbounded
+	ValidFrom  *time.Time `json:"valid_from,omitempty" db:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until,omitempty" db:"valid_until"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 
 	RoleName    string `json:"role_name,omitempty" db:"-"`
 	CompanyName string `json:"company_name,omitempty" db:"-"`
@@ -28,14 +35,17 @@ type UserRole struct {
 }
 
 type RoleModule struct {
-	ID        int64     `json:"id" db:"id"`
-	RoleID    int64     `json:"role_id" db:"role_id"`
-	ModuleID  int64     `json:"module_id" db:"module_id"`
-	CanRead   bool      `json:"can_read" db:"can_read"`
-	CanWrite  bool      `json:"can_write" db:"can_write"`
-	CanDelete bool      `json:"can_delete" db:"can_delete"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID         int64           `json:"id" db:"id"`
+	RoleID     int64           `json:"role_id" db:"role_id"`
+	ModuleID   int64           `json:"module_id" db:"module_id"`
+	CanRead    bool            `json:"can_read" db:"can_read"`
+	CanWrite   bool            `json:"can_write" db:"can_write"`
+	CanDelete  bool            `json:"can_delete" db:"can_delete"`
+	CanApprove bool            `json:"can_approve" db:"can_approve"`
+	Actions    rbac.Actions    `json:"actions" db:"actions"`
+	Conditions rbac.Conditions `json:"conditions,omitempty" db:"conditions"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
 }
 
 func (Role) TableName() string {
@@ -50,20 +60,29 @@ func (RoleModule) TableName() string {
 	return "role_modules"
 }
 
-// RoleWithPermissions represents a role with its module permissions
+// RoleWithPermissions represents a role with its parents and the module permissions of
+// the role and its ancestors
 type RoleWithPermissions struct {
 	Role    Role
+	Parents []*Role
 	Modules []RoleModulePermission
 }
 
-// RoleModulePermission represents module permission details
+// RoleModulePermission represents module permission details of a grant made to the role
+// or, at Depth > 0, to one of its ancestors
 type RoleModulePermission struct {
-	ModuleID   int64
-	ModuleName string
-	ModuleURL  string
-	CanRead    bool
-	CanWrite   bool
-	CanDelete  bool
+	GrantRoleID   int64
+	GrantRoleName string
+	Depth         int
+	ModuleID      int64
+	ModuleName    string
+	ModuleURL     string
+	CanRead       bool
+	CanWrite      bool
+	CanDelete     bool
+	CanApprove    bool
+	Actions       rbac.Actions
+	Conditions    rbac.Conditions
 }
 
 // User model for role module - minimal fields needed
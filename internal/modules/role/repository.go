@@ -2,10 +2,12 @@ package role
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	// removed
 	"gin-scalable-api/pkg/model"
+	"gin-scalable-api/pkg/rbac"
 )
 
 type RoleRepository struct {
@@ -159,15 +161,18 @@ func (r *RoleRepository) CheckUserExists(userID int64) (bool, error) {
 	return exists, nil
 }
 
-// AssignUserRole assigns a role to a user
+// AssignUserRole assigns a role to a user. A scheduled assignment stays pending until
+// the assignment sweeper activates it at valid_from.
 func (r *RoleRepository) AssignUserRole(userRole *UserRole) error {
 	query := `
-		INSERT INTO user_roles (user_id, role_id, company_id, branch_id, unit_id, created_at)
-		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		INSERT INTO user_roles (user_id, role_id, company_id, branch_id, unit_id, valid_from, valid_until, activated_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6::timestamp, $7::timestamp,
+			CASE WHEN $6::timestamp > CURRENT_TIMESTAMP THEN NULL ELSE CURRENT_TIMESTAMP END, CURRENT_TIMESTAMP)
 		RETURNING id, created_at
 	`
 
-	err := r.db.QueryRow(query, userRole.UserID, userRole.RoleID, userRole.CompanyID, userRole.BranchID, userRole.UnitID).Scan(
+	err := r.db.QueryRow(query, userRole.UserID, userRole.RoleID, userRole.CompanyID, userRole.BranchID, userRole.UnitID,
+		userRole.ValidFrom, userRole.ValidUntil).Scan(
 		&userRole.ID, &userRole.CreatedAt,
 	)
 	if err != nil {
@@ -331,9 +336,10 @@ func (r *RoleRepository) UpdateRoleModules(roleID int64, modules []*RoleModule)
 	// Insert new role modules
 	for _, module := range modules {
 		_, err = tx.Exec(`
-			INSERT INTO role_modules (role_id, module_id, can_read, can_write, can_delete)
-			VALUES ($1, $2, $3, $4, $5)
-		`, roleID, module.ModuleID, module.CanRead, module.CanWrite, module.CanDelete)
+			INSERT INTO role_modules (role_id, module_id, can_read, can_write, can_delete, can_approve, actions, conditions)
+			VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8::jsonb)
+		`, roleID, module.ModuleID, module.CanRead, module.CanWrite, module.CanDelete, module.CanApprove,
+			module.Actions, module.Conditions)
 		if err != nil {
 			return fmt.Errorf("failed to insert role module: %w", err)
 		}
@@ -377,14 +383,22 @@ func (r *RoleRepository) GetWithPermissions(id int64) (*RoleWithPermissions, err
 		return nil, err
 	}
 
-	// Get role module permissions with module details
+	parents, err := r.GetParents(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get module permissions of the role and its ancestors with module details
 	query := `
-		SELECT rm.module_id, m.name as module_name, m.url as module_url,
-		       rm.can_read, rm.can_write, rm.can_delete
-		FROM role_modules rm
+		SELECT ra.ancestor_id, gr.name, ra.depth,
+		       rm.module_id, m.name as module_name, m.url as module_url,
+		       rm.can_read, rm.can_write, rm.can_delete, rm.can_approve, rm.actions, rm.conditions
+		FROM role_ancestors ra
+		JOIN roles gr ON gr.id = ra.ancestor_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
-		WHERE rm.role_id = $1
-		ORDER BY m.name
+		WHERE ra.role_id = $1
+		ORDER BY m.name, rm.module_id, ra.depth, gr.name
 	`
 
 	rows, err := r.db.Query(query, id)
@@ -397,8 +411,9 @@ func (r *RoleRepository) GetWithPermissions(id int64) (*RoleWithPermissions, err
 	for rows.Next() {
 		perm := RoleModulePermission{}
 		err := rows.Scan(
+			&perm.GrantRoleID, &perm.GrantRoleName, &perm.Depth,
 			&perm.ModuleID, &perm.ModuleName, &perm.ModuleURL,
-			&perm.CanRead, &perm.CanWrite, &perm.CanDelete,
+			&perm.CanRead, &perm.CanWrite, &perm.CanDelete, &perm.CanApprove, &perm.Actions, &perm.Conditions,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan role module permission: %w", err)
@@ -408,14 +423,115 @@ func (r *RoleRepository) GetWithPermissions(id int64) (*RoleWithPermissions, err
 
 	return &RoleWithPermissions{
 		Role:    *role,
+		Parents: parents,
 		Modules: modulePermissions,
 	}, nil
 }
 
+// GetParents retrieves the roles a role directly inherits from
+func (r *RoleRepository) GetParents(roleID int64) ([]*Role, error) {
+	query := `
+		SELECT r.id, r.name, r.description, r.is_active, r.created_at, r.updated_at
+		FROM role_parents rp
+		JOIN roles r ON rp.parent_role_id = r.id
+		WHERE rp.role_id = $1
+		ORDER BY r.name
+	`
+
+	rows, err := r.db.Query(query, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role parents: %w", err)
+	}
+	defer rows.Close()
+
+	parents := []*Role{}
+	for rows.Next() {
+		parent := &Role{}
+		if err := rows.Scan(&parent.ID, &parent.Name, &parent.Description, &parent.IsActive,
+			&parent.CreatedAt, &parent.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan role parent: %w", err)
+		}
+		parents = append(parents, parent)
+	}
+
+	return parents, rows.Err()
+}
+
+// ReplaceParents replaces the parents of a role. The role hierarchy is locked while the
+// new parents are checked against it, so concurrent changes cannot form a cycle.
+func (r *RoleRepository) ReplaceParents(roleID int64, parentIDs []int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("LOCK TABLE role_parents IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return fmt.Errorf("failed to lock role parents: %w", err)
+	}
+
+	for _, parentID := range parentIDs {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1)", parentID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check parent role: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("parent role %d not found", parentID)
+		}
+	}
+
+	graph, err := loadRoleGraph(tx)
+	if err != nil {
+		return err
+	}
+	if err := graph.ValidateParents(roleID, parentIDs); err != nil {
+		return fmt.Errorf("invalid role inheritance: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM role_parents WHERE role_id = $1", roleID); err != nil {
+		return fmt.Errorf("failed to delete role parents: %w", err)
+	}
+	for _, parentID := range parentIDs {
+		if _, err := tx.Exec(`
+			INSERT INTO role_parents (role_id, parent_role_id) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, roleID, parentID); err != nil {
+			return fmt.Errorf("failed to insert role parent: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// loadRoleGraph reads the whole role hierarchy
+func loadRoleGraph(tx *sql.Tx) (rbac.RoleGraph, error) {
+	rows, err := tx.Query("SELECT role_id, parent_role_id FROM role_parents")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role hierarchy: %w", err)
+	}
+	defer rows.Close()
+
+	graph := rbac.RoleGraph{}
+	for rows.Next() {
+		var roleID, parentID int64
+		if err := rows.Scan(&roleID, &parentID); err != nil {
+			return nil, fmt.Errorf("failed to scan role parent: %w", err)
+		}
+		graph[roleID] = append(graph[roleID], parentID)
+	}
+
+	return graph, rows.Err()
+}
+
 // GetRoleModules retrieves module permissions for a role
 func (r *RoleRepository) GetRoleModules(roleID int64) ([]*RoleModule, error) {
 	query := `
-		SELECT rm.id, rm.role_id, rm.module_id, rm.can_read, rm.can_write, rm.can_delete, rm.created_at
+		SELECT rm.id, rm.role_id, rm.module_id, rm.can_read, rm.can_write, rm.can_delete, rm.can_approve,
+		       rm.actions, rm.conditions, rm.created_at
 		FROM role_modules rm
 		WHERE rm.role_id = $1
 		ORDER BY rm.module_id
@@ -432,8 +548,8 @@ func (r *RoleRepository) GetRoleModules(roleID int64) ([]*RoleModule, error) {
 		roleModule := &RoleModule{}
 		err := rows.Scan(
 			&roleModule.ID, &roleModule.RoleID, &roleModule.ModuleID,
-			&roleModule.CanRead, &roleModule.CanWrite, &roleModule.CanDelete,
-			&roleModule.CreatedAt,
+			&roleModule.CanRead, &roleModule.CanWrite, &roleModule.CanDelete, &roleModule.CanApprove,
+			&roleModule.Actions, &roleModule.Conditions, &roleModule.CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan role module: %w", err)
@@ -493,18 +609,20 @@ func (r *RoleRepository) AddRoleModules(roleID int64, modules []*RoleModule) err
 			// Update existing permissions
 			_, err = tx.Exec(`
 				UPDATE role_modules 
-				SET can_read = $3, can_write = $4, can_delete = $5
+				SET can_read = $3, can_write = $4, can_delete = $5, can_approve = $6, actions = $7::jsonb, conditions = $8::jsonb
 				WHERE role_id = $1 AND module_id = $2
-			`, roleID, module.ModuleID, module.CanRead, module.CanWrite, module.CanDelete)
+			`, roleID, module.ModuleID, module.CanRead, module.CanWrite, module.CanDelete, module.CanApprove,
+				module.Actions, module.Conditions)
 			if err != nil {
 				return fmt.Errorf("failed to update existing role module: %w", err)
 			}
 		} else {
 			// Insert new module
 			_, err = tx.Exec(`
-				INSERT INTO role_modules (role_id, module_id, can_read, can_write, can_delete)
-				VALUES ($1, $2, $3, $4, $5)
-			`, roleID, module.ModuleID, module.CanRead, module.CanWrite, module.CanDelete)
+				INSERT INTO role_modules (role_id, module_id, can_read, can_write, can_delete, can_approve, actions, conditions)
+				VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8::jsonb)
+			`, roleID, module.ModuleID, module.CanRead, module.CanWrite, module.CanDelete, module.CanApprove,
+				module.Actions, module.Conditions)
 			if err != nil {
 				return fmt.Errorf("failed to insert new role module: %w", err)
 			}
@@ -553,3 +671,36 @@ func (r *RoleRepository) RemoveRoleModules(roleID int64, moduleIDs []int64) erro
 
 	return nil
 }
+
+// GetDeclaredActions returns, per module, the actions the modules declare beyond the standard ones
+func (r *RoleRepository) GetDeclaredActions(moduleIDs []int64) (map[int64][]string, error) {
+	declared := make(map[int64][]string)
+	if len(moduleIDs) == 0 {
+		return declared, nil
+	}
+
+	idList, err := json.Marshal(moduleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT module_id, action FROM module_actions
+		WHERE module_id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)
+	`, string(idList))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get declared module actions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var moduleID int64
+		var action string
+		if err := rows.Scan(&moduleID, &action); err != nil {
+			return nil, fmt.Errorf("failed to scan module action: %w", err)
+		}
+		declared[moduleID] = append(declared[moduleID], action)
+	}
+
+	return declared, rows.Err()
+}
@@ -81,7 +81,7 @@ func (h *Handler) GetRoleByID(c *gin.Context) {
 }
 
 // @Summary      Get role with permissions
-// @Description  Mendapatkan detail role dengan daftar permissions/modules
+// @Description  Mendapatkan detail role dengan parent role dan permissions efektif per module, termasuk sumbernya (langsung atau diwarisi)
 // @Tags         Roles
 // @Accept       json
 // @Produce      json
@@ -108,7 +108,7 @@ func (h *Handler) GetRoleWithPermissions(c *gin.Context) {
 }
 
 // @Summary      Create new role
-// @Description  Membuat role baru
+// @Description  Membuat role baru, opsional dengan parent role yang permissions-nya diwarisi
 // @Tags         Roles
 // @Accept       json
 // @Produce      json
@@ -173,7 +173,12 @@ func (h *Handler) UpdateRole(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.UpdateRole(id, updateReq)
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.UpdateRole(userID, id, updateReq)
 	if err != nil {
 		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
 		return
@@ -201,7 +206,12 @@ func (h *Handler) DeleteRole(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteRole(id); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRole(userID, id); err != nil {
 		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
 		return
 	}
@@ -210,7 +220,7 @@ func (h *Handler) DeleteRole(c *gin.Context) {
 }
 
 // @Summary      Assign role to user
-// @Description  Menugaskan role ke user dengan scope company, branch, atau unit
+// @Description  Menugaskan role ke user dengan scope company, branch, atau unit. valid_from/valid_until opsional membatasi masa berlaku assignment. Assignment yang melanggar aturan separation of duties ditolak, kecuali SOD_MODE=override dan justification diisi
 // @Tags         Role Management
 // @Accept       json
 // @Produce      json
@@ -218,6 +228,7 @@ func (h *Handler) DeleteRole(c *gin.Context) {
 // @Success      200         {object}  response.Response{data=role.UserRoleAssignmentResponse}  "Role berhasil ditugaskan"
 // @Failure      400         {object}  response.Response  "Bad request - validation failed"
 // @Failure      404         {object}  response.Response  "User atau role tidak ditemukan"
+// @Failure      422         {object}  response.Response  "Melanggar aturan separation of duties"
 // @Failure      500         {object}  response.Response  "Internal server error"
 // @Router       /api/v1/role-management/assign-user-role [post]
 // @Security     BearerAuth
@@ -234,7 +245,12 @@ func (h *Handler) AssignUserRole(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.AssignRoleToUser(assignReq)
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.AssignRoleToUser(userID, assignReq)
 	if err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to assign user role", err.Error())
 		return
@@ -244,7 +260,7 @@ func (h *Handler) AssignUserRole(c *gin.Context) {
 }
 
 // @Summary      Bulk assign roles to users
-// @Description  Menugaskan role ke multiple users sekaligus
+// @Description  Menugaskan role ke multiple users sekaligus, opsional dengan valid_from/valid_until. User yang akan melanggar aturan separation of duties dilewati
 // @Tags         Role Management
 // @Accept       json
 // @Produce      json
@@ -268,7 +284,12 @@ func (h *Handler) BulkAssignUserRole(c *gin.Context) {
 		return
 	}
 
-	results, err := h.service.BulkAssignRoleToUsers(bulkAssignReq)
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	results, err := h.service.BulkAssignRoleToUsers(userID, bulkAssignReq)
 	if err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to bulk assign user roles", err.Error())
 		return
@@ -281,7 +302,7 @@ func (h *Handler) BulkAssignUserRole(c *gin.Context) {
 }
 
 // @Summary      Update role permissions (replace all)
-// @Description  Mengganti semua permissions/modules dari role (replace operation)
+// @Description  Mengganti semua permissions/modules dari role (replace operation). Perubahan yang membuat pemegang role melanggar aturan separation of duties ditolak, kecuali SOD_MODE=override dan justification diisi
 // @Tags         Role Management
 // @Accept       json
 // @Produce      json
@@ -290,6 +311,7 @@ func (h *Handler) BulkAssignUserRole(c *gin.Context) {
 // @Success      200          {object}  response.Response  "Permissions berhasil diupdate"
 // @Failure      400          {object}  response.Response  "Bad request - Invalid role ID atau validation failed"
 // @Failure      404          {object}  response.Response  "Role tidak ditemukan"
+// @Failure      422          {object}  response.Response  "Melanggar aturan separation of duties"
 // @Failure      500          {object}  response.Response  "Internal server error"
 // @Router       /api/v1/role-management/role/{roleId}/modules [put]
 // @Security     BearerAuth
@@ -312,7 +334,12 @@ func (h *Handler) UpdateRoleModules(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateRolePermissions(roleID, updateReq); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.UpdateRolePermissions(userID, roleID, updateReq); err != nil {
 		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
 		return
 	}
@@ -320,6 +347,93 @@ func (h *Handler) UpdateRoleModules(c *gin.Context) {
 	response.Success(c, http.StatusOK, constants.MsgPermissionsUpdated, nil)
 }
 
+// @Summary      Simulate role permissions update
+// @Description  Dry-run dari update permissions role (replace all) tanpa menyimpan perubahan: mengembalikan setiap user yang effective permissions-nya berubah, termasuk lewat role turunan, dengan before/after per module serta ringkasan jumlah user yang mendapat atau kehilangan tiap action. Permissions dihitung dengan logika yang sama seperti pengecekan akses, termasuk filter subscription perusahaan
+// @Tags         Role Management
+// @Accept       json
+// @Produce      json
+// @Param        roleId       path      int                                  true  "Role ID"
+// @Param        permissions  body      role.UpdateRolePermissionsRequest    true  "Role permissions yang diusulkan"
+// @Success      200          {object}  response.Response{data=rbac.PermissionSimulation}  "Simulasi berhasil"
+// @Failure      400          {object}  response.Response  "Bad request - Invalid role ID atau validation failed"
+// @Failure      404          {object}  response.Response  "Role tidak ditemukan"
+// @Failure      500          {object}  response.Response  "Internal server error"
+// @Router       /api/v1/role-management/role/{roleId}/modules/simulate [post]
+// @Security     BearerAuth
+func (h *Handler) SimulateRoleModules(c *gin.Context) {
+	roleID, err := strconv.ParseInt(c.Param("roleId"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid role ID")
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	simulateReq, ok := validatedBody.(*UpdateRolePermissionsRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.SimulateRolePermissions(roleID, simulateReq)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgPermissionsSimulated, result)
+}
+
+// @Summary      Update role parents
+// @Description  Mengganti parent role; role mewarisi permissions module dari semua ancestor-nya. Hierarki yang membentuk siklus atau lebih dari 10 tingkat ditolak (invalid). Parent yang membuat pemegang role melanggar aturan separation of duties ditolak, kecuali SOD_MODE=override dan justification diisi
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                            true  "Role ID"
+// @Param        parents  body      role.UpdateRoleParentsRequest  true  "Parent role IDs"
+// @Success      200      {object}  response.Response  "Parent role berhasil diupdate"
+// @Failure      400      {object}  response.Response  "Bad request - Invalid role ID, siklus inheritance atau validation failed"
+// @Failure      404      {object}  response.Response  "Role atau parent role tidak ditemukan"
+// @Failure      422      {object}  response.Response  "Melanggar aturan separation of duties"
+// @Failure      500      {object}  response.Response  "Internal server error"
+// @Router       /api/v1/roles/{id}/parents [put]
+// @Security     BearerAuth
+func (h *Handler) UpdateRoleParents(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid role ID")
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	updateReq, ok := validatedBody.(*UpdateRoleParentsRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.UpdateRoleParents(userID, id, updateReq); err != nil {
+		response.ErrorWithAutoStatus(c, "Failed to update role parents", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgRoleParentsUpdated, nil)
+}
+
 // @Summary      Remove role from user
 // @Description  Menghapus role assignment dari user
 // @Tags         Role Management
@@ -359,7 +473,12 @@ func (h *Handler) RemoveUserRole(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.RemoveRoleFromUser(userID, roleID, companyID); err != nil {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveRoleFromUser(actorID, userID, roleID, companyID); err != nil {
 		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
 		return
 	}
@@ -456,6 +575,22 @@ func (h *Handler) GetUserAccessSummary(c *gin.Context) {
 	response.Success(c, http.StatusOK, "User access summary successfully retrieved", result)
 }
 
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
 // Route registration
 func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 	roleManagement := api.Group("/role-management")
@@ -484,6 +619,14 @@ func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 			handler.UpdateRoleModules,
 		)
 
+		// POST /api/v1/role-management/role/:roleId/modules/simulate - Preview a role permissions update
+		roleManagement.POST("/role/:roleId/modules/simulate",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &UpdateRolePermissionsRequest{},
+			}),
+			handler.SimulateRoleModules,
+		)
+
 		// POST /api/v1/role-management/role/:roleId/modules - Add modules to role (APPEND)
 		roleManagement.POST("/role/:roleId/modules",
 			middleware.ValidateRequest(middleware.ValidationRules{
@@ -540,13 +683,21 @@ func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 			handler.UpdateRole,
 		)
 
+		// PUT /api/v1/roles/:id/parents - Replace the roles a role inherits from
+		roles.PUT("/:id/parents",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &UpdateRoleParentsRequest{},
+			}),
+			handler.UpdateRoleParents,
+		)
+
 		// DELETE /api/v1/roles/:id - Delete role by ID
 		roles.DELETE("/:id", handler.DeleteRole)
 	}
 }
 
 // @Summary      Add modules to role
-// @Description  Menambahkan modules ke role (append operation)
+// @Description  Menambahkan modules ke role (append operation). Perubahan yang membuat pemegang role melanggar aturan separation of duties ditolak, kecuali SOD_MODE=override dan justification diisi
 // @Tags         Role Management
 // @Accept       json
 // @Produce      json
@@ -555,6 +706,7 @@ func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 // @Success      200      {object}  response.Response  "Modules berhasil ditambahkan"
 // @Failure      400      {object}  response.Response  "Bad request - Invalid role ID atau validation failed"
 // @Failure      404      {object}  response.Response  "Role tidak ditemukan"
+// @Failure      422      {object}  response.Response  "Melanggar aturan separation of duties"
 // @Failure      500      {object}  response.Response  "Internal server error"
 // @Router       /api/v1/role-management/role/{roleId}/modules [post]
 // @Security     BearerAuth
@@ -577,7 +729,12 @@ func (h *Handler) AddRoleModules(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.AddRoleModules(roleID, addReq); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.AddRoleModules(userID, roleID, addReq); err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to add modules to role", err.Error())
 		return
 	}
@@ -617,7 +774,12 @@ func (h *Handler) RemoveRoleModules(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.RemoveRoleModules(roleID, removeReq); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveRoleModules(userID, roleID, removeReq); err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to remove modules from role", err.Error())
 		return
 	}
@@ -2,17 +2,30 @@ package role
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"gin-scalable-api/pkg/audittrail"
+	"gin-scalable-api/pkg/rbac"
 )
 
 type Service struct {
-	roleRepo *RoleRepository
+	roleRepo        *RoleRepository
+	permissionCache *rbac.PermissionCache
+	sodGuard        *rbac.SoDGuard
+	simulator       *rbac.PermissionSimulator
+	auditRecorder   audittrail.Recorder
 }
 
-func NewService(roleRepo *RoleRepository) *Service {
+func NewService(roleRepo *RoleRepository, permissionCache *rbac.PermissionCache, sodGuard *rbac.SoDGuard, simulator *rbac.PermissionSimulator, auditRecorder audittrail.Recorder) *Service {
 	return &Service{
-		roleRepo: roleRepo,
+		roleRepo:        roleRepo,
+		permissionCache: permissionCache,
+		sodGuard:        sodGuard,
+		simulator:       simulator,
+		auditRecorder:   auditRecorder,
 	}
 }
 
@@ -67,17 +80,45 @@ func (s *Service) GetRoleWithPermissions(id int64) (*RoleWithPermissionsResponse
 
 	response := &RoleWithPermissionsResponse{
 		RoleResponse: *toRoleResponse(&roleWithPermissions.Role),
+		Parents:      []RoleParentResponse{},
 		Modules:      []RoleModulePermissionResponse{},
 	}
+	for _, parent := range roleWithPermissions.Parents {
+		response.Parents = append(response.Parents, RoleParentResponse{ID: parent.ID, Name: parent.Name})
+	}
 
+	// Grants arrive grouped by module, nearest role first; any grant of an action allows it
 	for _, module := range roleWithPermissions.Modules {
-		response.Modules = append(response.Modules, RoleModulePermissionResponse{
-			ModuleID:   module.ModuleID,
-			ModuleName: module.ModuleName,
-			ModuleURL:  module.ModuleURL,
-			CanRead:    module.CanRead,
-			CanWrite:   module.CanWrite,
-			CanDelete:  module.CanDelete,
+		last := len(response.Modules) - 1
+		if last < 0 || response.Modules[last].ModuleID != module.ModuleID {
+			response.Modules = append(response.Modules, RoleModulePermissionResponse{
+				ModuleID:   module.ModuleID,
+				ModuleName: module.ModuleName,
+				ModuleURL:  module.ModuleURL,
+				Actions:    []string{},
+				Inherited:  true,
+				Sources:    []RolePermissionSourceResponse{},
+			})
+			last++
+		}
+
+		effective := &response.Modules[last]
+		effective.Actions = rbac.GrantedActions(false, false, false, false, append(effective.Actions, module.Actions...))
+		effective.CanRead = effective.CanRead || module.CanRead
+		effective.CanWrite = effective.CanWrite || module.CanWrite
+		effective.CanDelete = effective.CanDelete || module.CanDelete
+		effective.CanApprove = effective.CanApprove || module.CanApprove
+		if module.Depth == 0 {
+			effective.Inherited = false
+			effective.Conditions = module.Conditions
+		}
+		effective.Sources = append(effective.Sources, RolePermissionSourceResponse{
+			RoleID:     module.GrantRoleID,
+			RoleName:   module.GrantRoleName,
+			Inherited:  module.Depth > 0,
+			Depth:      module.Depth,
+			Actions:    module.Actions,
+			Conditions: module.Conditions,
 		})
 	}
 
@@ -95,14 +136,57 @@ func (s *Service) CreateRole(req *CreateRoleRequest) (*RoleResponse, error) {
 		return nil, err
 	}
 
+	if len(req.ParentRoleIDs) > 0 {
+		if err := s.roleRepo.ReplaceParents(role.ID, req.ParentRoleIDs); err != nil {
+			// Do not leave a role behind without the inheritance it was requested with
+			if deleteErr := s.roleRepo.Delete(role.ID); deleteErr != nil {
+				return nil, fmt.Errorf("%v; %w", err, deleteErr)
+			}
+			return nil, err
+		}
+	}
+
 	return toRoleResponse(role), nil
 }
 
-func (s *Service) UpdateRole(id int64, req *UpdateRoleRequest) (*RoleResponse, error) {
+// UpdateRoleParents replaces the roles a role inherits module permissions from
+func (s *Service) UpdateRoleParents(actorID, roleID int64, req *UpdateRoleParentsRequest) error {
+	if _, err := s.roleRepo.GetByID(roleID); err != nil {
+		return err
+	}
+
+	current, err := s.roleRepo.GetParents(roleID)
+	if err != nil {
+		return err
+	}
+	currentIDs := make([]int64, 0, len(current))
+	for _, parent := range current {
+		currentIDs = append(currentIDs, parent.ID)
+	}
+
+	override, err := s.sodGuard.CheckRoleParents(rbac.SoDChange{ActorID: actorID, Action: "role_parents_updated",
+		Justification: req.Justification}, roleID, req.ParentRoleIDs)
+	if err != nil {
+		return err
+	}
+	if err := s.roleRepo.ReplaceParents(roleID, req.ParentRoleIDs); err != nil {
+		return err
+	}
+	s.sodGuard.Record(override)
+
+	audittrail.RecordChange(s.auditRecorder, actorID, "role_parents_updated", "role", roleID,
+		map[string]interface{}{"parent_role_ids": sortedIDs(currentIDs)},
+		map[string]interface{}{"parent_role_ids": sortedIDs(req.ParentRoleIDs)})
+
+	return s.permissionCache.InvalidateRole(roleID)
+}
+
+func (s *Service) UpdateRole(actorID, id int64, req *UpdateRoleRequest) (*RoleResponse, error) {
 	role, err := s.roleRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	before := roleSnapshot(role)
 
 	// Only update fields that are provided (not empty)
 	if req.Name != "" {
@@ -119,28 +203,190 @@ func (s *Service) UpdateRole(id int64, req *UpdateRoleRequest) (*RoleResponse, e
 		return nil, err
 	}
 
+	audittrail.RecordChange(s.auditRecorder, actorID, "role_updated", "role", id, before, roleSnapshot(role))
+
+	if err := s.permissionCache.InvalidateRole(id); err != nil {
+		return nil, err
+	}
+
 	return toRoleResponse(role), nil
 }
 
-func (s *Service) DeleteRole(id int64) error {
-	return s.roleRepo.Delete(id)
+// DeleteRole deletes a role on behalf of actorID
+func (s *Service) DeleteRole(actorID, id int64) error {
+	role, err := s.roleRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	modules, err := s.roleRepo.GetRoleModules(id)
+	if err != nil {
+		return err
+	}
+	before := roleSnapshot(role)
+	for key, value := range permissionSnapshot(modules) {
+		before[key] = value
+	}
+
+	// Resolve holders before the delete cascades their assignments away
+	userIDs, err := s.permissionCache.RoleUserIDs(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.Delete(id); err != nil {
+		return err
+	}
+
+	audittrail.RecordChange(s.auditRecorder, actorID, "role_deleted", "role", id, before, map[string]interface{}{})
+
+	return s.permissionCache.InvalidateUsers(userIDs...)
 }
 
-func (s *Service) UpdateRolePermissions(roleID int64, req *UpdateRolePermissionsRequest) error {
+func (s *Service) UpdateRolePermissions(actorID, roleID int64, req *UpdateRolePermissionsRequest) error {
+	current, err := s.roleRepo.GetRoleModules(roleID)
+	if err != nil {
+		return err
+	}
+
 	var modules []*RoleModule
 	for _, perm := range req.Modules {
-		modules = append(modules, &RoleModule{
-			RoleID:    roleID,
-			ModuleID:  perm.ModuleID,
-			CanRead:   perm.CanRead,
-			CanWrite:  perm.CanWrite,
-			CanDelete: perm.CanDelete,
-		})
+		modules = append(modules, newRoleModule(roleID, perm.ModuleID, perm.CanRead, perm.CanWrite, perm.CanDelete,
+			perm.CanApprove, perm.Actions, perm.Conditions))
+	}
+	if err := s.validateGrants(modules); err != nil {
+		return err
+	}
+	override, err := s.sodGuard.CheckRoleGrants(rbac.SoDChange{ActorID: actorID, Action: "role_permissions_updated",
+		Justification: req.Justification}, roleID, sodGrants(modules), true)
+	if err != nil {
+		return err
+	}
+	if err := s.roleRepo.UpdateRoleModules(roleID, modules); err != nil {
+		return err
+	}
+	s.sodGuard.Record(override)
+
+	audittrail.RecordChange(s.auditRecorder, actorID, "role_permissions_updated", "role", roleID,
+		permissionSnapshot(current), permissionSnapshot(modules))
+
+	return s.permissionCache.InvalidateRole(roleID)
+}
+
+// SimulateRolePermissions previews UpdateRolePermissions without saving it: every user
+// whose effective permissions would change, with the before and after per module
+func (s *Service) SimulateRolePermissions(roleID int64, req *UpdateRolePermissionsRequest) (*rbac.PermissionSimulation, error) {
+	if _, err := s.roleRepo.GetByID(roleID); err != nil {
+		return nil, err
+	}
+
+	var modules []*RoleModule
+	for _, perm := range req.Modules {
+		modules = append(modules, newRoleModule(roleID, perm.ModuleID, perm.CanRead, perm.CanWrite, perm.CanDelete,
+			perm.CanApprove, perm.Actions, perm.Conditions))
+	}
+	if err := s.validateGrants(modules); err != nil {
+		return nil, err
+	}
+
+	grants := make([]rbac.SimulatedGrant, 0, len(modules))
+	for _, module := range modules {
+		grants = append(grants, rbac.SimulatedGrant{ModuleID: module.ModuleID, Actions: module.Actions, Conditions: module.Conditions})
+	}
+	return s.simulator.SimulateRoleGrants(roleID, grants, true)
+}
+
+// roleSnapshot is the audited state of a role
+func roleSnapshot(role *Role) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        role.Name,
+		"description": role.Description,
+		"is_active":   role.IsActive,
+	}
+}
+
+// newRoleModule combines the standard flags and the further actions of a requested grant
+func newRoleModule(roleID, moduleID int64, canRead, canWrite, canDelete, canApprove bool, actions []string, conditions map[string]string) *RoleModule {
+	granted := rbac.GrantedActions(canRead, canWrite, canDelete, canApprove, actions)
+	return &RoleModule{
+		RoleID:     roleID,
+		ModuleID:   moduleID,
+		CanRead:    granted.Has("read"),
+		CanWrite:   granted.Has("write"),
+		CanDelete:  granted.Has("delete"),
+		CanApprove: granted.Has("approve"),
+		Actions:    granted,
+		Conditions: conditions,
+	}
+}
+
+// sodGrants converts grants of a role for the separation-of-duties check
+func sodGrants(modules []*RoleModule) []rbac.SoDGrant {
+	grants := make([]rbac.SoDGrant, 0, len(modules))
+	for _, module := range modules {
+		grants = append(grants, rbac.SoDGrant{RoleID: module.RoleID, ModuleID: module.ModuleID, Actions: module.Actions})
+	}
+	return grants
+}
+
+// validateGrants rejects actions the modules do not declare and invalid conditions
+func (s *Service) validateGrants(modules []*RoleModule) error {
+	moduleIDs := make([]int64, 0, len(modules))
+	for _, module := range modules {
+		moduleIDs = append(moduleIDs, module.ModuleID)
+	}
+	declared, err := s.roleRepo.GetDeclaredActions(moduleIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, module := range modules {
+		for _, action := range module.Actions {
+			if !rbac.IsStandardAction(action) && !contains(declared[module.ModuleID], action) {
+				return fmt.Errorf("action %q tidak dideklarasikan oleh module %d (invalid)", action, module.ModuleID)
+			}
+		}
+		if err := module.Conditions.Validate(); err != nil {
+			return fmt.Errorf("kondisi module %d tidak valid (invalid): %v", module.ModuleID, err)
+		}
+	}
+	return nil
+}
+
+// sortedIDs returns a sorted copy of the IDs, so audit diffs ignore their order
+func sortedIDs(ids []int64) []int64 {
+	sorted := append([]int64{}, ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionSnapshot keys the permission bits of a role by module ID, so audit diffs
+// name the module and bit that changed
+func permissionSnapshot(modules []*RoleModule) map[string]interface{} {
+	snapshot := make(map[string]interface{}, len(modules))
+	for _, module := range modules {
+		snapshot[strconv.FormatInt(module.ModuleID, 10)] = map[string]interface{}{
+			"can_read":    module.CanRead,
+			"can_write":   module.CanWrite,
+			"can_delete":  module.CanDelete,
+			"can_approve": module.CanApprove,
+			"actions":     module.Actions,
+			"conditions":  module.Conditions,
+		}
 	}
-	return s.roleRepo.UpdateRoleModules(roleID, modules)
+	return map[string]interface{}{"modules": snapshot}
 }
 
-func (s *Service) AssignRoleToUser(req *AssignRoleRequest) (*UserRoleAssignmentResponse, error) {
+// AssignRoleToUser assigns a role on behalf of actorID; 0 marks assignments made by the system
+func (s *Service) AssignRoleToUser(actorID int64, req *AssignRoleRequest) (*UserRoleAssignmentResponse, error) {
 	// Verify user exists (query via repository, no cross-module import)
 	userExists, err := s.roleRepo.CheckUserExists(req.UserID)
 	if err != nil || !userExists {
@@ -152,17 +398,36 @@ func (s *Service) AssignRoleToUser(req *AssignRoleRequest) (*UserRoleAssignmentR
 		return nil, fmt.Errorf("peran dengan ID %d tidak ditemukan", req.RoleID)
 	}
 
+	if err := validateValidity(req.ValidFrom, req.ValidUntil); err != nil {
+		return nil, err
+	}
+
+	override, err := s.sodGuard.CheckRoleAssignment(rbac.SoDChange{ActorID: actorID, Action: "user_role_assigned",
+		Justification: req.Justification}, req.UserID, req.CompanyID, req.RoleID, req.UnitID)
+	if err != nil {
+		return nil, err
+	}
+
 	userRole := &UserRole{
-		UserID:    req.UserID,
-		RoleID:    req.RoleID,
-		CompanyID: req.CompanyID,
-		BranchID:  req.BranchID,
-		UnitID:    req.UnitID,
+		UserID:     req.UserID,
+		RoleID:     req.RoleID,
+		CompanyID:  req.CompanyID,
+		BranchID:   req.BranchID,
+		UnitID:     req.UnitID,
+		ValidFrom:  req.ValidFrom,
+		ValidUntil: req.ValidUntil,
 	}
 
 	if err := s.roleRepo.AssignUserRole(userRole); err != nil {
 		return nil, err
 	}
+	s.sodGuard.Record(override)
+
+	if err := s.permissionCache.InvalidateUser(req.UserID); err != nil {
+		return nil, err
+	}
+
+	s.recordAssignment(actorID, userRole, role)
 
 	return &UserRoleAssignmentResponse{
 		ID:          userRole.ID,
@@ -175,17 +440,23 @@ func (s *Service) AssignRoleToUser(req *AssignRoleRequest) (*UserRoleAssignmentR
 		CompanyName: "",
 		BranchName:  nil,
 		UnitName:    nil,
+		ValidFrom:   formatOptionalTime(userRole.ValidFrom),
+		ValidUntil:  formatOptionalTime(userRole.ValidUntil),
 		CreatedAt:   userRole.CreatedAt.Format(time.RFC3339),
 	}, nil
 }
 
-func (s *Service) BulkAssignRoleToUsers(req *BulkAssignRoleRequest) ([]UserRoleAssignmentResponse, error) {
+func (s *Service) BulkAssignRoleToUsers(actorID int64, req *BulkAssignRoleRequest) ([]UserRoleAssignmentResponse, error) {
 	// Verify role exists
 	role, err := s.roleRepo.GetByID(req.RoleID)
 	if err != nil {
 		return nil, fmt.Errorf("peran dengan ID %d tidak ditemukan", req.RoleID)
 	}
 
+	if err := validateValidity(req.ValidFrom, req.ValidUntil); err != nil {
+		return nil, err
+	}
+
 	var results []UserRoleAssignmentResponse
 	var errors []string
 
@@ -197,18 +468,29 @@ func (s *Service) BulkAssignRoleToUsers(req *BulkAssignRoleRequest) ([]UserRoleA
 			continue
 		}
 
+		override, err := s.sodGuard.CheckRoleAssignment(rbac.SoDChange{ActorID: actorID, Action: "user_role_assigned",
+			Justification: req.Justification}, userID, req.CompanyID, req.RoleID, req.UnitID)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+
 		userRole := &UserRole{
-			UserID:    userID,
-			RoleID:    req.RoleID,
-			CompanyID: req.CompanyID,
-			BranchID:  req.BranchID,
-			UnitID:    req.UnitID,
+			UserID:     userID,
+			RoleID:     req.RoleID,
+			CompanyID:  req.CompanyID,
+			BranchID:   req.BranchID,
+			UnitID:     req.UnitID,
+			ValidFrom:  req.ValidFrom,
+			ValidUntil: req.ValidUntil,
 		}
 
 		if err := s.roleRepo.AssignUserRole(userRole); err != nil {
 			errors = append(errors, fmt.Sprintf("gagal assign role untuk user ID %d: %v", userID, err))
 			continue
 		}
+		s.sodGuard.Record(override)
+		s.recordAssignment(actorID, userRole, role)
 
 		results = append(results, UserRoleAssignmentResponse{
 			ID:          userRole.ID,
@@ -221,6 +503,8 @@ func (s *Service) BulkAssignRoleToUsers(req *BulkAssignRoleRequest) ([]UserRoleA
 			CompanyName: "",
 			BranchName:  nil,
 			UnitName:    nil,
+			ValidFrom:   formatOptionalTime(userRole.ValidFrom),
+			ValidUntil:  formatOptionalTime(userRole.ValidUntil),
 			CreatedAt:   userRole.CreatedAt.Format(time.RFC3339),
 		})
 	}
@@ -229,11 +513,79 @@ func (s *Service) BulkAssignRoleToUsers(req *BulkAssignRoleRequest) ([]UserRoleA
 		return nil, fmt.Errorf("semua assignment gagal: %s", strings.Join(errors, "; "))
 	}
 
+	assignedUserIDs := make([]int64, 0, len(results))
+	for _, result := range results {
+		assignedUserIDs = append(assignedUserIDs, result.UserID)
+	}
+	if err := s.permissionCache.InvalidateUsers(assignedUserIDs...); err != nil {
+		return nil, err
+	}
+
 	return results, nil
 }
 
-func (s *Service) RemoveRoleFromUser(userID, roleID, companyID int64) error {
-	return s.roleRepo.RemoveUserRole(userID, roleID, companyID)
+// recordAssignment audits a role assignment with its target, so self-assignments can be detected
+func (s *Service) recordAssignment(actorID int64, userRole *UserRole, role *Role) {
+	audittrail.RecordChange(s.auditRecorder, actorID, "user_role_assigned", "user_role", userRole.ID,
+		map[string]interface{}{}, map[string]interface{}{
+			"user_id":     userRole.UserID,
+			"role_id":     role.ID,
+			"role_name":   role.Name,
+			"company_id":  userRole.CompanyID,
+			"branch_id":   userRole.BranchID,
+			"unit_id":     userRole.UnitID,
+			"valid_from":  userRole.ValidFrom,
+			"valid_until": userRole.ValidUntil,
+		})
+}
+
+// validateValidity checks the window of a time-bound assignment
+func validateValidity(validFrom, validUntil *time.Time) error {
+	if validUntil == nil {
+		return nil
+	}
+	if !validUntil.After(time.Now()) {
+		return fmt.Errorf("valid_until harus di masa depan (invalid)")
+	}
+	if validFrom != nil && !validUntil.After(*validFrom) {
+		return fmt.Errorf("valid_until harus setelah valid_from (invalid)")
+	}
+	return nil
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
+
+// RemoveRoleFromUser removes a role of a user in a company on behalf of actorID
+func (s *Service) RemoveRoleFromUser(actorID, userID, roleID, companyID int64) error {
+	assignments, err := s.roleRepo.GetUserRoles(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.RemoveUserRole(userID, roleID, companyID); err != nil {
+		return err
+	}
+
+	for _, userRole := range assignments {
+		if userRole.RoleID != roleID || userRole.CompanyID != companyID {
+			continue
+		}
+		audittrail.RecordChange(s.auditRecorder, actorID, "user_role_removed", "user_role", userRole.ID,
+			map[string]interface{}{
+				"user_id":    userRole.UserID,
+				"role_id":    userRole.RoleID,
+				"company_id": userRole.CompanyID,
+				"branch_id":  userRole.BranchID,
+			}, map[string]interface{}{})
+	}
+
+	return s.permissionCache.InvalidateUser(userID)
 }
 
 func (s *Service) GetUsersByRole(roleID int64, limit int) (interface{}, error) {
@@ -331,20 +683,60 @@ func toRoleResponse(role *Role) *RoleResponse {
 		UpdatedAt:   role.UpdatedAt.Format(time.RFC3339),
 	}
 }
-func (s *Service) AddRoleModules(roleID int64, req *AddRoleModulesRequest) error {
+func (s *Service) AddRoleModules(actorID, roleID int64, req *AddRoleModulesRequest) error {
 	var modules []*RoleModule
 	for _, perm := range req.Modules {
-		modules = append(modules, &RoleModule{
-			RoleID:    roleID,
-			ModuleID:  perm.ModuleID,
-			CanRead:   perm.CanRead,
-			CanWrite:  perm.CanWrite,
-			CanDelete: perm.CanDelete,
-		})
+		modules = append(modules, newRoleModule(roleID, perm.ModuleID, perm.CanRead, perm.CanWrite, perm.CanDelete,
+			perm.CanApprove, perm.Actions, perm.Conditions))
+	}
+	if err := s.validateGrants(modules); err != nil {
+		return err
 	}
-	return s.roleRepo.AddRoleModules(roleID, modules)
+	override, err := s.sodGuard.CheckRoleGrants(rbac.SoDChange{ActorID: actorID, Action: "role_modules_added",
+		Justification: req.Justification}, roleID, sodGrants(modules), false)
+	if err != nil {
+		return err
+	}
+	current, err := s.roleRepo.GetRoleModules(roleID)
+	if err != nil {
+		return err
+	}
+	if err := s.roleRepo.AddRoleModules(roleID, modules); err != nil {
+		return err
+	}
+	s.sodGuard.Record(override)
+
+	if err := s.recordRoleModulesChange(actorID, "role_modules_added", roleID, current); err != nil {
+		return err
+	}
+
+	return s.permissionCache.InvalidateRole(roleID)
 }
 
-func (s *Service) RemoveRoleModules(roleID int64, req *RemoveRoleModulesRequest) error {
-	return s.roleRepo.RemoveRoleModules(roleID, req.ModuleIDs)
+// RemoveRoleModules revokes the grants of a role on the given modules on behalf of actorID
+func (s *Service) RemoveRoleModules(actorID, roleID int64, req *RemoveRoleModulesRequest) error {
+	current, err := s.roleRepo.GetRoleModules(roleID)
+	if err != nil {
+		return err
+	}
+	if err := s.roleRepo.RemoveRoleModules(roleID, req.ModuleIDs); err != nil {
+		return err
+	}
+
+	if err := s.recordRoleModulesChange(actorID, "role_modules_removed", roleID, current); err != nil {
+		return err
+	}
+
+	return s.permissionCache.InvalidateRole(roleID)
+}
+
+// recordRoleModulesChange records the grants of a role before and after a change
+func (s *Service) recordRoleModulesChange(actorID int64, action string, roleID int64, before []*RoleModule) error {
+	after, err := s.roleRepo.GetRoleModules(roleID)
+	if err != nil {
+		return err
+	}
+
+	audittrail.RecordChange(s.auditRecorder, actorID, action, "role", roleID, permissionSnapshot(before), permissionSnapshot(after))
+	return nil
 }
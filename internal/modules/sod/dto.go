@@ -0,0 +1,98 @@
+package sod
+
+// CreateRuleRequest defines a rule. Role pair rules need role_a_id and role_b_id; action
+// pair rules need action_a and action_b and optionally a module. Without company_id the
+// rule applies to every company.
+type CreateRuleRequest struct {
+	Name        string `json:"name" validate:"required,min=3,max=150"`
+	Description string `json:"description" validate:"max=1000"`
+	Kind        string `json:"kind" validate:"required,oneof=role_pair action_pair"`
+	RoleAID     *int64 `json:"role_a_id" validate:"omitempty,min=1"`
+	RoleBID     *int64 `json:"role_b_id" validate:"omitempty,min=1"`
+	ModuleID    *int64 `json:"module_id" validate:"omitempty,min=1"`
+	ActionA     string `json:"action_a" validate:"max=50"`
+	ActionB     string `json:"action_b" validate:"max=50"`
+	CompanyID   *int64 `json:"company_id" validate:"omitempty,min=1"`
+}
+
+// UpdateRuleRequest renames, describes or (de)activates a rule. The pair of a rule cannot
+// change; define a new rule instead.
+type UpdateRuleRequest struct {
+	Name        *string `json:"name" validate:"omitempty,min=3,max=150"`
+	Description *string `json:"description" validate:"omitempty,max=1000"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// RuleListRequest filters rules. With company_id the rules applying to the company are
+// listed, its own and the global ones.
+type RuleListRequest struct {
+	CompanyID int64  `form:"company_id"`
+	Kind      string `form:"kind"`
+	Limit     int    `form:"limit"`
+	Offset    int    `form:"offset"`
+}
+
+// ViolationReportRequest narrows the violation report to a company
+type ViolationReportRequest struct {
+	CompanyID int64 `form:"company_id"`
+}
+
+type RuleResponse struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Kind        string  `json:"kind"`
+	RoleAID     *int64  `json:"role_a_id,omitempty"`
+	RoleAName   *string `json:"role_a_name,omitempty"`
+	RoleBID     *int64  `json:"role_b_id,omitempty"`
+	RoleBName   *string `json:"role_b_name,omitempty"`
+	ModuleID    *int64  `json:"module_id,omitempty"`
+	ModuleName  *string `json:"module_name,omitempty"`
+	ActionA     *string `json:"action_a,omitempty"`
+	ActionB     *string `json:"action_b,omitempty"`
+	CompanyID   *int64  `json:"company_id"`
+	CompanyName *string `json:"company_name"`
+	IsActive    bool    `json:"is_active"`
+	CreatedBy   *int64  `json:"created_by"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+type RuleListResponse struct {
+	Data    []*RuleResponse `json:"data"`
+	Total   int64           `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+	HasMore bool            `json:"has_more"`
+}
+
+// OverrideResponse is the latest justification recorded for a violation
+type OverrideResponse struct {
+	Change        string `json:"change"`
+	Justification string `json:"justification"`
+	GrantedBy     *int64 `json:"granted_by"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type ViolationResponse struct {
+	RuleID      int64             `json:"rule_id"`
+	RuleName    string            `json:"rule_name"`
+	Kind        string            `json:"kind"`
+	UserID      int64             `json:"user_id"`
+	UserName    string            `json:"user_name"`
+	UserEmail   string            `json:"user_email"`
+	CompanyID   int64             `json:"company_id"`
+	CompanyName string            `json:"company_name"`
+	ModuleID    *int64            `json:"module_id,omitempty"`
+	ModuleName  *string           `json:"module_name,omitempty"`
+	Detail      string            `json:"detail"`
+	Override    *OverrideResponse `json:"override"`
+}
+
+// ViolationReportResponse lists the violations of the active rules held right now
+type ViolationReportResponse struct {
+	Violations  []*ViolationResponse `json:"violations"`
+	Total       int                  `json:"total"`
+	Overridden  int                  `json:"overridden"`
+	GeneratedAt string               `json:"generated_at"`
+}
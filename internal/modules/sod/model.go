@@ -0,0 +1,62 @@
+package sod
+
+import (
+	"time"
+)
+
+// Rule kinds, matching rbac.SoDRolePair and rbac.SoDActionPair
+const (
+	KindRolePair   = "role_pair"
+	KindActionPair = "action_pair"
+)
+
+// Rule is a separation-of-duties rule: a pair of roles, or a pair of actions on a module
+// (any module when ModuleID is nil), that one user may not hold together. Rules without
+// a company apply to every company.
+type Rule struct {
+	ID          int64     `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description" db:"description"`
+	Kind        string    `json:"kind" db:"kind"`
+	RoleAID     *int64    `json:"role_a_id" db:"role_a_id"`
+	RoleAName   *string   `json:"role_a_name" db:"-"`
+	RoleBID     *int64    `json:"role_b_id" db:"role_b_id"`
+	RoleBName   *string   `json:"role_b_name" db:"-"`
+	ModuleID    *int64    `json:"module_id" db:"module_id"`
+	ModuleName  *string   `json:"module_name" db:"-"`
+	ActionA     *string   `json:"action_a" db:"action_a"`
+	ActionB     *string   `json:"action_b" db:"action_b"`
+	CompanyID   *int64    `json:"company_id" db:"company_id"`
+	CompanyName *string   `json:"company_name" db:"-"`
+	IsActive    bool      `json:"is_active" db:"is_active"`
+	CreatedBy   *int64    `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (Rule) TableName() string {
+	return "sod_rules"
+}
+
+// Override is a violation allowed in override mode with a justification
+type Override struct {
+	ID            int64     `json:"id" db:"id"`
+	RuleID        int64     `json:"rule_id" db:"rule_id"`
+	UserID        int64     `json:"user_id" db:"user_id"`
+	CompanyID     int64     `json:"company_id" db:"company_id"`
+	ModuleID      *int64    `json:"module_id" db:"module_id"`
+	Change        string    `json:"change" db:"change"`
+	Justification string    `json:"justification" db:"justification"`
+	GrantedBy     *int64    `json:"granted_by" db:"granted_by"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+func (Override) TableName() string {
+	return "sod_overrides"
+}
+
+// UserInfo names a user in the violation report
+type UserInfo struct {
+	Name  string
+	Email string
+}
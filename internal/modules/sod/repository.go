@@ -0,0 +1,325 @@
+package sod
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const ruleSelect = `
+	SELECT sr.id, sr.name, sr.description, sr.kind, sr.role_a_id, ra.name, sr.role_b_id, rb.name,
+		sr.module_id, m.name, sr.action_a, sr.action_b, sr.company_id, c.name, sr.is_active,
+		sr.created_by, sr.created_at, sr.updated_at
+	FROM sod_rules sr
+	LEFT JOIN roles ra ON sr.role_a_id = ra.id
+	LEFT JOIN roles rb ON sr.role_b_id = rb.id
+	LEFT JOIN modules m ON sr.module_id = m.id
+	LEFT JOIN companies c ON sr.company_id = c.id
+`
+
+func scanRule(scanner interface{ Scan(...interface{}) error }) (*Rule, error) {
+	rule := &Rule{}
+	err := scanner.Scan(
+		&rule.ID, &rule.Name, &rule.Description, &rule.Kind, &rule.RoleAID, &rule.RoleAName,
+		&rule.RoleBID, &rule.RoleBName, &rule.ModuleID, &rule.ModuleName, &rule.ActionA, &rule.ActionB,
+		&rule.CompanyID, &rule.CompanyName, &rule.IsActive, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// IsSuperAdmin checks whether a user holds the SUPER_ADMIN role
+func (r *Repository) IsSuperAdmin(userID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true AND r.name = 'SUPER_ADMIN'
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check super admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// IsCompanyAdmin checks whether a user may manage the rules of a company
+func (r *Repository) IsCompanyAdmin(userID, companyID int64) (bool, error) {
+	var isAdmin bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM active_user_roles ur
+			JOIN roles r ON ur.role_id = r.id
+			WHERE ur.user_id = $1 AND r.is_active = true
+				AND (r.name = 'SUPER_ADMIN' OR (r.name = 'COMPANY_ADMIN' AND ur.company_id = $2))
+		)
+	`
+
+	if err := r.db.QueryRow(query, userID, companyID).Scan(&isAdmin); err != nil {
+		return false, fmt.Errorf("failed to check company admin: %w", err)
+	}
+
+	return isAdmin, nil
+}
+
+// CompanyExists checks whether an active company exists
+func (r *Repository) CompanyExists(companyID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM companies WHERE id = $1 AND is_active = true)`
+	if err := r.db.QueryRow(query, companyID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check company: %w", err)
+	}
+	return exists, nil
+}
+
+// RoleExists checks whether a role exists
+func (r *Repository) RoleExists(roleID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1)`
+	if err := r.db.QueryRow(query, roleID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check role: %w", err)
+	}
+	return exists, nil
+}
+
+// ModuleExists checks whether a module exists
+func (r *Repository) ModuleExists(moduleID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM modules WHERE id = $1)`
+	if err := r.db.QueryRow(query, moduleID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check module: %w", err)
+	}
+	return exists, nil
+}
+
+// IsDeclaredAction checks whether a module declares a further action
+func (r *Repository) IsDeclaredAction(moduleID int64, action string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM module_actions WHERE module_id = $1 AND action = $2)`
+	if err := r.db.QueryRow(query, moduleID, action).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check module action: %w", err)
+	}
+	return exists, nil
+}
+
+// Create inserts a rule
+func (r *Repository) Create(rule *Rule) error {
+	query := `
+		INSERT INTO sod_rules (name, description, kind, role_a_id, role_b_id, module_id, action_a, action_b,
+			company_id, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.db.QueryRow(query, rule.Name, rule.Description, rule.Kind, rule.RoleAID, rule.RoleBID, rule.ModuleID,
+		rule.ActionA, rule.ActionB, rule.CompanyID, rule.IsActive, rule.CreatedBy,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create SoD rule: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetByID(id int64) (*Rule, error) {
+	rule, err := scanRule(r.db.QueryRow(ruleSelect+" WHERE sr.id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("aturan SoD tidak ditemukan (SoD rule not found)")
+		}
+		return nil, fmt.Errorf("failed to get SoD rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ruleConditions translates the rule list filters into SQL. A company filter keeps the
+// rules of the company and the global rules.
+func ruleConditions(companyID int64, kind string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if companyID > 0 {
+		args = append(args, companyID)
+		conditions = append(conditions, fmt.Sprintf("(sr.company_id IS NULL OR sr.company_id = $%d)", len(args)))
+	}
+	if kind == KindRolePair || kind == KindActionPair {
+		conditions = append(conditions, "sr.kind = '"+kind+"'")
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// List retrieves rules, oldest first
+func (r *Repository) List(companyID int64, kind string, limit, offset int) ([]*Rule, error) {
+	where, args := ruleConditions(companyID, kind)
+	args = append(args, limit, offset)
+	query := ruleSelect + where + fmt.Sprintf(" ORDER BY sr.id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SoD rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan SoD rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// Count counts the rules matching the list filters
+func (r *Repository) Count(companyID int64, kind string) (int64, error) {
+	where, args := ruleConditions(companyID, kind)
+
+	var count int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM sod_rules sr"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count SoD rules: %w", err)
+	}
+	return count, nil
+}
+
+// Update saves the name, description and status of a rule
+func (r *Repository) Update(rule *Rule) error {
+	query := `
+		UPDATE sod_rules
+		SET name = $2, description = $3, is_active = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	err := r.db.QueryRow(query, rule.ID, rule.Name, rule.Description, rule.IsActive).Scan(&rule.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("aturan SoD tidak ditemukan (SoD rule not found)")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update SoD rule: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a rule together with its recorded overrides
+func (r *Repository) Delete(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM sod_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete SoD rule: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("aturan SoD tidak ditemukan (SoD rule not found)")
+	}
+	return nil
+}
+
+// LatestOverrides returns the latest override of each rule, user, company and module
+func (r *Repository) LatestOverrides() ([]*Override, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT ON (rule_id, user_id, company_id, COALESCE(module_id, 0))
+			id, rule_id, user_id, company_id, module_id, change, justification, granted_by, created_at
+		FROM sod_overrides
+		ORDER BY rule_id, user_id, company_id, COALESCE(module_id, 0), created_at DESC, id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SoD overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []*Override
+	for rows.Next() {
+		override := &Override{}
+		if err := rows.Scan(&override.ID, &override.RuleID, &override.UserID, &override.CompanyID, &override.ModuleID,
+			&override.Change, &override.Justification, &override.GrantedBy, &override.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan SoD override: %w", err)
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, rows.Err()
+}
+
+// UserInfo returns the names and emails of users
+func (r *Repository) UserInfo(userIDs []int64) (map[int64]UserInfo, error) {
+	users := make(map[int64]UserInfo)
+	rows, err := r.queryByIDs(`SELECT id, name, email FROM users WHERE id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)`, userIDs)
+	if err != nil || rows == nil {
+		return users, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var info UserInfo
+		if err := rows.Scan(&id, &info.Name, &info.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users[id] = info
+	}
+	return users, rows.Err()
+}
+
+// CompanyNames returns the names of companies
+func (r *Repository) CompanyNames(companyIDs []int64) (map[int64]string, error) {
+	return r.names(`SELECT id, name FROM companies WHERE id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)`, companyIDs)
+}
+
+// ModuleNames returns the names of modules
+func (r *Repository) ModuleNames(moduleIDs []int64) (map[int64]string, error) {
+	return r.names(`SELECT id, name FROM modules WHERE id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)`, moduleIDs)
+}
+
+func (r *Repository) names(query string, ids []int64) (map[int64]string, error) {
+	names := make(map[int64]string)
+	rows, err := r.queryByIDs(query, ids)
+	if err != nil || rows == nil {
+		return names, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan name: %w", err)
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+func (r *Repository) queryByIDs(query string, ids []int64) (*sql.Rows, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	idList, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(query, string(idList))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up names: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,283 @@
+package sod
+
+import (
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/middleware"
+	"gin-scalable-api/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler struct
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Handler methods
+
+// @Summary      Buat aturan SoD
+// @Description  Membuat aturan separation of duties: role_pair (dua peran yang tidak boleh dipegang satu pengguna) atau action_pair (dua action yang tidak boleh dipegang pada module yang sama; tanpa module_id berlaku untuk semua module). Tanpa company_id aturan berlaku untuk semua perusahaan dan hanya dapat dibuat SUPER_ADMIN; aturan perusahaan dibuat COMPANY_ADMIN perusahaan tersebut
+// @Tags         Separation of Duties
+// @Accept       json
+// @Produce      json
+// @Param        rule  body      sod.CreateRuleRequest  true  "Data aturan"
+// @Success      201   {object}  response.Response{data=sod.RuleResponse}  "Aturan SoD berhasil dibuat"
+// @Failure      400   {object}  response.Response  "Bad request"
+// @Failure      403   {object}  response.Response  "Bukan admin"
+// @Failure      404   {object}  response.Response  "Perusahaan, peran, atau module tidak ditemukan"
+// @Router       /api/v1/sod-rules [post]
+// @Security     BearerAuth
+func (h *Handler) CreateRule(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*CreateRuleRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.CreateRule(userID, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusCreated, constants.MsgSoDRuleCreated, result)
+}
+
+// @Summary      Daftar aturan SoD
+// @Description  Dengan company_id mengembalikan aturan yang berlaku untuk perusahaan, yaitu aturan perusahaan dan aturan global (admin perusahaan). Tanpa company_id mengembalikan semua aturan (SUPER_ADMIN)
+// @Tags         Separation of Duties
+// @Produce      json
+// @Param        company_id  query     int     false  "Company ID"
+// @Param        kind        query     string  false  "role_pair atau action_pair"
+// @Param        limit       query     int     false  "Jumlah data (default 10, maks 100)"
+// @Param        offset      query     int     false  "Offset"
+// @Success      200         {object}  response.Response{data=sod.RuleListResponse}  "Daftar aturan SoD berhasil diambil"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      403         {object}  response.Response  "Bukan admin"
+// @Router       /api/v1/sod-rules [get]
+// @Security     BearerAuth
+func (h *Handler) GetRules(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req RuleListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", err.Error())
+		return
+	}
+
+	result, err := h.service.GetRules(userID, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgSoDRulesList, result)
+}
+
+// @Summary      Laporan pelanggaran SoD
+// @Description  Mencari pelanggaran aturan SoD aktif pada akses yang dipegang pengguna saat ini, termasuk peran warisan dan peran unit. Tanpa company_id mencakup semua perusahaan (SUPER_ADMIN); dengan company_id hanya perusahaan tersebut (admin perusahaan). Pelanggaran yang diizinkan dalam mode override menyertakan justification terakhir
+// @Tags         Separation of Duties
+// @Produce      json
+// @Param        company_id  query     int  false  "Company ID"
+// @Success      200         {object}  response.Response{data=sod.ViolationReportResponse}  "Laporan pelanggaran SoD berhasil dibuat"
+// @Failure      400         {object}  response.Response  "Bad request"
+// @Failure      403         {object}  response.Response  "Bukan admin"
+// @Router       /api/v1/sod-rules/violations [get]
+// @Security     BearerAuth
+func (h *Handler) GetViolations(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ViolationReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request parameters", err.Error())
+		return
+	}
+
+	result, err := h.service.GetViolations(userID, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgSoDViolationsReport, result)
+}
+
+// @Summary      Detail aturan SoD
+// @Description  Mengambil aturan SoD. Aturan global dapat dibaca semua pengguna, aturan perusahaan oleh admin perusahaan tersebut
+// @Tags         Separation of Duties
+// @Produce      json
+// @Param        id   path      int  true  "SoD rule ID"
+// @Success      200  {object}  response.Response{data=sod.RuleResponse}  "Aturan SoD berhasil diambil"
+// @Failure      403  {object}  response.Response  "Bukan admin perusahaan"
+// @Failure      404  {object}  response.Response  "Aturan SoD tidak ditemukan"
+// @Router       /api/v1/sod-rules/{id} [get]
+// @Security     BearerAuth
+func (h *Handler) GetRule(c *gin.Context) {
+	id, userID, ok := ruleParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetRule(userID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgSoDRuleRetrieved, result)
+}
+
+// @Summary      Ubah aturan SoD
+// @Description  Mengubah nama, deskripsi, atau status aktif aturan SoD. Pasangan peran atau action tidak dapat diubah; buat aturan baru
+// @Tags         Separation of Duties
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                    true  "SoD rule ID"
+// @Param        rule  body      sod.UpdateRuleRequest  true  "Perubahan aturan"
+// @Success      200   {object}  response.Response{data=sod.RuleResponse}  "Aturan SoD berhasil diubah"
+// @Failure      400   {object}  response.Response  "Bad request"
+// @Failure      403   {object}  response.Response  "Bukan admin"
+// @Failure      404   {object}  response.Response  "Aturan SoD tidak ditemukan"
+// @Router       /api/v1/sod-rules/{id} [put]
+// @Security     BearerAuth
+func (h *Handler) UpdateRule(c *gin.Context) {
+	id, userID, ok := ruleParams(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*UpdateRuleRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.UpdateRule(userID, id, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgSoDRuleUpdated, result)
+}
+
+// @Summary      Hapus aturan SoD
+// @Description  Menghapus aturan SoD beserta override yang tercatat; audit log tetap menyimpannya
+// @Tags         Separation of Duties
+// @Produce      json
+// @Param        id   path      int  true  "SoD rule ID"
+// @Success      200  {object}  response.Response  "Aturan SoD berhasil dihapus"
+// @Failure      403  {object}  response.Response  "Bukan admin"
+// @Failure      404  {object}  response.Response  "Aturan SoD tidak ditemukan"
+// @Router       /api/v1/sod-rules/{id} [delete]
+// @Security     BearerAuth
+func (h *Handler) DeleteRule(c *gin.Context) {
+	id, userID, ok := ruleParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRule(userID, id); err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgSoDRuleDeleted, nil)
+}
+
+func ruleParams(c *gin.Context) (int64, int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid SoD rule ID")
+		return 0, 0, false
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return id, userID, true
+}
+
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
+// Route registration
+
+func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
+	rules := router.Group("/sod-rules")
+	{
+		// GET /api/v1/sod-rules - List the rules of a company, or every rule
+		rules.GET("", handler.GetRules)
+
+		// POST /api/v1/sod-rules - Define a role pair or action pair rule
+		rules.POST("",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &CreateRuleRequest{},
+			}),
+			handler.CreateRule,
+		)
+
+		// GET /api/v1/sod-rules/violations - Report the current violations
+		rules.GET("/violations", handler.GetViolations)
+
+		// GET /api/v1/sod-rules/:id - Get a rule
+		rules.GET("/:id", handler.GetRule)
+
+		// PUT /api/v1/sod-rules/:id - Rename, describe or (de)activate a rule
+		rules.PUT("/:id",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &UpdateRuleRequest{},
+			}),
+			handler.UpdateRule,
+		)
+
+		// DELETE /api/v1/sod-rules/:id - Delete a rule
+		rules.DELETE("/:id", handler.DeleteRule)
+	}
+}
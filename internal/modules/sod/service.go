@@ -0,0 +1,428 @@
+package sod
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gin-scalable-api/pkg/audittrail"
+	"gin-scalable-api/pkg/rbac"
+)
+
+type Service struct {
+	repo          *Repository
+	sodGuard      *rbac.SoDGuard
+	auditRecorder audittrail.Recorder
+}
+
+func NewService(repo *Repository, sodGuard *rbac.SoDGuard, auditRecorder audittrail.Recorder) *Service {
+	return &Service{
+		repo:          repo,
+		sodGuard:      sodGuard,
+		auditRecorder: auditRecorder,
+	}
+}
+
+// Rule administration
+
+// CreateRule defines a rule. Company admins define rules of their company, super admins
+// also global rules. Existing violations of the rule are not removed; the violation
+// report lists them.
+func (s *Service) CreateRule(actorID int64, req *CreateRuleRequest) (*RuleResponse, error) {
+	if err := s.requireRuleAdmin(actorID, req.CompanyID); err != nil {
+		return nil, err
+	}
+
+	rule := &Rule{
+		Name:      strings.TrimSpace(req.Name),
+		Kind:      req.Kind,
+		CompanyID: req.CompanyID,
+		IsActive:  true,
+		CreatedBy: &actorID,
+	}
+	if description := strings.TrimSpace(req.Description); description != "" {
+		rule.Description = &description
+	}
+
+	switch req.Kind {
+	case KindRolePair:
+		if err := s.validateRolePair(req); err != nil {
+			return nil, err
+		}
+		rule.RoleAID, rule.RoleBID = req.RoleAID, req.RoleBID
+	case KindActionPair:
+		if err := s.validateActionPair(req); err != nil {
+			return nil, err
+		}
+		rule.ModuleID = req.ModuleID
+		rule.ActionA, rule.ActionB = &req.ActionA, &req.ActionB
+	}
+
+	if err := s.repo.Create(rule); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.GetByID(rule.ID)
+	if err != nil {
+		return nil, err
+	}
+	audittrail.RecordChange(s.auditRecorder, actorID, "sod_rule_created", "sod_rule", created.ID,
+		map[string]interface{}{}, ruleSnapshot(created))
+
+	return toRuleResponse(created), nil
+}
+
+func (s *Service) validateRolePair(req *CreateRuleRequest) error {
+	if req.RoleAID == nil || req.RoleBID == nil {
+		return errors.New("role_a_id dan role_b_id wajib diisi untuk aturan role_pair (required)")
+	}
+	if *req.RoleAID == *req.RoleBID {
+		return errors.New("role_a_id dan role_b_id harus berbeda (invalid)")
+	}
+	if req.ModuleID != nil || req.ActionA != "" || req.ActionB != "" {
+		return errors.New("aturan role_pair tidak memakai module_id, action_a, atau action_b (invalid)")
+	}
+	for _, roleID := range []int64{*req.RoleAID, *req.RoleBID} {
+		exists, err := s.repo.RoleExists(roleID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("peran dengan ID %d tidak ditemukan (role not found)", roleID)
+		}
+	}
+	return nil
+}
+
+func (s *Service) validateActionPair(req *CreateRuleRequest) error {
+	if req.ActionA == "" || req.ActionB == "" {
+		return errors.New("action_a dan action_b wajib diisi untuk aturan action_pair (required)")
+	}
+	if req.ActionA == req.ActionB {
+		return errors.New("action_a dan action_b harus berbeda (invalid)")
+	}
+	if req.RoleAID != nil || req.RoleBID != nil {
+		return errors.New("aturan action_pair tidak memakai role_a_id atau role_b_id (invalid)")
+	}
+	for _, action := range []string{req.ActionA, req.ActionB} {
+		if !rbac.IsValidAction(action) {
+			return fmt.Errorf("nama action %q tidak valid (invalid)", action)
+		}
+	}
+	if req.ModuleID == nil {
+		return nil
+	}
+
+	exists, err := s.repo.ModuleExists(*req.ModuleID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("module dengan ID %d tidak ditemukan (module not found)", *req.ModuleID)
+	}
+	for _, action := range []string{req.ActionA, req.ActionB} {
+		if rbac.IsStandardAction(action) {
+			continue
+		}
+		declared, err := s.repo.IsDeclaredAction(*req.ModuleID, action)
+		if err != nil {
+			return err
+		}
+		if !declared {
+			return fmt.Errorf("action %q tidak dideklarasikan oleh module %d (invalid)", action, *req.ModuleID)
+		}
+	}
+	return nil
+}
+
+// GetRules lists rules. With company_id company admins see the rules applying to their
+// company; without it super admins see every rule.
+func (s *Service) GetRules(actorID int64, req *RuleListRequest) (*RuleListResponse, error) {
+	switch req.Kind {
+	case "", KindRolePair, KindActionPair:
+	default:
+		return nil, errors.New("kind harus role_pair atau action_pair (invalid kind)")
+	}
+
+	if req.CompanyID > 0 {
+		if err := s.requireRuleAdmin(actorID, &req.CompanyID); err != nil {
+			return nil, err
+		}
+	} else if err := s.requireRuleAdmin(actorID, nil); err != nil {
+		return nil, err
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	rules, err := s.repo.List(req.CompanyID, req.Kind, req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.Count(req.CompanyID, req.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]*RuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		data = append(data, toRuleResponse(rule))
+	}
+
+	return &RuleListResponse{
+		Data:    data,
+		Total:   total,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+		HasMore: int64(req.Offset+len(data)) < total,
+	}, nil
+}
+
+// GetRule returns a rule. Global rules are readable by every user, company rules by the
+// admins of the company.
+func (s *Service) GetRule(actorID, id int64) (*RuleResponse, error) {
+	rule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rule.CompanyID != nil {
+		if err := s.requireRuleAdmin(actorID, rule.CompanyID); err != nil {
+			return nil, err
+		}
+	}
+	return toRuleResponse(rule), nil
+}
+
+// UpdateRule renames, describes or (de)activates a rule
+func (s *Service) UpdateRule(actorID, id int64, req *UpdateRuleRequest) (*RuleResponse, error) {
+	rule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireRuleAdmin(actorID, rule.CompanyID); err != nil {
+		return nil, err
+	}
+
+	before := ruleSnapshot(rule)
+	if req.Name != nil {
+		rule.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		rule.Description = &description
+		if description == "" {
+			rule.Description = nil
+		}
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.Update(rule); err != nil {
+		return nil, err
+	}
+	audittrail.RecordChange(s.auditRecorder, actorID, "sod_rule_updated", "sod_rule", rule.ID, before, ruleSnapshot(rule))
+
+	return toRuleResponse(rule), nil
+}
+
+// DeleteRule removes a rule and its recorded overrides; the audit log keeps them
+func (s *Service) DeleteRule(actorID, id int64) error {
+	rule, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.requireRuleAdmin(actorID, rule.CompanyID); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	audittrail.RecordChange(s.auditRecorder, actorID, "sod_rule_deleted", "sod_rule", id, ruleSnapshot(rule), map[string]interface{}{})
+	return nil
+}
+
+// Violation report
+
+// GetViolations finds the violations of the active rules in the access users hold right
+// now, across all companies for super admins or in one company for its admins. Violations
+// allowed in override mode carry their latest justification.
+func (s *Service) GetViolations(actorID int64, req *ViolationReportRequest) (*ViolationReportResponse, error) {
+	if req.CompanyID > 0 {
+		if err := s.requireRuleAdmin(actorID, &req.CompanyID); err != nil {
+			return nil, err
+		}
+	} else if err := s.requireRuleAdmin(actorID, nil); err != nil {
+		return nil, err
+	}
+
+	violations, err := s.sodGuard.Violations()
+	if err != nil {
+		return nil, err
+	}
+	if req.CompanyID > 0 {
+		inCompany := violations[:0]
+		for _, violation := range violations {
+			if violation.CompanyID == req.CompanyID {
+				inCompany = append(inCompany, violation)
+			}
+		}
+		violations = inCompany
+	}
+
+	overrides, err := s.repo.LatestOverrides()
+	if err != nil {
+		return nil, err
+	}
+	overrideByKey := make(map[string]*Override, len(overrides))
+	for _, override := range overrides {
+		overrideByKey[violationKey(override.RuleID, override.UserID, override.CompanyID, override.ModuleID)] = override
+	}
+
+	var userIDs, companyIDs, moduleIDs []int64
+	for _, violation := range violations {
+		userIDs = append(userIDs, violation.UserID)
+		companyIDs = append(companyIDs, violation.CompanyID)
+		if violation.ModuleID != nil {
+			moduleIDs = append(moduleIDs, *violation.ModuleID)
+		}
+	}
+	users, err := s.repo.UserInfo(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	companies, err := s.repo.CompanyNames(companyIDs)
+	if err != nil {
+		return nil, err
+	}
+	modules, err := s.repo.ModuleNames(moduleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ViolationReportResponse{
+		Violations:  make([]*ViolationResponse, 0, len(violations)),
+		Total:       len(violations),
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	}
+	for _, violation := range violations {
+		item := &ViolationResponse{
+			RuleID:      violation.RuleID,
+			RuleName:    violation.RuleName,
+			Kind:        violation.Kind,
+			UserID:      violation.UserID,
+			UserName:    users[violation.UserID].Name,
+			UserEmail:   users[violation.UserID].Email,
+			CompanyID:   violation.CompanyID,
+			CompanyName: companies[violation.CompanyID],
+			ModuleID:    violation.ModuleID,
+			Detail:      violation.Detail,
+		}
+		if violation.ModuleID != nil {
+			name := modules[*violation.ModuleID]
+			item.ModuleName = &name
+		}
+		if override := overrideByKey[violationKey(violation.RuleID, violation.UserID, violation.CompanyID, violation.ModuleID)]; override != nil {
+			item.Override = &OverrideResponse{
+				Change:        override.Change,
+				Justification: override.Justification,
+				GrantedBy:     override.GrantedBy,
+				CreatedAt:     override.CreatedAt.Format(time.RFC3339),
+			}
+			report.Overridden++
+		}
+		report.Violations = append(report.Violations, item)
+	}
+
+	return report, nil
+}
+
+// Helpers
+
+// requireRuleAdmin allows company admins to manage the rules of their company and super
+// admins to manage global rules (companyID nil)
+func (s *Service) requireRuleAdmin(actorID int64, companyID *int64) error {
+	if companyID == nil {
+		isAdmin, err := s.repo.IsSuperAdmin(actorID)
+		if err != nil {
+			return err
+		}
+		if !isAdmin {
+			return errors.New("access denied: only super admins can manage global SoD rules")
+		}
+		return nil
+	}
+
+	exists, err := s.repo.CompanyExists(*companyID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("perusahaan tidak ditemukan (company not found)")
+	}
+
+	isAdmin, err := s.repo.IsCompanyAdmin(actorID, *companyID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("access denied: only company admins can manage SoD rules")
+	}
+	return nil
+}
+
+func violationKey(ruleID, userID, companyID int64, moduleID *int64) string {
+	if moduleID == nil {
+		return fmt.Sprintf("%d/%d/%d", ruleID, userID, companyID)
+	}
+	return fmt.Sprintf("%d/%d/%d/%d", ruleID, userID, companyID, *moduleID)
+}
+
+// ruleSnapshot is the audited state of a rule
+func ruleSnapshot(rule *Rule) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        rule.Name,
+		"description": rule.Description,
+		"kind":        rule.Kind,
+		"role_a_id":   rule.RoleAID,
+		"role_b_id":   rule.RoleBID,
+		"module_id":   rule.ModuleID,
+		"action_a":    rule.ActionA,
+		"action_b":    rule.ActionB,
+		"company_id":  rule.CompanyID,
+		"is_active":   rule.IsActive,
+	}
+}
+
+func toRuleResponse(rule *Rule) *RuleResponse {
+	return &RuleResponse{
+		ID:          rule.ID,
+		Name:        rule.Name,
+		Description: rule.Description,
+		Kind:        rule.Kind,
+		RoleAID:     rule.RoleAID,
+		RoleAName:   rule.RoleAName,
+		RoleBID:     rule.RoleBID,
+		RoleBName:   rule.RoleBName,
+		ModuleID:    rule.ModuleID,
+		ModuleName:  rule.ModuleName,
+		ActionA:     rule.ActionA,
+		ActionB:     rule.ActionB,
+		CompanyID:   rule.CompanyID,
+		CompanyName: rule.CompanyName,
+		IsActive:    rule.IsActive,
+		CreatedBy:   rule.CreatedBy,
+		CreatedAt:   rule.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   rule.UpdatedAt.Format(time.RFC3339),
+	}
+}
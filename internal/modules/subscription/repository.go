@@ -31,7 +31,7 @@ type Repository interface {
 	Update(sub *Subscription) error
 	CheckModuleAccess(companyID int64, moduleID int64) (bool, error)
 	GetExpiring(days int) ([]*Subscription, error)
-	UpdateExpired() error
+	UpdateExpired() ([]int64, error)
 	GetStats() (map[string]interface{}, error)
 	MarkPaymentPaid(id int64) error
 }
@@ -319,11 +319,25 @@ func (r *repository) GetExpiring(days int) ([]*Subscription, error) {
 	return subs, nil
 }
 
-func (r *repository) UpdateExpired() error {
+func (r *repository) UpdateExpired() ([]int64, error) {
 	query := `UPDATE subscriptions SET status = 'expired' 
-		WHERE status = 'active' AND end_date < CURRENT_DATE`
-	_, err := r.db.Exec(query)
-	return err
+		WHERE status = 'active' AND end_date < CURRENT_DATE
+		RETURNING company_id`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var companyIDs []int64
+	for rows.Next() {
+		var companyID int64
+		if err := rows.Scan(&companyID); err != nil {
+			return nil, err
+		}
+		companyIDs = append(companyIDs, companyID)
+	}
+	return companyIDs, rows.Err()
 }
 
 func (r *repository) GetStats() (map[string]interface{}, error) {
@@ -131,7 +131,12 @@ func (h *Handler) UpdateSubscriptionPlan(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.UpdateSubscriptionPlan(id, req)
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.UpdateSubscriptionPlan(userID, id, req)
 	if err != nil {
 		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
 		return
@@ -291,7 +296,12 @@ func (h *Handler) UpdateSubscription(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.UpdateSubscription(id, req)
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.UpdateSubscription(userID, id, req)
 	if err != nil {
 		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
 		return
@@ -388,7 +398,12 @@ func (h *Handler) AddModulesToPlan(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.AddModulesToPlan(planID, req); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.AddModulesToPlan(userID, planID, req); err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to add modules to plan", err.Error())
 		return
 	}
@@ -422,7 +437,12 @@ func (h *Handler) RemoveModuleFromPlan(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.RemoveModuleFromPlan(planID, moduleID); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveModuleFromPlan(userID, planID, moduleID); err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to remove module from plan", err.Error())
 		return
 	}
@@ -430,6 +450,22 @@ func (h *Handler) RemoveModuleFromPlan(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Module successfully removed from plan", nil)
 }
 
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
 // Route registration
 func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
 	// Public plan routes (read-only)
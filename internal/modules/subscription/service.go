@@ -3,15 +3,21 @@ package subscription
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"time"
+
+	"gin-scalable-api/pkg/audittrail"
+	"gin-scalable-api/pkg/rbac"
 )
 
 type Service struct {
-	repo Repository
+	repo            Repository
+	permissionCache *rbac.PermissionCache
+	auditRecorder   audittrail.Recorder
 }
 
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repository, permissionCache *rbac.PermissionCache, auditRecorder audittrail.Recorder) *Service {
+	return &Service{repo: repo, permissionCache: permissionCache, auditRecorder: auditRecorder}
 }
 
 func (s *Service) GetSubscriptionPlans() ([]*SubscriptionPlanResponse, error) {
@@ -60,7 +66,7 @@ func (s *Service) CreateSubscriptionPlan(req *CreateSubscriptionPlanRequest) (*S
 	return toPlanResponse(plan), nil
 }
 
-func (s *Service) UpdateSubscriptionPlan(id int64, req *UpdateSubscriptionPlanRequest) (*SubscriptionPlanResponse, error) {
+func (s *Service) UpdateSubscriptionPlan(actorID, id int64, req *UpdateSubscriptionPlanRequest) (*SubscriptionPlanResponse, error) {
 	plan, err := s.repo.GetPlanByID(id)
 	if err != nil {
 		return nil, err
@@ -68,6 +74,7 @@ func (s *Service) UpdateSubscriptionPlan(id int64, req *UpdateSubscriptionPlanRe
 	if plan == nil {
 		return nil, errors.New("subscription plan not found")
 	}
+	before := planSnapshot(plan)
 
 	if req.Name != "" {
 		plan.Name = req.Name
@@ -101,6 +108,8 @@ func (s *Service) UpdateSubscriptionPlan(id int64, req *UpdateSubscriptionPlanRe
 		return nil, err
 	}
 
+	audittrail.RecordChange(s.auditRecorder, actorID, "subscription_plan_updated", "subscription_plan", id, before, planSnapshot(plan))
+
 	return toPlanResponse(plan), nil
 }
 
@@ -189,10 +198,14 @@ func (s *Service) CreateSubscription(req *CreateSubscriptionRequest) (*Subscript
 		return nil, err
 	}
 
+	if err := s.permissionCache.InvalidateCompany(sub.CompanyID); err != nil {
+		return nil, err
+	}
+
 	return toSubscriptionResponse(sub), nil
 }
 
-func (s *Service) UpdateSubscription(id int64, req *UpdateSubscriptionRequest) (*SubscriptionResponse, error) {
+func (s *Service) UpdateSubscription(actorID, id int64, req *UpdateSubscriptionRequest) (*SubscriptionResponse, error) {
 	sub, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, err
@@ -200,6 +213,7 @@ func (s *Service) UpdateSubscription(id int64, req *UpdateSubscriptionRequest) (
 	if sub == nil {
 		return nil, errors.New("subscription not found")
 	}
+	before := subscriptionSnapshot(sub)
 
 	if req.PlanID != nil {
 		sub.PlanID = *req.PlanID
@@ -228,10 +242,44 @@ func (s *Service) UpdateSubscription(id int64, req *UpdateSubscriptionRequest) (
 		return nil, err
 	}
 
+	audittrail.RecordChange(s.auditRecorder, actorID, "subscription_updated", "subscription", id, before, subscriptionSnapshot(sub))
+
+	if err := s.permissionCache.InvalidateCompany(sub.CompanyID); err != nil {
+		return nil, err
+	}
+
 	return toSubscriptionResponse(sub), nil
 }
 
-func (s *Service) RenewSubscription(id int64, planID *int64, billingCycle string) (*SubscriptionResponse, error) {
+// planSnapshot is the audited state of a subscription plan
+func planSnapshot(plan *SubscriptionPlan) map[string]interface{} {
+	return map[string]interface{}{
+		"name":          plan.Name,
+		"display_name":  plan.DisplayName,
+		"description":   plan.Description,
+		"price_monthly": plan.PriceMonthly,
+		"price_yearly":  plan.PriceYearly,
+		"max_users":     plan.MaxUsers,
+		"max_branches":  plan.MaxBranches,
+		"features":      plan.Features,
+		"is_active":     plan.IsActive,
+	}
+}
+
+// subscriptionSnapshot is the audited state of a company subscription
+func subscriptionSnapshot(sub *Subscription) map[string]interface{} {
+	return map[string]interface{}{
+		"plan_id":        sub.PlanID,
+		"status":         sub.Status,
+		"billing_cycle":  sub.BillingCycle,
+		"end_date":       sub.EndDate.Format("2006-01-02"),
+		"price":          sub.Price,
+		"payment_status": sub.PaymentStatus,
+		"auto_renew":     sub.AutoRenew,
+	}
+}
+
+func (s *Service) RenewSubscription(actorID, id int64, planID *int64, billingCycle string) (*SubscriptionResponse, error) {
 	sub, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, err
@@ -239,6 +287,7 @@ func (s *Service) RenewSubscription(id int64, planID *int64, billingCycle string
 	if sub == nil {
 		return nil, errors.New("subscription not found")
 	}
+	before := subscriptionSnapshot(sub)
 
 	if planID != nil {
 		sub.PlanID = *planID
@@ -257,10 +306,16 @@ func (s *Service) RenewSubscription(id int64, planID *int64, billingCycle string
 		return nil, err
 	}
 
+	audittrail.RecordChange(s.auditRecorder, actorID, "subscription_renewed", "subscription", id, before, subscriptionSnapshot(sub))
+
+	if err := s.permissionCache.InvalidateCompany(sub.CompanyID); err != nil {
+		return nil, err
+	}
+
 	return toSubscriptionResponse(sub), nil
 }
 
-func (s *Service) CancelSubscription(id int64) error {
+func (s *Service) CancelSubscription(actorID, id int64) error {
 	sub, err := s.repo.GetByID(id)
 	if err != nil {
 		return err
@@ -268,9 +323,16 @@ func (s *Service) CancelSubscription(id int64) error {
 	if sub == nil {
 		return errors.New("subscription not found")
 	}
+	before := subscriptionSnapshot(sub)
 
 	sub.Status = "cancelled"
-	return s.repo.Update(sub)
+	if err := s.repo.Update(sub); err != nil {
+		return err
+	}
+
+	audittrail.RecordChange(s.auditRecorder, actorID, "subscription_cancelled", "subscription", id, before, subscriptionSnapshot(sub))
+
+	return s.permissionCache.InvalidateCompany(sub.CompanyID)
 }
 
 func (s *Service) GetCompanySubscription(companyID int64) (*SubscriptionResponse, error) {
@@ -304,7 +366,17 @@ func (s *Service) GetExpiringSubscriptions(days int) ([]*SubscriptionResponse, e
 }
 
 func (s *Service) UpdateExpiredSubscriptions() error {
-	return s.repo.UpdateExpired()
+	companyIDs, err := s.repo.UpdateExpired()
+	if err != nil {
+		return err
+	}
+
+	for _, companyID := range companyIDs {
+		if err := s.permissionCache.InvalidateCompany(companyID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *Service) GetSubscriptionStats() (map[string]interface{}, error) {
@@ -406,7 +478,23 @@ func (s *Service) GetPlanModules(planID int64) (*PlanModulesListResponse, error)
 	}, nil
 }
 
-func (s *Service) AddModulesToPlan(planID int64, req *AddModulesToPlanRequest) error {
+// planModulesSnapshot is the audited module set of a subscription plan
+func (s *Service) planModulesSnapshot(planID int64) (map[string]interface{}, error) {
+	modules, err := s.repo.GetPlanModules(planID)
+	if err != nil {
+		return nil, err
+	}
+
+	moduleIDs := make([]int64, 0, len(modules))
+	for _, module := range modules {
+		moduleIDs = append(moduleIDs, module.ModuleID)
+	}
+	sort.Slice(moduleIDs, func(i, j int) bool { return moduleIDs[i] < moduleIDs[j] })
+
+	return map[string]interface{}{"module_ids": moduleIDs}, nil
+}
+
+func (s *Service) AddModulesToPlan(actorID, planID int64, req *AddModulesToPlanRequest) error {
 	// Validate request
 	if err := ValidateAddModulesToPlanRequest(req); err != nil {
 		return err
@@ -432,11 +520,26 @@ func (s *Service) AddModulesToPlan(planID int64, req *AddModulesToPlanRequest) e
 		}
 	}
 
+	before, err := s.planModulesSnapshot(planID)
+	if err != nil {
+		return err
+	}
+
 	// Add modules to plan
-	return s.repo.AddModulesToPlan(planID, req.ModuleIDs)
+	if err := s.repo.AddModulesToPlan(planID, req.ModuleIDs); err != nil {
+		return err
+	}
+
+	after, err := s.planModulesSnapshot(planID)
+	if err != nil {
+		return err
+	}
+	audittrail.RecordChange(s.auditRecorder, actorID, "plan_modules_added", "subscription_plan", planID, before, after)
+
+	return s.permissionCache.InvalidatePlan(planID)
 }
 
-func (s *Service) RemoveModuleFromPlan(planID int64, moduleID int64) error {
+func (s *Service) RemoveModuleFromPlan(actorID, planID int64, moduleID int64) error {
 	// Check if plan exists
 	exists, err := s.repo.CheckPlanExists(planID)
 	if err != nil {
@@ -455,6 +558,21 @@ func (s *Service) RemoveModuleFromPlan(planID int64, moduleID int64) error {
 		return errors.New("module not found")
 	}
 
+	before, err := s.planModulesSnapshot(planID)
+	if err != nil {
+		return err
+	}
+
 	// Remove module from plan
-	return s.repo.RemoveModuleFromPlan(planID, moduleID)
+	if err := s.repo.RemoveModuleFromPlan(planID, moduleID); err != nil {
+		return err
+	}
+
+	after, err := s.planModulesSnapshot(planID)
+	if err != nil {
+		return err
+	}
+	audittrail.RecordChange(s.auditRecorder, actorID, "plan_module_removed", "subscription_plan", planID, before, after)
+
+	return s.permissionCache.InvalidatePlan(planID)
 }
@@ -1,6 +1,10 @@
 package unit
 
-import "github.com/go-playground/validator/v10"
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
 
 type CreateUnitRequest struct {
 	BranchID    int64  `json:"branch_id" validate:"required"`
@@ -66,37 +70,51 @@ type UnitListResponse struct {
 	HasMore bool            `json:"has_more"`
 }
 
+// AssignUnitRoleRequest is the optional body of a unit role assignment
+type AssignUnitRoleRequest struct {
+	// ValidFrom schedules the assignment, ValidUntil expires it (RFC 3339)
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	// Justification overrides a separation-of-duties violation when SOD_MODE=override
+	Justification string `json:"justification,omitempty"`
+}
+
 type UnitRoleResponse struct {
-	ID        int64  `json:"id"`
-	UnitID    int64  `json:"unit_id"`
-	RoleID    int64  `json:"role_id"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	UnitName  string `json:"unit_name,omitempty"`
-	RoleName  string `json:"role_name,omitempty"`
+	ID         int64   `json:"id"`
+	UnitID     int64   `json:"unit_id"`
+	RoleID     int64   `json:"role_id"`
+	ValidFrom  *string `json:"valid_from,omitempty"`
+	ValidUntil *string `json:"valid_until,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  string  `json:"updated_at"`
+	UnitName   string  `json:"unit_name,omitempty"`
+	RoleName   string  `json:"role_name,omitempty"`
 }
 
 type UnitRoleModuleResponse struct {
-	ID             int64  `json:"id"`
-	UnitRoleID     int64  `json:"unit_role_id"`
-	ModuleID       int64  `json:"module_id"`
-	CanRead        bool   `json:"can_read"`
-	CanWrite       bool   `json:"can_write"`
-	CanDelete      bool   `json:"can_delete"`
-	CanApprove     bool   `json:"can_approve"`
-	CreatedAt      string `json:"created_at"`
-	UpdatedAt      string `json:"updated_at"`
-	ModuleName     string `json:"module_name,omitempty"`
-	ModuleCategory string `json:"module_category,omitempty"`
-	ModuleURL      string `json:"module_url,omitempty"`
-	UnitName       string `json:"unit_name,omitempty"`
-	RoleName       string `json:"role_name,omitempty"`
-	IsCustomized   bool   `json:"is_customized,omitempty"`
+	ID             int64             `json:"id"`
+	UnitRoleID     int64             `json:"unit_role_id"`
+	ModuleID       int64             `json:"module_id"`
+	CanRead        bool              `json:"can_read"`
+	CanWrite       bool              `json:"can_write"`
+	CanDelete      bool              `json:"can_delete"`
+	CanApprove     bool              `json:"can_approve"`
+	Actions        []string          `json:"actions"`
+	Conditions     map[string]string `json:"conditions,omitempty"`
+	CreatedAt      string            `json:"created_at"`
+	UpdatedAt      string            `json:"updated_at"`
+	ModuleName     string            `json:"module_name,omitempty"`
+	ModuleCategory string            `json:"module_category,omitempty"`
+	ModuleURL      string            `json:"module_url,omitempty"`
+	UnitName       string            `json:"unit_name,omitempty"`
+	RoleName       string            `json:"role_name,omitempty"`
+	IsCustomized   bool              `json:"is_customized,omitempty"`
 }
 
 type BulkUpdateUnitRoleModulesRequest struct {
-	UnitRoleID int64                            `json:"unit_role_id" validate:"required"`
-	Modules    []UpdateUnitRoleModulePermission `json:"modules" validate:"required,dive"`
+	UnitRoleID    int64                            `json:"unit_role_id" validate:"required"`
+	Modules       []UpdateUnitRoleModulePermission `json:"modules" validate:"required,dive"`
+	Justification string                           `json:"justification,omitempty" validate:"omitempty,max=1000"`
 }
 
 type UpdateUnitRoleModulePermission struct {
@@ -105,20 +123,26 @@ type UpdateUnitRoleModulePermission struct {
 	CanWrite   bool  `json:"can_write"`
 	CanDelete  bool  `json:"can_delete"`
 	CanApprove bool  `json:"can_approve"`
+	// Actions grants further actions the module declares, e.g. export or post-journal
+	Actions []string `json:"actions,omitempty" validate:"omitempty,max=50"`
+	// Conditions maps a granted action to the expression that must hold
+	Conditions map[string]string `json:"conditions,omitempty"`
 }
 
 type CopyUnitPermissionsRequest struct {
-	SourceUnitID      int64 `json:"source_unit_id" validate:"required"`
-	TargetUnitID      int64 `json:"target_unit_id" validate:"required"`
-	RoleID            int64 `json:"role_id" validate:"required"`
-	OverwriteExisting bool  `json:"overwrite_existing"`
+	SourceUnitID      int64  `json:"source_unit_id" validate:"required"`
+	TargetUnitID      int64  `json:"target_unit_id" validate:"required"`
+	RoleID            int64  `json:"role_id" validate:"required"`
+	OverwriteExisting bool   `json:"overwrite_existing"`
+	Justification     string `json:"justification,omitempty" validate:"omitempty,max=1000"`
 }
 
 // Alternative request for more flexible copying between different unit roles
 type CopyUnitRolePermissionsRequest struct {
-	SourceUnitRoleID  int64 `json:"source_unit_role_id" validate:"required"`
-	TargetUnitRoleID  int64 `json:"target_unit_role_id" validate:"required"`
-	OverwriteExisting bool  `json:"overwrite_existing"`
+	SourceUnitRoleID  int64  `json:"source_unit_role_id" validate:"required"`
+	TargetUnitRoleID  int64  `json:"target_unit_role_id" validate:"required"`
+	OverwriteExisting bool   `json:"overwrite_existing"`
+	Justification     string `json:"justification,omitempty" validate:"omitempty,max=1000"`
 }
 
 // Validation functions
@@ -1,6 +1,10 @@
 package unit
 
-import "time"
+import (
+	"time"
+
+	"gin-scalable-api/pkg/rbac"
+)
 
 type Unit struct {
 	ID          int64     `json:"id" db:"id"`
@@ -29,13 +33,16 @@ type UnitWithBranch struct {
 }
 
 type UnitRole struct {
-	ID        int64     `json:"id" db:"id"`
-	UnitID    int64     `json:"unit_id" db:"unit_id"`
-	RoleID    int64     `json:"role_id" db:"role_id"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
-	UnitName  string    `json:"unit_name,omitempty" db:"unit_name"`
-	RoleName  string    `json:"role_name,omitempty" db:"role_name"`
+	ID     int64 `json:"id" db:"id"`
+	UnitID int64 `json:"unit_id" db:"unit_id"`
+	RoleID int64 `json:"role_id" db:"role_id"`
+	// ValidFrom and ValidUntil bound when the unit role applies; nil is unbounded
+	ValidFrom  *time.Time `json:"valid_from,omitempty" db:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until,omitempty" db:"valid_until"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+	UnitName   string     `json:"unit_name,omitempty" db:"unit_name"`
+	RoleName   string     `json:"role_name,omitempty" db:"role_name"`
 }
 
 func (UnitRole) TableName() string {
@@ -43,15 +50,17 @@ func (UnitRole) TableName() string {
 }
 
 type UnitRoleModule struct {
-	ID         int64     `json:"id" db:"id"`
-	UnitRoleID int64     `json:"unit_role_id" db:"unit_role_id"`
-	ModuleID   int64     `json:"module_id" db:"module_id"`
-	CanRead    bool      `json:"can_read" db:"can_read"`
-	CanWrite   bool      `json:"can_write" db:"can_write"`
-	CanDelete  bool      `json:"can_delete" db:"can_delete"`
-	CanApprove bool      `json:"can_approve" db:"can_approve"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	ID         int64           `json:"id" db:"id"`
+	UnitRoleID int64           `json:"unit_role_id" db:"unit_role_id"`
+	ModuleID   int64           `json:"module_id" db:"module_id"`
+	CanRead    bool            `json:"can_read" db:"can_read"`
+	CanWrite   bool            `json:"can_write" db:"can_write"`
+	CanDelete  bool            `json:"can_delete" db:"can_delete"`
+	CanApprove bool            `json:"can_approve" db:"can_approve"`
+	Actions    rbac.Actions    `json:"actions" db:"actions"`
+	Conditions rbac.Conditions `json:"conditions,omitempty" db:"conditions"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
 }
 
 func (UnitRoleModule) TableName() string {
@@ -2,8 +2,12 @@ package unit
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
+
+	"gin-scalable-api/pkg/rbac"
 )
 
 type Repository interface {
@@ -17,17 +21,20 @@ type Repository interface {
 	Delete(id int64) error
 
 	// Unit Role methods
-	AssignRole(unitID int64, roleID int64) error
+	AssignRole(unitID int64, roleID int64, validFrom, validUntil *time.Time) error
 	RemoveRole(unitID int64, roleID int64) error
 	GetUnitRoles(unitID int64) ([]*UnitRole, error)
 
 	// Permission methods
 	GetUnitPermissions(unitID int64, roleID int64) ([]*UnitRoleModule, error)
+	GetUnitRoleID(unitID int64, roleID int64) (int64, error)
+	GetUnitRoleModules(unitRoleID int64) ([]*UnitRoleModule, error)
 	UpdatePermissions(unitRoleID int64, modules []UpdateUnitRoleModulePermission) error
 	CopyPermissions(sourceUnitID int64, targetUnitID int64, roleID int64, overwrite bool) error
 	CopyUnitRolePermissions(sourceUnitRoleID int64, targetUnitRoleID int64, overwrite bool) error
 	GetUnitRoleInfo(unitID int64) ([]map[string]interface{}, error)
 	GetUserEffectivePermissions(userID int64) ([]*UnitRoleModule, error)
+	GetDeclaredActions(moduleIDs []int64) (map[int64][]string, error)
 }
 
 type repository struct {
@@ -284,9 +291,15 @@ func (r *repository) Delete(id int64) error {
 	return err
 }
 
-func (r *repository) AssignRole(unitID int64, roleID int64) error {
-	query := `INSERT INTO unit_roles (unit_id, role_id) VALUES ($1, $2)`
-	_, err := r.db.Exec(query, unitID, roleID)
+// AssignRole assigns a role to a unit. A scheduled assignment stays pending until the
+// assignment sweeper activates it at valid_from.
+func (r *repository) AssignRole(unitID int64, roleID int64, validFrom, validUntil *time.Time) error {
+	query := `
+		INSERT INTO unit_roles (unit_id, role_id, valid_from, valid_until, activated_at)
+		VALUES ($1, $2, $3::timestamp, $4::timestamp,
+			CASE WHEN $3::timestamp > CURRENT_TIMESTAMP THEN NULL ELSE CURRENT_TIMESTAMP END)
+	`
+	_, err := r.db.Exec(query, unitID, roleID, validFrom, validUntil)
 	return err
 }
 
@@ -298,7 +311,7 @@ func (r *repository) RemoveRole(unitID int64, roleID int64) error {
 
 func (r *repository) GetUnitRoles(unitID int64) ([]*UnitRole, error) {
 	query := `
-		SELECT ur.id, ur.unit_id, ur.role_id, ur.created_at, ur.updated_at,
+		SELECT ur.id, ur.unit_id, ur.role_id, ur.valid_from, ur.valid_until, ur.created_at, ur.updated_at,
 			u.name as unit_name, r.name as role_name
 		FROM unit_roles ur
 		JOIN units u ON ur.unit_id = u.id
@@ -315,8 +328,8 @@ func (r *repository) GetUnitRoles(unitID int64) ([]*UnitRole, error) {
 	var roles []*UnitRole
 	for rows.Next() {
 		role := &UnitRole{}
-		err := rows.Scan(&role.ID, &role.UnitID, &role.RoleID, &role.CreatedAt, &role.UpdatedAt,
-			&role.UnitName, &role.RoleName)
+		err := rows.Scan(&role.ID, &role.UnitID, &role.RoleID, &role.ValidFrom, &role.ValidUntil,
+			&role.CreatedAt, &role.UpdatedAt, &role.UnitName, &role.RoleName)
 		if err != nil {
 			return nil, err
 		}
@@ -329,7 +342,7 @@ func (r *repository) GetUnitRoles(unitID int64) ([]*UnitRole, error) {
 func (r *repository) GetUnitPermissions(unitID int64, roleID int64) ([]*UnitRoleModule, error) {
 	query := `
 		SELECT urm.id, urm.unit_role_id, urm.module_id, urm.can_read, urm.can_write, 
-			urm.can_delete, urm.can_approve, urm.created_at, urm.updated_at
+			urm.can_delete, urm.can_approve, urm.actions, urm.conditions, urm.created_at, urm.updated_at
 		FROM unit_role_modules urm
 		JOIN unit_roles ur ON urm.unit_role_id = ur.id
 		WHERE ur.unit_id = $1 AND ur.role_id = $2
@@ -345,7 +358,7 @@ func (r *repository) GetUnitPermissions(unitID int64, roleID int64) ([]*UnitRole
 	for rows.Next() {
 		perm := &UnitRoleModule{}
 		err := rows.Scan(&perm.ID, &perm.UnitRoleID, &perm.ModuleID, &perm.CanRead, &perm.CanWrite,
-			&perm.CanDelete, &perm.CanApprove, &perm.CreatedAt, &perm.UpdatedAt)
+			&perm.CanDelete, &perm.CanApprove, &perm.Actions, &perm.Conditions, &perm.CreatedAt, &perm.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -355,6 +368,48 @@ func (r *repository) GetUnitPermissions(unitID int64, roleID int64) ([]*UnitRole
 	return permissions, nil
 }
 
+// GetUnitRoleModules retrieves the module permissions of a unit role
+// GetUnitRoleID returns the ID of the assignment of a role to a unit
+func (r *repository) GetUnitRoleID(unitID int64, roleID int64) (int64, error) {
+	var unitRoleID int64
+	err := r.db.QueryRow("SELECT id FROM unit_roles WHERE unit_id = $1 AND role_id = $2", unitID, roleID).Scan(&unitRoleID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("unit %d does not have role %d assigned (not found)", unitID, roleID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get unit role: %w", err)
+	}
+	return unitRoleID, nil
+}
+
+func (r *repository) GetUnitRoleModules(unitRoleID int64) ([]*UnitRoleModule, error) {
+	query := `
+		SELECT id, unit_role_id, module_id, can_read, can_write, can_delete, can_approve, actions, conditions, created_at, updated_at
+		FROM unit_role_modules
+		WHERE unit_role_id = $1
+		ORDER BY module_id
+	`
+
+	rows, err := r.db.Query(query, unitRoleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit role modules: %w", err)
+	}
+	defer rows.Close()
+
+	var permissions []*UnitRoleModule
+	for rows.Next() {
+		perm := &UnitRoleModule{}
+		err := rows.Scan(&perm.ID, &perm.UnitRoleID, &perm.ModuleID, &perm.CanRead, &perm.CanWrite,
+			&perm.CanDelete, &perm.CanApprove, &perm.Actions, &perm.Conditions, &perm.CreatedAt, &perm.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan unit role module: %w", err)
+		}
+		permissions = append(permissions, perm)
+	}
+
+	return permissions, rows.Err()
+}
+
 func (r *repository) UpdatePermissions(unitRoleID int64, modules []UpdateUnitRoleModulePermission) error {
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -364,12 +419,14 @@ func (r *repository) UpdatePermissions(unitRoleID int64, modules []UpdateUnitRol
 
 	for _, module := range modules {
 		query := `
-			INSERT INTO unit_role_modules (unit_role_id, module_id, can_read, can_write, can_delete, can_approve)
-			VALUES ($1, $2, $3, $4, $5, $6)
+			INSERT INTO unit_role_modules (unit_role_id, module_id, can_read, can_write, can_delete, can_approve, actions, conditions)
+			VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8::jsonb)
 			ON CONFLICT (unit_role_id, module_id) 
-			DO UPDATE SET can_read = $3, can_write = $4, can_delete = $5, can_approve = $6, updated_at = CURRENT_TIMESTAMP
+			DO UPDATE SET can_read = $3, can_write = $4, can_delete = $5, can_approve = $6, actions = $7::jsonb,
+				conditions = $8::jsonb, updated_at = CURRENT_TIMESTAMP
 		`
-		_, err := tx.Exec(query, unitRoleID, module.ModuleID, module.CanRead, module.CanWrite, module.CanDelete, module.CanApprove)
+		_, err := tx.Exec(query, unitRoleID, module.ModuleID, module.CanRead, module.CanWrite, module.CanDelete, module.CanApprove,
+			rbac.Actions(module.Actions), rbac.Conditions(module.Conditions))
 		if err != nil {
 			return err
 		}
@@ -411,10 +468,10 @@ func (r *repository) CopyPermissions(sourceUnitID int64, targetUnitID int64, rol
 
 	// Now perform the copy operation
 	query := `
-		INSERT INTO unit_role_modules (unit_role_id, module_id, can_read, can_write, can_delete, can_approve)
+		INSERT INTO unit_role_modules (unit_role_id, module_id, can_read, can_write, can_delete, can_approve, actions, conditions)
 		SELECT 
 			$1 as unit_role_id,
-			urm.module_id, urm.can_read, urm.can_write, urm.can_delete, urm.can_approve
+			urm.module_id, urm.can_read, urm.can_write, urm.can_delete, urm.can_approve, urm.actions, urm.conditions
 		FROM unit_role_modules urm
 		WHERE urm.unit_role_id = $2
 	`
@@ -422,7 +479,8 @@ func (r *repository) CopyPermissions(sourceUnitID int64, targetUnitID int64, rol
 	if overwrite {
 		query += ` ON CONFLICT (unit_role_id, module_id) DO UPDATE SET 
 			can_read = EXCLUDED.can_read, can_write = EXCLUDED.can_write, 
-			can_delete = EXCLUDED.can_delete, can_approve = EXCLUDED.can_approve`
+			can_delete = EXCLUDED.can_delete, can_approve = EXCLUDED.can_approve,
+			actions = EXCLUDED.actions, conditions = EXCLUDED.conditions`
 	} else {
 		query += ` ON CONFLICT (unit_role_id, module_id) DO NOTHING`
 	}
@@ -447,7 +505,7 @@ func (r *repository) CopyPermissions(sourceUnitID int64, targetUnitID int64, rol
 func (r *repository) GetUserEffectivePermissions(userID int64) ([]*UnitRoleModule, error) {
 	query := `
 		SELECT DISTINCT urm.id, urm.unit_role_id, urm.module_id, urm.can_read, urm.can_write, 
-			urm.can_delete, urm.can_approve, urm.created_at, urm.updated_at
+			urm.can_delete, urm.can_approve, urm.actions, urm.conditions, urm.created_at, urm.updated_at
 		FROM unit_role_modules urm
 		JOIN unit_roles ur ON urm.unit_role_id = ur.id
 		JOIN user_roles usr ON usr.unit_id = ur.unit_id AND usr.role_id = ur.role_id
@@ -464,7 +522,7 @@ func (r *repository) GetUserEffectivePermissions(userID int64) ([]*UnitRoleModul
 	for rows.Next() {
 		perm := &UnitRoleModule{}
 		err := rows.Scan(&perm.ID, &perm.UnitRoleID, &perm.ModuleID, &perm.CanRead, &perm.CanWrite,
-			&perm.CanDelete, &perm.CanApprove, &perm.CreatedAt, &perm.UpdatedAt)
+			&perm.CanDelete, &perm.CanApprove, &perm.Actions, &perm.Conditions, &perm.CreatedAt, &perm.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -497,10 +555,10 @@ func (r *repository) CopyUnitRolePermissions(sourceUnitRoleID int64, targetUnitR
 
 	// Perform the copy operation
 	query := `
-		INSERT INTO unit_role_modules (unit_role_id, module_id, can_read, can_write, can_delete, can_approve)
+		INSERT INTO unit_role_modules (unit_role_id, module_id, can_read, can_write, can_delete, can_approve, actions, conditions)
 		SELECT 
 			$1 as unit_role_id,
-			urm.module_id, urm.can_read, urm.can_write, urm.can_delete, urm.can_approve
+			urm.module_id, urm.can_read, urm.can_write, urm.can_delete, urm.can_approve, urm.actions, urm.conditions
 		FROM unit_role_modules urm
 		WHERE urm.unit_role_id = $2
 	`
@@ -508,7 +566,8 @@ func (r *repository) CopyUnitRolePermissions(sourceUnitRoleID int64, targetUnitR
 	if overwrite {
 		query += ` ON CONFLICT (unit_role_id, module_id) DO UPDATE SET 
 			can_read = EXCLUDED.can_read, can_write = EXCLUDED.can_write, 
-			can_delete = EXCLUDED.can_delete, can_approve = EXCLUDED.can_approve`
+			can_delete = EXCLUDED.can_delete, can_approve = EXCLUDED.can_approve,
+			actions = EXCLUDED.actions, conditions = EXCLUDED.conditions`
 	} else {
 		query += ` ON CONFLICT (unit_role_id, module_id) DO NOTHING`
 	}
@@ -576,3 +635,36 @@ func (r *repository) GetUnitRoleInfo(unitID int64) ([]map[string]interface{}, er
 
 	return results, nil
 }
+
+// GetDeclaredActions returns, per module, the actions the modules declare beyond the standard ones
+func (r *repository) GetDeclaredActions(moduleIDs []int64) (map[int64][]string, error) {
+	declared := make(map[int64][]string)
+	if len(moduleIDs) == 0 {
+		return declared, nil
+	}
+
+	idList, err := json.Marshal(moduleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT module_id, action FROM module_actions
+		WHERE module_id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)
+	`, string(idList))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get declared module actions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var moduleID int64
+		var action string
+		if err := rows.Scan(&moduleID, &action); err != nil {
+			return nil, fmt.Errorf("failed to scan module action: %w", err)
+		}
+		declared[moduleID] = append(declared[moduleID], action)
+	}
+
+	return declared, rows.Err()
+}
@@ -246,15 +246,17 @@ func (h *Handler) DeleteUnit(c *gin.Context) {
 
 // AssignRoleToUnit godoc
 // @Summary      Assign role to unit
-// @Description  Menugaskan role ke unit
+// @Description  Menugaskan role ke unit. Body opsional valid_from/valid_until membatasi masa berlaku assignment. Assignment yang membuat user unit melanggar aturan separation of duties ditolak, kecuali SOD_MODE=override dan justification diisi
 // @Tags         Units
 // @Accept       json
 // @Produce      json
-// @Param        id       path      int  true  "Unit ID"
-// @Param        role_id  path      int  true  "Role ID"
+// @Param        id       path      int                         true   "Unit ID"
+// @Param        role_id  path      int                         true   "Role ID"
+// @Param        request  body      unit.AssignUnitRoleRequest  false  "Masa berlaku assignment"
 // @Success      200      {object}  response.Response  "Role berhasil ditugaskan ke unit"
 // @Failure      400      {object}  response.Response  "Bad request - Invalid ID"
 // @Failure      404      {object}  response.Response  "Unit atau role tidak ditemukan"
+// @Failure      422      {object}  response.Response  "Melanggar aturan separation of duties"
 // @Failure      500      {object}  response.Response  "Internal server error"
 // @Router       /api/v1/units/{id}/roles/{role_id} [post]
 // @Security     BearerAuth
@@ -271,7 +273,21 @@ func (h *Handler) AssignRoleToUnit(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.AssignRoleToUnit(unitID, roleID); err != nil {
+	// The body is optional; without one the assignment does not expire
+	req := &AssignUnitRoleRequest{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(req); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err.Error())
+			return
+		}
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.AssignRoleToUnit(userID, unitID, roleID, req); err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to assign role", err.Error())
 		return
 	}
@@ -306,7 +322,12 @@ func (h *Handler) RemoveRoleFromUnit(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.RemoveRoleFromUnit(unitID, roleID); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveRoleFromUnit(userID, unitID, roleID); err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to remove role", err.Error())
 		return
 	}
@@ -381,7 +402,7 @@ func (h *Handler) GetUnitPermissions(c *gin.Context) {
 
 // UpdateUnitPermissions godoc
 // @Summary      Update unit role permissions
-// @Description  Memperbarui permissions/modules untuk unit role
+// @Description  Memperbarui permissions/modules untuk unit role. Perubahan yang membuat user unit melanggar aturan separation of duties ditolak, kecuali SOD_MODE=override dan justification diisi
 // @Tags         Unit Roles
 // @Accept       json
 // @Produce      json
@@ -390,6 +411,7 @@ func (h *Handler) GetUnitPermissions(c *gin.Context) {
 // @Success      200           {object}  response.Response  "Permissions berhasil diupdate"
 // @Failure      400           {object}  response.Response  "Bad request - Invalid unit role ID atau validation failed"
 // @Failure      404           {object}  response.Response  "Unit role tidak ditemukan"
+// @Failure      422           {object}  response.Response  "Melanggar aturan separation of duties"
 // @Failure      500           {object}  response.Response  "Internal server error"
 // @Router       /api/v1/unit-roles/{unit_role_id}/permissions [put]
 // @Security     BearerAuth
@@ -412,7 +434,12 @@ func (h *Handler) UpdateUnitPermissions(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateUnitPermissions(unitRoleID, req); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.UpdateUnitPermissions(userID, unitRoleID, req); err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to update permissions", err.Error())
 		return
 	}
@@ -420,9 +447,51 @@ func (h *Handler) UpdateUnitPermissions(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Permissions successfully updated", nil)
 }
 
+// SimulateUnitPermissions godoc
+// @Summary      Simulate unit role permissions update
+// @Description  Dry-run dari update permissions unit role tanpa menyimpan perubahan: mengembalikan setiap user di unit dan unit turunannya yang effective permissions-nya berubah, dengan before/after per module serta ringkasan jumlah user yang mendapat atau kehilangan tiap action. Module yang tidak ada di request tetap seperti semula, sama seperti update
+// @Tags         Unit Roles
+// @Accept       json
+// @Produce      json
+// @Param        unit_role_id  path      int                                     true  "Unit Role ID"
+// @Param        permissions   body      unit.BulkUpdateUnitRoleModulesRequest   true  "Permissions yang diusulkan"
+// @Success      200           {object}  response.Response{data=rbac.PermissionSimulation}  "Simulasi berhasil"
+// @Failure      400           {object}  response.Response  "Bad request - Invalid unit role ID atau validation failed"
+// @Failure      404           {object}  response.Response  "Unit role tidak ditemukan"
+// @Failure      500           {object}  response.Response  "Internal server error"
+// @Router       /api/v1/unit-roles/{unit_role_id}/permissions/simulate [post]
+// @Security     BearerAuth
+func (h *Handler) SimulateUnitPermissions(c *gin.Context) {
+	unitRoleID, err := strconv.ParseInt(c.Param("unit_role_id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, constants.MsgInvalidID, "Invalid unit role ID")
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*BulkUpdateUnitRoleModulesRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Invalid request format", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.SimulateUnitPermissions(unitRoleID, req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Failed to simulate permissions", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgPermissionsSimulated, result)
+}
+
 // CopyPermissions godoc
 // @Summary      Copy permissions between units
-// @Description  Menyalin permissions dari satu unit ke unit lain
+// @Description  Menyalin permissions dari satu unit ke unit lain. Salinan yang membuat user unit melanggar aturan separation of duties ditolak, kecuali SOD_MODE=override dan justification diisi
 // @Tags         Units
 // @Accept       json
 // @Produce      json
@@ -430,6 +499,7 @@ func (h *Handler) UpdateUnitPermissions(c *gin.Context) {
 // @Success      200   {object}  response.Response  "Permissions berhasil disalin"
 // @Failure      400   {object}  response.Response  "Bad request - validation failed"
 // @Failure      404   {object}  response.Response  "Unit tidak ditemukan"
+// @Failure      422   {object}  response.Response  "Melanggar aturan separation of duties"
 // @Failure      500   {object}  response.Response  "Internal server error"
 // @Router       /api/v1/units/copy-permissions [post]
 // @Security     BearerAuth
@@ -446,7 +516,12 @@ func (h *Handler) CopyPermissions(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.CopyPermissions(req); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.CopyPermissions(userID, req); err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to copy permissions", err.Error())
 		return
 	}
@@ -456,7 +531,7 @@ func (h *Handler) CopyPermissions(c *gin.Context) {
 
 // CopyUnitRolePermissions godoc
 // @Summary      Copy permissions between unit roles
-// @Description  Menyalin permissions dari satu unit role ke unit role lain
+// @Description  Menyalin permissions dari satu unit role ke unit role lain. Salinan yang membuat user unit melanggar aturan separation of duties ditolak, kecuali SOD_MODE=override dan justification diisi
 // @Tags         Units
 // @Accept       json
 // @Produce      json
@@ -464,6 +539,7 @@ func (h *Handler) CopyPermissions(c *gin.Context) {
 // @Success      200   {object}  response.Response  "Permissions berhasil disalin"
 // @Failure      400   {object}  response.Response  "Bad request - validation failed"
 // @Failure      404   {object}  response.Response  "Unit role tidak ditemukan"
+// @Failure      422   {object}  response.Response  "Melanggar aturan separation of duties"
 // @Failure      500   {object}  response.Response  "Internal server error"
 // @Router       /api/v1/units/copy-unit-role-permissions [post]
 // @Security     BearerAuth
@@ -480,7 +556,12 @@ func (h *Handler) CopyUnitRolePermissions(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.CopyUnitRolePermissions(req); err != nil {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.CopyUnitRolePermissions(userID, req); err != nil {
 		response.ErrorWithAutoStatus(c, "Failed to copy permissions", err.Error())
 		return
 	}
@@ -546,6 +627,22 @@ func (h *Handler) GetUnitRoleInfo(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Unit role information retrieved", result)
 }
 
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
 // Route registration
 func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
 	// Unit CRUD routes
@@ -610,6 +707,14 @@ func RegisterRoutes(router *gin.RouterGroup, handler *Handler) {
 			}),
 			handler.UpdateUnitPermissions,
 		)
+
+		// POST /api/v1/unit-roles/:unit_role_id/permissions/simulate - Preview a unit role permissions update
+		unitRoles.POST("/:unit_role_id/permissions/simulate",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &BulkUpdateUnitRoleModulesRequest{},
+			}),
+			handler.SimulateUnitPermissions,
+		)
 	}
 
 	// Permission management
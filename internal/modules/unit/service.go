@@ -2,15 +2,24 @@ package unit
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
+
+	"gin-scalable-api/pkg/audittrail"
+	"gin-scalable-api/pkg/rbac"
 )
 
 type Service struct {
-	repo Repository
+	repo            Repository
+	permissionCache *rbac.PermissionCache
+	sodGuard        *rbac.SoDGuard
+	simulator       *rbac.PermissionSimulator
+	auditRecorder   audittrail.Recorder
 }
 
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repository, permissionCache *rbac.PermissionCache, sodGuard *rbac.SoDGuard, simulator *rbac.PermissionSimulator, auditRecorder audittrail.Recorder) *Service {
+	return &Service{repo: repo, permissionCache: permissionCache, sodGuard: sodGuard, simulator: simulator, auditRecorder: auditRecorder}
 }
 
 func (s *Service) GetUnits(req *UnitListRequest) (*UnitListResponse, error) {
@@ -157,20 +166,100 @@ func (s *Service) UpdateUnit(id int64, req *UpdateUnitRequest) (*UnitResponse, e
 		return nil, err
 	}
 
+	// Parent and active changes alter the unit hierarchy seen by assigned users
+	if err := s.permissionCache.InvalidateUnit(id); err != nil {
+		return nil, err
+	}
+
 	unitWithBranch, _ := s.repo.GetByID(id)
 	return toUnitResponse(unitWithBranch), nil
 }
 
 func (s *Service) DeleteUnit(id int64) error {
-	return s.repo.Delete(id)
+	// Resolve affected users before the unit subtree disappears
+	userIDs, err := s.permissionCache.UnitUserIDs(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	return s.permissionCache.InvalidateUsers(userIDs...)
 }
 
-func (s *Service) AssignRoleToUnit(unitID int64, roleID int64) error {
-	return s.repo.AssignRole(unitID, roleID)
+// AssignRoleToUnit assigns a role to a unit on behalf of actorID
+func (s *Service) AssignRoleToUnit(actorID, unitID, roleID int64, req *AssignUnitRoleRequest) error {
+	if req.ValidUntil != nil {
+		if !req.ValidUntil.After(time.Now()) {
+			return fmt.Errorf("valid_until harus di masa depan (invalid)")
+		}
+		if req.ValidFrom != nil && !req.ValidUntil.After(*req.ValidFrom) {
+			return fmt.Errorf("valid_until harus setelah valid_from (invalid)")
+		}
+	}
+
+	override, err := s.sodGuard.CheckUnitRoleAssignment(rbac.SoDChange{ActorID: actorID, Action: "unit_role_assigned",
+		Justification: req.Justification}, unitID, roleID)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.unitRolesSnapshot(unitID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.AssignRole(unitID, roleID, req.ValidFrom, req.ValidUntil); err != nil {
+		return err
+	}
+	s.sodGuard.Record(override)
+
+	after, err := s.unitRolesSnapshot(unitID)
+	if err != nil {
+		return err
+	}
+	audittrail.RecordChange(s.auditRecorder, actorID, "unit_role_assigned", "unit", unitID, before, after)
+
+	return s.permissionCache.InvalidateUnit(unitID)
+}
+
+// RemoveRoleFromUnit removes a role from a unit on behalf of actorID
+func (s *Service) RemoveRoleFromUnit(actorID, unitID, roleID int64) error {
+	before, err := s.unitRolesSnapshot(unitID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.RemoveRole(unitID, roleID); err != nil {
+		return err
+	}
+
+	after, err := s.unitRolesSnapshot(unitID)
+	if err != nil {
+		return err
+	}
+	audittrail.RecordChange(s.auditRecorder, actorID, "unit_role_removed", "unit", unitID, before, after)
+
+	return s.permissionCache.InvalidateUnit(unitID)
 }
 
-func (s *Service) RemoveRoleFromUnit(unitID int64, roleID int64) error {
-	return s.repo.RemoveRole(unitID, roleID)
+// unitRolesSnapshot is the audited role set of a unit, keyed by role ID with the validity window
+func (s *Service) unitRolesSnapshot(unitID int64) (map[string]interface{}, error) {
+	roles, err := s.repo.GetUnitRoles(unitID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]interface{}, len(roles))
+	for _, role := range roles {
+		snapshot[strconv.FormatInt(role.RoleID, 10)] = map[string]interface{}{
+			"valid_from":  role.ValidFrom,
+			"valid_until": role.ValidUntil,
+		}
+	}
+	return map[string]interface{}{"roles": snapshot}, nil
 }
 
 func (s *Service) GetUnitRoles(unitID int64) ([]*UnitRoleResponse, error) {
@@ -201,16 +290,231 @@ func (s *Service) GetUnitPermissions(unitID int64, roleID int64) ([]*UnitRoleMod
 	return responses, nil
 }
 
-func (s *Service) UpdateUnitPermissions(unitRoleID int64, req *BulkUpdateUnitRoleModulesRequest) error {
-	return s.repo.UpdatePermissions(unitRoleID, req.Modules)
+func (s *Service) UpdateUnitPermissions(actorID, unitRoleID int64, req *BulkUpdateUnitRoleModulesRequest) error {
+	current, err := s.repo.GetUnitRoleModules(unitRoleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.normalizeGrants(req.Modules); err != nil {
+		return err
+	}
+
+	grants := make([]rbac.SoDGrant, 0, len(req.Modules))
+	for _, module := range req.Modules {
+		grants = append(grants, rbac.SoDGrant{UnitRoleID: unitRoleID, ModuleID: module.ModuleID, Actions: module.Actions})
+	}
+	override, err := s.sodGuard.CheckUnitRoleGrants(rbac.SoDChange{ActorID: actorID, Action: "unit_role_permissions_updated",
+		Justification: req.Justification}, unitRoleID, grants)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdatePermissions(unitRoleID, req.Modules); err != nil {
+		return err
+	}
+	s.sodGuard.Record(override)
+
+	// Modules missing from the request keep their permissions
+	before := unitPermissionSnapshot(current)
+	after := unitPermissionSnapshot(current)
+	for _, module := range req.Modules {
+		after[strconv.FormatInt(module.ModuleID, 10)] = map[string]interface{}{
+			"can_read":    module.CanRead,
+			"can_write":   module.CanWrite,
+			"can_delete":  module.CanDelete,
+			"can_approve": module.CanApprove,
+			"actions":     rbac.Actions(module.Actions),
+			"conditions":  rbac.Conditions(module.Conditions),
+		}
+	}
+	audittrail.RecordChange(s.auditRecorder, actorID, "unit_role_permissions_updated", "unit_role", unitRoleID,
+		map[string]interface{}{"modules": before}, map[string]interface{}{"modules": after})
+
+	return s.permissionCache.InvalidateUnitRole(unitRoleID)
 }
 
-func (s *Service) CopyPermissions(req *CopyUnitPermissionsRequest) error {
-	return s.repo.CopyPermissions(req.SourceUnitID, req.TargetUnitID, req.RoleID, req.OverwriteExisting)
+// SimulateUnitPermissions previews UpdateUnitPermissions without saving it: every user of
+// the unit and its descendants whose effective permissions would change, with the before
+// and after per module
+func (s *Service) SimulateUnitPermissions(unitRoleID int64, req *BulkUpdateUnitRoleModulesRequest) (*rbac.PermissionSimulation, error) {
+	if err := s.normalizeGrants(req.Modules); err != nil {
+		return nil, err
+	}
+
+	grants := make([]rbac.SimulatedGrant, 0, len(req.Modules))
+	for _, module := range req.Modules {
+		grants = append(grants, rbac.SimulatedGrant{ModuleID: module.ModuleID, Actions: module.Actions, Conditions: module.Conditions})
+	}
+	return s.simulator.SimulateUnitRoleGrants(unitRoleID, grants)
 }
 
-func (s *Service) CopyUnitRolePermissions(req *CopyUnitRolePermissionsRequest) error {
-	return s.repo.CopyUnitRolePermissions(req.SourceUnitRoleID, req.TargetUnitRoleID, req.OverwriteExisting)
+// normalizeGrants combines the standard flags and the further actions of each requested grant,
+// and rejects actions the modules do not declare and invalid conditions
+func (s *Service) normalizeGrants(modules []UpdateUnitRoleModulePermission) error {
+	moduleIDs := make([]int64, 0, len(modules))
+	for _, module := range modules {
+		moduleIDs = append(moduleIDs, module.ModuleID)
+	}
+	declared, err := s.repo.GetDeclaredActions(moduleIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range modules {
+		module := &modules[i]
+		granted := rbac.GrantedActions(module.CanRead, module.CanWrite, module.CanDelete, module.CanApprove, module.Actions)
+		for _, action := range granted {
+			if !rbac.IsStandardAction(action) && !contains(declared[module.ModuleID], action) {
+				return fmt.Errorf("action %q tidak dideklarasikan oleh module %d (invalid)", action, module.ModuleID)
+			}
+		}
+		if err := rbac.Conditions(module.Conditions).Validate(); err != nil {
+			return fmt.Errorf("kondisi module %d tidak valid (invalid): %v", module.ModuleID, err)
+		}
+
+		module.CanRead = granted.Has("read")
+		module.CanWrite = granted.Has("write")
+		module.CanDelete = granted.Has("delete")
+		module.CanApprove = granted.Has("approve")
+		module.Actions = granted
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// unitPermissionSnapshot keys the permission bits of a unit role by module ID, so audit
+// diffs name the module and bit that changed
+func unitPermissionSnapshot(modules []*UnitRoleModule) map[string]interface{} {
+	snapshot := make(map[string]interface{}, len(modules))
+	for _, module := range modules {
+		snapshot[strconv.FormatInt(module.ModuleID, 10)] = map[string]interface{}{
+			"can_read":    module.CanRead,
+			"can_write":   module.CanWrite,
+			"can_delete":  module.CanDelete,
+			"can_approve": module.CanApprove,
+			"actions":     module.Actions,
+			"conditions":  module.Conditions,
+		}
+	}
+	return snapshot
+}
+
+// CopyPermissions copies the grants of a role in one unit to the same role in another unit
+// on behalf of actorID
+func (s *Service) CopyPermissions(actorID int64, req *CopyUnitPermissionsRequest) error {
+	sourceUnitRoleID, err := s.repo.GetUnitRoleID(req.SourceUnitID, req.RoleID)
+	if err != nil {
+		return err
+	}
+	targetUnitRoleID, err := s.repo.GetUnitRoleID(req.TargetUnitID, req.RoleID)
+	if err != nil {
+		return err
+	}
+
+	override, err := s.checkCopy(rbac.SoDChange{ActorID: actorID, Action: "unit_permissions_copied",
+		Justification: req.Justification}, sourceUnitRoleID, targetUnitRoleID, req.OverwriteExisting)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.repo.GetUnitRoleModules(targetUnitRoleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.CopyPermissions(req.SourceUnitID, req.TargetUnitID, req.RoleID, req.OverwriteExisting); err != nil {
+		return err
+	}
+	s.sodGuard.Record(override)
+
+	if err := s.recordCopy(actorID, "unit_permissions_copied", targetUnitRoleID, before); err != nil {
+		return err
+	}
+
+	return s.permissionCache.InvalidateUnit(req.TargetUnitID)
+}
+
+// CopyUnitRolePermissions copies the grants of one unit role to another on behalf of actorID
+func (s *Service) CopyUnitRolePermissions(actorID int64, req *CopyUnitRolePermissionsRequest) error {
+	override, err := s.checkCopy(rbac.SoDChange{ActorID: actorID, Action: "unit_role_permissions_copied",
+		Justification: req.Justification}, req.SourceUnitRoleID, req.TargetUnitRoleID, req.OverwriteExisting)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.repo.GetUnitRoleModules(req.TargetUnitRoleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.CopyUnitRolePermissions(req.SourceUnitRoleID, req.TargetUnitRoleID, req.OverwriteExisting); err != nil {
+		return err
+	}
+	s.sodGuard.Record(override)
+
+	if err := s.recordCopy(actorID, "unit_role_permissions_copied", req.TargetUnitRoleID, before); err != nil {
+		return err
+	}
+
+	return s.permissionCache.InvalidateUnitRole(req.TargetUnitRoleID)
+}
+
+// recordCopy records the grants of the target unit role before and after a copy
+func (s *Service) recordCopy(actorID int64, action string, targetUnitRoleID int64, before []*UnitRoleModule) error {
+	after, err := s.repo.GetUnitRoleModules(targetUnitRoleID)
+	if err != nil {
+		return err
+	}
+
+	audittrail.RecordChange(s.auditRecorder, actorID, action, "unit_role", targetUnitRoleID,
+		map[string]interface{}{"modules": unitPermissionSnapshot(before)}, map[string]interface{}{"modules": unitPermissionSnapshot(after)})
+	return nil
+}
+
+// checkCopy checks the grants a copy writes into the target unit role against the
+// separation-of-duties rules
+func (s *Service) checkCopy(change rbac.SoDChange, sourceUnitRoleID, targetUnitRoleID int64, overwrite bool) (*rbac.SoDOverride, error) {
+	source, err := s.repo.GetUnitRoleModules(sourceUnitRoleID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.repo.GetUnitRoleModules(targetUnitRoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := copiedModules(source, target, overwrite)
+	grants := make([]rbac.SoDGrant, 0, len(copied))
+	for _, module := range copied {
+		grants = append(grants, rbac.SoDGrant{UnitRoleID: targetUnitRoleID, ModuleID: module.ModuleID, Actions: module.Actions})
+	}
+	return s.sodGuard.CheckUnitRoleGrants(change, targetUnitRoleID, grants)
+}
+
+// copiedModules returns the source grants a copy writes into the target: all of them with
+// overwrite, otherwise those for modules the target has no grant for
+func copiedModules(source, target []*UnitRoleModule, overwrite bool) []*UnitRoleModule {
+	existing := make(map[int64]bool, len(target))
+	for _, module := range target {
+		existing[module.ModuleID] = true
+	}
+
+	var copied []*UnitRoleModule
+	for _, module := range source {
+		if overwrite || !existing[module.ModuleID] {
+			copied = append(copied, module)
+		}
+	}
+	return copied
 }
 
 func (s *Service) GetUserEffectivePermissions(userID int64) ([]*UnitRoleModuleResponse, error) {
@@ -255,19 +559,29 @@ func toUnitResponse(unit *UnitWithBranch) *UnitResponse {
 	}
 }
 
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
+
 func toUnitRoleResponse(role *UnitRole) *UnitRoleResponse {
 	if role == nil {
 		return nil
 	}
 
 	return &UnitRoleResponse{
-		ID:        role.ID,
-		UnitID:    role.UnitID,
-		RoleID:    role.RoleID,
-		CreatedAt: role.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: role.UpdatedAt.Format(time.RFC3339),
-		UnitName:  role.UnitName,
-		RoleName:  role.RoleName,
+		ID:         role.ID,
+		UnitID:     role.UnitID,
+		RoleID:     role.RoleID,
+		ValidFrom:  formatOptionalTime(role.ValidFrom),
+		ValidUntil: formatOptionalTime(role.ValidUntil),
+		CreatedAt:  role.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  role.UpdatedAt.Format(time.RFC3339),
+		UnitName:   role.UnitName,
+		RoleName:   role.RoleName,
 	}
 }
 
@@ -284,6 +598,8 @@ func toUnitRoleModuleResponse(perm *UnitRoleModule) *UnitRoleModuleResponse {
 		CanWrite:   perm.CanWrite,
 		CanDelete:  perm.CanDelete,
 		CanApprove: perm.CanApprove,
+		Actions:    perm.Actions,
+		Conditions: perm.Conditions,
 		CreatedAt:  perm.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:  perm.UpdatedAt.Format(time.RFC3339),
 	}
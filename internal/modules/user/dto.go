@@ -31,6 +31,38 @@ type AccessCheckRequest struct {
 	ModuleURL    string `json:"module_url" validate:"required"`
 }
 
+// BatchAccessCheckRequest DTO; UserID checks on behalf of another user (super admins and service accounts)
+type BatchAccessCheckRequest struct {
+	UserID *int64            `json:"user_id" validate:"omitempty,min=1"`
+	Checks []AccessCheckItem `json:"checks" validate:"required,min=1,max=100,dive"`
+}
+
+// AccessCheckItem identifies a module by module_id or module_url; action defaults to read.
+// Context carries the request attributes conditional grants are evaluated against.
+type AccessCheckItem struct {
+	ModuleID  int64                  `json:"module_id" validate:"omitempty,min=1"`
+	ModuleURL string                 `json:"module_url" validate:"omitempty,max=255"`
+	Action    string                 `json:"action" validate:"omitempty,max=50"`
+	UnitID    *int64                 `json:"unit_id" validate:"omitempty,min=1"`
+	Context   map[string]interface{} `json:"context"`
+}
+
+// BatchAccessCheckResponse DTO; results are in the order of the checks
+type BatchAccessCheckResponse struct {
+	UserID  int64               `json:"user_id"`
+	Results []AccessCheckResult `json:"results"`
+}
+
+// AccessCheckResult DTO
+type AccessCheckResult struct {
+	ModuleID  int64  `json:"module_id"`
+	ModuleURL string `json:"module_url,omitempty"`
+	Action    string `json:"action"`
+	UnitID    *int64 `json:"unit_id,omitempty"`
+	Allowed   bool   `json:"allowed"`
+	Error     string `json:"error,omitempty"`
+}
+
 // UserListRequest DTO
 type UserListRequest struct {
 	Limit    int    `form:"limit"`
@@ -80,6 +112,20 @@ type UserListResponse struct {
 	HasMore bool            `json:"has_more"`
 }
 
+// LockoutStatusResponse DTO
+type LockoutStatusResponse struct {
+	UserID            int64 `json:"user_id"`
+	Locked            bool  `json:"locked"`
+	RetryAfterSeconds int   `json:"retry_after_seconds"`
+}
+
+// ExplainPermissionRequest DTO
+type ExplainPermissionRequest struct {
+	ModuleID int64  `form:"module_id"`
+	Action   string `form:"action"`
+	UnitID   *int64 `form:"unit_id"`
+}
+
 // Validation functions
 var validate *validator.Validate
 
@@ -2,6 +2,7 @@ package user
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	// removed - using local model
 	"gin-scalable-api/pkg/model"
@@ -294,7 +295,7 @@ func (r *UserRepository) GetUserRoles(userID int64) ([]*UserRole, error) {
 func (r *UserRepository) GetUserModulesWithSubscription(userID int64) ([]string, error) {
 	// First, get user's company ID
 	var companyID int64
-	err := r.db.QueryRow("SELECT company_id FROM user_roles WHERE user_id = $1 LIMIT 1", userID).Scan(&companyID)
+	err := r.db.QueryRow("SELECT company_id FROM active_user_roles WHERE user_id = $1 LIMIT 1", userID).Scan(&companyID)
 	if err != nil {
 		return r.getUserBasicModules(userID)
 	}
@@ -302,8 +303,9 @@ func (r *UserRepository) GetUserModulesWithSubscription(userID int64) ([]string,
 	// Query modules with subscription filtering
 	query := `
 		SELECT DISTINCT m.url
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		JOIN plan_modules pm ON m.id = pm.module_id AND pm.is_included = true
 		JOIN subscriptions s ON pm.plan_id = s.plan_id
@@ -342,7 +344,7 @@ func (r *UserRepository) GetUserModulesWithSubscription(userID int64) ([]string,
 func (r *UserRepository) GetUserModulesGroupedWithSubscription(userID int64) (map[string][][]string, error) {
 	// First, get user's company ID
 	var companyID int64
-	err := r.db.QueryRow("SELECT company_id FROM user_roles WHERE user_id = $1 LIMIT 1", userID).Scan(&companyID)
+	err := r.db.QueryRow("SELECT company_id FROM active_user_roles WHERE user_id = $1 LIMIT 1", userID).Scan(&companyID)
 	if err != nil {
 		return r.getUserBasicModulesGrouped(userID)
 	}
@@ -351,8 +353,9 @@ func (r *UserRepository) GetUserModulesGroupedWithSubscription(userID int64) (ma
 	query := `
 		SELECT DISTINCT m.name, m.url, m.icon, m.description, m.category, m.parent_id,
 			CASE WHEN m.parent_id IS NULL THEN 0 ELSE 1 END as sort_order
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		JOIN plan_modules pm ON m.id = pm.module_id AND pm.is_included = true
 		JOIN subscriptions s ON pm.plan_id = s.plan_id
@@ -395,8 +398,9 @@ func (r *UserRepository) GetUserModulesGroupedWithSubscription(userID int64) (ma
 func (r *UserRepository) getUserBasicModules(userID int64) ([]string, error) {
 	query := `
 		SELECT DISTINCT m.url
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		WHERE ur.user_id = $1 
 			AND rm.can_read = true
@@ -428,8 +432,9 @@ func (r *UserRepository) getUserBasicModulesGrouped(userID int64) (map[string][]
 	query := `
 		SELECT DISTINCT m.name, m.url, m.icon, m.description, m.category, m.parent_id,
 			CASE WHEN m.parent_id IS NULL THEN 0 ELSE 1 END as sort_order
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		WHERE ur.user_id = $1 
 			AND rm.can_read = true
@@ -773,3 +778,22 @@ func (r *UserRepository) GetAllWithRoles(limit, offset int, search string, isAct
 
 	return users, nil
 }
+
+// CreateAuditLog records an administrative action on a user in audit_logs
+func (r *UserRepository) CreateAuditLog(actorID int64, action string, targetUserID int64, details map[string]interface{}) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_logs (user_id, action, resource, resource_id, details, success, created_at)
+		VALUES ($1, $2, 'user', $3, $4, true, CURRENT_TIMESTAMP)
+	`
+
+	if _, err := r.db.Exec(query, actorID, action, targetUserID, detailsJSON); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
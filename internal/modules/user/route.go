@@ -320,6 +320,46 @@ func (h *Handler) CheckAccess(c *gin.Context) {
 	})
 }
 
+// @Summary      Batch check user access
+// @Description  Memeriksa banyak akses sekaligus (module_id atau module_url, action, unit_id opsional) dengan satu kali pemuatan izin. Super admin dan service account dapat memeriksa atas nama user lain melalui user_id
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        checks  body      user.BatchAccessCheckRequest  true  "Daftar pemeriksaan akses (maksimal 100)"
+// @Success      200     {object}  response.Response{data=user.BatchAccessCheckResponse}  "Pemeriksaan akses berhasil"
+// @Failure      400     {object}  response.Response  "Bad request - validation failed"
+// @Failure      401     {object}  response.Response  "Unauthorized"
+// @Failure      403     {object}  response.Response  "Forbidden - pemeriksaan atas nama user lain"
+// @Failure      404     {object}  response.Response  "User tidak ditemukan"
+// @Router       /api/v1/users/check-access/batch [post]
+// @Security     BearerAuth
+func (h *Handler) CheckAccessBatch(c *gin.Context) {
+	requestingUserID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	validatedBody, exists := c.Get("validated_body")
+	if !exists {
+		response.Error(c, http.StatusBadRequest, "Bad request", "validation failed")
+		return
+	}
+
+	req, ok := validatedBody.(*BatchAccessCheckRequest)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "Bad request", "invalid body structure")
+		return
+	}
+
+	result, err := h.service.CheckAccessBatch(requestingUserID, c.ClientIP(), req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgAccessChecked, result)
+}
+
 func (h *Handler) checkUserModuleAccess(userID int64, moduleURL string) (bool, error) {
 	modules, err := h.userRepo.GetUserModulesWithSubscription(userID)
 	if err != nil {
@@ -374,6 +414,130 @@ func (h *Handler) ChangeUserPassword(c *gin.Context) {
 	response.Success(c, http.StatusOK, constants.MsgPasswordChanged, nil)
 }
 
+// @Summary      Get login lockout status
+// @Description  Mendapatkan status penguncian login user akibat percobaan login gagal berulang (khusus super admin)
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  response.Response{data=user.LockoutStatusResponse}  "Status penguncian berhasil diambil"
+// @Failure      400  {object}  response.Response  "Bad request - Invalid user ID"
+// @Failure      403  {object}  response.Response  "Forbidden - hanya super admin"
+// @Failure      404  {object}  response.Response  "User tidak ditemukan"
+// @Router       /api/v1/users/{id}/lockout [get]
+// @Security     BearerAuth
+func (h *Handler) GetLockoutStatus(c *gin.Context) {
+	requestingUserID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid user ID")
+		return
+	}
+
+	status, err := h.service.GetLockoutStatus(requestingUserID, id)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgLockoutRetrieved, status)
+}
+
+// @Summary      Unlock user login
+// @Description  Membuka penguncian login user dan menghapus hitungan percobaan login gagal (khusus super admin)
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  response.Response{data=user.LockoutStatusResponse}  "User berhasil dibuka kuncinya"
+// @Failure      400  {object}  response.Response  "Bad request - Invalid user ID"
+// @Failure      403  {object}  response.Response  "Forbidden - hanya super admin"
+// @Failure      404  {object}  response.Response  "User tidak ditemukan"
+// @Router       /api/v1/users/{id}/unlock [post]
+// @Security     BearerAuth
+func (h *Handler) UnlockUser(c *gin.Context) {
+	requestingUserID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid user ID")
+		return
+	}
+
+	status, err := h.service.UnlockUser(requestingUserID, id, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgUserUnlocked, status)
+}
+
+// @Summary      Explain permission
+// @Description  Menjelaskan apakah user dapat melakukan aksi pada module beserta jejak keputusannya: role dan unit role yang berkontribusi, paket langganan (plan_modules), status module, tier langganan, dan fallback ke tier basic. User dapat melihat izinnya sendiri; super admin dapat melihat izin semua user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        id         path      int     true   "User ID"
+// @Param        module_id  query     int     true   "Module ID"
+// @Param        action     query     string  false  "Aksi: read, write, delete, approve atau action yang dideklarasikan module (default read)"
+// @Param        unit_id    query     int     false  "Unit ID untuk pemeriksaan berbasis unit"
+// @Success      200  {object}  response.Response{data=rbac.PermissionExplanation}  "Penjelasan izin berhasil diambil"
+// @Failure      400  {object}  response.Response  "Bad request - parameter tidak valid"
+// @Failure      403  {object}  response.Response  "Forbidden - hanya super admin untuk user lain"
+// @Failure      404  {object}  response.Response  "User tidak ditemukan"
+// @Router       /api/v1/users/{id}/permissions/explain [get]
+// @Security     BearerAuth
+func (h *Handler) ExplainPermission(c *gin.Context) {
+	requestingUserID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", "Invalid user ID")
+		return
+	}
+
+	var req ExplainPermissionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Bad request", err.Error())
+		return
+	}
+
+	explanation, err := h.service.ExplainPermission(requestingUserID, id, &req)
+	if err != nil {
+		response.ErrorWithAutoStatus(c, "Operation failed", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, constants.MsgPermissionExplained, explanation)
+}
+
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found in context")
+		return 0, false
+	}
+
+	userIDInt64, ok := userID.(int64)
+	if !ok {
+		response.Error(c, http.StatusInternalServerError, "Internal error", "Invalid user ID type")
+		return 0, false
+	}
+
+	return userIDInt64, true
+}
+
 // Route registration
 func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 	users := api.Group("/users")
@@ -417,6 +581,14 @@ func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 			handler.CheckAccess,
 		)
 
+		// POST /api/v1/users/check-access/batch - Check many module permissions in one call, optionally on behalf of another user
+		users.POST("/check-access/batch",
+			middleware.ValidateRequest(middleware.ValidationRules{
+				Body: &BatchAccessCheckRequest{},
+			}),
+			handler.CheckAccessBatch,
+		)
+
 		// PUT /api/v1/users/:id/password - Change user password with validation
 		users.PUT("/:id/password",
 			middleware.ValidateRequest(middleware.ValidationRules{
@@ -424,5 +596,14 @@ func RegisterRoutes(api *gin.RouterGroup, handler *Handler) {
 			}),
 			handler.ChangeUserPassword,
 		)
+
+		// GET /api/v1/users/:id/lockout - Get login lockout status (super admin)
+		users.GET("/:id/lockout", handler.GetLockoutStatus)
+
+		// POST /api/v1/users/:id/unlock - Lift login lockout and clear failed attempts (super admin)
+		users.POST("/:id/unlock", handler.UnlockUser)
+
+		// GET /api/v1/users/:id/permissions/explain - Explain a permission decision with its trace
+		users.GET("/:id/permissions/explain", handler.ExplainPermission)
 	}
 }
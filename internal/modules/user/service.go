@@ -2,20 +2,28 @@ package user
 
 import (
 	"fmt"
+	"gin-scalable-api/internal/constants"
+	"gin-scalable-api/pkg/lockout"
+	"gin-scalable-api/pkg/logger"
 	"gin-scalable-api/pkg/password"
 	"gin-scalable-api/pkg/rbac"
+	"math"
 	"time"
 )
 
 type Service struct {
-	userRepo    *UserRepository
-	rbacService *rbac.RBACService
+	userRepo      *UserRepository
+	rbacService   *rbac.RBACService
+	loginGuard    *lockout.Guard
+	passwordStore *password.Store
 }
 
-func NewService(userRepo *UserRepository, rbacService *rbac.RBACService) *Service {
+func NewService(userRepo *UserRepository, rbacService *rbac.RBACService, loginGuard *lockout.Guard, passwordStore *password.Store) *Service {
 	return &Service{
-		userRepo:    userRepo,
-		rbacService: rbacService,
+		userRepo:      userRepo,
+		rbacService:   rbacService,
+		loginGuard:    loginGuard,
+		passwordStore: passwordStore,
 	}
 }
 
@@ -108,19 +116,28 @@ func (s *Service) GetUserWithRoles(id int64) (*UserWithRolesResponse, error) {
 }
 
 func (s *Service) CreateUser(req *CreateUserRequest) (*UserResponse, error) {
-	hashedPassword := ""
-	if req.Password != "" {
-		hash, err := password.HashPassword(req.Password)
-		if err != nil {
-			return nil, err
+	// A new user has no company yet, so the default policy applies
+	policy := s.passwordStore.BasePolicy()
+
+	plainPassword := req.Password
+	mustChange := false
+	if plainPassword != "" {
+		info := password.PersonalInfo{Name: req.Name, Email: req.Email}
+		if req.UserIdentity != nil {
+			info.UserIdentity = *req.UserIdentity
 		}
-		hashedPassword = hash
-	} else {
-		hash, err := password.HashPassword("password123")
-		if err != nil {
+		if err := policy.Validate(plainPassword, info); err != nil {
 			return nil, err
 		}
-		hashedPassword = hash
+	} else {
+		// Users created without a password must replace the default one at their first login
+		plainPassword = "password123"
+		mustChange = true
+	}
+
+	hashedPassword, err := password.HashPassword(plainPassword)
+	if err != nil {
+		return nil, err
 	}
 
 	user := &User{
@@ -135,6 +152,11 @@ func (s *Service) CreateUser(req *CreateUserRequest) (*UserResponse, error) {
 		return nil, err
 	}
 
+	// Start the password history and expiry clock of the new user
+	if err := s.passwordStore.SetPassword(user.ID, hashedPassword, policy.HistoryCount, mustChange); err != nil {
+		return nil, err
+	}
+
 	return toUserResponse(user), nil
 }
 
@@ -186,13 +208,25 @@ func (s *Service) ChangePassword(userID int64, req *ChangePasswordRequest) error
 		return fmt.Errorf("password saat ini salah")
 	}
 
+	policy, err := s.passwordStore.CheckNewPassword(user.ID, req.NewPassword, personalInfo(user))
+	if err != nil {
+		return err
+	}
+
 	hashedPassword, err := password.HashPassword(req.NewPassword)
 	if err != nil {
 		return err
 	}
 
-	user.PasswordHash = hashedPassword
-	return s.userRepo.Update(user)
+	return s.passwordStore.SetPassword(user.ID, hashedPassword, policy.HistoryCount, false)
+}
+
+func personalInfo(user *User) password.PersonalInfo {
+	info := password.PersonalInfo{Name: user.Name, Email: user.Email}
+	if user.UserIdentity != nil {
+		info.UserIdentity = *user.UserIdentity
+	}
+	return info
 }
 
 func (s *Service) ChangeUserPassword(userID int64, req *ChangePasswordRequest) error {
@@ -229,6 +263,176 @@ func (s *Service) GetUsersWithRoles(req *UserListRequest) (interface{}, error) {
 	}, nil
 }
 
+// GetLockoutStatus reports whether a user is locked out of login after failed attempts
+func (s *Service) GetLockoutStatus(requestingUserID, userID int64) (*LockoutStatusResponse, error) {
+	if err := s.requireSuperAdmin(requestingUserID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, err
+	}
+
+	remaining, err := s.loginGuard.AccountLockedFor(userID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca status penguncian: %w", err)
+	}
+
+	return &LockoutStatusResponse{
+		UserID:            userID,
+		Locked:            remaining > 0,
+		RetryAfterSeconds: int(math.Ceil(remaining.Seconds())),
+	}, nil
+}
+
+// UnlockUser lifts a login lockout and clears the failed attempts of a user
+func (s *Service) UnlockUser(requestingUserID, userID int64, ip, userAgent string) (*LockoutStatusResponse, error) {
+	if err := s.requireSuperAdmin(requestingUserID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, err
+	}
+
+	wasLocked, err := s.loginGuard.Unlock(userID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka kunci pengguna: %w", err)
+	}
+
+	details := map[string]interface{}{
+		"method":      "POST",
+		"url":         fmt.Sprintf("/api/v1/users/%d/unlock", userID),
+		"status":      constants.AuditSuccess,
+		"status_code": 200,
+		"message":     "Login lockout lifted by administrator",
+		"was_locked":  wasLocked,
+		"ip":          ip,
+		"user_agent":  userAgent,
+	}
+	if err := s.userRepo.CreateAuditLog(requestingUserID, "account_unlocked", userID, details); err != nil {
+		logger.Error(fmt.Sprintf("Failed to audit unlock of user %d by user %d: %v", userID, requestingUserID, err))
+	}
+
+	return &LockoutStatusResponse{UserID: userID, Locked: false}, nil
+}
+
+// ExplainPermission explains whether a user may perform an action on a module and why.
+// Users can explain their own permissions; super admins can explain anyone's.
+func (s *Service) ExplainPermission(requestingUserID, userID int64, req *ExplainPermissionRequest) (*rbac.PermissionExplanation, error) {
+	if req.ModuleID <= 0 {
+		return nil, fmt.Errorf("module_id wajib diisi (required)")
+	}
+	if req.Action == "" {
+		req.Action = "read"
+	}
+	if !rbac.IsValidAction(req.Action) {
+		return nil, fmt.Errorf("nama action tidak valid (invalid action)")
+	}
+
+	if requestingUserID != userID {
+		isSuperAdmin, err := s.rbacService.IsSuperAdmin(requestingUserID)
+		if err != nil {
+			return nil, fmt.Errorf("gagal memeriksa status super admin: %w", err)
+		}
+		if !isSuperAdmin {
+			return nil, fmt.Errorf("access denied: hanya super admin yang dapat melihat penjelasan izin user lain")
+		}
+	}
+
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, err
+	}
+
+	explanation, err := s.rbacService.ExplainPermission(userID, req.ModuleID, req.Action, req.UnitID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menjelaskan izin: %w", err)
+	}
+	return explanation, nil
+}
+
+// CheckAccessBatch evaluates several access checks for the requesting user, or on behalf of
+// req.UserID when the requester is a super admin or a service account. Checks of the
+// caller's own access are evaluated with the caller's IP as the ip attribute.
+func (s *Service) CheckAccessBatch(requestingUserID int64, clientIP string, req *BatchAccessCheckRequest) (*BatchAccessCheckResponse, error) {
+	userID := requestingUserID
+	if req.UserID != nil && *req.UserID != requestingUserID {
+		isSuperAdmin, err := s.rbacService.IsSuperAdmin(requestingUserID)
+		if err != nil {
+			return nil, fmt.Errorf("gagal memeriksa status super admin: %w", err)
+		}
+		if !isSuperAdmin {
+			isServiceAccount, err := s.rbacService.HasRole(requestingUserID, rbac.RoleServiceAccount)
+			if err != nil {
+				return nil, fmt.Errorf("gagal memeriksa role service account: %w", err)
+			}
+			if !isServiceAccount {
+				return nil, fmt.Errorf("access denied: hanya super admin atau service account yang dapat memeriksa akses user lain")
+			}
+		}
+
+		if _, err := s.userRepo.GetByID(*req.UserID); err != nil {
+			return nil, err
+		}
+		userID = *req.UserID
+	}
+
+	checks := make([]rbac.AccessCheck, len(req.Checks))
+	for i, item := range req.Checks {
+		if (item.ModuleID == 0) == (item.ModuleURL == "") {
+			return nil, fmt.Errorf("check %d harus berisi tepat satu dari module_id atau module_url (required)", i)
+		}
+		if item.Action == "" {
+			req.Checks[i].Action = "read"
+		}
+		attributes := item.Context
+		if userID == requestingUserID {
+			attributes = make(map[string]interface{}, len(item.Context)+1)
+			for key, value := range item.Context {
+				attributes[key] = value
+			}
+			attributes["ip"] = clientIP
+		}
+		checks[i] = rbac.AccessCheck{
+			ModuleID:   item.ModuleID,
+			ModuleURL:  item.ModuleURL,
+			Action:     req.Checks[i].Action,
+			UnitID:     item.UnitID,
+			Attributes: attributes,
+		}
+	}
+
+	decisions, err := s.rbacService.CheckAccessBatch(userID, checks)
+	if err != nil {
+		return nil, fmt.Errorf("gagal memeriksa akses: %w", err)
+	}
+
+	results := make([]AccessCheckResult, len(decisions))
+	for i, decision := range decisions {
+		results[i] = AccessCheckResult{
+			ModuleID:  decision.ModuleID,
+			ModuleURL: req.Checks[i].ModuleURL,
+			Action:    req.Checks[i].Action,
+			UnitID:    req.Checks[i].UnitID,
+			Allowed:   decision.Allowed,
+			Error:     decision.Error,
+		}
+	}
+
+	return &BatchAccessCheckResponse{UserID: userID, Results: results}, nil
+}
+
+func (s *Service) requireSuperAdmin(userID int64) error {
+	isSuperAdmin, err := s.rbacService.IsSuperAdmin(userID)
+	if err != nil {
+		return fmt.Errorf("gagal memeriksa status super admin: %w", err)
+	}
+	if !isSuperAdmin {
+		return fmt.Errorf("access denied: hanya super admin yang dapat mengelola penguncian login")
+	}
+	return nil
+}
+
 // Helper function to convert model to DTO
 func toUserResponse(user *User) *UserResponse {
 	if user == nil {
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"gin-scalable-api/pkg/audittrail"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditTrail records every POST, PUT, PATCH and DELETE request in the audit log.
+// It must run after AuthMiddleware so the user is known. Entries are handed to the
+// queue without blocking, so the request latency is unaffected.
+func AuditTrail(queue *audittrail.Queue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// Unmatched routes have no resource to attribute
+			return
+		}
+
+		params := make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			params[param.Key] = param.Value
+		}
+
+		entry := audittrail.Entry{
+			UserID:     c.GetInt64("user_id"),
+			Action:     audittrail.Action(c.Request.Method, route),
+			Resource:   audittrail.ResourceFromRoute(route),
+			ResourceID: audittrail.ResourceIDFromParams(route, params),
+			Method:     c.Request.Method,
+			URL:        c.Request.URL.Path,
+			Route:      route,
+			Params:     params,
+			StatusCode: c.Writer.Status(),
+			Latency:    time.Since(start),
+			ClientIP:   c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			SessionID:  c.GetString("session_id"),
+			ClientID:   c.GetString("client_id"),
+			CreatedAt:  start,
+		}
+		queue.Enqueue(entry)
+	}
+}
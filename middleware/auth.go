@@ -58,14 +58,52 @@ func AuthMiddleware(jwtSecret string, redis *redis.Client) gin.HandlerFunc {
 			return
 		}
 
+		if !clientTokenAllowed(c, metadata.ClientID) {
+			response.Error(c, http.StatusForbidden, "Forbidden", "access denied: OAuth client tokens may only call userinfo or revoke their own consent")
+			c.Abort()
+			return
+		}
+
 		// Set user context
 		c.Set("user_id", metadata.UserID)
 		c.Set("abilities", metadata.Abilities)
+		c.Set("session_id", metadata.FamilyID)
+		c.Set("client_id", metadata.ClientID)
+		c.Set("scope", metadata.Scope)
+
+		// Record session activity for the session listing
+		if metadata.FamilyID != "" {
+			tokenService.TouchSession(metadata.FamilyID)
+		}
 
 		c.Next()
 	}
 }
 
+// clientTokenRoutes are the routes access tokens issued to OAuth clients may call. The
+// scopes a user consents to cover claims about the user, not the admin API.
+var clientTokenRoutes = map[string]bool{
+	"GET /api/v1/oauth/userinfo":  true,
+	"POST /api/v1/oauth/userinfo": true,
+}
+
+// clientTokenAllowed reports whether the token may call the matched route. First-party
+// tokens may call every route; a client token may call the userinfo endpoint and revoke
+// the consent of its own client.
+func clientTokenAllowed(c *gin.Context, clientID string) bool {
+	return allowsClientToken(c.Request.Method, c.FullPath(), clientID, c.Param("client_id"))
+}
+
+func allowsClientToken(method, route, clientID, routeClientID string) bool {
+	if clientID == "" {
+		return true
+	}
+	if method == http.MethodDelete && route == "/api/v1/oauth/consents/:client_id" {
+		return routeClientID == clientID
+	}
+	return clientTokenRoutes[method+" "+route]
+}
+
 // UnitAwareAuthMiddleware provides enhanced authentication with unit context
 func UnitAwareAuthMiddleware(jwtSecret string, redis *redis.Client, db interface{}) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -105,14 +143,23 @@ func UnitAwareAuthMiddleware(jwtSecret string, redis *redis.Client, db interface
 			return
 		}
 
+		if !clientTokenAllowed(c, metadata.ClientID) {
+			response.Error(c, http.StatusForbidden, "Forbidden", "access denied: OAuth client tokens may only call userinfo or revoke their own consent")
+			c.Abort()
+			return
+		}
+
 		// Set basic user context
 		c.Set("user_id", metadata.UserID)
 		c.Set("abilities", metadata.Abilities)
+		c.Set("session_id", metadata.FamilyID)
+		c.Set("client_id", metadata.ClientID)
+		c.Set("scope", metadata.Scope)
 
 		// Load unit-aware permissions if database connection is available
 		if dbConn, ok := db.(interface{ GetDB() interface{} }); ok {
 			if sqlDB, ok := dbConn.GetDB().(*sql.DB); ok {
-				unitRBACService := rbac.NewUnitRBACService(sqlDB)
+				unitRBACService := rbac.NewCachedUnitRBACService(sqlDB, rbac.NewPermissionCache(redis, sqlDB))
 
 				// Get comprehensive unit permissions
 				unitPermissions, err := unitRBACService.GetUserUnitPermissions(metadata.UserID)
@@ -0,0 +1,29 @@
+package middleware
+
+import "testing"
+
+func TestAllowsClientToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		method, route string
+		clientID      string
+		routeClientID string
+		want          bool
+	}{
+		{"first-party token on admin route", "POST", "/api/v1/role-management/assign-user-role", "", "", true},
+		{"client token on userinfo", "GET", "/api/v1/oauth/userinfo", "crm", "", true},
+		{"client token on userinfo via POST", "POST", "/api/v1/oauth/userinfo", "crm", "", true},
+		{"client token revoking its own consent", "DELETE", "/api/v1/oauth/consents/:client_id", "crm", "crm", true},
+		{"client token revoking another consent", "DELETE", "/api/v1/oauth/consents/:client_id", "crm", "erp", false},
+		{"client token on role assignment", "POST", "/api/v1/role-management/assign-user-role", "crm", "", false},
+		{"client token on user management", "GET", "/api/v1/users", "crm", "", false},
+		{"client token on sessions", "GET", "/api/v1/auth/sessions", "crm", "", false},
+		{"client token approving authorization", "POST", "/api/v1/oauth/authorize", "crm", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := allowsClientToken(tt.method, tt.route, tt.clientID, tt.routeClientID); got != tt.want {
+			t.Errorf("%s: allowsClientToken = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -62,11 +62,12 @@ func UnitAuthMiddleware(jwtSecret string, redis *redis.Client, db interface{}) g
 		// Set basic user context
 		c.Set("user_id", metadata.UserID)
 		c.Set("abilities", metadata.Abilities)
+		c.Set("session_id", metadata.FamilyID)
 
 		// Load unit-aware permissions if database connection is available
 		if dbConn, ok := db.(interface{ GetDB() interface{} }); ok {
 			if sqlDB, ok := dbConn.GetDB().(*sql.DB); ok {
-				unitRBACService := rbac.NewUnitRBACService(sqlDB)
+				unitRBACService := rbac.NewCachedUnitRBACService(sqlDB, rbac.NewPermissionCache(redis, sqlDB))
 
 				// Get comprehensive unit permissions
 				unitPermissions, err := unitRBACService.GetUserUnitPermissions(metadata.UserID)
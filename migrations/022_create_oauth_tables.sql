@@ -0,0 +1,28 @@
+-- OAuth2 / OpenID Connect clients and user consents
+
+-- Every OAuth client belongs to an application; an application may have several
+-- clients, e.g. a web frontend (confidential) and a mobile app (public, PKCE only).
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id BIGSERIAL PRIMARY KEY,
+    application_id BIGINT NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    client_id VARCHAR(100) NOT NULL UNIQUE,
+    client_secret_hash VARCHAR(64),
+    name VARCHAR(100) NOT NULL,
+    redirect_uris JSONB NOT NULL DEFAULT '[]',
+    is_confidential BOOLEAN NOT NULL DEFAULT true,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_clients_application_id ON oauth_clients(application_id);
+
+CREATE TABLE IF NOT EXISTS oauth_consents (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id BIGINT NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    scopes VARCHAR(255) NOT NULL,
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, client_id)
+);
@@ -0,0 +1,29 @@
+-- Password policies, password history and password expiry
+
+-- NULL means the password must be changed at the next login. Existing users get the
+-- time of the migration, so expiry starts counting from now.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS password_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_created ON password_history(user_id, created_at DESC);
+
+-- A company without a row uses the default policy of the service
+CREATE TABLE IF NOT EXISTS password_policies (
+    company_id BIGINT PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+    min_length INT NOT NULL DEFAULT 8 CHECK (min_length BETWEEN 6 AND 72),
+    require_uppercase BOOLEAN NOT NULL DEFAULT true,
+    require_lowercase BOOLEAN NOT NULL DEFAULT true,
+    require_digit BOOLEAN NOT NULL DEFAULT true,
+    require_symbol BOOLEAN NOT NULL DEFAULT false,
+    history_count INT NOT NULL DEFAULT 5 CHECK (history_count BETWEEN 0 AND 24),
+    max_age_days INT NOT NULL DEFAULT 0 CHECK (max_age_days >= 0),
+    updated_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
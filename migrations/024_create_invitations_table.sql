@@ -0,0 +1,30 @@
+-- Invitations of new users into a company, optionally scoped to a branch or unit
+
+CREATE TABLE IF NOT EXISTS invitations (
+    id BIGSERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(100),
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    branch_id BIGINT REFERENCES branches(id) ON DELETE CASCADE,
+    unit_id BIGINT REFERENCES units(id) ON DELETE CASCADE,
+    role_ids JSONB NOT NULL DEFAULT '[]',
+    -- SHA-256 of the token in the invitation link; the token itself is never stored
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'revoked')),
+    invited_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    accepted_user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    send_count INT NOT NULL DEFAULT 1,
+    expires_at TIMESTAMP NOT NULL,
+    last_sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_invitations_company_status ON invitations(company_id, status);
+
+-- At most one pending invitation per email and company
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invitations_pending_email
+    ON invitations(company_id, LOWER(email)) WHERE status = 'pending';
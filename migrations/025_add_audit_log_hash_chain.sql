@@ -0,0 +1,71 @@
+-- Tamper-evident audit log: every new entry stores a SHA-256 hash over its content and
+-- the hash of the previous entry of the same company, forming one chain per company.
+-- Entries without a company form chain 0. The hash is computed by a trigger, so every
+-- writer is covered; pkg/audittrail recomputes it to verify the chain.
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS company_id BIGINT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS chain_seq BIGINT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS hash VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_chain
+    ON audit_logs((COALESCE(company_id, 0)), chain_seq) WHERE chain_seq IS NOT NULL;
+
+-- Length-prefixed encoding keeps field boundaries unambiguous: NULL is "N;",
+-- any other value is "<bytes>:<value>;"
+CREATE OR REPLACE FUNCTION audit_chain_field(value TEXT) RETURNS TEXT AS $$
+    SELECT CASE WHEN value IS NULL THEN 'N;' ELSE octet_length(value)::TEXT || ':' || value || ';' END
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION audit_logs_chain() RETURNS TRIGGER AS $$
+DECLARE
+    chain_key BIGINT;
+    last_seq BIGINT;
+    last_hash TEXT;
+BEGIN
+    -- Entries of users are chained under the company they belong to
+    IF NEW.company_id IS NULL AND NEW.user_id IS NOT NULL THEN
+        SELECT ur.company_id INTO NEW.company_id
+        FROM user_roles ur
+        WHERE ur.user_id = NEW.user_id
+        ORDER BY ur.company_id
+        LIMIT 1;
+    END IF;
+    chain_key := COALESCE(NEW.company_id, 0);
+
+    -- One writer per chain at a time, until the inserting transaction ends
+    PERFORM pg_advisory_xact_lock(hashtext('audit_logs_chain'), hashtext(chain_key::TEXT));
+
+    SELECT chain_seq, hash INTO last_seq, last_hash
+    FROM audit_logs
+    WHERE COALESCE(company_id, 0) = chain_key AND chain_seq IS NOT NULL
+    ORDER BY chain_seq DESC
+    LIMIT 1;
+
+    IF NEW.created_at IS NULL THEN
+        NEW.created_at := CURRENT_TIMESTAMP;
+    END IF;
+
+    NEW.chain_seq := COALESCE(last_seq, 0) + 1;
+    NEW.prev_hash := COALESCE(last_hash, repeat('0', 64));
+    NEW.hash := encode(sha256(convert_to(
+        NEW.prev_hash
+        || audit_chain_field(chain_key::TEXT)
+        || audit_chain_field(NEW.chain_seq::TEXT)
+        || audit_chain_field(NEW.user_id::TEXT)
+        || audit_chain_field(NEW.action::TEXT)
+        || audit_chain_field(NEW.resource::TEXT)
+        || audit_chain_field(NEW.resource_id::TEXT)
+        || audit_chain_field(NEW.details::TEXT)
+        || audit_chain_field(NEW.success::TEXT)
+        || audit_chain_field(((EXTRACT(EPOCH FROM NEW.created_at) * 1000000)::BIGINT)::TEXT),
+        'UTF8')), 'hex');
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_logs_chain ON audit_logs;
+CREATE TRIGGER trg_audit_logs_chain
+    BEFORE INSERT ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_chain();
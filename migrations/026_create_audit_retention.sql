@@ -0,0 +1,77 @@
+-- Audit log retention: entries older than the retention period of their company are
+-- archived to compressed NDJSON files and deleted. Companies without a policy use the
+-- AUDIT_RETENTION_DAYS default.
+CREATE TABLE IF NOT EXISTS audit_retention_policies (
+    company_id BIGINT PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+    retention_days INTEGER NOT NULL CHECK (retention_days > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Last archived entry of every chain, so the remaining entries still verify and new
+-- entries continue the chain after everything has been archived
+CREATE TABLE IF NOT EXISTS audit_chain_checkpoints (
+    chain_key BIGINT PRIMARY KEY,
+    chain_seq BIGINT NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    archive_file TEXT NOT NULL,
+    archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_chain_created_at
+    ON audit_logs((COALESCE(company_id, 0)), created_at);
+
+CREATE OR REPLACE FUNCTION audit_logs_chain() RETURNS TRIGGER AS $$
+DECLARE
+    chain_key BIGINT;
+    last_seq BIGINT;
+    last_hash TEXT;
+BEGIN
+    -- Entries of users are chained under the company they belong to
+    IF NEW.company_id IS NULL AND NEW.user_id IS NOT NULL THEN
+        SELECT ur.company_id INTO NEW.company_id
+        FROM user_roles ur
+        WHERE ur.user_id = NEW.user_id
+        ORDER BY ur.company_id
+        LIMIT 1;
+    END IF;
+    chain_key := COALESCE(NEW.company_id, 0);
+
+    -- One writer per chain at a time, until the inserting transaction ends
+    PERFORM pg_advisory_xact_lock(hashtext('audit_logs_chain'), hashtext(chain_key::TEXT));
+
+    SELECT chain_seq, hash INTO last_seq, last_hash
+    FROM audit_logs
+    WHERE COALESCE(company_id, 0) = chain_key AND chain_seq IS NOT NULL
+    ORDER BY chain_seq DESC
+    LIMIT 1;
+
+    -- Every entry of the chain has been archived: continue after the checkpoint
+    IF last_seq IS NULL THEN
+        SELECT c.chain_seq, c.hash INTO last_seq, last_hash
+        FROM audit_chain_checkpoints c
+        WHERE c.chain_key = audit_logs_chain.chain_key;
+    END IF;
+
+    IF NEW.created_at IS NULL THEN
+        NEW.created_at := CURRENT_TIMESTAMP;
+    END IF;
+
+    NEW.chain_seq := COALESCE(last_seq, 0) + 1;
+    NEW.prev_hash := COALESCE(last_hash, repeat('0', 64));
+    NEW.hash := encode(sha256(convert_to(
+        NEW.prev_hash
+        || audit_chain_field(chain_key::TEXT)
+        || audit_chain_field(NEW.chain_seq::TEXT)
+        || audit_chain_field(NEW.user_id::TEXT)
+        || audit_chain_field(NEW.action::TEXT)
+        || audit_chain_field(NEW.resource::TEXT)
+        || audit_chain_field(NEW.resource_id::TEXT)
+        || audit_chain_field(NEW.details::TEXT)
+        || audit_chain_field(NEW.success::TEXT)
+        || audit_chain_field(((EXTRACT(EPOCH FROM NEW.created_at) * 1000000)::BIGINT)::TEXT),
+        'UTF8')), 'hex');
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,49 @@
+-- Security alerts raised by the anomaly detector over audit_logs
+
+CREATE TABLE IF NOT EXISTS security_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    rule VARCHAR(50) NOT NULL,
+    severity VARCHAR(10) NOT NULL CHECK (severity IN ('low', 'medium', 'high', 'critical')),
+    status VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'acknowledged', 'resolved')),
+    title VARCHAR(255) NOT NULL,
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    company_id BIGINT REFERENCES companies(id) ON DELETE CASCADE,
+    ip_address VARCHAR(64),
+    details JSONB NOT NULL DEFAULT '{}',
+    -- Repeated detections of the same anomaly are merged while the alert is active
+    dedup_key VARCHAR(255) NOT NULL,
+    occurrences INT NOT NULL DEFAULT 1,
+    first_audit_log_id BIGINT,
+    last_audit_log_id BIGINT,
+    first_seen_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    acknowledged_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    acknowledged_at TIMESTAMP,
+    resolved_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    resolution_note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_security_alerts_active_dedup
+    ON security_alerts(dedup_key) WHERE status IN ('open', 'acknowledged');
+CREATE INDEX IF NOT EXISTS idx_security_alerts_status ON security_alerts(status, severity, last_seen_at DESC);
+CREATE INDEX IF NOT EXISTS idx_security_alerts_company ON security_alerts(company_id, status);
+
+-- Position of the detector in audit_logs; detection starts with entries written after
+-- this migration rather than alerting on the whole history
+CREATE TABLE IF NOT EXISTS anomaly_detector_state (
+    id SMALLINT PRIMARY KEY CHECK (id = 1),
+    last_audit_log_id BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO anomaly_detector_state (id, last_audit_log_id)
+SELECT 1, COALESCE(MAX(id), 0) FROM audit_logs
+ON CONFLICT (id) DO NOTHING;
+
+-- Window lookups of the detector rules
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action_created_at ON audit_logs(action, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_created_at ON audit_logs(user_id, created_at);
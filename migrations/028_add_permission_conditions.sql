@@ -0,0 +1,13 @@
+-- Optional attribute conditions on grants: a JSON object mapping an action to a condition
+-- expression, e.g. {"approve": "amount <= 50000000"}. NULL grants every action unconditionally.
+
+ALTER TABLE role_modules ADD COLUMN IF NOT EXISTS conditions JSONB;
+ALTER TABLE unit_role_modules ADD COLUMN IF NOT EXISTS conditions JSONB;
+
+ALTER TABLE role_modules DROP CONSTRAINT IF EXISTS role_modules_conditions_object;
+ALTER TABLE role_modules ADD CONSTRAINT role_modules_conditions_object
+    CHECK (conditions IS NULL OR jsonb_typeof(conditions) = 'object');
+
+ALTER TABLE unit_role_modules DROP CONSTRAINT IF EXISTS unit_role_modules_conditions_object;
+ALTER TABLE unit_role_modules ADD CONSTRAINT unit_role_modules_conditions_object
+    CHECK (conditions IS NULL OR jsonb_typeof(conditions) = 'object');
@@ -0,0 +1,66 @@
+-- Custom permission actions. Every module supports the standard actions read, write,
+-- delete and approve; module_actions declares further actions of a module, e.g. export
+-- or post-journal. Grants store every action they allow in an actions array.
+
+CREATE TABLE IF NOT EXISTS module_actions (
+    id BIGSERIAL PRIMARY KEY,
+    module_id BIGINT NOT NULL REFERENCES modules(id) ON DELETE CASCADE,
+    action VARCHAR(50) NOT NULL CHECK (action ~ '^[a-z][a-z0-9_-]*$'),
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (module_id, action)
+);
+
+ALTER TABLE role_modules ADD COLUMN IF NOT EXISTS actions JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE unit_role_modules ADD COLUMN IF NOT EXISTS actions JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+ALTER TABLE role_modules DROP CONSTRAINT IF EXISTS role_modules_actions_array;
+ALTER TABLE role_modules ADD CONSTRAINT role_modules_actions_array
+    CHECK (jsonb_typeof(actions) = 'array');
+
+ALTER TABLE unit_role_modules DROP CONSTRAINT IF EXISTS unit_role_modules_actions_array;
+ALTER TABLE unit_role_modules ADD CONSTRAINT unit_role_modules_actions_array
+    CHECK (jsonb_typeof(actions) = 'array');
+
+-- The can_* flags stay as a view of the standard actions for existing readers. Writers
+-- that only set the flags keep working: when actions is not written, the standard
+-- actions are taken from the flags and further actions are kept; otherwise the flags
+-- are taken from actions.
+CREATE OR REPLACE FUNCTION sync_grant_actions() RETURNS TRIGGER AS $$
+BEGIN
+    IF (TG_OP = 'INSERT' AND NEW.actions = '[]'::jsonb)
+        OR (TG_OP = 'UPDATE' AND NEW.actions IS NOT DISTINCT FROM OLD.actions) THEN
+        SELECT COALESCE(jsonb_agg(granted.action ORDER BY granted.action), '[]'::jsonb) INTO NEW.actions
+        FROM (
+            SELECT flags.action
+            FROM (VALUES ('read', NEW.can_read), ('write', NEW.can_write),
+                         ('delete', NEW.can_delete), ('approve', NEW.can_approve)) AS flags(action, granted)
+            WHERE flags.granted
+            UNION
+            SELECT further.action
+            FROM jsonb_array_elements_text(NEW.actions) AS further(action)
+            WHERE further.action NOT IN ('read', 'write', 'delete', 'approve')
+        ) AS granted;
+    ELSE
+        NEW.can_read := NEW.actions ? 'read';
+        NEW.can_write := NEW.actions ? 'write';
+        NEW.can_delete := NEW.actions ? 'delete';
+        NEW.can_approve := NEW.actions ? 'approve';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS role_modules_sync_actions ON role_modules;
+CREATE TRIGGER role_modules_sync_actions
+    BEFORE INSERT OR UPDATE ON role_modules
+    FOR EACH ROW EXECUTE FUNCTION sync_grant_actions();
+
+DROP TRIGGER IF EXISTS unit_role_modules_sync_actions ON unit_role_modules;
+CREATE TRIGGER unit_role_modules_sync_actions
+    BEFORE INSERT OR UPDATE ON unit_role_modules
+    FOR EACH ROW EXECUTE FUNCTION sync_grant_actions();
+
+-- Map the existing flags; an update that leaves actions unchanged fills it from the flags
+UPDATE role_modules SET can_read = can_read WHERE actions = '[]'::jsonb;
+UPDATE unit_role_modules SET can_read = can_read WHERE actions = '[]'::jsonb;
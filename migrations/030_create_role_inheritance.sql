@@ -0,0 +1,28 @@
+-- Role inheritance: a role grants the module permissions of its parent roles, transitively.
+-- The service rejects cycles and chains longer than 10 parents; the view stops at that
+-- depth regardless.
+
+CREATE TABLE IF NOT EXISTS role_parents (
+    role_id BIGINT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    parent_role_id BIGINT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (role_id, parent_role_id),
+    CHECK (role_id <> parent_role_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_parents_parent ON role_parents(parent_role_id);
+
+-- Every role with itself (depth 0) and each of its ancestors at the shortest distance.
+-- Permission queries join user_roles to role_modules through this view.
+CREATE OR REPLACE VIEW role_ancestors AS
+WITH RECURSIVE ancestors(role_id, ancestor_id, depth) AS (
+    SELECT id, id, 0 FROM roles
+    UNION
+    SELECT a.role_id, rp.parent_role_id, a.depth + 1
+    FROM ancestors a
+    JOIN role_parents rp ON rp.role_id = a.ancestor_id
+    WHERE a.depth < 10
+)
+SELECT role_id, ancestor_id, MIN(depth) AS depth
+FROM ancestors
+GROUP BY role_id, ancestor_id;
@@ -0,0 +1,39 @@
+-- Time-bound role assignments. A user role or unit role assignment only applies from
+-- valid_from (NULL: immediately) until valid_until (NULL: indefinitely). The assignment
+-- sweeper deletes assignments past valid_until and sets activated_at when a scheduled
+-- assignment starts, so permission caches and sessions follow the window.
+
+ALTER TABLE user_roles ADD COLUMN IF NOT EXISTS valid_from TIMESTAMP;
+ALTER TABLE user_roles ADD COLUMN IF NOT EXISTS valid_until TIMESTAMP;
+ALTER TABLE user_roles ADD COLUMN IF NOT EXISTS activated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE unit_roles ADD COLUMN IF NOT EXISTS valid_from TIMESTAMP;
+ALTER TABLE unit_roles ADD COLUMN IF NOT EXISTS valid_until TIMESTAMP;
+ALTER TABLE unit_roles ADD COLUMN IF NOT EXISTS activated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE user_roles DROP CONSTRAINT IF EXISTS user_roles_validity_window;
+ALTER TABLE user_roles ADD CONSTRAINT user_roles_validity_window
+    CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_from < valid_until);
+
+ALTER TABLE unit_roles DROP CONSTRAINT IF EXISTS unit_roles_validity_window;
+ALTER TABLE unit_roles ADD CONSTRAINT unit_roles_validity_window
+    CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_from < valid_until);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_valid_until ON user_roles(valid_until) WHERE valid_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_user_roles_pending ON user_roles(valid_from) WHERE activated_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_unit_roles_valid_until ON unit_roles(valid_until) WHERE valid_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_unit_roles_pending ON unit_roles(valid_from) WHERE activated_at IS NULL;
+
+-- Assignments within their window. Permission and menu queries read these views, so an
+-- assignment stops applying at valid_until even before the sweeper removes it.
+CREATE OR REPLACE VIEW active_user_roles AS
+SELECT *
+FROM user_roles
+WHERE (valid_from IS NULL OR valid_from <= CURRENT_TIMESTAMP)
+    AND (valid_until IS NULL OR valid_until > CURRENT_TIMESTAMP);
+
+CREATE OR REPLACE VIEW active_unit_roles AS
+SELECT *
+FROM unit_roles
+WHERE (valid_from IS NULL OR valid_from <= CURRENT_TIMESTAMP)
+    AND (valid_until IS NULL OR valid_until > CURRENT_TIMESTAMP);
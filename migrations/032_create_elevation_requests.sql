@@ -0,0 +1,42 @@
+-- Just-in-time elevation: a user requests a role at a company, branch or unit scope for
+-- a bounded duration. An approver grants it as a user role with valid_until set, which
+-- the assignment sweeper removes once the duration has passed.
+
+CREATE TABLE IF NOT EXISTS elevation_requests (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id BIGINT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    branch_id BIGINT REFERENCES branches(id) ON DELETE CASCADE,
+    unit_id BIGINT REFERENCES units(id) ON DELETE CASCADE,
+    duration_minutes INT NOT NULL CHECK (duration_minutes > 0),
+    justification TEXT NOT NULL,
+    -- An expired request keeps status pending or approved and is recognized by expires_at
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    decided_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,
+    decision_note TEXT,
+    -- The assignment created on approval; cleared when the sweeper removes it
+    user_role_id BIGINT REFERENCES user_roles(id) ON DELETE SET NULL,
+    -- Approval deadline while pending, end of the elevation once approved
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_elevation_requests_company_status ON elevation_requests(company_id, status);
+CREATE INDEX IF NOT EXISTS idx_elevation_requests_user ON elevation_requests(user_id, created_at DESC);
+
+-- History of every request; expiry is derived from expires_at and not stored
+CREATE TABLE IF NOT EXISTS elevation_request_events (
+    id BIGSERIAL PRIMARY KEY,
+    request_id BIGINT NOT NULL REFERENCES elevation_requests(id) ON DELETE CASCADE,
+    event VARCHAR(20) NOT NULL
+        CHECK (event IN ('requested', 'approved', 'rejected', 'cancelled')),
+    actor_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_elevation_request_events_request ON elevation_request_events(request_id, created_at);
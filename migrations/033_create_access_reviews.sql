@@ -0,0 +1,49 @@
+-- Access review (recertification) campaigns. Starting a campaign snapshots every user
+-- role and unit role assignment in its scope as an item with a reviewer; reviewers
+-- certify or revoke their items, and closing the campaign removes the revoked assignments.
+
+CREATE TABLE IF NOT EXISTS access_review_campaigns (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(150) NOT NULL,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    -- Optional narrowing of the scope to a branch and/or a role
+    branch_id BIGINT REFERENCES branches(id) ON DELETE CASCADE,
+    role_id BIGINT REFERENCES roles(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    due_at TIMESTAMP,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    closed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_review_campaigns_company ON access_review_campaigns(company_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS access_review_items (
+    id BIGSERIAL PRIMARY KEY,
+    campaign_id BIGINT NOT NULL REFERENCES access_review_campaigns(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('user_role', 'unit_role')),
+    -- Snapshot of the assignment; it is not a foreign key so the item survives its removal
+    assignment_id BIGINT NOT NULL,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    role_id BIGINT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    branch_id BIGINT REFERENCES branches(id) ON DELETE SET NULL,
+    unit_id BIGINT REFERENCES units(id) ON DELETE SET NULL,
+    valid_until TIMESTAMP,
+    reviewer_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    decision VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (decision IN ('pending', 'certified', 'revoked')),
+    comment TEXT,
+    decided_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,
+    -- Set when closing the campaign removed the revoked assignment
+    removed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (campaign_id, kind, assignment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_review_items_reviewer ON access_review_items(campaign_id, reviewer_id, decision);
+CREATE INDEX IF NOT EXISTS idx_access_review_items_reviewer_open ON access_review_items(reviewer_id) WHERE decision = 'pending';
@@ -0,0 +1,47 @@
+-- Static separation-of-duties rules. A role pair rule forbids one user from holding both
+-- roles in a company; an action pair rule forbids one user from holding both actions on
+-- the same module (module_id NULL: any module). Rules without a company apply to every
+-- company. Role assignments, unit role assignments and permission updates that would
+-- introduce a violation are rejected, or with SOD_MODE=override allowed when the change
+-- carries a justification, which is recorded in sod_overrides.
+
+CREATE TABLE IF NOT EXISTS sod_rules (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(150) NOT NULL,
+    description TEXT,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('role_pair', 'action_pair')),
+    role_a_id BIGINT REFERENCES roles(id) ON DELETE CASCADE,
+    role_b_id BIGINT REFERENCES roles(id) ON DELETE CASCADE,
+    module_id BIGINT REFERENCES modules(id) ON DELETE CASCADE,
+    action_a VARCHAR(50),
+    action_b VARCHAR(50),
+    company_id BIGINT REFERENCES companies(id) ON DELETE CASCADE,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT sod_rules_pair CHECK (
+        (kind = 'role_pair' AND role_a_id IS NOT NULL AND role_b_id IS NOT NULL
+            AND role_a_id <> role_b_id AND module_id IS NULL AND action_a IS NULL AND action_b IS NULL)
+        OR (kind = 'action_pair' AND action_a IS NOT NULL AND action_b IS NOT NULL
+            AND action_a <> action_b AND role_a_id IS NULL AND role_b_id IS NULL)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_sod_rules_active ON sod_rules(company_id) WHERE is_active = true;
+
+-- Violations allowed in override mode, one row per rule, user and module the change conflicted on
+CREATE TABLE IF NOT EXISTS sod_overrides (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id BIGINT NOT NULL REFERENCES sod_rules(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    module_id BIGINT REFERENCES modules(id) ON DELETE CASCADE,
+    -- Audit action of the change that introduced the violation, e.g. user_role_assigned
+    change VARCHAR(50) NOT NULL,
+    justification TEXT NOT NULL,
+    granted_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sod_overrides_user ON sod_overrides(rule_id, user_id, company_id);
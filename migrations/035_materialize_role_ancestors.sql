@@ -0,0 +1,95 @@
+-- Materialize the role_ancestors closure. As a view the recursive CTE walked the whole
+-- roles table on every permission load; as a table it is kept up to date by triggers
+-- when roles and role parents change, and permission queries read it by index.
+
+DROP VIEW IF EXISTS role_ancestors;
+
+CREATE TABLE IF NOT EXISTS role_ancestors (
+    role_id BIGINT NOT NULL,
+    ancestor_id BIGINT NOT NULL,
+    depth INTEGER NOT NULL,
+    PRIMARY KEY (role_id, ancestor_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_ancestors_ancestor ON role_ancestors(ancestor_id);
+
+-- Recompute the closure of a role and of every role that inherits from it. The old closure
+-- still lists the inheriting roles when a parent link is removed. Roles are joined so that
+-- links to a role being deleted are skipped while its cascades run.
+CREATE OR REPLACE FUNCTION refresh_role_ancestors(changed_role_id BIGINT) RETURNS VOID AS $$
+DECLARE
+    affected BIGINT[];
+BEGIN
+    -- Serialize writers so concurrent hierarchy changes do not each compute from a stale closure
+    LOCK TABLE role_ancestors IN SHARE ROW EXCLUSIVE MODE;
+
+    SELECT array_agg(role_id) INTO affected FROM role_ancestors WHERE ancestor_id = changed_role_id;
+    affected := array_append(COALESCE(affected, '{}'), changed_role_id);
+
+    DELETE FROM role_ancestors WHERE role_id = ANY(affected);
+
+    INSERT INTO role_ancestors (role_id, ancestor_id, depth)
+    WITH RECURSIVE ancestors(role_id, ancestor_id, depth) AS (
+        SELECT id, id, 0 FROM roles WHERE id = ANY(affected)
+        UNION
+        SELECT a.role_id, rp.parent_role_id, a.depth + 1
+        FROM ancestors a
+        JOIN role_parents rp ON rp.role_id = a.ancestor_id
+        JOIN roles p ON p.id = rp.parent_role_id
+        WHERE a.depth < 10
+    )
+    SELECT role_id, ancestor_id, MIN(depth)
+    FROM ancestors
+    GROUP BY role_id, ancestor_id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION role_parents_refresh_ancestors() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM refresh_role_ancestors(OLD.role_id);
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        PERFORM refresh_role_ancestors(NEW.role_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION roles_refresh_ancestors() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO role_ancestors (role_id, ancestor_id, depth)
+        VALUES (NEW.id, NEW.id, 0)
+        ON CONFLICT DO NOTHING;
+    ELSE
+        DELETE FROM role_ancestors WHERE role_id = OLD.id OR ancestor_id = OLD.id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS role_parents_refresh_ancestors ON role_parents;
+CREATE TRIGGER role_parents_refresh_ancestors
+    AFTER INSERT OR UPDATE OR DELETE ON role_parents
+    FOR EACH ROW EXECUTE FUNCTION role_parents_refresh_ancestors();
+
+DROP TRIGGER IF EXISTS roles_refresh_ancestors ON roles;
+CREATE TRIGGER roles_refresh_ancestors
+    AFTER INSERT OR DELETE ON roles
+    FOR EACH ROW EXECUTE FUNCTION roles_refresh_ancestors();
+
+-- Fill the closure of the existing roles
+TRUNCATE role_ancestors;
+INSERT INTO role_ancestors (role_id, ancestor_id, depth)
+WITH RECURSIVE ancestors(role_id, ancestor_id, depth) AS (
+    SELECT id, id, 0 FROM roles
+    UNION
+    SELECT a.role_id, rp.parent_role_id, a.depth + 1
+    FROM ancestors a
+    JOIN role_parents rp ON rp.role_id = a.ancestor_id
+    WHERE a.depth < 10
+)
+SELECT role_id, ancestor_id, MIN(depth)
+FROM ancestors
+GROUP BY role_id, ancestor_id;
@@ -0,0 +1,183 @@
+// Package anomaly raises security alerts from rules evaluated over the audit log
+package anomaly
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gin-scalable-api/pkg/audittrail"
+)
+
+// Severity of an alert
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// Audit actions the rules look at
+const (
+	ActionLoginSucceeded         = "login_succeeded"
+	ActionLoginFailed            = "login_failed"
+	ActionUserRoleAssigned       = "user_role_assigned"
+	ActionRolePermissionsUpdated = "role_permissions_updated"
+)
+
+// Event is an audit log entry as seen by the rules. UserID and CompanyID are 0 when unknown.
+type Event struct {
+	ID         int64
+	UserID     int64
+	CompanyID  int64
+	Action     string
+	Resource   string
+	ResourceID int64
+	Success    bool
+	Method     string
+	Route      string
+	IP         string
+	UserAgent  string
+	Changes    []audittrail.Change
+	CreatedAt  time.Time
+}
+
+// IsDelete reports whether the event deleted something
+func (e Event) IsDelete() bool {
+	if e.Method != "" {
+		return e.Method == "DELETE" && e.Success
+	}
+	return strings.HasSuffix(e.Action, "_deleted")
+}
+
+// ChangedValue returns the new value of a field recorded in the event's changes
+func (e Event) ChangedValue(field string) (interface{}, bool) {
+	for _, change := range e.Changes {
+		if change.Field == field {
+			return change.After, true
+		}
+	}
+	return nil, false
+}
+
+// Alert is a detected anomaly. Alerts with the same Key are merged while the first one
+// has not been resolved.
+type Alert struct {
+	Rule      string
+	Severity  Severity
+	Title     string
+	UserID    int64
+	CompanyID int64
+	IP        string
+	Details   map[string]interface{}
+	Key       string
+	EventID   int64
+	At        time.Time
+}
+
+// EventQuery counts events in a time window up to and including an event
+type EventQuery struct {
+	Action  string
+	UserID  int64
+	IP      string
+	Deletes bool
+	Since   time.Time
+	UpToID  int64
+}
+
+// History answers the questions rules ask about past events and current state
+type History interface {
+	CountEvents(query EventQuery) (int64, error)
+	// LoginHistory reports whether the user logged in successfully before an event, and
+	// whether any of those logins came from ip or used userAgent
+	LoginHistory(userID int64, ip, userAgent string, beforeID int64) (hasLogins, knownIP, knownAgent bool, err error)
+	HoldsRole(userID, roleID int64) (bool, error)
+	// IsPrivilegedRole reports whether a role is an admin role or grants approval rights
+	IsPrivilegedRole(roleID int64) (bool, error)
+}
+
+// Rule inspects one event and returns an alert or nil
+type Rule interface {
+	Name() string
+	Evaluate(event Event, history History) (*Alert, error)
+}
+
+// BusinessHours is the time during which access is expected
+type BusinessHours struct {
+	// StartHour and EndHour bound the working day, EndHour exclusive
+	StartHour int
+	EndHour   int
+	Days      []time.Weekday
+	Location  *time.Location
+}
+
+// Contains reports whether t falls within business hours
+func (b BusinessHours) Contains(t time.Time) bool {
+	if b.Location != nil {
+		t = t.In(b.Location)
+	}
+
+	workday := false
+	for _, day := range b.Days {
+		if t.Weekday() == day {
+			workday = true
+			break
+		}
+	}
+	return workday && t.Hour() >= b.StartHour && t.Hour() < b.EndHour
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWeekdays parses a comma separated list of day abbreviations such as "mon,tue,wed"
+func ParseWeekdays(value string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		day, ok := weekdays[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", name)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// Config holds the thresholds of the default rules and the schedule of the detector
+type Config struct {
+	Interval  time.Duration
+	BatchSize int
+
+	FailedLoginThreshold int
+	FailedLoginWindow    time.Duration
+	MassDeleteThreshold  int
+	MassDeleteWindow     time.Duration
+	// BusinessHours disables the off-hours rule when it has no days
+	BusinessHours BusinessHours
+}
+
+// DefaultConfig returns the thresholds used when nothing is configured
+func DefaultConfig() Config {
+	return Config{
+		Interval:             30 * time.Second,
+		BatchSize:            500,
+		FailedLoginThreshold: 10,
+		FailedLoginWindow:    10 * time.Minute,
+		MassDeleteThreshold:  20,
+		MassDeleteWindow:     5 * time.Minute,
+		BusinessHours: BusinessHours{
+			StartHour: 7,
+			EndHour:   19,
+			Days:      []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Location:  time.Local,
+		},
+	}
+}
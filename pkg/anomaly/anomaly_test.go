@@ -0,0 +1,159 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+
+	"gin-scalable-api/pkg/audittrail"
+)
+
+type fakeHistory struct {
+	count      int64
+	hasLogins  bool
+	knownIP    bool
+	knownAgent bool
+	holdsRole  bool
+	privileged bool
+}
+
+func (h *fakeHistory) CountEvents(EventQuery) (int64, error) { return h.count, nil }
+
+func (h *fakeHistory) LoginHistory(int64, string, string, int64) (bool, bool, bool, error) {
+	return h.hasLogins, h.knownIP, h.knownAgent, nil
+}
+
+func (h *fakeHistory) HoldsRole(int64, int64) (bool, error) { return h.holdsRole, nil }
+
+func (h *fakeHistory) IsPrivilegedRole(int64) (bool, error) { return h.privileged, nil }
+
+// monday10 is within the default business hours
+var monday10 = time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+
+func TestBusinessHoursContains(t *testing.T) {
+	hours := DefaultConfig().BusinessHours
+	hours.Location = time.UTC
+
+	cases := map[time.Time]bool{
+		monday10:                         true,
+		monday10.Add(9 * time.Hour):      false, // 19:00
+		monday10.Add(-4 * time.Hour):     false, // 06:00
+		monday10.Add(5 * 24 * time.Hour): false, // Saturday
+	}
+	for at, want := range cases {
+		if got := hours.Contains(at); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", at, got, want)
+		}
+	}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	days, err := ParseWeekdays("Mon, tue,,sat")
+	if err != nil || len(days) != 3 || days[0] != time.Monday || days[2] != time.Saturday {
+		t.Errorf("unexpected result %v, %v", days, err)
+	}
+	if _, err := ParseWeekdays("mon,funday"); err == nil {
+		t.Error("expected an error for an unknown day")
+	}
+	if days, err := ParseWeekdays(""); err != nil || len(days) != 0 {
+		t.Errorf("expected no days for an empty list, got %v, %v", days, err)
+	}
+}
+
+func TestFailedLoginBurstThreshold(t *testing.T) {
+	rule := &FailedLoginBurst{Threshold: 5, Window: time.Minute}
+	event := Event{ID: 9, Action: ActionLoginFailed, IP: "10.0.0.1", CreatedAt: monday10}
+
+	if alert, _ := rule.Evaluate(event, &fakeHistory{count: 4}); alert != nil {
+		t.Errorf("expected no alert below the threshold, got %+v", alert)
+	}
+	alert, _ := rule.Evaluate(event, &fakeHistory{count: 5})
+	if alert == nil || alert.Severity != SeverityHigh || alert.Key != "failed_login_burst:ip:10.0.0.1" {
+		t.Errorf("expected a high alert keyed by IP, got %+v", alert)
+	}
+}
+
+func TestNewLoginLocationSkipsFirstLogin(t *testing.T) {
+	rule := &NewLoginLocation{}
+	event := Event{ID: 3, UserID: 7, Action: ActionLoginSucceeded, IP: "10.0.0.2", UserAgent: "curl"}
+
+	if alert, _ := rule.Evaluate(event, &fakeHistory{}); alert != nil {
+		t.Errorf("expected no alert on the first login, got %+v", alert)
+	}
+	if alert, _ := rule.Evaluate(event, &fakeHistory{hasLogins: true, knownIP: true, knownAgent: true}); alert != nil {
+		t.Errorf("expected no alert from a known location, got %+v", alert)
+	}
+	alert, _ := rule.Evaluate(event, &fakeHistory{hasLogins: true, knownAgent: true})
+	if alert == nil || alert.Severity != SeverityLow {
+		t.Errorf("expected a low alert for a new IP, got %+v", alert)
+	}
+	alert, _ = rule.Evaluate(event, &fakeHistory{hasLogins: true})
+	if alert == nil || alert.Severity != SeverityMedium {
+		t.Errorf("expected a medium alert for a new IP and user agent, got %+v", alert)
+	}
+}
+
+func TestMassDeleteCountsDeletesOnly(t *testing.T) {
+	rule := &MassDelete{Threshold: 3, Window: time.Minute}
+	history := &fakeHistory{count: 3}
+
+	if alert, _ := rule.Evaluate(Event{UserID: 1, Method: "POST", Success: true}, history); alert != nil {
+		t.Errorf("expected no alert for a non-delete, got %+v", alert)
+	}
+	if alert, _ := rule.Evaluate(Event{UserID: 1, Method: "DELETE", Success: true}, history); alert == nil {
+		t.Error("expected an alert for a delete request")
+	}
+	if alert, _ := rule.Evaluate(Event{UserID: 1, Action: "role_deleted"}, history); alert == nil {
+		t.Error("expected an alert for a delete recorded by a service")
+	}
+}
+
+func TestPrivilegeEscalationSelfAssignment(t *testing.T) {
+	rule := &PrivilegeEscalation{}
+	event := Event{ID: 5, UserID: 7, Action: ActionUserRoleAssigned, Changes: []audittrail.Change{
+		{Field: "role_id", After: float64(3)},
+		{Field: "user_id", After: float64(7)},
+	}}
+
+	alert, _ := rule.Evaluate(event, &fakeHistory{privileged: true})
+	if alert == nil || alert.Severity != SeverityCritical {
+		t.Errorf("expected a critical alert, got %+v", alert)
+	}
+
+	event.Changes[1].After = float64(8)
+	if alert, _ := rule.Evaluate(event, &fakeHistory{privileged: true}); alert != nil {
+		t.Errorf("expected no alert when assigning to someone else, got %+v", alert)
+	}
+}
+
+func TestPrivilegeEscalationSelfGrant(t *testing.T) {
+	rule := &PrivilegeEscalation{}
+	event := Event{ID: 6, UserID: 7, Action: ActionRolePermissionsUpdated, ResourceID: 3, Changes: []audittrail.Change{
+		{Field: "modules.12.can_approve", Before: false, After: true},
+	}}
+
+	if alert, _ := rule.Evaluate(event, &fakeHistory{holdsRole: false}); alert != nil {
+		t.Errorf("expected no alert for a role the user does not hold, got %+v", alert)
+	}
+	if alert, _ := rule.Evaluate(event, &fakeHistory{holdsRole: true}); alert == nil || alert.Severity != SeverityCritical {
+		t.Errorf("expected a critical alert, got %+v", alert)
+	}
+}
+
+func TestOffHoursAccessOncePerDay(t *testing.T) {
+	hours := DefaultConfig().BusinessHours
+	hours.Location = time.UTC
+	rule := &OffHoursAccess{Hours: hours}
+
+	request := Event{UserID: 7, Method: "POST", Success: true, CreatedAt: monday10}
+	if alert, _ := rule.Evaluate(request, nil); alert != nil {
+		t.Errorf("expected no alert within business hours, got %+v", alert)
+	}
+
+	request.CreatedAt = monday10.Add(12 * time.Hour)
+	first, _ := rule.Evaluate(request, nil)
+	request.CreatedAt = request.CreatedAt.Add(time.Hour)
+	second, _ := rule.Evaluate(request, nil)
+	if first == nil || second == nil || first.Key != second.Key {
+		t.Errorf("expected alerts sharing the key of the day, got %+v and %+v", first, second)
+	}
+}
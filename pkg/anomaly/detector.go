@@ -0,0 +1,282 @@
+package anomaly
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gin-scalable-api/pkg/audittrail"
+	"gin-scalable-api/pkg/logger"
+)
+
+// Detector evaluates the rules over audit_logs entries written since its last run and
+// stores the alerts in security_alerts
+type Detector struct {
+	db      *sql.DB
+	config  Config
+	rules   []Rule
+	history History
+}
+
+func NewDetector(db *sql.DB, config Config) *Detector {
+	defaults := DefaultConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+
+	return &Detector{
+		db:      db,
+		config:  config,
+		rules:   DefaultRules(config),
+		history: &sqlHistory{db: db},
+	}
+}
+
+// Start runs the detector every interval until ctx is cancelled
+func (d *Detector) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		// Catch up in batches before waiting for the next tick
+		for {
+			processed, raised, err := d.Run()
+			if err != nil {
+				logger.Error(fmt.Sprintf("Anomaly detection failed: %v", err))
+				break
+			}
+			if raised > 0 {
+				logger.Warning(fmt.Sprintf("Anomaly detection raised %d security alert(s)", raised))
+			}
+			if processed < d.config.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run evaluates the next batch of audit entries and returns how many entries were
+// processed and how many alerts were raised. Entries committed out of ID order after
+// the detector moved past them are not evaluated.
+func (d *Detector) Run() (int, int, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// One detector at a time across instances; the others skip this round
+	var locked bool
+	if err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock(hashtext('anomaly_detector'))`).Scan(&locked); err != nil {
+		return 0, 0, fmt.Errorf("failed to lock anomaly detector: %w", err)
+	}
+	if !locked {
+		return 0, 0, nil
+	}
+
+	var cursor int64
+	if err := tx.QueryRow(`SELECT last_audit_log_id FROM anomaly_detector_state WHERE id = 1`).Scan(&cursor); err != nil {
+		return 0, 0, fmt.Errorf("failed to read anomaly detector state: %w", err)
+	}
+
+	events, err := d.nextEvents(tx, cursor)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(events) == 0 {
+		return 0, 0, nil
+	}
+
+	raised := 0
+	for _, event := range events {
+		for _, rule := range d.rules {
+			alert, err := rule.Evaluate(event, d.history)
+			if err != nil {
+				return 0, 0, fmt.Errorf("rule %s failed on audit log %d: %w", rule.Name(), event.ID, err)
+			}
+			if alert == nil {
+				continue
+			}
+			if err := storeAlert(tx, alert); err != nil {
+				return 0, 0, err
+			}
+			raised++
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE anomaly_detector_state SET last_audit_log_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = 1
+	`, events[len(events)-1].ID); err != nil {
+		return 0, 0, fmt.Errorf("failed to update anomaly detector state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit security alerts: %w", err)
+	}
+	return len(events), raised, nil
+}
+
+func (d *Detector) nextEvents(tx *sql.Tx, cursor int64) ([]Event, error) {
+	rows, err := tx.Query(`
+		SELECT id, user_id, company_id, action, resource, resource_id, success, details, created_at
+		FROM audit_logs
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`, cursor, d.config.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		var userID, companyID, resourceID sql.NullInt64
+		var resource sql.NullString
+		var detailsJSON []byte
+		if err := rows.Scan(&event.ID, &userID, &companyID, &event.Action, &resource, &resourceID,
+			&event.Success, &detailsJSON, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		event.UserID = userID.Int64
+		event.CompanyID = companyID.Int64
+		event.Resource = resource.String
+		event.ResourceID = resourceID.Int64
+
+		var details struct {
+			Method    string              `json:"method"`
+			Route     string              `json:"route"`
+			Source    string              `json:"source"`
+			IP        string              `json:"ip"`
+			UserAgent string              `json:"user_agent"`
+			Changes   []audittrail.Change `json:"changes"`
+		}
+		if len(detailsJSON) > 0 {
+			json.Unmarshal(detailsJSON, &details)
+		}
+		// Only requests recorded by the audit middleware carry a meaningful method
+		if details.Source == "middleware" {
+			event.Method = details.Method
+			event.Route = details.Route
+		}
+		event.IP = details.IP
+		event.UserAgent = details.UserAgent
+		event.Changes = details.Changes
+
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func storeAlert(tx *sql.Tx, alert *Alert) error {
+	details, err := json.Marshal(alert.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert details: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO security_alerts (rule, severity, title, user_id, company_id, ip_address, details, dedup_key,
+			first_audit_log_id, last_audit_log_id, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9, $10, $10)
+		ON CONFLICT (dedup_key) WHERE status IN ('open', 'acknowledged') DO UPDATE
+		SET occurrences = security_alerts.occurrences + 1,
+			title = EXCLUDED.title,
+			details = EXCLUDED.details,
+			last_audit_log_id = EXCLUDED.last_audit_log_id,
+			last_seen_at = EXCLUDED.last_seen_at,
+			updated_at = CURRENT_TIMESTAMP
+	`, alert.Rule, string(alert.Severity), alert.Title,
+		sql.NullInt64{Int64: alert.UserID, Valid: alert.UserID != 0},
+		sql.NullInt64{Int64: alert.CompanyID, Valid: alert.CompanyID != 0},
+		sql.NullString{String: alert.IP, Valid: alert.IP != ""},
+		details, alert.Key, alert.EventID, alert.At)
+	if err != nil {
+		return fmt.Errorf("failed to store security alert: %w", err)
+	}
+	return nil
+}
+
+// sqlHistory answers rule queries from audit_logs and the role tables
+type sqlHistory struct {
+	db *sql.DB
+}
+
+func (h *sqlHistory) CountEvents(query EventQuery) (int64, error) {
+	conditions := "created_at >= $1 AND id <= $2"
+	args := []interface{}{query.Since, query.UpToID}
+
+	if query.Action != "" {
+		args = append(args, query.Action)
+		conditions += fmt.Sprintf(" AND action = $%d", len(args))
+	}
+	if query.UserID != 0 {
+		args = append(args, query.UserID)
+		conditions += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+	if query.IP != "" {
+		args = append(args, query.IP)
+		conditions += fmt.Sprintf(" AND details->>'ip' = $%d", len(args))
+	}
+	if query.Deletes {
+		conditions += ` AND ((details->>'source' = 'middleware' AND details->>'method' = 'DELETE' AND success)
+			OR (COALESCE(details->>'source', '') <> 'middleware' AND action LIKE '%\_deleted'))`
+	}
+
+	var count int64
+	if err := h.db.QueryRow(`SELECT COUNT(*) FROM audit_logs WHERE `+conditions, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+	return count, nil
+}
+
+func (h *sqlHistory) LoginHistory(userID int64, ip, userAgent string, beforeID int64) (bool, bool, bool, error) {
+	var hasLogins, knownIP, knownAgent bool
+	err := h.db.QueryRow(`
+		SELECT COUNT(*) > 0,
+			COALESCE(BOOL_OR(details->>'ip' = $3), false),
+			COALESCE(BOOL_OR(details->>'user_agent' = $4), false)
+		FROM audit_logs
+		WHERE user_id = $1 AND action = $5 AND success AND id < $2
+	`, userID, beforeID, ip, userAgent, ActionLoginSucceeded).Scan(&hasLogins, &knownIP, &knownAgent)
+	if err != nil {
+		return false, false, false, fmt.Errorf("failed to read login history: %w", err)
+	}
+	return hasLogins, knownIP, knownAgent, nil
+}
+
+func (h *sqlHistory) HoldsRole(userID, roleID int64) (bool, error) {
+	var holds bool
+	if err := h.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM user_roles WHERE user_id = $1 AND role_id = $2)
+	`, userID, roleID).Scan(&holds); err != nil {
+		return false, fmt.Errorf("failed to check user role: %w", err)
+	}
+	return holds, nil
+}
+
+func (h *sqlHistory) IsPrivilegedRole(roleID int64) (bool, error) {
+	var privileged bool
+	if err := h.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1 AND name IN ('SUPER_ADMIN', 'COMPANY_ADMIN'))
+			OR EXISTS(
+				SELECT 1 FROM role_ancestors ra
+				JOIN role_modules rm ON rm.role_id = ra.ancestor_id
+				WHERE ra.role_id = $1 AND rm.can_approve = true
+			)
+	`, roleID).Scan(&privileged); err != nil {
+		return false, fmt.Errorf("failed to check role privileges: %w", err)
+	}
+	return privileged, nil
+}
@@ -0,0 +1,238 @@
+package anomaly
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultRules returns the built-in rules configured by config
+func DefaultRules(config Config) []Rule {
+	rules := []Rule{
+		&FailedLoginBurst{Threshold: config.FailedLoginThreshold, Window: config.FailedLoginWindow},
+		&NewLoginLocation{},
+		&MassDelete{Threshold: config.MassDeleteThreshold, Window: config.MassDeleteWindow},
+		&PrivilegeEscalation{},
+	}
+	if len(config.BusinessHours.Days) > 0 {
+		rules = append(rules, &OffHoursAccess{Hours: config.BusinessHours})
+	}
+	return rules
+}
+
+func newAlert(rule Rule, event Event, severity Severity, title, key string, details map[string]interface{}) *Alert {
+	return &Alert{
+		Rule:      rule.Name(),
+		Severity:  severity,
+		Title:     title,
+		UserID:    event.UserID,
+		CompanyID: event.CompanyID,
+		IP:        event.IP,
+		Details:   details,
+		Key:       rule.Name() + ":" + key,
+		EventID:   event.ID,
+		At:        event.CreatedAt,
+	}
+}
+
+// FailedLoginBurst alerts when one IP address fails to log in Threshold times within Window
+type FailedLoginBurst struct {
+	Threshold int
+	Window    time.Duration
+}
+
+func (r *FailedLoginBurst) Name() string { return "failed_login_burst" }
+
+func (r *FailedLoginBurst) Evaluate(event Event, history History) (*Alert, error) {
+	if event.Action != ActionLoginFailed || event.IP == "" || r.Threshold <= 0 {
+		return nil, nil
+	}
+
+	count, err := history.CountEvents(EventQuery{
+		Action: ActionLoginFailed,
+		IP:     event.IP,
+		Since:  event.CreatedAt.Add(-r.Window),
+		UpToID: event.ID,
+	})
+	if err != nil || count < int64(r.Threshold) {
+		return nil, err
+	}
+
+	return newAlert(r, event, SeverityHigh,
+		fmt.Sprintf("%d failed logins from %s within %s", count, event.IP, r.Window),
+		"ip:"+event.IP,
+		map[string]interface{}{"failed_logins": count, "window_seconds": r.Window.Seconds()},
+	), nil
+}
+
+// NewLoginLocation alerts when a user logs in from an IP address or user agent never
+// seen for that user. The first login of a user is not reported.
+type NewLoginLocation struct{}
+
+func (r *NewLoginLocation) Name() string { return "new_login_location" }
+
+func (r *NewLoginLocation) Evaluate(event Event, history History) (*Alert, error) {
+	if event.Action != ActionLoginSucceeded || event.UserID == 0 {
+		return nil, nil
+	}
+
+	hasLogins, knownIP, knownAgent, err := history.LoginHistory(event.UserID, event.IP, event.UserAgent, event.ID)
+	if err != nil || !hasLogins || (knownIP && knownAgent) {
+		return nil, err
+	}
+
+	severity := SeverityLow
+	var unknown []string
+	if !knownIP {
+		unknown = append(unknown, "IP address")
+	}
+	if !knownAgent {
+		unknown = append(unknown, "user agent")
+	}
+	if !knownIP && !knownAgent {
+		severity = SeverityMedium
+	}
+
+	return newAlert(r, event, severity,
+		fmt.Sprintf("User %d logged in with a new %s", event.UserID, strings.Join(unknown, " and ")),
+		fmt.Sprintf("user:%d:%x", event.UserID, sha256.Sum256([]byte(event.IP+"\n"+event.UserAgent))),
+		map[string]interface{}{"new_ip": !knownIP, "new_user_agent": !knownAgent, "user_agent": event.UserAgent},
+	), nil
+}
+
+// MassDelete alerts when one user deletes Threshold resources within Window
+type MassDelete struct {
+	Threshold int
+	Window    time.Duration
+}
+
+func (r *MassDelete) Name() string { return "mass_delete" }
+
+func (r *MassDelete) Evaluate(event Event, history History) (*Alert, error) {
+	if !event.IsDelete() || event.UserID == 0 || r.Threshold <= 0 {
+		return nil, nil
+	}
+
+	count, err := history.CountEvents(EventQuery{
+		UserID:  event.UserID,
+		Deletes: true,
+		Since:   event.CreatedAt.Add(-r.Window),
+		UpToID:  event.ID,
+	})
+	if err != nil || count < int64(r.Threshold) {
+		return nil, err
+	}
+
+	return newAlert(r, event, SeverityHigh,
+		fmt.Sprintf("User %d deleted %d resources within %s", event.UserID, count, r.Window),
+		fmt.Sprintf("user:%d", event.UserID),
+		map[string]interface{}{"deletes": count, "window_seconds": r.Window.Seconds(), "last_action": event.Action},
+	), nil
+}
+
+// PrivilegeEscalation alerts when users assign a role to themselves or grant approval
+// rights to a role they hold
+type PrivilegeEscalation struct{}
+
+func (r *PrivilegeEscalation) Name() string { return "privilege_escalation" }
+
+func (r *PrivilegeEscalation) Evaluate(event Event, history History) (*Alert, error) {
+	if event.UserID == 0 {
+		return nil, nil
+	}
+
+	switch event.Action {
+	case ActionUserRoleAssigned:
+		return r.selfAssignment(event, history)
+	case ActionRolePermissionsUpdated:
+		return r.selfGrant(event, history)
+	}
+	return nil, nil
+}
+
+func (r *PrivilegeEscalation) selfAssignment(event Event, history History) (*Alert, error) {
+	targetUserID, ok := changedInt(event, "user_id")
+	if !ok || targetUserID != event.UserID {
+		return nil, nil
+	}
+	roleID, ok := changedInt(event, "role_id")
+	if !ok {
+		return nil, nil
+	}
+
+	privileged, err := history.IsPrivilegedRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	severity := SeverityMedium
+	if privileged {
+		severity = SeverityCritical
+	}
+	return newAlert(r, event, severity,
+		fmt.Sprintf("User %d assigned role %d to themselves", event.UserID, roleID),
+		fmt.Sprintf("event:%d", event.ID),
+		map[string]interface{}{"role_id": roleID, "privileged_role": privileged},
+	), nil
+}
+
+func (r *PrivilegeEscalation) selfGrant(event Event, history History) (*Alert, error) {
+	var granted []string
+	for _, change := range event.Changes {
+		if strings.HasSuffix(change.Field, ".can_approve") && change.After == true {
+			granted = append(granted, change.Field)
+		}
+	}
+	if len(granted) == 0 {
+		return nil, nil
+	}
+
+	holds, err := history.HoldsRole(event.UserID, event.ResourceID)
+	if err != nil || !holds {
+		return nil, err
+	}
+
+	return newAlert(r, event, SeverityCritical,
+		fmt.Sprintf("User %d granted approval rights to their own role %d", event.UserID, event.ResourceID),
+		fmt.Sprintf("event:%d", event.ID),
+		map[string]interface{}{"role_id": event.ResourceID, "granted": granted},
+	), nil
+}
+
+// changedInt reads a numeric field recorded in the changes of an event
+func changedInt(event Event, field string) (int64, bool) {
+	value, ok := event.ChangedValue(field)
+	if !ok {
+		return 0, false
+	}
+	number, ok := value.(float64)
+	return int64(number), ok
+}
+
+// OffHoursAccess alerts once per user and day on activity outside business hours
+type OffHoursAccess struct {
+	Hours BusinessHours
+}
+
+func (r *OffHoursAccess) Name() string { return "off_hours_access" }
+
+func (r *OffHoursAccess) Evaluate(event Event, history History) (*Alert, error) {
+	if event.UserID == 0 || !event.Success || r.Hours.Contains(event.CreatedAt) {
+		return nil, nil
+	}
+	if event.Action != ActionLoginSucceeded && event.Method == "" {
+		return nil, nil
+	}
+
+	local := event.CreatedAt
+	if r.Hours.Location != nil {
+		local = local.In(r.Hours.Location)
+	}
+
+	return newAlert(r, event, SeverityLow,
+		fmt.Sprintf("User %d active outside business hours", event.UserID),
+		fmt.Sprintf("user:%d:%s", event.UserID, local.Format("2006-01-02")),
+		map[string]interface{}{"local_time": local.Format("2006-01-02 15:04"), "action": event.Action},
+	), nil
+}
@@ -0,0 +1,218 @@
+package audittrail
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gin-scalable-api/pkg/logger"
+)
+
+// Anchor is the head of a chain exported outside the database. The chain hashes are plain
+// SHA-256 computed by a trigger, so someone with database access can rewrite entries and
+// recompute every later hash, or delete the tail of a chain, and the chain still verifies.
+// Anchors written elsewhere pin the chain: the entry at an anchored sequence must keep its
+// hash and the chain must reach every anchored sequence.
+type Anchor struct {
+	ChainKey   int64     `json:"chain_key"`
+	Seq        int64     `json:"chain_seq"`
+	Hash       string    `json:"hash"`
+	AnchoredAt time.Time `json:"anchored_at"`
+}
+
+// AnchorConfig configures the export of chain heads
+type AnchorConfig struct {
+	// File receives one NDJSON line per exported head. It must be kept out of reach of
+	// the database, e.g. shipped to write-once storage.
+	File string
+	// Interval between exports
+	Interval time.Duration
+}
+
+// Anchorer periodically appends the head of every chain that grew to the anchor file
+type Anchorer struct {
+	db     *sql.DB
+	config AnchorConfig
+	// heads holds the last exported sequence per chain, so unchanged chains are not repeated
+	heads map[int64]int64
+}
+
+func NewAnchorer(db *sql.DB, config AnchorConfig) *Anchorer {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Minute
+	}
+	return &Anchorer{db: db, config: config, heads: make(map[int64]int64)}
+}
+
+// Start exports the chain heads every interval until ctx is cancelled
+func (a *Anchorer) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.Run(time.Now()); err != nil {
+			logger.Error(fmt.Sprintf("Audit chain anchoring failed: %v", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run exports the heads of the chains that grew since the last run
+func (a *Anchorer) Run(now time.Time) ([]Anchor, error) {
+	heads, err := a.chainHeads()
+	if err != nil {
+		return nil, err
+	}
+
+	var anchors []Anchor
+	for _, head := range heads {
+		if a.heads[head.ChainKey] == head.Seq {
+			continue
+		}
+		head.AnchoredAt = now.UTC()
+		anchors = append(anchors, head)
+	}
+	if len(anchors) == 0 {
+		return nil, nil
+	}
+
+	if err := appendAnchors(a.config.File, anchors); err != nil {
+		return nil, err
+	}
+	for _, anchor := range anchors {
+		a.heads[anchor.ChainKey] = anchor.Seq
+	}
+	return anchors, nil
+}
+
+// chainHeads reads the last entry of every chain. Chains are taken from the companies table
+// and each head is read through the chain index rather than scanning audit_logs.
+func (a *Anchorer) chainHeads() ([]Anchor, error) {
+	rows, err := a.db.Query(`
+		SELECT keys.chain_key, head.chain_seq, head.hash
+		FROM (SELECT id AS chain_key FROM companies UNION SELECT 0) keys
+		CROSS JOIN LATERAL (
+			SELECT l.chain_seq, l.hash
+			FROM audit_logs l
+			WHERE COALESCE(l.company_id, 0) = keys.chain_key AND l.chain_seq IS NOT NULL
+			ORDER BY l.chain_seq DESC
+			LIMIT 1
+		) head
+		ORDER BY keys.chain_key
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit chain heads: %w", err)
+	}
+	defer rows.Close()
+
+	var heads []Anchor
+	for rows.Next() {
+		var head Anchor
+		if err := rows.Scan(&head.ChainKey, &head.Seq, &head.Hash); err != nil {
+			return nil, fmt.Errorf("failed to scan audit chain head: %w", err)
+		}
+		heads = append(heads, head)
+	}
+	return heads, rows.Err()
+}
+
+// appendAnchors appends anchors to the anchor file and syncs it
+func appendAnchors(path string, anchors []Anchor) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create audit anchor directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit anchor file: %w", err)
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, anchor := range anchors {
+		if err := encoder.Encode(anchor); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write audit anchor: %w", err)
+		}
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync audit anchor file: %w", err)
+	}
+	return file.Close()
+}
+
+// ReadAnchors reads an anchor file into the anchors of every chain. A missing file has no
+// anchors yet.
+func ReadAnchors(path string) (map[int64][]Anchor, error) {
+	anchors := make(map[int64][]Anchor)
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return anchors, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit anchor file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var anchor Anchor
+		if err := json.Unmarshal(scanner.Bytes(), &anchor); err != nil {
+			return nil, fmt.Errorf("invalid audit anchor on line %d: %w", line, err)
+		}
+		anchors[anchor.ChainKey] = append(anchors[anchor.ChainKey], anchor)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit anchor file: %w", err)
+	}
+	return anchors, nil
+}
+
+// checkpointArchiveMismatch compares a checkpoint with the last entry of its archive file and
+// describes the mismatch, or returns "" when they agree
+func checkpointArchiveMismatch(path string, seq int64, hash string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Sprintf("checkpoint archive cannot be read: %v", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Sprintf("checkpoint archive cannot be read: %v", err)
+	}
+
+	// The checkpoint is the last chained entry of the archive, as recorded by deleteArchived
+	var last *ArchivedEntry
+	decoder := json.NewDecoder(gz)
+	for decoder.More() {
+		var entry ArchivedEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return fmt.Sprintf("checkpoint archive cannot be read: %v", err)
+		}
+		if entry.ChainSeq != nil && entry.Hash != nil {
+			last = &entry
+		}
+	}
+
+	if last == nil || *last.ChainSeq != seq || *last.Hash != hash {
+		return "checkpoint does not match the last entry of its archive: the chain was rewritten"
+	}
+	return ""
+}
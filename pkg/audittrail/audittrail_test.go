@@ -0,0 +1,335 @@
+package audittrail
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResourceFromRoute(t *testing.T) {
+	cases := map[string]string{
+		"/api/v1/roles/:id":                     "role",
+		"/api/v1/companies/:id/password-policy": "company",
+		"/api/v1/access":                        "access",
+		"/api/v1/user-roles":                    "user_role",
+		"/api/v1/:id":                           "unknown",
+	}
+	for route, want := range cases {
+		if got := ResourceFromRoute(route); got != want {
+			t.Errorf("ResourceFromRoute(%q) = %q, want %q", route, got, want)
+		}
+	}
+}
+
+func TestResourceIDFromParams(t *testing.T) {
+	if got := ResourceIDFromParams("/api/v1/roles/:id", map[string]string{"id": "42"}); got != 42 {
+		t.Errorf("expected id param, got %d", got)
+	}
+	route := "/api/v1/oauth/consents/:client_id/users/:user_id"
+	if got := ResourceIDFromParams(route, map[string]string{"client_id": "abc", "user_id": "7"}); got != 7 {
+		t.Errorf("expected first numeric param, got %d", got)
+	}
+	if got := ResourceIDFromParams("/api/v1/roles", nil); got != 0 {
+		t.Errorf("expected 0 without params, got %d", got)
+	}
+}
+
+type recordingWriter struct {
+	mu      sync.Mutex
+	batches [][]Entry
+}
+
+func (w *recordingWriter) WriteBatch(entries []Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batches = append(w.batches, append([]Entry(nil), entries...))
+	return nil
+}
+
+func TestQueueBatchesAndDrainsOnClose(t *testing.T) {
+	writer := &recordingWriter{}
+	queue := NewQueue(writer, Config{BufferSize: 10, BatchSize: 2, FlushInterval: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		if !queue.Enqueue(Entry{UserID: int64(i + 1)}) {
+			t.Fatalf("entry %d rejected", i)
+		}
+	}
+	if err := queue.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+	for _, batch := range writer.batches {
+		if len(batch) > 2 {
+			t.Errorf("batch of %d exceeds batch size", len(batch))
+		}
+		total += len(batch)
+	}
+	if total != 5 {
+		t.Errorf("expected 5 written entries, got %d", total)
+	}
+	if queue.Enqueue(Entry{}) {
+		t.Error("expected entries to be rejected after close")
+	}
+}
+
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w *blockingWriter) WriteBatch(entries []Entry) error {
+	<-w.release
+	return nil
+}
+
+func TestQueueDropsWhenFull(t *testing.T) {
+	writer := &blockingWriter{release: make(chan struct{})}
+	queue := NewQueue(writer, Config{BufferSize: 1, BatchSize: 1, FlushInterval: time.Hour})
+
+	// The first entry is taken by the blocked writer, the second fills the buffer
+	queue.Enqueue(Entry{})
+	deadline := time.Now().Add(time.Second)
+	for !queue.Enqueue(Entry{}) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if queue.Enqueue(Entry{}) {
+		t.Fatal("expected entry to be dropped while the buffer is full")
+	}
+	if queue.Dropped() == 0 {
+		t.Error("expected dropped entries to be counted")
+	}
+
+	close(writer.release)
+	if err := queue.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiffReportsChangedFields(t *testing.T) {
+	before := map[string]interface{}{
+		"name": "Finance",
+		"modules": map[string]interface{}{
+			"12": map[string]bool{"can_read": true, "can_write": false},
+			"13": map[string]bool{"can_read": true},
+		},
+	}
+	after := map[string]interface{}{
+		"name": "Finance",
+		"modules": map[string]interface{}{
+			"12": map[string]bool{"can_read": true, "can_write": true},
+			"14": map[string]bool{"can_read": true},
+		},
+	}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"modules.12.can_write", "modules.13", "modules.14"}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i, field := range want {
+		if changes[i].Field != field {
+			t.Errorf("change %d: expected field %q, got %q", i, field, changes[i].Field)
+		}
+	}
+	if changes[0].Before != false || changes[0].After != true {
+		t.Errorf("unexpected values for can_write: %+v", changes[0])
+	}
+	if changes[1].After != nil || changes[2].Before != nil {
+		t.Errorf("removed and added modules should have nil sides: %+v", changes[1:])
+	}
+}
+
+func chainRecords(n int) []ChainRecord {
+	records := make([]ChainRecord, 0, n)
+	prev := GenesisHash
+	for i := 1; i <= n; i++ {
+		userID := int64(7)
+		action := "role_updated"
+		details := `{"changes": []}`
+		success := true
+		record := ChainRecord{
+			ID: int64(100 + i), ChainKey: 3, Seq: int64(i), UserID: &userID, Action: &action,
+			Details: &details, Success: &success, CreatedAtMicros: 1700000000000000 + int64(i), PrevHash: prev,
+		}
+		record.Hash = record.ComputeHash()
+		prev = record.Hash
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestComputeHashMatchesTriggerEncoding(t *testing.T) {
+	record := chainRecords(1)[0]
+	encoded := GenesisHash + "1:3;1:1;1:7;12:role_updated;N;N;15:{\"changes\": []};4:true;16:1700000000000001;"
+	sum := sha256.Sum256([]byte(encoded))
+	if want := hex.EncodeToString(sum[:]); record.Hash != want {
+		t.Errorf("hash = %s, want %s", record.Hash, want)
+	}
+}
+
+func TestChainVerifierAcceptsIntactChain(t *testing.T) {
+	verifier := NewChainVerifier(3)
+	for _, record := range chainRecords(5) {
+		verifier.Add(record)
+	}
+	report := verifier.Report()
+	if !report.Valid || report.Records != 5 || report.HeadSeq != 5 || report.FirstSeq != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestChainVerifierDetectsTampering(t *testing.T) {
+	records := chainRecords(4)
+	tampered := "role_deleted"
+	records[2].Action = &tampered
+
+	verifier := NewChainVerifier(3)
+	for _, record := range records {
+		verifier.Add(record)
+	}
+	report := verifier.Report()
+	if report.Valid || report.Break == nil || report.Break.Seq != 3 {
+		t.Errorf("expected break at seq 3, got %+v", report)
+	}
+}
+
+func TestChainVerifierDetectsDeletedRecords(t *testing.T) {
+	records := chainRecords(4)
+	records = append(records[:1], records[2:]...)
+
+	verifier := NewChainVerifier(3)
+	for _, record := range records {
+		verifier.Add(record)
+	}
+	if report := verifier.Report(); report.Valid || report.Break.Seq != 3 {
+		t.Errorf("expected gap at seq 3, got %+v", report)
+	}
+
+	archived := chainRecords(4)
+	anchored := NewChainVerifierFrom(3, archived[1].Seq, archived[1].Hash)
+	for _, record := range archived[2:] {
+		anchored.Add(record)
+	}
+	if report := anchored.Report(); !report.Valid || report.Records != 2 || report.FirstSeq != 3 {
+		t.Errorf("expected chain anchored after seq 2 to verify, got %+v", report)
+	}
+}
+
+func TestChainVerifierDetectsRewriteAgainstAnchors(t *testing.T) {
+	records := chainRecords(4)
+	anchors := []Anchor{{ChainKey: 3, Seq: 2, Hash: records[1].Hash}, {ChainKey: 3, Seq: 4, Hash: records[3].Hash}}
+
+	// Entry 2 edited and every later hash recomputed, as the trigger would
+	rewritten := chainRecords(4)
+	tampered := "role_deleted"
+	rewritten[1].Action = &tampered
+	for i := 1; i < len(rewritten); i++ {
+		rewritten[i].PrevHash = rewritten[i-1].Hash
+		rewritten[i].Hash = rewritten[i].ComputeHash()
+	}
+
+	plain := NewChainVerifier(3)
+	for _, record := range rewritten {
+		plain.Add(record)
+	}
+	if report := plain.Finish(); !report.Valid {
+		t.Fatalf("expected the recomputed chain to verify without anchors, got %+v", report)
+	}
+
+	anchored := NewChainVerifier(3)
+	anchored.ExpectAnchors(anchors)
+	for _, record := range rewritten {
+		anchored.Add(record)
+	}
+	if report := anchored.Finish(); report.Valid || report.Break.Seq != 2 {
+		t.Errorf("expected break at the anchored seq 2, got %+v", report)
+	}
+}
+
+func TestChainVerifierDetectsDeletedTailAgainstAnchors(t *testing.T) {
+	records := chainRecords(4)
+
+	verifier := NewChainVerifier(3)
+	verifier.ExpectAnchors([]Anchor{{ChainKey: 3, Seq: 4, Hash: records[3].Hash}})
+	for _, record := range records[:2] {
+		verifier.Add(record)
+	}
+	if report := verifier.Finish(); report.Valid || report.Break.Seq != 3 {
+		t.Errorf("expected the deleted tail to be reported from seq 3, got %+v", report)
+	}
+}
+
+func TestCheckpointArchiveMismatch(t *testing.T) {
+	archiver := NewArchiver(nil, RetentionConfig{Dir: t.TempDir()})
+	seq, hash := int64(2), "abc"
+	path, err := archiver.writeArchive(3, []ArchivedEntry{{ID: 1, ChainSeq: &seq, Hash: &hash}, {ID: 2}})
+	if err != nil {
+		t.Fatalf("writeArchive: %v", err)
+	}
+
+	if reason := checkpointArchiveMismatch(path, 2, "abc"); reason != "" {
+		t.Errorf("expected matching checkpoint, got %q", reason)
+	}
+	if reason := checkpointArchiveMismatch(path, 9, "abc"); reason == "" {
+		t.Error("expected a forged checkpoint to be reported")
+	}
+	if reason := checkpointArchiveMismatch(filepath.Join(t.TempDir(), "missing.ndjson.gz"), 2, "abc"); reason == "" {
+		t.Error("expected a missing archive to be reported")
+	}
+}
+
+func TestWriteArchiveProducesGzipNDJSON(t *testing.T) {
+	archiver := NewArchiver(nil, RetentionConfig{Dir: t.TempDir()})
+	seq := int64(1)
+	entries := []ArchivedEntry{
+		{ID: 10, Action: "role_updated", Details: json.RawMessage(`{"a":1}`), ChainSeq: &seq},
+		{ID: 11, Action: "role_deleted", Success: true},
+	}
+
+	path, err := archiver.writeArchive(4, entries)
+	if err != nil {
+		t.Fatalf("writeArchive: %v", err)
+	}
+	if filepath.Base(filepath.Dir(path)) != "company-4" {
+		t.Errorf("expected archive in the company directory, got %s", path)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.part")); len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var read []ArchivedEntry
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var entry ArchivedEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		read = append(read, entry)
+	}
+	if len(read) != 2 || read[0].ID != 10 || read[1].Action != "role_deleted" || *read[0].ChainSeq != 1 {
+		t.Errorf("unexpected archive content: %+v", read)
+	}
+}
@@ -0,0 +1,350 @@
+package audittrail
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GenesisHash is the previous hash of the first entry of every chain
+var GenesisHash = strings.Repeat("0", 64)
+
+// ChainRecord is an audit entry as covered by the hash chain. The values mirror the
+// columns hashed by the audit_logs_chain trigger of migration 025.
+type ChainRecord struct {
+	ID              int64
+	ChainKey        int64
+	Seq             int64
+	UserID          *int64
+	Action          *string
+	Resource        *string
+	ResourceID      *int64
+	Details         *string
+	Success         *bool
+	CreatedAtMicros int64
+	PrevHash        string
+	Hash            string
+}
+
+// ComputeHash recomputes the hash of a record from its content and PrevHash
+func (r ChainRecord) ComputeHash() string {
+	var b strings.Builder
+	b.WriteString(r.PrevHash)
+	writeChainField(&b, formatInt(&r.ChainKey))
+	writeChainField(&b, formatInt(&r.Seq))
+	writeChainField(&b, formatInt(r.UserID))
+	writeChainField(&b, r.Action)
+	writeChainField(&b, r.Resource)
+	writeChainField(&b, formatInt(r.ResourceID))
+	writeChainField(&b, r.Details)
+	writeChainField(&b, formatBool(r.Success))
+	writeChainField(&b, formatInt(&r.CreatedAtMicros))
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeChainField encodes a value as "<bytes>:<value>;" and NULL as "N;"
+func writeChainField(b *strings.Builder, value *string) {
+	if value == nil {
+		b.WriteString("N;")
+		return
+	}
+	b.WriteString(strconv.Itoa(len(*value)))
+	b.WriteByte(':')
+	b.WriteString(*value)
+	b.WriteByte(';')
+}
+
+func formatInt(value *int64) *string {
+	if value == nil {
+		return nil
+	}
+	formatted := strconv.FormatInt(*value, 10)
+	return &formatted
+}
+
+func formatBool(value *bool) *string {
+	if value == nil {
+		return nil
+	}
+	formatted := strconv.FormatBool(*value)
+	return &formatted
+}
+
+// ChainBreak is the first record at which a chain stops verifying
+type ChainBreak struct {
+	ID     int64  `json:"id"`
+	Seq    int64  `json:"seq"`
+	Reason string `json:"reason"`
+}
+
+// ChainReport is the result of verifying one chain
+type ChainReport struct {
+	CompanyID int64       `json:"company_id"`
+	Records   int64       `json:"records"`
+	FirstSeq  int64       `json:"first_seq"`
+	HeadSeq   int64       `json:"head_seq"`
+	HeadHash  string      `json:"head_hash"`
+	Valid     bool        `json:"valid"`
+	Break     *ChainBreak `json:"break,omitempty"`
+}
+
+// ChainVerifier checks the records of one chain in sequence order
+type ChainVerifier struct {
+	report   ChainReport
+	prevSeq  int64
+	prevHash string
+	// anchored maps exported sequences to their hash, lastAnchored is the highest of them
+	anchored     map[int64]string
+	lastAnchored int64
+}
+
+// NewChainVerifier starts a chain at the genesis hash
+func NewChainVerifier(chainKey int64) *ChainVerifier {
+	return NewChainVerifierFrom(chainKey, 0, GenesisHash)
+}
+
+// NewChainVerifierFrom starts a chain after a known record, e.g. the last record that
+// was archived and removed from the table
+func NewChainVerifierFrom(chainKey, seq int64, hash string) *ChainVerifier {
+	return &ChainVerifier{
+		report:   ChainReport{CompanyID: chainKey, Valid: true, HeadSeq: seq, HeadHash: hash},
+		prevSeq:  seq,
+		prevHash: hash,
+	}
+}
+
+// ExpectAnchors pins the chain to heads exported outside the database. Anchors before the
+// start of the chain, e.g. archived entries, cannot be checked and are ignored.
+func (v *ChainVerifier) ExpectAnchors(anchors []Anchor) {
+	v.anchored = make(map[int64]string, len(anchors))
+	for _, anchor := range anchors {
+		if anchor.Seq < v.prevSeq {
+			continue
+		}
+		v.anchored[anchor.Seq] = anchor.Hash
+		if anchor.Seq > v.lastAnchored {
+			v.lastAnchored = anchor.Seq
+		}
+	}
+
+	// The chain starts at a checkpoint that was anchored before it was archived
+	if hash, ok := v.anchored[v.prevSeq]; ok && v.prevSeq > 0 && hash != v.prevHash {
+		v.fail(ChainRecord{Seq: v.prevSeq}, "checkpoint hash differs from the exported anchor: the chain was rewritten")
+	}
+}
+
+// Add verifies the next record and reports whether the chain is still intact.
+// Records after the first break are ignored.
+func (v *ChainVerifier) Add(record ChainRecord) bool {
+	if !v.report.Valid {
+		return false
+	}
+
+	anchoredHash, anchored := v.anchored[record.Seq]
+	switch {
+	case record.Seq != v.prevSeq+1:
+		v.fail(record, fmt.Sprintf("expected sequence %d, found %d: records are missing", v.prevSeq+1, record.Seq))
+	case record.PrevHash != v.prevHash:
+		v.fail(record, "previous hash does not match the preceding record")
+	case record.ComputeHash() != record.Hash:
+		v.fail(record, "hash does not match the record content: the record was modified")
+	case anchored && anchoredHash != record.Hash:
+		v.fail(record, "hash differs from the exported anchor: the chain was rewritten")
+	default:
+		if v.report.Records == 0 {
+			v.report.FirstSeq = record.Seq
+		}
+		v.report.Records++
+		v.report.HeadSeq = record.Seq
+		v.report.HeadHash = record.Hash
+		v.prevSeq = record.Seq
+		v.prevHash = record.Hash
+		return true
+	}
+	return false
+}
+
+func (v *ChainVerifier) fail(record ChainRecord, reason string) {
+	v.report.Valid = false
+	v.report.Break = &ChainBreak{ID: record.ID, Seq: record.Seq, Reason: reason}
+}
+
+// Finish ends the chain: it must reach the last exported anchor, or its tail was deleted
+func (v *ChainVerifier) Finish() ChainReport {
+	if v.report.Valid && v.lastAnchored > v.prevSeq {
+		v.fail(ChainRecord{Seq: v.prevSeq + 1},
+			fmt.Sprintf("chain ends at sequence %d but sequence %d was exported: records were deleted", v.prevSeq, v.lastAnchored))
+	}
+	return v.report
+}
+
+// Report returns the verification result so far
+func (v *ChainVerifier) Report() ChainReport {
+	return v.report
+}
+
+// ChainStore reads the hash chains from audit_logs and checks them against the anchor file
+type ChainStore struct {
+	db *sql.DB
+	// anchorFile holds the exported chain heads; empty verifies without anchors
+	anchorFile string
+}
+
+func NewChainStore(db *sql.DB, anchorFile string) *ChainStore {
+	return &ChainStore{db: db, anchorFile: anchorFile}
+}
+
+func (s *ChainStore) anchors() (map[int64][]Anchor, error) {
+	if s.anchorFile == "" {
+		return map[int64][]Anchor{}, nil
+	}
+	return ReadAnchors(s.anchorFile)
+}
+
+// ChainKeys lists the companies that have a chain, in the table or the anchor file;
+// 0 is the chain of entries without a company
+func (s *ChainStore) ChainKeys() ([]int64, error) {
+	anchors, err := s.anchors()
+	if err != nil {
+		return nil, err
+	}
+	return s.chainKeys(anchors)
+}
+
+func (s *ChainStore) chainKeys(anchors map[int64][]Anchor) ([]int64, error) {
+	rows, err := s.db.Query(`
+		SELECT COALESCE(company_id, 0) FROM audit_logs WHERE chain_seq IS NOT NULL
+		UNION
+		SELECT chain_key FROM audit_chain_checkpoints
+		ORDER BY 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit chains: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []int64
+	seen := make(map[int64]bool)
+	for rows.Next() {
+		var key int64
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan audit chain: %w", err)
+		}
+		keys = append(keys, key)
+		seen[key] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// A chain deleted entirely is still listed by its anchors
+	for key := range anchors {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys, nil
+}
+
+// Verify streams the chain of a company through a verifier and returns the report.
+// Archived entries are skipped: the chain is verified from the last checkpoint on.
+func (s *ChainStore) Verify(chainKey int64) (*ChainReport, error) {
+	anchors, err := s.anchors()
+	if err != nil {
+		return nil, err
+	}
+	return s.verify(chainKey, anchors[chainKey])
+}
+
+func (s *ChainStore) verify(chainKey int64, anchors []Anchor) (*ChainReport, error) {
+	// A consistent snapshot, so a concurrent archive run does not show up as a gap
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	verifier := NewChainVerifier(chainKey)
+
+	var checkpointSeq int64
+	var checkpointHash, archiveFile string
+	err = tx.QueryRow(`SELECT chain_seq, hash, archive_file FROM audit_chain_checkpoints WHERE chain_key = $1`, chainKey).
+		Scan(&checkpointSeq, &checkpointHash, &archiveFile)
+	switch {
+	case err == nil:
+		verifier = NewChainVerifierFrom(chainKey, checkpointSeq, checkpointHash)
+		// Anchors before the checkpoint are skipped, so a checkpoint forged in the database
+		// could hide them; it must match the archive it was written with
+		if len(anchors) > 0 {
+			if reason := checkpointArchiveMismatch(archiveFile, checkpointSeq, checkpointHash); reason != "" {
+				verifier.fail(ChainRecord{Seq: checkpointSeq}, reason)
+			}
+		}
+	case err != sql.ErrNoRows:
+		return nil, fmt.Errorf("failed to read audit chain checkpoint: %w", err)
+	}
+	verifier.ExpectAnchors(anchors)
+
+	rows, err := tx.Query(`
+		SELECT id, chain_seq, user_id, action::TEXT, resource::TEXT, resource_id, details::TEXT, success,
+			(EXTRACT(EPOCH FROM created_at) * 1000000)::BIGINT, prev_hash, hash
+		FROM audit_logs
+		WHERE COALESCE(company_id, 0) = $1 AND chain_seq IS NOT NULL
+		ORDER BY chain_seq
+	`, chainKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit chain: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record := ChainRecord{ChainKey: chainKey}
+		var prevHash, hash sql.NullString
+		if err := rows.Scan(&record.ID, &record.Seq, &record.UserID, &record.Action, &record.Resource,
+			&record.ResourceID, &record.Details, &record.Success, &record.CreatedAtMicros, &prevHash, &hash); err != nil {
+			return nil, fmt.Errorf("failed to scan audit chain record: %w", err)
+		}
+		record.PrevHash = prevHash.String
+		record.Hash = hash.String
+
+		if !verifier.Add(record) {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit chain: %w", err)
+	}
+
+	report := verifier.Finish()
+	return &report, nil
+}
+
+// VerifyAll verifies every chain
+func (s *ChainStore) VerifyAll() ([]*ChainReport, error) {
+	anchors, err := s.anchors()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.chainKeys(anchors)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*ChainReport, 0, len(keys))
+	for _, key := range keys {
+		report, err := s.verify(key, anchors[key])
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
@@ -0,0 +1,113 @@
+package audittrail
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"gin-scalable-api/pkg/logger"
+)
+
+// Change is one modified field. Field is a dotted path into the JSON representation
+// of the changed value, e.g. "modules.12.can_write".
+type Change struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Diff compares the JSON representations of two values field by field. Objects are
+// compared recursively; arrays and scalars are compared as a whole, so collections
+// whose items should be diffed individually are best passed as maps keyed by ID.
+func Diff(before, after interface{}) ([]Change, error) {
+	beforeValue, err := toJSONValue(before)
+	if err != nil {
+		return nil, err
+	}
+	afterValue, err := toJSONValue(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	diffValues("", beforeValue, afterValue, &changes)
+	return changes, nil
+}
+
+func toJSONValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value for diff: %w", err)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode value for diff: %w", err)
+	}
+	return decoded, nil
+}
+
+func diffValues(path string, before, after interface{}, changes *[]Change) {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+
+	if !beforeIsMap || !afterIsMap {
+		if !reflect.DeepEqual(before, after) {
+			*changes = append(*changes, Change{Field: path, Before: before, After: after})
+		}
+		return
+	}
+
+	keys := make([]string, 0, len(beforeMap)+len(afterMap))
+	for key := range beforeMap {
+		keys = append(keys, key)
+	}
+	for key := range afterMap {
+		if _, seen := beforeMap[key]; !seen {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := key
+		if path != "" {
+			field = path + "." + key
+		}
+		diffValues(field, beforeMap[key], afterMap[key], changes)
+	}
+}
+
+// Recorder accepts audit entries; *Queue implements it
+type Recorder interface {
+	Enqueue(entry Entry) bool
+}
+
+// RecordChange writes an audit entry with the field-level diff between two states of
+// a resource. Nothing is recorded when the states are equal or the recorder is nil.
+func RecordChange(recorder Recorder, actorID int64, action, resource string, resourceID int64, before, after interface{}) {
+	if recorder == nil {
+		return
+	}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to diff %s %d for audit: %v", resource, resourceID, err))
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	recorder.Enqueue(Entry{
+		UserID:     actorID,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		StatusCode: 200,
+		Changes:    changes,
+		CreatedAt:  time.Now(),
+	})
+}
@@ -0,0 +1,126 @@
+// Package audittrail records mutating API requests in the audit log without
+// slowing down the requests themselves
+package audittrail
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is one recorded request
+type Entry struct {
+	UserID     int64
+	Action     string
+	Resource   string
+	ResourceID int64
+	Method     string
+	URL        string
+	Route      string
+	Params     map[string]string
+	StatusCode int
+	Latency    time.Duration
+	ClientIP   string
+	UserAgent  string
+	SessionID  string
+	ClientID   string
+	// Changes holds the field-level diff of entries recorded by services
+	Changes   []Change
+	CreatedAt time.Time
+}
+
+// Success reports whether the request completed without a client or server error
+func (e Entry) Success() bool {
+	return e.StatusCode < 400
+}
+
+// Details returns the fields stored in the details column of audit_logs. The keys
+// method, url, status and status_code match the entries written by the audit API.
+func (e Entry) Details() map[string]interface{} {
+	status := "success"
+	if !e.Success() {
+		status = "error"
+	}
+
+	details := map[string]interface{}{
+		"status":      status,
+		"status_code": e.StatusCode,
+	}
+
+	if e.Method == "" {
+		details["source"] = "service"
+	} else {
+		details["source"] = "middleware"
+		details["method"] = e.Method
+		details["url"] = e.URL
+		details["route"] = e.Route
+		details["latency_ms"] = float64(e.Latency.Microseconds()) / 1000
+		details["ip"] = e.ClientIP
+		details["user_agent"] = e.UserAgent
+	}
+
+	if len(e.Params) > 0 {
+		details["params"] = e.Params
+	}
+	if e.SessionID != "" {
+		details["session_id"] = e.SessionID
+	}
+	if e.ClientID != "" {
+		details["client_id"] = e.ClientID
+	}
+	if len(e.Changes) > 0 {
+		details["changes"] = e.Changes
+	}
+	return details
+}
+
+// apiPrefix is stripped from routes before the resource is inferred
+const apiPrefix = "/api/v1/"
+
+// Action names a request by its method and route template, e.g. "DELETE /api/v1/roles/:id",
+// so every request to the same endpoint shares one action
+func Action(method, route string) string {
+	return method + " " + route
+}
+
+// ResourceFromRoute infers the resource from the first segment of a route template,
+// in singular form: "/api/v1/roles/:id/permissions" is "role"
+func ResourceFromRoute(route string) string {
+	path := strings.TrimPrefix(route, apiPrefix)
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+		return "unknown"
+	}
+
+	segment = strings.ReplaceAll(segment, "-", "_")
+	switch {
+	case strings.HasSuffix(segment, "ies"):
+		return strings.TrimSuffix(segment, "ies") + "y"
+	case strings.HasSuffix(segment, "ss"):
+		return segment
+	case strings.HasSuffix(segment, "s"):
+		return strings.TrimSuffix(segment, "s")
+	default:
+		return segment
+	}
+}
+
+// ResourceIDFromParams picks the ID of the affected resource from the path parameters:
+// "id" when present, otherwise the first numeric parameter in route order. Returns 0
+// when the route has no numeric parameter.
+func ResourceIDFromParams(route string, params map[string]string) int64 {
+	if id, err := strconv.ParseInt(params["id"], 10, 64); err == nil {
+		return id
+	}
+
+	for _, segment := range strings.Split(route, "/") {
+		name, isParam := strings.CutPrefix(segment, ":")
+		if !isParam {
+			continue
+		}
+		if id, err := strconv.ParseInt(params[name], 10, 64); err == nil {
+			return id
+		}
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package audittrail
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gin-scalable-api/pkg/logger"
+)
+
+// Writer persists a batch of entries
+type Writer interface {
+	WriteBatch(entries []Entry) error
+}
+
+// Config sizes the queue
+type Config struct {
+	// BufferSize is the number of entries held in memory; entries beyond it are dropped
+	BufferSize int
+	// BatchSize is the number of entries written per insert
+	BatchSize int
+	// FlushInterval bounds how long an entry waits for its batch to fill
+	FlushInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		BufferSize:    1024,
+		BatchSize:     100,
+		FlushInterval: time.Second,
+	}
+}
+
+// Queue buffers entries and writes them in batches from a single background goroutine.
+// Enqueue never blocks: when the buffer is full the entry is dropped and counted.
+type Queue struct {
+	writer  Writer
+	config  Config
+	entries chan Entry
+	done    chan struct{}
+	dropped atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewQueue creates a queue and starts its writer goroutine
+func NewQueue(writer Writer, config Config) *Queue {
+	defaults := DefaultConfig()
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaults.BufferSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+
+	q := &Queue{
+		writer:  writer,
+		config:  config,
+		entries: make(chan Entry, config.BufferSize),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Enqueue adds an entry without blocking and reports whether it was accepted
+func (q *Queue) Enqueue(entry Entry) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return false
+	}
+
+	select {
+	case q.entries <- entry:
+		return true
+	default:
+		if dropped := q.dropped.Add(1); dropped == 1 || dropped%100 == 0 {
+			logger.Warning(fmt.Sprintf("Audit queue full, %d entries dropped so far", dropped))
+		}
+		return false
+	}
+}
+
+// Dropped returns the number of entries lost because the buffer was full
+func (q *Queue) Dropped() int64 {
+	return q.dropped.Load()
+}
+
+// Close stops accepting entries and waits until the buffered ones are written
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.entries)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("audit queue not drained: %w", ctx.Err())
+	}
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Entry, 0, q.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := q.writer.WriteBatch(batch); err != nil {
+			logger.Error(fmt.Sprintf("Failed to write %d audit entries: %v", len(batch), err))
+		}
+		batch = make([]Entry, 0, q.config.BatchSize)
+	}
+
+	for {
+		select {
+		case entry, ok := <-q.entries:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= q.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package audittrail
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gin-scalable-api/pkg/logger"
+)
+
+// RetentionConfig configures the archiving of old audit entries
+type RetentionConfig struct {
+	// Dir receives the archives, one sub directory per chain
+	Dir string
+	// DefaultDays applies to companies without a retention policy and to entries
+	// without a company; 0 keeps those entries forever
+	DefaultDays int
+	// Interval between archive runs
+	Interval time.Duration
+	// BatchSize bounds the entries written to one archive file
+	BatchSize int
+}
+
+// ArchivedEntry is one line of an archive file. The chain columns are kept so the
+// archived part of a chain can still be linked to the remaining entries.
+type ArchivedEntry struct {
+	ID         int64           `json:"id"`
+	CompanyID  *int64          `json:"company_id"`
+	UserID     *int64          `json:"user_id"`
+	Action     string          `json:"action"`
+	Resource   *string         `json:"resource"`
+	ResourceID *int64          `json:"resource_id"`
+	Details    json.RawMessage `json:"details"`
+	Success    bool            `json:"success"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ChainSeq   *int64          `json:"chain_seq"`
+	PrevHash   *string         `json:"prev_hash"`
+	Hash       *string         `json:"hash"`
+}
+
+// ArchiveResult summarizes what a run archived for one chain
+type ArchiveResult struct {
+	CompanyID int64
+	Archived  int64
+	Files     []string
+}
+
+// Archiver moves audit entries past their retention period into gzip compressed
+// NDJSON files and deletes them from audit_logs
+type Archiver struct {
+	db     *sql.DB
+	config RetentionConfig
+}
+
+func NewArchiver(db *sql.DB, config RetentionConfig) *Archiver {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 5000
+	}
+	return &Archiver{db: db, config: config}
+}
+
+// Start runs the archiver every interval until ctx is cancelled
+func (a *Archiver) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		a.runAndLog()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Archiver) runAndLog() {
+	results, err := a.Run(time.Now())
+	if err != nil {
+		logger.Error(fmt.Sprintf("Audit retention run failed: %v", err))
+	}
+	for _, result := range results {
+		logger.Info(fmt.Sprintf("Archived %d audit entries of chain %d to %d file(s)",
+			result.Archived, result.CompanyID, len(result.Files)))
+	}
+}
+
+// Run archives every chain once. Chains with nothing to archive are not reported.
+func (a *Archiver) Run(now time.Time) ([]ArchiveResult, error) {
+	retention, err := a.retentionDays()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ArchiveResult
+	for chainKey, days := range retention {
+		if days <= 0 {
+			continue
+		}
+
+		result := ArchiveResult{CompanyID: chainKey}
+		cutoff := now.AddDate(0, 0, -days)
+		for {
+			file, archived, err := a.archiveBatch(chainKey, cutoff)
+			if err != nil {
+				return results, fmt.Errorf("failed to archive audit chain %d: %w", chainKey, err)
+			}
+			if archived == 0 {
+				break
+			}
+			result.Archived += archived
+			result.Files = append(result.Files, file)
+		}
+
+		if result.Archived > 0 {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// retentionDays maps every chain to its retention period. Chains are taken from the
+// companies table rather than audit_logs, which is too large to scan every run.
+func (a *Archiver) retentionDays() (map[int64]int, error) {
+	rows, err := a.db.Query(`
+		SELECT keys.chain_key, COALESCE(p.retention_days, $1)
+		FROM (SELECT id AS chain_key FROM companies UNION SELECT 0) keys
+		LEFT JOIN audit_retention_policies p ON p.company_id = keys.chain_key
+	`, a.config.DefaultDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	retention := make(map[int64]int)
+	for rows.Next() {
+		var chainKey int64
+		var days int
+		if err := rows.Scan(&chainKey, &days); err != nil {
+			return nil, fmt.Errorf("failed to scan audit retention policy: %w", err)
+		}
+		retention[chainKey] = days
+	}
+	return retention, rows.Err()
+}
+
+// archiveBatch writes the oldest expired entries of a chain to one archive file and
+// deletes them. Only a prefix of the chain is archived, so the remaining entries
+// always verify from the checkpoint on.
+func (a *Archiver) archiveBatch(chainKey int64, cutoff time.Time) (string, int64, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Another instance archiving the same chain will pick up where this one stops
+	var locked bool
+	if err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock(hashtext('audit_logs_retention'), hashtext($1::TEXT))`,
+		chainKey).Scan(&locked); err != nil {
+		return "", 0, fmt.Errorf("failed to lock audit chain: %w", err)
+	}
+	if !locked {
+		return "", 0, nil
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, company_id, user_id, action, resource, resource_id, details, success, created_at,
+			chain_seq, prev_hash, hash
+		FROM audit_logs
+		WHERE COALESCE(company_id, 0) = $1 AND created_at < $2
+		  AND (chain_seq IS NULL OR chain_seq < COALESCE((
+			SELECT MIN(chain_seq) FROM audit_logs
+			WHERE COALESCE(company_id, 0) = $1 AND chain_seq IS NOT NULL AND created_at >= $2
+		  ), 9223372036854775807))
+		ORDER BY chain_seq NULLS FIRST, id
+		LIMIT $3
+	`, chainKey, cutoff, a.config.BatchSize)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read expired audit entries: %w", err)
+	}
+
+	var entries []ArchivedEntry
+	for rows.Next() {
+		var entry ArchivedEntry
+		var details []byte
+		if err := rows.Scan(&entry.ID, &entry.CompanyID, &entry.UserID, &entry.Action, &entry.Resource,
+			&entry.ResourceID, &details, &entry.Success, &entry.CreatedAt, &entry.ChainSeq, &entry.PrevHash,
+			&entry.Hash); err != nil {
+			rows.Close()
+			return "", 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if len(details) > 0 {
+			entry.Details = json.RawMessage(details)
+		}
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", 0, fmt.Errorf("failed to read expired audit entries: %w", err)
+	}
+	if len(entries) == 0 {
+		return "", 0, nil
+	}
+
+	file, err := a.writeArchive(chainKey, entries)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := deleteArchived(tx, chainKey, entries, file); err != nil {
+		os.Remove(file)
+		return "", 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		os.Remove(file)
+		return "", 0, fmt.Errorf("failed to commit audit archive: %w", err)
+	}
+
+	return file, int64(len(entries)), nil
+}
+
+func deleteArchived(tx *sql.Tx, chainKey int64, entries []ArchivedEntry, file string) error {
+	ids := make([]int64, 0, len(entries))
+	var last *ArchivedEntry
+	for i := range entries {
+		ids = append(ids, entries[i].ID)
+		if entries[i].ChainSeq != nil && entries[i].Hash != nil {
+			last = &entries[i]
+		}
+	}
+
+	idsJSON, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM audit_logs WHERE id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)
+	`, string(idsJSON)); err != nil {
+		return fmt.Errorf("failed to delete archived audit entries: %w", err)
+	}
+
+	if last == nil {
+		return nil
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO audit_chain_checkpoints (chain_key, chain_seq, hash, archive_file, archived_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (chain_key) DO UPDATE
+		SET chain_seq = EXCLUDED.chain_seq, hash = EXCLUDED.hash,
+			archive_file = EXCLUDED.archive_file, archived_at = EXCLUDED.archived_at
+	`, chainKey, *last.ChainSeq, *last.Hash, file); err != nil {
+		return fmt.Errorf("failed to record audit chain checkpoint: %w", err)
+	}
+	return nil
+}
+
+// writeArchive writes entries as gzip compressed NDJSON. The file only appears under
+// its final name once it is completely written and synced.
+func (a *Archiver) writeArchive(chainKey int64, entries []ArchivedEntry) (string, error) {
+	dir := filepath.Join(a.config.Dir, fmt.Sprintf("company-%d", chainKey))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create audit archive directory: %w", err)
+	}
+
+	name := fmt.Sprintf("audit-%d-%s-%d.ndjson.gz", chainKey, time.Now().UTC().Format("20060102T150405Z"), entries[0].ID)
+	path := filepath.Join(dir, name)
+
+	tmp, err := os.CreateTemp(dir, name+".*.part")
+	if err != nil {
+		return "", fmt.Errorf("failed to create audit archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	encoder := json.NewEncoder(gz)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			tmp.Close()
+			return "", fmt.Errorf("failed to write audit archive: %w", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write audit archive: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to sync audit archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close audit archive: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to finalize audit archive: %w", err)
+	}
+
+	return path, nil
+}
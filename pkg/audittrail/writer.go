@@ -0,0 +1,50 @@
+package audittrail
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SQLWriter inserts entries into audit_logs with one statement per batch
+type SQLWriter struct {
+	db *sql.DB
+}
+
+func NewSQLWriter(db *sql.DB) *SQLWriter {
+	return &SQLWriter{db: db}
+}
+
+func (w *SQLWriter) WriteBatch(entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	const columns = 7
+	placeholders := make([]string, 0, len(entries))
+	args := make([]interface{}, 0, len(entries)*columns)
+
+	for i, entry := range entries {
+		details, err := json.Marshal(entry.Details())
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+
+		base := i * columns
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7))
+		args = append(args,
+			sql.NullInt64{Int64: entry.UserID, Valid: entry.UserID != 0},
+			entry.Action, entry.Resource, entry.ResourceID, details, entry.Success(), entry.CreatedAt,
+		)
+	}
+
+	query := `INSERT INTO audit_logs (user_id, action, resource, resource_id, details, success, created_at) VALUES ` +
+		strings.Join(placeholders, ", ")
+
+	if _, err := w.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to insert audit entries: %w", err)
+	}
+	return nil
+}
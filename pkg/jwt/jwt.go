@@ -0,0 +1,254 @@
+// Package jwt signs and verifies compact JWS access tokens with RS256 or ES256
+// and publishes the verification keys as a JSON Web Key Set.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnknownKey       = errors.New("token signed with unknown key")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+)
+
+// Claims are the registered claims plus the RBAC specific ones carried by access tokens
+type Claims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  string   `json:"aud,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	UserID    int64    `json:"uid"`
+	CompanyID int64    `json:"cid,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	Abilities []string `json:"abilities"`
+	// TokenUse tells access tokens apart from other tokens signed with the same keys, such as ID tokens
+	TokenUse string `json:"token_use,omitempty"`
+}
+
+// TokenUseAccess marks access tokens
+const TokenUseAccess = "access"
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// Key is a private signing key identified by its key ID
+type Key struct {
+	ID        string
+	Algorithm string
+	Signer    crypto.Signer
+}
+
+// KeySet holds every key that may still verify tokens and the one used for signing
+type KeySet struct {
+	keys   map[string]*Key
+	active string
+}
+
+// NewKeySet creates a key set signing with activeKeyID
+func NewKeySet(keys []*Key, activeKeyID string) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key), active: activeKeyID}
+	for _, key := range keys {
+		if err := validateKey(key); err != nil {
+			return nil, err
+		}
+		ks.keys[key.ID] = key
+	}
+
+	if _, ok := ks.keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active key %q not found in key set", activeKeyID)
+	}
+
+	return ks, nil
+}
+
+// ActiveKeyID returns the key ID used for new tokens
+func (ks *KeySet) ActiveKeyID() string {
+	return ks.active
+}
+
+// Algorithms returns the distinct signing algorithms of the set
+func (ks *KeySet) Algorithms() []string {
+	seen := make(map[string]bool)
+	algorithms := []string{}
+	for _, alg := range []string{RS256, ES256} {
+		for _, key := range ks.keys {
+			if key.Algorithm == alg && !seen[alg] {
+				seen[alg] = true
+				algorithms = append(algorithms, alg)
+			}
+		}
+	}
+	return algorithms
+}
+
+// Sign creates a compact JWS for the claims using the active key
+func (ks *KeySet) Sign(claims *Claims) (string, error) {
+	return ks.SignPayload(claims)
+}
+
+// SignPayload signs an arbitrary JSON payload, e.g. OpenID Connect ID token claims
+func (ks *KeySet) SignPayload(claims interface{}) (string, error) {
+	key := ks.keys[ks.active]
+
+	headerJSON, err := json.Marshal(header{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodeSegment(headerJSON) + "." + encodeSegment(claimsJSON)
+	signature, err := sign(key, signingInput)
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + encodeSegment(signature), nil
+}
+
+// Verify checks signature, algorithm and time claims of a token
+func (ks *KeySet) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var h header
+	if err := decodeJSONSegment(parts[0], &h); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	key, ok := ks.keys[h.KeyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	// The algorithm is pinned by the key, never taken from the token
+	if h.Algorithm != key.Algorithm {
+		return nil, ErrInvalidSignature
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	if !verify(key, parts[0]+"."+parts[1], signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var claims Claims
+	if err := decodeJSONSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	unix := now.Unix()
+	if claims.ExpiresAt != 0 && unix >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && unix < claims.NotBefore {
+		return nil, ErrTokenNotYetValid
+	}
+
+	return &claims, nil
+}
+
+// LooksLikeJWT reports whether a bearer token has the compact JWS shape
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+func sign(key *Key, signingInput string) ([]byte, error) {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch k := key.Signer.(type) {
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS uses the fixed size R || S encoding, not ASN.1
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key.Signer)
+	}
+}
+
+func verify(key *Key, signingInput string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch k := key.Signer.Public().(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		if len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(k, digest[:], r, s)
+	default:
+		return false
+	}
+}
+
+func validateKey(key *Key) error {
+	switch key.Algorithm {
+	case RS256:
+		if _, ok := key.Signer.(*rsa.PrivateKey); !ok {
+			return fmt.Errorf("key %q: RS256 requires an RSA private key", key.ID)
+		}
+	case ES256:
+		k, ok := key.Signer.(*ecdsa.PrivateKey)
+		if !ok || k.Curve.Params().Name != "P-256" {
+			return fmt.Errorf("key %q: ES256 requires a P-256 EC private key", key.ID)
+		}
+	default:
+		return fmt.Errorf("key %q: unsupported algorithm %q", key.ID, key.Algorithm)
+	}
+	return nil
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeJSONSegment(segment string, dest interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
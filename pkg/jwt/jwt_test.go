@@ -0,0 +1,94 @@
+package jwt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestKeySet(t *testing.T, algorithm string, id string) *KeySet {
+	t.Helper()
+
+	signer, err := GenerateKey(algorithm)
+	if err != nil {
+		t.Fatalf("GenerateKey(%s) returned error: %v", algorithm, err)
+	}
+	ks, err := NewKeySet([]*Key{{ID: id, Algorithm: algorithm, Signer: signer}}, id)
+	if err != nil {
+		t.Fatalf("NewKeySet returned error: %v", err)
+	}
+	return ks
+}
+
+func TestSignAndVerifyRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	for _, algorithm := range []string{RS256, ES256} {
+		ks := newTestKeySet(t, algorithm, "k1")
+
+		token, err := ks.Sign(&Claims{ID: "abc", UserID: 7, CompanyID: 3, ExpiresAt: now.Unix() + 60, Abilities: []string{"/users"}})
+		if err != nil {
+			t.Fatalf("%s: Sign returned error: %v", algorithm, err)
+		}
+
+		claims, err := ks.Verify(token, now)
+		if err != nil {
+			t.Fatalf("%s: Verify returned error: %v", algorithm, err)
+		}
+		if claims.UserID != 7 || claims.CompanyID != 3 || claims.ID != "abc" || len(claims.Abilities) != 1 {
+			t.Errorf("%s: unexpected claims %+v", algorithm, claims)
+		}
+
+		if _, err := ks.Verify(token, now.Add(time.Minute)); err != ErrTokenExpired {
+			t.Errorf("%s: expected ErrTokenExpired, got %v", algorithm, err)
+		}
+	}
+}
+
+func TestVerifyRejectsTamperedAndForeignTokens(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ks := newTestKeySet(t, ES256, "k1")
+
+	token, _ := ks.Sign(&Claims{UserID: 7, ExpiresAt: now.Unix() + 60})
+
+	parts := strings.Split(token, ".")
+	forged := parts[0] + "." + encodeSegment([]byte(`{"uid":1,"exp":9999999999}`)) + "." + parts[2]
+	if _, err := ks.Verify(forged, now); err != ErrInvalidSignature {
+		t.Errorf("expected ErrInvalidSignature for modified payload, got %v", err)
+	}
+
+	// Same key ID, different key material
+	other := newTestKeySet(t, ES256, "k1")
+	if _, err := other.Verify(token, now); err != ErrInvalidSignature {
+		t.Errorf("expected ErrInvalidSignature for foreign key, got %v", err)
+	}
+
+	unknown := newTestKeySet(t, ES256, "k2")
+	if _, err := unknown.Verify(token, now); err != ErrUnknownKey {
+		t.Errorf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestRotatedKeysKeepVerifying(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	oldSigner, _ := GenerateKey(RS256)
+	newSigner, _ := GenerateKey(ES256)
+	keys := []*Key{
+		{ID: "2024", Algorithm: RS256, Signer: oldSigner},
+		{ID: "2025", Algorithm: ES256, Signer: newSigner},
+	}
+
+	before, _ := NewKeySet(keys, "2024")
+	after, _ := NewKeySet(keys, "2025")
+
+	token, _ := before.Sign(&Claims{UserID: 1, ExpiresAt: now.Unix() + 60})
+	if _, err := after.Verify(token, now); err != nil {
+		t.Errorf("token signed with previous key rejected after rotation: %v", err)
+	}
+
+	jwks := after.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].KeyType != "RSA" || jwks.Keys[1].KeyType != "EC" {
+		t.Errorf("unexpected JWKS %+v", jwks)
+	}
+}
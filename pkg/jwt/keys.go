@@ -0,0 +1,181 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// JSONWebKey is the public part of a signing key (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, sorted by key ID
+func (ks *KeySet) JWKS() JSONWebKeySet {
+	ids := make([]string, 0, len(ks.keys))
+	for id := range ks.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, id := range ids {
+		key := ks.keys[id]
+		jwk := JSONWebKey{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+
+		switch pub := key.Signer.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			jwk.KeyType = "EC"
+			jwk.Curve = "P-256"
+			x := make([]byte, 32)
+			y := make([]byte, 32)
+			pub.X.FillBytes(x)
+			pub.Y.FillBytes(y)
+			jwk.X = base64.RawURLEncoding.EncodeToString(x)
+			jwk.Y = base64.RawURLEncoding.EncodeToString(y)
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+// GenerateKey creates a new private key for the algorithm
+func GenerateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case RS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case ES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+}
+
+// EncodePrivateKeyPEM encodes a private key as PKCS#8 PEM
+func EncodePrivateKeyPEM(signer crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// LoadKeysFromDir loads every <kid>.pem private key of a directory. The algorithm of
+// each key follows from its type, so RS256 and ES256 keys can coexist during rotation.
+func LoadKeysFromDir(dir string) ([]*Key, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*Key
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key %s: %w", file, err)
+		}
+
+		signer, err := parsePrivateKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %s: %w", file, err)
+		}
+
+		key := &Key{
+			ID:     strings.TrimSuffix(filepath.Base(file), ".pem"),
+			Signer: signer,
+		}
+		switch signer.(type) {
+		case *rsa.PrivateKey:
+			key.Algorithm = RS256
+		case *ecdsa.PrivateKey:
+			key.Algorithm = ES256
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// LoadKeySet loads the signing keys from dir and activates activeKeyID. Without a
+// directory a single ephemeral key is generated, which only suits local development
+// because tokens do not survive a restart and cannot be shared between instances.
+func LoadKeySet(dir, activeKeyID, algorithm string) (*KeySet, error) {
+	if dir == "" {
+		signer, err := GenerateKey(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		key := &Key{ID: "ephemeral", Algorithm: algorithm, Signer: signer}
+		return NewKeySet([]*Key{key}, key.ID)
+	}
+
+	keys, err := LoadKeysFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %s", dir)
+	}
+
+	// Default to the last key in lexical order, so date based key IDs rotate naturally
+	if activeKeyID == "" {
+		activeKeyID = keys[len(keys)-1].ID
+	}
+
+	return NewKeySet(keys, activeKeyID)
+}
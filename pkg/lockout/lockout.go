@@ -0,0 +1,249 @@
+// Package lockout tracks failed login attempts in Redis per account and per IP address,
+// slows down repeated failures and temporarily locks accounts and addresses.
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Config holds the thresholds of the login guard
+type Config struct {
+	// MaxAttempts failed logins of one account within Window lock the account
+	MaxAttempts int
+	// IPMaxAttempts failed logins from one IP within Window lock the IP, whatever the account
+	IPMaxAttempts int
+	Window        time.Duration
+	Duration      time.Duration
+	// Failures beyond DelayAfter delay the next attempt by BaseDelay, doubling up to MaxDelay
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultConfig returns the thresholds used when nothing is configured
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:   5,
+		IPMaxAttempts: 20,
+		Window:        15 * time.Minute,
+		Duration:      15 * time.Minute,
+		DelayAfter:    2,
+		BaseDelay:     time.Second,
+		MaxDelay:      30 * time.Second,
+	}
+}
+
+// Error is returned while an account or IP may not attempt to log in
+type Error struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, as sent in the Retry-After header
+func (e *Error) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+func (e *Error) Error() string {
+	seconds := e.RetryAfterSeconds()
+	if e.Locked {
+		return fmt.Sprintf("login dikunci sementara karena terlalu banyak percobaan login gagal, coba lagi dalam %d detik (too many attempts)", seconds)
+	}
+	return fmt.Sprintf("terlalu banyak percobaan login gagal, coba lagi dalam %d detik (too many attempts)", seconds)
+}
+
+// Failure describes the state after a failed attempt was recorded
+type Failure struct {
+	Attempts      int64
+	AccountLocked bool
+	IPLocked      bool
+}
+
+// Guard enforces the login attempt limits
+type Guard struct {
+	redis  *redis.Client
+	config Config
+}
+
+func NewGuard(redis *redis.Client, config Config) *Guard {
+	return &Guard{redis: redis, config: config}
+}
+
+func failuresKey(scope, subject string) string {
+	return fmt.Sprintf("lockout:fail:%s:%s", scope, subject)
+}
+
+func lockKey(scope, subject string) string {
+	return fmt.Sprintf("lockout:lock:%s:%s", scope, subject)
+}
+
+func delayKey(scope, subject string) string {
+	return fmt.Sprintf("lockout:delay:%s:%s", scope, subject)
+}
+
+func accountSubject(userID int64) string {
+	return strconv.FormatInt(userID, 10)
+}
+
+// identitySubject normalizes a login identifier that matches no account
+func identitySubject(identity string) string {
+	return strings.ToLower(strings.TrimSpace(identity))
+}
+
+// CheckIP returns an *Error while the IP is locked
+func (g *Guard) CheckIP(ip string) error {
+	return g.check("ip", ip)
+}
+
+// CheckAccount returns an *Error while the account is locked or its progressive delay runs
+func (g *Guard) CheckAccount(userID int64) error {
+	return g.check("user", accountSubject(userID))
+}
+
+// CheckIdentity returns an *Error while a login identifier that matches no account is locked
+// or its progressive delay runs, so unknown identifiers answer like existing accounts
+func (g *Guard) CheckIdentity(identity string) error {
+	return g.check("identity", identitySubject(identity))
+}
+
+func (g *Guard) check(scope, subject string) error {
+	ctx := context.Background()
+
+	if ttl := g.redis.PTTL(ctx, lockKey(scope, subject)).Val(); ttl > 0 {
+		return &Error{RetryAfter: ttl, Locked: true}
+	}
+	if ttl := g.redis.PTTL(ctx, delayKey(scope, subject)).Val(); ttl > 0 {
+		return &Error{RetryAfter: ttl}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt for the IP and the account
+func (g *Guard) RecordFailure(userID int64, ip string) (*Failure, error) {
+	return g.recordFailure("user", accountSubject(userID), ip)
+}
+
+// RecordIdentityFailure counts a failed attempt for the IP and a login identifier that
+// matches no account. The identifier is locked and delayed like an account.
+func (g *Guard) RecordIdentityFailure(identity, ip string) (*Failure, error) {
+	return g.recordFailure("identity", identitySubject(identity), ip)
+}
+
+func (g *Guard) recordFailure(scope, subject, ip string) (*Failure, error) {
+	failure := &Failure{}
+
+	ipAttempts, err := g.increment("ip", ip)
+	if err != nil {
+		return nil, err
+	}
+	if ipAttempts >= int64(g.config.IPMaxAttempts) {
+		locked, err := g.lock("ip", ip)
+		if err != nil {
+			return nil, err
+		}
+		failure.IPLocked = locked
+	}
+
+	attempts, err := g.increment(scope, subject)
+	if err != nil {
+		return nil, err
+	}
+	failure.Attempts = attempts
+
+	if attempts >= int64(g.config.MaxAttempts) {
+		locked, err := g.lock(scope, subject)
+		if err != nil {
+			return nil, err
+		}
+		failure.AccountLocked = locked
+		return failure, nil
+	}
+
+	if delay := g.delayFor(attempts); delay > 0 {
+		if err := g.redis.Set(context.Background(), delayKey(scope, subject), 1, delay).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return failure, nil
+}
+
+// RecordSuccess clears the failure counter of an account after a successful login.
+// The IP counter is kept, so one valid account cannot be used to reset a stuffing run.
+func (g *Guard) RecordSuccess(userID int64) error {
+	subject := accountSubject(userID)
+	return g.redis.Del(context.Background(), failuresKey("user", subject), delayKey("user", subject)).Err()
+}
+
+// Unlock lifts the lockout of an account and reports whether it was locked
+func (g *Guard) Unlock(userID int64) (bool, error) {
+	subject := accountSubject(userID)
+	ctx := context.Background()
+
+	wasLocked, err := g.redis.Exists(ctx, lockKey("user", subject)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	if err := g.redis.Del(ctx, lockKey("user", subject), failuresKey("user", subject), delayKey("user", subject)).Err(); err != nil {
+		return false, err
+	}
+
+	return wasLocked > 0, nil
+}
+
+// AccountLockedFor returns the remaining lockout of an account, 0 when not locked
+func (g *Guard) AccountLockedFor(userID int64) (time.Duration, error) {
+	ttl, err := g.redis.PTTL(context.Background(), lockKey("user", accountSubject(userID))).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (g *Guard) increment(scope, subject string) (int64, error) {
+	ctx := context.Background()
+	key := failuresKey(scope, subject)
+
+	pipe := g.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	// The window starts with the first failure and is not extended by later ones
+	pipe.ExpireNX(ctx, key, g.config.Window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// lock sets the lock key and reports whether this call created it
+func (g *Guard) lock(scope, subject string) (bool, error) {
+	return g.redis.SetNX(context.Background(), lockKey(scope, subject), 1, g.config.Duration).Result()
+}
+
+// delayFor returns the progressive delay after a number of failed attempts
+func (g *Guard) delayFor(attempts int64) time.Duration {
+	over := attempts - int64(g.config.DelayAfter)
+	if over <= 0 || g.config.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := g.config.BaseDelay
+	for i := int64(1); i < over && delay < g.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > g.config.MaxDelay {
+		delay = g.config.MaxDelay
+	}
+	return delay
+}
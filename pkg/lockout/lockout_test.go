@@ -0,0 +1,37 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelayForIsProgressiveAndCapped(t *testing.T) {
+	guard := NewGuard(nil, DefaultConfig())
+
+	expected := map[int64]time.Duration{
+		1: 0,
+		2: 0,
+		3: time.Second,
+		4: 2 * time.Second,
+		5: 4 * time.Second,
+		8: 30 * time.Second,
+		9: 30 * time.Second,
+	}
+	for attempts, want := range expected {
+		if got := guard.delayFor(attempts); got != want {
+			t.Errorf("delayFor(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestErrorRoundsRetryAfterUp(t *testing.T) {
+	err := &Error{RetryAfter: 1500 * time.Millisecond}
+	if got := err.RetryAfterSeconds(); got != 2 {
+		t.Fatalf("RetryAfterSeconds() = %d, want 2", got)
+	}
+
+	err = &Error{RetryAfter: 3 * time.Second}
+	if got := err.RetryAfterSeconds(); got != 3 {
+		t.Fatalf("RetryAfterSeconds() = %d, want 3", got)
+	}
+}
@@ -0,0 +1,73 @@
+// Package mailer delivers transactional emails through a pluggable Sender
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages; implementations must be safe for concurrent use
+type Sender interface {
+	Send(message Message) error
+}
+
+// Config selects and configures a Sender
+type Config struct {
+	// Driver is "smtp", "file" or "log"
+	Driver   string
+	From     string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// Dir receives one .eml file per message with the file driver
+	Dir string
+}
+
+// New returns the Sender configured by the driver name
+func New(config Config) (Sender, error) {
+	switch config.Driver {
+	case "smtp":
+		return NewSMTPSender(config.Host, config.Port, config.Username, config.Password, config.From), nil
+	case "file":
+		return NewFileSender(config.Dir, config.From)
+	case "log", "":
+		return NewLogSender(config.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", config.Driver)
+	}
+}
+
+// render builds the RFC 5322 representation of a message
+func render(from string, message Message) ([]byte, error) {
+	if _, err := mail.ParseAddress(message.To); err != nil {
+		return nil, fmt.Errorf("invalid recipient address: %w", err)
+	}
+	if strings.ContainsAny(message.Subject, "\r\n") {
+		return nil, fmt.Errorf("invalid subject: contains a line break")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderRejectsHeaderInjection(t *testing.T) {
+	if _, err := render("no-reply@example.com", Message{To: "user@example.com", Subject: "Hi\r\nBcc: x@example.com"}); err == nil {
+		t.Fatal("expected subject with a line break to be rejected")
+	}
+	if _, err := render("no-reply@example.com", Message{To: "user@example.com\r\nBcc: x@example.com", Subject: "Hi"}); err == nil {
+		t.Fatal("expected invalid recipient to be rejected")
+	}
+}
+
+func TestFileSenderWritesMessage(t *testing.T) {
+	dir := t.TempDir()
+	sender, err := NewFileSender(dir, "RBAC Service <no-reply@example.com>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sender.Send(Message{To: "user@example.com", Subject: "Reset password", Body: "line one\nline two"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one .eml file, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"To: user@example.com\r\n", "Subject: Reset password\r\n", "line one\r\nline two"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("message does not contain %q", want)
+		}
+	}
+}
+
+func TestEnvelopeAddressStripsDisplayName(t *testing.T) {
+	if got := envelopeAddress("RBAC Service <no-reply@example.com>"); got != "no-reply@example.com" {
+		t.Fatalf("envelopeAddress() = %q", got)
+	}
+}
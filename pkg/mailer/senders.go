@@ -0,0 +1,112 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gin-scalable-api/pkg/logger"
+)
+
+// SMTPSender delivers messages through an SMTP server. STARTTLS is used when the
+// server offers it; credentials are only sent over TLS or to localhost.
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPSender(host string, port int, username, password, from string) *SMTPSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPSender{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s *SMTPSender) Send(message Message) error {
+	data, err := render(s.from, message)
+	if err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(s.addr, s.auth, envelopeAddress(s.from), []string{message.To}, data); err != nil {
+		return fmt.Errorf("failed to send mail via smtp: %w", err)
+	}
+	return nil
+}
+
+// FileSender writes every message as an .eml file, for local development and tests
+type FileSender struct {
+	dir  string
+	from string
+}
+
+func NewFileSender(dir, from string) (*FileSender, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mail directory is required for the file driver")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	return &FileSender{dir: dir, from: from}, nil
+}
+
+func (s *FileSender) Send(message Message) error {
+	data, err := render(s.from, message)
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405"), hex.EncodeToString(suffix))
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
+
+// LogSender writes every message to the application log. Messages can contain
+// secrets such as reset links, so it must not be used in production.
+type LogSender struct {
+	from string
+}
+
+func NewLogSender(from string) *LogSender {
+	return &LogSender{from: from}
+}
+
+func (s *LogSender) Send(message Message) error {
+	data, err := render(s.from, message)
+	if err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Mail (log driver):\n%s", data))
+	return nil
+}
+
+// envelopeAddress strips the display name from a From header value
+func envelopeAddress(from string) string {
+	if address, err := mail.ParseAddress(from); err == nil {
+		return address.Address
+	}
+	return from
+}
@@ -0,0 +1,55 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	blocklistMu sync.RWMutex
+	blocklist   = map[string]struct{}{}
+)
+
+// LoadBlocklist reads common passwords, one per line, and replaces the active blocklist.
+// Empty lines and lines starting with # are ignored; matching is case-insensitive.
+func LoadBlocklist(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open password blocklist: %w", err)
+	}
+	defer file.Close()
+
+	entries := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read password blocklist: %w", err)
+	}
+
+	SetBlocklist(entries)
+	return len(entries), nil
+}
+
+// SetBlocklist replaces the active blocklist; keys must be lower case
+func SetBlocklist(entries map[string]struct{}) {
+	blocklistMu.Lock()
+	defer blocklistMu.Unlock()
+	blocklist = entries
+}
+
+// IsCommonPassword reports whether a password is on the blocklist
+func IsCommonPassword(plain string) bool {
+	blocklistMu.RLock()
+	defer blocklistMu.RUnlock()
+	_, blocked := blocklist[strings.ToLower(plain)]
+	return blocked
+}
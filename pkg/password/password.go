@@ -20,6 +20,8 @@ func VerifyPassword(hashedPassword, plainPassword string) error {
 }
 
 // IsValidPassword checks if a password meets minimum requirements
+//
+// Deprecated: use Policy.Validate, which enforces the configured password policy.
 func IsValidPassword(password string) bool {
 	// Minimum 6 characters, maximum 100 characters
 	return len(password) >= 6 && len(password) <= 100
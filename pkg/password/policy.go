@@ -0,0 +1,151 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Policy describes the requirements a new password must meet
+type Policy struct {
+	MinLength        int  `json:"min_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+	// HistoryCount forbids reusing the last N passwords, 0 disables the check
+	HistoryCount int `json:"history_count"`
+	// MaxAgeDays forces a change at login once the password is older, 0 disables expiry
+	MaxAgeDays int `json:"max_age_days"`
+}
+
+// MaxLength bounds every policy; bcrypt ignores everything beyond 72 bytes anyway
+const MaxLength = 72
+
+// Strictest combines two policies into one that satisfies both.
+// Used for users that belong to several companies.
+func (p Policy) Strictest(other Policy) Policy {
+	return Policy{
+		MinLength:        max(p.MinLength, other.MinLength),
+		RequireUppercase: p.RequireUppercase || other.RequireUppercase,
+		RequireLowercase: p.RequireLowercase || other.RequireLowercase,
+		RequireDigit:     p.RequireDigit || other.RequireDigit,
+		RequireSymbol:    p.RequireSymbol || other.RequireSymbol,
+		HistoryCount:     max(p.HistoryCount, other.HistoryCount),
+		MaxAgeDays:       minPositive(p.MaxAgeDays, other.MaxAgeDays),
+	}
+}
+
+// IsExpired reports whether a password set at changedAt must be changed.
+// A nil changedAt marks a password that must be changed at the next login.
+func (p Policy) IsExpired(changedAt *time.Time, now time.Time) bool {
+	if changedAt == nil {
+		return true
+	}
+	if p.MaxAgeDays <= 0 {
+		return false
+	}
+	return now.After(changedAt.AddDate(0, 0, p.MaxAgeDays))
+}
+
+// PersonalInfo holds the account attributes a password may not contain
+type PersonalInfo struct {
+	Name         string
+	Email        string
+	UserIdentity string
+}
+
+// PolicyError lists every requirement a password failed
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("password tidak memenuhi kebijakan (invalid password): %s", strings.Join(e.Violations, "; "))
+}
+
+// Validate checks a password against the policy, the blocklist and the personal info of its owner.
+// It returns a *PolicyError listing all violations, or nil.
+func (p Policy) Validate(plain string, info PersonalInfo) error {
+	var violations []string
+
+	length := len([]rune(plain))
+	if length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("minimal %d karakter", p.MinLength))
+	}
+	if len(plain) > MaxLength {
+		violations = append(violations, fmt.Sprintf("maksimal %d byte", MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range plain {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, "harus mengandung huruf besar")
+	}
+	if p.RequireLowercase && !hasLower {
+		violations = append(violations, "harus mengandung huruf kecil")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "harus mengandung angka")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "harus mengandung simbol")
+	}
+
+	if IsCommonPassword(plain) {
+		violations = append(violations, "terlalu umum dan mudah ditebak")
+	}
+	if containsPersonalInfo(plain, info) {
+		violations = append(violations, "tidak boleh mengandung nama, email, atau user identity")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// personalInfoMinLength skips fragments too short to be meaningful, e.g. initials
+const personalInfoMinLength = 3
+
+func containsPersonalInfo(plain string, info PersonalInfo) bool {
+	lowered := strings.ToLower(plain)
+
+	var fragments []string
+	fragments = append(fragments, strings.Fields(info.Name)...)
+	if local, _, found := strings.Cut(info.Email, "@"); found {
+		fragments = append(fragments, local)
+	}
+	fragments = append(fragments, info.UserIdentity)
+
+	for _, fragment := range fragments {
+		fragment = strings.ToLower(strings.TrimSpace(fragment))
+		if len(fragment) >= personalInfoMinLength && strings.Contains(lowered, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func minPositive(a, b int) int {
+	switch {
+	case a <= 0:
+		return b
+	case b <= 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
@@ -0,0 +1,84 @@
+package password
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	MinLength:        10,
+	RequireUppercase: true,
+	RequireLowercase: true,
+	RequireDigit:     true,
+	RequireSymbol:    true,
+}
+
+func TestValidateAcceptsCompliantPassword(t *testing.T) {
+	if err := testPolicy.Validate("Tr0ub4dor&3x", PersonalInfo{Name: "Budi Santoso", Email: "budi@example.com"}); err != nil {
+		t.Fatalf("expected password to pass, got %v", err)
+	}
+}
+
+func TestValidateListsEveryViolation(t *testing.T) {
+	err := testPolicy.Validate("abc", PersonalInfo{})
+
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected *PolicyError, got %v", err)
+	}
+	// too short, no upper case, no digit, no symbol
+	if len(policyErr.Violations) != 4 {
+		t.Fatalf("expected 4 violations, got %d: %v", len(policyErr.Violations), policyErr.Violations)
+	}
+}
+
+func TestValidateRejectsPersonalInfo(t *testing.T) {
+	info := PersonalInfo{Name: "Budi Santoso", Email: "b.santoso@example.com", UserIdentity: "EMP-0042"}
+
+	for _, plain := range []string{"Santoso#2025", "B.santoso#2025", "emp-0042#Abcd"} {
+		if err := testPolicy.Validate(plain, info); err == nil {
+			t.Errorf("expected %q to be rejected", plain)
+		}
+	}
+}
+
+func TestValidateRejectsBlocklistedPassword(t *testing.T) {
+	SetBlocklist(map[string]struct{}{"p@ssw0rd2025!": {}})
+	defer SetBlocklist(map[string]struct{}{})
+
+	if err := testPolicy.Validate("P@ssw0rd2025!", PersonalInfo{}); err == nil {
+		t.Fatal("expected blocklisted password to be rejected")
+	}
+}
+
+func TestStrictestCombinesPolicies(t *testing.T) {
+	a := Policy{MinLength: 8, RequireDigit: true, HistoryCount: 3, MaxAgeDays: 0}
+	b := Policy{MinLength: 12, RequireSymbol: true, HistoryCount: 5, MaxAgeDays: 90}
+
+	got := a.Strictest(b)
+	want := Policy{MinLength: 12, RequireDigit: true, RequireSymbol: true, HistoryCount: 5, MaxAgeDays: 90}
+	if got != want {
+		t.Fatalf("Strictest() = %+v, want %+v", got, want)
+	}
+}
+
+func TestIsExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -10)
+	old := now.AddDate(0, 0, -100)
+
+	policy := Policy{MaxAgeDays: 90}
+	if policy.IsExpired(&recent, now) {
+		t.Error("recent password should not be expired")
+	}
+	if !policy.IsExpired(&old, now) {
+		t.Error("old password should be expired")
+	}
+	if !policy.IsExpired(nil, now) {
+		t.Error("password marked for change should be expired")
+	}
+	if (Policy{}).IsExpired(&old, now) {
+		t.Error("password should never expire without a maximum age")
+	}
+}
@@ -0,0 +1,183 @@
+package password
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Store reads password policies and keeps the password history of users
+type Store struct {
+	db   *sql.DB
+	base Policy
+}
+
+// NewStore creates a store; base applies to users whose companies define no policy
+func NewStore(db *sql.DB, base Policy) *Store {
+	return &Store{db: db, base: base}
+}
+
+// BasePolicy returns the policy used when no company policy applies
+func (s *Store) BasePolicy() Policy {
+	return s.base
+}
+
+// EffectivePolicy returns the strictest policy of all companies the user has roles in,
+// or the base policy when none of them defines one
+func (s *Store) EffectivePolicy(userID int64) (Policy, error) {
+	return s.strictestPolicy(`
+		SELECT pp.min_length, pp.require_uppercase, pp.require_lowercase, pp.require_digit,
+		       pp.require_symbol, pp.history_count, pp.max_age_days
+		FROM password_policies pp
+		WHERE pp.company_id IN (SELECT DISTINCT company_id FROM user_roles WHERE user_id = $1)
+	`, userID)
+}
+
+// CompanyPolicy returns the policy of a company, or the base policy when it defines none
+func (s *Store) CompanyPolicy(companyID int64) (Policy, error) {
+	return s.strictestPolicy(`
+		SELECT min_length, require_uppercase, require_lowercase, require_digit,
+		       require_symbol, history_count, max_age_days
+		FROM password_policies
+		WHERE company_id = $1
+	`, companyID)
+}
+
+func (s *Store) strictestPolicy(query string, args ...interface{}) (Policy, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to get password policies: %w", err)
+	}
+	defer rows.Close()
+
+	var effective *Policy
+	for rows.Next() {
+		var policy Policy
+		if err := rows.Scan(&policy.MinLength, &policy.RequireUppercase, &policy.RequireLowercase,
+			&policy.RequireDigit, &policy.RequireSymbol, &policy.HistoryCount, &policy.MaxAgeDays); err != nil {
+			return Policy{}, fmt.Errorf("failed to scan password policy: %w", err)
+		}
+		if effective == nil {
+			effective = &policy
+		} else {
+			combined := effective.Strictest(policy)
+			effective = &combined
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return Policy{}, fmt.Errorf("failed to read password policies: %w", err)
+	}
+
+	if effective == nil {
+		return s.base, nil
+	}
+	return *effective, nil
+}
+
+// ChangedAt returns when the password of a user was last set, nil when it must be changed
+func (s *Store) ChangedAt(userID int64) (*time.Time, error) {
+	var changedAt sql.NullTime
+	if err := s.db.QueryRow(`SELECT password_changed_at FROM users WHERE id = $1`, userID).Scan(&changedAt); err != nil {
+		return nil, fmt.Errorf("failed to get password age: %w", err)
+	}
+
+	if !changedAt.Valid {
+		return nil, nil
+	}
+	return &changedAt.Time, nil
+}
+
+// IsReused reports whether a password matches the current one or one of the last historyCount passwords
+func (s *Store) IsReused(userID int64, plain string, historyCount int) (bool, error) {
+	if historyCount <= 0 {
+		return false, nil
+	}
+
+	query := `
+		SELECT password_hash FROM users WHERE id = $1
+		UNION ALL
+		(SELECT password_hash FROM password_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2)
+	`
+
+	rows, err := s.db.Query(query, userID, historyCount)
+	if err != nil {
+		return false, fmt.Errorf("failed to get password history: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return false, fmt.Errorf("failed to scan password history: %w", err)
+		}
+		if VerifyPassword(hash, plain) == nil {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// CheckNewPassword validates a new password of an existing user against the user's
+// effective policy and password history, and returns the policy that applied
+func (s *Store) CheckNewPassword(userID int64, plain string, info PersonalInfo) (Policy, error) {
+	policy, err := s.EffectivePolicy(userID)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	if err := policy.Validate(plain, info); err != nil {
+		return policy, err
+	}
+
+	reused, err := s.IsReused(userID, plain, policy.HistoryCount)
+	if err != nil {
+		return policy, err
+	}
+	if reused {
+		return policy, &PolicyError{Violations: []string{
+			fmt.Sprintf("tidak boleh sama dengan %d password terakhir", policy.HistoryCount),
+		}}
+	}
+
+	return policy, nil
+}
+
+// SetPassword stores a new password hash, records it in the history and trims the history
+// to the last keep entries. mustChange forces a change at the next login, e.g. for
+// passwords set by an administrator.
+func (s *Store) SetPassword(userID int64, hash string, keep int, mustChange bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	changedAt := sql.NullTime{Time: time.Now(), Valid: !mustChange}
+	if _, err := tx.Exec(`
+		UPDATE users SET password_hash = $2, password_changed_at = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, userID, hash, changedAt); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO password_history (user_id, password_hash, created_at) VALUES ($1, $2, CURRENT_TIMESTAMP)
+	`, userID, hash); err != nil {
+		return fmt.Errorf("failed to record password history: %w", err)
+	}
+
+	if keep < 1 {
+		keep = 1
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2
+		)
+	`, userID, keep); err != nil {
+		return fmt.Errorf("failed to trim password history: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,132 @@
+package rbac
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// StandardActions are supported by every module; modules declare further actions in module_actions
+var StandardActions = []string{"read", "write", "delete", "approve"}
+
+// MaxActionLength bounds the length of an action name
+const MaxActionLength = 50
+
+var actionPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// IsValidAction reports whether an action is a well-formed action name: lowercase letters,
+// digits, '_' and '-', starting with a letter. Whether a module supports it is decided by
+// its grants, which only hold standard and declared actions.
+func IsValidAction(action string) bool {
+	return len(action) <= MaxActionLength && actionPattern.MatchString(action)
+}
+
+// IsStandardAction reports whether an action is one of read, write, delete or approve
+func IsStandardAction(action string) bool {
+	return containsString(StandardActions, action)
+}
+
+// Actions is the set of actions a grant allows, stored as a JSONB array
+type Actions []string
+
+// GrantedActions combines the standard flags of a grant with further actions into a sorted set
+func GrantedActions(canRead, canWrite, canDelete, canApprove bool, further []string) Actions {
+	actions := Actions{}
+	for i, granted := range [4]bool{canRead, canWrite, canDelete, canApprove} {
+		if granted {
+			actions = append(actions, StandardActions[i])
+		}
+	}
+	for _, action := range further {
+		if !containsString(actions, action) {
+			actions = append(actions, action)
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// Has reports whether the set contains an action
+func (a Actions) Has(action string) bool {
+	return containsString(a, action)
+}
+
+// Custom returns the actions of the set that are not standard actions
+func (a Actions) Custom() []string {
+	custom := []string{}
+	for _, action := range a {
+		if !IsStandardAction(action) {
+			custom = append(custom, action)
+		}
+	}
+	return custom
+}
+
+// Value stores the set in a JSONB column
+func (a Actions) Value() (driver.Value, error) {
+	if a == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads the set from a JSONB column
+func (a *Actions) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = Actions{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	}
+	return fmt.Errorf("cannot scan %T into actions", src)
+}
+
+// grantsAction reports whether a permission allows an action, from its standard flags or
+// its further actions
+func grantsAction(action string, canRead, canWrite, canDelete, canApprove bool, further []string) bool {
+	switch action {
+	case "read":
+		return canRead
+	case "write":
+		return canWrite
+	case "delete":
+		return canDelete
+	case "approve":
+		return canApprove
+	}
+	return containsString(further, action)
+}
+
+// actionGrants gives uniform access to the standard flags and the further actions of a permission
+type actionGrants struct {
+	flags   [4]*bool
+	further *[]string
+}
+
+func (g actionGrants) has(action string) bool {
+	for i, standard := range StandardActions {
+		if action == standard {
+			return *g.flags[i]
+		}
+	}
+	return containsString(*g.further, action)
+}
+
+func (g actionGrants) add(action string) {
+	for i, standard := range StandardActions {
+		if action == standard {
+			*g.flags[i] = true
+			return
+		}
+	}
+	*g.further = append(*g.further, action)
+}
@@ -0,0 +1,33 @@
+package rbac
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIsValidAction(t *testing.T) {
+	for _, action := range []string{"read", "approve", "export", "post-journal", "void_2"} {
+		if !IsValidAction(action) {
+			t.Errorf("%q: expected a valid action", action)
+		}
+	}
+	for _, action := range []string{"", "Export", "2fa", "post journal", "-void", strings.Repeat("a", MaxActionLength+1)} {
+		if IsValidAction(action) {
+			t.Errorf("%q: expected an invalid action", action)
+		}
+	}
+}
+
+func TestGrantedActions(t *testing.T) {
+	actions := GrantedActions(true, false, false, true, []string{"print", "read", "export"})
+	if want := (Actions{"approve", "export", "print", "read"}); !reflect.DeepEqual(actions, want) {
+		t.Errorf("got %v, want %v", actions, want)
+	}
+	if custom := actions.Custom(); !reflect.DeepEqual(custom, []string{"export", "print"}) {
+		t.Errorf("got custom actions %v", custom)
+	}
+	if !actions.Has("print") || actions.Has("write") {
+		t.Errorf("unexpected membership in %v", actions)
+	}
+}
@@ -0,0 +1,284 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PermissionCacheTTL bounds how long a resolved permission set may live in Redis.
+// Explicit invalidation handles grant changes; the TTL only covers time-based
+// changes such as a subscription passing its end date.
+const PermissionCacheTTL = 10 * time.Minute
+
+const (
+	cacheKindPermissions     = "perms"
+	cacheKindUnitPermissions = "unit_perms"
+)
+
+// PermissionCache caches resolved user permissions in Redis.
+//
+// Every user has a generation counter (rbac:gen:<user_id>) that is part of the
+// cache key. Invalidation bumps the counter instead of deleting entries, so a
+// fill computed from data read before an invalidation is written under the old
+// generation and can never be served afterwards. Generation keys carry no TTL
+// on purpose: letting one expire would reset the counter and could resurrect
+// an older entry.
+type PermissionCache struct {
+	redis *redis.Client
+	db    *sql.DB
+	ttl   time.Duration
+}
+
+// NewPermissionCache creates a new permission cache
+func NewPermissionCache(redisClient *redis.Client, db *sql.DB) *PermissionCache {
+	return &PermissionCache{redis: redisClient, db: db, ttl: PermissionCacheTTL}
+}
+
+func generationKey(userID int64) string {
+	return fmt.Sprintf("rbac:gen:%d", userID)
+}
+
+func entryKey(kind string, userID, generation int64) string {
+	return fmt.Sprintf("rbac:%s:%d:%d", kind, userID, generation)
+}
+
+// generation returns the current cache generation for a user
+func (c *PermissionCache) generation(ctx context.Context, userID int64) (int64, error) {
+	value, err := c.redis.Get(ctx, generationKey(userID)).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// load looks up a cached entry. It returns the generation observed before the
+// lookup so that a subsequent store is bound to the same generation.
+func (c *PermissionCache) load(kind string, userID int64, dest interface{}) (int64, bool) {
+	ctx := context.Background()
+	generation, err := c.generation(ctx, userID)
+	if err != nil {
+		return -1, false
+	}
+
+	data, err := c.redis.Get(ctx, entryKey(kind, userID, generation)).Bytes()
+	if err != nil {
+		return generation, false
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return generation, false
+	}
+	return generation, true
+}
+
+// store writes an entry under the given generation. A negative generation means
+// Redis was unavailable during lookup and nothing is written.
+func (c *PermissionCache) store(kind string, userID, generation int64, value interface{}) {
+	if generation < 0 {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	c.redis.Set(context.Background(), entryKey(kind, userID, generation), data, c.ttl)
+}
+
+// InvalidateUsers drops cached permissions for the given users
+func (c *PermissionCache) InvalidateUsers(userIDs ...int64) error {
+	if c == nil || len(userIDs) == 0 {
+		return nil
+	}
+
+	pipe := c.redis.Pipeline()
+	for _, userID := range userIDs {
+		pipe.Incr(context.Background(), generationKey(userID))
+	}
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		return fmt.Errorf("failed to invalidate permission cache: %w", err)
+	}
+	return nil
+}
+
+// InvalidateUser drops cached permissions for a single user
+func (c *PermissionCache) InvalidateUser(userID int64) error {
+	return c.InvalidateUsers(userID)
+}
+
+// InvalidateRole drops cached permissions for every user holding the role or a role
+// inheriting from it
+func (c *PermissionCache) InvalidateRole(roleID int64) error {
+	if c == nil {
+		return nil
+	}
+	userIDs, err := c.RoleUserIDs(roleID)
+	if err != nil {
+		return err
+	}
+	return c.InvalidateUsers(userIDs...)
+}
+
+// InvalidateUnit drops cached permissions for users assigned to the unit or
+// any of its descendants, since unit role grants are inherited downwards
+func (c *PermissionCache) InvalidateUnit(unitID int64) error {
+	if c == nil {
+		return nil
+	}
+	userIDs, err := c.UnitUserIDs(unitID)
+	if err != nil {
+		return err
+	}
+	return c.InvalidateUsers(userIDs...)
+}
+
+// UnitUserIDs returns users assigned to the unit or any of its descendants
+func (c *PermissionCache) UnitUserIDs(unitID int64) ([]int64, error) {
+	if c == nil {
+		return nil, nil
+	}
+	query := `
+		WITH RECURSIVE unit_tree AS (
+			SELECT id, 0 as level FROM units WHERE id = $1
+			UNION ALL
+			SELECT u.id, ut.level + 1
+			FROM units u
+			JOIN unit_tree ut ON u.parent_id = ut.id
+			WHERE ut.level < 10
+		)
+		SELECT DISTINCT ur.user_id
+		FROM user_roles ur
+		JOIN unit_tree ut ON ur.unit_id = ut.id
+	`
+	return c.queryUserIDs(query, unitID)
+}
+
+// InvalidateUnitRole drops cached permissions affected by a unit role change
+func (c *PermissionCache) InvalidateUnitRole(unitRoleID int64) error {
+	if c == nil {
+		return nil
+	}
+	var unitID int64
+	err := c.db.QueryRow(`SELECT unit_id FROM unit_roles WHERE id = $1`, unitRoleID).Scan(&unitID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get unit role: %w", err)
+	}
+	return c.InvalidateUnit(unitID)
+}
+
+// InvalidateCompany drops cached permissions for every user in the company
+func (c *PermissionCache) InvalidateCompany(companyID int64) error {
+	if c == nil {
+		return nil
+	}
+	userIDs, err := c.queryUserIDs(`SELECT DISTINCT user_id FROM user_roles WHERE company_id = $1`, companyID)
+	if err != nil {
+		return err
+	}
+	return c.InvalidateUsers(userIDs...)
+}
+
+// InvalidatePlan drops cached permissions for users of every company subscribed to the plan
+func (c *PermissionCache) InvalidatePlan(planID int64) error {
+	if c == nil {
+		return nil
+	}
+	query := `
+		SELECT DISTINCT ur.user_id
+		FROM user_roles ur
+		JOIN subscriptions s ON s.company_id = ur.company_id
+		WHERE s.plan_id = $1
+	`
+	userIDs, err := c.queryUserIDs(query, planID)
+	if err != nil {
+		return err
+	}
+	return c.InvalidateUsers(userIDs...)
+}
+
+// InvalidateModule drops cached permissions for users holding a grant on the module
+func (c *PermissionCache) InvalidateModule(moduleID int64) error {
+	if c == nil {
+		return nil
+	}
+	userIDs, err := c.ModuleUserIDs(moduleID)
+	if err != nil {
+		return err
+	}
+	return c.InvalidateUsers(userIDs...)
+}
+
+// ModuleUserIDs returns users holding a grant on the module, through a role or a unit role of
+// their units or any ancestor unit. Callers deleting a module resolve these before the delete
+// and pass them to InvalidateUsers afterwards.
+func (c *PermissionCache) ModuleUserIDs(moduleID int64) ([]int64, error) {
+	if c == nil {
+		return nil, nil
+	}
+	query := `
+		WITH RECURSIVE unit_tree AS (
+			SELECT ur.unit_id AS id, 0 as level
+			FROM unit_role_modules urm
+			JOIN unit_roles ur ON urm.unit_role_id = ur.id
+			WHERE urm.module_id = $1
+			UNION ALL
+			SELECT u.id, ut.level + 1
+			FROM units u
+			JOIN unit_tree ut ON u.parent_id = ut.id
+			WHERE ut.level < 10
+		)
+		SELECT ur.user_id
+		FROM user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
+		WHERE rm.module_id = $1
+		UNION
+		SELECT ur.user_id
+		FROM user_roles ur
+		JOIN unit_tree ut ON ur.unit_id = ut.id
+	`
+	return c.queryUserIDs(query, moduleID)
+}
+
+// RoleUserIDs returns users currently holding the role or a role inheriting from it. Callers deleting a role
+// resolve these before the delete and pass them to InvalidateUsers afterwards.
+func (c *PermissionCache) RoleUserIDs(roleID int64) ([]int64, error) {
+	if c == nil {
+		return nil, nil
+	}
+	query := `
+		SELECT DISTINCT ur.user_id
+		FROM user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		WHERE ra.ancestor_id = $1
+	`
+	return c.queryUserIDs(query, roleID)
+}
+
+func (c *PermissionCache) queryUserIDs(query string, args ...interface{}) ([]int64, error) {
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve affected users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan affected user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,185 @@
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MaxBatchChecks bounds the number of checks in one CheckAccessBatch call
+const MaxBatchChecks = 100
+
+// AccessCheck asks whether an action is allowed on a module, identified by ID or URL.
+// With a unit the unit-aware rules of HasUnitPermission apply. Attributes are the
+// request context conditional grants are evaluated against.
+type AccessCheck struct {
+	ModuleID   int64
+	ModuleURL  string
+	Action     string
+	UnitID     *int64
+	Attributes map[string]interface{}
+}
+
+// AccessDecision is the answer to one AccessCheck; Error is set when the check could not be evaluated
+type AccessDecision struct {
+	ModuleID int64
+	Allowed  bool
+	Error    string
+}
+
+// accessSnapshot holds everything a batch of checks is decided on, loaded once per batch
+type accessSnapshot struct {
+	permissions     *UserPermissions
+	unitPermissions *UnitUserPermissions
+	consoleAdmin    bool
+	moduleIDs       map[string]int64
+	// builtins are loaded on the first conditional grant
+	builtins     *builtinAttributes
+	loadBuiltins func() (*builtinAttributes, error)
+}
+
+// CheckAccessBatch answers several access checks for one user from a single permission load
+func (r *RBACService) CheckAccessBatch(userID int64, checks []AccessCheck) ([]AccessDecision, error) {
+	if len(checks) > MaxBatchChecks {
+		return nil, fmt.Errorf("too many checks: %d (max %d)", len(checks), MaxBatchChecks)
+	}
+
+	snapshot := &accessSnapshot{
+		loadBuiltins: func() (*builtinAttributes, error) {
+			return loadBuiltinAttributes(r.db, userID, time.Now())
+		},
+	}
+	var err error
+	if snapshot.permissions, err = r.GetUserPermissions(userID); err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	needsUnits, needsConsoleAdmin := false, false
+	for _, check := range checks {
+		if check.ModuleID == 0 && check.ModuleURL != "" {
+			urls = append(urls, check.ModuleURL)
+		}
+		needsUnits = needsUnits || check.UnitID != nil
+		needsConsoleAdmin = needsConsoleAdmin || (check.UnitID == nil && isConsoleAdminModule(check.ModuleID))
+	}
+
+	if snapshot.moduleIDs, err = r.moduleIDsByURL(urls); err != nil {
+		return nil, err
+	}
+	for _, id := range snapshot.moduleIDs {
+		needsConsoleAdmin = needsConsoleAdmin || isConsoleAdminModule(id)
+	}
+
+	if needsUnits {
+		unitService := &UnitRBACService{db: r.db, cache: r.cache}
+		if snapshot.unitPermissions, err = unitService.GetUserUnitPermissions(userID); err != nil {
+			return nil, err
+		}
+	}
+	if needsConsoleAdmin {
+		if snapshot.consoleAdmin, err = r.hasConsoleAdminRole(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	decisions := make([]AccessDecision, len(checks))
+	for i, check := range checks {
+		decisions[i] = snapshot.decide(check)
+	}
+	return decisions, nil
+}
+
+// decide applies the rules of HasPermission, or of HasUnitPermission when a unit is given
+func (s *accessSnapshot) decide(check AccessCheck) AccessDecision {
+	decision := AccessDecision{ModuleID: check.ModuleID}
+	if decision.ModuleID == 0 {
+		id, found := s.moduleIDs[check.ModuleURL]
+		if !found {
+			decision.Error = "module not found"
+			return decision
+		}
+		decision.ModuleID = id
+	}
+
+	if !IsValidAction(check.Action) {
+		decision.Error = fmt.Sprintf("invalid permission type: %s", check.Action)
+		return decision
+	}
+
+	if check.UnitID != nil {
+		if s.unitPermissions == nil {
+			return decision
+		}
+		inUnit := false
+		for _, unitID := range s.unitPermissions.EffectiveUnits {
+			if unitID == *check.UnitID {
+				inUnit = true
+				break
+			}
+		}
+		modulePerm, exists := s.unitPermissions.Modules[decision.ModuleID]
+		decision.Allowed = inUnit && exists && modulePerm.Grants(check.Action)
+		return s.applyConditions(decision, modulePerm.Conditions[check.Action], check.Attributes)
+	}
+
+	if s.consoleAdmin && isConsoleAdminModule(decision.ModuleID) {
+		decision.Allowed = true
+		return decision
+	}
+	modulePerm, exists := s.permissions.Modules[decision.ModuleID]
+	decision.Allowed = exists && modulePerm.Grants(check.Action)
+	return s.applyConditions(decision, modulePerm.Conditions[check.Action], check.Attributes)
+}
+
+// applyConditions restricts an allowed decision to the conditions of its grants
+func (s *accessSnapshot) applyConditions(decision AccessDecision, conditions []string, attributes map[string]interface{}) AccessDecision {
+	if !decision.Allowed || len(conditions) == 0 {
+		return decision
+	}
+
+	if s.builtins == nil {
+		builtins, err := s.loadBuiltins()
+		if err != nil {
+			decision.Allowed = false
+			decision.Error = "failed to evaluate conditions"
+			return decision
+		}
+		s.builtins = builtins
+	}
+
+	decision.Allowed = anyConditionHolds(conditions, s.builtins.environment(attributes))
+	return decision
+}
+
+// moduleIDsByURL resolves module URLs to IDs in one query
+func (r *RBACService) moduleIDsByURL(urls []string) (map[string]int64, error) {
+	moduleIDs := make(map[string]int64)
+	if len(urls) == 0 {
+		return moduleIDs, nil
+	}
+
+	urlList, err := json.Marshal(urls)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, url FROM modules
+		WHERE url IN (SELECT jsonb_array_elements_text($1::jsonb))
+	`, string(urlList))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve module urls: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var url string
+		if err := rows.Scan(&id, &url); err != nil {
+			return nil, fmt.Errorf("failed to scan module: %w", err)
+		}
+		moduleIDs[url] = id
+	}
+	return moduleIDs, rows.Err()
+}
@@ -0,0 +1,46 @@
+package rbac
+
+import "testing"
+
+func TestAccessSnapshotDecide(t *testing.T) {
+	unitID, otherUnitID := int64(5), int64(9)
+	snapshot := &accessSnapshot{
+		permissions: &UserPermissions{Modules: map[int64]ModulePermission{
+			10: {ModuleID: 10, CanRead: true},
+			11: {ModuleID: 11, CanRead: true, CanWrite: true},
+			12: {ModuleID: 12, Actions: []string{"post-journal"}},
+		}},
+		unitPermissions: &UnitUserPermissions{
+			EffectiveUnits: []int64{unitID},
+			Modules:        map[int64]UnitModulePermission{10: {ModuleID: 10, CanRead: true, CanApprove: true}},
+		},
+		consoleAdmin: true,
+		moduleIDs:    map[string]int64{"/payroll": 11},
+	}
+
+	tests := []struct {
+		name    string
+		check   AccessCheck
+		allowed bool
+		err     bool
+	}{
+		{"read granted", AccessCheck{ModuleID: 10, Action: "read"}, true, false},
+		{"write denied", AccessCheck{ModuleID: 10, Action: "write"}, false, false},
+		{"by url", AccessCheck{ModuleURL: "/payroll", Action: "write"}, true, false},
+		{"unknown url", AccessCheck{ModuleURL: "/missing", Action: "read"}, false, true},
+		{"invalid action", AccessCheck{ModuleID: 10, Action: "Execute!"}, false, true},
+		{"ungranted action", AccessCheck{ModuleID: 10, Action: "export"}, false, false},
+		{"custom action", AccessCheck{ModuleID: 12, Action: "post-journal"}, true, false},
+		{"unknown module", AccessCheck{ModuleID: 99, Action: "read"}, false, false},
+		{"console admin", AccessCheck{ModuleID: 140, Action: "delete"}, true, false},
+		{"unit grant", AccessCheck{ModuleID: 10, Action: "approve", UnitID: &unitID}, true, false},
+		{"outside unit", AccessCheck{ModuleID: 10, Action: "read", UnitID: &otherUnitID}, false, false},
+	}
+
+	for _, tt := range tests {
+		decision := snapshot.decide(tt.check)
+		if decision.Allowed != tt.allowed || (decision.Error != "") != tt.err {
+			t.Errorf("%s: got allowed=%t error=%q", tt.name, decision.Allowed, decision.Error)
+		}
+	}
+}
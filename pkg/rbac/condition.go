@@ -0,0 +1,791 @@
+package rbac
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Conditions restrict a grant: a condition expression per action, e.g.
+// {"approve": "amount <= 50000000 && branch_id in user.branch_ids"}.
+// An action without a condition is granted unconditionally.
+//
+// Expressions support:
+//   - literals: numbers, 'strings' or "strings", true, false, null and lists [1, 2]
+//   - attributes of the request context, nested with dots: amount, user.branch_ids
+//   - comparisons ==, !=, <, <=, >, >= and membership: branch_id in [1, 2]
+//   - cidr(ip, "10.0.0.0/8", ...), true when the IP address is in one of the ranges
+//   - &&, || and ! with parentheses
+//
+// Missing attributes evaluate to null, and comparisons between values of different
+// types are false, so a condition that cannot be evaluated does not hold.
+type Conditions map[string]string
+
+// MaxConditionLength bounds the length of one condition expression
+const MaxConditionLength = 1000
+
+// maxConditionDepth bounds the nesting of parentheses, lists and operators
+const maxConditionDepth = 32
+
+// Condition is a parsed condition expression
+type Condition struct {
+	source string
+	root   conditionNode
+}
+
+var conditionCache sync.Map
+
+// ParseCondition parses a condition expression
+func ParseCondition(source string) (*Condition, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("empty condition")
+	}
+	if len(source) > MaxConditionLength {
+		return nil, fmt.Errorf("condition longer than %d characters", MaxConditionLength)
+	}
+
+	tokens, err := tokenizeCondition(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &conditionParser{tokens: tokens}
+	root, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.peek().text, p.peek().pos)
+	}
+	return &Condition{source: source, root: root}, nil
+}
+
+// compiledCondition parses a condition once and reuses it for later evaluations
+func compiledCondition(source string) (*Condition, error) {
+	if cached, ok := conditionCache.Load(source); ok {
+		return cached.(*Condition), nil
+	}
+	condition, err := ParseCondition(source)
+	if err != nil {
+		return nil, err
+	}
+	conditionCache.Store(source, condition)
+	return condition, nil
+}
+
+// Validate checks that every condition is keyed by a well-formed action and parses
+func (c Conditions) Validate() error {
+	for action, source := range c {
+		if !IsValidAction(action) {
+			return fmt.Errorf("condition for invalid action %q", action)
+		}
+		if _, err := ParseCondition(source); err != nil {
+			return fmt.Errorf("condition for %s: %w", action, err)
+		}
+	}
+	return nil
+}
+
+// Value stores conditions in a JSONB column; no conditions are stored as NULL
+func (c Conditions) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads conditions from a JSONB column
+func (c *Conditions) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into conditions", src)
+	}
+	return json.Unmarshal(data, c)
+}
+
+// String returns the source of the condition
+func (c *Condition) String() string {
+	return c.source
+}
+
+// Evaluate reports whether the condition holds for the given attributes
+func (c *Condition) Evaluate(attributes map[string]interface{}) bool {
+	return c.root.eval(attributes) == true
+}
+
+// conditionHolds evaluates a condition source; unparseable conditions never hold
+func conditionHolds(source string, attributes map[string]interface{}) bool {
+	condition, err := compiledCondition(source)
+	if err != nil {
+		return false
+	}
+	return condition.Evaluate(attributes)
+}
+
+// Tokenizer
+
+type conditionTokenKind int
+
+const (
+	tokenNumber conditionTokenKind = iota
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type conditionToken struct {
+	kind  conditionTokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+var conditionOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","}
+
+func tokenizeCondition(source string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(source[i+1:], source[i])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			text := source[i+1 : i+1+end]
+			tokens = append(tokens, conditionToken{kind: tokenString, text: text, value: text, pos: i})
+			i += end + 2
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(source) && unicode.IsDigit(rune(source[i+1]))):
+			start := i
+			i++
+			for i < len(source) && (unicode.IsDigit(rune(source[i])) || source[i] == '.') {
+				i++
+			}
+			number, err := strconv.ParseFloat(source[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", source[start:i], start)
+			}
+			tokens = append(tokens, conditionToken{kind: tokenNumber, text: source[start:i], value: number, pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(source) && (unicode.IsLetter(rune(source[i])) || unicode.IsDigit(rune(source[i])) || source[i] == '_' || source[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, conditionToken{kind: tokenIdent, text: source[start:i], pos: start})
+		default:
+			matched := false
+			for _, operator := range conditionOperators {
+				if strings.HasPrefix(source[i:], operator) {
+					tokens = append(tokens, conditionToken{kind: tokenOperator, text: operator, pos: i})
+					i += len(operator)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// Parser
+
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+}
+
+func (p *conditionParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *conditionParser) peek() conditionToken {
+	if p.done() {
+		return conditionToken{text: "end of condition", pos: -1}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *conditionParser) accept(kind conditionTokenKind, text string) bool {
+	if !p.done() && p.tokens[p.pos].kind == kind && p.tokens[p.pos].text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *conditionParser) expect(text string) error {
+	if !p.accept(tokenOperator, text) {
+		return fmt.Errorf("expected %q, got %q", text, p.peek().text)
+	}
+	return nil
+}
+
+func (p *conditionParser) parseOr(depth int) (conditionNode, error) {
+	if depth > maxConditionDepth {
+		return nil, fmt.Errorf("condition nested too deeply")
+	}
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenOperator, "||") {
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd(depth int) (conditionNode, error) {
+	left, err := p.parseNot(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenOperator, "&&") {
+		right, err := p.parseNot(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseNot(depth int) (conditionNode, error) {
+	if p.accept(tokenOperator, "!") {
+		if depth > maxConditionDepth {
+			return nil, fmt.Errorf("condition nested too deeply")
+		}
+		operand, err := p.parseNot(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison(depth)
+}
+
+func (p *conditionParser) parseComparison(depth int) (conditionNode, error) {
+	left, err := p.parsePrimary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.accept(tokenIdent, "in") {
+		right, err := p.parsePrimary(depth)
+		if err != nil {
+			return nil, err
+		}
+		return &inNode{needle: left, haystack: right}, nil
+	}
+
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(tokenOperator, operator) {
+			right, err := p.parsePrimary(depth)
+			if err != nil {
+				return nil, err
+			}
+			return &compareNode{operator: operator, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parsePrimary(depth int) (conditionNode, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+	token := p.tokens[p.pos]
+
+	switch token.kind {
+	case tokenNumber, tokenString:
+		p.pos++
+		return &literalNode{value: token.value}, nil
+	case tokenIdent:
+		p.pos++
+		switch token.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		case "in":
+			return nil, fmt.Errorf("unexpected \"in\" at position %d", token.pos)
+		}
+		if p.accept(tokenOperator, "(") {
+			return p.parseCall(token, depth+1)
+		}
+		return &attributeNode{path: token.text}, nil
+	}
+
+	switch {
+	case p.accept(tokenOperator, "("):
+		inner, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case p.accept(tokenOperator, "["):
+		list := &listNode{}
+		if p.accept(tokenOperator, "]") {
+			return list, nil
+		}
+		for {
+			item, err := p.parseOr(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			list.items = append(list.items, item)
+			if p.accept(tokenOperator, "]") {
+				return list, nil
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.pos)
+}
+
+func (p *conditionParser) parseCall(name conditionToken, depth int) (conditionNode, error) {
+	var args []conditionNode
+	if !p.accept(tokenOperator, ")") {
+		for {
+			arg, err := p.parseOr(depth)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.accept(tokenOperator, ")") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch name.text {
+	case "cidr":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("cidr needs an address and at least one range")
+		}
+		call := &cidrNode{address: args[0]}
+		for _, arg := range args[1:] {
+			literal, ok := arg.(*literalNode)
+			text, isString := literalValue(literal)
+			if !ok || !isString {
+				return nil, fmt.Errorf("cidr ranges must be string literals")
+			}
+			_, network, err := net.ParseCIDR(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cidr range %q", text)
+			}
+			call.networks = append(call.networks, network)
+		}
+		return call, nil
+	}
+	return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+}
+
+func literalValue(literal *literalNode) (string, bool) {
+	if literal == nil {
+		return "", false
+	}
+	text, ok := literal.value.(string)
+	return text, ok
+}
+
+// Evaluation
+
+type conditionNode interface {
+	eval(attributes map[string]interface{}) interface{}
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(map[string]interface{}) interface{} {
+	return n.value
+}
+
+type attributeNode struct {
+	path string
+}
+
+func (n *attributeNode) eval(attributes map[string]interface{}) interface{} {
+	if value, ok := attributes[n.path]; ok {
+		return normalizeValue(value)
+	}
+
+	var current interface{} = attributes
+	for _, segment := range strings.Split(n.path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if current, ok = object[segment]; !ok {
+			return nil
+		}
+	}
+	return normalizeValue(current)
+}
+
+type listNode struct {
+	items []conditionNode
+}
+
+func (n *listNode) eval(attributes map[string]interface{}) interface{} {
+	values := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		values[i] = item.eval(attributes)
+	}
+	return values
+}
+
+type logicalNode struct {
+	or          bool
+	left, right conditionNode
+}
+
+func (n *logicalNode) eval(attributes map[string]interface{}) interface{} {
+	left := n.left.eval(attributes) == true
+	if n.or {
+		return left || n.right.eval(attributes) == true
+	}
+	return left && n.right.eval(attributes) == true
+}
+
+type notNode struct {
+	operand conditionNode
+}
+
+func (n *notNode) eval(attributes map[string]interface{}) interface{} {
+	return n.operand.eval(attributes) != true
+}
+
+type compareNode struct {
+	operator    string
+	left, right conditionNode
+}
+
+func (n *compareNode) eval(attributes map[string]interface{}) interface{} {
+	left, right := n.left.eval(attributes), n.right.eval(attributes)
+	switch n.operator {
+	case "==":
+		return valuesEqual(left, right)
+	case "!=":
+		return !valuesEqual(left, right)
+	}
+
+	order, comparable := compareValues(left, right)
+	if !comparable {
+		return false
+	}
+	switch n.operator {
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	}
+	return false
+}
+
+type inNode struct {
+	needle, haystack conditionNode
+}
+
+func (n *inNode) eval(attributes map[string]interface{}) interface{} {
+	needle := n.needle.eval(attributes)
+	values, ok := n.haystack.eval(attributes).([]interface{})
+	if !ok {
+		return false
+	}
+	for _, value := range values {
+		if valuesEqual(needle, value) {
+			return true
+		}
+	}
+	return false
+}
+
+type cidrNode struct {
+	address  conditionNode
+	networks []*net.IPNet
+}
+
+func (n *cidrNode) eval(attributes map[string]interface{}) interface{} {
+	text, ok := n.address.eval(attributes).(string)
+	if !ok {
+		return false
+	}
+	ip := net.ParseIP(strings.TrimSpace(text))
+	if ip == nil {
+		return false
+	}
+	for _, network := range n.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func valuesEqual(left, right interface{}) bool {
+	if order, comparable := compareValues(left, right); comparable {
+		return order == 0
+	}
+	leftBool, leftOK := left.(bool)
+	rightBool, rightOK := right.(bool)
+	if leftOK && rightOK {
+		return leftBool == rightBool
+	}
+	return left == nil && right == nil
+}
+
+// compareValues orders two numbers or two strings
+func compareValues(left, right interface{}) (int, bool) {
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case l < r:
+			return -1, true
+		case l > r:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(l, r), true
+	}
+	return 0, false
+}
+
+// normalizeValue converts Go values from the request context to the types of the
+// expression language: float64, string, bool, nil, []interface{} and maps
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, float64, string, bool, []interface{}, map[string]interface{}:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case *int64:
+		if v == nil {
+			return nil
+		}
+		return float64(*v)
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array:
+		values := make([]interface{}, reflected.Len())
+		for i := range values {
+			values[i] = normalizeValue(reflected.Index(i).Interface())
+		}
+		return values
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(reflected.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(reflected.Uint())
+	}
+	return nil
+}
+
+// Grants
+
+// parseGrantConditions decodes the conditions column of a grant. Conditions that cannot be
+// decoded deny every action of the grant, so a corrupt grant fails closed.
+func parseGrantConditions(data []byte, actions Actions) Conditions {
+	if len(data) == 0 {
+		return nil
+	}
+	var conditions Conditions
+	if err := json.Unmarshal(data, &conditions); err != nil {
+		conditions = make(Conditions, len(actions))
+		for _, action := range actions {
+			conditions[action] = "false"
+		}
+	}
+	return conditions
+}
+
+// mergeGrant ORs the actions of one grant into a module permission and merges its conditions.
+// An action stays conditional only while every grant of it carries a condition.
+func mergeGrant(granted actionGrants, conditions map[string][]string, grant Actions, grantConditions Conditions) map[string][]string {
+	for _, action := range grant {
+		condition := grantConditions[action]
+		existing, conditional := conditions[action]
+		switch {
+		case !granted.has(action):
+			granted.add(action)
+			if condition != "" {
+				if conditions == nil {
+					conditions = make(map[string][]string)
+				}
+				conditions[action] = []string{condition}
+			}
+		case !conditional:
+			// already granted unconditionally
+		case condition == "":
+			delete(conditions, action)
+		default:
+			if !containsString(existing, condition) {
+				conditions[action] = append(existing, condition)
+			}
+		}
+	}
+
+	if len(conditions) == 0 {
+		return nil
+	}
+	return conditions
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// anyConditionHolds reports whether one of the conditions of an action holds
+func anyConditionHolds(conditions []string, environment map[string]interface{}) bool {
+	for _, condition := range conditions {
+		if conditionHolds(condition, environment) {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinAttributes are the attributes every condition can use: user.id, user.company_ids,
+// user.branch_ids, user.unit_ids, time.hour, time.minute and time.weekday (0 is Sunday)
+type builtinAttributes struct {
+	user map[string]interface{}
+	time map[string]interface{}
+}
+
+func loadBuiltinAttributes(db querier, userID int64, now time.Time) (*builtinAttributes, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT ur.company_id, COALESCE(ur.branch_id, u.branch_id), ur.unit_id
+		FROM active_user_roles ur
+		LEFT JOIN units u ON ur.unit_id = u.id
+		WHERE ur.user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user scope: %w", err)
+	}
+	defer rows.Close()
+
+	companyIDs, branchIDs, unitIDs := []interface{}{}, []interface{}{}, []interface{}{}
+	seen := make(map[string]bool)
+	addID := func(list *[]interface{}, kind string, id sql.NullInt64) {
+		key := fmt.Sprintf("%s:%d", kind, id.Int64)
+		if id.Valid && !seen[key] {
+			seen[key] = true
+			*list = append(*list, float64(id.Int64))
+		}
+	}
+	for rows.Next() {
+		var companyID, branchID, unitID sql.NullInt64
+		if err := rows.Scan(&companyID, &branchID, &unitID); err != nil {
+			return nil, fmt.Errorf("failed to scan user scope: %w", err)
+		}
+		addID(&companyIDs, "company", companyID)
+		addID(&branchIDs, "branch", branchID)
+		addID(&unitIDs, "unit", unitID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read user scope: %w", err)
+	}
+
+	return &builtinAttributes{
+		user: map[string]interface{}{
+			"id":          float64(userID),
+			"company_ids": companyIDs,
+			"branch_ids":  branchIDs,
+			"unit_ids":    unitIDs,
+		},
+		time: map[string]interface{}{
+			"hour":    float64(now.Hour()),
+			"minute":  float64(now.Minute()),
+			"weekday": float64(now.Weekday()),
+		},
+	}, nil
+}
+
+// environment combines request attributes with the built-in attributes, which the
+// request cannot override
+func (b *builtinAttributes) environment(attributes map[string]interface{}) map[string]interface{} {
+	environment := make(map[string]interface{}, len(attributes)+2)
+	for key, value := range attributes {
+		if key == "user" || key == "time" || strings.HasPrefix(key, "user.") || strings.HasPrefix(key, "time.") {
+			continue
+		}
+		environment[key] = value
+	}
+	environment["user"] = b.user
+	environment["time"] = b.time
+	return environment
+}
+
+// conditionEnvironment returns the attributes conditions of a user are evaluated against
+func conditionEnvironment(db querier, userID int64, attributes map[string]interface{}, now time.Time) (map[string]interface{}, error) {
+	builtins, err := loadBuiltinAttributes(db, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	return builtins.environment(attributes), nil
+}
+
+func (r *RBACService) conditionEnvironment(userID int64, attributes map[string]interface{}) (map[string]interface{}, error) {
+	return conditionEnvironment(r.db, userID, attributes, time.Now())
+}
@@ -0,0 +1,130 @@
+package rbac
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConditionEvaluate(t *testing.T) {
+	builtins := &builtinAttributes{
+		user: map[string]interface{}{"id": float64(7), "branch_ids": []interface{}{float64(2), float64(3)}},
+		time: map[string]interface{}{"hour": float64(10), "weekday": float64(time.Monday)},
+	}
+	environment := builtins.environment(map[string]interface{}{
+		"amount":    int64(25000000),
+		"branch_id": 3,
+		"ip":        "10.1.2.3",
+		"currency":  "IDR",
+		"tags":      []string{"urgent"},
+		"invoice":   map[string]interface{}{"status": "posted"},
+		"user.id":   99,
+	})
+
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"amount <= 50000000", true},
+		{"amount > 50000000", false},
+		{"branch_id in user.branch_ids", true},
+		{"branch_id in [4, 5]", false},
+		{"time.hour >= 8 && time.hour < 17", true},
+		{"time.weekday in [0, 6]", false},
+		{`cidr(ip, "10.0.0.0/8", "192.168.0.0/16")`, true},
+		{`cidr(ip, "192.168.0.0/16")`, false},
+		{"currency == 'IDR' && !(amount > 30000000)", true},
+		{`"urgent" in tags`, true},
+		{"invoice.status == 'posted'", true},
+		{"user.id == 7", true},
+		{"missing == null", true},
+		{"missing < 5", false},
+		{"currency > 5", false},
+		{"amount > 100 || missing", true},
+		{"amount", false},
+	}
+
+	for _, tt := range tests {
+		condition, err := ParseCondition(tt.source)
+		if err != nil {
+			t.Errorf("%s: unexpected parse error: %v", tt.source, err)
+			continue
+		}
+		if got := condition.Evaluate(environment); got != tt.want {
+			t.Errorf("%s: got %t, want %t", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestParseConditionErrors(t *testing.T) {
+	for _, source := range []string{
+		"",
+		"amount <=",
+		"amount <= 5 &&",
+		"(amount > 1",
+		"amount # 5",
+		"'unterminated",
+		`cidr(ip, "not-a-range")`,
+		"cidr(ip)",
+		"unknown(ip)",
+		"amount 5",
+		"[1, 2",
+	} {
+		if _, err := ParseCondition(source); err == nil {
+			t.Errorf("%q: expected a parse error", source)
+		}
+	}
+
+	deep := ""
+	for i := 0; i < 40; i++ {
+		deep += "("
+	}
+	if _, err := ParseCondition(deep + "true"); err == nil {
+		t.Error("expected an error for deeply nested conditions")
+	}
+}
+
+func TestMergeGrant(t *testing.T) {
+	var permission ModulePermission
+
+	// Two conditional grants of approve: either condition may hold
+	permission.Conditions = mergeGrant(permission.grants(), permission.Conditions, Actions{"read", "approve"},
+		Conditions{"approve": "amount <= 10"})
+	permission.Conditions = mergeGrant(permission.grants(), permission.Conditions, Actions{"approve"},
+		Conditions{"approve": "amount <= 100"})
+	if !permission.CanApprove || len(permission.Conditions["approve"]) != 2 {
+		t.Fatalf("expected two approve conditions, got %v", permission.Conditions)
+	}
+	if _, conditional := permission.Conditions["read"]; conditional {
+		t.Error("read was granted without conditions")
+	}
+
+	// An unconditional grant lifts the conditions
+	permission.Conditions = mergeGrant(permission.grants(), permission.Conditions, Actions{"approve"}, nil)
+	if permission.Conditions != nil {
+		t.Errorf("expected no conditions, got %v", permission.Conditions)
+	}
+
+	// Later conditional grants do not restrict an unconditional one
+	permission.Conditions = mergeGrant(permission.grants(), permission.Conditions, Actions{"approve"},
+		Conditions{"approve": "false"})
+	if permission.Conditions != nil {
+		t.Errorf("expected approve to stay unconditional, got %v", permission.Conditions)
+	}
+
+	// Declared actions are merged like standard ones
+	permission.Conditions = mergeGrant(permission.grants(), permission.Conditions, Actions{"export"},
+		Conditions{"export": "amount <= 10"})
+	if !permission.Grants("export") || len(permission.Conditions["export"]) != 1 || permission.CanWrite {
+		t.Errorf("expected a conditional export grant, got %+v", permission)
+	}
+}
+
+func TestParseGrantConditionsFailsClosed(t *testing.T) {
+	conditions := parseGrantConditions([]byte(`{"approve": 5}`), Actions{"read", "post-journal"})
+	if conditions["read"] != "false" || conditions["post-journal"] != "false" {
+		t.Errorf("expected undecodable conditions to deny, got %v", conditions)
+	}
+	if parseGrantConditions(nil, Actions{"read"}) != nil {
+		t.Error("expected no conditions for a NULL column")
+	}
+}
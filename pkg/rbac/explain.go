@@ -0,0 +1,430 @@
+package rbac
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Reasons of a permission decision
+const (
+	ReasonGranted              = "granted"
+	ReasonConsoleAdmin         = "console_admin"
+	ReasonModuleNotFound       = "module_not_found"
+	ReasonModuleInactive       = "module_inactive"
+	ReasonNoUnitAccess         = "no_unit_access"
+	ReasonNoRoleGrant          = "no_role_grant"
+	ReasonAssignmentNotActive  = "assignment_not_active"
+	ReasonConditionNotMet      = "condition_not_met"
+	ReasonSubscriptionInactive = "subscription_inactive"
+	ReasonNotInPlan            = "not_in_plan"
+	ReasonNotBasicTier         = "not_basic_tier"
+	ReasonDenied               = "denied"
+)
+
+// Subscription tiers a decision can be made under
+const (
+	TierSubscription = "subscription"
+	TierBasic        = "basic"
+)
+
+// PermissionExplanation is a permission decision together with the data it was based on
+type PermissionExplanation struct {
+	UserID   int64  `json:"user_id"`
+	ModuleID int64  `json:"module_id"`
+	Action   string `json:"action"`
+	Allowed  bool   `json:"allowed"`
+	Reason   string `json:"reason"`
+	// Tier is "subscription" when the company plan applied, "basic" when only basic tier modules count
+	Tier          string `json:"tier"`
+	BasicFallback string `json:"basic_fallback,omitempty"`
+	ConsoleAdmin  bool   `json:"console_admin"`
+	// Conditions are the conditions of the grants of the action, evaluated without request
+	// attributes as HasPermission does; ConditionsHold is set when there are any
+	Conditions     []string `json:"conditions,omitempty"`
+	ConditionsHold *bool    `json:"conditions_hold,omitempty"`
+
+	Module       *ModuleTrace       `json:"module"`
+	Subscription *SubscriptionTrace `json:"subscription"`
+	Roles        []RoleGrantTrace   `json:"roles"`
+	Unit         *UnitTrace         `json:"unit,omitempty"`
+	Steps        []string           `json:"steps"`
+}
+
+// ModuleTrace describes the requested module
+type ModuleTrace struct {
+	ID               int64  `json:"id"`
+	Name             string `json:"name"`
+	URL              string `json:"url"`
+	IsActive         bool   `json:"is_active"`
+	SubscriptionTier string `json:"subscription_tier"`
+}
+
+// SubscriptionTrace describes the company subscription considered for the module
+type SubscriptionTrace struct {
+	CompanyID          int64      `json:"company_id"`
+	SubscriptionID     *int64     `json:"subscription_id"`
+	PlanID             *int64     `json:"plan_id"`
+	PlanName           string     `json:"plan_name,omitempty"`
+	Status             string     `json:"status,omitempty"`
+	EndDate            *time.Time `json:"end_date"`
+	Active             bool       `json:"active"`
+	PlanIncludesModule bool       `json:"plan_includes_module"`
+}
+
+// RoleGrantTrace describes one role assignment of the user and what it grants on the module,
+// directly or through a role it inherits from; an assignment inheriting several grants of
+// the module appears once per grant
+type RoleGrantTrace struct {
+	RoleID        int64      `json:"role_id"`
+	RoleName      string     `json:"role_name"`
+	RoleActive    bool       `json:"role_active"`
+	Level         string     `json:"level"`
+	CompanyID     int64      `json:"company_id"`
+	BranchID      *int64     `json:"branch_id,omitempty"`
+	UnitID        *int64     `json:"unit_id,omitempty"`
+	ValidFrom     *time.Time `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time `json:"valid_until,omitempty"`
+	InEffect      bool       `json:"in_effect"` // the assignment is within its validity window
+	ModuleGranted bool       `json:"module_granted"`
+	InheritedFrom string     `json:"inherited_from,omitempty"` // parent role of an inherited grant
+	Actions       Actions    `json:"actions"`
+	Conditions    Conditions `json:"conditions,omitempty"`
+	GrantsAction  bool       `json:"grants_action"`
+}
+
+// UnitTrace describes the unit-aware decision when a unit was requested
+type UnitTrace struct {
+	UnitID           int64              `json:"unit_id"`
+	InEffectiveUnits bool               `json:"in_effective_units"`
+	EffectiveUnits   []int64            `json:"effective_units"`
+	GrantedBy        []PermissionSource `json:"granted_by"`
+	HighestLevel     string             `json:"highest_level,omitempty"`
+	GrantsAction     bool               `json:"grants_action"`
+}
+
+// isConsoleAdminModule reports whether CONSOLE ADMIN has full access to a module (API Documentation)
+func isConsoleAdminModule(moduleID int64) bool {
+	return moduleID >= 139 && moduleID <= 143
+}
+
+// ExplainPermission resolves whether a user may perform an action on a module and
+// records every input of the decision. With a unit the unit-aware rules apply, as
+// in HasUnitPermission; otherwise the rules of HasPermission apply. Permissions are
+// resolved from the database, bypassing the cache.
+func (r *RBACService) ExplainPermission(userID, moduleID int64, action string, unitID *int64) (*PermissionExplanation, error) {
+	if !IsValidAction(action) {
+		return nil, fmt.Errorf("invalid permission type: %s", action)
+	}
+
+	explanation := &PermissionExplanation{
+		UserID:   userID,
+		ModuleID: moduleID,
+		Action:   action,
+		Roles:    []RoleGrantTrace{},
+		Steps:    []string{},
+	}
+
+	if err := r.traceModule(explanation); err != nil {
+		return nil, err
+	}
+	if err := r.traceRoles(explanation); err != nil {
+		return nil, err
+	}
+
+	permissions, err := r.loadUserPermissions(userID)
+	if err != nil {
+		return nil, err
+	}
+	explanation.Tier = TierSubscription
+	if permissions.BasicFallback != "" {
+		explanation.Tier = TierBasic
+		explanation.BasicFallback = permissions.BasicFallback
+		explanation.step("only basic tier modules apply (%s)", permissions.BasicFallback)
+	} else {
+		explanation.step("subscription tier applies")
+	}
+
+	if err := r.traceSubscription(explanation); err != nil {
+		return nil, err
+	}
+
+	if unitID != nil {
+		unitPermissions, err := (&UnitRBACService{db: r.db}).loadUserUnitPermissions(userID)
+		if err != nil {
+			return nil, err
+		}
+		explanation.Unit = traceUnit(unitPermissions, moduleID, action, *unitID)
+		explanation.step("unit %d in effective units: %t, unit-aware permissions grant %s: %t",
+			*unitID, explanation.Unit.InEffectiveUnits, action, explanation.Unit.GrantsAction)
+		granted := explanation.Unit.InEffectiveUnits && explanation.Unit.GrantsAction
+		if granted {
+			if granted, err = r.traceConditions(explanation, unitPermissions.Modules[moduleID].Conditions[action]); err != nil {
+				return nil, err
+			}
+		}
+		explanation.Allowed = granted
+	} else {
+		if isConsoleAdminModule(moduleID) {
+			explanation.ConsoleAdmin, err = r.hasConsoleAdminRole(userID)
+			if err != nil {
+				return nil, err
+			}
+			explanation.step("API documentation module, CONSOLE ADMIN role: %t", explanation.ConsoleAdmin)
+		}
+		modulePerm, exists := permissions.Modules[moduleID]
+		granted := exists && modulePerm.Grants(action)
+		if granted && !explanation.ConsoleAdmin {
+			if granted, err = r.traceConditions(explanation, modulePerm.Conditions[action]); err != nil {
+				return nil, err
+			}
+		}
+		explanation.Allowed = explanation.ConsoleAdmin || granted
+	}
+
+	explanation.Reason = explanation.reason()
+	explanation.step("decision: allowed=%t (%s)", explanation.Allowed, explanation.Reason)
+	return explanation, nil
+}
+
+// traceConditions evaluates the conditions of a granted action without request attributes
+func (r *RBACService) traceConditions(e *PermissionExplanation, conditions []string) (bool, error) {
+	if len(conditions) == 0 {
+		return true, nil
+	}
+
+	environment, err := r.conditionEnvironment(e.UserID, nil)
+	if err != nil {
+		return false, err
+	}
+	holds := anyConditionHolds(conditions, environment)
+	e.Conditions = conditions
+	e.ConditionsHold = &holds
+	e.step("%s is granted under conditions %q, holding without request attributes: %t", e.Action, conditions, holds)
+	return holds, nil
+}
+
+func (e *PermissionExplanation) step(format string, args ...interface{}) {
+	e.Steps = append(e.Steps, fmt.Sprintf(format, args...))
+}
+
+// reason picks the first check that explains the decision
+func (e *PermissionExplanation) reason() string {
+	if e.Allowed {
+		if e.ConsoleAdmin {
+			return ReasonConsoleAdmin
+		}
+		return ReasonGranted
+	}
+
+	if e.Module == nil {
+		return ReasonModuleNotFound
+	}
+	if !e.Module.IsActive {
+		return ReasonModuleInactive
+	}
+	if e.Unit != nil && !e.Unit.InEffectiveUnits {
+		return ReasonNoUnitAccess
+	}
+	if e.ConditionsHold != nil && !*e.ConditionsHold {
+		return ReasonConditionNotMet
+	}
+
+	granted := false
+	for _, role := range e.Roles {
+		if role.GrantsAction {
+			granted = true
+			break
+		}
+	}
+	if e.Unit != nil && len(e.Unit.GrantedBy) > 0 {
+		granted = granted || e.Unit.GrantsAction
+	}
+	if !granted {
+		for _, role := range e.Roles {
+			if !role.InEffect && role.Actions.Has(e.Action) {
+				return ReasonAssignmentNotActive
+			}
+		}
+		return ReasonNoRoleGrant
+	}
+
+	if e.Module.SubscriptionTier == "" || e.Module.SubscriptionTier == "basic" {
+		return ReasonDenied
+	}
+	switch {
+	case e.Subscription == nil || !e.Subscription.Active:
+		return ReasonSubscriptionInactive
+	case !e.Subscription.PlanIncludesModule:
+		return ReasonNotInPlan
+	case e.Tier == TierBasic:
+		return ReasonNotBasicTier
+	}
+	return ReasonDenied
+}
+
+func (r *RBACService) traceModule(e *PermissionExplanation) error {
+	module := &ModuleTrace{}
+	var tier sql.NullString
+	err := r.db.QueryRow(`
+		SELECT id, name, url, is_active, subscription_tier FROM modules WHERE id = $1
+	`, e.ModuleID).Scan(&module.ID, &module.Name, &module.URL, &module.IsActive, &tier)
+	if err == sql.ErrNoRows {
+		e.step("module %d does not exist", e.ModuleID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get module: %w", err)
+	}
+
+	module.SubscriptionTier = tier.String
+	e.Module = module
+	e.step("module %q is_active=%t subscription_tier=%q", module.Name, module.IsActive, module.SubscriptionTier)
+	return nil
+}
+
+// formatWindowBound renders an optional bound of a validity window
+func formatWindowBound(t *time.Time) string {
+	if t == nil {
+		return "none"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (r *RBACService) traceRoles(e *PermissionExplanation) error {
+	now := time.Now()
+	rows, err := r.db.Query(`
+		SELECT ur.role_id, r.name, r.is_active, ur.company_id, ur.branch_id, ur.unit_id,
+			ur.valid_from, ur.valid_until, rm.role_id IS NOT NULL, rm.conditions, rm.actions, gr.id, gr.name
+		FROM user_roles ur
+		JOIN roles r ON ur.role_id = r.id
+		LEFT JOIN (
+			role_ancestors ra
+			JOIN role_modules rm ON rm.role_id = ra.ancestor_id AND rm.module_id = $2
+			JOIN roles gr ON gr.id = ra.ancestor_id
+		) ON ra.role_id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY ur.id, ra.depth, gr.id
+	`, e.UserID, e.ModuleID)
+	if err != nil {
+		return fmt.Errorf("failed to get user roles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role RoleGrantTrace
+		var conditions []byte
+		var grantingRoleID *int64
+		var grantingRoleName *string
+		if err := rows.Scan(&role.RoleID, &role.RoleName, &role.RoleActive, &role.CompanyID,
+			&role.BranchID, &role.UnitID, &role.ValidFrom, &role.ValidUntil, &role.ModuleGranted, &conditions, &role.Actions,
+			&grantingRoleID, &grantingRoleName); err != nil {
+			return fmt.Errorf("failed to scan user role: %w", err)
+		}
+		role.Conditions = parseGrantConditions(conditions, role.Actions)
+		if grantingRoleID != nil && *grantingRoleID != role.RoleID {
+			role.InheritedFrom = *grantingRoleName
+		}
+
+		role.Level = "company"
+		if role.UnitID != nil {
+			role.Level = "unit"
+		} else if role.BranchID != nil {
+			role.Level = "branch"
+		}
+		role.InEffect = (role.ValidFrom == nil || !role.ValidFrom.After(now)) &&
+			(role.ValidUntil == nil || role.ValidUntil.After(now))
+		role.GrantsAction = role.InEffect && role.Actions.Has(e.Action)
+
+		e.Roles = append(e.Roles, role)
+		if !role.InEffect {
+			e.step("role %q assignment is outside its validity window (valid_from=%v, valid_until=%v)",
+				role.RoleName, formatWindowBound(role.ValidFrom), formatWindowBound(role.ValidUntil))
+		}
+		if role.InheritedFrom != "" {
+			e.step("role %q (%s level) inherits from %q, which grants %s: %t",
+				role.RoleName, role.Level, role.InheritedFrom, e.Action, role.GrantsAction)
+		} else {
+			e.step("role %q (%s level) grants %s: %t", role.RoleName, role.Level, e.Action, role.GrantsAction)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read user roles: %w", err)
+	}
+
+	if len(e.Roles) == 0 {
+		e.step("user has no role assignments")
+	}
+	return nil
+}
+
+// traceSubscription reports the subscription of the company used for subscription filtering,
+// preferring an active one that includes the module
+func (r *RBACService) traceSubscription(e *PermissionExplanation) error {
+	var companyID int64
+	err := r.db.QueryRow(`SELECT company_id FROM active_user_roles WHERE user_id = $1 LIMIT 1`, e.UserID).Scan(&companyID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user company: %w", err)
+	}
+
+	subscription := &SubscriptionTrace{CompanyID: companyID}
+	var planName, status sql.NullString
+	var endDate sql.NullTime
+	err = r.db.QueryRow(`
+		SELECT s.id, s.plan_id, sp.name, s.status, s.end_date,
+			s.status = 'active' AND s.end_date > CURRENT_DATE AS active,
+			EXISTS (
+				SELECT 1 FROM plan_modules pm
+				WHERE pm.plan_id = s.plan_id AND pm.module_id = $2 AND pm.is_included = true
+			) AS includes_module
+		FROM subscriptions s
+		LEFT JOIN subscription_plans sp ON s.plan_id = sp.id
+		WHERE s.company_id = $1
+		ORDER BY active DESC, includes_module DESC, s.end_date DESC
+		LIMIT 1
+	`, companyID, e.ModuleID).Scan(&subscription.SubscriptionID, &subscription.PlanID, &planName,
+		&status, &endDate, &subscription.Active, &subscription.PlanIncludesModule)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get company subscription: %w", err)
+	}
+
+	subscription.PlanName = planName.String
+	subscription.Status = status.String
+	if endDate.Valid {
+		subscription.EndDate = &endDate.Time
+	}
+	e.Subscription = subscription
+
+	if subscription.SubscriptionID == nil {
+		e.step("company %d has no subscription", companyID)
+	} else {
+		e.step("company %d subscription plan %q active=%t includes module: %t",
+			companyID, subscription.PlanName, subscription.Active, subscription.PlanIncludesModule)
+	}
+	return nil
+}
+
+func traceUnit(permissions *UnitUserPermissions, moduleID int64, action string, unitID int64) *UnitTrace {
+	trace := &UnitTrace{
+		UnitID:         unitID,
+		EffectiveUnits: permissions.EffectiveUnits,
+		GrantedBy:      []PermissionSource{},
+	}
+
+	for _, effectiveUnitID := range permissions.EffectiveUnits {
+		if effectiveUnitID == unitID {
+			trace.InEffectiveUnits = true
+			break
+		}
+	}
+
+	if modulePerm, exists := permissions.Modules[moduleID]; exists {
+		trace.GrantedBy = modulePerm.GrantedBy
+		trace.HighestLevel = modulePerm.HighestLevel
+		trace.GrantsAction = modulePerm.Grants(action)
+	}
+	return trace
+}
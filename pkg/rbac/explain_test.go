@@ -0,0 +1,37 @@
+package rbac
+
+import "testing"
+
+func TestExplanationReason(t *testing.T) {
+	activeModule := &ModuleTrace{ID: 7, IsActive: true, SubscriptionTier: "premium"}
+	granting := []RoleGrantTrace{{RoleName: "HR_ADMIN", ModuleGranted: true, Actions: Actions{"read"}, GrantsAction: true}}
+	includedPlan := &SubscriptionTrace{CompanyID: 1, Active: true, PlanIncludesModule: true}
+
+	tests := []struct {
+		name        string
+		explanation PermissionExplanation
+		want        string
+	}{
+		{"granted", PermissionExplanation{Allowed: true, Module: activeModule}, ReasonGranted},
+		{"console admin", PermissionExplanation{Allowed: true, ConsoleAdmin: true}, ReasonConsoleAdmin},
+		{"missing module", PermissionExplanation{}, ReasonModuleNotFound},
+		{"inactive module", PermissionExplanation{Module: &ModuleTrace{IsActive: false}}, ReasonModuleInactive},
+		{"no grant", PermissionExplanation{Module: activeModule, Roles: []RoleGrantTrace{{RoleName: "STAFF"}}}, ReasonNoRoleGrant},
+		{"expired assignment", PermissionExplanation{Module: activeModule, Action: "read",
+			Roles: []RoleGrantTrace{{RoleName: "STAFF", Actions: Actions{"read"}}}}, ReasonAssignmentNotActive},
+		{"outside unit", PermissionExplanation{Module: activeModule, Roles: granting, Unit: &UnitTrace{UnitID: 3}}, ReasonNoUnitAccess},
+		{"expired subscription", PermissionExplanation{Module: activeModule, Roles: granting, Tier: TierBasic,
+			Subscription: &SubscriptionTrace{CompanyID: 1, PlanIncludesModule: true}}, ReasonSubscriptionInactive},
+		{"no subscription", PermissionExplanation{Module: activeModule, Roles: granting, Tier: TierBasic}, ReasonSubscriptionInactive},
+		{"not in plan", PermissionExplanation{Module: activeModule, Roles: granting, Tier: TierSubscription,
+			Subscription: &SubscriptionTrace{CompanyID: 1, Active: true}}, ReasonNotInPlan},
+		{"basic fallback", PermissionExplanation{Module: activeModule, Roles: granting, Tier: TierBasic,
+			BasicFallback: FallbackSubscriptionError, Subscription: includedPlan}, ReasonNotBasicTier},
+	}
+
+	for _, tt := range tests {
+		if got := tt.explanation.reason(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
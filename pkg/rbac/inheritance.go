@@ -0,0 +1,138 @@
+package rbac
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MaxRoleDepth bounds the number of parent links in a chain of roles
+const MaxRoleDepth = 10
+
+// RoleGraph maps a role to its parent roles
+type RoleGraph map[int64][]int64
+
+// SetParents returns a copy of the graph in which roleID has the given parents
+func (g RoleGraph) SetParents(roleID int64, parents []int64) RoleGraph {
+	graph := make(RoleGraph, len(g)+1)
+	for id, roleParents := range g {
+		graph[id] = roleParents
+	}
+	if len(parents) == 0 {
+		delete(graph, roleID)
+	} else {
+		graph[roleID] = parents
+	}
+	return graph
+}
+
+// Cycle returns a cycle of the graph as the role IDs along it, starting and ending with the
+// same role, or nil when the graph is acyclic
+func (g RoleGraph) Cycle() []int64 {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[int64]int, len(g))
+	var path []int64
+
+	var visit func(roleID int64) []int64
+	visit = func(roleID int64) []int64 {
+		state[roleID] = visiting
+		path = append(path, roleID)
+		for _, parentID := range g[roleID] {
+			switch state[parentID] {
+			case visiting:
+				for i, id := range path {
+					if id == parentID {
+						return append(append([]int64{}, path[i:]...), parentID)
+					}
+				}
+			case unvisited:
+				if cycle := visit(parentID); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[roleID] = done
+		return nil
+	}
+
+	for _, roleID := range g.roleIDs() {
+		if state[roleID] == unvisited {
+			if cycle := visit(roleID); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Depth returns the number of parent links in the longest chain; the graph must be acyclic
+func (g RoleGraph) Depth() int {
+	depths := make(map[int64]int, len(g))
+	var depth func(roleID int64) int
+	depth = func(roleID int64) int {
+		if d, known := depths[roleID]; known {
+			return d
+		}
+		d := 0
+		for _, parentID := range g[roleID] {
+			if parentDepth := depth(parentID) + 1; parentDepth > d {
+				d = parentDepth
+			}
+		}
+		depths[roleID] = d
+		return d
+	}
+
+	longest := 0
+	for _, roleID := range g.roleIDs() {
+		if d := depth(roleID); d > longest {
+			longest = d
+		}
+	}
+	return longest
+}
+
+// Ancestors returns the parent roles of a role, transitively, nearest first
+func (g RoleGraph) Ancestors(roleID int64) []int64 {
+	seen := map[int64]bool{roleID: true}
+	var ancestors []int64
+	queue := []int64{roleID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, parentID := range g[current] {
+			if !seen[parentID] {
+				seen[parentID] = true
+				ancestors = append(ancestors, parentID)
+				queue = append(queue, parentID)
+			}
+		}
+	}
+	return ancestors
+}
+
+// ValidateParents checks that giving roleID the parents keeps the hierarchy acyclic and
+// within MaxRoleDepth
+func (g RoleGraph) ValidateParents(roleID int64, parents []int64) error {
+	graph := g.SetParents(roleID, parents)
+	if cycle := graph.Cycle(); cycle != nil {
+		return fmt.Errorf("role inheritance cycle: %v", cycle)
+	}
+	if depth := graph.Depth(); depth > MaxRoleDepth {
+		return fmt.Errorf("role inheritance too deep: %d levels (max %d)", depth, MaxRoleDepth)
+	}
+	return nil
+}
+
+func (g RoleGraph) roleIDs() []int64 {
+	roleIDs := make([]int64, 0, len(g))
+	for roleID := range g {
+		roleIDs = append(roleIDs, roleID)
+	}
+	sort.Slice(roleIDs, func(i, j int) bool { return roleIDs[i] < roleIDs[j] })
+	return roleIDs
+}
@@ -0,0 +1,47 @@
+package rbac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoleGraphValidateParents(t *testing.T) {
+	// 4 -> 3 -> 2 -> 1, and 5 -> 1
+	graph := RoleGraph{4: {3}, 3: {2}, 2: {1}, 5: {1}}
+
+	if err := graph.ValidateParents(5, []int64{1, 4}); err != nil {
+		t.Errorf("unexpected error for a diamond: %v", err)
+	}
+	if err := graph.ValidateParents(1, []int64{4}); err == nil {
+		t.Error("expected a cycle through 1 -> 4 -> 3 -> 2 -> 1")
+	}
+	if err := graph.ValidateParents(2, []int64{2}); err == nil {
+		t.Error("expected a role inheriting from itself to be a cycle")
+	}
+
+	chain := RoleGraph{}
+	for roleID := int64(1); roleID < MaxRoleDepth+1; roleID++ {
+		chain[roleID+1] = []int64{roleID}
+	}
+	if err := chain.ValidateParents(100, nil); err != nil {
+		t.Errorf("unexpected error at the maximum depth: %v", err)
+	}
+	if err := chain.ValidateParents(1, []int64{100}); err == nil {
+		t.Error("expected an error beyond the maximum depth")
+	}
+}
+
+func TestRoleGraphCycleAndAncestors(t *testing.T) {
+	graph := RoleGraph{1: {2}, 2: {3}, 3: {1}, 4: {1}}
+	if cycle := graph.Cycle(); !reflect.DeepEqual(cycle, []int64{1, 2, 3, 1}) {
+		t.Errorf("got cycle %v", cycle)
+	}
+
+	acyclic := RoleGraph{4: {3, 2}, 3: {1}, 2: {1}}
+	if cycle := acyclic.Cycle(); cycle != nil {
+		t.Errorf("unexpected cycle %v", cycle)
+	}
+	if ancestors := acyclic.Ancestors(4); !reflect.DeepEqual(ancestors, []int64{3, 2, 1}) {
+		t.Errorf("got ancestors %v", ancestors)
+	}
+}
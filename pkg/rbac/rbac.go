@@ -10,26 +10,85 @@ type UserPermissions struct {
 	UserID  int64
 	Roles   []string
 	Modules map[int64]ModulePermission
+	// BasicFallback tells why only basic tier modules were granted, empty when the
+	// company subscription applied
+	BasicFallback string
 }
 
+// Reasons for falling back to basic tier permissions
+const (
+	FallbackNoCompany             = "no_company"
+	FallbackSubscriptionError     = "subscription_query_failed"
+	FallbackNoSubscriptionModules = "no_subscription_modules"
+)
+
 type ModulePermission struct {
 	ModuleID   int64
 	CanRead    bool
 	CanWrite   bool
 	CanDelete  bool
 	CanApprove bool
+	// Actions are the granted actions beyond the standard ones, declared by the module
+	Actions []string
+	// Conditions lists, per action, the conditions of which one must hold; actions
+	// without an entry are granted unconditionally
+	Conditions map[string][]string
+}
+
+// grants gives access to the granted actions of the permission
+func (p *ModulePermission) grants() actionGrants {
+	return actionGrants{flags: [4]*bool{&p.CanRead, &p.CanWrite, &p.CanDelete, &p.CanApprove}, further: &p.Actions}
+}
+
+// Grants reports whether the permission allows an action
+func (p ModulePermission) Grants(action string) bool {
+	return grantsAction(action, p.CanRead, p.CanWrite, p.CanDelete, p.CanApprove, p.Actions)
+}
+
+// querier runs queries against the database or within a transaction
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 type RBACService struct {
-	db *sql.DB
+	db    querier
+	cache *PermissionCache
+	// overlay proposes grants in place of stored ones when simulating a change
+	overlay *grantOverlay
 }
 
 func NewRBACService(db *sql.DB) *RBACService {
 	return &RBACService{db: db}
 }
 
+// NewCachedRBACService creates an RBAC service that caches resolved permissions
+func NewCachedRBACService(db *sql.DB, cache *PermissionCache) *RBACService {
+	return &RBACService{db: db, cache: cache}
+}
+
 // GetUserPermissions retrieves all permissions for a user with subscription filtering
 func (r *RBACService) GetUserPermissions(userID int64) (*UserPermissions, error) {
+	if r.cache == nil {
+		return r.loadUserPermissions(userID)
+	}
+
+	cached := &UserPermissions{}
+	generation, hit := r.cache.load(cacheKindPermissions, userID, cached)
+	if hit {
+		return cached, nil
+	}
+
+	permissions, err := r.loadUserPermissions(userID)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.store(cacheKindPermissions, userID, generation, permissions)
+	return permissions, nil
+}
+
+// loadUserPermissions resolves user permissions from the database
+func (r *RBACService) loadUserPermissions(userID int64) (*UserPermissions, error) {
 	permissions := &UserPermissions{
 		UserID:  userID,
 		Roles:   []string{},
@@ -39,7 +98,7 @@ func (r *RBACService) GetUserPermissions(userID int64) (*UserPermissions, error)
 	// Get user roles
 	roleQuery := `
 		SELECT DISTINCT r.name
-		FROM user_roles ur
+		FROM active_user_roles ur
 		JOIN roles r ON ur.role_id = r.id
 		WHERE ur.user_id = $1
 	`
@@ -60,23 +119,21 @@ func (r *RBACService) GetUserPermissions(userID int64) (*UserPermissions, error)
 
 	// Get user's company ID for subscription filtering
 	var companyID int64
-	companyQuery := `SELECT company_id FROM user_roles WHERE user_id = $1 LIMIT 1`
+	companyQuery := `SELECT company_id FROM active_user_roles WHERE user_id = $1 LIMIT 1`
 	err = r.db.QueryRow(companyQuery, userID).Scan(&companyID)
 	if err != nil {
 		// If no company found, use basic permissions only
+		permissions.BasicFallback = FallbackNoCompany
 		return r.getUserBasicPermissions(userID, permissions)
 	}
 
-	// Get user module permissions with subscription filtering
+	// Get user module grants with subscription filtering
 	permQuery := `
-		SELECT 
-			rm.module_id,
-			MAX(CASE WHEN rm.can_read THEN 1 ELSE 0 END) as can_read,
-			MAX(CASE WHEN rm.can_write THEN 1 ELSE 0 END) as can_write,
-			MAX(CASE WHEN rm.can_delete THEN 1 ELSE 0 END) as can_delete,
-			MAX(CASE WHEN rm.can_approve THEN 1 ELSE 0 END) as can_approve
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		SELECT DISTINCT
+			rm.role_id, rm.module_id, rm.actions, rm.conditions
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		JOIN plan_modules pm ON m.id = pm.module_id AND pm.is_included = true
 		JOIN subscriptions s ON pm.plan_id = s.plan_id
@@ -85,34 +142,25 @@ func (r *RBACService) GetUserPermissions(userID int64) (*UserPermissions, error)
 			AND s.status = 'active'
 			AND s.end_date > CURRENT_DATE
 			AND m.is_active = true
-		GROUP BY rm.module_id
 	`
 
 	permRows, err := r.db.Query(permQuery, userID, companyID)
 	if err != nil {
+		permissions.BasicFallback = FallbackSubscriptionError
 		return r.getUserBasicPermissions(userID, permissions)
 	}
 	defer permRows.Close()
 
-	for permRows.Next() {
-		var moduleID int64
-		var canRead, canWrite, canDelete, canApprove int
-
-		if err := permRows.Scan(&moduleID, &canRead, &canWrite, &canDelete, &canApprove); err != nil {
-			continue
-		}
-
-		permissions.Modules[moduleID] = ModulePermission{
-			ModuleID:   moduleID,
-			CanRead:    canRead == 1,
-			CanWrite:   canWrite == 1,
-			CanDelete:  canDelete == 1,
-			CanApprove: canApprove == 1,
-		}
+	scanModuleGrants(permRows, permissions, r.overlay)
+	for _, grant := range r.overlay.roleGrants(func(module simulatedModule) bool {
+		return module.active && module.subscribed[companyID]
+	}) {
+		mergeModuleGrant(permissions, grant.ModuleID, grant.Actions, grant.Conditions)
 	}
 
 	// If no subscription modules found, fallback to basic
 	if len(permissions.Modules) == 0 {
+		permissions.BasicFallback = FallbackNoSubscriptionModules
 		return r.getUserBasicPermissions(userID, permissions)
 	}
 
@@ -122,19 +170,15 @@ func (r *RBACService) GetUserPermissions(userID int64) (*UserPermissions, error)
 // getUserBasicPermissions returns basic tier permissions only
 func (r *RBACService) getUserBasicPermissions(userID int64, permissions *UserPermissions) (*UserPermissions, error) {
 	permQuery := `
-		SELECT 
-			rm.module_id,
-			MAX(CASE WHEN rm.can_read THEN 1 ELSE 0 END) as can_read,
-			MAX(CASE WHEN rm.can_write THEN 1 ELSE 0 END) as can_write,
-			MAX(CASE WHEN rm.can_delete THEN 1 ELSE 0 END) as can_delete,
-			MAX(CASE WHEN rm.can_approve THEN 1 ELSE 0 END) as can_approve
-		FROM user_roles ur
-		JOIN role_modules rm ON ur.role_id = rm.role_id
+		SELECT DISTINCT
+			rm.role_id, rm.module_id, rm.actions, rm.conditions
+		FROM active_user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN modules m ON rm.module_id = m.id
 		WHERE ur.user_id = $1
 			AND m.is_active = true
 			AND (m.subscription_tier = 'basic' OR m.subscription_tier IS NULL)
-		GROUP BY rm.module_id
 	`
 
 	permRows, err := r.db.Query(permQuery, userID)
@@ -143,30 +187,60 @@ func (r *RBACService) getUserBasicPermissions(userID int64, permissions *UserPer
 	}
 	defer permRows.Close()
 
-	for permRows.Next() {
-		var moduleID int64
-		var canRead, canWrite, canDelete, canApprove int
+	scanModuleGrants(permRows, permissions, r.overlay)
+	for _, grant := range r.overlay.roleGrants(func(module simulatedModule) bool {
+		return module.active && module.basic
+	}) {
+		mergeModuleGrant(permissions, grant.ModuleID, grant.Actions, grant.Conditions)
+	}
+
+	return permissions, nil
+}
+
+// scanModuleGrants merges the grants of the user's roles and the roles they inherit from
+// into the module permissions; any grant of
+// an action allows it, and conditions only remain when every grant of the action has one.
+// Grants replaced by the overlay are left out.
+func scanModuleGrants(rows *sql.Rows, permissions *UserPermissions, overlay *grantOverlay) {
+	for rows.Next() {
+		var roleID, moduleID int64
+		var grant Actions
+		var conditions []byte
 
-		if err := permRows.Scan(&moduleID, &canRead, &canWrite, &canDelete, &canApprove); err != nil {
+		if err := rows.Scan(&roleID, &moduleID, &grant, &conditions); err != nil {
 			continue
 		}
-
-		permissions.Modules[moduleID] = ModulePermission{
-			ModuleID:   moduleID,
-			CanRead:    canRead == 1,
-			CanWrite:   canWrite == 1,
-			CanDelete:  canDelete == 1,
-			CanApprove: canApprove == 1,
+		if overlay.coversRoleGrant(roleID, moduleID) {
+			continue
 		}
+
+		mergeModuleGrant(permissions, moduleID, grant, parseGrantConditions(conditions, grant))
 	}
+}
 
-	return permissions, nil
+// mergeModuleGrant merges one grant into the permission of its module
+func mergeModuleGrant(permissions *UserPermissions, moduleID int64, grant Actions, conditions Conditions) {
+	modulePerm, exists := permissions.Modules[moduleID]
+	if !exists {
+		modulePerm = ModulePermission{ModuleID: moduleID}
+	}
+
+	modulePerm.Conditions = mergeGrant(modulePerm.grants(), modulePerm.Conditions, grant, conditions)
+
+	permissions.Modules[moduleID] = modulePerm
 }
 
-// HasPermission checks if user has specific permission for a module
+// HasPermission checks if user has specific permission for a module. Conditional grants
+// are evaluated without request attributes; use HasPermissionWithContext to supply them.
 func (r *RBACService) HasPermission(userID int64, moduleID int64, permission string) (bool, error) {
+	return r.HasPermissionWithContext(userID, moduleID, permission, nil)
+}
+
+// HasPermissionWithContext checks if user has specific permission for a module, evaluating
+// the conditions of conditional grants against the request attributes
+func (r *RBACService) HasPermissionWithContext(userID int64, moduleID int64, permission string, attributes map[string]interface{}) (bool, error) {
 	// Special case: CONSOLE ADMIN role has full access to API Documentation modules (139-143)
-	if moduleID >= 139 && moduleID <= 143 {
+	if isConsoleAdminModule(moduleID) {
 		hasConsoleAdminRole, err := r.hasConsoleAdminRole(userID)
 		if err != nil {
 			return false, err
@@ -181,30 +255,32 @@ func (r *RBACService) HasPermission(userID int64, moduleID int64, permission str
 		return false, err
 	}
 
+	if !IsValidAction(permission) {
+		return false, fmt.Errorf("invalid permission type: %s", permission)
+	}
+
 	modulePerm, exists := permissions.Modules[moduleID]
 	if !exists {
 		return false, nil
 	}
 
-	switch permission {
-	case "read":
-		return modulePerm.CanRead, nil
-	case "write":
-		return modulePerm.CanWrite, nil
-	case "delete":
-		return modulePerm.CanDelete, nil
-	case "approve":
-		return modulePerm.CanApprove, nil
-	default:
-		return false, fmt.Errorf("invalid permission type: %s", permission)
+	granted := modulePerm.Grants(permission)
+	if !granted || len(modulePerm.Conditions[permission]) == 0 {
+		return granted, nil
+	}
+
+	environment, err := r.conditionEnvironment(userID, attributes)
+	if err != nil {
+		return false, err
 	}
+	return anyConditionHolds(modulePerm.Conditions[permission], environment), nil
 }
 
 // hasConsoleAdminRole checks if user has CONSOLE ADMIN role (role_id=13)
 func (r *RBACService) hasConsoleAdminRole(userID int64) (bool, error) {
 	query := `
 		SELECT COUNT(*)
-		FROM user_roles ur
+		FROM active_user_roles ur
 		JOIN roles r ON ur.role_id = r.id
 		WHERE ur.user_id = $1 AND r.id = 13
 	`
@@ -242,20 +318,7 @@ func (r *RBACService) GetAccessibleModules(userID int64, permission string) ([]i
 
 	var moduleIDs []int64
 	for moduleID, modulePerm := range permissions.Modules {
-		hasPermission := false
-
-		switch permission {
-		case "read":
-			hasPermission = modulePerm.CanRead
-		case "write":
-			hasPermission = modulePerm.CanWrite
-		case "delete":
-			hasPermission = modulePerm.CanDelete
-		case "approve":
-			hasPermission = modulePerm.CanApprove
-		}
-
-		if hasPermission {
+		if modulePerm.Grants(permission) {
 			moduleIDs = append(moduleIDs, moduleID)
 		}
 	}
@@ -263,6 +326,9 @@ func (r *RBACService) GetAccessibleModules(userID int64, permission string) ([]i
 	return moduleIDs, nil
 }
 
+// RoleServiceAccount is held by accounts of downstream services, which may check permissions on behalf of other users
+const RoleServiceAccount = "SERVICE_ACCOUNT"
+
 // IsSuperAdmin checks if user is super admin
 func (r *RBACService) IsSuperAdmin(userID int64) (bool, error) {
 	return r.HasRole(userID, "SUPER_ADMIN")
@@ -276,24 +342,13 @@ func (r *RBACService) GetFilteredModules(userID int64, permission string, limit,
 	}
 
 	// Build accessible module IDs based on permission
+	if !IsValidAction(permission) {
+		permission = "read" // Default to read permission
+	}
+
 	var accessibleModuleIDs []int64
 	for moduleID, modulePerm := range permissions.Modules {
-		hasPermission := false
-
-		switch permission {
-		case "read":
-			hasPermission = modulePerm.CanRead
-		case "write":
-			hasPermission = modulePerm.CanWrite
-		case "delete":
-			hasPermission = modulePerm.CanDelete
-		case "approve":
-			hasPermission = modulePerm.CanApprove
-		default:
-			hasPermission = modulePerm.CanRead // Default to read permission
-		}
-
-		if hasPermission {
+		if modulePerm.Grants(permission) {
 			accessibleModuleIDs = append(accessibleModuleIDs, moduleID)
 		}
 	}
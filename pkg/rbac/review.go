@@ -0,0 +1,67 @@
+package rbac
+
+// AdminLevels reports the administrative levels a role confers. Unit admins manage
+// units, branch admins also branches, company and super admins the whole company.
+func AdminLevels(roleName string) (company, branch, unit bool) {
+	switch roleName {
+	case "SUPER_ADMIN", "COMPANY_ADMIN":
+		return true, true, true
+	case "BRANCH_ADMIN":
+		return false, true, true
+	case "UNIT_ADMIN":
+		return false, false, true
+	}
+	return false, false, false
+}
+
+// AdminAssignment is an administrative role held by a user at a company, branch or unit
+type AdminAssignment struct {
+	UserID    int64
+	RoleName  string
+	CompanyID int64
+	BranchID  *int64
+	UnitID    *int64
+}
+
+// ChooseReviewer picks who reviews an assignment at the given scope: an admin of its
+// unit, else of its branch, else of its company, never the user holding the assignment.
+// An admin counts for the scope it is assigned at, so a branch admin of another branch
+// or a unit admin of a sibling unit is not considered. Ties go to the lowest user ID so
+// repeated campaigns pick the same reviewer. ok is false when no admin qualifies.
+func ChooseReviewer(admins []AdminAssignment, companyID int64, branchID, unitID *int64, subjectID int64) (reviewerID int64, ok bool) {
+	// Candidates by tier: 0 unit, 1 branch, 2 company
+	var best [3]int64
+	for _, admin := range admins {
+		if admin.UserID == subjectID || admin.CompanyID != companyID {
+			continue
+		}
+
+		company, branch, unit := AdminLevels(admin.RoleName)
+		tier := -1
+		switch {
+		case admin.UnitID != nil:
+			if unit && unitID != nil && *admin.UnitID == *unitID {
+				tier = 0
+			}
+		case admin.BranchID != nil:
+			if branch && branchID != nil && *admin.BranchID == *branchID {
+				tier = 1
+			}
+		default:
+			if company {
+				tier = 2
+			}
+		}
+
+		if tier >= 0 && (best[tier] == 0 || admin.UserID < best[tier]) {
+			best[tier] = admin.UserID
+		}
+	}
+
+	for _, candidate := range best {
+		if candidate != 0 {
+			return candidate, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,44 @@
+package rbac
+
+import "testing"
+
+func TestChooseReviewer(t *testing.T) {
+	branch1, branch2 := int64(10), int64(20)
+	unit1, unit2 := int64(100), int64(200)
+
+	admins := []AdminAssignment{
+		{UserID: 9, RoleName: "COMPANY_ADMIN", CompanyID: 1},
+		{UserID: 7, RoleName: "COMPANY_ADMIN", CompanyID: 1},
+		{UserID: 5, RoleName: "BRANCH_ADMIN", CompanyID: 1, BranchID: &branch1},
+		{UserID: 4, RoleName: "BRANCH_ADMIN", CompanyID: 1, BranchID: &branch2},
+		{UserID: 3, RoleName: "UNIT_ADMIN", CompanyID: 1, BranchID: &branch1, UnitID: &unit1},
+		{UserID: 2, RoleName: "STAFF", CompanyID: 1, BranchID: &branch1, UnitID: &unit2},
+		{UserID: 1, RoleName: "COMPANY_ADMIN", CompanyID: 2},
+	}
+
+	tests := []struct {
+		name     string
+		branchID *int64
+		unitID   *int64
+		subject  int64
+		want     int64
+	}{
+		{"unit admin of the unit", &branch1, &unit1, 50, 3},
+		{"no unit admin, branch admin", &branch1, &unit2, 50, 5},
+		{"branch assignment", &branch2, nil, 50, 4},
+		{"company assignment, lowest ID", nil, nil, 50, 7},
+		{"subject never reviews own access", &branch1, &unit1, 3, 5},
+		{"subject skipped among company admins", nil, nil, 7, 9},
+	}
+
+	for _, tt := range tests {
+		got, ok := ChooseReviewer(admins, 1, tt.branchID, tt.unitID, tt.subject)
+		if !ok || got != tt.want {
+			t.Errorf("%s: got %d (%v), want %d", tt.name, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := ChooseReviewer(admins, 3, nil, nil, 50); ok {
+		t.Error("expected no reviewer in a company without admins")
+	}
+}
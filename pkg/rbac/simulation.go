@@ -0,0 +1,615 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SimulatedGrant is a proposed module grant of a role or unit role
+type SimulatedGrant struct {
+	ModuleID   int64
+	Actions    Actions
+	Conditions Conditions
+}
+
+// PermissionGrant is what a user is effectively granted on a module
+type PermissionGrant struct {
+	Actions    Actions             `json:"actions"`
+	Conditions map[string][]string `json:"conditions,omitempty"`
+}
+
+// ModulePermissionChange is the before and after of a module whose effective grant
+// changes; Before or After is nil when the module is not granted at all
+type ModulePermissionChange struct {
+	ModuleID   int64            `json:"module_id"`
+	ModuleName string           `json:"module_name"`
+	Before     *PermissionGrant `json:"before"`
+	After      *PermissionGrant `json:"after"`
+	Added      []string         `json:"added_actions"`
+	Removed    []string         `json:"removed_actions"`
+	// ConditionsChanged lists the actions granted before and after whose conditions differ
+	ConditionsChanged []string `json:"conditions_changed"`
+}
+
+// UserPermissionImpact lists the modules whose effective permissions change for a user
+type UserPermissionImpact struct {
+	UserID    int64  `json:"user_id"`
+	UserName  string `json:"user_name"`
+	UserEmail string `json:"user_email"`
+	// Modules are the changes to the permissions checked by HasPermission
+	Modules []ModulePermissionChange `json:"modules"`
+	// UnitModules are the changes to the unit-aware permissions checked by HasUnitPermission
+	UnitModules []ModulePermissionChange `json:"unit_modules"`
+	// BasicFallbackBefore and BasicFallbackAfter tell why only basic tier modules apply
+	BasicFallbackBefore string `json:"basic_fallback_before,omitempty"`
+	BasicFallbackAfter  string `json:"basic_fallback_after,omitempty"`
+}
+
+// ActionImpact counts the users gaining and losing an action on a module
+type ActionImpact struct {
+	ModuleID    int64  `json:"module_id"`
+	ModuleName  string `json:"module_name"`
+	Action      string `json:"action"`
+	UsersGained int    `json:"users_gained"`
+	UsersLost   int    `json:"users_lost"`
+}
+
+// PermissionSimulation is the outcome of a dry run of a permission change
+type PermissionSimulation struct {
+	UsersEvaluated int                    `json:"users_evaluated"`
+	UsersAffected  int                    `json:"users_affected"`
+	Summary        []ActionImpact         `json:"summary"`
+	Users          []UserPermissionImpact `json:"users"`
+}
+
+// PermissionSimulator previews how a change of role or unit role grants changes the
+// effective permissions of users. Permissions are resolved by the same queries as
+// RBACService and UnitRBACService, so inheritance, validity windows and subscription
+// filtering apply, and the proposed grants are applied in memory in place of the stored
+// ones. Nothing is written.
+type PermissionSimulator struct {
+	db *sql.DB
+}
+
+// NewPermissionSimulator creates a permission simulator
+func NewPermissionSimulator(db *sql.DB) *PermissionSimulator {
+	return &PermissionSimulator{db: db}
+}
+
+// SimulateRoleGrants previews changing the module grants of a role for every user holding
+// it or a role inheriting from it. With replace the grants become all the grants of the
+// role, otherwise they are set per module.
+func (s *PermissionSimulator) SimulateRoleGrants(roleID int64, grants []SimulatedGrant, replace bool) (*PermissionSimulation, error) {
+	return s.simulate(func(tx *sql.Tx) ([]int64, *grantOverlay, error) {
+		userIDs, err := queryIDs(tx, `
+			SELECT DISTINCT ur.user_id
+			FROM active_user_roles ur
+			JOIN role_ancestors ra ON ra.role_id = ur.role_id
+			WHERE ra.ancestor_id = $1
+			ORDER BY ur.user_id
+		`, roleID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		overlay := newGrantOverlay(grants)
+		overlay.roleID = roleID
+		overlay.replace = replace
+		if err := tx.QueryRow(`SELECT name FROM roles WHERE id = $1`, roleID).Scan(&overlay.roleName); err != nil && err != sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("failed to get role: %w", err)
+		}
+		holders, err := queryIDs(tx, `SELECT role_id FROM role_ancestors WHERE ancestor_id = $1`, roleID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, holder := range holders {
+			overlay.holders[holder] = true
+		}
+		return userIDs, overlay, nil
+	})
+}
+
+// SimulateUnitRoleGrants previews setting module grants of a unit role, per module, for
+// every user assigned to its unit or a descendant unit
+func (s *PermissionSimulator) SimulateUnitRoleGrants(unitRoleID int64, grants []SimulatedGrant) (*PermissionSimulation, error) {
+	return s.simulate(func(tx *sql.Tx) ([]int64, *grantOverlay, error) {
+		overlay := newGrantOverlay(grants)
+		overlay.unitRoleID = unitRoleID
+		err := tx.QueryRow(`
+			SELECT unr.role_id, r.name, unr.unit_id, u.name, EXISTS (SELECT 1 FROM active_unit_roles a WHERE a.id = unr.id)
+			FROM unit_roles unr
+			JOIN roles r ON r.id = unr.role_id
+			JOIN units u ON u.id = unr.unit_id
+			WHERE unr.id = $1
+		`, unitRoleID).Scan(&overlay.roleID, &overlay.roleName, &overlay.unitID, &overlay.unitName, &overlay.unitActive)
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("unit role dengan ID %d tidak ditemukan (unit role not found)", unitRoleID)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get unit role: %w", err)
+		}
+
+		userIDs, err := queryIDs(tx, `
+			WITH RECURSIVE unit_tree AS (
+				SELECT id, 0 as level FROM units WHERE id = $1
+				UNION ALL
+				SELECT u.id, ut.level + 1
+				FROM units u
+				JOIN unit_tree ut ON u.parent_id = ut.id
+				WHERE ut.level < 10
+			)
+			SELECT DISTINCT ur.user_id
+			FROM active_user_roles ur
+			JOIN unit_tree ut ON ur.unit_id = ut.id
+			ORDER BY ur.user_id
+		`, overlay.unitID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return userIDs, overlay, nil
+	})
+}
+
+// resolvedPermissions are the permissions of a user under both resolutions
+type resolvedPermissions struct {
+	permissions     *UserPermissions
+	unitPermissions *UnitUserPermissions
+}
+
+// simulate resolves the permissions of the users before and after applying the overlay of
+// a change. Everything is read in one read-only transaction, so both resolutions see the
+// same snapshot and no rows are locked.
+func (s *PermissionSimulator) simulate(load func(*sql.Tx) ([]int64, *grantOverlay, error)) (*PermissionSimulation, error) {
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	userIDs, overlay, err := load(tx)
+	if err != nil {
+		return nil, err
+	}
+	if overlay.modules, err = loadSimulatedModules(tx, overlay.grants); err != nil {
+		return nil, err
+	}
+
+	before, err := resolveAll(tx, userIDs, nil)
+	if err != nil {
+		return nil, err
+	}
+	after, err := resolveAll(tx, userIDs, overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	simulation := &PermissionSimulation{UsersEvaluated: len(userIDs), Users: []UserPermissionImpact{}}
+	for i, userID := range userIDs {
+		impact := UserPermissionImpact{
+			UserID:      userID,
+			Modules:     DiffModulePermissions(before[i].permissions.Modules, after[i].permissions.Modules),
+			UnitModules: DiffModulePermissions(unitModulePermissions(before[i].unitPermissions), unitModulePermissions(after[i].unitPermissions)),
+		}
+		if len(impact.Modules) == 0 && len(impact.UnitModules) == 0 {
+			continue
+		}
+		impact.BasicFallbackBefore = before[i].permissions.BasicFallback
+		impact.BasicFallbackAfter = after[i].permissions.BasicFallback
+		simulation.Users = append(simulation.Users, impact)
+	}
+	simulation.UsersAffected = len(simulation.Users)
+
+	if err := describe(tx, simulation); err != nil {
+		return nil, err
+	}
+	simulation.Summary = SummarizeImpacts(simulation.Users)
+	return simulation, nil
+}
+
+// resolveAll resolves the permissions of each user with the overlay, bypassing the cache
+func resolveAll(tx *sql.Tx, userIDs []int64, overlay *grantOverlay) ([]resolvedPermissions, error) {
+	rbacService := &RBACService{db: tx, overlay: overlay}
+	unitService := &UnitRBACService{db: tx, overlay: overlay}
+
+	resolved := make([]resolvedPermissions, 0, len(userIDs))
+	for _, userID := range userIDs {
+		permissions, err := rbacService.loadUserPermissions(userID)
+		if err != nil {
+			return nil, err
+		}
+		unitPermissions, err := unitService.loadUserUnitPermissions(userID)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, resolvedPermissions{permissions: permissions, unitPermissions: unitPermissions})
+	}
+	return resolved, nil
+}
+
+// loadSimulatedModules loads what decides whether the proposed grants take effect
+func loadSimulatedModules(tx *sql.Tx, grants map[int64]SimulatedGrant) (map[int64]simulatedModule, error) {
+	moduleIDs := make([]int64, 0, len(grants))
+	for moduleID := range grants {
+		moduleIDs = append(moduleIDs, moduleID)
+	}
+
+	modules := make(map[int64]simulatedModule, len(moduleIDs))
+	err := queryByIDs(tx, `
+		SELECT m.id, m.is_active, COALESCE(m.subscription_tier = 'basic', true), s.company_id
+		FROM modules m
+		LEFT JOIN plan_modules pm ON pm.module_id = m.id AND pm.is_included = true
+		LEFT JOIN subscriptions s ON s.plan_id = pm.plan_id AND s.status = 'active' AND s.end_date > CURRENT_DATE
+		WHERE m.id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)
+	`, moduleIDs, func(rows *sql.Rows) error {
+		var moduleID int64
+		var module simulatedModule
+		var companyID sql.NullInt64
+		if err := rows.Scan(&moduleID, &module.active, &module.basic, &companyID); err != nil {
+			return err
+		}
+		if existing, ok := modules[moduleID]; ok {
+			module.subscribed = existing.subscribed
+		} else {
+			module.subscribed = map[int64]bool{}
+		}
+		if companyID.Valid {
+			module.subscribed[companyID.Int64] = true
+		}
+		modules[moduleID] = module
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load simulated modules: %w", err)
+	}
+	return modules, nil
+}
+
+// grantOverlay proposes the module grants of a role or a unit role. Permissions resolved
+// with an overlay leave out the stored grants it replaces and merge in the proposed ones.
+type grantOverlay struct {
+	// roleID is the role whose role_modules are proposed, or the role of the unit role
+	// whose unit_role_modules are proposed
+	roleID     int64
+	unitRoleID int64
+	// replace drops every stored grant of the role rather than those of the proposed modules
+	replace bool
+	grants  map[int64]SimulatedGrant
+	modules map[int64]simulatedModule
+	// holders are the roles inheriting the role, including the role itself
+	holders  map[int64]bool
+	roleName string
+	unitID   int64
+	unitName string
+	// unitActive is false outside the validity window of the unit role
+	unitActive bool
+}
+
+// simulatedModule decides whether a proposed grant on a module takes effect
+type simulatedModule struct {
+	active bool
+	// basic modules are granted without a subscription
+	basic bool
+	// subscribed are the companies whose active subscription includes the module
+	subscribed map[int64]bool
+}
+
+// newGrantOverlay proposes grants per module; a later grant of a module replaces an earlier one
+func newGrantOverlay(grants []SimulatedGrant) *grantOverlay {
+	overlay := &grantOverlay{grants: make(map[int64]SimulatedGrant, len(grants)), holders: map[int64]bool{}}
+	for _, grant := range grants {
+		overlay.grants[grant.ModuleID] = grant
+	}
+	return overlay
+}
+
+// coversRoleGrant reports whether a stored grant of a role is replaced by the overlay
+func (o *grantOverlay) coversRoleGrant(roleID, moduleID int64) bool {
+	if o == nil || o.unitRoleID != 0 || roleID != o.roleID {
+		return false
+	}
+	_, proposed := o.grants[moduleID]
+	return o.replace || proposed
+}
+
+// coversUnitRoleGrant reports whether a stored grant of a unit role is replaced by the overlay
+func (o *grantOverlay) coversUnitRoleGrant(unitRoleID, moduleID int64) bool {
+	if o == nil || o.unitRoleID == 0 || unitRoleID != o.unitRoleID {
+		return false
+	}
+	_, proposed := o.grants[moduleID]
+	return proposed
+}
+
+// roleGrants returns the proposed grants of a role on the modules that take effect. They
+// apply to the users holding the role, the only users a simulation resolves.
+func (o *grantOverlay) roleGrants(effective func(simulatedModule) bool) []SimulatedGrant {
+	if o == nil || o.unitRoleID != 0 {
+		return nil
+	}
+	return o.effectiveGrants(effective)
+}
+
+// unitRoleGrants returns the proposed grants of a unit role on the active modules when the
+// unit role applies to the effective units
+func (o *grantOverlay) unitRoleGrants(effectiveUnits []int64) []SimulatedGrant {
+	if o == nil || o.unitRoleID == 0 || !o.unitActive {
+		return nil
+	}
+	for _, unitID := range effectiveUnits {
+		if unitID == o.unitID {
+			return o.effectiveGrants(func(module simulatedModule) bool { return module.active })
+		}
+	}
+	return nil
+}
+
+// heldRoles returns the assigned roles inheriting the role of the overlay, each once
+func (o *grantOverlay) heldRoles(assignments []UnitRoleInfo) []int64 {
+	if o == nil || o.unitRoleID != 0 {
+		return nil
+	}
+	held := map[int64]bool{}
+	for _, assignment := range assignments {
+		if o.holders[assignment.RoleID] {
+			held[assignment.RoleID] = true
+		}
+	}
+	return setIDs(held)
+}
+
+func (o *grantOverlay) effectiveGrants(effective func(simulatedModule) bool) []SimulatedGrant {
+	moduleIDs := make(map[int64]bool, len(o.grants))
+	for moduleID := range o.grants {
+		moduleIDs[moduleID] = true
+	}
+
+	var grants []SimulatedGrant
+	for _, moduleID := range setIDs(moduleIDs) {
+		if effective(o.modules[moduleID]) {
+			grants = append(grants, o.grants[moduleID])
+		}
+	}
+	return grants
+}
+
+// describe names the users and modules of the simulation
+func describe(tx *sql.Tx, simulation *PermissionSimulation) error {
+	if len(simulation.Users) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int64, 0, len(simulation.Users))
+	moduleSet := map[int64]bool{}
+	for _, user := range simulation.Users {
+		userIDs = append(userIDs, user.UserID)
+		for _, change := range append(append([]ModulePermissionChange{}, user.Modules...), user.UnitModules...) {
+			moduleSet[change.ModuleID] = true
+		}
+	}
+
+	type userInfo struct{ name, email string }
+	users := map[int64]userInfo{}
+	err := queryByIDs(tx, `SELECT id, name, email FROM users WHERE id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)`,
+		userIDs, func(rows *sql.Rows) error {
+			var id int64
+			var info userInfo
+			if err := rows.Scan(&id, &info.name, &info.email); err != nil {
+				return err
+			}
+			users[id] = info
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	moduleNames := map[int64]string{}
+	err = queryByIDs(tx, `SELECT id, name FROM modules WHERE id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)`,
+		setIDs(moduleSet), func(rows *sql.Rows) error {
+			var id int64
+			var name string
+			if err := rows.Scan(&id, &name); err != nil {
+				return err
+			}
+			moduleNames[id] = name
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	for i := range simulation.Users {
+		user := &simulation.Users[i]
+		user.UserName = users[user.UserID].name
+		user.UserEmail = users[user.UserID].email
+		for j := range user.Modules {
+			user.Modules[j].ModuleName = moduleNames[user.Modules[j].ModuleID]
+		}
+		for j := range user.UnitModules {
+			user.UnitModules[j].ModuleName = moduleNames[user.UnitModules[j].ModuleID]
+		}
+	}
+	return nil
+}
+
+func queryIDs(tx *sql.Tx, query string, args ...interface{}) ([]int64, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve affected users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan affected user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func queryByIDs(tx *sql.Tx, query string, ids []int64, scan func(*sql.Rows) error) error {
+	idList, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	rows, err := tx.Query(query, string(idList))
+	if err != nil {
+		return fmt.Errorf("failed to look up names: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Errorf("failed to scan name: %w", err)
+		}
+	}
+	return rows.Err()
+}
+
+// unitModulePermissions reduces unit-aware module permissions to what they grant
+func unitModulePermissions(permissions *UnitUserPermissions) map[int64]ModulePermission {
+	modules := make(map[int64]ModulePermission, len(permissions.Modules))
+	for moduleID, module := range permissions.Modules {
+		modules[moduleID] = ModulePermission{
+			ModuleID:   moduleID,
+			CanRead:    module.CanRead,
+			CanWrite:   module.CanWrite,
+			CanDelete:  module.CanDelete,
+			CanApprove: module.CanApprove,
+			Actions:    module.Actions,
+			Conditions: module.Conditions,
+		}
+	}
+	return modules
+}
+
+// permissionGrant is what a module permission grants, nil when it grants nothing
+func permissionGrant(permission ModulePermission, exists bool) *PermissionGrant {
+	if !exists {
+		return nil
+	}
+	actions := GrantedActions(permission.CanRead, permission.CanWrite, permission.CanDelete, permission.CanApprove, permission.Actions)
+	if len(actions) == 0 {
+		return nil
+	}
+	return &PermissionGrant{Actions: actions, Conditions: permission.Conditions}
+}
+
+// DiffModulePermissions compares module permissions and returns the modules whose
+// granted actions or conditions differ, ordered by module ID
+func DiffModulePermissions(before, after map[int64]ModulePermission) []ModulePermissionChange {
+	moduleSet := map[int64]bool{}
+	for moduleID := range before {
+		moduleSet[moduleID] = true
+	}
+	for moduleID := range after {
+		moduleSet[moduleID] = true
+	}
+
+	changes := []ModulePermissionChange{}
+	for _, moduleID := range setIDs(moduleSet) {
+		beforePermission, beforeExists := before[moduleID]
+		afterPermission, afterExists := after[moduleID]
+		change := ModulePermissionChange{
+			ModuleID:          moduleID,
+			Before:            permissionGrant(beforePermission, beforeExists),
+			After:             permissionGrant(afterPermission, afterExists),
+			Added:             []string{},
+			Removed:           []string{},
+			ConditionsChanged: []string{},
+		}
+
+		var beforeActions, afterActions Actions
+		var beforeConditions, afterConditions map[string][]string
+		if change.Before != nil {
+			beforeActions, beforeConditions = change.Before.Actions, change.Before.Conditions
+		}
+		if change.After != nil {
+			afterActions, afterConditions = change.After.Actions, change.After.Conditions
+		}
+		for _, action := range afterActions {
+			if !beforeActions.Has(action) {
+				change.Added = append(change.Added, action)
+			} else if !sameConditions(beforeConditions[action], afterConditions[action]) {
+				change.ConditionsChanged = append(change.ConditionsChanged, action)
+			}
+		}
+		for _, action := range beforeActions {
+			if !afterActions.Has(action) {
+				change.Removed = append(change.Removed, action)
+			}
+		}
+
+		if len(change.Added) > 0 || len(change.Removed) > 0 || len(change.ConditionsChanged) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// sameConditions compares the conditions of an action regardless of their order
+func sameConditions(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, condition := range a {
+		if !containsString(b, condition) {
+			return false
+		}
+	}
+	return true
+}
+
+// SummarizeImpacts counts, per module and action, the users gaining and losing it under
+// either resolution, ordered by module ID and action
+func SummarizeImpacts(users []UserPermissionImpact) []ActionImpact {
+	type actionKey struct {
+		moduleID int64
+		action   string
+	}
+	impacts := map[actionKey]*ActionImpact{}
+	impact := func(change ModulePermissionChange, action string) *ActionImpact {
+		key := actionKey{change.ModuleID, action}
+		if impacts[key] == nil {
+			impacts[key] = &ActionImpact{ModuleID: change.ModuleID, ModuleName: change.ModuleName, Action: action}
+		}
+		return impacts[key]
+	}
+
+	for _, user := range users {
+		gained := map[actionKey]bool{}
+		lost := map[actionKey]bool{}
+		for _, change := range append(append([]ModulePermissionChange{}, user.Modules...), user.UnitModules...) {
+			for _, action := range change.Added {
+				if key := (actionKey{change.ModuleID, action}); !gained[key] {
+					gained[key] = true
+					impact(change, action).UsersGained++
+				}
+			}
+			for _, action := range change.Removed {
+				if key := (actionKey{change.ModuleID, action}); !lost[key] {
+					lost[key] = true
+					impact(change, action).UsersLost++
+				}
+			}
+		}
+	}
+
+	summary := make([]ActionImpact, 0, len(impacts))
+	for _, impact := range impacts {
+		summary = append(summary, *impact)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].ModuleID != summary[j].ModuleID {
+			return summary[i].ModuleID < summary[j].ModuleID
+		}
+		return summary[i].Action < summary[j].Action
+	})
+	return summary
+}
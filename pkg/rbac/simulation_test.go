@@ -0,0 +1,127 @@
+package rbac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffModulePermissions(t *testing.T) {
+	before := map[int64]ModulePermission{
+		10: {ModuleID: 10, CanRead: true, CanApprove: true},
+		20: {ModuleID: 20, CanRead: true, Actions: []string{"export"}},
+		30: {ModuleID: 30, CanRead: true, CanWrite: true, Conditions: map[string][]string{"write": {"a", "b"}}},
+		40: {ModuleID: 40, CanRead: true},
+	}
+	after := map[int64]ModulePermission{
+		10: {ModuleID: 10, CanRead: true},
+		20: {ModuleID: 20, CanRead: true, Actions: []string{"export"}},
+		30: {ModuleID: 30, CanRead: true, CanWrite: true, Conditions: map[string][]string{"write": {"b"}}},
+		50: {ModuleID: 50, CanWrite: true},
+	}
+
+	changes := DiffModulePermissions(before, after)
+
+	var modules []int64
+	for _, change := range changes {
+		modules = append(modules, change.ModuleID)
+	}
+	if !reflect.DeepEqual(modules, []int64{10, 30, 40, 50}) {
+		t.Fatalf("changed modules = %v, want [10 30 40 50]", modules)
+	}
+
+	if got := changes[0].Removed; !reflect.DeepEqual(got, []string{"approve"}) {
+		t.Errorf("module 10 removed = %v, want [approve]", got)
+	}
+	if got := changes[1].ConditionsChanged; !reflect.DeepEqual(got, []string{"write"}) {
+		t.Errorf("module 30 conditions changed = %v, want [write]", got)
+	}
+	if changes[2].After != nil || !reflect.DeepEqual(changes[2].Removed, []string{"read"}) {
+		t.Errorf("module 40 = %+v, want all access removed", changes[2])
+	}
+	if changes[3].Before != nil || !reflect.DeepEqual(changes[3].Added, []string{"write"}) {
+		t.Errorf("module 50 = %+v, want write added", changes[3])
+	}
+}
+
+func TestSummarizeImpacts(t *testing.T) {
+	lostApproval := ModulePermissionChange{ModuleID: 10, ModuleName: "Payments", Removed: []string{"approve"}}
+	users := []UserPermissionImpact{
+		// Lost under both resolutions, counted once
+		{UserID: 1, Modules: []ModulePermissionChange{lostApproval}, UnitModules: []ModulePermissionChange{lostApproval}},
+		{UserID: 2, Modules: []ModulePermissionChange{lostApproval, {ModuleID: 5, Added: []string{"read"}}}},
+	}
+
+	want := []ActionImpact{
+		{ModuleID: 5, Action: "read", UsersGained: 1},
+		{ModuleID: 10, ModuleName: "Payments", Action: "approve", UsersLost: 2},
+	}
+	if got := SummarizeImpacts(users); !reflect.DeepEqual(got, want) {
+		t.Errorf("SummarizeImpacts = %+v, want %+v", got, want)
+	}
+}
+
+func TestGrantOverlay(t *testing.T) {
+	payments, journals, retired := int64(10), int64(20), int64(30)
+	modules := map[int64]simulatedModule{
+		payments: {active: true, subscribed: map[int64]bool{1: true}},
+		journals: {active: true, basic: true, subscribed: map[int64]bool{}},
+		retired:  {basic: true},
+	}
+
+	role := newGrantOverlay([]SimulatedGrant{
+		{ModuleID: payments, Actions: Actions{"read"}},
+		{ModuleID: journals, Actions: Actions{"read"}},
+		{ModuleID: payments, Actions: Actions{"read", "approve"}},
+		{ModuleID: retired, Actions: Actions{"read"}},
+	})
+	role.roleID, role.modules, role.holders = 3, modules, map[int64]bool{3: true, 4: true}
+
+	if !role.coversRoleGrant(3, payments) || role.coversRoleGrant(3, 40) || role.coversRoleGrant(5, payments) {
+		t.Errorf("per module overlay covers the wrong grants")
+	}
+	if role.coversUnitRoleGrant(3, payments) {
+		t.Errorf("role overlay covers a unit role grant")
+	}
+	role.replace = true
+	if !role.coversRoleGrant(3, 40) {
+		t.Errorf("replacing overlay keeps a stored grant of the role")
+	}
+
+	// The subscription of company 1 includes payments, journals only take effect as a basic module
+	got := role.roleGrants(func(module simulatedModule) bool { return module.active && module.subscribed[1] })
+	if len(got) != 1 || got[0].ModuleID != payments || !reflect.DeepEqual(got[0].Actions, Actions{"read", "approve"}) {
+		t.Errorf("subscribed grants = %+v, want the last grant of payments", got)
+	}
+	got = role.roleGrants(func(module simulatedModule) bool { return module.active && module.basic })
+	if len(got) != 1 || got[0].ModuleID != journals {
+		t.Errorf("basic grants = %+v, want journals", got)
+	}
+
+	if held := role.heldRoles([]UnitRoleInfo{{RoleID: 4}, {RoleID: 8}, {RoleID: 4}}); !reflect.DeepEqual(held, []int64{4}) {
+		t.Errorf("held roles = %v, want [4]", held)
+	}
+
+	unitRole := newGrantOverlay([]SimulatedGrant{{ModuleID: journals, Actions: Actions{"approve"}}})
+	unitRole.roleID, unitRole.unitRoleID, unitRole.unitID, unitRole.modules = 3, 7, 50, modules
+	if unitRole.coversRoleGrant(3, journals) || !unitRole.coversUnitRoleGrant(7, journals) || unitRole.coversUnitRoleGrant(7, payments) {
+		t.Errorf("unit role overlay covers the wrong grants")
+	}
+	if got := unitRole.unitRoleGrants([]int64{50}); len(got) != 0 {
+		t.Errorf("expired unit role grants = %+v, want none", got)
+	}
+	unitRole.unitActive = true
+	if got := unitRole.unitRoleGrants([]int64{60}); len(got) != 0 {
+		t.Errorf("grants outside the effective units = %+v, want none", got)
+	}
+	if got := unitRole.unitRoleGrants([]int64{60, 50}); len(got) != 1 || got[0].ModuleID != journals {
+		t.Errorf("unit role grants = %+v, want journals", got)
+	}
+	if unitRole.roleGrants(func(simulatedModule) bool { return true }) != nil || unitRole.heldRoles([]UnitRoleInfo{{RoleID: 3}}) != nil {
+		t.Errorf("unit role overlay proposes role grants")
+	}
+
+	var none *grantOverlay
+	if none.coversRoleGrant(3, payments) || none.roleGrants(nil) != nil || none.unitRoleGrants([]int64{50}) != nil {
+		t.Errorf("nil overlay changes grants")
+	}
+}
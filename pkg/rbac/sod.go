@@ -0,0 +1,239 @@
+package rbac
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Separation-of-duties rule kinds
+const (
+	// SoDRolePair forbids holding both roles of the pair
+	SoDRolePair = "role_pair"
+	// SoDActionPair forbids holding both actions of the pair on the same module
+	SoDActionPair = "action_pair"
+)
+
+// SoDRule is a static separation-of-duties constraint. A role pair rule names two roles
+// no user may hold together in a company, directly, by inheritance or through a unit;
+// an action pair rule names two actions no user may hold together on one module. An
+// action pair rule without a module applies to every module, and a rule without a
+// company applies to every company.
+type SoDRule struct {
+	ID        int64
+	Name      string
+	Kind      string
+	RoleAID   *int64
+	RoleBID   *int64
+	ModuleID  *int64
+	ActionA   string
+	ActionB   string
+	CompanyID *int64
+}
+
+// AppliesTo reports whether the rule constrains users of the company
+func (r SoDRule) AppliesTo(companyID int64) bool {
+	return r.CompanyID == nil || *r.CompanyID == companyID
+}
+
+// SoDGrant is the actions a role (UnitRoleID 0) or a unit role (RoleID 0) grants on a module
+type SoDGrant struct {
+	RoleID     int64
+	UnitRoleID int64
+	ModuleID   int64
+	Actions    Actions
+}
+
+// SoDProfile is the access a user holds in a company: every role held, including roles
+// inherited and roles of the user's units, and the grants of those roles
+type SoDProfile struct {
+	UserID    int64
+	CompanyID int64
+	Roles     map[int64]bool
+	Grants    []SoDGrant
+
+	// Roles whose role_modules apply to the user, and unit roles whose unit_role_modules do
+	grantRoles map[int64]bool
+	unitRoles  map[int64]bool
+}
+
+// NewSoDProfile returns an empty profile of a user in a company
+func NewSoDProfile(userID, companyID int64) *SoDProfile {
+	return &SoDProfile{
+		UserID:     userID,
+		CompanyID:  companyID,
+		Roles:      map[int64]bool{},
+		grantRoles: map[int64]bool{},
+		unitRoles:  map[int64]bool{},
+	}
+}
+
+// Clone returns a copy of the profile that can be changed independently
+func (p *SoDProfile) Clone() *SoDProfile {
+	clone := NewSoDProfile(p.UserID, p.CompanyID)
+	for id := range p.Roles {
+		clone.Roles[id] = true
+	}
+	for id := range p.grantRoles {
+		clone.grantRoles[id] = true
+	}
+	for id := range p.unitRoles {
+		clone.unitRoles[id] = true
+	}
+	clone.Grants = append([]SoDGrant{}, p.Grants...)
+	return clone
+}
+
+// AddRoles marks roles as held
+func (p *SoDProfile) AddRoles(roleIDs ...int64) {
+	for _, roleID := range roleIDs {
+		p.Roles[roleID] = true
+	}
+}
+
+// SetGrants adds grants, replacing any grant of the same role or unit role on the same module
+func (p *SoDProfile) SetGrants(grants ...SoDGrant) {
+	for _, grant := range grants {
+		replaced := false
+		for i, existing := range p.Grants {
+			if existing.RoleID == grant.RoleID && existing.UnitRoleID == grant.UnitRoleID && existing.ModuleID == grant.ModuleID {
+				p.Grants[i] = grant
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			p.Grants = append(p.Grants, grant)
+		}
+	}
+}
+
+// RemoveGrants drops every grant of a role (unitRoleID 0) or of a unit role (roleID 0)
+func (p *SoDProfile) RemoveGrants(roleID, unitRoleID int64) {
+	kept := p.Grants[:0]
+	for _, grant := range p.Grants {
+		if grant.RoleID != roleID || grant.UnitRoleID != unitRoleID {
+			kept = append(kept, grant)
+		}
+	}
+	p.Grants = kept
+}
+
+// SoDAssignment is the access a role or unit role brings to its holder: the role with
+// every role it inherits from, and the grants that apply
+type SoDAssignment struct {
+	// UnitRoleID is 0 for a role held directly, whose roles grant through role_modules
+	UnitRoleID int64
+	Roles      []int64
+	Grants     []SoDGrant
+}
+
+// Assign adds the access of an assignment
+func (p *SoDProfile) Assign(assignment SoDAssignment) {
+	p.AddRoles(assignment.Roles...)
+	if assignment.UnitRoleID == 0 {
+		for _, roleID := range assignment.Roles {
+			p.grantRoles[roleID] = true
+		}
+	} else {
+		p.unitRoles[assignment.UnitRoleID] = true
+	}
+	p.SetGrants(assignment.Grants...)
+}
+
+// HasRoleGrants reports whether the role_modules of the role apply to the user
+func (p *SoDProfile) HasRoleGrants(roleID int64) bool {
+	return p.grantRoles[roleID]
+}
+
+// HasUnitRole reports whether the unit_role_modules of the unit role apply to the user
+func (p *SoDProfile) HasUnitRole(unitRoleID int64) bool {
+	return p.unitRoles[unitRoleID]
+}
+
+// SoDViolation is a rule broken by the access of a user in a company. ModuleID is set
+// for action pair rules.
+type SoDViolation struct {
+	RuleID    int64  `json:"rule_id"`
+	RuleName  string `json:"rule_name"`
+	Kind      string `json:"kind"`
+	UserID    int64  `json:"user_id"`
+	CompanyID int64  `json:"company_id"`
+	ModuleID  *int64 `json:"module_id,omitempty"`
+	Detail    string `json:"detail"`
+}
+
+func (v SoDViolation) key() string {
+	if v.ModuleID == nil {
+		return fmt.Sprintf("%d/%d/%d", v.RuleID, v.UserID, v.CompanyID)
+	}
+	return fmt.Sprintf("%d/%d/%d/%d", v.RuleID, v.UserID, v.CompanyID, *v.ModuleID)
+}
+
+// Violations evaluates the rules that apply to the company of the profile
+func (p *SoDProfile) Violations(rules []SoDRule) []SoDViolation {
+	// Union of the actions held on each module, whichever role grants them
+	moduleActions := map[int64]Actions{}
+	for _, grant := range p.Grants {
+		for _, action := range grant.Actions {
+			if !moduleActions[grant.ModuleID].Has(action) {
+				moduleActions[grant.ModuleID] = append(moduleActions[grant.ModuleID], action)
+			}
+		}
+	}
+	moduleIDs := make([]int64, 0, len(moduleActions))
+	for moduleID := range moduleActions {
+		moduleIDs = append(moduleIDs, moduleID)
+	}
+	sort.Slice(moduleIDs, func(i, j int) bool { return moduleIDs[i] < moduleIDs[j] })
+
+	var violations []SoDViolation
+	for _, rule := range rules {
+		if !rule.AppliesTo(p.CompanyID) {
+			continue
+		}
+		violation := SoDViolation{
+			RuleID:    rule.ID,
+			RuleName:  rule.Name,
+			Kind:      rule.Kind,
+			UserID:    p.UserID,
+			CompanyID: p.CompanyID,
+		}
+		switch rule.Kind {
+		case SoDRolePair:
+			if rule.RoleAID != nil && rule.RoleBID != nil && p.Roles[*rule.RoleAID] && p.Roles[*rule.RoleBID] {
+				violation.Detail = fmt.Sprintf("holds roles %d and %d", *rule.RoleAID, *rule.RoleBID)
+				violations = append(violations, violation)
+			}
+		case SoDActionPair:
+			for i, moduleID := range moduleIDs {
+				if rule.ModuleID != nil && *rule.ModuleID != moduleID {
+					continue
+				}
+				actions := moduleActions[moduleID]
+				if actions.Has(rule.ActionA) && actions.Has(rule.ActionB) {
+					moduleViolation := violation
+					moduleViolation.ModuleID = &moduleIDs[i]
+					moduleViolation.Detail = fmt.Sprintf("holds %s and %s on module %d", rule.ActionA, rule.ActionB, moduleID)
+					violations = append(violations, moduleViolation)
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// NewSoDViolations returns the violations of after that were not already in before, so a
+// change is only blamed for the conflicts it introduces
+func NewSoDViolations(before, after []SoDViolation) []SoDViolation {
+	existing := make(map[string]bool, len(before))
+	for _, violation := range before {
+		existing[violation.key()] = true
+	}
+	var introduced []SoDViolation
+	for _, violation := range after {
+		if !existing[violation.key()] {
+			introduced = append(introduced, violation)
+		}
+	}
+	return introduced
+}
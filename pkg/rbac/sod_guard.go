@@ -0,0 +1,641 @@
+package rbac
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gin-scalable-api/pkg/audittrail"
+	"gin-scalable-api/pkg/logger"
+)
+
+// SoDChange identifies a change checked against the separation-of-duties rules
+type SoDChange struct {
+	ActorID int64
+	// Audit action of the change, e.g. user_role_assigned
+	Action string
+	// Justification allows the change in override mode despite the violations it introduces
+	Justification string
+}
+
+// SoDOverride holds the violations a justified change introduced in override mode. The
+// caller records it with SoDGuard.Record once the change is made.
+type SoDOverride struct {
+	Change     SoDChange
+	Violations []SoDViolation
+}
+
+// SoDGuard checks role assignments, unit role assignments and permission updates against
+// the active separation-of-duties rules. Only the violations a change introduces count, so
+// existing violations do not block unrelated changes. A nil guard allows every change.
+type SoDGuard struct {
+	db            *sql.DB
+	allowOverride bool
+	recorder      audittrail.Recorder
+}
+
+// NewSoDGuard creates a guard; with allowOverride, violations are allowed when the change
+// carries a justification instead of being rejected
+func NewSoDGuard(db *sql.DB, allowOverride bool, recorder audittrail.Recorder) *SoDGuard {
+	return &SoDGuard{
+		db:            db,
+		allowOverride: allowOverride,
+		recorder:      recorder,
+	}
+}
+
+// CheckRoleAssignment checks assigning a role to a user in a company. An assignment placed in
+// a unit also brings the unit roles of the unit and its parent units.
+func (g *SoDGuard) CheckRoleAssignment(change SoDChange, userID, companyID, roleID int64, unitID *int64) (*SoDOverride, error) {
+	if g == nil {
+		return nil, nil
+	}
+	closure, err := g.roleClosure(roleID)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := g.roleGrants(closure)
+	if err != nil {
+		return nil, err
+	}
+	assignments := []SoDAssignment{{Roles: closure, Grants: grants}}
+	if unitID != nil {
+		unitAssignments, err := g.unitAssignments(*unitID)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, unitAssignments...)
+	}
+
+	profiles, err := g.profiles([]int64{userID})
+	if err != nil {
+		return nil, err
+	}
+	profile := findProfile(profiles, userID, companyID)
+	if profile == nil {
+		profile = NewSoDProfile(userID, companyID)
+	}
+
+	return g.enforce(change, []*SoDProfile{profile}, func(p *SoDProfile) bool {
+		for _, assignment := range assignments {
+			p.Assign(assignment)
+		}
+		return true
+	})
+}
+
+// CheckUnitRoleAssignment checks assigning a role to a unit, which the users of the unit
+// and its descendants receive
+func (g *SoDGuard) CheckUnitRoleAssignment(change SoDChange, unitID, roleID int64) (*SoDOverride, error) {
+	if g == nil {
+		return nil, nil
+	}
+	var companyID int64
+	err := g.db.QueryRow(`
+		SELECT b.company_id FROM units u JOIN branches b ON u.branch_id = b.id WHERE u.id = $1
+	`, unitID).Scan(&companyID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("unit dengan ID %d tidak ditemukan", unitID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit company: %w", err)
+	}
+	closure, err := g.roleClosure(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new unit role grants nothing until its permissions are set, so only role pairs matter
+	profiles, err := g.unitProfiles(unitID)
+	if err != nil {
+		return nil, err
+	}
+	return g.enforce(change, profiles, func(p *SoDProfile) bool {
+		if p.CompanyID != companyID {
+			return false
+		}
+		p.AddRoles(closure...)
+		return true
+	})
+}
+
+// CheckRoleGrants checks changing the module grants of a role for every user receiving
+// them. With replace the grants become all the grants of the role, otherwise they are
+// set per module.
+func (g *SoDGuard) CheckRoleGrants(change SoDChange, roleID int64, grants []SoDGrant, replace bool) (*SoDOverride, error) {
+	if g == nil {
+		return nil, nil
+	}
+	userIDs, err := g.queryIDs(`
+		SELECT DISTINCT ur.user_id
+		FROM user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		WHERE ra.ancestor_id = $1
+	`, roleID)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := g.profiles(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	return g.enforce(change, profiles, func(p *SoDProfile) bool {
+		if !p.HasRoleGrants(roleID) {
+			return false
+		}
+		if replace {
+			p.RemoveGrants(roleID, 0)
+		}
+		p.SetGrants(grants...)
+		return true
+	})
+}
+
+// CheckRoleParents checks making a role inherit from parent roles, for every user holding
+// the role or a role inheriting from it. Holders gain the parents, their ancestors and
+// their grants; holders through a unit role gain only the roles, as unit roles grant
+// through their own modules.
+func (g *SoDGuard) CheckRoleParents(change SoDChange, roleID int64, parentIDs []int64) (*SoDOverride, error) {
+	if g == nil || len(parentIDs) == 0 {
+		return nil, nil
+	}
+	inherited := map[int64]bool{}
+	for _, parentID := range parentIDs {
+		closure, err := g.roleClosure(parentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range closure {
+			inherited[id] = true
+		}
+	}
+	ancestors := setIDs(inherited)
+	grants, err := g.roleGrants(ancestors)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs, err := g.queryIDs(`
+		WITH RECURSIVE unit_tree AS (
+			SELECT unr.unit_id AS id, 0 AS level
+			FROM unit_roles unr
+			JOIN role_ancestors ra ON ra.role_id = unr.role_id
+			WHERE ra.ancestor_id = $1
+
+			UNION
+
+			SELECT u.id, ut.level + 1
+			FROM units u
+			JOIN unit_tree ut ON u.parent_id = ut.id
+			WHERE ut.level < 10
+		)
+		SELECT ur.user_id
+		FROM user_roles ur
+		JOIN role_ancestors ra ON ra.role_id = ur.role_id
+		WHERE ra.ancestor_id = $1
+
+		UNION
+
+		SELECT ur.user_id
+		FROM user_roles ur
+		JOIN unit_tree ut ON ur.unit_id = ut.id
+	`, roleID)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := g.profiles(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	return g.enforce(change, profiles, func(p *SoDProfile) bool {
+		if !p.Roles[roleID] {
+			return false
+		}
+		p.AddRoles(ancestors...)
+		if p.HasRoleGrants(roleID) {
+			for _, id := range ancestors {
+				p.grantRoles[id] = true
+			}
+			p.SetGrants(grants...)
+		}
+		return true
+	})
+}
+
+// CheckUnitRoleGrants checks setting module grants of a unit role, per module
+func (g *SoDGuard) CheckUnitRoleGrants(change SoDChange, unitRoleID int64, grants []SoDGrant) (*SoDOverride, error) {
+	if g == nil {
+		return nil, nil
+	}
+	var unitID int64
+	err := g.db.QueryRow(`SELECT unit_id FROM unit_roles WHERE id = $1`, unitRoleID).Scan(&unitID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("unit role dengan ID %d tidak ditemukan", unitRoleID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit role: %w", err)
+	}
+	profiles, err := g.unitProfiles(unitID)
+	if err != nil {
+		return nil, err
+	}
+	return g.enforce(change, profiles, func(p *SoDProfile) bool {
+		if !p.HasUnitRole(unitRoleID) {
+			return false
+		}
+		p.SetGrants(grants...)
+		return true
+	})
+}
+
+// Violations evaluates the active rules against the access every user currently holds,
+// across all companies
+func (g *SoDGuard) Violations() ([]SoDViolation, error) {
+	if g == nil {
+		return nil, nil
+	}
+	rules, err := g.rules()
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	profiles, err := g.profiles(nil)
+	if err != nil {
+		return nil, err
+	}
+	violations := []SoDViolation{}
+	for _, profile := range profiles {
+		violations = append(violations, profile.Violations(rules)...)
+	}
+	return violations, nil
+}
+
+// Record stores the overridden violations and audits them. The change has already been
+// made, so failures are logged rather than returned.
+func (g *SoDGuard) Record(override *SoDOverride) {
+	if g == nil || override == nil {
+		return
+	}
+	for _, violation := range override.Violations {
+		var id int64
+		err := g.db.QueryRow(`
+			INSERT INTO sod_overrides (rule_id, user_id, company_id, module_id, change, justification, granted_by)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0))
+			RETURNING id
+		`, violation.RuleID, violation.UserID, violation.CompanyID, violation.ModuleID, override.Change.Action,
+			override.Change.Justification, override.Change.ActorID).Scan(&id)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to record SoD override of rule %d for user %d: %v", violation.RuleID, violation.UserID, err))
+			continue
+		}
+		audittrail.RecordChange(g.recorder, override.Change.ActorID, "sod_overridden", "sod_override", id,
+			map[string]interface{}{}, map[string]interface{}{
+				"rule_id":       violation.RuleID,
+				"user_id":       violation.UserID,
+				"company_id":    violation.CompanyID,
+				"module_id":     violation.ModuleID,
+				"change":        override.Change.Action,
+				"justification": override.Change.Justification,
+			})
+	}
+}
+
+// enforce applies a change to copies of the profiles and rejects or overrides the
+// violations it introduces. apply reports whether the change affects a profile.
+func (g *SoDGuard) enforce(change SoDChange, profiles []*SoDProfile, apply func(*SoDProfile) bool) (*SoDOverride, error) {
+	if len(profiles) == 0 {
+		return nil, nil
+	}
+	rules, err := g.rules()
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	var introduced []SoDViolation
+	for _, profile := range profiles {
+		after := profile.Clone()
+		if !apply(after) {
+			continue
+		}
+		introduced = append(introduced, NewSoDViolations(profile.Violations(rules), after.Violations(rules))...)
+	}
+	if len(introduced) == 0 {
+		return nil, nil
+	}
+
+	// Rule names are user input, so the messages name rules by ID to keep the status keywords intact
+	first := introduced[0]
+	more := ""
+	if len(introduced) > 1 {
+		more = fmt.Sprintf(" dan %d pelanggaran lainnya", len(introduced)-1)
+	}
+	if !g.allowOverride {
+		return nil, fmt.Errorf("perubahan melanggar aturan separation of duties #%d untuk pengguna %d%s (cannot grant conflicting access)",
+			first.RuleID, first.UserID, more)
+	}
+	if strings.TrimSpace(change.Justification) == "" {
+		return nil, fmt.Errorf("perubahan melanggar aturan separation of duties #%d untuk pengguna %d%s; justification wajib diisi untuk mengesampingkannya (required)",
+			first.RuleID, first.UserID, more)
+	}
+	return &SoDOverride{Change: change, Violations: introduced}, nil
+}
+
+// rules loads the active rules
+func (g *SoDGuard) rules() ([]SoDRule, error) {
+	rows, err := g.db.Query(`
+		SELECT id, name, kind, role_a_id, role_b_id, module_id, COALESCE(action_a, ''), COALESCE(action_b, ''), company_id
+		FROM sod_rules
+		WHERE is_active = true
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SoD rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []SoDRule
+	for rows.Next() {
+		var rule SoDRule
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.Kind, &rule.RoleAID, &rule.RoleBID, &rule.ModuleID,
+			&rule.ActionA, &rule.ActionB, &rule.CompanyID); err != nil {
+			return nil, fmt.Errorf("failed to scan SoD rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// profiles loads the profiles of the users in every company they hold roles in; nil
+// loads every user. Scheduled assignments count as held, expired ones do not.
+func (g *SoDGuard) profiles(userIDs []int64) ([]*SoDProfile, error) {
+	var filter interface{}
+	if userIDs != nil {
+		if len(userIDs) == 0 {
+			return nil, nil
+		}
+		data, err := json.Marshal(userIDs)
+		if err != nil {
+			return nil, err
+		}
+		filter = string(data)
+	}
+
+	rows, err := g.db.Query(`
+		WITH RECURSIVE held_user_roles AS (
+			SELECT ur.user_id, ur.company_id, ur.role_id, ur.unit_id
+			FROM user_roles ur
+			WHERE (ur.valid_until IS NULL OR ur.valid_until > CURRENT_TIMESTAMP)
+				AND ($1::jsonb IS NULL OR ur.user_id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint))
+		),
+		user_units AS (
+			SELECT h.user_id, h.company_id, u.id AS unit_id, u.parent_id, 0 AS level
+			FROM held_user_roles h
+			JOIN units u ON u.id = h.unit_id
+
+			UNION
+
+			SELECT uu.user_id, uu.company_id, p.id, p.parent_id, uu.level + 1
+			FROM units p
+			JOIN user_units uu ON p.id = uu.parent_id
+			WHERE uu.level < 10
+		),
+		held AS (
+			SELECT user_id, company_id, role_id, 0::bigint AS unit_role_id
+			FROM held_user_roles
+
+			UNION
+
+			SELECT DISTINCT uu.user_id, uu.company_id, unr.role_id, unr.id
+			FROM user_units uu
+			JOIN unit_roles unr ON unr.unit_id = uu.unit_id
+			WHERE unr.valid_until IS NULL OR unr.valid_until > CURRENT_TIMESTAMP
+		)
+		SELECT h.user_id, h.company_id, h.unit_role_id, ra.ancestor_id
+		FROM held h
+		JOIN role_ancestors ra ON ra.role_id = h.role_id
+		ORDER BY h.user_id, h.company_id
+	`, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get held roles: %w", err)
+	}
+	defer rows.Close()
+
+	type profileKey struct{ userID, companyID int64 }
+	byKey := map[profileKey]*SoDProfile{}
+	var profiles []*SoDProfile
+	roleIDs := map[int64]bool{}
+	unitRoleIDs := map[int64]bool{}
+	for rows.Next() {
+		var userID, companyID, unitRoleID, roleID int64
+		if err := rows.Scan(&userID, &companyID, &unitRoleID, &roleID); err != nil {
+			return nil, fmt.Errorf("failed to scan held role: %w", err)
+		}
+		key := profileKey{userID, companyID}
+		profile := byKey[key]
+		if profile == nil {
+			profile = NewSoDProfile(userID, companyID)
+			byKey[key] = profile
+			profiles = append(profiles, profile)
+		}
+		profile.AddRoles(roleID)
+		// Unit roles grant through their own modules, not the modules of their role
+		if unitRoleID == 0 {
+			profile.grantRoles[roleID] = true
+			roleIDs[roleID] = true
+		} else {
+			profile.unitRoles[unitRoleID] = true
+			unitRoleIDs[unitRoleID] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	roleGrants, err := g.roleGrants(setIDs(roleIDs))
+	if err != nil {
+		return nil, err
+	}
+	unitRoleGrants, err := g.unitRoleGrants(setIDs(unitRoleIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		for _, grant := range roleGrants {
+			if profile.grantRoles[grant.RoleID] {
+				profile.SetGrants(grant)
+			}
+		}
+		for _, grant := range unitRoleGrants {
+			if profile.unitRoles[grant.UnitRoleID] {
+				profile.SetGrants(grant)
+			}
+		}
+	}
+	return profiles, nil
+}
+
+// unitProfiles loads the profiles of the users assigned to the unit or its descendants
+func (g *SoDGuard) unitProfiles(unitID int64) ([]*SoDProfile, error) {
+	userIDs, err := g.queryIDs(`
+		WITH RECURSIVE unit_tree AS (
+			SELECT id, 0 as level FROM units WHERE id = $1
+			UNION ALL
+			SELECT u.id, ut.level + 1
+			FROM units u
+			JOIN unit_tree ut ON u.parent_id = ut.id
+			WHERE ut.level < 10
+		)
+		SELECT DISTINCT ur.user_id
+		FROM user_roles ur
+		JOIN unit_tree ut ON ur.unit_id = ut.id
+	`, unitID)
+	if err != nil {
+		return nil, err
+	}
+	return g.profiles(userIDs)
+}
+
+// unitAssignments loads the unit roles that users of the unit receive, from the unit and
+// its parent units. Scheduled unit roles count as held, expired ones do not.
+func (g *SoDGuard) unitAssignments(unitID int64) ([]SoDAssignment, error) {
+	rows, err := g.db.Query(`
+		WITH RECURSIVE unit_chain AS (
+			SELECT id, parent_id, 0 AS level FROM units WHERE id = $1
+
+			UNION
+
+			SELECT p.id, p.parent_id, uc.level + 1
+			FROM units p
+			JOIN unit_chain uc ON p.id = uc.parent_id
+			WHERE uc.level < 10
+		)
+		SELECT unr.id, ra.ancestor_id
+		FROM unit_roles unr
+		JOIN unit_chain uc ON unr.unit_id = uc.id
+		JOIN role_ancestors ra ON ra.role_id = unr.role_id
+		WHERE unr.valid_until IS NULL OR unr.valid_until > CURRENT_TIMESTAMP
+		ORDER BY unr.id, ra.depth
+	`, unitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit roles: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []SoDAssignment
+	byUnitRole := map[int64]int{}
+	for rows.Next() {
+		var unitRoleID, roleID int64
+		if err := rows.Scan(&unitRoleID, &roleID); err != nil {
+			return nil, fmt.Errorf("failed to scan unit role: %w", err)
+		}
+		i, ok := byUnitRole[unitRoleID]
+		if !ok {
+			i = len(assignments)
+			byUnitRole[unitRoleID] = i
+			assignments = append(assignments, SoDAssignment{UnitRoleID: unitRoleID})
+		}
+		assignments[i].Roles = append(assignments[i].Roles, roleID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	unitRoleIDs := make([]int64, 0, len(assignments))
+	for _, assignment := range assignments {
+		unitRoleIDs = append(unitRoleIDs, assignment.UnitRoleID)
+	}
+	grants, err := g.unitRoleGrants(unitRoleIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		i := byUnitRole[grant.UnitRoleID]
+		assignments[i].Grants = append(assignments[i].Grants, grant)
+	}
+	return assignments, nil
+}
+
+// roleClosure returns the role and every role it inherits from
+func (g *SoDGuard) roleClosure(roleID int64) ([]int64, error) {
+	return g.queryIDs(`SELECT ancestor_id FROM role_ancestors WHERE role_id = $1 ORDER BY depth`, roleID)
+}
+
+// roleGrants loads the module grants of the roles
+func (g *SoDGuard) roleGrants(roleIDs []int64) ([]SoDGrant, error) {
+	return g.queryGrants(`
+		SELECT role_id, 0, module_id, actions
+		FROM role_modules
+		WHERE role_id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)
+	`, roleIDs)
+}
+
+// unitRoleGrants loads the module grants of the unit roles
+func (g *SoDGuard) unitRoleGrants(unitRoleIDs []int64) ([]SoDGrant, error) {
+	return g.queryGrants(`
+		SELECT 0, unit_role_id, module_id, actions
+		FROM unit_role_modules
+		WHERE unit_role_id IN (SELECT jsonb_array_elements_text($1::jsonb)::bigint)
+	`, unitRoleIDs)
+}
+
+func (g *SoDGuard) queryGrants(query string, ids []int64) ([]SoDGrant, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := g.db.Query(query, string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grants: %w", err)
+	}
+	defer rows.Close()
+
+	var grants []SoDGrant
+	for rows.Next() {
+		var grant SoDGrant
+		if err := rows.Scan(&grant.RoleID, &grant.UnitRoleID, &grant.ModuleID, &grant.Actions); err != nil {
+			return nil, fmt.Errorf("failed to scan grant: %w", err)
+		}
+		grants = append(grants, grant)
+	}
+	return grants, rows.Err()
+}
+
+func (g *SoDGuard) queryIDs(query string, args ...interface{}) ([]int64, error) {
+	rows, err := g.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query IDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func findProfile(profiles []*SoDProfile, userID, companyID int64) *SoDProfile {
+	for _, profile := range profiles {
+		if profile.UserID == userID && profile.CompanyID == companyID {
+			return profile
+		}
+	}
+	return nil
+}
+
+func setIDs(set map[int64]bool) []int64 {
+	ids := make([]int64, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
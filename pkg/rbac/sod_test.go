@@ -0,0 +1,128 @@
+package rbac
+
+import "testing"
+
+func TestSoDViolations(t *testing.T) {
+	creator, approver := int64(1), int64(2)
+	payments, journals := int64(10), int64(20)
+	otherCompany := int64(9)
+
+	rules := []SoDRule{
+		{ID: 1, Name: "create vs approve payment", Kind: SoDRolePair, RoleAID: &creator, RoleBID: &approver},
+		{ID: 2, Name: "write vs approve", Kind: SoDActionPair, ActionA: "write", ActionB: "approve"},
+		{ID: 3, Name: "post vs approve journals", Kind: SoDActionPair, ModuleID: &journals, ActionA: "post-journal", ActionB: "approve"},
+		{ID: 4, Name: "other company", Kind: SoDRolePair, RoleAID: &creator, RoleBID: &approver, CompanyID: &otherCompany},
+	}
+
+	tests := []struct {
+		name   string
+		roles  []int64
+		grants []SoDGrant
+		want   []int64
+	}{
+		{"no access", nil, nil, nil},
+		{"one role of the pair", []int64{creator}, nil, nil},
+		{"both roles", []int64{creator, approver}, nil, []int64{1}},
+		{"write and approve from different roles", nil, []SoDGrant{
+			{RoleID: 1, ModuleID: payments, Actions: Actions{"read", "write"}},
+			{UnitRoleID: 5, ModuleID: payments, Actions: Actions{"approve"}},
+		}, []int64{2}},
+		{"write and approve on different modules", nil, []SoDGrant{
+			{RoleID: 1, ModuleID: payments, Actions: Actions{"write"}},
+			{RoleID: 2, ModuleID: journals, Actions: Actions{"approve"}},
+		}, nil},
+		{"rule scoped to a module", nil, []SoDGrant{
+			{RoleID: 1, ModuleID: payments, Actions: Actions{"post-journal", "approve"}},
+			{RoleID: 1, ModuleID: journals, Actions: Actions{"post-journal", "approve"}},
+		}, []int64{3}},
+	}
+
+	for _, tt := range tests {
+		profile := NewSoDProfile(100, 1)
+		profile.AddRoles(tt.roles...)
+		profile.SetGrants(tt.grants...)
+
+		got := profile.Violations(rules)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d violations %+v, want rules %v", tt.name, len(got), got, tt.want)
+			continue
+		}
+		for i, violation := range got {
+			if violation.RuleID != tt.want[i] {
+				t.Errorf("%s: violation %d is rule %d, want %d", tt.name, i, violation.RuleID, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestNewSoDViolations(t *testing.T) {
+	payments := int64(10)
+	rules := []SoDRule{{ID: 2, Name: "write vs approve", Kind: SoDActionPair, ActionA: "write", ActionB: "approve"}}
+
+	before := NewSoDProfile(100, 1)
+	before.SetGrants(SoDGrant{RoleID: 1, ModuleID: payments, Actions: Actions{"write"}})
+
+	after := before.Clone()
+	after.SetGrants(SoDGrant{RoleID: 2, ModuleID: payments, Actions: Actions{"approve"}})
+	introduced := NewSoDViolations(before.Violations(rules), after.Violations(rules))
+	if len(introduced) != 1 || *introduced[0].ModuleID != payments {
+		t.Fatalf("got %+v, want one violation on module %d", introduced, payments)
+	}
+	if len(before.Grants) != 1 {
+		t.Errorf("changing the clone changed the original: %+v", before.Grants)
+	}
+
+	// An existing violation is not blamed on a further change
+	further := after.Clone()
+	further.SetGrants(SoDGrant{RoleID: 3, ModuleID: 30, Actions: Actions{"read"}})
+	if got := NewSoDViolations(after.Violations(rules), further.Violations(rules)); len(got) != 0 {
+		t.Errorf("got %+v, want no new violations", got)
+	}
+
+	// Replacing the grants of a role removes the violation
+	after.RemoveGrants(2, 0)
+	if got := after.Violations(rules); len(got) != 0 {
+		t.Errorf("got %+v after removing the grants of role 2", got)
+	}
+}
+
+func TestSoDProfileAssign(t *testing.T) {
+	creator, approver, clerk := int64(1), int64(2), int64(3)
+	payments := int64(10)
+	rules := []SoDRule{
+		{ID: 1, Name: "create vs approve payment", Kind: SoDRolePair, RoleAID: &creator, RoleBID: &approver},
+		{ID: 2, Name: "write vs approve", Kind: SoDActionPair, ActionA: "write", ActionB: "approve"},
+	}
+
+	// The user holds the creator role, which writes payments
+	before := NewSoDProfile(100, 1)
+	before.Assign(SoDAssignment{Roles: []int64{creator},
+		Grants: []SoDGrant{{RoleID: creator, ModuleID: payments, Actions: Actions{"write"}}}})
+
+	// A clerk role assigned in a unit whose parent unit grants the approver role
+	clerkRole := SoDAssignment{Roles: []int64{clerk},
+		Grants: []SoDGrant{{RoleID: clerk, ModuleID: payments, Actions: Actions{"read"}}}}
+	parentUnitRole := SoDAssignment{UnitRoleID: 7, Roles: []int64{approver},
+		Grants: []SoDGrant{{UnitRoleID: 7, ModuleID: payments, Actions: Actions{"approve"}}}}
+
+	withoutUnit := before.Clone()
+	withoutUnit.Assign(clerkRole)
+	if got := NewSoDViolations(before.Violations(rules), withoutUnit.Violations(rules)); len(got) != 0 {
+		t.Fatalf("got %+v without the unit, want no violations", got)
+	}
+
+	withUnit := before.Clone()
+	withUnit.Assign(clerkRole)
+	withUnit.Assign(parentUnitRole)
+	got := NewSoDViolations(before.Violations(rules), withUnit.Violations(rules))
+	if len(got) != 2 || got[0].RuleID != 1 || got[1].RuleID != 2 {
+		t.Fatalf("got %+v through the unit, want rules 1 and 2", got)
+	}
+
+	if !withUnit.HasRoleGrants(clerk) || withUnit.HasRoleGrants(approver) {
+		t.Errorf("role grants: clerk %v, approver %v", withUnit.HasRoleGrants(clerk), withUnit.HasRoleGrants(approver))
+	}
+	if !withUnit.HasUnitRole(7) {
+		t.Errorf("unit role 7 is not applied")
+	}
+}
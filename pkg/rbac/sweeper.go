@@ -0,0 +1,293 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gin-scalable-api/pkg/audittrail"
+	"gin-scalable-api/pkg/logger"
+)
+
+// SessionRevoker ends every session of a user
+type SessionRevoker interface {
+	RevokeAllUserTokens(userID int64) error
+}
+
+// ExpiredAssignment is a user role or unit role assignment removed at the end of its window
+type ExpiredAssignment struct {
+	ID         int64
+	Kind       string // "user_role", "unit_role"
+	UserID     int64  // user roles only
+	UnitID     *int64
+	RoleID     int64
+	CompanyID  int64 // user roles only
+	BranchID   *int64
+	ValidFrom  *time.Time
+	ValidUntil time.Time
+}
+
+// ActivatedAssignment is a scheduled user role or unit role assignment whose window started
+type ActivatedAssignment struct {
+	ID        int64
+	Kind      string
+	UserID    int64
+	UnitID    *int64
+	RoleID    int64
+	ValidFrom time.Time
+}
+
+// SweepResult summarizes one sweep
+type SweepResult struct {
+	Expired   []ExpiredAssignment
+	Activated []ActivatedAssignment
+}
+
+// AssignmentSweeper enforces the validity windows of role assignments: it deletes user
+// roles and unit roles past valid_until, revoking the sessions of the affected users,
+// and picks up scheduled assignments once valid_from has passed. Permission queries
+// already ignore assignments outside their window; the sweeper makes caches and
+// sessions follow and leaves an audit entry for every change.
+type AssignmentSweeper struct {
+	db       *sql.DB
+	cache    *PermissionCache
+	sessions SessionRevoker
+	recorder audittrail.Recorder
+	interval time.Duration
+}
+
+func NewAssignmentSweeper(db *sql.DB, cache *PermissionCache, sessions SessionRevoker, recorder audittrail.Recorder, interval time.Duration) *AssignmentSweeper {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &AssignmentSweeper{
+		db:       db,
+		cache:    cache,
+		sessions: sessions,
+		recorder: recorder,
+		interval: interval,
+	}
+}
+
+// Start runs the sweeper every interval until ctx is cancelled
+func (s *AssignmentSweeper) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := s.Run(time.Now())
+		if err != nil {
+			logger.Error(fmt.Sprintf("Role assignment sweep failed: %v", err))
+		} else if len(result.Expired) > 0 || len(result.Activated) > 0 {
+			logger.Info(fmt.Sprintf("Expired %d and activated %d role assignment(s)",
+				len(result.Expired), len(result.Activated)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run expires and activates the assignments whose window ended or started by now.
+// The database changes are committed before caches, sessions and the audit trail are
+// updated; a failure there is logged and does not undo the sweep.
+func (s *AssignmentSweeper) Run(now time.Time) (*SweepResult, error) {
+	result := &SweepResult{}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// One sweeper at a time across instances; the others skip this round
+	var locked bool
+	if err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock(hashtext('assignment_sweeper'))`).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("failed to lock assignment sweeper: %w", err)
+	}
+	if !locked {
+		return result, nil
+	}
+
+	if result.Expired, err = expireAssignments(tx, now); err != nil {
+		return nil, err
+	}
+	if result.Activated, err = activateAssignments(tx, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.applyExpired(result.Expired)
+	s.applyActivated(result.Activated)
+	return result, nil
+}
+
+func expireAssignments(tx *sql.Tx, now time.Time) ([]ExpiredAssignment, error) {
+	var expired []ExpiredAssignment
+
+	rows, err := tx.Query(`
+		DELETE FROM user_roles
+		WHERE valid_until <= $1
+		RETURNING id, user_id, role_id, company_id, branch_id, unit_id, valid_from, valid_until
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire user roles: %w", err)
+	}
+	for rows.Next() {
+		assignment := ExpiredAssignment{Kind: "user_role"}
+		if err := rows.Scan(&assignment.ID, &assignment.UserID, &assignment.RoleID, &assignment.CompanyID,
+			&assignment.BranchID, &assignment.UnitID, &assignment.ValidFrom, &assignment.ValidUntil); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan expired user role: %w", err)
+		}
+		expired = append(expired, assignment)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read expired user roles: %w", err)
+	}
+
+	rows, err = tx.Query(`
+		DELETE FROM unit_roles
+		WHERE valid_until <= $1
+		RETURNING id, unit_id, role_id, valid_from, valid_until
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire unit roles: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		assignment := ExpiredAssignment{Kind: "unit_role"}
+		if err := rows.Scan(&assignment.ID, &assignment.UnitID, &assignment.RoleID,
+			&assignment.ValidFrom, &assignment.ValidUntil); err != nil {
+			return nil, fmt.Errorf("failed to scan expired unit role: %w", err)
+		}
+		expired = append(expired, assignment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read expired unit roles: %w", err)
+	}
+
+	return expired, nil
+}
+
+func activateAssignments(tx *sql.Tx, now time.Time) ([]ActivatedAssignment, error) {
+	var activated []ActivatedAssignment
+
+	rows, err := tx.Query(`
+		UPDATE user_roles SET activated_at = $1
+		WHERE activated_at IS NULL AND (valid_from IS NULL OR valid_from <= $1)
+		RETURNING id, user_id, unit_id, role_id, COALESCE(valid_from, $1)
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate user roles: %w", err)
+	}
+	for rows.Next() {
+		assignment := ActivatedAssignment{Kind: "user_role"}
+		if err := rows.Scan(&assignment.ID, &assignment.UserID, &assignment.UnitID,
+			&assignment.RoleID, &assignment.ValidFrom); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan activated user role: %w", err)
+		}
+		activated = append(activated, assignment)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read activated user roles: %w", err)
+	}
+
+	rows, err = tx.Query(`
+		UPDATE unit_roles SET activated_at = $1
+		WHERE activated_at IS NULL AND (valid_from IS NULL OR valid_from <= $1)
+		RETURNING id, unit_id, role_id, COALESCE(valid_from, $1)
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate unit roles: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		assignment := ActivatedAssignment{Kind: "unit_role"}
+		if err := rows.Scan(&assignment.ID, &assignment.UnitID, &assignment.RoleID, &assignment.ValidFrom); err != nil {
+			return nil, fmt.Errorf("failed to scan activated unit role: %w", err)
+		}
+		activated = append(activated, assignment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read activated unit roles: %w", err)
+	}
+
+	return activated, nil
+}
+
+// applyExpired drops the cached permissions and ends the sessions of the users who lost
+// an assignment, so tokens carrying its abilities stop working
+func (s *AssignmentSweeper) applyExpired(expired []ExpiredAssignment) {
+	revoked := make(map[int64]bool)
+	for _, assignment := range expired {
+		userIDs, err := s.affectedUsers(assignment.Kind, assignment.UserID, assignment.UnitID)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to resolve users of expired %s %d: %v", assignment.Kind, assignment.ID, err))
+		}
+		if err := s.cache.InvalidateUsers(userIDs...); err != nil {
+			logger.Error(fmt.Sprintf("Failed to invalidate permissions of expired %s %d: %v", assignment.Kind, assignment.ID, err))
+		}
+		for _, userID := range userIDs {
+			if revoked[userID] || s.sessions == nil {
+				continue
+			}
+			revoked[userID] = true
+			if err := s.sessions.RevokeAllUserTokens(userID); err != nil {
+				logger.Error(fmt.Sprintf("Failed to revoke sessions of user %d: %v", userID, err))
+			}
+		}
+
+		before := map[string]interface{}{
+			"role_id":     assignment.RoleID,
+			"unit_id":     assignment.UnitID,
+			"valid_from":  assignment.ValidFrom,
+			"valid_until": assignment.ValidUntil,
+		}
+		if assignment.Kind == "user_role" {
+			before["user_id"] = assignment.UserID
+			before["company_id"] = assignment.CompanyID
+			before["branch_id"] = assignment.BranchID
+		}
+		audittrail.RecordChange(s.recorder, 0, assignment.Kind+"_expired", assignment.Kind, assignment.ID,
+			before, map[string]interface{}{})
+	}
+}
+
+// applyActivated drops the cached permissions of the users who gained an assignment
+func (s *AssignmentSweeper) applyActivated(activated []ActivatedAssignment) {
+	for _, assignment := range activated {
+		userIDs, err := s.affectedUsers(assignment.Kind, assignment.UserID, assignment.UnitID)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to resolve users of activated %s %d: %v", assignment.Kind, assignment.ID, err))
+		}
+		if err := s.cache.InvalidateUsers(userIDs...); err != nil {
+			logger.Error(fmt.Sprintf("Failed to invalidate permissions of activated %s %d: %v", assignment.Kind, assignment.ID, err))
+		}
+
+		audittrail.RecordChange(s.recorder, 0, assignment.Kind+"_activated", assignment.Kind, assignment.ID,
+			map[string]interface{}{"active": false},
+			map[string]interface{}{"active": true, "valid_from": assignment.ValidFrom})
+	}
+}
+
+// affectedUsers returns the user of a user role, or the users inheriting a unit role
+func (s *AssignmentSweeper) affectedUsers(kind string, userID int64, unitID *int64) ([]int64, error) {
+	if kind == "user_role" {
+		return []int64{userID}, nil
+	}
+	if unitID == nil {
+		return nil, nil
+	}
+	return s.cache.UnitUserIDs(*unitID)
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // UnitUserPermissions represents user permissions with unit context
@@ -41,8 +42,12 @@ type UnitModulePermission struct {
 	CanWrite     bool
 	CanDelete    bool
 	CanApprove   bool
+	Actions      []string           // Granted actions beyond the standard ones
 	GrantedBy    []PermissionSource // Track where permission comes from
 	HighestLevel string             // "company", "branch", "unit"
+	// Conditions lists, per action, the conditions of which one must hold; actions
+	// without an entry are granted unconditionally
+	Conditions map[string][]string
 }
 
 // PermissionSource tracks the source of a permission
@@ -50,6 +55,7 @@ type PermissionSource struct {
 	Type     string `json:"type"` // "role", "unit_role"
 	RoleID   int64  `json:"role_id"`
 	RoleName string `json:"role_name"`
+	ViaRole  int64  `json:"via_role_id,omitempty"` // assigned role inheriting the grant of RoleID
 	UnitID   *int64 `json:"unit_id,omitempty"`
 	UnitName string `json:"unit_name,omitempty"`
 	Level    string `json:"level"` // "company", "branch", "unit"
@@ -57,7 +63,10 @@ type PermissionSource struct {
 
 // UnitRBACService provides unit-aware RBAC functionality
 type UnitRBACService struct {
-	db *sql.DB
+	db    querier
+	cache *PermissionCache
+	// overlay proposes grants in place of stored ones when simulating a change
+	overlay *grantOverlay
 }
 
 // NewUnitRBACService creates a new unit-aware RBAC service
@@ -65,8 +74,33 @@ func NewUnitRBACService(db *sql.DB) *UnitRBACService {
 	return &UnitRBACService{db: db}
 }
 
+// NewCachedUnitRBACService creates a unit-aware RBAC service that caches resolved permissions
+func NewCachedUnitRBACService(db *sql.DB, cache *PermissionCache) *UnitRBACService {
+	return &UnitRBACService{db: db, cache: cache}
+}
+
 // GetUserUnitPermissions retrieves comprehensive unit-aware permissions for a user
 func (r *UnitRBACService) GetUserUnitPermissions(userID int64) (*UnitUserPermissions, error) {
+	if r.cache == nil {
+		return r.loadUserUnitPermissions(userID)
+	}
+
+	cached := &UnitUserPermissions{}
+	generation, hit := r.cache.load(cacheKindUnitPermissions, userID, cached)
+	if hit {
+		return cached, nil
+	}
+
+	permissions, err := r.loadUserUnitPermissions(userID)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.store(cacheKindUnitPermissions, userID, generation, permissions)
+	return permissions, nil
+}
+
+// loadUserUnitPermissions resolves unit-aware permissions from the database
+func (r *UnitRBACService) loadUserUnitPermissions(userID int64) (*UnitUserPermissions, error) {
 	permissions := &UnitUserPermissions{
 		UserID:         userID,
 		Roles:          []string{},
@@ -110,7 +144,7 @@ func (r *UnitRBACService) loadUserRoleAssignments(permissions *UnitUserPermissio
 				WHEN ur.branch_id IS NOT NULL THEN 'branch'
 				ELSE 'company'
 			END as level
-		FROM user_roles ur
+		FROM active_user_roles ur
 		JOIN roles r ON ur.role_id = r.id
 		JOIN companies c ON ur.company_id = c.id
 		LEFT JOIN branches b ON ur.branch_id = b.id
@@ -265,26 +299,22 @@ func (r *UnitRBACService) loadRoleModulePermissions(permissions *UnitUserPermiss
 
 	// Query with subscription filtering
 	query := fmt.Sprintf(`
-		SELECT 
-			rm.module_id,
-			MAX(CASE WHEN rm.can_read THEN 1 ELSE 0 END) as can_read,
-			MAX(CASE WHEN rm.can_write THEN 1 ELSE 0 END) as can_write,
-			MAX(CASE WHEN rm.can_delete THEN 1 ELSE 0 END) as can_delete,
-			MAX(CASE WHEN rm.can_approve THEN 1 ELSE 0 END) as can_approve,
-			r.name as role_name
-		FROM role_modules rm
+		SELECT DISTINCT
+			rm.role_id, rm.module_id, rm.actions, rm.conditions,
+			r.name as role_name, ra.role_id as assigned_role_id
+		FROM role_ancestors ra
+		JOIN role_modules rm ON rm.role_id = ra.ancestor_id
 		JOIN roles r ON rm.role_id = r.id
 		JOIN modules m ON rm.module_id = m.id
 		LEFT JOIN plan_modules pm ON m.id = pm.module_id AND pm.is_included = true
 		LEFT JOIN subscriptions s ON pm.plan_id = s.plan_id AND s.company_id = $%d
-		WHERE rm.role_id IN (%s)
+		WHERE ra.role_id IN (%s)
 			AND m.is_active = true
 			AND (
 				s.status = 'active' AND s.end_date > CURRENT_DATE
 				OR m.subscription_tier = 'basic' 
 				OR m.subscription_tier IS NULL
 			)
-		GROUP BY rm.module_id, r.name
 	`, len(roleIDs)+1, strings.Join(placeholders, ","))
 
 	args := append(roleIDs, companyID)
@@ -295,42 +325,62 @@ func (r *UnitRBACService) loadRoleModulePermissions(permissions *UnitUserPermiss
 	defer rows.Close()
 
 	for rows.Next() {
-		var moduleID int64
-		var canRead, canWrite, canDelete, canApprove int
+		var roleID, moduleID, assignedRoleID int64
+		var grant Actions
+		var conditions []byte
 		var roleName string
 
-		if err := rows.Scan(&moduleID, &canRead, &canWrite, &canDelete, &canApprove, &roleName); err != nil {
+		if err := rows.Scan(&roleID, &moduleID, &grant, &conditions, &roleName, &assignedRoleID); err != nil {
+			continue
+		}
+		if r.overlay.coversRoleGrant(roleID, moduleID) {
 			continue
 		}
 
-		// Get or create module permission
-		modulePerm, exists := permissions.Modules[moduleID]
-		if !exists {
-			modulePerm = UnitModulePermission{
-				ModuleID:     moduleID,
-				GrantedBy:    []PermissionSource{},
-				HighestLevel: "company",
-			}
+		addRoleGrant(permissions, moduleID, grant, parseGrantConditions(conditions, grant), roleID, roleName, assignedRoleID)
+	}
+
+	// Proposed grants reach the user through every assigned role inheriting the role
+	grants := r.overlay.roleGrants(func(module simulatedModule) bool {
+		return module.active && (module.subscribed[companyID] || module.basic)
+	})
+	for _, assignedRoleID := range r.overlay.heldRoles(permissions.UnitRoles) {
+		for _, grant := range grants {
+			addRoleGrant(permissions, grant.ModuleID, grant.Actions, grant.Conditions, r.overlay.roleID, r.overlay.roleName, assignedRoleID)
 		}
+	}
 
-		// Merge permissions (OR logic - any true makes it true)
-		modulePerm.CanRead = modulePerm.CanRead || (canRead == 1)
-		modulePerm.CanWrite = modulePerm.CanWrite || (canWrite == 1)
-		modulePerm.CanDelete = modulePerm.CanDelete || (canDelete == 1)
-		modulePerm.CanApprove = modulePerm.CanApprove || (canApprove == 1)
-
-		// Add permission source
-		source := PermissionSource{
-			Type:     "role",
-			RoleName: roleName,
-			Level:    "company", // Traditional roles are company-level
+	return nil
+}
+
+// addRoleGrant merges a grant of a role, held through the assigned role, into the module permission
+func addRoleGrant(permissions *UnitUserPermissions, moduleID int64, grant Actions, conditions Conditions, roleID int64, roleName string, assignedRoleID int64) {
+	// Get or create module permission
+	modulePerm, exists := permissions.Modules[moduleID]
+	if !exists {
+		modulePerm = UnitModulePermission{
+			ModuleID:     moduleID,
+			GrantedBy:    []PermissionSource{},
+			HighestLevel: "company",
 		}
-		modulePerm.GrantedBy = append(modulePerm.GrantedBy, source)
+	}
 
-		permissions.Modules[moduleID] = modulePerm
+	// Merge permissions (OR logic - any grant allows the action)
+	modulePerm.mergeGrant(grant, conditions)
+
+	// Add permission source
+	source := PermissionSource{
+		Type:     "role",
+		RoleID:   roleID,
+		RoleName: roleName,
+		Level:    "company", // Traditional roles are company-level
+	}
+	if assignedRoleID != roleID {
+		source.ViaRole = assignedRoleID
 	}
+	modulePerm.GrantedBy = append(modulePerm.GrantedBy, source)
 
-	return nil
+	permissions.Modules[moduleID] = modulePerm
 }
 
 // loadUnitRoleModulePermissions loads permissions from unit-specific role assignments
@@ -349,23 +399,18 @@ func (r *UnitRBACService) loadUnitRoleModulePermissions(permissions *UnitUserPer
 
 	// Query unit role module permissions
 	query := fmt.Sprintf(`
-		SELECT 
-			urm.module_id,
-			MAX(CASE WHEN urm.can_read THEN 1 ELSE 0 END) as can_read,
-			MAX(CASE WHEN urm.can_write THEN 1 ELSE 0 END) as can_write,
-			MAX(CASE WHEN urm.can_delete THEN 1 ELSE 0 END) as can_delete,
-			MAX(CASE WHEN urm.can_approve THEN 1 ELSE 0 END) as can_approve,
+		SELECT DISTINCT
+			ur.id, ur.role_id, urm.module_id, urm.actions, urm.conditions,
 			r.name as role_name,
 			u.name as unit_name,
 			u.id as unit_id
 		FROM unit_role_modules urm
-		JOIN unit_roles ur ON urm.unit_role_id = ur.id
+		JOIN active_unit_roles ur ON urm.unit_role_id = ur.id
 		JOIN roles r ON ur.role_id = r.id
 		JOIN units u ON ur.unit_id = u.id
 		JOIN modules m ON urm.module_id = m.id
 		WHERE ur.unit_id IN (%s)
 			AND m.is_active = true
-		GROUP BY urm.module_id, r.name, u.name, u.id
 	`, strings.Join(placeholders, ","))
 
 	rows, err := r.db.Query(query, unitIDs...)
@@ -375,70 +420,83 @@ func (r *UnitRBACService) loadUnitRoleModulePermissions(permissions *UnitUserPer
 	defer rows.Close()
 
 	for rows.Next() {
-		var moduleID, unitID int64
-		var canRead, canWrite, canDelete, canApprove int
+		var unitRoleID, roleID, moduleID, unitID int64
+		var grant Actions
+		var conditions []byte
 		var roleName, unitName string
 
-		if err := rows.Scan(&moduleID, &canRead, &canWrite, &canDelete, &canApprove, &roleName, &unitName, &unitID); err != nil {
+		if err := rows.Scan(&unitRoleID, &roleID, &moduleID, &grant, &conditions, &roleName, &unitName, &unitID); err != nil {
 			continue
 		}
-
-		// Get or create module permission
-		modulePerm, exists := permissions.Modules[moduleID]
-		if !exists {
-			modulePerm = UnitModulePermission{
-				ModuleID:     moduleID,
-				GrantedBy:    []PermissionSource{},
-				HighestLevel: "unit",
-			}
+		if r.overlay.coversUnitRoleGrant(unitRoleID, moduleID) {
+			continue
 		}
 
-		// Merge permissions (OR logic - any true makes it true)
-		modulePerm.CanRead = modulePerm.CanRead || (canRead == 1)
-		modulePerm.CanWrite = modulePerm.CanWrite || (canWrite == 1)
-		modulePerm.CanDelete = modulePerm.CanDelete || (canDelete == 1)
-		modulePerm.CanApprove = modulePerm.CanApprove || (canApprove == 1)
+		addUnitRoleGrant(permissions, moduleID, grant, parseGrantConditions(conditions, grant), roleID, roleName, unitID, unitName)
+	}
 
-		// Update highest level if unit-level is more specific
-		if modulePerm.HighestLevel == "company" {
-			modulePerm.HighestLevel = "unit"
-		}
+	for _, grant := range r.overlay.unitRoleGrants(permissions.EffectiveUnits) {
+		addUnitRoleGrant(permissions, grant.ModuleID, grant.Actions, grant.Conditions,
+			r.overlay.roleID, r.overlay.roleName, r.overlay.unitID, r.overlay.unitName)
+	}
+
+	return nil
+}
 
-		// Add permission source
-		source := PermissionSource{
-			Type:     "unit_role",
-			RoleName: roleName,
-			UnitID:   &unitID,
-			UnitName: unitName,
-			Level:    "unit",
+// addUnitRoleGrant merges a grant of a unit role into the module permission
+func addUnitRoleGrant(permissions *UnitUserPermissions, moduleID int64, grant Actions, conditions Conditions, roleID int64, roleName string, unitID int64, unitName string) {
+	// Get or create module permission
+	modulePerm, exists := permissions.Modules[moduleID]
+	if !exists {
+		modulePerm = UnitModulePermission{
+			ModuleID:     moduleID,
+			GrantedBy:    []PermissionSource{},
+			HighestLevel: "unit",
 		}
-		modulePerm.GrantedBy = append(modulePerm.GrantedBy, source)
+	}
+
+	// Merge permissions (OR logic - any grant allows the action)
+	modulePerm.mergeGrant(grant, conditions)
 
-		permissions.Modules[moduleID] = modulePerm
+	// Update highest level if unit-level is more specific
+	if modulePerm.HighestLevel == "company" {
+		modulePerm.HighestLevel = "unit"
 	}
 
-	return nil
+	// Add permission source
+	source := PermissionSource{
+		Type:     "unit_role",
+		RoleID:   roleID,
+		RoleName: roleName,
+		UnitID:   &unitID,
+		UnitName: unitName,
+		Level:    "unit",
+	}
+	modulePerm.GrantedBy = append(modulePerm.GrantedBy, source)
+
+	permissions.Modules[moduleID] = modulePerm
 }
 
 // determineAdminLevels determines user's administrative levels
 func (r *UnitRBACService) determineAdminLevels(permissions *UnitUserPermissions) {
 	for _, role := range permissions.Roles {
-		switch role {
-		case "SUPER_ADMIN", "COMPANY_ADMIN":
-			permissions.IsCompanyAdmin = true
-			permissions.IsBranchAdmin = true
-			permissions.IsUnitAdmin = true
-		case "BRANCH_ADMIN":
-			permissions.IsBranchAdmin = true
-			permissions.IsUnitAdmin = true
-		case "UNIT_ADMIN":
-			permissions.IsUnitAdmin = true
-		}
+		company, branch, unit := AdminLevels(role)
+		permissions.IsCompanyAdmin = permissions.IsCompanyAdmin || company
+		permissions.IsBranchAdmin = permissions.IsBranchAdmin || branch
+		permissions.IsUnitAdmin = permissions.IsUnitAdmin || unit
 	}
 }
 
-// HasUnitPermission checks if user has specific permission for a module in a unit context
+// HasUnitPermission checks if user has specific permission for a module in a unit context.
+// Conditional grants are evaluated without request attributes; use
+// HasUnitPermissionWithContext to supply them.
 func (r *UnitRBACService) HasUnitPermission(userID int64, moduleID int64, permission string, unitID *int64) (bool, error) {
+	return r.HasUnitPermissionWithContext(userID, moduleID, permission, unitID, nil)
+}
+
+// HasUnitPermissionWithContext checks if user has specific permission for a module in a unit
+// context, evaluating the conditions of conditional grants against the request attributes
+func (r *UnitRBACService) HasUnitPermissionWithContext(userID int64, moduleID int64, permission string, unitID *int64, attributes map[string]interface{}) (bool, error) {
 	permissions, err := r.GetUserUnitPermissions(userID)
 	if err != nil {
 		return false, err
@@ -458,24 +516,37 @@ func (r *UnitRBACService) HasUnitPermission(userID int64, moduleID int64, permis
 		}
 	}
 
+	if !IsValidAction(permission) {
+		return false, fmt.Errorf("invalid permission type: %s", permission)
+	}
+
 	// Check module permission
 	modulePerm, exists := permissions.Modules[moduleID]
 	if !exists {
 		return false, nil
 	}
 
-	switch permission {
-	case "read":
-		return modulePerm.CanRead, nil
-	case "write":
-		return modulePerm.CanWrite, nil
-	case "delete":
-		return modulePerm.CanDelete, nil
-	case "approve":
-		return modulePerm.CanApprove, nil
-	default:
-		return false, fmt.Errorf("invalid permission type: %s", permission)
+	granted := modulePerm.Grants(permission)
+	if !granted || len(modulePerm.Conditions[permission]) == 0 {
+		return granted, nil
 	}
+
+	environment, err := conditionEnvironment(r.db, userID, attributes, time.Now())
+	if err != nil {
+		return false, err
+	}
+	return anyConditionHolds(modulePerm.Conditions[permission], environment), nil
+}
+
+// mergeGrant ORs one grant into the permission and merges its conditions
+func (p *UnitModulePermission) mergeGrant(grant Actions, conditions Conditions) {
+	granted := actionGrants{flags: [4]*bool{&p.CanRead, &p.CanWrite, &p.CanDelete, &p.CanApprove}, further: &p.Actions}
+	p.Conditions = mergeGrant(granted, p.Conditions, grant, conditions)
+}
+
+// Grants reports whether the permission allows an action
+func (p UnitModulePermission) Grants(action string) bool {
+	return grantsAction(action, p.CanRead, p.CanWrite, p.CanDelete, p.CanApprove, p.Actions)
 }
 
 // CanAccessUnit checks if user can access a specific unit
@@ -0,0 +1,43 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func (ts *SimpleTokenService) authorizationCodeKey(code string) string {
+	return fmt.Sprintf("oauth:code:%s", ts.HashToken(code))
+}
+
+// StoreAuthorizationCode stores a short-lived OAuth2 authorization code. Only the code hash is used as key.
+func (ts *SimpleTokenService) StoreAuthorizationCode(code string, grant AuthorizationCode, ttl time.Duration) error {
+	data, err := json.Marshal(grant)
+	if err != nil {
+		return err
+	}
+
+	return ts.redis.Set(context.Background(), ts.authorizationCodeKey(code), data, ttl).Err()
+}
+
+// ConsumeAuthorizationCode returns and deletes an authorization code in one step,
+// so a code can be exchanged at most once
+func (ts *SimpleTokenService) ConsumeAuthorizationCode(code string) (*AuthorizationCode, error) {
+	data, err := ts.redis.GetDel(context.Background(), ts.authorizationCodeKey(code)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("authorization code not found or expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var grant AuthorizationCode
+	if err := json.Unmarshal([]byte(data), &grant); err != nil {
+		return nil, err
+	}
+
+	return &grant, nil
+}
@@ -0,0 +1,70 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+func (ts *SimpleTokenService) challengeKey(token string) string {
+	return fmt.Sprintf("2fa:challenge:%s", ts.HashToken(token))
+}
+
+// StoreLoginChallenge stores a short-lived login challenge. Only the token hash is used as key.
+func (ts *SimpleTokenService) StoreLoginChallenge(token string, challenge LoginChallenge, ttl time.Duration) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+
+	return ts.redis.Set(context.Background(), ts.challengeKey(token), data, ttl).Err()
+}
+
+// GetLoginChallenge retrieves a pending login challenge
+func (ts *SimpleTokenService) GetLoginChallenge(token string) (*LoginChallenge, error) {
+	data, err := ts.redis.Get(context.Background(), ts.challengeKey(token)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("challenge token not found or expired")
+	}
+
+	var challenge LoginChallenge
+	if err := json.Unmarshal([]byte(data), &challenge); err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// RecordLoginChallengeFailure counts a failed verification and returns the number of failures so far
+func (ts *SimpleTokenService) RecordLoginChallengeFailure(token string) (int64, error) {
+	ctx := context.Background()
+
+	attemptsKey := ts.challengeKey(token) + ":attempts"
+	attempts, err := ts.redis.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// Counter lives exactly as long as the challenge itself
+	if ttl := ts.redis.TTL(ctx, ts.challengeKey(token)).Val(); ttl > 0 {
+		ts.redis.Expire(ctx, attemptsKey, ttl)
+	}
+
+	return attempts, nil
+}
+
+// DeleteLoginChallenge removes a login challenge so it cannot be used again. It reports
+// whether the challenge still existed, which lets concurrent verifications succeed only once.
+func (ts *SimpleTokenService) DeleteLoginChallenge(token string) (bool, error) {
+	ctx := context.Background()
+
+	key := ts.challengeKey(token)
+	deleted, err := ts.redis.Del(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	ts.redis.Del(ctx, key+":attempts")
+
+	return deleted > 0, nil
+}
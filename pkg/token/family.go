@@ -0,0 +1,298 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+// consumeRefreshScript atomically removes a refresh token and leaves a "used" marker
+// carrying the same metadata for the remaining lifetime of the token. Only one caller
+// can ever consume a given token; every later presentation finds the marker instead.
+var consumeRefreshScript = redis.NewScript(`
+local data = redis.call('GET', KEYS[1])
+if not data then
+	return false
+end
+local ttl = redis.call('PTTL', KEYS[1])
+redis.call('DEL', KEYS[1])
+if ttl > 0 then
+	redis.call('SET', KEYS[2], data, 'PX', ttl)
+end
+return data
+`)
+
+func familyKey(familyID string) string {
+	return fmt.Sprintf("refresh:family:%s", familyID)
+}
+
+func familyAccessKey(familyID string) string {
+	return fmt.Sprintf("refresh:family:%s:access", familyID)
+}
+
+func userFamiliesKey(userID int64) string {
+	return fmt.Sprintf("refresh:families:%d", userID)
+}
+
+func (ts *SimpleTokenService) usedRefreshKey(token string) string {
+	return fmt.Sprintf("refresh:used:%s", ts.HashToken(token))
+}
+
+// bindRefreshToken records the refresh token as the current member of its family
+func (ts *SimpleTokenService) bindRefreshToken(ctx context.Context, token string, metadata RefreshTokenMetadata, ttl time.Duration) error {
+	key := familyKey(metadata.FamilyID)
+	now := time.Now().Unix()
+
+	pipe := ts.redis.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", metadata.UserID, "refresh_token", token, "last_seen", now)
+	pipe.HSetNX(ctx, key, "created_at", now)
+	pipe.HSetNX(ctx, key, "user_agent", metadata.UserAgent)
+	pipe.HSetNX(ctx, key, "ip", metadata.IP)
+	pipe.Expire(ctx, key, ttl)
+	pipe.SAdd(ctx, userFamiliesKey(metadata.UserID), metadata.FamilyID)
+	pipe.Expire(ctx, userFamiliesKey(metadata.UserID), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// bindAccessToken attaches an access token to its family so the family can be revoked as a whole
+func (ts *SimpleTokenService) bindAccessToken(ctx context.Context, token string, familyID string, ttl time.Duration) error {
+	key := familyAccessKey(familyID)
+
+	// The set must outlive the access token; the family TTL is the upper bound
+	if familyTTL := ts.redis.TTL(ctx, familyKey(familyID)).Val(); familyTTL > ttl {
+		ttl = familyTTL
+	}
+
+	pipe := ts.redis.TxPipeline()
+	pipe.SAdd(ctx, key, token)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ConsumeRefreshToken validates and invalidates a refresh token in one step.
+// Presenting a token that was already consumed revokes its whole family and returns
+// ErrRefreshTokenReused together with the metadata of the replayed token.
+func (ts *SimpleTokenService) ConsumeRefreshToken(token string) (*RefreshTokenMetadata, error) {
+	ctx := context.Background()
+
+	tokenKey := fmt.Sprintf("refresh:token:%s", token)
+	data, err := consumeRefreshScript.Run(ctx, ts.redis, []string{tokenKey, ts.usedRefreshKey(token)}).Text()
+	if err == redis.Nil {
+		return ts.detectRefreshReuse(ctx, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata RefreshTokenMetadata
+	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+		return nil, err
+	}
+
+	// A family revoked in the meantime no longer accepts its tokens
+	current, err := ts.redis.HGet(ctx, familyKey(metadata.FamilyID), "refresh_token").Result()
+	if err != nil || current != token {
+		return nil, fmt.Errorf("token not found or expired")
+	}
+
+	return &metadata, nil
+}
+
+// detectRefreshReuse checks whether an unknown refresh token was previously rotated
+func (ts *SimpleTokenService) detectRefreshReuse(ctx context.Context, token string) (*RefreshTokenMetadata, error) {
+	data, err := ts.redis.Get(ctx, ts.usedRefreshKey(token)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("token not found or expired")
+	}
+
+	var metadata RefreshTokenMetadata
+	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+		return nil, err
+	}
+
+	if err := ts.RevokeFamily(metadata.FamilyID); err != nil {
+		return &metadata, err
+	}
+
+	return &metadata, ErrRefreshTokenReused
+}
+
+// RevokeFamily revokes the current refresh token and every access token issued within a family
+func (ts *SimpleTokenService) RevokeFamily(familyID string) error {
+	ctx := context.Background()
+
+	family, err := ts.redis.HGetAll(ctx, familyKey(familyID)).Result()
+	if err != nil {
+		return err
+	}
+	accessTokens, err := ts.redis.SMembers(ctx, familyAccessKey(familyID)).Result()
+	if err != nil {
+		return err
+	}
+
+	pipe := ts.redis.TxPipeline()
+	if refreshToken := family["refresh_token"]; refreshToken != "" {
+		pipe.Del(ctx, fmt.Sprintf("refresh:token:%s", refreshToken))
+	}
+	for _, accessToken := range accessTokens {
+		// JWTs cannot be deleted, so they are denylisted until they expire
+		if jti, expiresAt, ok := parseJWTMember(accessToken); ok {
+			denyJWT(ctx, pipe, jti, expiresAt)
+			continue
+		}
+		pipe.Del(ctx, fmt.Sprintf("access:token:%s", accessToken))
+	}
+	pipe.Del(ctx, familyKey(familyID), familyAccessKey(familyID))
+	if userID, err := strconv.ParseInt(family["user_id"], 10, 64); err == nil {
+		pipe.SRem(ctx, userFamiliesKey(userID), familyID)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// TouchSession records activity on a session; sessions that no longer exist are left untouched
+func (ts *SimpleTokenService) TouchSession(sessionID string) error {
+	ctx := context.Background()
+
+	key := familyKey(sessionID)
+	if ts.redis.Exists(ctx, key).Val() == 0 {
+		return nil
+	}
+	return ts.redis.HSet(ctx, key, "last_seen", time.Now().Unix()).Err()
+}
+
+// GetUserSessions lists active sessions of a user with their device metadata
+func (ts *SimpleTokenService) GetUserSessions(userID int64) ([]SessionInfo, error) {
+	ctx := context.Background()
+
+	families, err := ts.getUserFamilies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []SessionInfo{}
+	for _, family := range families {
+		if len(family.AccessTokens) == 0 && len(family.RefreshTokens) == 0 {
+			continue
+		}
+
+		session := SessionInfo{
+			SessionID: family.FamilyID,
+			UserAgent: family.UserAgent,
+			IP:        family.IP,
+			CreatedAt: family.CreatedAt,
+			LastSeen:  family.LastSeen,
+		}
+		if ttl := ts.redis.TTL(ctx, familyKey(family.FamilyID)).Val(); ttl > 0 {
+			session.ExpiresAt = time.Now().Add(ttl).Unix()
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes a single session after checking that it belongs to the user
+func (ts *SimpleTokenService) RevokeSession(userID int64, sessionID string) error {
+	ctx := context.Background()
+
+	owner, err := ts.redis.HGet(ctx, familyKey(sessionID), "user_id").Result()
+	if err != nil || owner != strconv.FormatInt(userID, 10) {
+		return fmt.Errorf("session not found")
+	}
+
+	return ts.RevokeFamily(sessionID)
+}
+
+// getUserFamilies returns token families of a user, pruning families that already expired
+func (ts *SimpleTokenService) getUserFamilies(ctx context.Context, userID int64) ([]TokenFamilyInfo, error) {
+	familyIDs, err := ts.redis.SMembers(ctx, userFamiliesKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	families := []TokenFamilyInfo{}
+	for _, familyID := range familyIDs {
+		family, err := ts.redis.HGetAll(ctx, familyKey(familyID)).Result()
+		if err != nil || len(family) == 0 {
+			ts.redis.SRem(ctx, userFamiliesKey(userID), familyID)
+			continue
+		}
+
+		info := TokenFamilyInfo{
+			FamilyID:      familyID,
+			UserAgent:     family["user_agent"],
+			IP:            family["ip"],
+			AccessTokens:  []TokenInfo{},
+			RefreshTokens: []TokenInfo{},
+		}
+		info.CreatedAt, _ = strconv.ParseInt(family["created_at"], 10, 64)
+		info.LastSeen, _ = strconv.ParseInt(family["last_seen"], 10, 64)
+
+		ts.collectFamilyAccessTokens(ctx, familyID, &info)
+
+		refreshTokenKey := fmt.Sprintf("refresh:token:%s", family["refresh_token"])
+		if ttl, err := ts.redis.TTL(ctx, refreshTokenKey).Result(); err == nil && ttl > 0 {
+			info.RefreshTokens = append(info.RefreshTokens, TokenInfo{
+				Type:     "refresh",
+				TTL:      int64(ttl.Seconds()),
+				FamilyID: familyID,
+			})
+		}
+
+		families = append(families, info)
+	}
+
+	return families, nil
+}
+
+// collectFamilyAccessTokens adds live access tokens of a family and drops expired ones from its set
+func (ts *SimpleTokenService) collectFamilyAccessTokens(ctx context.Context, familyID string, info *TokenFamilyInfo) {
+	accessTokens, err := ts.redis.SMembers(ctx, familyAccessKey(familyID)).Result()
+	if err != nil {
+		return
+	}
+
+	for _, accessToken := range accessTokens {
+		if _, expiresAt, ok := parseJWTMember(accessToken); ok {
+			if time.Now().Unix() >= expiresAt {
+				ts.redis.SRem(ctx, familyAccessKey(familyID), accessToken)
+				continue
+			}
+			info.AccessTokens = append(info.AccessTokens, TokenInfo{
+				Type:      "access",
+				ExpiresAt: expiresAt,
+				UserAgent: info.UserAgent,
+				IP:        info.IP,
+				FamilyID:  familyID,
+			})
+			continue
+		}
+
+		metadata, err := ts.GetAccessToken(accessToken)
+		if err != nil {
+			ts.redis.SRem(ctx, familyAccessKey(familyID), accessToken)
+			continue
+		}
+		if time.Now().Unix() >= metadata.ExpiresAt {
+			continue
+		}
+		info.AccessTokens = append(info.AccessTokens, TokenInfo{
+			Type:      "access",
+			ExpiresAt: metadata.ExpiresAt,
+			UserAgent: metadata.UserAgent,
+			IP:        metadata.IP,
+			FamilyID:  familyID,
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-scalable-api/pkg/jwt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// jwtAccessTokens is set once at startup when the service runs in JWT token mode.
+// Token services are created per request in the middleware, so the key set is
+// shared at package level instead of being passed to every constructor.
+var jwtAccessTokens *jwtSettings
+
+type jwtSettings struct {
+	keys   *jwt.KeySet
+	issuer string
+}
+
+// jwtMemberPrefix marks JWT access tokens in a family access set. Only the jti and
+// expiry are kept there, which is all revocation needs.
+const jwtMemberPrefix = "jwt:"
+
+// EnableJWT switches access tokens from opaque Redis tokens to signed JWTs
+func EnableJWT(keys *jwt.KeySet, issuer string) {
+	jwtAccessTokens = &jwtSettings{keys: keys, issuer: issuer}
+}
+
+// JWTEnabled reports whether access tokens are issued as JWTs
+func JWTEnabled() bool {
+	return jwtAccessTokens != nil
+}
+
+func jwtDenyKey(jti string) string {
+	return fmt.Sprintf("jwt:deny:%s", jti)
+}
+
+// IssueAccessToken creates an access token for the metadata. In JWT mode the token
+// is a signed JWT that downstream services can verify with the JWKS; otherwise it is
+// an opaque token stored in Redis.
+func (ts *SimpleTokenService) IssueAccessToken(metadata TokenMetadata, ttl time.Duration) (string, error) {
+	if jwtAccessTokens == nil {
+		accessToken, err := ts.GenerateToken()
+		if err != nil {
+			return "", err
+		}
+		if err := ts.StoreAccessToken(accessToken, metadata, ttl); err != nil {
+			return "", err
+		}
+		return accessToken, nil
+	}
+
+	jti, err := ts.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	abilities := metadata.Abilities
+	if abilities == nil {
+		abilities = []string{}
+	}
+
+	accessToken, err := jwtAccessTokens.keys.Sign(&jwt.Claims{
+		Issuer:    jwtAccessTokens.issuer,
+		Subject:   strconv.FormatInt(metadata.UserID, 10),
+		ID:        jti,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: metadata.ExpiresAt,
+		UserID:    metadata.UserID,
+		CompanyID: metadata.CompanyID,
+		SessionID: metadata.FamilyID,
+		ClientID:  metadata.ClientID,
+		Scope:     metadata.Scope,
+		Abilities: abilities,
+		TokenUse:  jwt.TokenUseAccess,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if metadata.FamilyID != "" {
+		member := fmt.Sprintf("%s%s:%d", jwtMemberPrefix, jti, metadata.ExpiresAt)
+		if err := ts.bindAccessToken(context.Background(), member, metadata.FamilyID, ttl); err != nil {
+			return "", err
+		}
+	}
+
+	return accessToken, nil
+}
+
+// getJWTAccessToken verifies a JWT access token and checks the revocation denylist
+func (ts *SimpleTokenService) getJWTAccessToken(accessToken string) (*TokenMetadata, error) {
+	claims, err := jwtAccessTokens.keys.Verify(accessToken, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("token not found or expired")
+	}
+	// ID tokens share the keys and issuer, so only tokens issued as access tokens are accepted
+	if claims.Issuer != jwtAccessTokens.issuer || claims.TokenUse != jwt.TokenUseAccess ||
+		claims.ID == "" || claims.UserID == 0 {
+		return nil, fmt.Errorf("token not found or expired")
+	}
+
+	denied, err := ts.redis.Exists(context.Background(), jwtDenyKey(claims.ID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if denied > 0 {
+		return nil, fmt.Errorf("token has been revoked")
+	}
+
+	return &TokenMetadata{
+		UserID:    claims.UserID,
+		CompanyID: claims.CompanyID,
+		Abilities: claims.Abilities,
+		ExpiresAt: claims.ExpiresAt,
+		FamilyID:  claims.SessionID,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+	}, nil
+}
+
+// parseJWTMember splits a family access set member created by IssueAccessToken
+func parseJWTMember(member string) (jti string, expiresAt int64, ok bool) {
+	if !strings.HasPrefix(member, jwtMemberPrefix) {
+		return "", 0, false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(member, jwtMemberPrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", 0, false
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return parts[0], expiresAt, true
+}
+
+// denyJWT adds a jti to the denylist until the token would have expired anyway
+func denyJWT(ctx context.Context, pipe redis.Pipeliner, jti string, expiresAt int64) {
+	ttl := time.Until(time.Unix(expiresAt, 0))
+	if ttl <= 0 {
+		return
+	}
+	pipe.Set(ctx, jwtDenyKey(jti), 1, ttl)
+}
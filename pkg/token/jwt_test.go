@@ -0,0 +1,64 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"gin-scalable-api/pkg/jwt"
+)
+
+func enableTestJWT(t *testing.T) *jwt.KeySet {
+	t.Helper()
+
+	signer, err := jwt.GenerateKey(jwt.ES256)
+	if err != nil {
+		t.Fatalf("GenerateKey returned error: %v", err)
+	}
+	keys, err := jwt.NewKeySet([]*jwt.Key{{ID: "k1", Algorithm: jwt.ES256, Signer: signer}}, "k1")
+	if err != nil {
+		t.Fatalf("NewKeySet returned error: %v", err)
+	}
+
+	EnableJWT(keys, "https://auth.example.com")
+	t.Cleanup(func() { jwtAccessTokens = nil })
+	return keys
+}
+
+func TestGetAccessTokenRejectsNonAccessTokens(t *testing.T) {
+	keys := enableTestJWT(t)
+	now := time.Now()
+
+	idToken, err := keys.SignPayload(map[string]interface{}{
+		"iss":   "https://auth.example.com",
+		"sub":   "7",
+		"aud":   "third-party-client",
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"nonce": "n-0S6_WzA2Mj",
+	})
+	if err != nil {
+		t.Fatalf("SignPayload returned error: %v", err)
+	}
+
+	tests := map[string]*jwt.Claims{
+		"without token_use": {Issuer: "https://auth.example.com", ID: "jti-1", UserID: 7, ExpiresAt: now.Add(time.Hour).Unix()},
+		"without jti":       {Issuer: "https://auth.example.com", UserID: 7, ExpiresAt: now.Add(time.Hour).Unix(), TokenUse: jwt.TokenUseAccess},
+		"without uid":       {Issuer: "https://auth.example.com", ID: "jti-2", ExpiresAt: now.Add(time.Hour).Unix(), TokenUse: jwt.TokenUseAccess},
+	}
+	tokens := map[string]string{"id token": idToken}
+	for name, claims := range tests {
+		signed, err := keys.Sign(claims)
+		if err != nil {
+			t.Fatalf("%s: Sign returned error: %v", name, err)
+		}
+		tokens[name] = signed
+	}
+
+	// Rejected before the denylist is consulted, so no Redis is needed
+	ts := NewSimpleTokenService(nil)
+	for name, signed := range tokens {
+		if metadata, err := ts.GetAccessToken(signed); err == nil {
+			t.Errorf("%s: GetAccessToken accepted the token as %+v", name, metadata)
+		}
+	}
+}
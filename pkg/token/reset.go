@@ -0,0 +1,97 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrPasswordResetThrottled is returned when a user requests reset tokens too quickly
+var ErrPasswordResetThrottled = errors.New("password reset requested too recently")
+
+func (ts *SimpleTokenService) passwordResetKey(token string) string {
+	return fmt.Sprintf("pwreset:token:%s", ts.HashToken(token))
+}
+
+func passwordResetUserKey(userID int64) string {
+	return fmt.Sprintf("pwreset:user:%d", userID)
+}
+
+func passwordResetCooldownKey(userID int64) string {
+	return fmt.Sprintf("pwreset:cooldown:%d", userID)
+}
+
+// StorePasswordResetToken stores a single-use password reset token. Only the token hash is
+// used as key, and a new token replaces any earlier one of the same user. Within cooldown
+// of the previous request it returns ErrPasswordResetThrottled and stores nothing.
+func (ts *SimpleTokenService) StorePasswordResetToken(token string, reset PasswordReset, ttl, cooldown time.Duration) error {
+	ctx := context.Background()
+
+	allowed, err := ts.redis.SetNX(ctx, passwordResetCooldownKey(reset.UserID), 1, cooldown).Result()
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrPasswordResetThrottled
+	}
+
+	data, err := json.Marshal(reset)
+	if err != nil {
+		return err
+	}
+
+	key := ts.passwordResetKey(token)
+	userKey := passwordResetUserKey(reset.UserID)
+
+	// Invalidate the token of an earlier request
+	if previous, err := ts.redis.Get(ctx, userKey).Result(); err == nil {
+		ts.redis.Del(ctx, previous)
+	}
+
+	pipe := ts.redis.TxPipeline()
+	pipe.Set(ctx, key, data, ttl)
+	pipe.Set(ctx, userKey, key, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetPasswordReset looks up a reset token without consuming it
+func (ts *SimpleTokenService) GetPasswordReset(token string) (*PasswordReset, error) {
+	data, err := ts.redis.Get(context.Background(), ts.passwordResetKey(token)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("password reset token not found or expired")
+	}
+
+	var reset PasswordReset
+	if err := json.Unmarshal([]byte(data), &reset); err != nil {
+		return nil, err
+	}
+
+	return &reset, nil
+}
+
+// ConsumePasswordResetToken returns and deletes a reset token in one step,
+// so a token can reset the password at most once
+func (ts *SimpleTokenService) ConsumePasswordResetToken(token string) (*PasswordReset, error) {
+	ctx := context.Background()
+
+	data, err := ts.redis.GetDel(ctx, ts.passwordResetKey(token)).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("password reset token not found or expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var reset PasswordReset
+	if err := json.Unmarshal([]byte(data), &reset); err != nil {
+		return nil, err
+	}
+	ts.redis.Del(ctx, passwordResetUserKey(reset.UserID))
+
+	return &reset, nil
+}
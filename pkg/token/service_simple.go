@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"time"
 
+	"gin-scalable-api/pkg/jwt"
+
 	"github.com/redis/go-redis/v9"
 )
 
@@ -37,13 +39,12 @@ func (ts *SimpleTokenService) HashToken(token string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// StoreAccessToken stores access token with token as key
+// StoreAccessToken stores access token with token as key and indexes it under its session
 func (ts *SimpleTokenService) StoreAccessToken(token string, metadata TokenMetadata, ttl time.Duration) error {
 	ctx := context.Background()
 
 	// Use token as key directly
 	tokenKey := fmt.Sprintf("access:token:%s", token)
-	userKey := fmt.Sprintf("access:user:%d", metadata.UserID)
 
 	// Store token metadata
 	data, err := json.Marshal(metadata)
@@ -56,17 +57,21 @@ func (ts *SimpleTokenService) StoreAccessToken(token string, metadata TokenMetad
 		return err
 	}
 
-	// Store user -> token mapping (for logout all)
-	return ts.redis.Set(ctx, userKey, token, ttl).Err()
+	// Bind token to its session so the session can be revoked as a whole.
+	// Sessions are indexed per user, so concurrent logins no longer overwrite each other.
+	if metadata.FamilyID == "" {
+		return nil
+	}
+	return ts.bindAccessToken(ctx, token, metadata.FamilyID, ttl)
 }
 
-// StoreRefreshToken stores refresh token with token as key
+// StoreRefreshToken stores refresh token with token as key and makes it the
+// current token of its family
 func (ts *SimpleTokenService) StoreRefreshToken(token string, metadata RefreshTokenMetadata, ttl time.Duration) error {
 	ctx := context.Background()
 
 	// Use token as key directly
 	tokenKey := fmt.Sprintf("refresh:token:%s", token)
-	userKey := fmt.Sprintf("refresh:user:%d", metadata.UserID)
 
 	// Store token metadata
 	data, err := json.Marshal(metadata)
@@ -79,14 +84,18 @@ func (ts *SimpleTokenService) StoreRefreshToken(token string, metadata RefreshTo
 		return err
 	}
 
-	// Store user -> token mapping (for logout all)
-	return ts.redis.Set(ctx, userKey, token, ttl).Err()
+	// Store family -> current token and user -> families mapping (for logout all)
+	return ts.bindRefreshToken(ctx, token, metadata, ttl)
 }
 
-// GetAccessToken retrieves access token metadata from Redis
+// GetAccessToken retrieves access token metadata from Redis, or from the claims of a JWT access token
 func (ts *SimpleTokenService) GetAccessToken(token string) (*TokenMetadata, error) {
 	ctx := context.Background()
 
+	if jwtAccessTokens != nil && jwt.LooksLikeJWT(token) {
+		return ts.getJWTAccessToken(token)
+	}
+
 	// Direct key lookup - no KEYS command needed
 	tokenKey := fmt.Sprintf("access:token:%s", token)
 	data, err := ts.redis.Get(ctx, tokenKey).Result()
@@ -130,102 +139,65 @@ func (ts *SimpleTokenService) RevokeToken(token string, tokenType string) error
 	return ts.redis.Del(ctx, tokenKey).Err()
 }
 
-// RevokeAllUserTokens removes all tokens for a user
+// RevokeAllUserTokens revokes every session of a user
 func (ts *SimpleTokenService) RevokeAllUserTokens(userID int64) error {
 	ctx := context.Background()
 
-	// Get user's tokens from user mapping
-	accessUserKey := fmt.Sprintf("access:user:%d", userID)
-	refreshUserKey := fmt.Sprintf("refresh:user:%d", userID)
-
-	// Get access token
-	if accessToken, err := ts.redis.Get(ctx, accessUserKey).Result(); err == nil {
-		accessTokenKey := fmt.Sprintf("access:token:%s", accessToken)
-		ts.redis.Del(ctx, accessTokenKey)
+	familyIDs, err := ts.redis.SMembers(ctx, userFamiliesKey(userID)).Result()
+	if err != nil {
+		return err
 	}
-
-	// Get refresh token
-	if refreshToken, err := ts.redis.Get(ctx, refreshUserKey).Result(); err == nil {
-		refreshTokenKey := fmt.Sprintf("refresh:token:%s", refreshToken)
-		ts.redis.Del(ctx, refreshTokenKey)
+	for _, familyID := range familyIDs {
+		if err := ts.RevokeFamily(familyID); err != nil {
+			return err
+		}
 	}
 
-	// Delete user mappings
-	ts.redis.Del(ctx, accessUserKey)
-	ts.redis.Del(ctx, refreshUserKey)
+	// Tokens issued before sessions were indexed kept a single user -> token mapping
+	for _, tokenType := range []string{"access", "refresh"} {
+		userKey := fmt.Sprintf("%s:user:%d", tokenType, userID)
+		if legacyToken, err := ts.redis.Get(ctx, userKey).Result(); err == nil {
+			ts.redis.Del(ctx, fmt.Sprintf("%s:token:%s", tokenType, legacyToken))
+		}
+		ts.redis.Del(ctx, userKey)
+	}
 
-	return nil
+	return ts.redis.Del(ctx, userFamiliesKey(userID)).Err()
 }
 
-// GetUserTokens retrieves tokens for a user (for frontend token check)
+// GetUserTokens retrieves tokens for a user grouped by refresh family (for frontend token check)
 func (ts *SimpleTokenService) GetUserTokens(userID int64) (*UserTokensResponse, error) {
 	ctx := context.Background()
 
-	var accessTokens []TokenInfo
-	var refreshTokens []TokenInfo
-
-	// Check access token via user mapping
-	accessUserKey := fmt.Sprintf("access:user:%d", userID)
-	if accessToken, err := ts.redis.Get(ctx, accessUserKey).Result(); err == nil {
-		accessTokenKey := fmt.Sprintf("access:token:%s", accessToken)
-		accessData, err := ts.redis.Get(ctx, accessTokenKey).Result()
-		if err == nil {
-			var metadata TokenMetadata
-			if err := json.Unmarshal([]byte(accessData), &metadata); err == nil {
-				// Check if token is still valid
-				if time.Now().Unix() < metadata.ExpiresAt {
-					accessTokens = append(accessTokens, TokenInfo{
-						Type:      "access",
-						ExpiresAt: metadata.ExpiresAt,
-						UserAgent: metadata.UserAgent,
-						IP:        metadata.IP,
-					})
-				}
-			}
-		}
+	families, err := ts.getUserFamilies(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check refresh token via user mapping
-	refreshUserKey := fmt.Sprintf("refresh:user:%d", userID)
-	if refreshToken, err := ts.redis.Get(ctx, refreshUserKey).Result(); err == nil {
-		refreshTokenKey := fmt.Sprintf("refresh:token:%s", refreshToken)
-		refreshData, err := ts.redis.Get(ctx, refreshTokenKey).Result()
-		if err == nil {
-			var metadata RefreshTokenMetadata
-			if err := json.Unmarshal([]byte(refreshData), &metadata); err == nil {
-				// Get TTL for refresh token
-				ttl, err := ts.redis.TTL(ctx, refreshTokenKey).Result()
-				if err == nil && ttl > 0 {
-					refreshTokens = append(refreshTokens, TokenInfo{
-						Type:     "refresh",
-						TTL:      int64(ttl.Seconds()),
-						FamilyID: metadata.FamilyID,
-					})
-				}
-			}
-		}
+	var accessTokens []TokenInfo
+	var refreshTokens []TokenInfo
+	for _, family := range families {
+		accessTokens = append(accessTokens, family.AccessTokens...)
+		refreshTokens = append(refreshTokens, family.RefreshTokens...)
 	}
 
 	return &UserTokensResponse{
 		UserID:        userID,
 		AccessTokens:  accessTokens,
 		RefreshTokens: refreshTokens,
+		Families:      families,
 		HasValidToken: len(accessTokens) > 0 || len(refreshTokens) > 0,
 	}, nil
 }
 
-// GetUserSessionCount returns the number of active sessions for a user (always 0 or 1)
+// GetUserSessionCount returns the number of active sessions for a user
 func (ts *SimpleTokenService) GetUserSessionCount(userID int64) (int, error) {
-	tokensResponse, err := ts.GetUserTokens(userID)
+	sessions, err := ts.GetUserSessions(userID)
 	if err != nil {
 		return 0, err
 	}
 
-	if len(tokensResponse.AccessTokens) > 0 {
-		return 1, nil
-	}
-
-	return 0, nil
+	return len(sessions), nil
 }
 
 // CleanupExpiredTokens removes expired tokens from Redis
@@ -2,15 +2,23 @@ package token
 
 type TokenMetadata struct {
 	UserID    int64    `json:"user_id"`
+	CompanyID int64    `json:"company_id,omitempty"`
 	UserAgent string   `json:"user_agent"`
 	IP        string   `json:"ip"`
 	Abilities []string `json:"abilities"`
 	ExpiresAt int64    `json:"expires_at"`
+	FamilyID  string   `json:"family_id,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
 }
 
 type RefreshTokenMetadata struct {
-	UserID   int64  `json:"user_id"`
-	FamilyID string `json:"family_id"`
+	UserID    int64  `json:"user_id"`
+	FamilyID  string `json:"family_id"`
+	UserAgent string `json:"user_agent,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
 }
 
 type TokenInfo struct {
@@ -22,9 +30,61 @@ type TokenInfo struct {
 	FamilyID  string `json:"family_id,omitempty"`
 }
 
-type UserTokensResponse struct {
-	UserID        int64       `json:"user_id"`
+// SessionInfo describes one login session (a refresh token family) of a user
+type SessionInfo struct {
+	SessionID string `json:"session_id"`
+	UserAgent string `json:"user_agent,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+	LastSeen  int64  `json:"last_seen"`
+	ExpiresAt int64  `json:"expires_at"`
+	Current   bool   `json:"current"`
+}
+
+// TokenFamilyInfo groups the tokens that descend from a single login
+type TokenFamilyInfo struct {
+	FamilyID      string      `json:"family_id"`
+	UserAgent     string      `json:"user_agent,omitempty"`
+	IP            string      `json:"ip,omitempty"`
+	CreatedAt     int64       `json:"created_at,omitempty"`
+	LastSeen      int64       `json:"last_seen,omitempty"`
 	AccessTokens  []TokenInfo `json:"access_tokens"`
 	RefreshTokens []TokenInfo `json:"refresh_tokens"`
-	HasValidToken bool        `json:"has_valid_token"`
+}
+
+type UserTokensResponse struct {
+	UserID        int64             `json:"user_id"`
+	AccessTokens  []TokenInfo       `json:"access_tokens"`
+	RefreshTokens []TokenInfo       `json:"refresh_tokens"`
+	Families      []TokenFamilyInfo `json:"families"`
+	HasValidToken bool              `json:"has_valid_token"`
+}
+
+// LoginChallenge is the pending state of a login waiting for a second factor
+// or, with PasswordChangeRequired, for the replacement of an expired password
+type LoginChallenge struct {
+	UserID                 int64  `json:"user_id"`
+	UserAgent              string `json:"user_agent"`
+	IP                     string `json:"ip"`
+	SetupRequired          bool   `json:"setup_required"`
+	PasswordChangeRequired bool   `json:"password_change_required,omitempty"`
+}
+
+// PasswordReset is the pending state behind an emailed password reset token
+type PasswordReset struct {
+	UserID      int64  `json:"user_id"`
+	RequestedIP string `json:"requested_ip,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// AuthorizationCode is the pending grant behind an OAuth2 authorization code
+type AuthorizationCode struct {
+	UserID              int64  `json:"user_id"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	Nonce               string `json:"nonce,omitempty"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	AuthTime            int64  `json:"auth_time"`
 }
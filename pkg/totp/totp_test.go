@@ -0,0 +1,58 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 test key from RFC 6238 appendix B
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerateCodeMatchesRFC6238Vectors(t *testing.T) {
+	// Appendix B lists 8 digit codes; the 6 digit code is the last six digits
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+
+	for unix, expected := range vectors {
+		code, err := GenerateCode(rfc6238Secret, Step(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("GenerateCode(%d) returned error: %v", unix, err)
+		}
+		if code != expected[2:] {
+			t.Errorf("GenerateCode(%d) = %s, want %s", unix, code, expected[2:])
+		}
+	}
+}
+
+func TestValidateAcceptsAdjacentSteps(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	previous, _ := GenerateCode(rfc6238Secret, Step(now)-1)
+	if step, ok := Validate(rfc6238Secret, previous, now); !ok || step != Step(now)-1 {
+		t.Errorf("expected code of previous step to be accepted")
+	}
+
+	stale, _ := GenerateCode(rfc6238Secret, Step(now)-2)
+	if _, ok := Validate(rfc6238Secret, stale, now); ok {
+		t.Errorf("expected code two steps old to be rejected")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("RBAC Service", "john@example.com", "JBSWY3DPEHPK3PXP")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/RBAC%20Service:john@example.com?") {
+		t.Errorf("unexpected label in %s", uri)
+	}
+	if !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") || !strings.Contains(uri, "issuer=RBAC+Service") {
+		t.Errorf("missing secret or issuer in %s", uri)
+	}
+}